@@ -16,6 +16,7 @@ import (
 	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/generate"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/private"
 	"code.gitea.io/gitea/modules/setting"
 
 	"github.com/urfave/cli"
@@ -32,6 +33,8 @@ var (
 			subcmdRepoSyncReleases,
 			subcmdRegenerate,
 			subcmdAuth,
+			subcmdMigrateStorage,
+			subcmdReindex,
 		},
 	}
 
@@ -141,6 +144,41 @@ var (
 		},
 	}
 
+	subcmdReindex = cli.Command{
+		Name:  "reindex",
+		Usage: "Empty an indexer and populate it again",
+		Subcommands: []cli.Command{
+			microcmdReindexIssues,
+			microcmdReindexCode,
+		},
+	}
+
+	microcmdReindexIssues = cli.Command{
+		Name:   "issues",
+		Usage:  "Reindex all the issues and pull requests",
+		Action: runReindex("issues"),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "config, c",
+				Value: "custom/conf/app.ini",
+				Usage: "Custom configuration file path",
+			},
+		},
+	}
+
+	microcmdReindexCode = cli.Command{
+		Name:   "code",
+		Usage:  "Reindex the contents of all the repositories",
+		Action: runReindex("code"),
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "config, c",
+				Value: "custom/conf/app.ini",
+				Usage: "Custom configuration file path",
+			},
+		},
+	}
+
 	subcmdAuth = cli.Command{
 		Name:  "auth",
 		Usage: "Modify external auth providers",
@@ -432,6 +470,23 @@ func runRegenerateKeys(c *cli.Context) error {
 	return models.RewriteAllPublicKeys()
 }
 
+// runReindex asks the running server to reindex, as the indexers can only be
+// opened by a single process
+func runReindex(indexerType string) func(c *cli.Context) error {
+	return func(c *cli.Context) error {
+		if c.IsSet("config") {
+			setting.CustomConf = c.String("config")
+		}
+		setting.NewContext()
+
+		if err := private.Reindex(indexerType); err != nil {
+			return err
+		}
+		fmt.Printf("Reindexing %s in the background, see the server log for progress\n", indexerType)
+		return nil
+	}
+}
+
 func parseOAuth2Config(c *cli.Context) *models.OAuth2Config {
 	var customURLMapping *oauth2.CustomURLMapping
 	if c.IsSet("use-custom-urls") {
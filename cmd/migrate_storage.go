@@ -0,0 +1,138 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/urfave/cli"
+)
+
+var subcmdMigrateStorage = cli.Command{
+	Name:  "migrate-storage",
+	Usage: "Copy the stored objects of one kind to another storage",
+	Description: "Copies all attachments, avatars, uploads or LFS objects from the storage which is currently " +
+		"configured to the storage given by the flags. Run it before changing the storage in the configuration.",
+	Action: runMigrateStorage,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "config, c",
+			Value: "custom/conf/app.ini",
+			Usage: "Custom configuration file path",
+		},
+		cli.StringFlag{
+			Name:  "type, t",
+			Usage: "Kind of objects to migrate: attachments, avatars, uploads or lfs",
+		},
+		cli.StringFlag{
+			Name:  "storage, s",
+			Value: setting.MinioStorageType,
+			Usage: "Type of the target storage: local or minio",
+		},
+		cli.StringFlag{
+			Name:  "path, p",
+			Usage: "Target directory of a local storage",
+		},
+		cli.StringFlag{
+			Name:  "minio-endpoint",
+			Value: "localhost:9000",
+			Usage: "Endpoint of the minio storage",
+		},
+		cli.StringFlag{
+			Name:  "minio-access-key-id",
+			Usage: "Access key ID of the minio storage",
+		},
+		cli.StringFlag{
+			Name:  "minio-secret-access-key",
+			Usage: "Secret access key of the minio storage",
+		},
+		cli.StringFlag{
+			Name:  "minio-bucket",
+			Value: "gitea",
+			Usage: "Bucket of the minio storage",
+		},
+		cli.StringFlag{
+			Name:  "minio-location",
+			Value: "us-east-1",
+			Usage: "Location of the minio bucket",
+		},
+		cli.StringFlag{
+			Name:  "minio-base-path",
+			Usage: "Base path of the objects in the minio bucket, defaults to the type followed by a slash",
+		},
+		cli.BoolFlag{
+			Name:  "minio-use-ssl",
+			Usage: "Connect to the minio storage with SSL",
+		},
+	},
+}
+
+func runMigrateStorage(c *cli.Context) error {
+	if c.IsSet("config") {
+		setting.CustomConf = c.String("config")
+	}
+	if err := initDB(); err != nil {
+		return err
+	}
+	if err := storage.Init(); err != nil {
+		return err
+	}
+
+	var src storage.ObjectStorage
+	switch c.String("type") {
+	case "attachments":
+		src = storage.Attachments
+	case "avatars":
+		src = storage.Avatars
+	case "uploads":
+		src = storage.Uploads
+	case "lfs":
+		src = storage.LFS
+	default:
+		return fmt.Errorf("--type must be one of attachments, avatars, uploads or lfs")
+	}
+
+	cfg := setting.Storage{
+		Type: c.String("storage"),
+		Path: c.String("path"),
+		Minio: setting.MinioStorageConfig{
+			Endpoint:        c.String("minio-endpoint"),
+			AccessKeyID:     c.String("minio-access-key-id"),
+			SecretAccessKey: c.String("minio-secret-access-key"),
+			Bucket:          c.String("minio-bucket"),
+			Location:        c.String("minio-location"),
+			BasePath:        c.String("minio-base-path"),
+			UseSSL:          c.Bool("minio-use-ssl"),
+		},
+	}
+	if cfg.Type == setting.LocalStorageType && len(cfg.Path) == 0 {
+		return fmt.Errorf("--path is required for a local storage")
+	}
+	if !c.IsSet("minio-base-path") {
+		cfg.Minio.BasePath = c.String("type") + "/"
+	}
+
+	dst, err := storage.NewStorage(cfg)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err = src.IterateObjects(func(p string, obj storage.Object) error {
+		if _, err := dst.Save(p, obj); err != nil {
+			return fmt.Errorf("copy %s: %v", p, err)
+		}
+		count++
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Printf("Migrated %d %s objects\n", count, c.String("type"))
+	return nil
+}
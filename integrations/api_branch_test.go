@@ -43,3 +43,77 @@ func TestAPIGetBranch(t *testing.T) {
 		testAPIGetBranch(t, test.BranchName, test.Exists)
 	}
 }
+
+func TestAPIBranchProtection(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/user2/repo1/branch_protections"
+
+	req := NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName:             "master",
+		EnablePushWhitelist:    true,
+		PushWhitelistUsernames: []string{"user2"},
+		RequiredApprovals:      1,
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var bp api.BranchProtection
+	DecodeJSON(t, resp, &bp)
+	assert.EqualValues(t, "master", bp.BranchName)
+	assert.True(t, bp.EnablePushWhitelist)
+	assert.EqualValues(t, []string{"user2"}, bp.PushWhitelistUsernames)
+	assert.EqualValues(t, 1, bp.RequiredApprovals)
+
+	// protecting a branch twice is forbidden
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName: "master",
+	})
+	session.MakeRequest(t, req, http.StatusForbidden)
+
+	// unknown branches and users are rejected
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName: "doesnotexist",
+	})
+	session.MakeRequest(t, req, http.StatusNotFound)
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateBranchProtectionOption{
+		BranchName:             "feature/1",
+		PushWhitelistUsernames: []string{"doesnotexist"},
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequest(t, "GET", urlStr+"?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var bps []*api.BranchProtection
+	DecodeJSON(t, resp, &bps)
+	assert.Len(t, bps, 1)
+	assert.EqualValues(t, "master", bps[0].BranchName)
+
+	disabled := false
+	enabled := true
+	approvals := int64(0)
+	req = NewRequestWithJSON(t, "PATCH", urlStr+"/master?token="+token, &api.EditBranchProtectionOption{
+		EnablePushWhitelist: &disabled,
+		RequiredApprovals:   &approvals,
+		EnableStatusCheck:   &enabled,
+		StatusCheckContexts: []string{"ci/build", " ci/build ", "", "ci/test"},
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &bp)
+	assert.False(t, bp.EnablePushWhitelist)
+	assert.EqualValues(t, []string{"user2"}, bp.PushWhitelistUsernames)
+	assert.EqualValues(t, 0, bp.RequiredApprovals)
+	assert.True(t, bp.EnableStatusCheck)
+	assert.EqualValues(t, []string{"ci/build", "ci/test"}, bp.StatusCheckContexts)
+
+	req = NewRequest(t, "GET", urlStr+"/master?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &bp)
+	assert.False(t, bp.EnablePushWhitelist)
+
+	req = NewRequest(t, "DELETE", urlStr+"/master?token="+token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+
+	req = NewRequest(t, "GET", urlStr+"/master?token="+token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoHookDeliveries(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	hookURL := "/api/v1/repos/user2/repo1/hooks/1"
+
+	req := NewRequest(t, "GET", hookURL+"/deliveries?token="+token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Equal(t, "1", resp.Header().Get("X-Total-Count"))
+	var deliveries []*api.HookDelivery
+	DecodeJSON(t, resp, &deliveries)
+	if assert.Len(t, deliveries, 1) {
+		assert.EqualValues(t, 1, deliveries[0].ID)
+		assert.Equal(t, "uuid1", deliveries[0].UUID)
+	}
+
+	req = NewRequest(t, "POST", hookURL+"/deliveries/1/redeliver?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	var delivery api.HookDelivery
+	DecodeJSON(t, resp, &delivery)
+	assert.NotEqual(t, "uuid1", delivery.UUID)
+	models.AssertExistsAndLoadBean(t, &models.HookTask{ID: delivery.ID, HookID: 1, RepoID: 1})
+
+	req = NewRequest(t, "POST", fmt.Sprintf("%s/deliveries/%d/redeliver?token=%s", hookURL, models.NonexistentID, token))
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	// the deliveries of another hook can not be redelivered
+	req = NewRequest(t, "POST", "/api/v1/repos/user2/repo1/hooks/2/deliveries/1/redeliver?token="+token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
@@ -63,3 +63,40 @@ func TestAPIReplaceIssueLabels(t *testing.T) {
 	models.AssertCount(t, &models.IssueLabel{IssueID: issue.ID}, 1)
 	models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: issue.ID, LabelID: label.ID})
 }
+
+func TestAPIAddExclusiveIssueLabels(t *testing.T) {
+	assert.NoError(t, models.LoadFixtures())
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID}).(*models.Issue)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session)
+	labelIDs := make([]int64, 0, 2)
+	for _, name := range []string{"status/todo", "status/done"} {
+		req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/%s/labels?token=%s", owner.Name, repo.Name, token), &api.CreateLabelOption{
+			Name:      name,
+			Color:     "#abcdef",
+			Exclusive: true,
+			Priority:  len(labelIDs),
+		})
+		resp := session.MakeRequest(t, req, http.StatusCreated)
+		var apiLabel api.Label
+		DecodeJSON(t, resp, &apiLabel)
+		assert.True(t, apiLabel.Exclusive)
+		assert.EqualValues(t, len(labelIDs), apiLabel.Priority)
+		labelIDs = append(labelIDs, apiLabel.ID)
+	}
+
+	urlStr := fmt.Sprintf("/api/v1/repos/%s/%s/issues/%d/labels?token=%s",
+		owner.Name, repo.Name, issue.Index, token)
+	for _, labelID := range labelIDs {
+		req := NewRequestWithJSON(t, "POST", urlStr, &api.IssueLabelsOption{
+			Labels: []int64{labelID},
+		})
+		session.MakeRequest(t, req, http.StatusOK)
+	}
+	models.AssertNotExistsBean(t, &models.IssueLabel{IssueID: issue.ID, LabelID: labelIDs[0]})
+	models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: issue.ID, LabelID: labelIDs[1]})
+}
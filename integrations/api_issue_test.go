@@ -34,6 +34,43 @@ func TestAPIListIssues(t *testing.T) {
 	}
 }
 
+func TestAPISearchIssues(t *testing.T) {
+	prepareTestEnv(t)
+
+	searchIssues := func(session *TestSession, query string, expectedStatus int) []*api.Issue {
+		req := NewRequestf(t, "GET", "/api/v1/repos/issues/search?%s", query)
+		resp := session.MakeRequest(t, req, expectedStatus)
+		var apiIssues []*api.Issue
+		if expectedStatus == http.StatusOK {
+			DecodeJSON(t, resp, &apiIssues)
+			assert.Equal(t, fmt.Sprintf("%d", len(apiIssues)), resp.Header().Get("X-Total-Count"))
+		}
+		return apiIssues
+	}
+
+	// issue 4 of the private repository of user2 is not visible to guests
+	session := emptyTestSession(t)
+	apiIssues := searchIssues(session, "state=all&q=author:user2", http.StatusOK)
+	if assert.Len(t, apiIssues, 1) {
+		assert.EqualValues(t, 5, apiIssues[0].ID)
+		assert.Equal(t, "user2/repo1", apiIssues[0].Repository.FullName)
+	}
+
+	session = loginUser(t, "user2")
+	apiIssues = searchIssues(session, "q=is:pr+repo:user2/repo1", http.StatusOK)
+	assert.Len(t, apiIssues, 2)
+	for _, apiIssue := range apiIssues {
+		assert.NotNil(t, apiIssue.PullRequest)
+	}
+
+	apiIssues = searchIssues(session, "type=pulls&q=is:issue+label:label1", http.StatusOK)
+	if assert.Len(t, apiIssues, 1) {
+		assert.EqualValues(t, 1, apiIssues[0].ID)
+	}
+
+	searchIssues(session, "q=is:draft", http.StatusUnprocessableEntity)
+}
+
 func TestAPICreateIssue(t *testing.T) {
 	prepareTestEnv(t)
 	const body, title = "apiTestBody", "apiTestTitle"
@@ -0,0 +1,92 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPINotification(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequest(t, "GET", "/api/v1/notifications")
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	// unread and pinned notifications are listed by default
+	req = NewRequest(t, "GET", "/api/v1/notifications?token="+token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var threads []*api.NotificationThread
+	DecodeJSON(t, resp, &threads)
+	if assert.Len(t, threads, 2) {
+		assert.EqualValues(t, 4, threads[0].ID)
+		assert.True(t, threads[0].Unread)
+		assert.EqualValues(t, 3, threads[1].ID)
+		assert.True(t, threads[1].Pinned)
+		assert.EqualValues(t, "issue2", threads[0].Subject.Title)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?all=true&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 3)
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?pinned=true&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 1)
+
+	req = NewRequest(t, "GET", "/api/v1/notifications?since=2000-01-01T00:00:01Z&token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 0)
+	req = NewRequest(t, "GET", "/api/v1/notifications?since=yesterday&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/notifications?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 2)
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo2/notifications?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &threads)
+	assert.Len(t, threads, 0)
+
+	// single threads
+	req = NewRequest(t, "GET", "/api/v1/notifications/threads/4?token="+token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var thread api.NotificationThread
+	DecodeJSON(t, resp, &thread)
+	assert.EqualValues(t, 4, thread.ID)
+	assert.EqualValues(t, "user2/repo1", thread.Repository.FullName)
+
+	req = NewRequest(t, "GET", "/api/v1/notifications/threads/1?token="+token)
+	session.MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequest(t, "GET", "/api/v1/notifications/threads/1000?token="+token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequest(t, "PATCH", "/api/v1/notifications/threads/2?to-status=pinned&token="+token)
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 2, Status: models.NotificationStatusPinned})
+	req = NewRequest(t, "PATCH", "/api/v1/notifications/threads/2?to-status=archived&token="+token)
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequest(t, "PATCH", "/api/v1/notifications/threads/1?token="+token)
+	session.MakeRequest(t, req, http.StatusForbidden)
+
+	// mark all as read
+	req = NewRequest(t, "PUT", "/api/v1/notifications?token="+token)
+	session.MakeRequest(t, req, http.StatusResetContent)
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 4, Status: models.NotificationStatusRead})
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 2, Status: models.NotificationStatusPinned})
+	models.AssertExistsAndLoadBean(t, &models.Notification{ID: 1, Status: models.NotificationStatusUnread})
+}
@@ -0,0 +1,101 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIOrgLabels(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/orgs/user3/labels?token="+token, &api.CreateLabelOption{
+		Name:  "triage",
+		Color: "#abcdef",
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var apiLabel api.Label
+	DecodeJSON(t, resp, &apiLabel)
+	label := models.AssertExistsAndLoadBean(t, &models.Label{ID: apiLabel.ID, OrgID: 3}).(*models.Label)
+	assert.EqualValues(t, 0, label.RepoID)
+
+	req = NewRequest(t, "GET", "/api/v1/orgs/user3/labels")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiLabels []*api.Label
+	DecodeJSON(t, resp, &apiLabels)
+	assert.Len(t, apiLabels, models.GetCount(t, &models.Label{OrgID: 3}))
+
+	newName := "needs-triage"
+	req = NewRequestWithJSON(t, "PATCH", fmt.Sprintf("/api/v1/orgs/user3/labels/%d?token=%s", label.ID, token), &api.EditLabelOption{
+		Name: &newName,
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.Label{ID: label.ID, Name: newName})
+
+	req = NewRequestf(t, "GET", "/api/v1/orgs/user3/labels/%s", newName)
+	MakeRequest(t, req, http.StatusOK)
+
+	// only the owners can manage the labels of the organization
+	session4 := loginUser(t, "user4")
+	token4 := getTokenForLoggedInUser(t, session4)
+	req = NewRequestf(t, "DELETE", "/api/v1/orgs/user3/labels/%d?token=%s", label.ID, token4)
+	session4.MakeRequest(t, req, http.StatusForbidden)
+
+	// the label can be used in the repositories of the organization only
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user3/repo3/issues/1/labels?token="+token, &api.IssueLabelsOption{
+		Labels: []int64{label.ID},
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiLabels)
+	assert.Len(t, apiLabels, 1)
+	models.AssertExistsAndLoadBean(t, &models.IssueLabel{IssueID: 6, LabelID: label.ID})
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues/1/labels?token="+token, &api.IssueLabelsOption{
+		Labels: []int64{label.ID},
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertNotExistsBean(t, &models.IssueLabel{IssueID: 1, LabelID: label.ID})
+
+	req = NewRequestf(t, "DELETE", "/api/v1/orgs/user3/labels/%d?token=%s", label.ID, token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Label{ID: label.ID})
+	models.AssertNotExistsBean(t, &models.IssueLabel{LabelID: label.ID})
+}
+
+func TestOrgLabelsSettings(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/org/user3/settings/labels")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "orglabel3")
+
+	req = NewRequestWithValues(t, "POST", "/org/user3/settings/labels/new", map[string]string{
+		"_csrf":       GetCSRF(t, session, "/org/user3/settings/labels"),
+		"title":       "kind/bug",
+		"description": "Something is not working",
+		"color":       "#ee0701",
+		"exclusive":   "on",
+		"priority":    "5",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	models.AssertExistsAndLoadBean(t, &models.Label{OrgID: 3, Name: "kind/bug", Exclusive: true, Priority: 5})
+
+	// the labels of the organization are listed in its repositories
+	req = NewRequest(t, "GET", "/user3/repo3/labels")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "kind/bug")
+	assert.Contains(t, resp.Body.String(), "/org/user3/settings/labels")
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIPullReview(t *testing.T) {
+	prepareTestEnv(t)
+	pullIssue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 3}).(*models.Issue)
+	assert.NoError(t, pullIssue.LoadAttributes())
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: pullIssue.RepoID}).(*models.Repository)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/reviews", owner.Name, repo.Name, pullIssue.Index)
+
+	// pending reviews of other users are hidden
+	req := NewRequestf(t, "GET", "%s?token=%s", urlStr, token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var reviews []*api.PullReview
+	DecodeJSON(t, resp, &reviews)
+	assert.Len(t, reviews, 5)
+	for _, review := range reviews {
+		if review.State == api.ReviewStatePending {
+			assert.EqualValues(t, owner.ID, review.Reviewer.ID)
+		}
+	}
+
+	anonReq := NewRequest(t, "GET", urlStr)
+	resp = MakeRequest(t, anonReq, http.StatusOK)
+	DecodeJSON(t, resp, &reviews)
+	assert.Len(t, reviews, 4)
+
+	req = NewRequestf(t, "GET", "%s/6", urlStr)
+	MakeRequest(t, req, http.StatusNotFound)
+
+	// submit the existing pending review of the owner
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/6?token=%s", urlStr, token), &api.SubmitPullReviewOptions{
+		Event: api.ReviewStateComment,
+		Body:  "just a comment",
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var review api.PullReview
+	DecodeJSON(t, resp, &review)
+	assert.EqualValues(t, 6, review.ID)
+	assert.EqualValues(t, api.ReviewStateComment, review.State)
+	assert.EqualValues(t, "just a comment", review.Body)
+	models.AssertExistsAndLoadBean(t, &models.Review{ID: 6, Type: models.ReviewTypeComment})
+
+	// an already submitted review can not be submitted again
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/6?token=%s", urlStr, token), &api.SubmitPullReviewOptions{
+		Event: api.ReviewStateApproved,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// create a new approval
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s?token=%s", urlStr, token), &api.CreatePullReviewOptions{
+		Event: api.ReviewStateApproved,
+		Body:  "LGTM",
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &review)
+	assert.EqualValues(t, api.ReviewStateApproved, review.State)
+	assert.EqualValues(t, owner.ID, review.Reviewer.ID)
+
+	// a review requesting changes needs some content
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s?token=%s", urlStr, token), &api.CreatePullReviewOptions{
+		Event: api.ReviewStateRequestChanges,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// dismiss the approval of user4
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("%s/8/dismissals?token=%s", urlStr, token), &api.DismissPullReviewOptions{
+		Message: "outdated",
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &review)
+	assert.True(t, review.Dismissed)
+	models.AssertExistsIf(t, true, &models.Review{ID: 8}, models.Cond("dismissed = ?", true))
+}
+
+func TestAPIPullReviewOwnPull(t *testing.T) {
+	prepareTestEnv(t)
+	pullIssue := models.AssertExistsAndLoadBean(t, &models.Issue{ID: 3}).(*models.Issue)
+	assert.NoError(t, pullIssue.LoadAttributes())
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: pullIssue.RepoID}).(*models.Repository)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+
+	session := loginUser(t, pullIssue.Poster.Name)
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/reviews?token=%s", owner.Name, repo.Name, pullIssue.Index, token), &api.CreatePullReviewOptions{
+		Event: api.ReviewStateApproved,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"testing"
 
+	"code.gitea.io/git"
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/setting"
@@ -56,3 +57,78 @@ func TestAPIMergePullWIP(t *testing.T) {
 
 	session.MakeRequest(t, req, http.StatusMethodNotAllowed)
 }
+
+func TestAPIScheduleMergePull(t *testing.T) {
+	prepareTestEnv(t)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{Status: models.PullRequestStatusMergeable}, models.Cond("has_merged = ?", false)).(*models.PullRequest)
+
+	// the head of the pull request has a pending status
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	assert.NoError(t, err)
+	sha, err := gitRepo.GetBranchCommitID(repo.DefaultBranch)
+	assert.NoError(t, err)
+	_, err = git.NewCommand("update-ref", pr.GetGitRefName(), sha).RunInDir(repo.RepoPath())
+	assert.NoError(t, err)
+	assert.NoError(t, models.NewCommitStatus(repo, owner, sha, &models.CommitStatus{State: models.CommitStatusPending, Context: "ci"}))
+
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/merge?token=%s", owner.Name, repo.Name, pr.Index, token)
+	req := NewRequestWithJSON(t, http.MethodPost, urlStr, &auth.MergePullRequestForm{
+		Do:                     string(models.MergeStyleMerge),
+		MergeWhenChecksSucceed: true,
+	})
+	session.MakeRequest(t, req, http.StatusAccepted)
+	models.AssertExistsAndLoadBean(t, &models.PullAutoMerge{PullID: pr.ID, MergeStyle: models.MergeStyleMerge})
+
+	req = NewRequestWithJSON(t, http.MethodPost, urlStr, &auth.MergePullRequestForm{
+		Do:                     string(models.MergeStyleMerge),
+		MergeWhenChecksSucceed: true,
+	})
+	session.MakeRequest(t, req, http.StatusConflict)
+
+	req = NewRequestf(t, "GET", "/%s/%s/pulls/%d", owner.Name, repo.Name, pr.Index)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	htmlDoc.AssertElement(t, fmt.Sprintf("form[action=\"/%s/%s/pulls/%d/cancel_auto_merge\"]", owner.Name, repo.Name, pr.Index), true)
+
+	req = NewRequest(t, http.MethodDelete, urlStr)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.PullAutoMerge{PullID: pr.ID})
+	req = NewRequest(t, http.MethodDelete, urlStr)
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPIMergePullRequiredStatusChecks(t *testing.T) {
+	prepareTestEnv(t)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: repo.OwnerID}).(*models.User)
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{Status: models.PullRequestStatusMergeable}, models.Cond("has_merged = ?", false)).(*models.PullRequest)
+
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	assert.NoError(t, err)
+	sha, err := gitRepo.GetBranchCommitID(repo.DefaultBranch)
+	assert.NoError(t, err)
+	_, err = git.NewCommand("update-ref", pr.GetGitRefName(), sha).RunInDir(repo.RepoPath())
+	assert.NoError(t, err)
+	assert.NoError(t, models.UpdateProtectBranch(repo, &models.ProtectedBranch{
+		RepoID:              repo.ID,
+		BranchName:          pr.BaseBranch,
+		EnableStatusCheck:   true,
+		StatusCheckContexts: []string{"ci/build", "ci/test"},
+	}, models.WhitelistOptions{}))
+	assert.NoError(t, models.NewCommitStatus(repo, owner, sha, &models.CommitStatus{State: models.CommitStatusSuccess, Context: "ci/build"}))
+	assert.NoError(t, models.NewCommitStatus(repo, owner, sha, &models.CommitStatus{State: models.CommitStatusFailure, Context: "ci/test"}))
+
+	session := loginUser(t, owner.Name)
+	token := getTokenForLoggedInUser(t, session)
+	req := NewRequestWithJSON(t, http.MethodPost, fmt.Sprintf("/api/v1/repos/%s/%s/pulls/%d/merge?token=%s", owner.Name, repo.Name, pr.Index, token), &auth.MergePullRequestForm{
+		Do: string(models.MergeStyleMerge),
+	})
+	resp := session.MakeRequest(t, req, http.StatusMethodNotAllowed)
+	assert.Contains(t, resp.Body.String(), "ci/test")
+	assert.NotContains(t, resp.Body.String(), "ci/build")
+	models.AssertExistsAndLoadBean(t, &models.PullRequest{ID: pr.ID, HasMerged: false})
+}
@@ -0,0 +1,158 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIGetContents(t *testing.T) {
+	prepareTestEnv(t)
+
+	req := NewRequest(t, "GET", "/api/v1/repos/user2/repo1/contents/README.md")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var contents api.ContentsResponse
+	DecodeJSON(t, resp, &contents)
+	assert.EqualValues(t, "README.md", contents.Name)
+	assert.EqualValues(t, "README.md", contents.Path)
+	assert.EqualValues(t, "file", contents.Type)
+	assert.EqualValues(t, "4b4851ad51df6a7d9f25c979345979eaeb5b349f", contents.SHA)
+	assert.EqualValues(t, "base64", contents.Encoding)
+	content, err := base64.StdEncoding.DecodeString(contents.Content)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "# repo1\n\nDescription for repo1", string(content))
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/contents")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var list []*api.ContentsResponse
+	DecodeJSON(t, resp, &list)
+	if assert.Len(t, list, 1) {
+		assert.EqualValues(t, "README.md", list[0].Name)
+		assert.Empty(t, list[0].Content)
+	}
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/contents/README.md?ref=doesnotexist")
+	MakeRequest(t, req, http.StatusNotFound)
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/contents/doesnotexist")
+	MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestAPIUpdateFile(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/user2/repo1/contents/"
+
+	// anonymous users and users without write access may not commit
+	req := NewRequestWithJSON(t, "PUT", urlStr+"new/file.txt", &api.UpdateFileOptions{})
+	MakeRequest(t, req, http.StatusUnauthorized)
+	session4 := loginUser(t, "user4")
+	token4 := getTokenForLoggedInUser(t, session4)
+	req = NewRequestWithJSON(t, "PUT", urlStr+"new/file.txt?token="+token4, &api.UpdateFileOptions{})
+	session4.MakeRequest(t, req, http.StatusForbidden)
+
+	// create a new file in a new directory
+	req = NewRequestWithJSON(t, "PUT", urlStr+"new/file.txt?token="+token, &api.UpdateFileOptions{
+		Message:   "Add a new file",
+		Content:   base64.StdEncoding.EncodeToString([]byte("new content")),
+		Author:    api.Identity{Name: "Jane Doe", Email: "jane@example.com"},
+		Committer: api.Identity{Name: "Bot", Email: "bot@example.com"},
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var fileResp api.FileResponse
+	DecodeJSON(t, resp, &fileResp)
+	assert.EqualValues(t, "new/file.txt", fileResp.Content.Path)
+	assert.EqualValues(t, "Add a new file\n", fileResp.Commit.Message)
+	assert.EqualValues(t, "Jane Doe", fileResp.Commit.Author.Name)
+	assert.EqualValues(t, "jane@example.com", fileResp.Commit.Author.Email)
+	assert.EqualValues(t, "Bot", fileResp.Commit.Committer.Name)
+	assert.EqualValues(t, "bot@example.com", fileResp.Commit.Committer.Email)
+
+	// updating an existing file needs the current sha
+	req = NewRequestWithJSON(t, "PUT", urlStr+"README.md?token="+token, &api.UpdateFileOptions{
+		Content: base64.StdEncoding.EncodeToString([]byte("updated")),
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "PUT", urlStr+"README.md?token="+token, &api.UpdateFileOptions{
+		Content: base64.StdEncoding.EncodeToString([]byte("updated")),
+		SHA:     "0000000000000000000000000000000000000000",
+	})
+	session.MakeRequest(t, req, http.StatusConflict)
+	req = NewRequestWithJSON(t, "PUT", urlStr+"README.md?token="+token, &api.UpdateFileOptions{
+		Content: "not base64!",
+		SHA:     "4b4851ad51df6a7d9f25c979345979eaeb5b349f",
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "PUT", urlStr+"README.md?token="+token, &api.UpdateFileOptions{
+		Content: base64.StdEncoding.EncodeToString([]byte("updated")),
+		SHA:     "4b4851ad51df6a7d9f25c979345979eaeb5b349f",
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &fileResp)
+	assert.EqualValues(t, "Update 'README.md'\n", fileResp.Commit.Message)
+	assert.EqualValues(t, "User Two", fileResp.Commit.Author.Name)
+	newSHA := fileResp.Content.SHA
+	assert.NotEqual(t, "4b4851ad51df6a7d9f25c979345979eaeb5b349f", newSHA)
+
+	req = NewRequest(t, "GET", urlStr+"README.md")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var contents api.ContentsResponse
+	DecodeJSON(t, resp, &contents)
+	assert.EqualValues(t, newSHA, contents.SHA)
+	assert.EqualValues(t, base64.StdEncoding.EncodeToString([]byte("updated")), contents.Content)
+
+	// commit to a new branch
+	req = NewRequestWithJSON(t, "PUT", urlStr+"README.md?token="+token, &api.UpdateFileOptions{
+		Content:       base64.StdEncoding.EncodeToString([]byte("on a branch")),
+		SHA:           newSHA,
+		NewBranchName: "feature/api",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	req = NewRequest(t, "GET", urlStr+"README.md?ref=feature/api")
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &contents)
+	assert.EqualValues(t, base64.StdEncoding.EncodeToString([]byte("on a branch")), contents.Content)
+	req = NewRequestWithJSON(t, "PUT", urlStr+"README.md?token="+token, &api.UpdateFileOptions{
+		SHA:           newSHA,
+		NewBranchName: "feature/api",
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPIDeleteFile(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/user2/repo1/contents/README.md?token=" + token
+
+	req := NewRequestWithJSON(t, "DELETE", urlStr, &api.DeleteFileOptions{
+		SHA: "0000000000000000000000000000000000000000",
+	})
+	session.MakeRequest(t, req, http.StatusConflict)
+
+	req = NewRequestWithJSON(t, "DELETE", urlStr, &api.DeleteFileOptions{
+		SHA: "4b4851ad51df6a7d9f25c979345979eaeb5b349f",
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var fileResp api.FileResponse
+	DecodeJSON(t, resp, &fileResp)
+	assert.Nil(t, fileResp.Content)
+	assert.EqualValues(t, "Delete 'README.md'\n", fileResp.Commit.Message)
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/contents/README.md")
+	MakeRequest(t, req, http.StatusNotFound)
+	req = NewRequestWithJSON(t, "DELETE", urlStr, &api.DeleteFileOptions{
+		SHA: "4b4851ad51df6a7d9f25c979345979eaeb5b349f",
+	})
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
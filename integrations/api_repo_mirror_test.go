@@ -0,0 +1,53 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIMirrorSyncTrigger(t *testing.T) {
+	prepareTestEnv(t)
+
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+	repo, err := models.MigrateRepository(user, user, models.MigrateRepoOptions{
+		Name:       "repo1_mirror",
+		IsMirror:   true,
+		RemoteAddr: models.RepoPath(user.Name, "repo1"),
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.GetMirror())
+
+	payload := `{"ref":"refs/heads/master","after":"65f1bf27bc3bf70f64657658635e66094edbcb4d"}`
+	req := NewRequestWithBody(t, "POST", "/api/v1/mirror-sync/"+repo.Mirror.TriggerToken, strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	MakeRequest(t, req, http.StatusAccepted)
+
+	req = NewRequestWithBody(t, "POST", "/api/v1/mirror-sync/invalid", strings.NewReader(payload))
+	MakeRequest(t, req, http.StatusNotFound)
+
+	session := loginUser(t, user.Name)
+	req = NewRequestf(t, "GET", "/%s/%s/settings", user.Name, repo.Name)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), repo.Mirror.TriggerURL())
+
+	token := getTokenForLoggedInUser(t, session)
+	req = NewRequestf(t, "GET", "/api/v1/repos/%s/%s/mirror-sync?token=%s", user.Name, repo.Name, token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var syncs []*api.MirrorSync
+	DecodeJSON(t, resp, &syncs)
+	assert.True(t, len(syncs) <= 1)
+
+	// only mirrors have a synchronization history
+	req = NewRequestf(t, "GET", "/api/v1/repos/%s/repo1/mirror-sync?token=%s", user.Name, token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
@@ -0,0 +1,176 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoProjectBoards(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/projects?token="+token, &api.CreateProjectBoardOption{
+		Title:   "release",
+		Columns: []string{"Backlog", "Doing"},
+	})
+	resp := session.MakeRequest(t, req, http.StatusCreated)
+	var apiBoard api.ProjectBoard
+	DecodeJSON(t, resp, &apiBoard)
+	models.AssertExistsAndLoadBean(t, &models.ProjectBoard{ID: apiBoard.ID, RepoID: 1})
+	assert.Len(t, apiBoard.Columns, 2)
+	backlog, doing := apiBoard.Columns[0], apiBoard.Columns[1]
+
+	req = NewRequest(t, "GET", "/api/v1/repos/user2/repo1/projects")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var apiBoards []*api.ProjectBoard
+	DecodeJSON(t, resp, &apiBoards)
+	assert.Len(t, apiBoards, models.GetCount(t, &models.ProjectBoard{RepoID: 1}))
+
+	// only the writers of the repository can manage its project boards
+	session4 := loginUser(t, "user4")
+	token4 := getTokenForLoggedInUser(t, session4)
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/projects?token="+token4, &api.CreateProjectBoardOption{
+		Title: "forbidden",
+	})
+	session4.MakeRequest(t, req, http.StatusForbidden)
+
+	cardsURL := fmt.Sprintf("/api/v1/repos/user2/repo1/projects/%d/columns/%d/cards?token=%s", apiBoard.ID, backlog.ID, token)
+	req = NewRequestWithJSON(t, "POST", cardsURL, &api.CreateProjectCardOption{Issue: 1})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	var apiCard api.ProjectCard
+	DecodeJSON(t, resp, &apiCard)
+	assert.EqualValues(t, 1, apiCard.Issue.ID)
+	assert.EqualValues(t, backlog.ID, apiCard.ColumnID)
+	models.AssertExistsAndLoadBean(t, &models.Comment{
+		Type:            models.CommentTypeProjectCard,
+		IssueID:         1,
+		ProjectBoardID:  apiBoard.ID,
+		ProjectColumnID: backlog.ID,
+	})
+
+	// an issue has a single card per board
+	req = NewRequestWithJSON(t, "POST", cardsURL, &api.CreateProjectCardOption{Issue: 1})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+	req = NewRequestWithJSON(t, "POST", cardsURL, &api.CreateProjectCardOption{Issue: 9999})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	position := 0
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/projects/%d/cards/%d/move?token=%s", apiBoard.ID, apiCard.ID, token), &api.MoveProjectCardOption{
+		ColumnID: doing.ID,
+		Position: &position,
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &apiCard)
+	assert.EqualValues(t, doing.ID, apiCard.ColumnID)
+	models.AssertExistsAndLoadBean(t, &models.Comment{
+		Type:               models.CommentTypeProjectCard,
+		IssueID:            1,
+		ProjectBoardID:     apiBoard.ID,
+		OldProjectColumnID: backlog.ID,
+		ProjectColumnID:    doing.ID,
+	})
+
+	// the columns of another board can not be used
+	req = NewRequestWithJSON(t, "POST", fmt.Sprintf("/api/v1/repos/user2/repo1/projects/%d/cards/%d/move?token=%s", apiBoard.ID, apiCard.ID, token), &api.MoveProjectCardOption{
+		ColumnID: 1,
+	})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	req = NewRequestf(t, "DELETE", "/api/v1/repos/user2/repo1/projects/%d?token=%s", apiBoard.ID, token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.ProjectBoard{ID: apiBoard.ID})
+	models.AssertNotExistsBean(t, &models.ProjectColumn{BoardID: apiBoard.ID})
+	models.AssertNotExistsBean(t, &models.ProjectCard{BoardID: apiBoard.ID})
+}
+
+func TestRepoProjectBoard(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	req := NewRequest(t, "GET", "/user2/repo1/projects")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "roadmap")
+
+	req = NewRequest(t, "GET", "/user2/repo1/projects/1/edit")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "roadmap")
+
+	req = NewRequest(t, "GET", "/user2/repo1/projects/1")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 3, htmlDoc.doc.Find(".project-card").Length())
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/projects/1/cards/1/move", map[string]string{
+		"_csrf":     htmlDoc.GetCSRF(),
+		"column_id": "3",
+		"position":  "0",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.ProjectCard{ID: 1, ColumnID: 3, Sorting: 0})
+	models.AssertExistsAndLoadBean(t, &models.ProjectCard{ID: 3, ColumnID: 3, Sorting: 1})
+
+	// the move is shown in the issue
+	req = NewRequest(t, "GET", "/user2/repo1/issues/1")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "moved this from <b>To Do</b> to <b>Done</b> in the <b>roadmap</b> project")
+}
+
+func TestAPIRepoProjectCardsPermission(t *testing.T) {
+	prepareTestEnv(t)
+
+	// disable the pull requests of the repository, the card of the pull
+	// request 2 on the board 1 must no longer be visible
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	var units []models.RepoUnit
+	for _, tp := range []models.UnitType{models.UnitTypeCode, models.UnitTypeIssues, models.UnitTypeReleases, models.UnitTypeWiki, models.UnitTypeProjects} {
+		unit, err := repo.GetUnit(tp)
+		assert.NoError(t, err)
+		units = append(units, *unit)
+	}
+	assert.NoError(t, models.UpdateRepositoryUnits(repo, units))
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+
+	req := NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/projects/1?token=%s", token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var apiBoard api.ProjectBoard
+	DecodeJSON(t, resp, &apiBoard)
+	if assert.Len(t, apiBoard.Columns, 3) && assert.Len(t, apiBoard.Columns[0].Cards, 1) {
+		assert.EqualValues(t, 1, apiBoard.Columns[0].Cards[0].Issue.ID)
+	}
+
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/projects/1/columns/1/cards?token=%s", token)
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	var apiCards []*api.ProjectCard
+	DecodeJSON(t, resp, &apiCards)
+	assert.Len(t, apiCards, 1)
+
+	// the pull requests can not be added or moved without write access
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/projects/1/columns/2/cards?token="+token, &api.CreateProjectCardOption{Issue: 3})
+	session.MakeRequest(t, req, http.StatusForbidden)
+	models.AssertNotExistsBean(t, &models.ProjectCard{BoardID: 1, IssueID: 3})
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/projects/1/cards/2/move?token="+token, &api.MoveProjectCardOption{
+		ColumnID: 2,
+	})
+	session.MakeRequest(t, req, http.StatusNotFound)
+	models.AssertExistsAndLoadBean(t, &models.ProjectCard{ID: 2, ColumnID: 1})
+
+	req = NewRequest(t, "GET", "/user2/repo1/projects/1")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, 2, htmlDoc.doc.Find(".project-card").Length())
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRepoSecrets(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	secretsURL := "/api/v1/repos/user2/repo1/secrets"
+
+	req := NewRequestWithJSON(t, "PUT", secretsURL+"/deploy_token?token="+token, &api.CreateOrUpdateSecretOption{Data: "s3cr3t"})
+	session.MakeRequest(t, req, http.StatusCreated)
+	req = NewRequestWithJSON(t, "PUT", secretsURL+"/DEPLOY_TOKEN?token="+token, &api.CreateOrUpdateSecretOption{Data: "n3w-s3cr3t"})
+	session.MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequestWithJSON(t, "PUT", secretsURL+"/DEPLOY-TOKEN?token="+token, &api.CreateOrUpdateSecretOption{Data: "s3cr3t"})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	secret := models.AssertExistsAndLoadBean(t, &models.Secret{RepoID: 1, Name: "DEPLOY_TOKEN"}).(*models.Secret)
+	data, err := secret.GetData()
+	assert.NoError(t, err)
+	assert.Equal(t, "n3w-s3cr3t", data)
+
+	// only the names of the secrets are listed
+	req = NewRequest(t, "GET", secretsURL+"?token="+token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.NotContains(t, resp.Body.String(), "s3cr3t")
+	var apiSecrets []*api.Secret
+	DecodeJSON(t, resp, &apiSecrets)
+	if assert.Len(t, apiSecrets, 1) {
+		assert.Equal(t, "DEPLOY_TOKEN", apiSecrets[0].Name)
+	}
+
+	// only the administrators of the repository can manage its secrets
+	otherSession := loginUser(t, "user4")
+	otherToken := getTokenForLoggedInUser(t, otherSession)
+	req = NewRequest(t, "GET", secretsURL+"?token="+otherToken)
+	otherSession.MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequest(t, "DELETE", secretsURL+"/deploy_token?token="+token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequest(t, "DELETE", secretsURL+"/deploy_token?token="+token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+	models.AssertNotExistsBean(t, &models.Secret{RepoID: 1, Name: "DEPLOY_TOKEN"})
+}
+
+func TestAPIOrgSecrets(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	secretsURL := "/api/v1/orgs/user3/secrets"
+
+	req := NewRequestWithJSON(t, "PUT", secretsURL+"/ORG_TOKEN?token="+token, &api.CreateOrUpdateSecretOption{Data: "s3cr3t"})
+	session.MakeRequest(t, req, http.StatusCreated)
+	models.AssertExistsAndLoadBean(t, &models.Secret{OwnerID: 3, Name: "ORG_TOKEN"})
+
+	req = NewRequest(t, "GET", secretsURL+"?token="+token)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	var apiSecrets []*api.Secret
+	DecodeJSON(t, resp, &apiSecrets)
+	if assert.Len(t, apiSecrets, 1) {
+		assert.Equal(t, "ORG_TOKEN", apiSecrets[0].Name)
+	}
+
+	// only the owners of the organization can manage its secrets
+	otherSession := loginUser(t, "user4")
+	otherToken := getTokenForLoggedInUser(t, otherSession)
+	req = NewRequest(t, "GET", secretsURL+"?token="+otherToken)
+	otherSession.MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequest(t, "DELETE", secretsURL+"/ORG_TOKEN?token="+token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	models.AssertNotExistsBean(t, &models.Secret{OwnerID: 3, Name: "ORG_TOKEN"})
+}
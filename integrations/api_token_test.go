@@ -9,7 +9,10 @@ import (
 	"testing"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/util"
 	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
 )
 
 // TestAPICreateAndDeleteToken tests that token that was just created can be deleted
@@ -48,3 +51,59 @@ func TestAPIDeleteMissingToken(t *testing.T) {
 	req = AddBasicAuthHeader(req, user.Name)
 	MakeRequest(t, req, http.StatusNotFound)
 }
+
+func TestAPITokenScopes(t *testing.T) {
+	prepareTestEnv(t)
+	user := models.AssertExistsAndLoadBean(t, &models.User{ID: 2}).(*models.User)
+
+	req := NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+		Name:       "ci",
+		Scopes:     []string{"repo:read"},
+		Repository: "user2/repo1",
+	})
+	req = AddBasicAuthHeader(req, user.Name)
+	resp := MakeRequest(t, req, http.StatusCreated)
+	var token api.AccessToken
+	DecodeJSON(t, resp, &token)
+	assert.EqualValues(t, []string{"repo:read"}, token.Scopes)
+	assert.EqualValues(t, "user2/repo1", token.Repository)
+	assert.Nil(t, token.ExpiresAt)
+
+	// reading the repository is allowed
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1?token=%s", token.Sha1)
+	MakeRequest(t, req, http.StatusOK)
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo1/issues?token=%s", token.Sha1)
+	MakeRequest(t, req, http.StatusOK)
+
+	// writing to it is not
+	req = NewRequestWithJSON(t, "POST", "/api/v1/repos/user2/repo1/issues?token="+token.Sha1, &api.CreateIssueOption{
+		Title: "issue",
+	})
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// nor is another repository or another scope
+	req = NewRequestf(t, "GET", "/api/v1/repos/user2/repo2?token=%s", token.Sha1)
+	MakeRequest(t, req, http.StatusForbidden)
+	req = NewRequestf(t, "GET", "/api/v1/user/emails?token=%s", token.Sha1)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	req = NewRequestWithJSON(t, "POST", "/api/v1/users/user2/tokens", &api.CreateAccessTokenOption{
+		Name:   "invalid",
+		Scopes: []string{"repo:admin"},
+	})
+	req = AddBasicAuthHeader(req, user.Name)
+	MakeRequest(t, req, http.StatusUnprocessableEntity)
+}
+
+func TestAPIExpiredToken(t *testing.T) {
+	prepareTestEnv(t)
+	token := &models.AccessToken{
+		UID:         2,
+		Name:        "expired",
+		ExpiresUnix: util.TimeStampNow() - 60,
+	}
+	assert.NoError(t, models.NewAccessToken(token))
+
+	req := NewRequestf(t, "GET", "/api/v1/user?token=%s", token.Sha1)
+	MakeRequest(t, req, http.StatusUnauthorized)
+}
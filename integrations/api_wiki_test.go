@@ -0,0 +1,100 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIWikiPages(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	token := getTokenForLoggedInUser(t, session)
+	urlStr := "/api/v1/repos/user2/repo1/wiki/pages"
+
+	req := NewRequest(t, "GET", urlStr)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var pages []*api.WikiPageMetaData
+	DecodeJSON(t, resp, &pages)
+	if assert.Len(t, pages, 1) {
+		assert.EqualValues(t, "Home", pages[0].Title)
+		assert.EqualValues(t, "Home", pages[0].SubURL)
+		assert.EqualValues(t, "2c54faec6c45d31c1abfaecdab471eac6633738a", pages[0].LastCommit.ID)
+	}
+
+	req = NewRequest(t, "GET", urlStr+"/Home")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var page api.WikiPage
+	DecodeJSON(t, resp, &page)
+	assert.EqualValues(t, "Home", page.Title)
+	assert.EqualValues(t, "# Home page\n\nThis is the home page!\n", page.Content)
+	assert.Contains(t, page.ContentHTML, "This is the home page!")
+	assert.EqualValues(t, 1, page.CommitCount)
+
+	req = NewRequest(t, "GET", urlStr+"/Home/revisions")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var commits []*api.WikiCommit
+	DecodeJSON(t, resp, &commits)
+	assert.Len(t, commits, 1)
+
+	req = NewRequest(t, "GET", urlStr+"/Unknown-page")
+	MakeRequest(t, req, http.StatusNotFound)
+
+	// anonymous users can not write to the wiki
+	req = NewRequestWithJSON(t, "POST", urlStr, &api.CreateWikiPageOptions{Title: "New page"})
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateWikiPageOptions{
+		Title:   "New page",
+		Content: "Some *content*",
+		Message: "Add new page",
+	})
+	resp = session.MakeRequest(t, req, http.StatusCreated)
+	DecodeJSON(t, resp, &page)
+	assert.EqualValues(t, "New page", page.Title)
+	assert.EqualValues(t, "New-page", page.SubURL)
+	assert.EqualValues(t, "Some *content*", page.Content)
+	assert.Contains(t, page.ContentHTML, "<em>content</em>")
+	assert.EqualValues(t, "Add new page\n", page.LastCommit.Message)
+	assert.EqualValues(t, "user2", page.LastCommit.Author.UserName)
+
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateWikiPageOptions{Title: "New page"})
+	session.MakeRequest(t, req, http.StatusConflict)
+	req = NewRequestWithJSON(t, "POST", urlStr+"?token="+token, &api.CreateWikiPageOptions{Title: "_pages"})
+	session.MakeRequest(t, req, http.StatusUnprocessableEntity)
+
+	// renaming keeps the content if none is given
+	req = NewRequestWithJSON(t, "PATCH", urlStr+"/New-page?token="+token, &api.EditWikiPageOptions{
+		Title: "Renamed page",
+	})
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &page)
+	assert.EqualValues(t, "Renamed page", page.Title)
+	assert.EqualValues(t, "Some *content*", page.Content)
+
+	req = NewRequestWithJSON(t, "PATCH", urlStr+"/Renamed-page?token="+token, &api.EditWikiPageOptions{
+		Title: "Home",
+	})
+	session.MakeRequest(t, req, http.StatusConflict)
+
+	req = NewRequest(t, "GET", urlStr+"/New-page")
+	MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequest(t, "DELETE", urlStr+"/Renamed-page?token="+token)
+	session.MakeRequest(t, req, http.StatusNoContent)
+	req = NewRequest(t, "DELETE", urlStr+"/Renamed-page?token="+token)
+	session.MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequest(t, "GET", urlStr)
+	resp = MakeRequest(t, req, http.StatusOK)
+	DecodeJSON(t, resp, &pages)
+	assert.Len(t, pages, 1)
+}
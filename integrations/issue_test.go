@@ -6,6 +6,7 @@ package integrations
 
 import (
 	"net/http"
+	"net/url"
 	"path"
 	"strconv"
 	"strings"
@@ -101,6 +102,44 @@ func TestViewIssuesKeyword(t *testing.T) {
 	})
 }
 
+func TestViewIssuesQuery(t *testing.T) {
+	prepareTestEnv(t)
+
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+
+	req := NewRequestf(t, "GET", "%s/issues?q=%s", repo.RelLink(), url.QueryEscape("is:closed label:label2 author:user2"))
+	resp := MakeRequest(t, req, http.StatusOK)
+
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	issuesSelection := getIssuesSelection(t, htmlDoc)
+	assert.EqualValues(t, 1, issuesSelection.Length())
+	issuesSelection.Each(func(_ int, selection *goquery.Selection) {
+		issue := getIssue(t, repo.ID, selection)
+		assert.EqualValues(t, 5, issue.ID)
+	})
+
+	req = NewRequestf(t, "GET", "%s/issues?q=%s", repo.RelLink(), url.QueryEscape("is:open label:label2"))
+	resp = MakeRequest(t, req, http.StatusOK)
+	assert.EqualValues(t, 0, getIssuesSelection(t, NewHTMLParser(t, resp.Body)).Length())
+}
+
+func TestDashboardIssuesQuery(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	req := NewRequestf(t, "GET", "/issues?type=your_repositories&q=%s", url.QueryEscape("is:closed author:@me"))
+	resp := session.MakeRequest(t, req, http.StatusOK)
+
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	issuesSelection := getIssuesSelection(t, htmlDoc)
+	assert.EqualValues(t, 1, issuesSelection.Length())
+	issuesSelection.Each(func(_ int, selection *goquery.Selection) {
+		issue := getIssue(t, 1, selection)
+		assert.EqualValues(t, 5, issue.ID)
+	})
+	assert.EqualValues(t, "is:closed author:@me", htmlDoc.GetInputValueByName("q"))
+}
+
 func TestNoLoginViewIssue(t *testing.T) {
 	prepareTestEnv(t)
 
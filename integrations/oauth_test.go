@@ -0,0 +1,346 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth/oauth2"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	oauthClientID     = "da7da3ba-9a13-4167-856f-3899de0b0138"
+	oauthClientSecret = "4MK8Na6R55smdCY0WuCCumZ6hjRPnGY5saWVRHHjJiA="
+)
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+}
+
+// authorizeOAuthApplication grants the application access to the account of the session user
+// and returns the authorization code
+func authorizeOAuthApplication(t *testing.T, session *TestSession, values url.Values) string {
+	req := NewRequest(t, "GET", "/login/oauth/authorize?"+values.Encode())
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.Equal(t, values.Get("client_id"), htmlDoc.GetInputValueByName("client_id"))
+
+	form := map[string]string{
+		"_csrf":   htmlDoc.GetCSRF(),
+		"granted": "true",
+	}
+	for _, name := range []string{"client_id", "redirect_uri", "state", "scope", "nonce", "code_challenge", "code_challenge_method"} {
+		form[name] = htmlDoc.GetInputValueByName(name)
+	}
+	req = NewRequestWithValues(t, "POST", "/login/oauth/grant", form)
+	resp = session.MakeRequest(t, req, http.StatusFound)
+
+	redirect, err := url.Parse(resp.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, values.Get("state"), redirect.Query().Get("state"))
+	assert.NotEmpty(t, redirect.Query().Get("code"))
+	return redirect.Query().Get("code")
+}
+
+func TestOAuth2AuthorizationCodeFlow(t *testing.T) {
+	prepareTestEnv(t)
+	session := loginUser(t, "user2")
+
+	code := authorizeOAuthApplication(t, session, url.Values{
+		"client_id":     {oauthClientID},
+		"redirect_uri":  {"a"},
+		"response_type": {"code"},
+		"state":         {"thestate"},
+		"scope":         {"openid profile email user"},
+		"nonce":         {"thenonce"},
+	})
+
+	// exchange the code for tokens
+	req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+		"redirect_uri":  "a",
+		"code":          code,
+	})
+	resp := MakeRequest(t, req, http.StatusOK)
+	var tokens oauthTokenResponse
+	DecodeJSON(t, resp, &tokens)
+	assert.Equal(t, "bearer", tokens.TokenType)
+	assert.EqualValues(t, setting.OAuth2.AccessTokenExpirationTime, tokens.ExpiresIn)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	idToken := new(oauth2.OIDCToken)
+	assert.NoError(t, oauth2.DefaultSigningKey.Parse(tokens.IDToken, idToken))
+	assert.Equal(t, "2", idToken.Subject)
+	assert.Equal(t, oauthClientID, idToken.Audience)
+	assert.Equal(t, setting.AppURL, idToken.Issuer)
+	assert.Equal(t, "thenonce", idToken.Nonce)
+	assert.Equal(t, "user2", idToken.PreferredUsername)
+	assert.Equal(t, "user2@example.com", idToken.Email)
+
+	// a code can only be exchanged once
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	// userinfo endpoint
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var userInfo map[string]interface{}
+	DecodeJSON(t, resp, &userInfo)
+	assert.Equal(t, "2", userInfo["sub"])
+	assert.Equal(t, "user2", userInfo["preferred_username"])
+	assert.Equal(t, "user2@example.com", userInfo["email"])
+
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Set("Authorization", "Bearer "+tokens.RefreshToken)
+	MakeRequest(t, req, http.StatusUnauthorized)
+
+	// the access token can be used for the API within the granted scopes
+	req = NewRequest(t, "GET", "/api/v1/user")
+	req.Header.Set("Authorization", "bearer "+tokens.AccessToken)
+	resp = MakeRequest(t, req, http.StatusOK)
+	user := new(struct{ UserName string })
+	DecodeJSON(t, resp, user)
+	assert.Equal(t, "user2", user.UserName)
+
+	req = NewRequest(t, "GET", "/api/v1/user/emails")
+	req.Header.Set("Authorization", "bearer "+tokens.AccessToken)
+	MakeRequest(t, req, http.StatusOK)
+
+	req = NewRequest(t, "GET", "/api/v1/user/repos")
+	req.Header.Set("Authorization", "bearer "+tokens.AccessToken)
+	MakeRequest(t, req, http.StatusForbidden)
+
+	// refresh the access token, with http basic auth for the client
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": tokens.RefreshToken,
+	})
+	req.SetBasicAuth(oauthClientID, oauthClientSecret)
+	resp = MakeRequest(t, req, http.StatusOK)
+	var refreshed oauthTokenResponse
+	DecodeJSON(t, resp, &refreshed)
+	assert.NotEmpty(t, refreshed.AccessToken)
+	assert.NotEmpty(t, refreshed.RefreshToken)
+
+	// an access token cannot be used as refresh token
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+		"refresh_token": tokens.AccessToken,
+	})
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	// the tokens stop working once the grant is revoked
+	grant := models.AssertExistsAndLoadBean(t, &models.OAuth2Grant{UserID: 2, ApplicationID: 1}).(*models.OAuth2Grant)
+	assert.NoError(t, models.RevokeOAuth2Grant(grant.ID, 2))
+	req = NewRequest(t, "GET", "/login/oauth/userinfo")
+	req.Header.Set("Authorization", "Bearer "+refreshed.AccessToken)
+	MakeRequest(t, req, http.StatusUnauthorized)
+}
+
+func TestOAuth2AccessTokenInvalidClient(t *testing.T) {
+	prepareTestEnv(t)
+
+	req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     oauthClientID,
+		"client_secret": "wrong secret",
+		"redirect_uri":  "a",
+		"code":          "authcode",
+	})
+	resp := MakeRequest(t, req, http.StatusUnauthorized)
+	var errResp map[string]string
+	DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, "invalid_client", errResp["error"])
+
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", map[string]string{
+		"grant_type":    "password",
+		"client_id":     oauthClientID,
+		"client_secret": oauthClientSecret,
+	})
+	resp = MakeRequest(t, req, http.StatusBadRequest)
+	DecodeJSON(t, resp, &errResp)
+	assert.Equal(t, "unsupported_grant_type", errResp["error"])
+}
+
+func TestOAuth2AuthorizeErrors(t *testing.T) {
+	prepareTestEnv(t)
+	session := loginUser(t, "user2")
+
+	// unknown clients and redirect uris are not redirected to
+	req := NewRequest(t, "GET", "/login/oauth/authorize?client_id=unknown&redirect_uri=a&response_type=code")
+	session.MakeRequest(t, req, http.StatusBadRequest)
+	req = NewRequest(t, "GET", "/login/oauth/authorize?client_id="+oauthClientID+"&redirect_uri=https://evil.example.com&response_type=code")
+	session.MakeRequest(t, req, http.StatusBadRequest)
+
+	req = NewRequest(t, "GET", "/login/oauth/authorize?client_id="+oauthClientID+"&redirect_uri=a&response_type=token&state=s")
+	resp := session.MakeRequest(t, req, http.StatusFound)
+	redirect, err := url.Parse(resp.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "unsupported_response_type", redirect.Query().Get("error"))
+	assert.Equal(t, "s", redirect.Query().Get("state"))
+
+	// public clients must use PKCE
+	req = NewRequest(t, "GET", "/login/oauth/authorize?client_id=ce5a1322-42a7-11ea-b77f-2e728ce88125&redirect_uri=http://127.0.0.1/callback&response_type=code")
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	redirect, err = url.Parse(resp.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "127.0.0.1", redirect.Host)
+	assert.Equal(t, "invalid_request", redirect.Query().Get("error"))
+
+	// denying access
+	req = NewRequest(t, "GET", "/login/oauth/authorize?client_id="+oauthClientID+"&redirect_uri=a&response_type=code&state=s")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	req = NewRequestWithValues(t, "POST", "/login/oauth/grant", map[string]string{
+		"_csrf":        htmlDoc.GetCSRF(),
+		"client_id":    oauthClientID,
+		"redirect_uri": "a",
+		"state":        "s",
+		"granted":      "false",
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	redirect, err = url.Parse(resp.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "access_denied", redirect.Query().Get("error"))
+	models.AssertNotExistsBean(t, &models.OAuth2Grant{UserID: 2, ApplicationID: 1})
+}
+
+func TestOAuth2PublicClientPKCE(t *testing.T) {
+	prepareTestEnv(t)
+	session := loginUser(t, "user2")
+
+	code := authorizeOAuthApplication(t, session, url.Values{
+		"client_id":             {"ce5a1322-42a7-11ea-b77f-2e728ce88125"},
+		"redirect_uri":          {"http://127.0.0.1/callback"},
+		"response_type":         {"code"},
+		"state":                 {"thestate"},
+		"code_challenge":        {"CjvyTLSdR47G5zYenDA-eDWW4lRrO8yvjcWwbD_deOg"},
+		"code_challenge_method": {"S256"},
+	})
+
+	values := map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     "ce5a1322-42a7-11ea-b77f-2e728ce88125",
+		"redirect_uri":  "http://127.0.0.1/callback",
+		"code":          code,
+		"code_verifier": "wrong verifier",
+	}
+	req := NewRequestWithValues(t, "POST", "/login/oauth/access_token", values)
+	MakeRequest(t, req, http.StatusBadRequest)
+
+	values["code_verifier"] = "N1Zo9-8Rfwhkt68r1r29ty8YwIraXR8eh_1Qwxg7yQXsonBt"
+	req = NewRequestWithValues(t, "POST", "/login/oauth/access_token", values)
+	resp := MakeRequest(t, req, http.StatusOK)
+	var tokens oauthTokenResponse
+	DecodeJSON(t, resp, &tokens)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.Empty(t, tokens.IDToken)
+}
+
+func TestOAuth2AlreadyGranted(t *testing.T) {
+	prepareTestEnv(t)
+	session := loginUser(t, "user1")
+
+	// user1 already granted the application the openid and profile scopes
+	req := NewRequest(t, "GET", "/login/oauth/authorize?client_id="+oauthClientID+"&redirect_uri=a&response_type=code&state=thestate&scope=openid")
+	resp := session.MakeRequest(t, req, http.StatusFound)
+	redirect, err := url.Parse(resp.Header().Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "thestate", redirect.Query().Get("state"))
+	assert.NotEmpty(t, redirect.Query().Get("code"))
+
+	// but has to confirm new scopes
+	req = NewRequest(t, "GET", "/login/oauth/authorize?client_id="+oauthClientID+"&redirect_uri=a&response_type=code&state=thestate&scope=openid+email")
+	session.MakeRequest(t, req, http.StatusOK)
+}
+
+func TestOIDCDiscovery(t *testing.T) {
+	prepareTestEnv(t)
+
+	req := NewRequest(t, "GET", "/.well-known/openid-configuration")
+	resp := MakeRequest(t, req, http.StatusOK)
+	var config map[string]interface{}
+	DecodeJSON(t, resp, &config)
+	assert.Equal(t, setting.AppURL, config["issuer"])
+	assert.Equal(t, setting.AppURL+"login/oauth/authorize", config["authorization_endpoint"])
+	assert.Equal(t, setting.AppURL+"login/oauth/keys", config["jwks_uri"])
+
+	req = NewRequest(t, "GET", "/login/oauth/keys")
+	resp = MakeRequest(t, req, http.StatusOK)
+	var keys struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	DecodeJSON(t, resp, &keys)
+	if assert.Len(t, keys.Keys, 1) {
+		assert.Equal(t, oauth2.DefaultSigningKey.KeyID(), keys.Keys[0]["kid"])
+		assert.Equal(t, "RS256", keys.Keys[0]["alg"])
+	}
+}
+
+func TestOAuth2ApplicationSettings(t *testing.T) {
+	prepareTestEnv(t)
+	session := loginUser(t, "user2")
+
+	req := NewRequestWithValues(t, "POST", "/user/settings/applications/oauth2", map[string]string{
+		"_csrf":               GetCSRF(t, session, "/user/settings/applications"),
+		"application_name":    "my app",
+		"redirect_uri":        "https://example.com/callback\nhttps://example.com/other",
+		"confidential_client": "on",
+	})
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	app := models.AssertExistsAndLoadBean(t, &models.OAuth2Application{UID: 2, Name: "my app"}).(*models.OAuth2Application)
+	assert.Equal(t, []string{"https://example.com/callback", "https://example.com/other"}, app.RedirectURIs)
+	assert.True(t, app.ConfidentialClient)
+
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	secret, _ := htmlDoc.doc.Find("#client-secret").Attr("value")
+	assert.True(t, app.ValidateClientSecret(secret))
+
+	// other users cannot see the application
+	req = NewRequestf(t, "GET", "/user/settings/applications/oauth2/%d", app.ID)
+	loginUser(t, "user4").MakeRequest(t, req, http.StatusNotFound)
+
+	req = NewRequestWithValues(t, "POST", "/user/settings/applications/oauth2/delete", map[string]string{
+		"_csrf": GetCSRF(t, session, "/user/settings/applications"),
+		"id":    fmt.Sprint(app.ID),
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertNotExistsBean(t, &models.OAuth2Application{ID: app.ID})
+}
+
+func TestOAuth2OrgApplicationSettings(t *testing.T) {
+	prepareTestEnv(t)
+	session := loginUser(t, "user2")
+
+	req := NewRequest(t, "GET", "/org/user3/settings/applications")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	assert.Contains(t, htmlDoc.doc.Find(".key.list").Text(), "Public app of an organization")
+
+	req = NewRequest(t, "GET", "/org/user3/settings/applications/oauth2/2")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc = NewHTMLParser(t, resp.Body)
+	assert.Equal(t, "ce5a1322-42a7-11ea-b77f-2e728ce88125", htmlDoc.GetInputValueByID("client-id"))
+
+	// applications of other owners cannot be edited from the organization
+	req = NewRequest(t, "GET", "/org/user3/settings/applications/oauth2/1")
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepoPushMirrorSettings(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	csrf := GetCSRF(t, session, "/user2/repo1/settings")
+
+	// only remote URLs are accepted
+	req := NewRequestWithValues(t, "POST", "/user2/repo1/settings", map[string]string{
+		"_csrf":                csrf,
+		"action":               "push-mirror-add",
+		"push_mirror_address":  "/tmp/repo.git",
+		"push_mirror_interval": "8h",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertNotExistsBean(t, &models.PushMirror{RepoID: 1})
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings", map[string]string{
+		"_csrf":                      csrf,
+		"action":                     "push-mirror-add",
+		"push_mirror_address":        "http://localhost:1/user2/repo1.git",
+		"push_mirror_username":       "user2",
+		"push_mirror_password":       "s3cr3t",
+		"push_mirror_interval":       "8h",
+		"push_mirror_sync_on_commit": "on",
+	})
+	resp := session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, "/user2/repo1/settings", test.RedirectURL(resp))
+	m := models.AssertExistsAndLoadBean(t, &models.PushMirror{RepoID: 1}).(*models.PushMirror)
+	assert.True(t, m.SyncOnCommit)
+
+	// the credentials are not displayed
+	req = NewRequest(t, "GET", "/user2/repo1/settings")
+	resp = session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "http://localhost:1/user2/repo1.git")
+	assert.NotContains(t, resp.Body.String(), "s3cr3t")
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings", map[string]string{
+		"_csrf":          csrf,
+		"action":         "push-mirror-remove",
+		"push_mirror_id": fmt.Sprint(m.ID),
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	models.AssertNotExistsBean(t, &models.PushMirror{ID: m.ID})
+}
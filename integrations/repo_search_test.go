@@ -7,6 +7,9 @@ package integrations
 import (
 	"net/http"
 	"testing"
+	"time"
+
+	"code.gitea.io/gitea/models"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/stretchr/testify/assert"
@@ -33,3 +36,49 @@ func TestSearchRepo(t *testing.T) {
 	filenames := resultFilenames(t, NewHTMLParser(t, resp.Body))
 	assert.EqualValues(t, []string{"README.md"}, filenames)
 }
+
+func TestSearchRepoRef(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	settingsURL := "/user2/repo1/settings"
+	req := NewRequestWithValues(t, "POST", settingsURL, map[string]string{
+		"_csrf":            GetCSRF(t, session, settingsURL),
+		"action":           "indexer",
+		"indexer_branches": "[develop",
+		"indexer_tags":     "v1.*",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1, IndexerBranches: "", IndexerTags: ""})
+
+	req = NewRequestWithValues(t, "POST", settingsURL, map[string]string{
+		"_csrf":            GetCSRF(t, session, settingsURL),
+		"action":           "indexer",
+		"indexer_branches": "develop, feature/* ",
+		"indexer_tags":     "v1.*",
+	})
+	session.MakeRequest(t, req, http.StatusFound)
+	repo := models.AssertExistsAndLoadBean(t, &models.Repository{ID: 1}).(*models.Repository)
+	assert.Equal(t, "develop,feature/*", repo.IndexerBranches)
+	assert.Equal(t, "v1.*", repo.IndexerTags)
+
+	// the refs are indexed in the background
+	var refs []string
+	for i := 0; i < 100; i++ {
+		var err error
+		refs, err = repo.GetIndexedRefs()
+		assert.NoError(t, err)
+		if len(refs) == 3 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"refs/heads/develop", "refs/heads/feature/1", "refs/tags/v1.1"}, refs)
+
+	req = NewRequest(t, "GET", "/user2/repo1/search?q=Description&ref=refs/tags/v1.1")
+	resp := MakeRequest(t, req, http.StatusOK)
+	doc := NewHTMLParser(t, resp.Body)
+	assert.EqualValues(t, []string{"README.md"}, resultFilenames(t, doc))
+	selected, _ := doc.doc.Find("select[name=ref] option[selected]").Attr("value")
+	assert.Equal(t, "refs/tags/v1.1", selected)
+}
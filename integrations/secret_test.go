@@ -0,0 +1,57 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testAddSecret(t *testing.T, session *TestSession, link, name, data string, expectedStatus int) {
+	req := NewRequestWithValues(t, "POST", link+"/settings/secrets", map[string]string{
+		"_csrf": GetCSRF(t, session, link+"/settings/secrets"),
+		"name":  name,
+		"data":  data,
+	})
+	session.MakeRequest(t, req, expectedStatus)
+}
+
+func TestRepoSettingsSecrets(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	testAddSecret(t, session, "/user2/repo1", "deploy_token", "s3cr3t", http.StatusFound)
+	testAddSecret(t, session, "/user2/repo1", "2TOKEN", "s3cr3t", http.StatusOK)
+	models.AssertExistsAndLoadBean(t, &models.Secret{RepoID: 1, Name: "DEPLOY_TOKEN"})
+	models.AssertNotExistsBean(t, &models.Secret{RepoID: 1, Name: "2TOKEN"})
+
+	req := NewRequest(t, "GET", "/user2/repo1/settings/secrets")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "DEPLOY_TOKEN")
+	assert.NotContains(t, resp.Body.String(), "s3cr3t")
+
+	req = NewRequestWithValues(t, "POST", "/user2/repo1/settings/secrets/delete", map[string]string{
+		"_csrf": GetCSRF(t, session, "/user2/repo1/settings/secrets"),
+		"id":    "DEPLOY_TOKEN",
+	})
+	session.MakeRequest(t, req, http.StatusOK)
+	models.AssertNotExistsBean(t, &models.Secret{RepoID: 1, Name: "DEPLOY_TOKEN"})
+}
+
+func TestOrgSettingsSecrets(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	testAddSecret(t, session, "/org/user3", "ORG_TOKEN", "s3cr3t", http.StatusFound)
+	models.AssertExistsAndLoadBean(t, &models.Secret{OwnerID: 3, Name: "ORG_TOKEN"})
+
+	req := NewRequest(t, "GET", "/org/user3/settings/secrets")
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	assert.Contains(t, resp.Body.String(), "ORG_TOKEN")
+}
@@ -0,0 +1,71 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package integrations
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/test"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedeliverWebhook(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	hookURL := "/user2/repo1/settings/hooks/1"
+	req := NewRequest(t, "GET", hookURL)
+	resp := session.MakeRequest(t, req, http.StatusOK)
+	htmlDoc := NewHTMLParser(t, resp.Body)
+	action, exists := htmlDoc.doc.Find("form[action$='/deliveries/1/redeliver']").Attr("action")
+	assert.True(t, exists)
+	assert.Equal(t, hookURL+"/deliveries/1/redeliver", action)
+
+	req = NewRequestWithValues(t, "POST", action, map[string]string{
+		"_csrf": htmlDoc.GetCSRF(),
+	})
+	resp = session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, hookURL, test.RedirectURL(resp))
+	models.AssertCount(t, &models.HookTask{HookID: 1}, 2)
+
+	req = NewRequestWithValues(t, "POST", fmt.Sprintf("%s/deliveries/%d/redeliver", hookURL, models.NonexistentID), map[string]string{
+		"_csrf": htmlDoc.GetCSRF(),
+	})
+	session.MakeRequest(t, req, http.StatusNotFound)
+}
+
+func TestNewMatrixWebhook(t *testing.T) {
+	prepareTestEnv(t)
+
+	session := loginUser(t, "user2")
+	csrf := GetCSRF(t, session, "/user2/repo1/settings/hooks/matrix/new")
+	req := NewRequestWithValues(t, "POST", "/user2/repo1/settings/hooks/matrix/new", map[string]string{
+		"_csrf":          csrf,
+		"homeserver_url": "https://matrix.example.com",
+		"room_id":        "!room:example.com",
+		"access_token":   "s3cr3t",
+		"message_type":   "m.text",
+		"events":         "push_only",
+		"active":         "on",
+	})
+	resp := session.MakeRequest(t, req, http.StatusFound)
+	assert.Equal(t, "/user2/repo1/settings/hooks", test.RedirectURL(resp))
+
+	hook := models.AssertExistsAndLoadBean(t, &models.Webhook{RepoID: 1, HookTaskType: models.MATRIX}).(*models.Webhook)
+	assert.Equal(t, "https://matrix.example.com/_matrix/client/r0/rooms/%21room:example.com/send/m.room.message", hook.URL)
+	assert.Equal(t, &models.MatrixMeta{
+		HomeserverURL: "https://matrix.example.com",
+		Room:          "!room:example.com",
+		AccessToken:   "s3cr3t",
+		MessageType:   models.MatrixMessageTypeText,
+	}, hook.GetMatrixHook())
+
+	req = NewRequest(t, "GET", fmt.Sprintf("/user2/repo1/settings/hooks/%d", hook.ID))
+	session.MakeRequest(t, req, http.StatusOK)
+}
@@ -5,8 +5,12 @@
 package models
 
 import (
+	"io/ioutil"
+	"strings"
 	"testing"
 
+	"code.gitea.io/gitea/modules/storage"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -87,3 +91,32 @@ func TestUpdateAttachment(t *testing.T) {
 
 	AssertExistsAndLoadBean(t, &Attachment{Name: "new_name"})
 }
+
+// stringFile implements multipart.File for in-memory content
+type stringFile struct {
+	*strings.Reader
+}
+
+func (stringFile) Close() error {
+	return nil
+}
+
+func TestNewAttachment(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	attach, err := NewAttachment("file.txt", []byte("first "), stringFile{strings.NewReader("second")})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 12, attach.Size)
+	AssertExistsAndLoadBean(t, &Attachment{ID: attach.ID, UUID: attach.UUID})
+
+	fr, err := storage.Attachments.Open(attach.RelativePath())
+	assert.NoError(t, err)
+	content, err := ioutil.ReadAll(fr)
+	fr.Close()
+	assert.NoError(t, err)
+	assert.EqualValues(t, "first second", string(content))
+
+	assert.NoError(t, DeleteAttachment(attach, true))
+	_, err = storage.Attachments.Stat(attach.RelativePath())
+	assert.True(t, storage.IsErrObjectNotExist(err))
+}
@@ -6,6 +6,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/base"
@@ -36,6 +37,8 @@ type ProtectedBranch struct {
 	ApprovalsWhitelistUserIDs []int64        `xorm:"JSON TEXT"`
 	ApprovalsWhitelistTeamIDs []int64        `xorm:"JSON TEXT"`
 	RequiredApprovals         int64          `xorm:"NOT NULL DEFAULT 0"`
+	EnableStatusCheck         bool           `xorm:"NOT NULL DEFAULT false"`
+	StatusCheckContexts       []string       `xorm:"JSON TEXT"`
 	CreatedUnix               util.TimeStamp `xorm:"created"`
 	UpdatedUnix               util.TimeStamp `xorm:"updated"`
 }
@@ -97,6 +100,43 @@ func (protectBranch *ProtectedBranch) HasEnoughApprovals(pr *PullRequest) bool {
 	return protectBranch.GetGrantedApprovalsCount(pr) >= protectBranch.RequiredApprovals
 }
 
+// GetMissingStatusContexts returns the required status contexts which have not succeeded
+// among the given latest statuses of a commit.
+func (protectBranch *ProtectedBranch) GetMissingStatusContexts(statuses []*CommitStatus) []string {
+	if !protectBranch.EnableStatusCheck {
+		return nil
+	}
+
+	succeeded := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		if status.State == CommitStatusSuccess {
+			succeeded[status.Context] = true
+		}
+	}
+
+	missing := make([]string, 0, len(protectBranch.StatusCheckContexts))
+	for _, context := range protectBranch.StatusCheckContexts {
+		if !succeeded[context] {
+			missing = append(missing, context)
+		}
+	}
+	return missing
+}
+
+// CleanStatusCheckContexts trims the given status contexts and drops the empty and duplicated ones
+func CleanStatusCheckContexts(contexts []string) []string {
+	cleaned := make([]string, 0, len(contexts))
+	seen := make(map[string]bool, len(contexts))
+	for _, context := range contexts {
+		context = strings.TrimSpace(context)
+		if len(context) > 0 && !seen[context] {
+			seen[context] = true
+			cleaned = append(cleaned, context)
+		}
+	}
+	return cleaned
+}
+
 // GetGrantedApprovalsCount returns the number of granted approvals for pr. A granted approval must be authored by a user in an approval whitelist.
 func (protectBranch *ProtectedBranch) GetGrantedApprovalsCount(pr *PullRequest) int64 {
 	reviews, err := GetReviewersByPullID(pr.Issue.ID)
@@ -78,3 +78,24 @@ func getDeletedBranch(t *testing.T, branch *DeletedBranch) *DeletedBranch {
 
 	return deletedBranch
 }
+
+func TestProtectedBranch_GetMissingStatusContexts(t *testing.T) {
+	protectBranch := &ProtectedBranch{
+		EnableStatusCheck:   true,
+		StatusCheckContexts: []string{"ci/build", "ci/test", "ci/deploy"},
+	}
+	statuses := []*CommitStatus{
+		{Context: "ci/build", State: CommitStatusSuccess},
+		{Context: "ci/test", State: CommitStatusPending},
+		{Context: "ci/lint", State: CommitStatusFailure},
+	}
+	assert.EqualValues(t, []string{"ci/test", "ci/deploy"}, protectBranch.GetMissingStatusContexts(statuses))
+
+	protectBranch.EnableStatusCheck = false
+	assert.Empty(t, protectBranch.GetMissingStatusContexts(statuses))
+}
+
+func TestCleanStatusCheckContexts(t *testing.T) {
+	assert.EqualValues(t, []string{"ci/build", "CI/build", "ci/test"},
+		CleanStatusCheckContexts([]string{" ci/build", "", "CI/build", "ci/build ", "ci/test\r"}))
+}
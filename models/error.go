@@ -483,6 +483,21 @@ func (err ErrAccessTokenEmpty) Error() string {
 	return fmt.Sprintf("access token is empty")
 }
 
+// ErrAccessTokenInvalidScope represents a "AccessTokenInvalidScope" kind of error.
+type ErrAccessTokenInvalidScope struct {
+	Scope string
+}
+
+// IsErrAccessTokenInvalidScope checks if an error is a ErrAccessTokenInvalidScope.
+func IsErrAccessTokenInvalidScope(err error) bool {
+	_, ok := err.(ErrAccessTokenInvalidScope)
+	return ok
+}
+
+func (err ErrAccessTokenInvalidScope) Error() string {
+	return fmt.Sprintf("access token scope is invalid [scope: %s]", err.Scope)
+}
+
 // ________                            .__                __  .__
 // \_____  \_______  _________    ____ |__|____________ _/  |_|__| ____   ____
 //  /   |   \_  __ \/ ___\__  \  /    \|  \___   /\__  \\   __\  |/  _ \ /    \
@@ -733,6 +748,53 @@ func (err ErrRepoFileAlreadyExist) Error() string {
 	return fmt.Sprintf("repository file already exists [file_name: %s]", err.FileName)
 }
 
+// ErrInvalidRefPattern represents a "InvalidRefPattern" kind of error.
+type ErrInvalidRefPattern struct {
+	Pattern string
+}
+
+// IsErrInvalidRefPattern checks if an error is a ErrInvalidRefPattern.
+func IsErrInvalidRefPattern(err error) bool {
+	_, ok := err.(ErrInvalidRefPattern)
+	return ok
+}
+
+func (err ErrInvalidRefPattern) Error() string {
+	return fmt.Sprintf("ref pattern is not valid [pattern: %s]", err.Pattern)
+}
+
+// ErrRepoFileDoesNotExist represents a "RepoFileDoesNotExist" kind of error.
+type ErrRepoFileDoesNotExist struct {
+	Path string
+}
+
+// IsErrRepoFileDoesNotExist checks if an error is a ErrRepoFileDoesNotExist.
+func IsErrRepoFileDoesNotExist(err error) bool {
+	_, ok := err.(ErrRepoFileDoesNotExist)
+	return ok
+}
+
+func (err ErrRepoFileDoesNotExist) Error() string {
+	return fmt.Sprintf("repository file does not exist [path: %s]", err.Path)
+}
+
+// ErrSHADoesNotMatch represents a "SHADoesNotMatch" kind of error.
+type ErrSHADoesNotMatch struct {
+	Path       string
+	GivenSHA   string
+	CurrentSHA string
+}
+
+// IsErrSHADoesNotMatch checks if an error is a ErrSHADoesNotMatch.
+func IsErrSHADoesNotMatch(err error) bool {
+	_, ok := err.(ErrSHADoesNotMatch)
+	return ok
+}
+
+func (err ErrSHADoesNotMatch) Error() string {
+	return fmt.Sprintf("sha does not match [path: %s, given: %s, expected: %s]", err.Path, err.GivenSHA, err.CurrentSHA)
+}
+
 // ErrUserDoesNotHaveAccessToRepo represets an error where the user doesn't has access to a given repo
 type ErrUserDoesNotHaveAccessToRepo struct {
 	UserID   int64
@@ -853,6 +915,22 @@ func (err ErrWebhookNotExist) Error() string {
 	return fmt.Sprintf("webhook does not exist [id: %d]", err.ID)
 }
 
+// ErrHookTaskNotExist represents a "HookTaskNotExist" kind of error.
+type ErrHookTaskNotExist struct {
+	ID     int64
+	HookID int64
+}
+
+// IsErrHookTaskNotExist checks if an error is a ErrHookTaskNotExist.
+func IsErrHookTaskNotExist(err error) bool {
+	_, ok := err.(ErrHookTaskNotExist)
+	return ok
+}
+
+func (err ErrHookTaskNotExist) Error() string {
+	return fmt.Sprintf("hook task does not exist [id: %d, hook_id: %d]", err.ID, err.HookID)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -944,6 +1022,36 @@ func (err ErrInvalidMergeStyle) Error() string {
 		err.ID, err.Style)
 }
 
+// ErrPullAutoMergeAlreadyScheduled represents an error if an auto merge is already scheduled for a pull request
+type ErrPullAutoMergeAlreadyScheduled struct {
+	PullID int64
+}
+
+// IsErrPullAutoMergeAlreadyScheduled checks if an error is a ErrPullAutoMergeAlreadyScheduled.
+func IsErrPullAutoMergeAlreadyScheduled(err error) bool {
+	_, ok := err.(ErrPullAutoMergeAlreadyScheduled)
+	return ok
+}
+
+func (err ErrPullAutoMergeAlreadyScheduled) Error() string {
+	return fmt.Sprintf("pull request is already scheduled to auto merge [pull_id: %d]", err.PullID)
+}
+
+// ErrPullAutoMergeNotExist represents an error if no auto merge is scheduled for a pull request
+type ErrPullAutoMergeNotExist struct {
+	PullID int64
+}
+
+// IsErrPullAutoMergeNotExist checks if an error is a ErrPullAutoMergeNotExist.
+func IsErrPullAutoMergeNotExist(err error) bool {
+	_, ok := err.(ErrPullAutoMergeNotExist)
+	return ok
+}
+
+func (err ErrPullAutoMergeNotExist) Error() string {
+	return fmt.Sprintf("pull request is not scheduled to auto merge [pull_id: %d]", err.PullID)
+}
+
 // _________                                       __
 // \_   ___ \  ____   _____   _____   ____   _____/  |_
 // /    \  \/ /  _ \ /     \ /     \_/ __ \ /    \   __\
@@ -1034,6 +1142,22 @@ func (err ErrLabelNotExist) Error() string {
 	return fmt.Sprintf("label does not exist [label_id: %d, repo_id: %d]", err.LabelID, err.RepoID)
 }
 
+// ErrOrgLabelNotExist represents a "OrgLabelNotExist" kind of error.
+type ErrOrgLabelNotExist struct {
+	LabelID int64
+	OrgID   int64
+}
+
+// IsErrOrgLabelNotExist checks if an error is a ErrOrgLabelNotExist.
+func IsErrOrgLabelNotExist(err error) bool {
+	_, ok := err.(ErrOrgLabelNotExist)
+	return ok
+}
+
+func (err ErrOrgLabelNotExist) Error() string {
+	return fmt.Sprintf("label does not exist [label_id: %d, org_id: %d]", err.LabelID, err.OrgID)
+}
+
 //    _____  .__.__                   __
 //   /     \ |__|  |   ____   _______/  |_  ____   ____   ____
 //  /  \ /  \|  |  | _/ __ \ /  ___/\   __\/  _ \ /    \_/ __ \
@@ -1132,6 +1256,64 @@ func (err ErrLoginSourceInUse) Error() string {
 	return fmt.Sprintf("login source is still used by some users [id: %d]", err.ID)
 }
 
+// ErrOAuthClientIDInvalid will be thrown if client id cannot be found
+type ErrOAuthClientIDInvalid struct {
+	ClientID string
+}
+
+// IsErrOAuthClientIDInvalid checks if an error is a ErrOAuthClientIDInvalid.
+func IsErrOAuthClientIDInvalid(err error) bool {
+	_, ok := err.(ErrOAuthClientIDInvalid)
+	return ok
+}
+
+func (err ErrOAuthClientIDInvalid) Error() string {
+	return fmt.Sprintf("client id is invalid [client_id: %s]", err.ClientID)
+}
+
+// ErrOAuthApplicationNotFound will be thrown if id cannot be found
+type ErrOAuthApplicationNotFound struct {
+	ID int64
+}
+
+// IsErrOAuthApplicationNotFound checks if an error is a ErrOAuthApplicationNotFound.
+func IsErrOAuthApplicationNotFound(err error) bool {
+	_, ok := err.(ErrOAuthApplicationNotFound)
+	return ok
+}
+
+func (err ErrOAuthApplicationNotFound) Error() string {
+	return fmt.Sprintf("oauth2 application does not exist [id: %d]", err.ID)
+}
+
+// ErrOAuthGrantNotExist will be thrown if a grant cannot be found
+type ErrOAuthGrantNotExist struct {
+	ID int64
+}
+
+// IsErrOAuthGrantNotExist checks if an error is a ErrOAuthGrantNotExist.
+func IsErrOAuthGrantNotExist(err error) bool {
+	_, ok := err.(ErrOAuthGrantNotExist)
+	return ok
+}
+
+func (err ErrOAuthGrantNotExist) Error() string {
+	return fmt.Sprintf("oauth2 grant does not exist [id: %d]", err.ID)
+}
+
+// ErrOAuthCodeInvalid will be thrown if an authorization code has already been used
+type ErrOAuthCodeInvalid struct{}
+
+// IsErrOAuthCodeInvalid checks if an error is a ErrOAuthCodeInvalid.
+func IsErrOAuthCodeInvalid(err error) bool {
+	_, ok := err.(ErrOAuthCodeInvalid)
+	return ok
+}
+
+func (err ErrOAuthCodeInvalid) Error() string {
+	return "oauth2 authorization code is invalid or has already been used"
+}
+
 // ___________
 // \__    ___/___ _____    _____
 //   |    |_/ __ \\__  \  /     \
@@ -1366,3 +1548,132 @@ func IsErrReviewNotExist(err error) bool {
 func (err ErrReviewNotExist) Error() string {
 	return fmt.Sprintf("review does not exist [id: %d]", err.ID)
 }
+
+//  _   _       _   _  __ _           _   _
+// | \ | | ___ | |_(_)/ _(_) ___ __ _| |_(_) ___  _ __
+// |  \| |/ _ \| __| | |_| |/ __/ _` | __| |/ _ \| '_ \
+// | |\  | (_) | |_| |  _| | (_| (_| | |_| | (_) | | | |
+// |_| \_|\___/ \__|_|_| |_|\___\__,_|\__|_|\___/|_| |_|
+
+// ErrNotificationNotExist represents a "NotificationNotExist" kind of error.
+type ErrNotificationNotExist struct {
+	ID int64
+}
+
+// IsErrNotificationNotExist checks if an error is a ErrNotificationNotExist.
+func IsErrNotificationNotExist(err error) bool {
+	_, ok := err.(ErrNotificationNotExist)
+	return ok
+}
+
+func (err ErrNotificationNotExist) Error() string {
+	return fmt.Sprintf("notification does not exist [id: %d]", err.ID)
+}
+
+//  _____                     _
+// /  ___|                   | |
+// \ `--.  ___  ___ _ __ ___| |_
+//  `--. \/ _ \/ __| '__/ _ \ __|
+// /\__/ /  __/ (__| | |  __/ |_
+// \____/ \___|\___|_|  \___|\__|
+
+// ErrSecretNameInvalid represents a "SecretNameInvalid" kind of error.
+type ErrSecretNameInvalid struct {
+	Name string
+}
+
+// IsErrSecretNameInvalid checks if an error is a ErrSecretNameInvalid.
+func IsErrSecretNameInvalid(err error) bool {
+	_, ok := err.(ErrSecretNameInvalid)
+	return ok
+}
+
+func (err ErrSecretNameInvalid) Error() string {
+	return fmt.Sprintf("secret name is invalid [name: %s]", err.Name)
+}
+
+// ErrSecretNotExist represents a "SecretNotExist" kind of error.
+type ErrSecretNotExist struct {
+	Name string
+}
+
+// IsErrSecretNotExist checks if an error is a ErrSecretNotExist.
+func IsErrSecretNotExist(err error) bool {
+	_, ok := err.(ErrSecretNotExist)
+	return ok
+}
+
+func (err ErrSecretNotExist) Error() string {
+	return fmt.Sprintf("secret does not exist [name: %s]", err.Name)
+}
+
+// __________                   __               __
+// \______   \_______  ____    |__| ____   _____/  |_
+//  |     ___/\_  __ \/  _ \   |  |/ __ \_/ ___\   __\
+//  |    |     |  | \(  <_> )  |  \  ___/\  \___|  |
+//  |____|     |__|   \____/\__|  |\___  >\___  >__|
+//                         \______|    \/     \/
+
+// ErrProjectBoardNotExist represents a "ProjectBoardNotExist" kind of error.
+type ErrProjectBoardNotExist struct {
+	ID     int64
+	RepoID int64
+}
+
+// IsErrProjectBoardNotExist checks if an error is a ErrProjectBoardNotExist.
+func IsErrProjectBoardNotExist(err error) bool {
+	_, ok := err.(ErrProjectBoardNotExist)
+	return ok
+}
+
+func (err ErrProjectBoardNotExist) Error() string {
+	return fmt.Sprintf("project board does not exist [id: %d, repo_id: %d]", err.ID, err.RepoID)
+}
+
+// ErrProjectColumnNotExist represents a "ProjectColumnNotExist" kind of error.
+type ErrProjectColumnNotExist struct {
+	ID      int64
+	BoardID int64
+}
+
+// IsErrProjectColumnNotExist checks if an error is a ErrProjectColumnNotExist.
+func IsErrProjectColumnNotExist(err error) bool {
+	_, ok := err.(ErrProjectColumnNotExist)
+	return ok
+}
+
+func (err ErrProjectColumnNotExist) Error() string {
+	return fmt.Sprintf("project column does not exist [id: %d, board_id: %d]", err.ID, err.BoardID)
+}
+
+// ErrProjectCardNotExist represents a "ProjectCardNotExist" kind of error.
+type ErrProjectCardNotExist struct {
+	ID      int64
+	BoardID int64
+}
+
+// IsErrProjectCardNotExist checks if an error is a ErrProjectCardNotExist.
+func IsErrProjectCardNotExist(err error) bool {
+	_, ok := err.(ErrProjectCardNotExist)
+	return ok
+}
+
+func (err ErrProjectCardNotExist) Error() string {
+	return fmt.Sprintf("project card does not exist [id: %d, board_id: %d]", err.ID, err.BoardID)
+}
+
+// ErrProjectCardAlreadyExist represents a "ProjectCardAlreadyExist" kind of error.
+type ErrProjectCardAlreadyExist struct {
+	BoardID int64
+	IssueID int64
+}
+
+// IsErrProjectCardAlreadyExist checks if an error is a ErrProjectCardAlreadyExist.
+func IsErrProjectCardAlreadyExist(err error) bool {
+	_, ok := err.(ErrProjectCardAlreadyExist)
+	return ok
+}
+
+func (err ErrProjectCardAlreadyExist) Error() string {
+	return fmt.Sprintf("issue already has a card on the project board [board_id: %d, issue_id: %d]", err.BoardID, err.IssueID)
+}
@@ -30,6 +30,7 @@ type Issue struct {
 	Index           int64       `xorm:"UNIQUE(repo_index)"` // Index in one repository.
 	PosterID        int64       `xorm:"INDEX"`
 	Poster          *User       `xorm:"-"`
+	OriginalAuthor  string      // Name of the poster on the service the issue was migrated from.
 	Title           string      `xorm:"name"`
 	Content         string      `xorm:"TEXT"`
 	RenderedContent string      `xorm:"-"`
@@ -354,6 +355,12 @@ func (issue *Issue) apiFormat(e Engine) *api.Issue {
 		Comments: issue.NumComments,
 		Created:  issue.CreatedUnix.AsTime(),
 		Updated:  issue.UpdatedUnix.AsTime(),
+		Repository: &api.RepositoryMeta{
+			ID:       issue.Repo.ID,
+			Name:     issue.Repo.Name,
+			Owner:    issue.Repo.mustOwnerName(e),
+			FullName: issue.Repo.FullName(),
+		},
 	}
 
 	if issue.ClosedUnix != 0 {
@@ -965,7 +972,9 @@ type NewIssueOptions struct {
 
 func newIssue(e *xorm.Session, doer *User, opts NewIssueOptions) (err error) {
 	opts.Issue.Title = strings.TrimSpace(opts.Issue.Title)
-	opts.Issue.Index = opts.Repo.NextIssueIndex()
+	if opts.Issue.Index, err = opts.Repo.nextIssueIndex(e); err != nil {
+		return fmt.Errorf("nextIssueIndex: %v", err)
+	}
 
 	if opts.Issue.MilestoneID > 0 {
 		milestone, err := getMilestoneByRepoID(e, opts.Issue.RepoID, opts.Issue.MilestoneID)
@@ -1056,7 +1065,7 @@ func newIssue(e *xorm.Session, doer *User, opts NewIssueOptions) (err error) {
 
 		for _, label := range labels {
 			// Silently drop invalid labels.
-			if label.RepoID != opts.Repo.ID {
+			if !label.IsAvailableToRepo(opts.Repo) {
 				continue
 			}
 
@@ -1213,6 +1222,18 @@ type IssuesOptions struct {
 	Labels      string
 	SortType    string
 	IssueIDs    []int64
+	// LabelNames restricts the issues to those having all the labels with
+	// the given names, matched case insensitively
+	LabelNames []string
+	// MilestoneName restricts the issues to those of the milestones with the
+	// given name, matched case insensitively
+	MilestoneName string
+	// the creation and update time ranges, where the lower bounds are
+	// inclusive, the upper bounds exclusive and 0 means unbounded
+	CreatedAfterUnix  int64
+	CreatedBeforeUnix int64
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
 }
 
 // sortIssuesSession sort an issues-related session based on the provided
@@ -1230,7 +1251,12 @@ func sortIssuesSession(sess *xorm.Session, sortType string) {
 	case "leastcomment":
 		sess.Asc("issue.num_comments")
 	case "priority":
-		sess.Desc("issue.priority")
+		// issues are ordered by the highest priority of their labels first
+		sess.OrderBy("(SELECT COALESCE(MAX(label.priority), 0) FROM issue_label " +
+			"INNER JOIN label ON label.id = issue_label.label_id " +
+			"WHERE issue_label.issue_id = issue.id) DESC").
+			Desc("issue.priority").
+			Desc("issue.created_unix")
 	default:
 		sess.Desc("issue.created_unix")
 	}
@@ -1300,9 +1326,65 @@ func (opts *IssuesOptions) setupSession(sess *xorm.Session) error {
 				In("issue_label.label_id", labelIDs)
 		}
 	}
+
+	for _, name := range opts.LabelNames {
+		sess.In("issue.id", builder.Select("issue_label.issue_id").From("issue_label").
+			Join("INNER", "label", "label.id = issue_label.label_id").
+			Where(builder.Expr("LOWER(label.name) = ?", strings.ToLower(name))))
+	}
+
+	if len(opts.MilestoneName) > 0 {
+		sess.In("issue.milestone_id", builder.Select("id").From("milestone").
+			Where(builder.Expr("LOWER(name) = ?", strings.ToLower(opts.MilestoneName))))
+	}
+
+	if opts.CreatedAfterUnix > 0 {
+		sess.And("issue.created_unix >= ?", opts.CreatedAfterUnix)
+	}
+	if opts.CreatedBeforeUnix > 0 {
+		sess.And("issue.created_unix < ?", opts.CreatedBeforeUnix)
+	}
+	if opts.UpdatedAfterUnix > 0 {
+		sess.And("issue.updated_unix >= ?", opts.UpdatedAfterUnix)
+	}
+	if opts.UpdatedBeforeUnix > 0 {
+		sess.And("issue.updated_unix < ?", opts.UpdatedBeforeUnix)
+	}
 	return nil
 }
 
+// CountIssues returns the number of issues matching the options, ignoring
+// the pagination
+func CountIssues(opts *IssuesOptions) (int64, error) {
+	sess := x.NewSession()
+	defer sess.Close()
+
+	countOpts := *opts
+	countOpts.Page = 0
+	countOpts.PageSize = 0
+	if err := countOpts.setupSession(sess); err != nil {
+		return 0, err
+	}
+	return sess.Count(new(Issue))
+}
+
+// GetIssueStatsByIssuesOptions returns the numbers of open and closed issues
+// matching the options, whatever their IsClosed option
+func GetIssueStatsByIssuesOptions(opts *IssuesOptions) (*IssueStats, error) {
+	statsOpts := *opts
+	statsOpts.IsClosed = util.OptionalBoolFalse
+	openCount, err := CountIssues(&statsOpts)
+	if err != nil {
+		return nil, err
+	}
+	statsOpts.IsClosed = util.OptionalBoolTrue
+	closedCount, err := CountIssues(&statsOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &IssueStats{OpenCount: openCount, ClosedCount: closedCount}, nil
+}
+
 // CountIssuesByRepo map from repoID to number of issues matching the options
 func CountIssuesByRepo(opts *IssuesOptions) (map[int64]int64, error) {
 	sess := x.NewSession()
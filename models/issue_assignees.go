@@ -289,7 +289,5 @@ func MakeIDsFromAPIAssigneesToAdd(oneAssignee string, multipleAssignees []string
 	}
 
 	// Get the IDs of all assignees
-	assigneeIDs = GetUserIDsByNames(multipleAssignees)
-
-	return
+	return GetUserIDsByNames(multipleAssignees, true)
 }
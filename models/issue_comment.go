@@ -80,6 +80,12 @@ const (
 	CommentTypeCode
 	// Reviews a pull request by giving general feedback
 	CommentTypeReview
+	// Pull request scheduled to be merged automatically
+	CommentTypeAutoMergeScheduled
+	// Automatic merge of the pull request canceled
+	CommentTypeAutoMergeCanceled
+	// Card added to, moved between columns of or removed from a project board
+	CommentTypeProjectCard
 )
 
 // CommentTag defines comment tag type
@@ -99,6 +105,7 @@ type Comment struct {
 	Type             CommentType
 	PosterID         int64  `xorm:"INDEX"`
 	Poster           *User  `xorm:"-"`
+	OriginalAuthor   string // Name of the poster on the service the comment was migrated from.
 	IssueID          int64  `xorm:"INDEX"`
 	Issue            *Issue `xorm:"-"`
 	LabelID          int64
@@ -115,6 +122,13 @@ type Comment struct {
 	DependentIssueID int64
 	DependentIssue   *Issue `xorm:"-"`
 
+	ProjectBoardID     int64
+	ProjectBoard       *ProjectBoard `xorm:"-"`
+	OldProjectColumnID int64
+	OldProjectColumn   *ProjectColumn `xorm:"-"`
+	ProjectColumnID    int64
+	ProjectColumn      *ProjectColumn `xorm:"-"`
+
 	CommitID        int64
 	Line            int64 // - previous line / + proposed line
 	TreePath        string
@@ -246,6 +260,30 @@ func (c *Comment) APIFormat() *api.Comment {
 	}
 }
 
+// APIFormatCodeComment converts a code Comment to the api.PullReviewComment format
+func (c *Comment) APIFormatCodeComment() *api.PullReviewComment {
+	apiComment := &api.PullReviewComment{
+		ID:           c.ID,
+		Body:         c.Content,
+		Reviewer:     c.Poster.APIFormat(),
+		ReviewID:     c.ReviewID,
+		Created:      c.CreatedUnix.AsTime(),
+		Updated:      c.UpdatedUnix.AsTime(),
+		Path:         c.TreePath,
+		CommitID:     c.CommitSHA,
+		OrigCommitID: c.CommitSHA,
+		DiffHunk:     c.Patch,
+		HTMLURL:      c.HTMLURL(),
+		HTMLPullURL:  c.PRURL(),
+	}
+	if c.Line < 0 {
+		apiComment.OldLineNum = c.UnsignedLine()
+	} else {
+		apiComment.LineNum = c.UnsignedLine()
+	}
+	return apiComment
+}
+
 // CommentHashTag returns unique hash tag for comment id.
 func CommentHashTag(id int64) string {
 	return fmt.Sprintf("issuecomment-%d", id)
@@ -301,6 +339,41 @@ func (c *Comment) LoadMilestone() error {
 	return nil
 }
 
+// LoadProjectColumns if comment.Type is CommentTypeProjectCard, then load
+// the project board and its columns
+func (c *Comment) LoadProjectColumns() error {
+	if c.ProjectBoardID > 0 {
+		var board ProjectBoard
+		has, err := x.ID(c.ProjectBoardID).Get(&board)
+		if err != nil {
+			return err
+		} else if has {
+			c.ProjectBoard = &board
+		}
+	}
+
+	if c.OldProjectColumnID > 0 {
+		var oldColumn ProjectColumn
+		has, err := x.ID(c.OldProjectColumnID).Get(&oldColumn)
+		if err != nil {
+			return err
+		} else if has {
+			c.OldProjectColumn = &oldColumn
+		}
+	}
+
+	if c.ProjectColumnID > 0 {
+		var column ProjectColumn
+		has, err := x.ID(c.ProjectColumnID).Get(&column)
+		if err != nil {
+			return err
+		} else if has {
+			c.ProjectColumn = &column
+		}
+	}
+	return nil
+}
+
 // LoadPoster loads comment poster
 func (c *Comment) LoadPoster() error {
 	if c.PosterID <= 0 || c.Poster != nil {
@@ -506,25 +579,28 @@ func createComment(e *xorm.Session, opts *CreateCommentOptions) (_ *Comment, err
 	}
 
 	comment := &Comment{
-		Type:             opts.Type,
-		PosterID:         opts.Doer.ID,
-		Poster:           opts.Doer,
-		IssueID:          opts.Issue.ID,
-		LabelID:          LabelID,
-		OldMilestoneID:   opts.OldMilestoneID,
-		MilestoneID:      opts.MilestoneID,
-		RemovedAssignee:  opts.RemovedAssignee,
-		AssigneeID:       opts.AssigneeID,
-		CommitID:         opts.CommitID,
-		CommitSHA:        opts.CommitSHA,
-		Line:             opts.LineNum,
-		Content:          opts.Content,
-		OldTitle:         opts.OldTitle,
-		NewTitle:         opts.NewTitle,
-		DependentIssueID: opts.DependentIssueID,
-		TreePath:         opts.TreePath,
-		ReviewID:         opts.ReviewID,
-		Patch:            opts.Patch,
+		Type:               opts.Type,
+		PosterID:           opts.Doer.ID,
+		Poster:             opts.Doer,
+		IssueID:            opts.Issue.ID,
+		LabelID:            LabelID,
+		OldMilestoneID:     opts.OldMilestoneID,
+		MilestoneID:        opts.MilestoneID,
+		RemovedAssignee:    opts.RemovedAssignee,
+		AssigneeID:         opts.AssigneeID,
+		CommitID:           opts.CommitID,
+		CommitSHA:          opts.CommitSHA,
+		Line:               opts.LineNum,
+		Content:            opts.Content,
+		OldTitle:           opts.OldTitle,
+		NewTitle:           opts.NewTitle,
+		DependentIssueID:   opts.DependentIssueID,
+		ProjectBoardID:     opts.ProjectBoardID,
+		OldProjectColumnID: opts.OldProjectColumnID,
+		ProjectColumnID:    opts.ProjectColumnID,
+		TreePath:           opts.TreePath,
+		ReviewID:           opts.ReviewID,
+		Patch:              opts.Patch,
 	}
 	if _, err = e.Insert(comment); err != nil {
 		return nil, err
@@ -733,6 +809,22 @@ func createChangeTitleComment(e *xorm.Session, doer *User, repo *Repository, iss
 	})
 }
 
+func createProjectCardComment(e *xorm.Session, doer *User, issue *Issue, boardID, oldColumnID, columnID int64) (*Comment, error) {
+	if err := issue.loadRepo(e); err != nil {
+		return nil, err
+	}
+
+	return createComment(e, &CreateCommentOptions{
+		Type:               CommentTypeProjectCard,
+		Doer:               doer,
+		Repo:               issue.Repo,
+		Issue:              issue,
+		ProjectBoardID:     boardID,
+		OldProjectColumnID: oldColumnID,
+		ProjectColumnID:    columnID,
+	})
+}
+
 func createDeleteBranchComment(e *xorm.Session, doer *User, repo *Repository, issue *Issue, branchName string) (*Comment, error) {
 	return createComment(e, &CreateCommentOptions{
 		Type:      CommentTypeDeleteBranch,
@@ -784,21 +876,24 @@ type CreateCommentOptions struct {
 	Issue *Issue
 	Label *Label
 
-	DependentIssueID int64
-	OldMilestoneID   int64
-	MilestoneID      int64
-	AssigneeID       int64
-	RemovedAssignee  bool
-	OldTitle         string
-	NewTitle         string
-	CommitID         int64
-	CommitSHA        string
-	Patch            string
-	LineNum          int64
-	TreePath         string
-	ReviewID         int64
-	Content          string
-	Attachments      []string // UUIDs of attachments
+	DependentIssueID   int64
+	OldMilestoneID     int64
+	MilestoneID        int64
+	AssigneeID         int64
+	RemovedAssignee    bool
+	OldTitle           string
+	NewTitle           string
+	ProjectBoardID     int64
+	OldProjectColumnID int64
+	ProjectColumnID    int64
+	CommitID           int64
+	CommitSHA          string
+	Patch              string
+	LineNum            int64
+	TreePath           string
+	ReviewID           int64
+	Content            string
+	Attachments        []string // UUIDs of attachments
 }
 
 // CreateComment creates comment of issue or commit.
@@ -9,23 +9,28 @@ import (
 
 	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
+
+	"github.com/Unknwon/com"
 )
 
 // issueIndexerUpdateQueue queue of issue ids to be updated
-var issueIndexerUpdateQueue chan int64
+var issueIndexerUpdateQueue = queue.NewNamed("issue_indexer", setting.Indexer.UpdateQueueLength)
 
 // InitIssueIndexer initialize issue indexer
 func InitIssueIndexer() {
 	indexer.InitIssueIndexer(populateIssueIndexer)
-	issueIndexerUpdateQueue = make(chan int64, setting.Indexer.UpdateQueueLength)
-	go processIssueIndexerUpdateQueue()
+	if err := issueIndexerUpdateQueue.Configure(setting.GetQueueSettings("issue_indexer", setting.Indexer.UpdateQueueLength)); err != nil {
+		log.Fatal(4, "Failed to create issue indexer queue: %v", err)
+	}
+	issueIndexerUpdateQueue.Run(processIssueIndexerUpdate)
 }
 
 // populateIssueIndexer populate the issue indexer with issue data
 func populateIssueIndexer() error {
-	batch := indexer.IssueIndexerBatch()
+	batch := indexer.NewIssueIndexerBatch()
 	for page := 1; ; page++ {
 		repos, _, err := SearchRepositoryByName(&SearchRepoOptions{
 			Page:        page,
@@ -53,7 +58,7 @@ func populateIssueIndexer() error {
 				return err
 			}
 			for _, issue := range issues {
-				if err := issue.update().AddToFlushingBatch(batch); err != nil {
+				if err := batch.Add(issue.indexerData()); err != nil {
 					return err
 				}
 			}
@@ -61,44 +66,52 @@ func populateIssueIndexer() error {
 	}
 }
 
-func processIssueIndexerUpdateQueue() {
-	batch := indexer.IssueIndexerBatch()
-	for {
-		var issueID int64
-		select {
-		case issueID = <-issueIndexerUpdateQueue:
-		default:
-			// flush whatever updates we currently have, since we
-			// might have to wait a while
-			if err := batch.Flush(); err != nil {
-				log.Error(4, "IssueIndexer: %v", err)
-			}
-			issueID = <-issueIndexerUpdateQueue
-		}
-		issue, err := GetIssueByID(issueID)
-		if err != nil {
-			log.Error(4, "GetIssueByID: %v", err)
-		} else if err = issue.update().AddToFlushingBatch(batch); err != nil {
-			log.Error(4, "IssueIndexer: %v", err)
+// ReindexIssues empties the issue indexer and populates it again in the
+// background with all the issues
+func ReindexIssues() error {
+	if err := indexer.ResetIssueIndexer(); err != nil {
+		return fmt.Errorf("ResetIssueIndexer: %v", err)
+	}
+	go func() {
+		log.Info("Reindexing all the issues")
+		if err := populateIssueIndexer(); err != nil {
+			log.Error(4, "populateIssueIndexer: %v", err)
+			return
 		}
+		log.Info("Done reindexing all the issues")
+	}()
+	return nil
+}
+
+// processIssueIndexerUpdate updates the issue in the indexer, the update is
+// flushed right away so that it is not lost once it left the queue
+func processIssueIndexerUpdate(issueID string) {
+	issue, err := GetIssueByID(com.StrTo(issueID).MustInt64())
+	if err != nil {
+		log.Error(4, "GetIssueByID: %v", err)
+		return
+	}
+	batch := indexer.NewIssueIndexerBatch()
+	if err = batch.Add(issue.indexerData()); err != nil {
+		log.Error(4, "IssueIndexer: %v", err)
+	} else if err = batch.Flush(); err != nil {
+		log.Error(4, "IssueIndexer: %v", err)
 	}
 }
 
-func (issue *Issue) update() indexer.IssueIndexerUpdate {
+func (issue *Issue) indexerData() *indexer.IssueIndexerData {
 	comments := make([]string, 0, 5)
 	for _, comment := range issue.Comments {
 		if comment.Type == CommentTypeComment {
 			comments = append(comments, comment.Content)
 		}
 	}
-	return indexer.IssueIndexerUpdate{
-		IssueID: issue.ID,
-		Data: &indexer.IssueIndexerData{
-			RepoID:   issue.RepoID,
-			Title:    issue.Title,
-			Content:  issue.Content,
-			Comments: comments,
-		},
+	return &indexer.IssueIndexerData{
+		ID:       issue.ID,
+		RepoID:   issue.RepoID,
+		Title:    issue.Title,
+		Content:  issue.Content,
+		Comments: comments,
 	}
 }
 
@@ -122,11 +135,5 @@ func UpdateIssueIndexerCols(issueID int64, cols ...string) {
 
 // UpdateIssueIndexer add/update an issue to the issue indexer
 func UpdateIssueIndexer(issueID int64) {
-	select {
-	case issueIndexerUpdateQueue <- issueID:
-	default:
-		go func() {
-			issueIndexerUpdateQueue <- issueID
-		}()
-	}
+	go issueIndexerUpdateQueue.Add(issueID)
 }
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/go-xorm/builder"
 	"github.com/go-xorm/xorm"
 
 	api "code.gitea.io/sdk/gitea"
@@ -58,13 +59,19 @@ func GetLabelTemplateFile(name string) ([][3]string, error) {
 	return list, nil
 }
 
-// Label represents a label of repository for issues.
+// Label represents a label of repository for issues, or a label of an
+// organization which can be used by the issues of all its repositories.
+// An exclusive label is mutually exclusive on an issue with the other
+// exclusive labels sharing its scope, e.g. "priority/high" and "priority/low".
 type Label struct {
 	ID              int64 `xorm:"pk autoincr"`
 	RepoID          int64 `xorm:"INDEX"`
+	OrgID           int64 `xorm:"INDEX"`
 	Name            string
 	Description     string
 	Color           string `xorm:"VARCHAR(7)"`
+	Exclusive       bool   `xorm:"NOT NULL DEFAULT false"`
+	Priority        int    `xorm:"NOT NULL DEFAULT 0"`
 	NumIssues       int
 	NumClosedIssues int
 	NumOpenIssues   int  `xorm:"-"`
@@ -74,12 +81,41 @@ type Label struct {
 // APIFormat converts a Label to the api.Label format
 func (label *Label) APIFormat() *api.Label {
 	return &api.Label{
-		ID:    label.ID,
-		Name:  label.Name,
-		Color: strings.TrimLeft(label.Color, "#"),
+		ID:        label.ID,
+		Name:      label.Name,
+		Color:     strings.TrimLeft(label.Color, "#"),
+		Exclusive: label.Exclusive,
+		Priority:  label.Priority,
 	}
 }
 
+// ExclusiveScope returns the scope of an exclusive label, the part of its
+// name before the last "/", or an empty string if the label is not exclusive.
+func (label *Label) ExclusiveScope() string {
+	if !label.Exclusive {
+		return ""
+	}
+	i := strings.LastIndex(label.Name, "/")
+	if i <= 0 || i == len(label.Name)-1 {
+		return ""
+	}
+	return label.Name[:i]
+}
+
+// BelongsToOrg returns true if the label is a label of an organization.
+func (label *Label) BelongsToOrg() bool {
+	return label.OrgID > 0
+}
+
+// IsAvailableToRepo returns true if the label can be used by the issues of
+// the repository, being a label of it or of its owner organization.
+func (label *Label) IsAvailableToRepo(repo *Repository) bool {
+	if label.BelongsToOrg() {
+		return label.OrgID == repo.OwnerID
+	}
+	return label.RepoID == repo.ID
+}
+
 // CalOpenIssues calculates the open issues of label.
 func (label *Label) CalOpenIssues() {
 	label.NumOpenIssues = label.NumIssues - label.NumClosedIssues
@@ -198,11 +234,35 @@ func GetLabelsInRepoByIDs(repoID int64, labelIDs []int64) ([]*Label, error) {
 		Find(&labels)
 }
 
-// GetLabelsByRepoID returns all labels that belong to given repository by ID.
-func GetLabelsByRepoID(repoID int64, sortType string) ([]*Label, error) {
-	labels := make([]*Label, 0, 10)
-	sess := x.Where("repo_id = ?", repoID)
+// GetLabelAvailableToRepoByID returns a label by ID which can be used by the
+// issues of the given repository.
+func GetLabelAvailableToRepoByID(repo *Repository, labelID int64) (*Label, error) {
+	l, err := getLabelInRepoByID(x, 0, labelID)
+	if err != nil {
+		return nil, err
+	} else if !l.IsAvailableToRepo(repo) {
+		return nil, ErrLabelNotExist{labelID, repo.ID}
+	}
+	return l, nil
+}
+
+// GetLabelsAvailableToRepoByIDs returns a list of labels by IDs which can be
+// used by the issues of the given repository, it silently ignores the other
+// label IDs.
+func GetLabelsAvailableToRepoByIDs(repo *Repository, labelIDs []int64) ([]*Label, error) {
+	labels := make([]*Label, 0, len(labelIDs))
+	return labels, x.
+		Where(availableLabelsCond(repo)).
+		In("id", labelIDs).
+		Asc("name").
+		Find(&labels)
+}
+
+func availableLabelsCond(repo *Repository) builder.Cond {
+	return builder.Eq{"repo_id": repo.ID}.Or(builder.Eq{"org_id": repo.OwnerID})
+}
 
+func sortLabels(sess *xorm.Session, sortType string) {
 	switch sortType {
 	case "reversealphabetically":
 		sess.Desc("name")
@@ -210,10 +270,82 @@ func GetLabelsByRepoID(repoID int64, sortType string) ([]*Label, error) {
 		sess.Asc("num_issues")
 	case "mostissues":
 		sess.Desc("num_issues")
+	case "priority":
+		sess.Desc("priority").Asc("name")
 	default:
 		sess.Asc("name")
 	}
+}
+
+// GetLabelsByRepoID returns all labels that belong to given repository by ID.
+func GetLabelsByRepoID(repoID int64, sortType string) ([]*Label, error) {
+	labels := make([]*Label, 0, 10)
+	sess := x.Where("repo_id = ?", repoID)
+	sortLabels(sess, sortType)
+	return labels, sess.Find(&labels)
+}
+
+// GetLabelsAvailableToRepo returns all labels which can be used by the issues
+// of the given repository, its own labels and the ones of its owner
+// organization.
+func GetLabelsAvailableToRepo(repo *Repository, sortType string) ([]*Label, error) {
+	labels := make([]*Label, 0, 10)
+	sess := x.Where(availableLabelsCond(repo))
+	sortLabels(sess, sortType)
+	return labels, sess.Find(&labels)
+}
+
+// getLabelInOrgByID returns a label by ID in given organization.
+func getLabelInOrgByID(e Engine, orgID, labelID int64) (*Label, error) {
+	if labelID <= 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{labelID, orgID}
+	}
+
+	l := &Label{
+		ID:    labelID,
+		OrgID: orgID,
+	}
+	has, err := e.Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{l.ID, l.OrgID}
+	}
+	return l, nil
+}
+
+// GetLabelInOrgByID returns a label by ID in given organization.
+func GetLabelInOrgByID(orgID, labelID int64) (*Label, error) {
+	return getLabelInOrgByID(x, orgID, labelID)
+}
+
+// GetLabelInOrgByName returns a label by name in given organization.
+func GetLabelInOrgByName(orgID int64, labelName string) (*Label, error) {
+	if len(labelName) == 0 || orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+
+	l := &Label{
+		Name:  labelName,
+		OrgID: orgID,
+	}
+	has, err := x.Get(l)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOrgLabelNotExist{0, l.OrgID}
+	}
+	return l, nil
+}
 
+// GetLabelsByOrgID returns all labels that belong to given organization by ID.
+func GetLabelsByOrgID(orgID int64, sortType string) ([]*Label, error) {
+	if orgID <= 0 {
+		return nil, ErrOrgLabelNotExist{0, orgID}
+	}
+	labels := make([]*Label, 0, 10)
+	sess := x.Where("org_id = ?", orgID)
+	sortLabels(sess, sortType)
 	return labels, sess.Find(&labels)
 }
 
@@ -249,7 +381,23 @@ func DeleteLabel(repoID, labelID int64) error {
 		}
 		return err
 	}
+	return deleteLabel(labelID)
+}
+
+// DeleteOrgLabel delete a label of given organization, removing it from the
+// issues of all its repositories.
+func DeleteOrgLabel(orgID, labelID int64) error {
+	_, err := GetLabelInOrgByID(orgID, labelID)
+	if err != nil {
+		if IsErrOrgLabelNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return deleteLabel(labelID)
+}
 
+func deleteLabel(labelID int64) (err error) {
 	sess := x.NewSession()
 	defer sess.Close()
 	if err = sess.Begin(); err != nil {
@@ -272,6 +420,22 @@ func DeleteLabel(repoID, labelID int64) error {
 	return sess.Commit()
 }
 
+// removeOrgLabelsFromRepo removes the labels of the organization from the
+// issues of the repository, e.g. when it is transferred to another owner.
+func removeOrgLabelsFromRepo(e Engine, orgID, repoID int64) error {
+	if _, err := e.
+		Where(builder.In("label_id", builder.Select("id").From("label").Where(builder.Eq{"org_id": orgID}))).
+		And(builder.In("issue_id", builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID}))).
+		Delete(new(IssueLabel)); err != nil {
+		return err
+	}
+
+	_, err := e.Exec("UPDATE `label` SET num_issues=(SELECT COUNT(*) FROM `issue_label` WHERE label_id=`label`.id), "+
+		"num_closed_issues=(SELECT COUNT(*) FROM `issue_label` INNER JOIN `issue` ON `issue`.id=`issue_label`.issue_id "+
+		"WHERE `issue_label`.label_id=`label`.id AND `issue`.is_closed=?) WHERE org_id=?", true, orgID)
+	return err
+}
+
 // .___                            .____          ___.          .__
 // |   | ______ ________ __   ____ |    |   _____ \_ |__   ____ |  |
 // |   |/  ___//  ___/  |  \_/ __ \|    |   \__  \ | __ \_/ __ \|  |
@@ -296,7 +460,34 @@ func HasIssueLabel(issueID, labelID int64) bool {
 	return hasIssueLabel(x, issueID, labelID)
 }
 
+// removeExclusiveLabels removes from the issue the other exclusive labels
+// sharing the scope of the given label.
+func removeExclusiveLabels(e *xorm.Session, issue *Issue, label *Label, doer *User) error {
+	scope := label.ExclusiveScope()
+	if len(scope) == 0 {
+		return nil
+	}
+
+	labels, err := getLabelsByIssueID(e, issue.ID)
+	if err != nil {
+		return fmt.Errorf("getLabelsByIssueID: %v", err)
+	}
+	for _, l := range labels {
+		if l.ID == label.ID || l.ExclusiveScope() != scope {
+			continue
+		}
+		if err = deleteIssueLabel(e, issue, l, doer); err != nil {
+			return fmt.Errorf("deleteIssueLabel: %v", err)
+		}
+	}
+	return nil
+}
+
 func newIssueLabel(e *xorm.Session, issue *Issue, label *Label, doer *User) (err error) {
+	if err = removeExclusiveLabels(e, issue, label, doer); err != nil {
+		return err
+	}
+
 	if _, err = e.Insert(&IssueLabel{
 		IssueID: issue.ID,
 		LabelID: label.ID,
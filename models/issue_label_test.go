@@ -25,6 +25,24 @@ func TestLabel_APIFormat(t *testing.T) {
 	}, *label.APIFormat())
 }
 
+func TestLabel_ExclusiveScope(t *testing.T) {
+	for _, test := range []struct {
+		Name          string
+		Exclusive     bool
+		ExpectedScope string
+	}{
+		{"priority/high", true, "priority"},
+		{"kind/bug/ui", true, "kind/bug"},
+		{"priority/high", false, ""},
+		{"bug", true, ""},
+		{"/bug", true, ""},
+		{"priority/", true, ""},
+	} {
+		label := &Label{Name: test.Name, Exclusive: test.Exclusive}
+		assert.Equal(t, test.ExpectedScope, label.ExclusiveScope(), test.Name)
+	}
+}
+
 func TestLabel_CalOpenIssues(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	label := AssertExistsAndLoadBean(t, &Label{ID: 1}).(*Label)
@@ -120,6 +138,95 @@ func TestGetLabelsByRepoID(t *testing.T) {
 	testSuccess(1, "default", []int64{1, 2})
 }
 
+func TestGetLabelInOrgByName(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label, err := GetLabelInOrgByName(3, "orglabel3")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+	assert.Equal(t, "orglabel3", label.Name)
+
+	_, err = GetLabelInOrgByName(3, "")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByName(0, "orglabel3")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByName(NonexistentID, "nonexistent")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestGetLabelInOrgByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label, err := GetLabelInOrgByID(3, 3)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, label.ID)
+
+	_, err = GetLabelInOrgByID(3, -1)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	_, err = GetLabelInOrgByID(0, 3)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+
+	// repository labels are not organization labels
+	_, err = GetLabelInOrgByID(3, 1)
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestGetLabelsByOrgID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	labels, err := GetLabelsByOrgID(3, "reversealphabetically")
+	assert.NoError(t, err)
+	if assert.Len(t, labels, 2) {
+		assert.EqualValues(t, 4, labels[0].ID)
+		assert.EqualValues(t, 3, labels[1].ID)
+	}
+
+	labels, err = GetLabelsByOrgID(NonexistentID, "")
+	assert.NoError(t, err)
+	assert.Len(t, labels, 0)
+
+	_, err = GetLabelsByOrgID(0, "")
+	assert.True(t, IsErrOrgLabelNotExist(err))
+}
+
+func TestGetLabelsAvailableToRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	testSuccess := func(repoID int64, expectedLabelIDs []int64) {
+		repo := AssertExistsAndLoadBean(t, &Repository{ID: repoID}).(*Repository)
+		labels, err := GetLabelsAvailableToRepo(repo, "")
+		assert.NoError(t, err)
+		if assert.Len(t, labels, len(expectedLabelIDs)) {
+			for i, label := range labels {
+				assert.EqualValues(t, expectedLabelIDs[i], label.ID)
+			}
+		}
+
+		labels, err = GetLabelsAvailableToRepoByIDs(repo, []int64{1, 2, 3, 4, NonexistentID})
+		assert.NoError(t, err)
+		assert.Len(t, labels, len(expectedLabelIDs))
+	}
+	testSuccess(1, []int64{1, 2})
+	testSuccess(3, []int64{3, 4})
+}
+
+func TestGetLabelAvailableToRepoByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	repo1 := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repo3 := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+
+	label, err := GetLabelAvailableToRepoByID(repo3, 3)
+	assert.NoError(t, err)
+	assert.True(t, label.BelongsToOrg())
+	assert.True(t, label.IsAvailableToRepo(repo3))
+	assert.False(t, label.IsAvailableToRepo(repo1))
+
+	_, err = GetLabelAvailableToRepoByID(repo1, 3)
+	assert.True(t, IsErrLabelNotExist(err))
+
+	_, err = GetLabelAvailableToRepoByID(repo3, 1)
+	assert.True(t, IsErrLabelNotExist(err))
+}
+
 func TestGetLabelsByIssueID(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	labels, err := GetLabelsByIssueID(1)
@@ -157,6 +264,42 @@ func TestDeleteLabel(t *testing.T) {
 	CheckConsistencyFor(t, &Label{}, &Repository{})
 }
 
+func TestDeleteOrgLabel(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue)
+	label := AssertExistsAndLoadBean(t, &Label{ID: 3}).(*Label)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	assert.NoError(t, NewIssueLabel(issue, label, doer))
+
+	// only the labels of the organization can be deleted
+	assert.NoError(t, DeleteOrgLabel(3, 1))
+	AssertExistsAndLoadBean(t, &Label{ID: 1})
+
+	assert.NoError(t, DeleteOrgLabel(label.OrgID, label.ID))
+	AssertNotExistsBean(t, &Label{ID: label.ID})
+	AssertNotExistsBean(t, &IssueLabel{LabelID: label.ID})
+
+	assert.NoError(t, DeleteOrgLabel(NonexistentID, NonexistentID))
+	CheckConsistencyFor(t, &Label{})
+}
+
+func TestRemoveOrgLabelsFromRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 6}).(*Issue)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	for _, id := range []int64{3, 4} {
+		label := AssertExistsAndLoadBean(t, &Label{ID: id}).(*Label)
+		assert.NoError(t, NewIssueLabel(issue, label, doer))
+	}
+	CheckConsistencyFor(t, &Label{})
+
+	assert.NoError(t, removeOrgLabelsFromRepo(x, 3, 3))
+	AssertNotExistsBean(t, &IssueLabel{IssueID: issue.ID})
+	label := AssertExistsAndLoadBean(t, &Label{ID: 3}).(*Label)
+	assert.EqualValues(t, 0, label.NumIssues)
+	CheckConsistencyFor(t, &Label{})
+}
+
 func TestHasIssueLabel(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	assert.True(t, HasIssueLabel(1, 1))
@@ -188,6 +331,35 @@ func TestNewIssueLabel(t *testing.T) {
 	CheckConsistencyFor(t, &Issue{}, &Label{})
 }
 
+func TestNewIssueLabel_Exclusive(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 1}).(*Issue)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	high := &Label{RepoID: 1, Name: "priority/high", Color: "#ee0701", Exclusive: true}
+	low := &Label{RepoID: 1, Name: "priority/low", Color: "#00aabb", Exclusive: true}
+	other := &Label{RepoID: 1, Name: "priority/other", Color: "#00aabb"}
+	assert.NoError(t, NewLabels(high, low, other))
+
+	assert.NoError(t, NewIssueLabels(issue, []*Label{high, other}, doer))
+	AssertExistsAndLoadBean(t, &IssueLabel{IssueID: issue.ID, LabelID: high.ID})
+
+	// adding an exclusive label removes the other exclusive labels of its scope
+	assert.NoError(t, NewIssueLabel(issue, low, doer))
+	AssertExistsAndLoadBean(t, &IssueLabel{IssueID: issue.ID, LabelID: low.ID})
+	AssertNotExistsBean(t, &IssueLabel{IssueID: issue.ID, LabelID: high.ID})
+	AssertExistsAndLoadBean(t, &IssueLabel{IssueID: issue.ID, LabelID: other.ID})
+	AssertExistsAndLoadBean(t, &IssueLabel{IssueID: issue.ID, LabelID: 1})
+	AssertExistsAndLoadBean(t, &Comment{
+		Type:     CommentTypeLabel,
+		PosterID: doer.ID,
+		IssueID:  issue.ID,
+		LabelID:  high.ID,
+		Content:  "",
+	})
+	CheckConsistencyFor(t, &Issue{}, &Label{})
+}
+
 func TestNewIssueLabels(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	label1 := AssertExistsAndLoadBean(t, &Label{ID: 1}).(*Label)
@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	"code.gitea.io/gitea/modules/util"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -199,6 +201,33 @@ func TestIssues(t *testing.T) {
 			},
 			[]int64{5, 2, 1},
 		},
+		{
+			IssuesOptions{
+				LabelNames: []string{"LABEL1"},
+				SortType:   "oldest",
+			},
+			[]int64{1, 2},
+		},
+		{
+			IssuesOptions{
+				LabelNames: []string{"label1", "label2"},
+			},
+			[]int64{},
+		},
+		{
+			IssuesOptions{
+				MilestoneName: "milestone1",
+			},
+			[]int64{2},
+		},
+		{
+			IssuesOptions{
+				CreatedAfterUnix:  946684830,
+				CreatedBeforeUnix: 946684850,
+				SortType:          "oldest",
+			},
+			[]int64{4, 5},
+		},
 	} {
 		issues, err := Issues(&test.Opts)
 		assert.NoError(t, err)
@@ -210,6 +239,41 @@ func TestIssues(t *testing.T) {
 	}
 }
 
+func TestIssues_SortByLabelPriority(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	label := AssertExistsAndLoadBean(t, &Label{ID: 2}).(*Label)
+	label.Priority = 10
+	assert.NoError(t, UpdateLabel(label))
+
+	issues, err := Issues(&IssuesOptions{
+		RepoIDs:  []int64{1},
+		SortType: "priority",
+	})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, issues) {
+		assert.EqualValues(t, 5, issues[0].ID)
+	}
+}
+
+func TestCountIssues(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	count, err := CountIssues(&IssuesOptions{
+		RepoIDs:  []int64{1},
+		Page:     1,
+		PageSize: 1,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, count)
+
+	stats, err := GetIssueStatsByIssuesOptions(&IssuesOptions{
+		RepoIDs:  []int64{1},
+		IsClosed: util.OptionalBoolTrue,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, stats.OpenCount)
+	assert.EqualValues(t, 1, stats.ClosedCount)
+}
+
 func TestGetUserIssueStats(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 	for _, test := range []struct {
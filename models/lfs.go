@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"path"
 
 	"code.gitea.io/gitea/modules/util"
 )
@@ -16,6 +17,15 @@ type LFSMetaObject struct {
 	CreatedUnix  util.TimeStamp `xorm:"created"`
 }
 
+// RelativePath returns the relative path of the lfs object in the lfs storage
+func (m *LFSMetaObject) RelativePath() string {
+	if len(m.Oid) < 5 {
+		return m.Oid
+	}
+
+	return path.Join(m.Oid[0:2], m.Oid[2:4], m.Oid[4:])
+}
+
 // LFSTokenResponse defines the JSON structure in which the JWT token is stored.
 // This structure is fetched via SSH and passed by the Git LFS client to the server
 // endpoint for authorization.
@@ -30,6 +30,7 @@ const (
 	mailIssueMention base.TplName = "issue/mention"
 
 	mailNotifyCollaborator base.TplName = "notify/collaborator"
+	mailWebhookDisabled    base.TplName = "notify/webhook_disabled"
 )
 
 var templates *template.Template
@@ -141,6 +142,42 @@ func SendCollaboratorMail(u, doer *User, repo *Repository) {
 	mailer.SendAsync(msg)
 }
 
+// SendWebhookDisabledMail notifies the users that the webhook of the repository
+// or organization name has been disabled after too many failed deliveries
+func SendWebhookDisabledMail(users []*User, w *Webhook, name, link string) {
+	tos := make([]string, 0, len(users))
+	for _, u := range users {
+		if u.IsActive && !u.ProhibitLogin {
+			tos = append(tos, u.Email)
+		}
+	}
+	if len(tos) == 0 {
+		return
+	}
+
+	subject := fmt.Sprintf("A webhook of %s has been disabled", name)
+
+	data := map[string]interface{}{
+		"Subject":      subject,
+		"Name":         name,
+		"URL":          w.URL,
+		"FailureCount": w.FailureCount,
+		"Link":         link,
+	}
+
+	var content bytes.Buffer
+
+	if err := templates.ExecuteTemplate(&content, string(mailWebhookDisabled), data); err != nil {
+		log.Error(3, "Template: %v", err)
+		return
+	}
+
+	msg := mailer.NewMessage(tos, subject, content.String())
+	msg.Info = fmt.Sprintf("HookID: %d, webhook disabled", w.ID)
+
+	mailer.SendAsync(msg)
+}
+
 func composeTplData(subject, body, link string) map[string]interface{} {
 	data := make(map[string]interface{}, 10)
 	data["Subject"] = subject
@@ -0,0 +1,220 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-xorm/xorm"
+)
+
+// InsertMilestones inserts the milestones of a migrated repository keeping
+// their timestamps
+func InsertMilestones(ms ...*Milestone) (err error) {
+	if len(ms) == 0 {
+		return nil
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for _, m := range ms {
+		if _, err = sess.NoAutoTime().Insert(m); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+func insertMigratedIssue(sess *xorm.Session, issue *Issue) error {
+	if _, err := sess.NoAutoTime().Insert(issue); err != nil {
+		return err
+	}
+
+	issueLabels := make([]IssueLabel, 0, len(issue.Labels))
+	for _, label := range issue.Labels {
+		issueLabels = append(issueLabels, IssueLabel{
+			IssueID: issue.ID,
+			LabelID: label.ID,
+		})
+	}
+	if len(issueLabels) > 0 {
+		if _, err := sess.Insert(issueLabels); err != nil {
+			return err
+		}
+	}
+
+	for _, reaction := range issue.Reactions {
+		reaction.IssueID = issue.ID
+	}
+	return insertMigratedReactions(sess, issue.Reactions)
+}
+
+func insertMigratedReactions(sess *xorm.Session, reactions []*Reaction) error {
+	for _, reaction := range reactions {
+		if _, err := sess.NoAutoTime().Insert(reaction); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertIssues inserts the issues of a migrated repository with their labels
+// and reactions, the labels must have been inserted before
+func InsertIssues(issues ...*Issue) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		if err = insertMigratedIssue(sess, issue); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// InsertIssueComments inserts the comments of migrated issues with their reactions
+func InsertIssueComments(comments []*Comment) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for _, comment := range comments {
+		if _, err = sess.NoAutoTime().Insert(comment); err != nil {
+			return err
+		}
+		for _, reaction := range comment.Reactions {
+			reaction.IssueID = comment.IssueID
+			reaction.CommentID = comment.ID
+		}
+		if err = insertMigratedReactions(sess, comment.Reactions); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// InsertPullRequests inserts the pull requests of a migrated repository
+// together with their issues
+func InsertPullRequests(prs ...*PullRequest) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		if err = insertMigratedIssue(sess, pr.Issue); err != nil {
+			return err
+		}
+		pr.IssueID = pr.Issue.ID
+		pr.Index = pr.Issue.Index
+		if _, err = sess.NoAutoTime().Insert(pr); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// InsertReleases inserts the releases of a migrated repository with their
+// attachments, whose files must have been saved before. The releases which
+// were created for the tags of the repository are replaced.
+func InsertReleases(rels ...*Release) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for _, rel := range rels {
+		rel.LowerTagName = strings.ToLower(rel.TagName)
+		existing := &Release{RepoID: rel.RepoID, LowerTagName: rel.LowerTagName}
+		has, err := sess.Get(existing)
+		if err != nil {
+			return err
+		} else if has {
+			rel.ID = existing.ID
+			if _, err = sess.ID(rel.ID).AllCols().Update(rel); err != nil {
+				return err
+			}
+		} else if _, err = sess.Insert(rel); err != nil {
+			return err
+		}
+
+		for _, attach := range rel.Attachments {
+			attach.ReleaseID = rel.ID
+			if _, err = sess.NoAutoTime().Insert(attach); err != nil {
+				return err
+			}
+		}
+	}
+	return sess.Commit()
+}
+
+// UpdateRepoIssueCounters recalculates the issue, pull request, comment,
+// label and milestone counters of a repository, it is used after issues
+// have been inserted without updating them
+func UpdateRepoIssueCounters(repoID int64) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	for _, stmt := range []struct {
+		sql  string
+		args []interface{}
+	}{
+		{"UPDATE `issue` SET num_comments=(SELECT COUNT(*) FROM `comment` WHERE issue_id=`issue`.id AND type=?) WHERE repo_id=?", []interface{}{CommentTypeComment, repoID}},
+		{"UPDATE `repository` SET num_issues=(SELECT COUNT(*) FROM `issue` WHERE repo_id=? AND is_pull=?) WHERE id=?", []interface{}{repoID, false, repoID}},
+		{"UPDATE `repository` SET num_closed_issues=(SELECT COUNT(*) FROM `issue` WHERE repo_id=? AND is_pull=? AND is_closed=?) WHERE id=?", []interface{}{repoID, false, true, repoID}},
+		{"UPDATE `repository` SET num_pulls=(SELECT COUNT(*) FROM `issue` WHERE repo_id=? AND is_pull=?) WHERE id=?", []interface{}{repoID, true, repoID}},
+		{"UPDATE `repository` SET num_closed_pulls=(SELECT COUNT(*) FROM `issue` WHERE repo_id=? AND is_pull=? AND is_closed=?) WHERE id=?", []interface{}{repoID, true, true, repoID}},
+		{"UPDATE `repository` SET num_milestones=(SELECT COUNT(*) FROM `milestone` WHERE repo_id=?) WHERE id=?", []interface{}{repoID, repoID}},
+		{"UPDATE `repository` SET num_closed_milestones=(SELECT COUNT(*) FROM `milestone` WHERE repo_id=? AND is_closed=?) WHERE id=?", []interface{}{repoID, true, repoID}},
+		{"UPDATE `label` SET num_issues=(SELECT COUNT(*) FROM `issue_label` WHERE label_id=`label`.id) WHERE repo_id=?", []interface{}{repoID}},
+		{"UPDATE `label` SET num_closed_issues=(SELECT COUNT(*) FROM `issue_label` INNER JOIN `issue` ON `issue`.id=`issue_label`.issue_id WHERE `issue_label`.label_id=`label`.id AND `issue`.is_closed=?) WHERE repo_id=?", []interface{}{true, repoID}},
+	} {
+		if _, err = sess.Exec(append([]interface{}{stmt.sql}, stmt.args...)...); err != nil {
+			return fmt.Errorf("%s: %v", stmt.sql, err)
+		}
+	}
+
+	milestones := make([]*Milestone, 0, 10)
+	if err = sess.Where("repo_id=?", repoID).Find(&milestones); err != nil {
+		return err
+	}
+	for _, m := range milestones {
+		if m.NumIssues, err = countRepoMilestoneIssues(sess, m.ID, false); err != nil {
+			return err
+		}
+		if m.NumClosedIssues, err = countRepoMilestoneIssues(sess, m.ID, true); err != nil {
+			return err
+		}
+		// the completeness is calculated by BeforeUpdate
+		if _, err = sess.ID(m.ID).Cols("num_issues", "num_closed_issues", "completeness").Update(m); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+func countRepoMilestoneIssues(e Engine, milestoneID int64, onlyClosed bool) (int, error) {
+	sess := e.Where("milestone_id=?", milestoneID)
+	if onlyClosed {
+		sess.And("is_closed=?", true)
+	}
+	count, err := sess.Count(new(Issue))
+	return int(count), err
+}
@@ -210,6 +210,34 @@ var migrations = []Migration{
 	NewMigration("add theme to users", addUserDefaultTheme),
 	// v78 -> v79
 	NewMigration("rename repo is_bare to repo is_empty", renameRepoIsBareToIsEmpty),
+	// v79 -> v80
+	NewMigration("add dismissed to reviews", addReviewDismissed),
+	// v80 -> v81
+	NewMigration("add original author to issues and comments", addOriginalAuthorToIssuesAndComments),
+	// v81 -> v82
+	NewMigration("add pull auto merge table", addPullAutoMerge),
+	// v82 -> v83
+	NewMigration("add status check columns for protected branches", addStatusCheckColumnsForProtectedBranches),
+	// v83 -> v84
+	NewMigration("add scope, repository and expiry to access tokens", addScopeAndExpiryToAccessTokens),
+	// v84 -> v85
+	NewMigration("add oauth2 application tables", addOAuth2ApplicationTables),
+	// v85 -> v86
+	NewMigration("add ref to repo indexer status and indexed refs to repositories", addRefToRepoIndexerStatus),
+	// v86 -> v87
+	NewMigration("add secret table", addSecretTable),
+	// v87 -> v88
+	NewMigration("add delivery attempts to hook tasks and failure count to webhooks", addWebhookDeliveryAttempts),
+	// v88 -> v89
+	NewMigration("add push mirror table", addPushMirrorTable),
+	// v89 -> v90
+	NewMigration("add trigger token and sync history to mirrors", addMirrorTriggerTokenAndSyncHistory),
+	// v90 -> v91
+	NewMigration("add org_id to label", addOrgIDToLabel),
+	// v91 -> v92
+	NewMigration("add exclusive and priority to label", addExclusiveAndPriorityToLabel),
+	// v92 -> v93
+	NewMigration("add project boards", addProjectBoards),
 }
 
 // Migrate database to current version
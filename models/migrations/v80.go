@@ -0,0 +1,23 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"github.com/go-xorm/xorm"
+)
+
+func addOriginalAuthorToIssuesAndComments(x *xorm.Engine) error {
+	type Issue struct {
+		OriginalAuthor string
+	}
+	if err := x.Sync2(new(Issue)); err != nil {
+		return err
+	}
+
+	type Comment struct {
+		OriginalAuthor string
+	}
+	return x.Sync2(new(Comment))
+}
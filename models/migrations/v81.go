@@ -0,0 +1,23 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addPullAutoMerge(x *xorm.Engine) error {
+	type PullAutoMerge struct {
+		ID          int64          `xorm:"pk autoincr"`
+		PullID      int64          `xorm:"UNIQUE"`
+		DoerID      int64          `xorm:"NOT NULL"`
+		MergeStyle  string         `xorm:"VARCHAR(30)"`
+		Message     string         `xorm:"LONGTEXT"`
+		CreatedUnix util.TimeStamp `xorm:"created"`
+	}
+	return x.Sync2(new(PullAutoMerge))
+}
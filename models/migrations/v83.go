@@ -0,0 +1,20 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addScopeAndExpiryToAccessTokens(x *xorm.Engine) error {
+	type AccessToken struct {
+		Scope       string         `xorm:"VARCHAR(255) NOT NULL DEFAULT 'all'"`
+		RepoID      int64          `xorm:"INDEX NOT NULL DEFAULT 0"`
+		ExpiresUnix util.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
+	}
+	return x.Sync2(new(AccessToken))
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+// OAuth2ApplicationV84 describes the oauth2 application table as of v84
+type OAuth2ApplicationV84 struct {
+	ID                 int64 `xorm:"pk autoincr"`
+	UID                int64 `xorm:"INDEX"`
+	Name               string
+	ClientID           string `xorm:"UNIQUE"`
+	ClientSecret       string
+	ConfidentialClient bool           `xorm:"NOT NULL DEFAULT true"`
+	RedirectURIs       []string       `xorm:"redirect_uris JSON TEXT"`
+	CreatedUnix        util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix        util.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName will be invoked by XORM to customize the table name: `oauth2_application`
+func (*OAuth2ApplicationV84) TableName() string {
+	return "oauth2_application"
+}
+
+// OAuth2AuthorizationCodeV84 describes the oauth2 authorization code table as of v84
+type OAuth2AuthorizationCodeV84 struct {
+	ID                  int64 `xorm:"pk autoincr"`
+	GrantID             int64
+	Code                string `xorm:"INDEX UNIQUE"`
+	CodeChallenge       string
+	CodeChallengeMethod string
+	RedirectURI         string
+	ValidUntil          util.TimeStamp `xorm:"INDEX"`
+}
+
+// TableName will be invoked by XORM to customize the table name: `oauth2_authorization_code`
+func (*OAuth2AuthorizationCodeV84) TableName() string {
+	return "oauth2_authorization_code"
+}
+
+// OAuth2GrantV84 describes the oauth2 grant table as of v84
+type OAuth2GrantV84 struct {
+	ID            int64          `xorm:"pk autoincr"`
+	UserID        int64          `xorm:"INDEX UNIQUE(user_application)"`
+	ApplicationID int64          `xorm:"INDEX UNIQUE(user_application)"`
+	Counter       int64          `xorm:"NOT NULL DEFAULT 1"`
+	Scope         string         `xorm:"TEXT"`
+	Nonce         string         `xorm:"TEXT"`
+	CreatedUnix   util.TimeStamp `xorm:"created"`
+	UpdatedUnix   util.TimeStamp `xorm:"updated"`
+}
+
+// TableName will be invoked by XORM to customize the table name: `oauth2_grant`
+func (*OAuth2GrantV84) TableName() string {
+	return "oauth2_grant"
+}
+
+func addOAuth2ApplicationTables(x *xorm.Engine) error {
+	return x.Sync2(new(OAuth2ApplicationV84), new(OAuth2AuthorizationCodeV84), new(OAuth2GrantV84))
+}
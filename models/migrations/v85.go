@@ -0,0 +1,33 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addRefToRepoIndexerStatus(x *xorm.Engine) error {
+	// RepoIndexerStatus see models/repo_indexer.go
+	type RepoIndexerStatus struct {
+		RefName string `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
+	}
+
+	type Repository struct {
+		IndexerBranches string `xorm:"TEXT"`
+		IndexerTags     string `xorm:"TEXT"`
+	}
+
+	if err := x.Sync2(new(RepoIndexerStatus), new(Repository)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+
+	// the existing statuses are those of the default branches
+	if _, err := x.Exec("UPDATE repo_indexer_status SET ref_name = ?", "HEAD"); err != nil {
+		return fmt.Errorf("UPDATE repo_indexer_status: %v", err)
+	}
+	return nil
+}
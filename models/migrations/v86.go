@@ -0,0 +1,31 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addSecretTable(x *xorm.Engine) error {
+	// Secret see models/secret.go
+	type Secret struct {
+		ID          int64          `xorm:"pk autoincr"`
+		OwnerID     int64          `xorm:"UNIQUE(owner_repo_name) INDEX NOT NULL DEFAULT 0"`
+		RepoID      int64          `xorm:"UNIQUE(owner_repo_name) INDEX NOT NULL DEFAULT 0"`
+		Name        string         `xorm:"UNIQUE(owner_repo_name) NOT NULL"`
+		Data        string         `xorm:"LONGTEXT"`
+		CreatedUnix util.TimeStamp `xorm:"created NOT NULL"`
+		UpdatedUnix util.TimeStamp `xorm:"updated NOT NULL"`
+	}
+
+	if err := x.Sync2(new(Secret)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addWebhookDeliveryAttempts(x *xorm.Engine) error {
+	// Webhook see models/webhook.go
+	type Webhook struct {
+		FailureCount int `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	// HookTask see models/webhook.go
+	type HookTask struct {
+		Attempts        int            `xorm:"NOT NULL DEFAULT 0"`
+		NextAttemptUnix util.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(Webhook)); err != nil {
+		return fmt.Errorf("Sync2 webhook: %v", err)
+	}
+	if err := x.Sync2(new(HookTask)); err != nil {
+		return fmt.Errorf("Sync2 hook_task: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addPushMirrorTable(x *xorm.Engine) error {
+	// PushMirror see models/repo_push_mirror.go
+	type PushMirror struct {
+		ID           int64 `xorm:"pk autoincr"`
+		RepoID       int64 `xorm:"INDEX"`
+		RemoteName   string
+		Interval     time.Duration
+		SyncOnCommit bool `xorm:"NOT NULL DEFAULT false"`
+
+		CreatedUnix    util.TimeStamp `xorm:"created"`
+		UpdatedUnix    util.TimeStamp `xorm:"INDEX"`
+		NextUpdateUnix util.TimeStamp `xorm:"INDEX"`
+		LastError      string         `xorm:"TEXT"`
+	}
+
+	if err := x.Sync2(new(PushMirror)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addMirrorTriggerTokenAndSyncHistory(x *xorm.Engine) error {
+	// Mirror see models/repo_mirror.go
+	type Mirror struct {
+		ID           int64  `xorm:"pk autoincr"`
+		TriggerToken string `xorm:"INDEX"`
+	}
+
+	// MirrorSyncRecord see models/repo_mirror_sync.go
+	type MirrorSyncRecord struct {
+		ID          int64    `xorm:"pk autoincr"`
+		RepoID      int64    `xorm:"INDEX"`
+		Refs        []string `xorm:"TEXT JSON"`
+		ChangedRefs []string `xorm:"TEXT JSON"`
+		IsSuccess   bool
+		Error       string `xorm:"TEXT"`
+		Duration    time.Duration
+		CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+	}
+
+	if err := x.Sync2(new(Mirror)); err != nil {
+		return fmt.Errorf("Sync2 mirror: %v", err)
+	}
+	if err := x.Sync2(new(MirrorSyncRecord)); err != nil {
+		return fmt.Errorf("Sync2 mirror_sync_record: %v", err)
+	}
+
+	mirrors := make([]*Mirror, 0, 10)
+	if err := x.Where("trigger_token IS NULL OR trigger_token = ''").Find(&mirrors); err != nil {
+		return fmt.Errorf("select mirrors: %v", err)
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	var err error
+	for _, m := range mirrors {
+		if m.TriggerToken, err = generate.GetRandomString(40); err != nil {
+			return err
+		}
+		if _, err = sess.ID(m.ID).Cols("trigger_token").Update(m); err != nil {
+			return err
+		}
+	}
+
+	return sess.Commit()
+}
@@ -0,0 +1,24 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addExclusiveAndPriorityToLabel(x *xorm.Engine) error {
+	// Label see models/issue_label.go
+	type Label struct {
+		Exclusive bool `xorm:"NOT NULL DEFAULT false"`
+		Priority  int  `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	if err := x.Sync2(new(Label)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
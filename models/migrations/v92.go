@@ -0,0 +1,55 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/go-xorm/xorm"
+)
+
+func addProjectBoards(x *xorm.Engine) error {
+	// ProjectBoard see models/project_board.go
+	type ProjectBoard struct {
+		ID          int64 `xorm:"pk autoincr"`
+		RepoID      int64 `xorm:"INDEX"`
+		Title       string
+		Description string         `xorm:"TEXT"`
+		CreatorID   int64          `xorm:"NOT NULL DEFAULT 0"`
+		CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+		UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+	}
+
+	// ProjectColumn see models/project_board.go
+	type ProjectColumn struct {
+		ID      int64 `xorm:"pk autoincr"`
+		BoardID int64 `xorm:"INDEX"`
+		Title   string
+		Sorting int `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	// ProjectCard see models/project_board.go
+	type ProjectCard struct {
+		ID       int64 `xorm:"pk autoincr"`
+		BoardID  int64 `xorm:"UNIQUE(s)"`
+		IssueID  int64 `xorm:"UNIQUE(s)"`
+		ColumnID int64 `xorm:"INDEX"`
+		Sorting  int   `xorm:"NOT NULL DEFAULT 0"`
+	}
+
+	// Comment see models/issue_comment.go
+	type Comment struct {
+		ProjectBoardID     int64
+		OldProjectColumnID int64
+		ProjectColumnID    int64
+	}
+
+	if err := x.Sync2(new(ProjectBoard), new(ProjectColumn), new(ProjectCard), new(Comment)); err != nil {
+		return fmt.Errorf("Sync2: %v", err)
+	}
+	return nil
+}
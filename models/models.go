@@ -125,6 +125,16 @@ func init() {
 		new(U2FRegistration),
 		new(TeamUnit),
 		new(Review),
+		new(PullAutoMerge),
+		new(OAuth2Application),
+		new(OAuth2AuthorizationCode),
+		new(OAuth2Grant),
+		new(Secret),
+		new(PushMirror),
+		new(MirrorSyncRecord),
+		new(ProjectBoard),
+		new(ProjectColumn),
+		new(ProjectCard),
 	)
 
 	gonicNames := []string{"SSL", "UID"}
@@ -160,11 +170,17 @@ func LoadConfigs() {
 	DbCfg.Timeout = sec.Key("SQLITE_TIMEOUT").MustInt(500)
 
 	sec = setting.Cfg.Section("indexer")
+	setting.Indexer.IssueType = sec.Key("ISSUE_INDEXER_TYPE").MustString("bleve")
+	setting.Indexer.IssueConnStr = sec.Key("ISSUE_INDEXER_CONN_STR").MustString("http://localhost:9200")
+	setting.Indexer.IssueIndexerName = sec.Key("ISSUE_INDEXER_NAME").MustString("gitea_issues")
 	setting.Indexer.IssuePath = sec.Key("ISSUE_INDEXER_PATH").MustString(path.Join(setting.AppDataPath, "indexers/issues.bleve"))
 	if !filepath.IsAbs(setting.Indexer.IssuePath) {
 		setting.Indexer.IssuePath = path.Join(setting.AppWorkPath, setting.Indexer.IssuePath)
 	}
 	setting.Indexer.RepoIndexerEnabled = sec.Key("REPO_INDEXER_ENABLED").MustBool(false)
+	setting.Indexer.RepoType = sec.Key("REPO_INDEXER_TYPE").MustString("bleve")
+	setting.Indexer.RepoConnStr = sec.Key("REPO_INDEXER_CONN_STR").MustString("http://localhost:9200")
+	setting.Indexer.RepoIndexerName = sec.Key("REPO_INDEXER_NAME").MustString("gitea_codes")
 	setting.Indexer.RepoPath = sec.Key("REPO_INDEXER_PATH").MustString(path.Join(setting.AppDataPath, "indexers/repos.bleve"))
 	if !filepath.IsAbs(setting.Indexer.RepoPath) {
 		setting.Indexer.RepoPath = path.Join(setting.AppWorkPath, setting.Indexer.RepoPath)
@@ -6,8 +6,15 @@ package models
 
 import (
 	"fmt"
+	"path"
 
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
+
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/go-xorm/builder"
+	"github.com/go-xorm/xorm"
 )
 
 type (
@@ -56,6 +63,61 @@ type Notification struct {
 	UpdatedUnix util.TimeStamp `xorm:"updated INDEX NOT NULL"`
 }
 
+// FindNotificationOptions represent the filters for notifications. If an ID is 0 it will be ignored.
+type FindNotificationOptions struct {
+	UserID            int64
+	RepoID            int64
+	Status            []NotificationStatus
+	UpdatedAfterUnix  int64
+	UpdatedBeforeUnix int64
+	Page              int
+	PageSize          int
+}
+
+func (opts *FindNotificationOptions) toCond() builder.Cond {
+	cond := builder.NewCond()
+	if opts.UserID != 0 {
+		cond = cond.And(builder.Eq{"notification.user_id": opts.UserID})
+	}
+	if opts.RepoID != 0 {
+		cond = cond.And(builder.Eq{"notification.repo_id": opts.RepoID})
+	}
+	if len(opts.Status) > 0 {
+		cond = cond.And(builder.In("notification.status", opts.Status))
+	}
+	if opts.UpdatedAfterUnix != 0 {
+		cond = cond.And(builder.Gte{"notification.updated_unix": opts.UpdatedAfterUnix})
+	}
+	if opts.UpdatedBeforeUnix != 0 {
+		cond = cond.And(builder.Lte{"notification.updated_unix": opts.UpdatedBeforeUnix})
+	}
+	return cond
+}
+
+func (opts *FindNotificationOptions) toSession(e Engine) *xorm.Session {
+	sess := e.Where(opts.toCond())
+	if opts.Page > 0 && opts.PageSize > 0 {
+		sess = sess.Limit(opts.PageSize, (opts.Page-1)*opts.PageSize)
+	}
+	return sess
+}
+
+// GetNotifications returns all notifications that fit to the given options,
+// most recently updated first.
+func GetNotifications(opts *FindNotificationOptions) ([]*Notification, error) {
+	return getNotifications(x, opts)
+}
+
+func getNotifications(e Engine, opts *FindNotificationOptions) (notifications []*Notification, err error) {
+	err = opts.toSession(e).OrderBy("notification.updated_unix DESC, notification.id DESC").Find(&notifications)
+	return
+}
+
+// CountNotifications counts all notifications that fit to the given options and ignores pagination.
+func CountNotifications(opts *FindNotificationOptions) (int64, error) {
+	return x.Where(opts.toCond()).Count(&Notification{})
+}
+
 // CreateOrUpdateIssueNotifications creates an issue notification
 // for each watcher, or updates it if already exists
 func CreateOrUpdateIssueNotifications(issue *Issue, notificationAuthorID int64) error {
@@ -236,6 +298,69 @@ func (n *Notification) GetIssue() (*Issue, error) {
 	return n.Issue, err
 }
 
+// APIURL returns the absolute API URL of this notification thread.
+func (n *Notification) APIURL() string {
+	return setting.AppURL + path.Join("api/v1/notifications/threads", fmt.Sprint(n.ID))
+}
+
+// APIFormat converts a Notification to api.NotificationThread
+func (n *Notification) APIFormat() (*api.NotificationThread, error) {
+	return n.apiFormat(x)
+}
+
+func (n *Notification) apiFormat(e Engine) (*api.NotificationThread, error) {
+	if n.Repository == nil {
+		repo, err := getRepositoryByID(e, n.RepoID)
+		if err != nil {
+			return nil, err
+		}
+		n.Repository = repo
+	}
+	user, err := getUserByID(e, n.UserID)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := accessLevelUnit(e, user, n.Repository, UnitTypeCode)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := &api.NotificationSubject{}
+	switch n.Source {
+	case NotificationSourceIssue, NotificationSourcePullRequest:
+		if n.Issue == nil {
+			issue, err := getIssueByID(e, n.IssueID)
+			if err != nil {
+				return nil, err
+			}
+			n.Issue = issue
+		}
+		n.Issue.Repo = n.Repository
+		subject.Title = n.Issue.Title
+		subject.URL = n.Issue.APIURL()
+		subject.HTMLURL = n.Issue.HTMLURL()
+		if n.Source == NotificationSourcePullRequest {
+			subject.Type = api.NotifySubjectPull
+		} else {
+			subject.Type = api.NotifySubjectIssue
+		}
+	case NotificationSourceCommit:
+		subject.Title = n.CommitID
+		subject.HTMLURL = n.Repository.HTMLURL() + "/commit/" + n.CommitID
+		subject.Type = api.NotifySubjectCommit
+	}
+
+	return &api.NotificationThread{
+		ID:         n.ID,
+		Repository: n.Repository.innerAPIFormat(e, mode, false),
+		Subject:    subject,
+		Unread:     n.Status == NotificationStatusUnread,
+		Pinned:     n.Status == NotificationStatusPinned,
+		UpdatedAt:  n.UpdatedUnix.AsTime(),
+		URL:        n.APIURL(),
+	}, nil
+}
+
 // GetNotificationCount returns the notification count for user
 func GetNotificationCount(user *User, status NotificationStatus) (int64, error) {
 	return getNotificationCount(x, user, status)
@@ -268,7 +393,7 @@ func setNotificationStatusReadIfUnread(e Engine, userID, issueID int64) error {
 
 // SetNotificationStatus change the notification status
 func SetNotificationStatus(notificationID int64, user *User, status NotificationStatus) error {
-	notification, err := getNotificationByID(notificationID)
+	notification, err := getNotificationByID(x, notificationID)
 	if err != nil {
 		return err
 	}
@@ -283,9 +408,14 @@ func SetNotificationStatus(notificationID int64, user *User, status Notification
 	return err
 }
 
-func getNotificationByID(notificationID int64) (*Notification, error) {
+// GetNotificationByID returns the notification with the given ID
+func GetNotificationByID(notificationID int64) (*Notification, error) {
+	return getNotificationByID(x, notificationID)
+}
+
+func getNotificationByID(e Engine, notificationID int64) (*Notification, error) {
 	notification := new(Notification)
-	ok, err := x.
+	ok, err := e.
 		Where("id = ?", notificationID).
 		Get(notification)
 
@@ -294,7 +424,7 @@ func getNotificationByID(notificationID int64) (*Notification, error) {
 	}
 
 	if !ok {
-		return nil, fmt.Errorf("Notification %d does not exists", notificationID)
+		return nil, ErrNotificationNotExist{ID: notificationID}
 	}
 
 	return notification, nil
@@ -309,3 +439,21 @@ func UpdateNotificationStatuses(user *User, currentStatus NotificationStatus, de
 		Update(n)
 	return err
 }
+
+// MarkNotificationsRead marks all unread notifications of a user as read which were last
+// updated before lastReadUnix. If repoID is not 0, only notifications of that repository
+// are marked and if lastReadUnix is 0 all unread notifications are marked.
+func MarkNotificationsRead(user *User, repoID int64, lastReadUnix util.TimeStamp) error {
+	opts := &FindNotificationOptions{
+		UserID:            user.ID,
+		RepoID:            repoID,
+		Status:            []NotificationStatus{NotificationStatusUnread},
+		UpdatedBeforeUnix: int64(lastReadUnix),
+	}
+	n := &Notification{Status: NotificationStatusRead, UpdatedBy: user.ID}
+	_, err := x.
+		Where(opts.toCond()).
+		Cols("status", "updated_by", "updated_unix").
+		Update(n)
+	return err
+}
@@ -7,6 +7,8 @@ package models
 import (
 	"testing"
 
+	"code.gitea.io/gitea/modules/setting"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -99,3 +101,72 @@ func TestUpdateNotificationStatuses(t *testing.T) {
 	AssertExistsAndLoadBean(t,
 		&Notification{ID: notfPinned.ID, Status: NotificationStatusPinned})
 }
+
+func TestGetNotifications(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	notfs, err := GetNotifications(&FindNotificationOptions{
+		UserID: 2,
+		Status: []NotificationStatus{NotificationStatusUnread, NotificationStatusPinned},
+	})
+	assert.NoError(t, err)
+	if assert.Len(t, notfs, 2) {
+		assert.EqualValues(t, 4, notfs[0].ID)
+		assert.EqualValues(t, 3, notfs[1].ID)
+	}
+
+	notfs, err = GetNotifications(&FindNotificationOptions{UserID: 2, Page: 2, PageSize: 2})
+	assert.NoError(t, err)
+	if assert.Len(t, notfs, 1) {
+		assert.EqualValues(t, 2, notfs[0].ID)
+	}
+
+	notfs, err = GetNotifications(&FindNotificationOptions{UserID: 2, UpdatedAfterUnix: 946684801})
+	assert.NoError(t, err)
+	assert.Len(t, notfs, 0)
+
+	cnt, err := CountNotifications(&FindNotificationOptions{UserID: 2, RepoID: 1, UpdatedBeforeUnix: 946684800})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, cnt)
+}
+
+func TestGetNotificationByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	notf, err := GetNotificationByID(1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, notf.UserID)
+
+	_, err = GetNotificationByID(NonexistentID)
+	assert.True(t, IsErrNotificationNotExist(err))
+}
+
+func TestNotification_APIFormat(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	notf := AssertExistsAndLoadBean(t, &Notification{ID: 3}).(*Notification)
+	thread, err := notf.APIFormat()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, thread.ID)
+	assert.False(t, thread.Unread)
+	assert.True(t, thread.Pinned)
+	assert.EqualValues(t, "user2/repo1", thread.Repository.FullName)
+	assert.EqualValues(t, "issue2", thread.Subject.Title)
+	assert.EqualValues(t, "Issue", thread.Subject.Type)
+	assert.EqualValues(t, setting.AppURL+"api/v1/notifications/threads/3", thread.URL)
+}
+
+func TestMarkNotificationsRead(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+
+	// notifications updated after the given time are kept unread
+	assert.NoError(t, MarkNotificationsRead(user, 0, 946684799))
+	AssertExistsAndLoadBean(t, &Notification{ID: 4, Status: NotificationStatusUnread})
+
+	// other repositories are left untouched
+	assert.NoError(t, MarkNotificationsRead(user, 2, 0))
+	AssertExistsAndLoadBean(t, &Notification{ID: 4, Status: NotificationStatusUnread})
+
+	assert.NoError(t, MarkNotificationsRead(user, 1, 0))
+	AssertExistsAndLoadBean(t, &Notification{ID: 4, Status: NotificationStatusRead})
+	AssertExistsAndLoadBean(t, &Notification{ID: 3, Status: NotificationStatusPinned})
+	AssertExistsAndLoadBean(t, &Notification{ID: 1, Status: NotificationStatusUnread})
+}
@@ -0,0 +1,457 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+// OAuth2AuthorizationCodeValidity is the time during which an authorization code can be exchanged for tokens
+const OAuth2AuthorizationCodeValidity = 10 * 60
+
+// OAuth2Application represents an OAuth2 client registered by a user or an organization,
+// which can ask users to sign in with Gitea.
+type OAuth2Application struct {
+	ID   int64 `xorm:"pk autoincr"`
+	UID  int64 `xorm:"INDEX"`
+	User *User `xorm:"-"`
+
+	Name string
+
+	ClientID     string `xorm:"UNIQUE"`
+	ClientSecret string
+	// ConfidentialClient is false for the clients which cannot keep a secret, they have to use PKCE instead
+	ConfidentialClient bool `xorm:"NOT NULL DEFAULT true"`
+
+	RedirectURIs []string `xorm:"redirect_uris JSON TEXT"`
+
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
+}
+
+// TableName sets the table name to `oauth2_application`
+func (app *OAuth2Application) TableName() string {
+	return "oauth2_application"
+}
+
+// LoadUser loads the owner of the application
+func (app *OAuth2Application) LoadUser() (err error) {
+	if app.User == nil {
+		app.User, err = GetUserByID(app.UID)
+	}
+	return
+}
+
+// PrimaryRedirectURI returns the first redirect uri or an empty string if empty
+func (app *OAuth2Application) PrimaryRedirectURI() string {
+	if len(app.RedirectURIs) == 0 {
+		return ""
+	}
+	return app.RedirectURIs[0]
+}
+
+func hashOAuth2ClientSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateClientSecret generates a new client secret, stores its hash and returns it.
+// The secret itself is never stored and can only be shown once.
+func (app *OAuth2Application) GenerateClientSecret() (string, error) {
+	secret, err := generate.GetRandomString(44)
+	if err != nil {
+		return "", err
+	}
+	app.ClientSecret = hashOAuth2ClientSecret(secret)
+	if _, err = x.ID(app.ID).Cols("client_secret").Update(app); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// ValidateClientSecret validates the given secret against the hash of the client secret
+func (app *OAuth2Application) ValidateClientSecret(secret string) bool {
+	if len(app.ClientSecret) == 0 || len(secret) == 0 {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(app.ClientSecret), []byte(hashOAuth2ClientSecret(secret))) == 1
+}
+
+// ContainsRedirectURI checks if the redirect uri is registered for the application
+func (app *OAuth2Application) ContainsRedirectURI(redirectURI string) bool {
+	for _, uri := range app.RedirectURIs {
+		if uri == redirectURI {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGrantByUserID returns the grant of the user to the application, or nil if the user has not granted access to it
+func (app *OAuth2Application) GetGrantByUserID(userID int64) (*OAuth2Grant, error) {
+	grant := new(OAuth2Grant)
+	has, err := x.Where("user_id = ? AND application_id = ?", userID, app.ID).Get(grant)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return grant, nil
+}
+
+// CreateGrant grants the application access to the account of the user with the given scope
+func (app *OAuth2Application) CreateGrant(userID int64, scope string) (*OAuth2Grant, error) {
+	grant := &OAuth2Grant{
+		ApplicationID: app.ID,
+		UserID:        userID,
+		Scope:         scope,
+		Counter:       1,
+	}
+	if _, err := x.Insert(grant); err != nil {
+		return nil, err
+	}
+	return grant, nil
+}
+
+// GetOAuth2ApplicationByClientID returns the oauth2 application with the given client id
+func GetOAuth2ApplicationByClientID(clientID string) (*OAuth2Application, error) {
+	app := new(OAuth2Application)
+	has, err := x.Where("client_id = ?", clientID).Get(app)
+	if err != nil {
+		return nil, err
+	} else if !has || len(clientID) == 0 {
+		return nil, ErrOAuthClientIDInvalid{ClientID: clientID}
+	}
+	return app, nil
+}
+
+// GetOAuth2ApplicationByID returns the oauth2 application with the given id
+func GetOAuth2ApplicationByID(id int64) (*OAuth2Application, error) {
+	app := new(OAuth2Application)
+	has, err := x.ID(id).Get(app)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrOAuthApplicationNotFound{ID: id}
+	}
+	return app, nil
+}
+
+// GetOAuth2ApplicationsByUserID returns all oauth2 applications owned by the user or organization
+func GetOAuth2ApplicationsByUserID(userID int64) ([]*OAuth2Application, error) {
+	apps := make([]*OAuth2Application, 0, 5)
+	return apps, x.Where("uid = ?", userID).Asc("id").Find(&apps)
+}
+
+// CreateOAuth2ApplicationOptions holds options to create an oauth2 application
+type CreateOAuth2ApplicationOptions struct {
+	Name               string
+	UserID             int64
+	ConfidentialClient bool
+	RedirectURIs       []string
+}
+
+// CreateOAuth2Application creates a new oauth2 application with a random client id
+func CreateOAuth2Application(opts CreateOAuth2ApplicationOptions) (*OAuth2Application, error) {
+	app := &OAuth2Application{
+		UID:                opts.UserID,
+		Name:               opts.Name,
+		ClientID:           gouuid.NewV4().String(),
+		ConfidentialClient: opts.ConfidentialClient,
+		RedirectURIs:       opts.RedirectURIs,
+	}
+	if _, err := x.Insert(app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// UpdateOAuth2ApplicationOptions holds options to update an oauth2 application
+type UpdateOAuth2ApplicationOptions struct {
+	ID                 int64
+	Name               string
+	UserID             int64
+	ConfidentialClient bool
+	RedirectURIs       []string
+}
+
+// UpdateOAuth2Application updates the name and the redirect uris of an oauth2 application
+func UpdateOAuth2Application(opts UpdateOAuth2ApplicationOptions) (*OAuth2Application, error) {
+	app, err := GetOAuth2ApplicationByID(opts.ID)
+	if err != nil {
+		return nil, err
+	} else if app.UID != opts.UserID {
+		return nil, ErrOAuthApplicationNotFound{ID: opts.ID}
+	}
+
+	app.Name = opts.Name
+	app.ConfidentialClient = opts.ConfidentialClient
+	app.RedirectURIs = opts.RedirectURIs
+	if _, err = x.ID(app.ID).Cols("name", "confidential_client", "redirect_uris").Update(app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// DeleteOAuth2Application deletes the application owned by the user, with all its grants and authorization codes
+func DeleteOAuth2Application(id, userID int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if deleted, err := sess.Delete(&OAuth2Application{ID: id, UID: userID}); err != nil {
+		return err
+	} else if deleted == 0 {
+		return ErrOAuthApplicationNotFound{ID: id}
+	}
+
+	grantIDs := make([]int64, 0, 10)
+	if err := sess.Table("oauth2_grant").Where("application_id = ?", id).Cols("id").Find(&grantIDs); err != nil {
+		return err
+	}
+	if len(grantIDs) > 0 {
+		if _, err := sess.In("grant_id", grantIDs).Delete(new(OAuth2AuthorizationCode)); err != nil {
+			return err
+		}
+		if _, err := sess.In("id", grantIDs).Delete(new(OAuth2Grant)); err != nil {
+			return err
+		}
+	}
+	return sess.Commit()
+}
+
+// OAuth2AuthorizationCode is a code which can be exchanged once for an access token and a refresh token
+type OAuth2AuthorizationCode struct {
+	ID                  int64        `xorm:"pk autoincr"`
+	Grant               *OAuth2Grant `xorm:"-"`
+	GrantID             int64
+	Code                string `xorm:"INDEX UNIQUE"`
+	CodeChallenge       string
+	CodeChallengeMethod string
+	RedirectURI         string
+	ValidUntil          util.TimeStamp `xorm:"INDEX"`
+}
+
+// TableName sets the table name to `oauth2_authorization_code`
+func (code *OAuth2AuthorizationCode) TableName() string {
+	return "oauth2_authorization_code"
+}
+
+// GenerateRedirectURI returns the redirect uri with the code and the state as query parameters
+func (code *OAuth2AuthorizationCode) GenerateRedirectURI(state string) (*url.URL, error) {
+	redirect, err := url.Parse(code.RedirectURI)
+	if err != nil {
+		return nil, err
+	}
+	q := redirect.Query()
+	if len(state) > 0 {
+		q.Set("state", state)
+	}
+	q.Set("code", code.Code)
+	redirect.RawQuery = q.Encode()
+	return redirect, nil
+}
+
+// IsExpired returns true if the code can no longer be exchanged
+func (code *OAuth2AuthorizationCode) IsExpired() bool {
+	return code.ValidUntil <= util.TimeStampNow()
+}
+
+// Invalidate deletes the code so that it cannot be used again.
+// It returns ErrOAuthCodeInvalid if the code has already been used.
+func (code *OAuth2AuthorizationCode) Invalidate() error {
+	deleted, err := x.Delete(&OAuth2AuthorizationCode{ID: code.ID})
+	if err != nil {
+		return err
+	} else if deleted == 0 {
+		return ErrOAuthCodeInvalid{}
+	}
+	return nil
+}
+
+// ValidateCodeChallenge checks the PKCE code verifier against the challenge of the code
+func (code *OAuth2AuthorizationCode) ValidateCodeChallenge(verifier string) bool {
+	switch code.CodeChallengeMethod {
+	case "S256":
+		// base64url(SHA256(verifier)) see https://tools.ietf.org/html/rfc7636#section-4.6
+		hash := sha256.Sum256([]byte(verifier))
+		return subtle.ConstantTimeCompare([]byte(code.CodeChallenge), []byte(base64.RawURLEncoding.EncodeToString(hash[:]))) == 1
+	case "plain":
+		return subtle.ConstantTimeCompare([]byte(code.CodeChallenge), []byte(verifier)) == 1
+	case "":
+		return true
+	default:
+		return false
+	}
+}
+
+// GetOAuth2AuthorizationByCode returns the authorization code with its grant, or nil if it does not exist
+func GetOAuth2AuthorizationByCode(code string) (*OAuth2AuthorizationCode, error) {
+	authCode := new(OAuth2AuthorizationCode)
+	if has, err := x.Where("code = ?", code).Get(authCode); err != nil {
+		return nil, err
+	} else if !has || len(code) == 0 {
+		return nil, nil
+	}
+	authCode.Grant = new(OAuth2Grant)
+	if has, err := x.ID(authCode.GrantID).Get(authCode.Grant); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return authCode, nil
+}
+
+// OAuth2Grant represents the permission given by a user to an application to access their account
+type OAuth2Grant struct {
+	ID            int64              `xorm:"pk autoincr"`
+	UserID        int64              `xorm:"INDEX UNIQUE(user_application)"`
+	Application   *OAuth2Application `xorm:"-"`
+	ApplicationID int64              `xorm:"INDEX UNIQUE(user_application)"`
+	// Counter is increased every time a refresh token is used, which invalidates the previous refresh tokens
+	Counter     int64          `xorm:"NOT NULL DEFAULT 1"`
+	Scope       string         `xorm:"TEXT"`
+	Nonce       string         `xorm:"TEXT"`
+	CreatedUnix util.TimeStamp `xorm:"created"`
+	UpdatedUnix util.TimeStamp `xorm:"updated"`
+}
+
+// TableName sets the table name to `oauth2_grant`
+func (grant *OAuth2Grant) TableName() string {
+	return "oauth2_grant"
+}
+
+// LoadApplication loads the application the grant is given to
+func (grant *OAuth2Grant) LoadApplication() (err error) {
+	if grant.Application == nil {
+		grant.Application, err = GetOAuth2ApplicationByID(grant.ApplicationID)
+	}
+	return
+}
+
+// GenerateNewAuthorizationCode generates a new authorization code for the grant
+func (grant *OAuth2Grant) GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod string) (*OAuth2AuthorizationCode, error) {
+	secret, err := generate.GetRandomString(32)
+	if err != nil {
+		return nil, err
+	}
+	code := &OAuth2AuthorizationCode{
+		Grant:               grant,
+		GrantID:             grant.ID,
+		Code:                secret,
+		CodeChallenge:       codeChallenge,
+		CodeChallengeMethod: codeChallengeMethod,
+		RedirectURI:         redirectURI,
+		ValidUntil:          util.TimeStampNow().Add(OAuth2AuthorizationCodeValidity),
+	}
+	if _, err = x.Insert(code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// IncreaseCounter increases the counter of the grant, invalidating the refresh tokens issued until now
+func (grant *OAuth2Grant) IncreaseCounter() error {
+	if _, err := x.ID(grant.ID).Incr("counter").Update(new(OAuth2Grant)); err != nil {
+		return err
+	}
+	updated, err := GetOAuth2GrantByID(grant.ID)
+	if err != nil {
+		return err
+	}
+	grant.Counter = updated.Counter
+	return nil
+}
+
+// Scopes returns the scopes of the grant
+func (grant *OAuth2Grant) Scopes() []string {
+	return strings.Fields(grant.Scope)
+}
+
+// ScopeContains returns true if the grant scope contains the given scope
+func (grant *OAuth2Grant) ScopeContains(scope string) bool {
+	for _, s := range grant.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// SetScopeAndNonce updates the scope and the nonce of the grant for a new authorization
+func (grant *OAuth2Grant) SetScopeAndNonce(scope, nonce string) error {
+	grant.Scope = scope
+	grant.Nonce = nonce
+	_, err := x.ID(grant.ID).Cols("scope", "nonce").Update(grant)
+	return err
+}
+
+// AccessToken returns the access token used to check the API permissions of the requests
+// signed in with an oauth2 access token of the grant. The personal access token scopes
+// requested by the application are granted, and all of them when it requested none.
+func (grant *OAuth2Grant) AccessToken() *AccessToken {
+	return &AccessToken{
+		UID:   grant.UserID,
+		Name:  fmt.Sprintf("oauth2 grant %d", grant.ID),
+		Scope: strings.Join(grant.Scopes(), ","),
+	}
+}
+
+// GetOAuth2GrantByID returns the grant with the given id
+func GetOAuth2GrantByID(id int64) (*OAuth2Grant, error) {
+	grant := new(OAuth2Grant)
+	if has, err := x.ID(id).Get(grant); err != nil {
+		return nil, err
+	} else if !has {
+		return nil, nil
+	}
+	return grant, nil
+}
+
+// GetOAuth2GrantsByUserID lists all the grants of the user, with their applications
+func GetOAuth2GrantsByUserID(uid int64) ([]*OAuth2Grant, error) {
+	grants := make([]*OAuth2Grant, 0, 5)
+	if err := x.Where("user_id = ?", uid).Asc("id").Find(&grants); err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		if err := grant.LoadApplication(); err != nil {
+			return nil, err
+		}
+	}
+	return grants, nil
+}
+
+// RevokeOAuth2Grant deletes the grant of the user and its authorization codes,
+// so that the tokens issued for it stop working
+func RevokeOAuth2Grant(grantID, userID int64) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+	if deleted, err := sess.Delete(&OAuth2Grant{ID: grantID, UserID: userID}); err != nil {
+		return err
+	} else if deleted == 0 {
+		return ErrOAuthGrantNotExist{ID: grantID}
+	}
+	if _, err := sess.Delete(&OAuth2AuthorizationCode{GrantID: grantID}); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
@@ -327,6 +327,36 @@ func GetTeam(orgID int64, name string) (*Team, error) {
 	return getTeam(x, orgID, name)
 }
 
+// GetTeamIDsByNames returns a slice of team ids corresponds to names.
+// If ignoreNonExistent is false, ErrTeamNotExist is returned for the first unknown name.
+func GetTeamIDsByNames(orgID int64, names []string, ignoreNonExistent bool) ([]int64, error) {
+	ids := make([]int64, 0, len(names))
+	for _, name := range names {
+		t, err := GetTeam(orgID, name)
+		if err != nil {
+			if ignoreNonExistent && err == ErrTeamNotExist {
+				continue
+			}
+			return nil, err
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids, nil
+}
+
+// GetTeamNamesByID returns the names of the teams with the given ids.
+func GetTeamNamesByID(teamIDs []int64) ([]string, error) {
+	names := make([]string, 0, len(teamIDs))
+	if len(teamIDs) == 0 {
+		return names, nil
+	}
+	return names, x.Table("team").
+		In("id", teamIDs).
+		Asc("name").
+		Cols("name").
+		Find(&names)
+}
+
 func getTeamByID(e Engine, teamID int64) (*Team, error) {
 	t := new(Team)
 	has, err := e.ID(teamID).Get(t)
@@ -180,6 +180,33 @@ func TestGetTeam(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGetTeamIDsByNames(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	ids, err := GetTeamIDsByNames(3, []string{"team1", "Owners"}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2, 1}, ids)
+
+	_, err = GetTeamIDsByNames(3, []string{"team1", "nonexistent"}, false)
+	assert.Equal(t, ErrTeamNotExist, err)
+
+	ids, err = GetTeamIDsByNames(3, []string{"team1", "nonexistent"}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{2}, ids)
+}
+
+func TestGetTeamNamesByID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	names, err := GetTeamNamesByID([]int64{2, 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Owners", "team1"}, names)
+
+	names, err = GetTeamNamesByID(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
 func TestGetTeamByID(t *testing.T) {
 	assert.NoError(t, PrepareTestDatabase())
 
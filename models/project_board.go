@@ -0,0 +1,542 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/util"
+	api "code.gitea.io/sdk/gitea"
+
+	"github.com/go-xorm/builder"
+	"github.com/go-xorm/xorm"
+)
+
+// ProjectBoard represents a kanban board of a repository, made of ordered
+// columns holding its issues and pull requests as cards.
+type ProjectBoard struct {
+	ID              int64 `xorm:"pk autoincr"`
+	RepoID          int64 `xorm:"INDEX"`
+	Title           string
+	Description     string         `xorm:"TEXT"`
+	RenderedContent string         `xorm:"-"`
+	CreatorID       int64          `xorm:"NOT NULL DEFAULT 0"`
+	CreatedUnix     util.TimeStamp `xorm:"INDEX created"`
+	UpdatedUnix     util.TimeStamp `xorm:"INDEX updated"`
+
+	Columns []*ProjectColumn `xorm:"-"`
+}
+
+// ProjectColumn represents a column of a project board.
+type ProjectColumn struct {
+	ID      int64 `xorm:"pk autoincr"`
+	BoardID int64 `xorm:"INDEX"`
+	Title   string
+	Sorting int `xorm:"NOT NULL DEFAULT 0"`
+
+	Cards []*ProjectCard `xorm:"-"`
+}
+
+// ProjectCard represents an issue or a pull request placed in a column of a
+// project board, an issue can only have one card on each board.
+type ProjectCard struct {
+	ID       int64 `xorm:"pk autoincr"`
+	BoardID  int64 `xorm:"UNIQUE(s)"`
+	IssueID  int64 `xorm:"UNIQUE(s)"`
+	ColumnID int64 `xorm:"INDEX"`
+	Sorting  int   `xorm:"NOT NULL DEFAULT 0"`
+
+	Issue *Issue `xorm:"-"`
+}
+
+// APIFormat returns this ProjectBoard in API format, with its columns if they
+// are loaded.
+func (b *ProjectBoard) APIFormat() *api.ProjectBoard {
+	apiBoard := &api.ProjectBoard{
+		ID:          b.ID,
+		Title:       b.Title,
+		Description: b.Description,
+		Created:     b.CreatedUnix.AsTime(),
+		Updated:     b.UpdatedUnix.AsTime(),
+	}
+	if b.Columns != nil {
+		apiBoard.Columns = make([]*api.ProjectColumn, len(b.Columns))
+		for i := range b.Columns {
+			apiBoard.Columns[i] = b.Columns[i].APIFormat()
+		}
+	}
+	return apiBoard
+}
+
+// APIFormat returns this ProjectColumn in API format, with its cards if they
+// are loaded.
+func (c *ProjectColumn) APIFormat() *api.ProjectColumn {
+	apiColumn := &api.ProjectColumn{
+		ID:      c.ID,
+		BoardID: c.BoardID,
+		Title:   c.Title,
+		Sorting: c.Sorting,
+	}
+	if c.Cards != nil {
+		apiColumn.Cards = make([]*api.ProjectCard, len(c.Cards))
+		for i := range c.Cards {
+			apiColumn.Cards[i] = c.Cards[i].APIFormat()
+		}
+	}
+	return apiColumn
+}
+
+// APIFormat returns this ProjectCard in API format.
+func (c *ProjectCard) APIFormat() *api.ProjectCard {
+	apiCard := &api.ProjectCard{
+		ID:       c.ID,
+		BoardID:  c.BoardID,
+		ColumnID: c.ColumnID,
+		Sorting:  c.Sorting,
+	}
+	if c.Issue != nil {
+		apiCard.Issue = c.Issue.APIFormat()
+	}
+	return apiCard
+}
+
+// NewProjectBoard creates a new project board with the given columns.
+func NewProjectBoard(b *ProjectBoard, columnTitles ...string) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Insert(b); err != nil {
+		return err
+	}
+
+	b.Columns = make([]*ProjectColumn, 0, len(columnTitles))
+	for i, title := range columnTitles {
+		c := &ProjectColumn{
+			BoardID: b.ID,
+			Title:   title,
+			Sorting: i,
+		}
+		if _, err = sess.Insert(c); err != nil {
+			return err
+		}
+		b.Columns = append(b.Columns, c)
+	}
+
+	return sess.Commit()
+}
+
+func getProjectBoardByRepoID(e Engine, repoID, id int64) (*ProjectBoard, error) {
+	b := &ProjectBoard{
+		ID:     id,
+		RepoID: repoID,
+	}
+	has, err := e.Get(b)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectBoardNotExist{id, repoID}
+	}
+	return b, nil
+}
+
+// GetProjectBoardByRepoID returns the project board in a repository.
+func GetProjectBoardByRepoID(repoID, id int64) (*ProjectBoard, error) {
+	return getProjectBoardByRepoID(x, repoID, id)
+}
+
+// GetProjectBoardsByRepoID returns all the project boards of a repository.
+func GetProjectBoardsByRepoID(repoID int64) ([]*ProjectBoard, error) {
+	boards := make([]*ProjectBoard, 0, 5)
+	return boards, x.Where("repo_id = ?", repoID).Asc("title").Find(&boards)
+}
+
+// UpdateProjectBoard updates information of given project board.
+func UpdateProjectBoard(b *ProjectBoard) error {
+	_, err := x.ID(b.ID).Cols("title, description").Update(b)
+	return err
+}
+
+// DeleteProjectBoardByRepoID deletes a project board from a repository,
+// with its columns and cards.
+func DeleteProjectBoardByRepoID(repoID, id int64) error {
+	b, err := GetProjectBoardByRepoID(repoID, id)
+	if err != nil {
+		if IsErrProjectBoardNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if err = deleteBeans(sess,
+		&ProjectCard{BoardID: b.ID},
+		&ProjectColumn{BoardID: b.ID},
+	); err != nil {
+		return err
+	}
+	if _, err = sess.ID(b.ID).Delete(new(ProjectBoard)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// deleteProjectBoardsOfRepo deletes all the project boards of a repository,
+// with their columns and cards.
+func deleteProjectBoardsOfRepo(e Engine, repoID int64) error {
+	boardIDs := builder.Select("id").From("project_board").Where(builder.Eq{"repo_id": repoID})
+	if _, err := e.In("board_id", boardIDs).Delete(new(ProjectCard)); err != nil {
+		return err
+	}
+	if _, err := e.In("board_id", boardIDs).Delete(new(ProjectColumn)); err != nil {
+		return err
+	}
+	_, err := e.Delete(&ProjectBoard{RepoID: repoID})
+	return err
+}
+
+func (b *ProjectBoard) loadColumns(e Engine) (err error) {
+	b.Columns = make([]*ProjectColumn, 0, 5)
+	return e.Where("board_id = ?", b.ID).
+		Asc("sorting").
+		Asc("id").
+		Find(&b.Columns)
+}
+
+// LoadColumns loads the columns of the project board.
+func (b *ProjectBoard) LoadColumns() error {
+	return b.loadColumns(x)
+}
+
+// LoadColumnsAndCards loads the columns of the project board, each one with
+// its cards and their issues.
+func (b *ProjectBoard) LoadColumnsAndCards() error {
+	if err := b.loadColumns(x); err != nil {
+		return err
+	}
+
+	cards := make([]*ProjectCard, 0, 10)
+	if err := x.Where("board_id = ?", b.ID).
+		Asc("sorting").
+		Asc("id").
+		Find(&cards); err != nil {
+		return err
+	}
+	if err := loadProjectCardsIssues(x, cards); err != nil {
+		return err
+	}
+
+	columns := make(map[int64]*ProjectColumn, len(b.Columns))
+	for _, c := range b.Columns {
+		c.Cards = make([]*ProjectCard, 0, 5)
+		columns[c.ID] = c
+	}
+	for _, card := range cards {
+		if c, ok := columns[card.ColumnID]; ok {
+			c.Cards = append(c.Cards, card)
+		}
+	}
+	return nil
+}
+
+// FilterCards removes from the loaded columns of the project board the cards
+// whose issue or pull request cannot be read with the given permission.
+func (b *ProjectBoard) FilterCards(perm *Permission) {
+	for _, c := range b.Columns {
+		if c.Cards != nil {
+			c.Cards = FilterProjectCards(c.Cards, perm)
+		}
+	}
+}
+
+// FilterProjectCards returns the cards whose issue or pull request can be read
+// with the given permission, the issues of the cards must be loaded.
+func FilterProjectCards(cards []*ProjectCard, perm *Permission) []*ProjectCard {
+	filtered := make([]*ProjectCard, 0, len(cards))
+	for _, card := range cards {
+		if card.Issue != nil && perm.CanReadIssuesOrPulls(card.Issue.IsPull) {
+			filtered = append(filtered, card)
+		}
+	}
+	return filtered
+}
+
+func loadProjectCardsIssues(e Engine, cards []*ProjectCard) error {
+	if len(cards) == 0 {
+		return nil
+	}
+
+	issueIDs := make([]int64, len(cards))
+	for i := range cards {
+		issueIDs[i] = cards[i].IssueID
+	}
+	issues, err := getIssuesByIDs(e, issueIDs)
+	if err != nil {
+		return err
+	}
+	if err = IssueList(issues).loadAttributes(e); err != nil {
+		return err
+	}
+
+	issuesMap := make(map[int64]*Issue, len(issues))
+	for _, issue := range issues {
+		issuesMap[issue.ID] = issue
+	}
+	for _, card := range cards {
+		card.Issue = issuesMap[card.IssueID]
+	}
+	return nil
+}
+
+// NewProjectColumn creates a new column at the end of a project board.
+func NewProjectColumn(c *ProjectColumn) (err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	var maxSorting int
+	if _, err = sess.Table("project_column").
+		Where("board_id = ?", c.BoardID).
+		Select("COALESCE(MAX(sorting), -1)").
+		Get(&maxSorting); err != nil {
+		return err
+	}
+	c.Sorting = maxSorting + 1
+
+	if _, err = sess.Insert(c); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+func getProjectColumnByBoardID(e Engine, boardID, id int64) (*ProjectColumn, error) {
+	c := &ProjectColumn{
+		ID:      id,
+		BoardID: boardID,
+	}
+	has, err := e.Get(c)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectColumnNotExist{id, boardID}
+	}
+	return c, nil
+}
+
+// GetProjectColumnByBoardID returns the column of a project board.
+func GetProjectColumnByBoardID(boardID, id int64) (*ProjectColumn, error) {
+	return getProjectColumnByBoardID(x, boardID, id)
+}
+
+// UpdateProjectColumn updates information of given project column.
+func UpdateProjectColumn(c *ProjectColumn) error {
+	_, err := x.ID(c.ID).Cols("title, sorting").Update(c)
+	return err
+}
+
+// DeleteProjectColumnByBoardID deletes a column from a project board with
+// its cards, the issues themselves are left untouched.
+func DeleteProjectColumnByBoardID(boardID, id int64) error {
+	c, err := GetProjectColumnByBoardID(boardID, id)
+	if err != nil {
+		if IsErrProjectColumnNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.Delete(&ProjectCard{ColumnID: c.ID}); err != nil {
+		return err
+	}
+	if _, err = sess.ID(c.ID).Delete(new(ProjectColumn)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetProjectCardsByColumnID returns the cards of a project column in order,
+// with their issues.
+func GetProjectCardsByColumnID(columnID int64) ([]*ProjectCard, error) {
+	cards, err := getProjectCardsByColumnID(x, columnID)
+	if err != nil {
+		return nil, err
+	}
+	return cards, loadProjectCardsIssues(x, cards)
+}
+
+func getProjectCardsByColumnID(e Engine, columnID int64) ([]*ProjectCard, error) {
+	cards := make([]*ProjectCard, 0, 10)
+	return cards, e.Where("column_id = ?", columnID).
+		Asc("sorting").
+		Asc("id").
+		Find(&cards)
+}
+
+// GetProjectCardByBoardID returns the card of a project board.
+func GetProjectCardByBoardID(boardID, id int64) (*ProjectCard, error) {
+	card := &ProjectCard{
+		ID:      id,
+		BoardID: boardID,
+	}
+	has, err := x.Get(card)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrProjectCardNotExist{id, boardID}
+	}
+	return card, nil
+}
+
+// LoadIssue loads the issue of the project card.
+func (c *ProjectCard) LoadIssue() (err error) {
+	if c.Issue == nil {
+		c.Issue, err = GetIssueByID(c.IssueID)
+	}
+	return err
+}
+
+// placeProjectCard inserts the card in the column at the given position,
+// appending it if the position is out of range, and updates the sorting of
+// the cards of the column.
+func placeProjectCard(e *xorm.Session, card *ProjectCard, column *ProjectColumn, position int) error {
+	cards, err := getProjectCardsByColumnID(e, column.ID)
+	if err != nil {
+		return err
+	}
+
+	ordered := make([]*ProjectCard, 0, len(cards)+1)
+	for _, c := range cards {
+		if c.ID != card.ID {
+			ordered = append(ordered, c)
+		}
+	}
+	if position < 0 || position > len(ordered) {
+		position = len(ordered)
+	}
+	ordered = append(ordered, nil)
+	copy(ordered[position+1:], ordered[position:])
+	ordered[position] = card
+
+	card.ColumnID = column.ID
+	for i, c := range ordered {
+		if c.ID != card.ID && c.Sorting == i {
+			continue
+		}
+		c.Sorting = i
+		if _, err = e.ID(c.ID).Cols("column_id, sorting").Update(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewProjectCard adds the issue to a project board as a card at the end of
+// the given column, and records it in the issue.
+func NewProjectCard(doer *User, issue *Issue, column *ProjectColumn) (_ *ProjectCard, err error) {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+
+	has, err := sess.Exist(&ProjectCard{
+		BoardID: column.BoardID,
+		IssueID: issue.ID,
+	})
+	if err != nil {
+		return nil, err
+	} else if has {
+		return nil, ErrProjectCardAlreadyExist{column.BoardID, issue.ID}
+	}
+
+	card := &ProjectCard{
+		BoardID:  column.BoardID,
+		IssueID:  issue.ID,
+		ColumnID: column.ID,
+		Issue:    issue,
+	}
+	if _, err = sess.Insert(card); err != nil {
+		return nil, err
+	}
+	if err = placeProjectCard(sess, card, column, -1); err != nil {
+		return nil, fmt.Errorf("placeProjectCard: %v", err)
+	}
+
+	if _, err = createProjectCardComment(sess, doer, issue, column.BoardID, 0, column.ID); err != nil {
+		return nil, fmt.Errorf("createProjectCardComment: %v", err)
+	}
+
+	return card, sess.Commit()
+}
+
+// MoveProjectCard moves the card to the given position of a column of its
+// project board, the move is recorded in the issue if the card changes of
+// column.
+func MoveProjectCard(doer *User, card *ProjectCard, column *ProjectColumn, position int) (err error) {
+	if card.BoardID != column.BoardID {
+		return ErrProjectColumnNotExist{column.ID, card.BoardID}
+	}
+	if err = card.LoadIssue(); err != nil {
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	oldColumnID := card.ColumnID
+	if err = placeProjectCard(sess, card, column, position); err != nil {
+		return fmt.Errorf("placeProjectCard: %v", err)
+	}
+
+	if oldColumnID != column.ID {
+		if _, err = createProjectCardComment(sess, doer, card.Issue, card.BoardID, oldColumnID, column.ID); err != nil {
+			return fmt.Errorf("createProjectCardComment: %v", err)
+		}
+	}
+
+	return sess.Commit()
+}
+
+// DeleteProjectCard removes the card of the issue from its project board,
+// and records it in the issue.
+func DeleteProjectCard(doer *User, card *ProjectCard) (err error) {
+	if err = card.LoadIssue(); err != nil {
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err = sess.ID(card.ID).Delete(new(ProjectCard)); err != nil {
+		return err
+	}
+
+	if _, err = createProjectCardComment(sess, doer, card.Issue, card.BoardID, card.ColumnID, 0); err != nil {
+		return fmt.Errorf("createProjectCardComment: %v", err)
+	}
+
+	return sess.Commit()
+}
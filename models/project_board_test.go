@@ -0,0 +1,229 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertProjectCards(t *testing.T, columnID int64, expectedIssueIDs ...int64) {
+	cards, err := GetProjectCardsByColumnID(columnID)
+	assert.NoError(t, err)
+	issueIDs := make([]int64, len(cards))
+	for i, card := range cards {
+		issueIDs[i] = card.IssueID
+		assert.NotNil(t, card.Issue)
+	}
+	assert.Equal(t, expectedIssueIDs, issueIDs)
+}
+
+func TestNewProjectBoard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	board := &ProjectBoard{
+		RepoID:    1,
+		Title:     "sprint",
+		CreatorID: 2,
+	}
+	assert.NoError(t, NewProjectBoard(board, "Backlog", "Done"))
+	AssertExistsAndLoadBean(t, board)
+	if assert.Len(t, board.Columns, 2) {
+		AssertExistsAndLoadBean(t, &ProjectColumn{BoardID: board.ID, Title: "Backlog", Sorting: 0})
+		AssertExistsAndLoadBean(t, &ProjectColumn{BoardID: board.ID, Title: "Done", Sorting: 1})
+	}
+}
+
+func TestGetProjectBoardByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	board, err := GetProjectBoardByRepoID(1, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "roadmap", board.Title)
+
+	_, err = GetProjectBoardByRepoID(2, 1)
+	assert.True(t, IsErrProjectBoardNotExist(err))
+}
+
+func TestGetProjectBoardsByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	boards, err := GetProjectBoardsByRepoID(1)
+	assert.NoError(t, err)
+	assert.Len(t, boards, GetCount(t, &ProjectBoard{RepoID: 1}))
+
+	boards, err = GetProjectBoardsByRepoID(NonexistentID)
+	assert.NoError(t, err)
+	assert.Len(t, boards, 0)
+}
+
+func TestProjectBoard_LoadColumnsAndCards(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	board := AssertExistsAndLoadBean(t, &ProjectBoard{ID: 1}).(*ProjectBoard)
+	assert.NoError(t, board.LoadColumnsAndCards())
+	if assert.Len(t, board.Columns, 3) {
+		assert.EqualValues(t, "To Do", board.Columns[0].Title)
+		assert.Len(t, board.Columns[0].Cards, 2)
+		assert.Len(t, board.Columns[1].Cards, 0)
+		if assert.Len(t, board.Columns[2].Cards, 1) {
+			assert.EqualValues(t, 5, board.Columns[2].Cards[0].Issue.ID)
+		}
+	}
+
+	apiBoard := board.APIFormat()
+	if assert.Len(t, apiBoard.Columns, 3) {
+		assert.Len(t, apiBoard.Columns[0].Cards, 2)
+	}
+}
+
+func TestProjectBoard_FilterCards(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	board := AssertExistsAndLoadBean(t, &ProjectBoard{ID: 1}).(*ProjectBoard)
+	assert.NoError(t, board.LoadColumnsAndCards())
+
+	// issue 2 is a pull request, hidden without access to the pull requests
+	board.FilterCards(&Permission{
+		UnitsMode: map[UnitType]AccessMode{
+			UnitTypeProjects: AccessModeRead,
+			UnitTypeIssues:   AccessModeRead,
+		},
+	})
+	if assert.Len(t, board.Columns, 3) {
+		if assert.Len(t, board.Columns[0].Cards, 1) {
+			assert.EqualValues(t, 1, board.Columns[0].Cards[0].IssueID)
+		}
+		assert.Len(t, board.Columns[2].Cards, 1)
+	}
+
+	board.FilterCards(&Permission{
+		UnitsMode: map[UnitType]AccessMode{
+			UnitTypeProjects: AccessModeRead,
+		},
+	})
+	for _, c := range board.Columns {
+		assert.Len(t, c.Cards, 0)
+	}
+}
+
+func TestNewProjectColumn(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	column := &ProjectColumn{BoardID: 1, Title: "Review"}
+	assert.NoError(t, NewProjectColumn(column))
+	AssertExistsAndLoadBean(t, &ProjectColumn{ID: column.ID, Sorting: 3})
+}
+
+func TestDeleteProjectColumnByBoardID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	assert.NoError(t, DeleteProjectColumnByBoardID(1, 1))
+	AssertNotExistsBean(t, &ProjectColumn{ID: 1})
+	AssertNotExistsBean(t, &ProjectCard{ColumnID: 1})
+	AssertExistsAndLoadBean(t, &Issue{ID: 1})
+
+	assert.NoError(t, DeleteProjectColumnByBoardID(1, NonexistentID))
+}
+
+func TestDeleteProjectBoardByRepoID(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	assert.NoError(t, DeleteProjectBoardByRepoID(1, 1))
+	AssertNotExistsBean(t, &ProjectBoard{ID: 1})
+	AssertNotExistsBean(t, &ProjectColumn{BoardID: 1})
+	AssertNotExistsBean(t, &ProjectCard{BoardID: 1})
+
+	assert.NoError(t, DeleteProjectBoardByRepoID(1, NonexistentID))
+}
+
+func TestDeleteProjectBoardsOfRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	assert.NoError(t, deleteProjectBoardsOfRepo(x, 1))
+	AssertNotExistsBean(t, &ProjectBoard{RepoID: 1})
+	AssertNotExistsBean(t, &ProjectColumn{BoardID: 1})
+	AssertNotExistsBean(t, &ProjectCard{BoardID: 1})
+}
+
+func TestNewProjectCard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+	column := AssertExistsAndLoadBean(t, &ProjectColumn{ID: 1}).(*ProjectColumn)
+
+	card, err := NewProjectCard(doer, issue, column)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, card.Sorting)
+	assertProjectCards(t, column.ID, 1, 2, 3)
+	AssertExistsAndLoadBean(t, &Comment{
+		Type:               CommentTypeProjectCard,
+		PosterID:           doer.ID,
+		IssueID:            issue.ID,
+		ProjectBoardID:     1,
+		OldProjectColumnID: 0,
+		ProjectColumnID:    column.ID,
+	})
+
+	// an issue has a single card on each board
+	_, err = NewProjectCard(doer, issue, column)
+	assert.True(t, IsErrProjectCardAlreadyExist(err))
+}
+
+func TestMoveProjectCard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	card := AssertExistsAndLoadBean(t, &ProjectCard{ID: 2}).(*ProjectCard)
+	todo := AssertExistsAndLoadBean(t, &ProjectColumn{ID: 1}).(*ProjectColumn)
+	done := AssertExistsAndLoadBean(t, &ProjectColumn{ID: 3}).(*ProjectColumn)
+
+	// reordering a column is not recorded in the issue
+	assert.NoError(t, MoveProjectCard(doer, card, todo, 0))
+	assertProjectCards(t, todo.ID, 2, 1)
+	AssertNotExistsBean(t, &Comment{Type: CommentTypeProjectCard, IssueID: card.IssueID})
+
+	assert.NoError(t, MoveProjectCard(doer, card, done, 0))
+	assertProjectCards(t, todo.ID, 1)
+	assertProjectCards(t, done.ID, 2, 5)
+	AssertExistsAndLoadBean(t, &Comment{
+		Type:               CommentTypeProjectCard,
+		PosterID:           doer.ID,
+		IssueID:            card.IssueID,
+		ProjectBoardID:     1,
+		OldProjectColumnID: todo.ID,
+		ProjectColumnID:    done.ID,
+	})
+
+	// out of range positions append the card to the column
+	card = AssertExistsAndLoadBean(t, &ProjectCard{ID: 1}).(*ProjectCard)
+	assert.NoError(t, MoveProjectCard(doer, card, done, 10))
+	assertProjectCards(t, done.ID, 2, 5, 1)
+
+	column := &ProjectColumn{ID: NonexistentID, BoardID: NonexistentID}
+	assert.True(t, IsErrProjectColumnNotExist(MoveProjectCard(doer, card, column, 0)))
+}
+
+func TestDeleteProjectCard(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	card := AssertExistsAndLoadBean(t, &ProjectCard{ID: 3}).(*ProjectCard)
+
+	assert.NoError(t, DeleteProjectCard(doer, card))
+	AssertNotExistsBean(t, &ProjectCard{ID: card.ID})
+	AssertExistsAndLoadBean(t, &Comment{
+		Type:               CommentTypeProjectCard,
+		PosterID:           doer.ID,
+		IssueID:            card.IssueID,
+		ProjectBoardID:     1,
+		OldProjectColumnID: card.ColumnID,
+		ProjectColumnID:    0,
+	})
+}
+
+func TestComment_LoadProjectColumns(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	comment := &Comment{
+		Type:               CommentTypeProjectCard,
+		ProjectBoardID:     1,
+		OldProjectColumnID: 1,
+		ProjectColumnID:    NonexistentID,
+	}
+	assert.NoError(t, comment.LoadProjectColumns())
+	assert.NotNil(t, comment.ProjectBoard)
+	assert.NotNil(t, comment.OldProjectColumn)
+	assert.Nil(t, comment.ProjectColumn)
+}
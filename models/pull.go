@@ -19,8 +19,8 @@ import (
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
-	"code.gitea.io/gitea/modules/sync"
 	"code.gitea.io/gitea/modules/util"
 	api "code.gitea.io/sdk/gitea"
 
@@ -28,7 +28,7 @@ import (
 	"github.com/go-xorm/xorm"
 )
 
-var pullRequestQueue = sync.NewUniqueQueue(setting.Repository.PullRequestQueueLength)
+var pullRequestQueue = queue.NewNamed("pr_patch_checker", setting.Repository.PullRequestQueueLength)
 
 // PullRequestType defines pull request type
 type PullRequestType int
@@ -328,6 +328,27 @@ func (pr *PullRequest) CheckUserAllowedToMerge(doer *User) (err error) {
 	return nil
 }
 
+// GetMissingStatusContexts returns the status contexts required by the protected base branch
+// which have not succeeded yet for the head commit of the pull request.
+func (pr *PullRequest) GetMissingStatusContexts() ([]string, error) {
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return nil, fmt.Errorf("LoadProtectedBranch: %v", err)
+	}
+	if pr.ProtectedBranch == nil || !pr.ProtectedBranch.EnableStatusCheck {
+		return nil, nil
+	}
+
+	sha, err := pr.HeadCommitID()
+	if err != nil {
+		return nil, fmt.Errorf("HeadCommitID: %v", err)
+	}
+	statuses, err := getAllLatestCommitStatus(x, pr.BaseRepo, sha)
+	if err != nil {
+		return nil, fmt.Errorf("getAllLatestCommitStatus: %v", err)
+	}
+	return pr.ProtectedBranch.GetMissingStatusContexts(statuses), nil
+}
+
 // Merge merges pull request to base repository.
 // FIXME: add repoWorkingPull make sure two merges does not happen at same time.
 func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle MergeStyle, message string) (err error) {
@@ -347,6 +368,14 @@ func (pr *PullRequest) Merge(doer *User, baseGitRepo *git.Repository, mergeStyle
 		return fmt.Errorf("CheckUserAllowedToMerge: %v", err)
 	}
 
+	if missing, err := pr.GetMissingStatusContexts(); err != nil {
+		return fmt.Errorf("GetMissingStatusContexts: %v", err)
+	} else if len(missing) > 0 {
+		return ErrNotAllowedToMerge{
+			"Required status checks have not succeeded: " + strings.Join(missing, ", "),
+		}
+	}
+
 	// Check if merge style is correct and allowed
 	if !prConfig.IsMergeStyleAllowed(mergeStyle) {
 		return ErrInvalidMergeStyle{pr.BaseRepo.ID, mergeStyle}
@@ -1218,6 +1247,9 @@ func AddTestPullRequestTask(doer *User, repoID int64, branch string, isSync bool
 	}
 
 	if isSync {
+		// New commits have not been checked nor approved yet.
+		cancelScheduledMerges(doer, prs)
+
 		requests := PullRequestList(prs)
 		if err = requests.LoadAttributes(); err != nil {
 			log.Error(4, "PullRequestList.LoadAttributes: %v", err)
@@ -1303,6 +1335,8 @@ func (pr *PullRequest) checkAndUpdateStatus() {
 	if !pullRequestQueue.Exist(pr.ID) {
 		if err := pr.UpdateCols("status"); err != nil {
 			log.Error(4, "Update[%d]: %v", pr.ID, err)
+		} else if pr.Status == PullRequestStatusMergeable {
+			pr.AddToAutoMergeQueue()
 		}
 	}
 }
@@ -1339,7 +1373,6 @@ func (pr *PullRequest) GetWorkInProgressPrefix() string {
 }
 
 // TestPullRequests checks and tests untested patches of pull requests.
-// TODO: test more pull requests at same time.
 func TestPullRequests() {
 	prs := make([]*PullRequest, 0, 10)
 
@@ -1370,31 +1403,33 @@ func TestPullRequests() {
 	}
 
 	// Start listening on new test requests.
-	for prID := range pullRequestQueue.Queue() {
+	pullRequestQueue.Run(func(prID string) {
 		log.Trace("TestPullRequests[%v]: processing test task", prID)
-		pullRequestQueue.Remove(prID)
 
 		id := com.StrTo(prID).MustInt64()
 		if _, ok := checkedPRs[id]; ok {
-			continue
+			return
 		}
 
 		pr, err := GetPullRequestByID(id)
 		if err != nil {
 			log.Error(4, "GetPullRequestByID[%s]: %v", prID, err)
-			continue
+			return
 		} else if pr.manuallyMerged() {
-			continue
+			return
 		} else if err = pr.testPatch(x); err != nil {
 			log.Error(4, "testPatch[%d]: %v", pr.ID, err)
-			continue
+			return
 		}
 
 		pr.checkAndUpdateStatus()
-	}
+	})
 }
 
 // InitTestPullRequests runs the task to test all the checking status pull requests
 func InitTestPullRequests() {
+	if err := pullRequestQueue.Configure(setting.GetQueueSettings("pr_patch_checker", setting.Repository.PullRequestQueueLength)); err != nil {
+		log.Fatal(4, "Failed to create pull request queue: %v", err)
+	}
 	go TestPullRequests()
 }
@@ -0,0 +1,391 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"fmt"
+
+	"code.gitea.io/git"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/Unknwon/com"
+	"github.com/go-xorm/xorm"
+)
+
+var autoMergeQueue = queue.NewNamed("pr_auto_merge", setting.Repository.PullRequestQueueLength)
+
+// PullAutoMerge represents a pull request scheduled to be merged
+// as soon as its checks succeed and it has enough approvals.
+type PullAutoMerge struct {
+	ID          int64          `xorm:"pk autoincr"`
+	PullID      int64          `xorm:"UNIQUE"`
+	DoerID      int64          `xorm:"NOT NULL"`
+	Doer        *User          `xorm:"-"`
+	MergeStyle  MergeStyle     `xorm:"VARCHAR(30)"`
+	Message     string         `xorm:"LONGTEXT"`
+	CreatedUnix util.TimeStamp `xorm:"created"`
+}
+
+func (scheduled *PullAutoMerge) loadAttributes(e Engine) (err error) {
+	if scheduled.Doer == nil {
+		scheduled.Doer, err = getUserByID(e, scheduled.DoerID)
+		if IsErrUserNotExist(err) {
+			scheduled.DoerID = -1
+			scheduled.Doer = NewGhostUser()
+		} else if err != nil {
+			return fmt.Errorf("getUserByID [%d]: %v", scheduled.DoerID, err)
+		}
+	}
+	return nil
+}
+
+// LoadAttributes loads the user who scheduled the merge
+func (scheduled *PullAutoMerge) LoadAttributes() error {
+	return scheduled.loadAttributes(x)
+}
+
+func getScheduledMergeByPullID(e Engine, pullID int64) (*PullAutoMerge, error) {
+	scheduled := new(PullAutoMerge)
+	has, err := e.Where("pull_id = ?", pullID).Get(scheduled)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPullAutoMergeNotExist{pullID}
+	}
+	return scheduled, scheduled.loadAttributes(e)
+}
+
+// GetScheduledMergeByPullID returns the scheduled merge of the pull request
+func GetScheduledMergeByPullID(pullID int64) (*PullAutoMerge, error) {
+	return getScheduledMergeByPullID(x, pullID)
+}
+
+func createAutoMergeComment(e *xorm.Session, doer *User, pr *PullRequest, scheduled bool) error {
+	if err := pr.loadIssue(e); err != nil {
+		return err
+	} else if err := pr.Issue.loadRepo(e); err != nil {
+		return err
+	}
+
+	cType := CommentTypeAutoMergeScheduled
+	if !scheduled {
+		cType = CommentTypeAutoMergeCanceled
+	}
+	_, err := createComment(e, &CreateCommentOptions{
+		Type:  cType,
+		Doer:  doer,
+		Repo:  pr.Issue.Repo,
+		Issue: pr.Issue,
+	})
+	return err
+}
+
+// CheckUserAllowedToScheduleMerge checks whether the user is allowed to schedule the merge,
+// which unlike merging right away does not require the pull request to be approved yet.
+func (pr *PullRequest) CheckUserAllowedToScheduleMerge(doer *User) error {
+	if doer == nil {
+		return ErrNotAllowedToMerge{
+			"Not signed in",
+		}
+	}
+
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return fmt.Errorf("LoadProtectedBranch: %v", err)
+	}
+	if pr.ProtectedBranch != nil && !pr.ProtectedBranch.CanUserMerge(doer.ID) {
+		return ErrNotAllowedToMerge{
+			"The branch is protected",
+		}
+	}
+	return nil
+}
+
+// ScheduleAutoMerge schedules the pull request to be merged with the given style and message
+// once its commit statuses succeed and it has enough approvals.
+func ScheduleAutoMerge(doer *User, pr *PullRequest, style MergeStyle, message string) error {
+	if err := pr.GetBaseRepo(); err != nil {
+		return fmt.Errorf("GetBaseRepo: %v", err)
+	}
+	prUnit, err := pr.BaseRepo.GetUnit(UnitTypePullRequests)
+	if err != nil {
+		return err
+	}
+	if !prUnit.PullRequestsConfig().IsMergeStyleAllowed(style) {
+		return ErrInvalidMergeStyle{pr.BaseRepo.ID, style}
+	}
+	if err = pr.CheckUserAllowedToScheduleMerge(doer); err != nil {
+		return err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if exist, err := sess.Exist(&PullAutoMerge{PullID: pr.ID}); err != nil {
+		return err
+	} else if exist {
+		return ErrPullAutoMergeAlreadyScheduled{pr.ID}
+	}
+
+	if _, err := sess.Insert(&PullAutoMerge{
+		PullID:     pr.ID,
+		DoerID:     doer.ID,
+		MergeStyle: style,
+		Message:    message,
+	}); err != nil {
+		return fmt.Errorf("insert: %v", err)
+	}
+	if err := createAutoMergeComment(sess, doer, pr, true); err != nil {
+		return fmt.Errorf("createAutoMergeComment: %v", err)
+	}
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	pr.AddToAutoMergeQueue()
+	return nil
+}
+
+func removeScheduledMerge(e *xorm.Session, doer *User, pr *PullRequest, comment bool) (bool, error) {
+	affected, err := e.Delete(&PullAutoMerge{PullID: pr.ID})
+	if err != nil {
+		return false, err
+	} else if affected == 0 {
+		return false, nil
+	}
+
+	if comment {
+		if err = createAutoMergeComment(e, doer, pr, false); err != nil {
+			return false, fmt.Errorf("createAutoMergeComment: %v", err)
+		}
+	}
+	return true, nil
+}
+
+// CancelScheduledMerge cancels the automatic merge of the pull request
+func CancelScheduledMerge(doer *User, pr *PullRequest) error {
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if removed, err := removeScheduledMerge(sess, doer, pr, true); err != nil {
+		return err
+	} else if !removed {
+		return ErrPullAutoMergeNotExist{pr.ID}
+	}
+	return sess.Commit()
+}
+
+// cancelScheduledMerges cancels the automatic merge of the given pull requests,
+// which happens when new commits are pushed to their head branch.
+func cancelScheduledMerges(doer *User, prs []*PullRequest) {
+	for _, pr := range prs {
+		sess := x.NewSession()
+		if err := sess.Begin(); err != nil {
+			log.Error(4, "cancelScheduledMerges: %v", err)
+		} else if removed, err := removeScheduledMerge(sess, doer, pr, true); err != nil {
+			log.Error(4, "removeScheduledMerge[%d]: %v", pr.ID, err)
+		} else if removed {
+			if err = sess.Commit(); err != nil {
+				log.Error(4, "cancelScheduledMerges: %v", err)
+			}
+		}
+		sess.Close()
+	}
+}
+
+// HeadCommitID returns the ID of the head commit of the pull request as known by the base repository
+func (pr *PullRequest) HeadCommitID() (string, error) {
+	if err := pr.GetBaseRepo(); err != nil {
+		return "", err
+	}
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return "", err
+	}
+	return gitRepo.GetRefCommitID(pr.GetGitRefName())
+}
+
+// CommitStatusesSucceeded returns true if the head commit of the pull request
+// has commit statuses and all the latest of them are successful. A commit
+// without any status has not been checked yet, so it has not succeeded.
+func (pr *PullRequest) CommitStatusesSucceeded() (bool, error) {
+	sha, err := pr.HeadCommitID()
+	if err != nil {
+		return false, fmt.Errorf("HeadCommitID: %v", err)
+	}
+	statuses, err := getAllLatestCommitStatus(x, pr.BaseRepo, sha)
+	if err != nil {
+		return false, fmt.Errorf("getAllLatestCommitStatus: %v", err)
+	}
+	if len(statuses) == 0 {
+		return false, nil
+	}
+	for _, status := range statuses {
+		if status.State != CommitStatusSuccess {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// IsReadyToMerge returns true if the pull request can be merged right now
+// as far as its checks, approvals and dependencies are concerned.
+func (pr *PullRequest) IsReadyToMerge() (bool, error) {
+	if !pr.CanAutoMerge() || pr.HasMerged {
+		return false, nil
+	}
+	if err := pr.LoadIssue(); err != nil {
+		return false, err
+	} else if pr.Issue.IsClosed || pr.IsWorkInProgress() {
+		return false, nil
+	}
+
+	if noDeps, err := IssueNoDependenciesLeft(pr.Issue); err != nil {
+		return false, fmt.Errorf("IssueNoDependenciesLeft: %v", err)
+	} else if !noDeps {
+		return false, nil
+	}
+
+	if err := pr.LoadProtectedBranch(); err != nil {
+		return false, fmt.Errorf("LoadProtectedBranch: %v", err)
+	} else if pr.ProtectedBranch != nil && !pr.ProtectedBranch.HasEnoughApprovals(pr) {
+		return false, nil
+	}
+
+	// Only the required statuses matter when the base branch requires some.
+	if pr.ProtectedBranch != nil && pr.ProtectedBranch.EnableStatusCheck && len(pr.ProtectedBranch.StatusCheckContexts) > 0 {
+		missing, err := pr.GetMissingStatusContexts()
+		if err != nil {
+			return false, err
+		}
+		return len(missing) == 0, nil
+	}
+	return pr.CommitStatusesSucceeded()
+}
+
+// AddToAutoMergeQueue checks the pull request again if it is scheduled to be merged automatically.
+func (pr *PullRequest) AddToAutoMergeQueue() {
+	go autoMergeQueue.Add(pr.ID)
+}
+
+// addScheduledMergesToQueue adds the scheduled merges of the pull requests
+// whose base or head repository is the given one to the queue.
+func addScheduledMergesToQueue(repoID int64) {
+	ids := make([]int64, 0, 10)
+	if err := x.Table("pull_auto_merge").
+		Join("INNER", "pull_request", "pull_request.id = pull_auto_merge.pull_id").
+		Where("pull_request.base_repo_id = ? OR pull_request.head_repo_id = ?", repoID, repoID).
+		Cols("pull_auto_merge.pull_id").
+		Find(&ids); err != nil {
+		log.Error(4, "Find scheduled merges [repo_id: %d]: %v", repoID, err)
+		return
+	}
+	for _, id := range ids {
+		go autoMergeQueue.Add(id)
+	}
+}
+
+// handleAutoMerge merges the pull request if it is scheduled and ready to be merged.
+func handleAutoMerge(prID string) {
+	log.Trace("handleAutoMerge[%v]: processing auto merge task", prID)
+
+	id := com.StrTo(prID).MustInt64()
+	scheduled, err := GetScheduledMergeByPullID(id)
+	if err != nil {
+		if !IsErrPullAutoMergeNotExist(err) {
+			log.Error(4, "GetScheduledMergeByPullID[%d]: %v", id, err)
+		}
+		return
+	}
+
+	pr, err := GetPullRequestByID(id)
+	if err != nil {
+		log.Error(4, "GetPullRequestByID[%d]: %v", id, err)
+		return
+	} else if err = pr.LoadIssue(); err != nil {
+		log.Error(4, "LoadIssue[%d]: %v", id, err)
+		return
+	}
+	if pr.HasMerged || pr.Issue.IsClosed {
+		if _, err = x.Delete(&PullAutoMerge{ID: scheduled.ID}); err != nil {
+			log.Error(4, "Delete scheduled merge[%d]: %v", id, err)
+		}
+		return
+	}
+
+	if ready, err := pr.IsReadyToMerge(); err != nil {
+		log.Error(4, "IsReadyToMerge[%d]: %v", id, err)
+		return
+	} else if !ready {
+		return
+	}
+
+	// Only one worker may claim the scheduled merge.
+	if affected, err := x.Delete(&PullAutoMerge{ID: scheduled.ID}); err != nil {
+		log.Error(4, "Delete scheduled merge[%d]: %v", id, err)
+		return
+	} else if affected != 1 {
+		return
+	}
+
+	if err = mergeScheduled(scheduled, pr); err != nil {
+		log.Error(4, "Auto merge pull request[%d]: %v", id, err)
+		sess := x.NewSession()
+		defer sess.Close()
+		if err = sess.Begin(); err != nil {
+			log.Error(4, "handleAutoMerge: %v", err)
+		} else if err = createAutoMergeComment(sess, scheduled.Doer, pr, false); err != nil {
+			log.Error(4, "createAutoMergeComment[%d]: %v", id, err)
+		} else if err = sess.Commit(); err != nil {
+			log.Error(4, "handleAutoMerge: %v", err)
+		}
+	}
+}
+
+func mergeScheduled(scheduled *PullAutoMerge, pr *PullRequest) error {
+	if err := pr.GetBaseRepo(); err != nil {
+		return fmt.Errorf("GetBaseRepo: %v", err)
+	}
+	perm, err := GetUserRepoPermission(pr.BaseRepo, scheduled.Doer)
+	if err != nil {
+		return fmt.Errorf("GetUserRepoPermission: %v", err)
+	} else if !perm.CanWrite(UnitTypeCode) {
+		return ErrNotAllowedToMerge{"The user who scheduled the merge cannot write to the repository"}
+	}
+	// The merge whitelist or the approvals may have changed since the merge was scheduled.
+	if err = pr.CheckUserAllowedToMerge(scheduled.Doer); err != nil {
+		return err
+	}
+
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	if err != nil {
+		return fmt.Errorf("OpenRepository: %v", err)
+	}
+	return pr.Merge(scheduled.Doer, gitRepo, scheduled.MergeStyle, scheduled.Message)
+}
+
+// InitAutoMergePullRequests runs the task merging the pull requests scheduled to be merged automatically
+func InitAutoMergePullRequests() {
+	if err := autoMergeQueue.Configure(setting.GetQueueSettings("pr_auto_merge", setting.Repository.PullRequestQueueLength)); err != nil {
+		log.Fatal(4, "Failed to create auto merge queue: %v", err)
+	}
+
+	ids := make([]int64, 0, 10)
+	if err := x.Table("pull_auto_merge").Cols("pull_id").Find(&ids); err != nil {
+		log.Error(4, "Find scheduled merges: %v", err)
+	}
+	for _, id := range ids {
+		go autoMergeQueue.Add(id)
+	}
+	go autoMergeQueue.Run(handleAutoMerge)
+}
@@ -0,0 +1,156 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/git"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleAutoMerge(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+
+	assert.NoError(t, ScheduleAutoMerge(doer, pr, MergeStyleMerge, "merge message"))
+	scheduled, err := GetScheduledMergeByPullID(pr.ID)
+	assert.NoError(t, err)
+	assert.EqualValues(t, doer.ID, scheduled.Doer.ID)
+	assert.EqualValues(t, MergeStyleMerge, scheduled.MergeStyle)
+	assert.EqualValues(t, "merge message", scheduled.Message)
+	AssertExistsAndLoadBean(t, &Comment{IssueID: pr.IssueID, PosterID: doer.ID, Type: CommentTypeAutoMergeScheduled})
+
+	err = ScheduleAutoMerge(doer, pr, MergeStyleSquash, "")
+	assert.True(t, IsErrPullAutoMergeAlreadyScheduled(err))
+
+	assert.NoError(t, CancelScheduledMerge(doer, pr))
+	AssertNotExistsBean(t, &PullAutoMerge{PullID: pr.ID})
+	AssertExistsAndLoadBean(t, &Comment{IssueID: pr.IssueID, PosterID: doer.ID, Type: CommentTypeAutoMergeCanceled})
+
+	err = CancelScheduledMerge(doer, pr)
+	assert.True(t, IsErrPullAutoMergeNotExist(err))
+	_, err = GetScheduledMergeByPullID(pr.ID)
+	assert.True(t, IsErrPullAutoMergeNotExist(err))
+}
+
+func TestScheduleAutoMerge_InvalidStyle(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+
+	err := ScheduleAutoMerge(doer, pr, MergeStyle("unknown"), "")
+	assert.True(t, IsErrInvalidMergeStyle(err))
+	AssertNotExistsBean(t, &PullAutoMerge{PullID: pr.ID})
+}
+
+func TestCancelScheduledMerges(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	pusher := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+
+	assert.NoError(t, ScheduleAutoMerge(doer, pr, MergeStyleMerge, ""))
+	cancelScheduledMerges(pusher, []*PullRequest{pr})
+	AssertNotExistsBean(t, &PullAutoMerge{PullID: pr.ID})
+	AssertExistsAndLoadBean(t, &Comment{IssueID: pr.IssueID, PosterID: pusher.ID, Type: CommentTypeAutoMergeCanceled})
+}
+
+func TestPullRequest_IsReadyToMerge(t *testing.T) {
+	PrepareTestEnv(t)
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	assert.NoError(t, pr.GetBaseRepo())
+
+	// the head commit of the pull request is the head of master
+	gitRepo, err := git.OpenRepository(pr.BaseRepo.RepoPath())
+	assert.NoError(t, err)
+	sha, err := gitRepo.GetBranchCommitID("master")
+	assert.NoError(t, err)
+	_, err = git.NewCommand("update-ref", pr.GetGitRefName(), sha).RunInDir(pr.BaseRepo.RepoPath())
+	assert.NoError(t, err)
+
+	// a commit without statuses has not been checked yet
+	ready, err := pr.IsReadyToMerge()
+	assert.NoError(t, err)
+	assert.False(t, ready)
+
+	creator := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	assert.NoError(t, NewCommitStatus(pr.BaseRepo, creator, sha, &CommitStatus{State: CommitStatusSuccess, Context: "ci"}))
+	assert.NoError(t, NewCommitStatus(pr.BaseRepo, creator, sha, &CommitStatus{State: CommitStatusPending, Context: "deploy"}))
+	ready, err = pr.IsReadyToMerge()
+	assert.NoError(t, err)
+	assert.False(t, ready)
+
+	assert.NoError(t, NewCommitStatus(pr.BaseRepo, creator, sha, &CommitStatus{State: CommitStatusSuccess, Context: "deploy"}))
+	ready, err = pr.IsReadyToMerge()
+	assert.NoError(t, err)
+	assert.True(t, ready)
+
+	// only the required statuses matter once the protected branch requires some
+	protectBranch := &ProtectedBranch{
+		RepoID:              pr.BaseRepo.ID,
+		BranchName:          "master",
+		EnableStatusCheck:   true,
+		StatusCheckContexts: []string{"ci"},
+	}
+	assert.NoError(t, UpdateProtectBranch(pr.BaseRepo, protectBranch, WhitelistOptions{}))
+	assert.NoError(t, NewCommitStatus(pr.BaseRepo, creator, sha, &CommitStatus{State: CommitStatusFailure, Context: "deploy"}))
+	pr.ProtectedBranch = nil
+	ready, err = pr.IsReadyToMerge()
+	assert.NoError(t, err)
+	assert.True(t, ready)
+
+	assert.NoError(t, NewCommitStatus(pr.BaseRepo, creator, sha, &CommitStatus{State: CommitStatusPending, Context: "ci"}))
+	missing, err := pr.GetMissingStatusContexts()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"ci"}, missing)
+	ready, err = pr.IsReadyToMerge()
+	assert.NoError(t, err)
+	assert.False(t, ready)
+
+	// approvals are required by the protected branch
+	assert.NoError(t, NewCommitStatus(pr.BaseRepo, creator, sha, &CommitStatus{State: CommitStatusSuccess, Context: "ci"}))
+	protectBranch.RequiredApprovals = 1
+	assert.NoError(t, UpdateProtectBranch(pr.BaseRepo, protectBranch, WhitelistOptions{}))
+	pr.ProtectedBranch = nil
+	ready, err = pr.IsReadyToMerge()
+	assert.NoError(t, err)
+	assert.False(t, ready)
+
+	pr.Status = PullRequestStatusChecking
+	ready, err = pr.IsReadyToMerge()
+	assert.NoError(t, err)
+	assert.False(t, ready)
+}
+
+func TestHandleAutoMerge_Merged(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 1}).(*PullRequest)
+	assert.True(t, pr.HasMerged)
+
+	_, err := x.Insert(&PullAutoMerge{PullID: pr.ID, DoerID: 2, MergeStyle: MergeStyleMerge})
+	assert.NoError(t, err)
+	handleAutoMerge("1")
+	AssertNotExistsBean(t, &PullAutoMerge{PullID: pr.ID})
+}
+
+func TestMergeScheduled_NotAllowed(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 2}).(*PullRequest)
+	assert.NoError(t, pr.GetBaseRepo())
+	scheduled := &PullAutoMerge{PullID: pr.ID, DoerID: 2, MergeStyle: MergeStyleMerge}
+	assert.NoError(t, scheduled.LoadAttributes())
+
+	// the merge whitelist changed after the merge was scheduled
+	assert.NoError(t, UpdateProtectBranch(pr.BaseRepo, &ProtectedBranch{
+		RepoID:               pr.BaseRepo.ID,
+		BranchName:           pr.BaseBranch,
+		EnableMergeWhitelist: true,
+	}, WhitelistOptions{}))
+	err := mergeScheduled(scheduled, pr)
+	assert.True(t, IsErrNotAllowedToMerge(err))
+}
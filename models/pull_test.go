@@ -5,7 +5,6 @@
 package models
 
 import (
-	"strconv"
 	"testing"
 	"time"
 
@@ -194,14 +193,11 @@ func TestPullRequest_AddToTaskQueue(t *testing.T) {
 	pr := AssertExistsAndLoadBean(t, &PullRequest{ID: 1}).(*PullRequest)
 	pr.AddToTaskQueue()
 
-	select {
-	case id := <-pullRequestQueue.Queue():
-		assert.EqualValues(t, strconv.FormatInt(pr.ID, 10), id)
-	case <-time.After(time.Second):
-		assert.Fail(t, "Timeout: nothing was added to pullRequestQueue")
+	// the pull request is added asynchronously
+	for i := 0; i < 100 && !pullRequestQueue.Exist(pr.ID); i++ {
+		time.Sleep(10 * time.Millisecond)
 	}
-
-	assert.True(t, pullRequestQueue.Exist(pr.ID))
+	assert.True(t, pullRequestQueue.Exist(pr.ID), "Timeout: nothing was added to pullRequestQueue")
 	pr = AssertExistsAndLoadBean(t, &PullRequest{ID: 1}).(*PullRequest)
 	assert.Equal(t, PullRequestStatusChecking, pr.Status)
 }
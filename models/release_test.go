@@ -133,8 +133,8 @@ func TestRelease_MirrorDelete(t *testing.T) {
 	err = mirror.GetMirror()
 	assert.NoError(t, err)
 
-	_, ok := mirror.Mirror.runSync()
-	assert.True(t, ok)
+	_, err = mirror.Mirror.runSync(nil)
+	assert.NoError(t, err)
 
 	count, err := GetReleaseCountByRepoID(mirror.ID, findOptions)
 	assert.EqualValues(t, initCount+1, count)
@@ -143,8 +143,8 @@ func TestRelease_MirrorDelete(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NoError(t, DeleteReleaseByID(release.ID, user, true))
 
-	_, ok = mirror.Mirror.runSync()
-	assert.True(t, ok)
+	_, err = mirror.Mirror.runSync(nil)
+	assert.NoError(t, err)
 
 	count, err = GetReleaseCountByRepoID(mirror.ID, findOptions)
 	assert.EqualValues(t, initCount, count)
@@ -26,6 +26,7 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/process"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/sync"
 	"code.gitea.io/gitea/modules/util"
 	api "code.gitea.io/sdk/gitea"
@@ -195,13 +196,18 @@ type Repository struct {
 	ExternalMetas map[string]string `xorm:"-"`
 	Units         []*RepoUnit       `xorm:"-"`
 
-	IsFork        bool               `xorm:"INDEX NOT NULL DEFAULT false"`
-	ForkID        int64              `xorm:"INDEX"`
-	BaseRepo      *Repository        `xorm:"-"`
-	Size          int64              `xorm:"NOT NULL DEFAULT 0"`
-	IndexerStatus *RepoIndexerStatus `xorm:"-"`
-	IsFsckEnabled bool               `xorm:"NOT NULL DEFAULT true"`
-	Topics        []string           `xorm:"TEXT JSON"`
+	IsFork        bool        `xorm:"INDEX NOT NULL DEFAULT false"`
+	ForkID        int64       `xorm:"INDEX"`
+	BaseRepo      *Repository `xorm:"-"`
+	Size          int64       `xorm:"NOT NULL DEFAULT 0"`
+	IsFsckEnabled bool        `xorm:"NOT NULL DEFAULT true"`
+	Topics        []string    `xorm:"TEXT JSON"`
+
+	// IndexerBranches and IndexerTags are the comma-separated patterns of
+	// the branches, besides the default one, and tags indexed by the repo
+	// indexer, see IndexerBranchPatterns and IndexerTagPatterns
+	IndexerBranches string `xorm:"TEXT"`
+	IndexerTags     string `xorm:"TEXT"`
 
 	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
@@ -709,7 +715,22 @@ func (repo *Repository) getUsersWithAccessMode(e Engine, mode AccessMode) (_ []*
 // FIXME: should have a mutex to prevent producing same index for two issues that are created
 // closely enough.
 func (repo *Repository) NextIssueIndex() int64 {
-	return int64(repo.NumIssues+repo.NumPulls) + 1
+	index, err := repo.nextIssueIndex(x)
+	if err != nil {
+		log.Error(4, "nextIssueIndex: %v", err)
+		return int64(repo.NumIssues+repo.NumPulls) + 1
+	}
+	return index
+}
+
+// nextIssueIndex returns the index following the greatest one of the
+// repository, which is not the number of issues if they have been migrated
+func (repo *Repository) nextIssueIndex(e Engine) (int64, error) {
+	var maxIndex int64
+	if _, err := e.Table("issue").Select("COALESCE(MAX(`index`), 0)").Where("repo_id = ?", repo.ID).Get(&maxIndex); err != nil {
+		return 0, err
+	}
+	return maxIndex + 1, nil
 }
 
 var (
@@ -1500,10 +1521,12 @@ func TransferOwnership(doer *User, newOwnerName string, repo *Repository) error
 		}
 	}
 
-	// Remove old team-repository relations.
+	// Remove old team-repository relations and organization labels.
 	if owner.IsOrganization() {
 		if err = owner.removeOrgRepo(sess, repo.ID); err != nil {
 			return fmt.Errorf("removeOrgRepo: %v", err)
+		} else if err = removeOrgLabelsFromRepo(sess, owner.ID, repo.ID); err != nil {
+			return fmt.Errorf("removeOrgLabelsFromRepo: %v", err)
 		}
 	}
 
@@ -1763,6 +1786,9 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 				return err
 			}
 		}
+		if err = removeOrgLabelsFromRepo(sess, org.ID, repoID); err != nil {
+			return fmt.Errorf("removeOrgLabelsFromRepo: %v", err)
+		}
 	}
 
 	if err = deleteBeans(sess,
@@ -1771,6 +1797,8 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Watch{RepoID: repoID},
 		&Star{RepoID: repoID},
 		&Mirror{RepoID: repoID},
+		&PushMirror{RepoID: repoID},
+		&MirrorSyncRecord{RepoID: repoID},
 		&Milestone{RepoID: repoID},
 		&Release{RepoID: repoID},
 		&Collaboration{RepoID: repoID},
@@ -1780,10 +1808,17 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		&Webhook{RepoID: repoID},
 		&HookTask{RepoID: repoID},
 		&Notification{RepoID: repoID},
+		&AccessToken{RepoID: repoID},
+		&RepoIndexerStatus{RepoID: repoID},
+		&Secret{RepoID: repoID},
 	); err != nil {
 		return fmt.Errorf("deleteBeans: %v", err)
 	}
 
+	if err = deleteProjectBoardsOfRepo(sess, repoID); err != nil {
+		return fmt.Errorf("deleteProjectBoardsOfRepo: %v", err)
+	}
+
 	deleteCond := builder.Select("id").From("issue").Where(builder.Eq{"repo_id": repoID})
 	// Delete comments and attachments
 	if _, err = sess.In("issue_id", deleteCond).
@@ -1819,7 +1854,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 		return err
 	}
 	for j := range attachments {
-		attachmentPaths = append(attachmentPaths, attachments[j].LocalPath())
+		attachmentPaths = append(attachmentPaths, attachments[j].RelativePath())
 	}
 
 	if _, err = sess.In("issue_id", deleteCond).
@@ -1853,7 +1888,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 
 	// Remove attachment files.
 	for i := range attachmentPaths {
-		removeAllWithNotice(sess, "Delete attachment", attachmentPaths[i])
+		removeStorageWithNotice(sess, storage.Attachments, "Delete attachment", attachmentPaths[i])
 	}
 
 	// Remove LFS objects
@@ -1872,11 +1907,7 @@ func DeleteRepository(doer *User, uid, repoID int64) error {
 			continue
 		}
 
-		oidPath := filepath.Join(v.Oid[0:2], v.Oid[2:4], v.Oid[4:len(v.Oid)])
-		err = os.Remove(filepath.Join(setting.LFS.ContentPath, oidPath))
-		if err != nil {
-			return err
-		}
+		removeStorageWithNotice(sess, storage.LFS, "Delete orphaned LFS file", v.RelativePath())
 	}
 
 	if _, err := sess.Delete(&LFSMetaObject{RepositoryID: repoID}); err != nil {
@@ -2168,10 +2199,11 @@ func SyncRepositoryHooks() error {
 var taskStatusTable = sync.NewStatusTable()
 
 const (
-	mirrorUpdate   = "mirror_update"
-	gitFsck        = "git_fsck"
-	checkRepos     = "check_repos"
-	archiveCleanup = "archive_cleanup"
+	mirrorUpdate     = "mirror_update"
+	pushMirrorUpdate = "push_mirror_update"
+	gitFsck          = "git_fsck"
+	checkRepos       = "check_repos"
+	archiveCleanup   = "archive_cleanup"
 )
 
 // GitFsck calls 'git fsck' to check repository health.
@@ -5,6 +5,7 @@
 package models
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,6 +24,7 @@ import (
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 )
 
 // ___________    .___.__  __    ___________.__.__
@@ -72,6 +74,37 @@ func (repo *Repository) CheckoutNewBranch(oldBranch, newBranch string) error {
 	return checkoutNewBranch(repo.RepoPath(), repo.LocalCopyPath(), oldBranch, newBranch)
 }
 
+// checkRepoFileSHA checks that the blob of treePath on branch has the given SHA.
+func checkRepoFileSHA(repo *Repository, branch, treePath, sha string) error {
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		return fmt.Errorf("OpenRepository: %v", err)
+	}
+	commit, err := gitRepo.GetBranchCommit(branch)
+	if err != nil {
+		return fmt.Errorf("GetBranchCommit [branch: %s]: %v", branch, err)
+	}
+	entry, err := commit.GetTreeEntryByPath(treePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			return ErrRepoFileDoesNotExist{treePath}
+		}
+		return fmt.Errorf("GetTreeEntryByPath [path: %s]: %v", treePath, err)
+	}
+	if entry.ID.String() != sha {
+		return ErrSHADoesNotMatch{Path: treePath, GivenSHA: sha, CurrentSHA: entry.ID.String()}
+	}
+	return nil
+}
+
+// committerSig returns the given committer or the signature of doer if it is nil.
+func committerSig(doer *User, committer *git.Signature) *git.Signature {
+	if committer != nil {
+		return committer
+	}
+	return doer.NewGitSig()
+}
+
 // UpdateRepoFileOptions holds the repository file update options
 type UpdateRepoFileOptions struct {
 	LastCommitID string
@@ -82,6 +115,10 @@ type UpdateRepoFileOptions struct {
 	Message      string
 	Content      string
 	IsNewFile    bool
+	// SHA is the expected blob SHA of OldTreeName, it is not checked if empty.
+	SHA       string
+	Author    *git.Signature
+	Committer *git.Signature
 }
 
 // UpdateRepoFile adds or updates a file in repository.
@@ -95,6 +132,12 @@ func (repo *Repository) UpdateRepoFile(doer *User, opts UpdateRepoFileOptions) (
 		return fmt.Errorf("UpdateLocalCopyBranch [branch: %s]: %v", opts.OldBranch, err)
 	}
 
+	if len(opts.SHA) > 0 {
+		if err = checkRepoFileSHA(repo, opts.OldBranch, opts.OldTreeName, opts.SHA); err != nil {
+			return err
+		}
+	}
+
 	if opts.OldBranch != opts.NewBranch {
 		if err := repo.CheckoutNewBranch(opts.OldBranch, opts.NewBranch); err != nil {
 			return fmt.Errorf("CheckoutNewBranch [old_branch: %s, new_branch: %s]: %v", opts.OldBranch, opts.NewBranch, err)
@@ -132,7 +175,8 @@ func (repo *Repository) UpdateRepoFile(doer *User, opts UpdateRepoFileOptions) (
 	if err = git.AddChanges(localPath, true); err != nil {
 		return fmt.Errorf("git add --all: %v", err)
 	} else if err = git.CommitChanges(localPath, git.CommitChangesOptions{
-		Committer: doer.NewGitSig(),
+		Committer: committerSig(doer, opts.Committer),
+		Author:    opts.Author,
 		Message:   opts.Message,
 	}); err != nil {
 		return fmt.Errorf("CommitChanges: %v", err)
@@ -249,6 +293,10 @@ type DeleteRepoFileOptions struct {
 	NewBranch    string
 	TreePath     string
 	Message      string
+	// SHA is the expected blob SHA of TreePath, it is not checked if empty.
+	SHA       string
+	Author    *git.Signature
+	Committer *git.Signature
 }
 
 // DeleteRepoFile deletes a repository file
@@ -262,6 +310,12 @@ func (repo *Repository) DeleteRepoFile(doer *User, opts DeleteRepoFileOptions) (
 		return fmt.Errorf("UpdateLocalCopyBranch [branch: %s]: %v", opts.OldBranch, err)
 	}
 
+	if len(opts.SHA) > 0 {
+		if err = checkRepoFileSHA(repo, opts.OldBranch, opts.TreePath, opts.SHA); err != nil {
+			return err
+		}
+	}
+
 	if opts.OldBranch != opts.NewBranch {
 		if err := repo.CheckoutNewBranch(opts.OldBranch, opts.NewBranch); err != nil {
 			return fmt.Errorf("CheckoutNewBranch [old_branch: %s, new_branch: %s]: %v", opts.OldBranch, opts.NewBranch, err)
@@ -276,7 +330,8 @@ func (repo *Repository) DeleteRepoFile(doer *User, opts DeleteRepoFileOptions) (
 	if err = git.AddChanges(localPath, true); err != nil {
 		return fmt.Errorf("git add --all: %v", err)
 	} else if err = git.CommitChanges(localPath, git.CommitChangesOptions{
-		Committer: doer.NewGitSig(),
+		Committer: committerSig(doer, opts.Committer),
+		Author:    opts.Author,
 		Message:   opts.Message,
 	}); err != nil {
 		return fmt.Errorf("CommitChanges: %v", err)
@@ -340,14 +395,14 @@ type Upload struct {
 	Name string
 }
 
-// UploadLocalPath returns where uploads is stored in local file system based on given UUID.
-func UploadLocalPath(uuid string) string {
-	return path.Join(setting.Repository.Upload.TempPath, uuid[0:1], uuid[1:2], uuid)
+// UploadRelativePath returns the relative path of an upload in the uploads storage based on given UUID.
+func UploadRelativePath(uuid string) string {
+	return path.Join(uuid[0:1], uuid[1:2], uuid)
 }
 
-// LocalPath returns where uploads are temporarily stored in local file system.
-func (upload *Upload) LocalPath() string {
-	return UploadLocalPath(upload.UUID)
+// RelativePath returns the relative path where the upload is temporarily stored in the uploads storage.
+func (upload *Upload) RelativePath() string {
+	return UploadRelativePath(upload.UUID)
 }
 
 // NewUpload creates a new upload object.
@@ -357,28 +412,33 @@ func NewUpload(name string, buf []byte, file multipart.File) (_ *Upload, err err
 		Name: name,
 	}
 
-	localPath := upload.LocalPath()
-	if err = os.MkdirAll(path.Dir(localPath), os.ModePerm); err != nil {
-		return nil, fmt.Errorf("MkdirAll: %v", err)
+	if _, err = storage.Uploads.Save(upload.RelativePath(), io.MultiReader(bytes.NewReader(buf), file)); err != nil {
+		return nil, fmt.Errorf("Save: %v", err)
 	}
 
-	fw, err := os.Create(localPath)
-	if err != nil {
-		return nil, fmt.Errorf("Create: %v", err)
+	if _, err := x.Insert(upload); err != nil {
+		return nil, err
 	}
-	defer fw.Close()
 
-	if _, err = fw.Write(buf); err != nil {
-		return nil, fmt.Errorf("Write: %v", err)
-	} else if _, err = io.Copy(fw, file); err != nil {
-		return nil, fmt.Errorf("Copy: %v", err)
+	return upload, nil
+}
+
+// copyUploadToFile writes the content of an upload to the given local file.
+func copyUploadToFile(upload *Upload, targetPath string) error {
+	fr, err := storage.Uploads.Open(upload.RelativePath())
+	if err != nil {
+		return err
 	}
+	defer fr.Close()
 
-	if _, err := x.Insert(upload); err != nil {
-		return nil, err
+	fw, err := os.Create(targetPath)
+	if err != nil {
+		return err
 	}
+	defer fw.Close()
 
-	return upload, nil
+	_, err = io.Copy(fw, fr)
+	return err
 }
 
 // GetUploadByUUID returns the Upload by UUID
@@ -427,12 +487,7 @@ func DeleteUploads(uploads ...*Upload) (err error) {
 	}
 
 	for _, upload := range uploads {
-		localPath := upload.LocalPath()
-		if !com.IsFile(localPath) {
-			continue
-		}
-
-		if err := os.Remove(localPath); err != nil {
+		if err := storage.Uploads.Delete(upload.RelativePath()); err != nil {
 			return fmt.Errorf("remove upload: %v", err)
 		}
 	}
@@ -507,13 +562,11 @@ func (repo *Repository) UploadRepoFiles(doer *User, opts UploadRepoFileOptions)
 
 	// Copy uploaded files into repository.
 	for _, upload := range uploads {
-		tmpPath := upload.LocalPath()
 		targetPath := path.Join(dirPath, upload.Name)
-		if !com.IsFile(tmpPath) {
-			continue
-		}
-
-		if err = com.Copy(tmpPath, targetPath); err != nil {
+		if err = copyUploadToFile(upload, targetPath); err != nil {
+			if storage.IsErrObjectNotExist(err) {
+				continue
+			}
 			return fmt.Errorf("Copy: %v", err)
 		}
 	}
@@ -6,6 +6,7 @@ package models
 
 import (
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 
@@ -13,69 +14,165 @@ import (
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
-
-	"github.com/ethantkoenig/rupture"
 )
 
-// RepoIndexerStatus status of a repo's entry in the repo indexer
-// For now, implicitly refers to default branch
+// RepoIndexerStatus status of a ref's entry in the repo indexer, the default
+// branch being the indexer.RepoIndexerDefaultRef ref
 type RepoIndexerStatus struct {
 	ID        int64  `xorm:"pk autoincr"`
 	RepoID    int64  `xorm:"INDEX"`
+	RefName   string `xorm:"VARCHAR(255) NOT NULL DEFAULT ''"`
 	CommitSha string `xorm:"VARCHAR(40)"`
 }
 
-func (repo *Repository) getIndexerStatus() error {
-	if repo.IndexerStatus != nil {
-		return nil
+// getIndexerStatuses returns the statuses of the indexed refs of the
+// repository, by ref name
+func (repo *Repository) getIndexerStatuses() (map[string]*RepoIndexerStatus, error) {
+	statuses := make([]*RepoIndexerStatus, 0, 1)
+	if err := x.Where("repo_id = ?", repo.ID).Find(&statuses); err != nil {
+		return nil, err
 	}
-	status := &RepoIndexerStatus{RepoID: repo.ID}
-	has, err := x.Get(status)
-	if err != nil {
-		return err
-	} else if !has {
-		status.CommitSha = ""
+	statusMap := make(map[string]*RepoIndexerStatus, len(statuses))
+	for _, status := range statuses {
+		statusMap[status.RefName] = status
 	}
-	repo.IndexerStatus = status
-	return nil
+	return statusMap, nil
 }
 
-func (repo *Repository) updateIndexerStatus(sha string) error {
-	if err := repo.getIndexerStatus(); err != nil {
+func (repo *Repository) updateIndexerStatus(status *RepoIndexerStatus, sha string) error {
+	status.CommitSha = sha
+	if status.ID == 0 {
+		_, err := x.Insert(status)
 		return err
 	}
-	if len(repo.IndexerStatus.CommitSha) == 0 {
-		repo.IndexerStatus.CommitSha = sha
-		_, err := x.Insert(repo.IndexerStatus)
-		return err
-	}
-	repo.IndexerStatus.CommitSha = sha
-	_, err := x.ID(repo.IndexerStatus.ID).Cols("commit_sha").
-		Update(repo.IndexerStatus)
+	_, err := x.ID(status.ID).Cols("commit_sha").Update(status)
 	return err
 }
 
+// GetIndexedRefs returns the sorted names of the refs of the repository,
+// other than its default branch, which are indexed by the repo indexer
+func (repo *Repository) GetIndexedRefs() ([]string, error) {
+	refs := make([]string, 0, 5)
+	if err := x.Table("repo_indexer_status").
+		Where("repo_id = ? AND ref_name <> ?", repo.ID, indexer.RepoIndexerDefaultRef).
+		Asc("ref_name").
+		Cols("ref_name").
+		Find(&refs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// splitRefPatterns splits comma-separated ref patterns
+func splitRefPatterns(patterns string) []string {
+	fields := strings.Split(patterns, ",")
+	result := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); len(field) > 0 {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// IndexerBranchPatterns returns the patterns, as accepted by path.Match, of
+// the names of the branches to index besides the default branch
+func (repo *Repository) IndexerBranchPatterns() []string {
+	return splitRefPatterns(repo.IndexerBranches)
+}
+
+// IndexerTagPatterns returns the patterns, as accepted by path.Match, of the
+// names of the tags to index
+func (repo *Repository) IndexerTagPatterns() []string {
+	return splitRefPatterns(repo.IndexerTags)
+}
+
+// ValidateRefPatterns checks that the comma-separated ref patterns are well
+// formed, and returns them normalized
+func ValidateRefPatterns(patterns string) (string, error) {
+	result := splitRefPatterns(patterns)
+	for _, pattern := range result {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return "", ErrInvalidRefPattern{Pattern: pattern}
+		}
+	}
+	return strings.Join(result, ","), nil
+}
+
+// isIndexedRef returns whether the ref with the given full name is indexed
+// by the repo indexer, if it exists
+func (repo *Repository) isIndexedRef(refFullName string) bool {
+	if strings.HasPrefix(refFullName, git.BranchPrefix) {
+		branch := strings.TrimPrefix(refFullName, git.BranchPrefix)
+		return branch == repo.DefaultBranch || matchRefPatterns(repo.IndexerBranchPatterns(), branch)
+	} else if strings.HasPrefix(refFullName, git.TagPrefix) {
+		return matchRefPatterns(repo.IndexerTagPatterns(), strings.TrimPrefix(refFullName, git.TagPrefix))
+	}
+	return false
+}
+
+func matchRefPatterns(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
 type repoIndexerOperation struct {
-	repo    *Repository
+	repoID  int64
 	deleted bool
 }
 
-var repoIndexerOperationQueue chan repoIndexerOperation
+// repoIndexerOperationDeleteSuffix marks the queued operations which delete
+// a repository from the indexer, updates are queued as the repository ID
+const repoIndexerOperationDeleteSuffix = ":delete"
+
+func (op repoIndexerOperation) String() string {
+	if op.deleted {
+		return strconv.FormatInt(op.repoID, 10) + repoIndexerOperationDeleteSuffix
+	}
+	return strconv.FormatInt(op.repoID, 10)
+}
+
+func parseRepoIndexerOperation(s string) (op repoIndexerOperation, err error) {
+	op.deleted = strings.HasSuffix(s, repoIndexerOperationDeleteSuffix)
+	op.repoID, err = strconv.ParseInt(strings.TrimSuffix(s, repoIndexerOperationDeleteSuffix), 10, 64)
+	return op, err
+}
+
+var repoIndexerOperationQueue = queue.NewNamed("repo_indexer", setting.Indexer.UpdateQueueLength)
 
 // InitRepoIndexer initialize the repo indexer
 func InitRepoIndexer() {
 	if !setting.Indexer.RepoIndexerEnabled {
 		return
 	}
-	repoIndexerOperationQueue = make(chan repoIndexerOperation, setting.Indexer.UpdateQueueLength)
+	if err := repoIndexerOperationQueue.Configure(setting.GetQueueSettings("repo_indexer", setting.Indexer.UpdateQueueLength)); err != nil {
+		log.Fatal(4, "Failed to create repo indexer queue: %v", err)
+	}
 	indexer.InitRepoIndexer(populateRepoIndexerAsynchronously)
-	go processRepoIndexerOperationQueue()
+	repoIndexerOperationQueue.Run(processRepoIndexerOperation)
+}
+
+// ReindexRepos empties the repo indexer and populates it again in the
+// background with the contents of all the repositories
+func ReindexRepos() error {
+	if !setting.Indexer.RepoIndexerEnabled {
+		return fmt.Errorf("repository indexer is disabled")
+	}
+	if err := indexer.ResetRepoIndexer(); err != nil {
+		return fmt.Errorf("ResetRepoIndexer: %v", err)
+	}
+	return populateRepoIndexerAsynchronously()
 }
 
 // populateRepoIndexerAsynchronously asynchronously populates the repo indexer
 // with pre-existing data. This should only be run when the indexer is created
-// for the first time.
+// for the first time, or after it has been reset.
 func populateRepoIndexerAsynchronously() error {
 	exist, err := x.Table("repository").Exist()
 	if err != nil {
@@ -119,43 +216,118 @@ func populateRepoIndexer(maxRepoID int64) {
 			break
 		}
 		for _, repo := range repos {
-			repoIndexerOperationQueue <- repoIndexerOperation{
-				repo:    repo,
+			repoIndexerOperationQueue.Add(repoIndexerOperation{
+				repoID:  repo.ID,
 				deleted: false,
-			}
+			})
 			maxRepoID = repo.ID - 1
 		}
 	}
 	log.Info("Done populating the repo indexer with existing repositories")
 }
 
+// indexedRef a ref of a repository to index
+type indexedRef struct {
+	Name string
+	Sha  string
+}
+
+// getRefsToIndex returns the refs of the repository to index: its default
+// branch, if it exists, and the branches and tags matching its patterns
+func getRefsToIndex(repo *Repository) ([]indexedRef, error) {
+	stdout, err := git.NewCommand("for-each-ref", "--format=%(objectname) %(refname)",
+		git.BranchPrefix, git.TagPrefix).RunInDir(repo.RepoPath())
+	if err != nil {
+		return nil, err
+	}
+
+	branchPatterns := repo.IndexerBranchPatterns()
+	tagPatterns := repo.IndexerTagPatterns()
+	refs := make([]indexedRef, 0, 1)
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		sha, refName := fields[0], fields[1]
+		if strings.HasPrefix(refName, git.BranchPrefix) {
+			branch := strings.TrimPrefix(refName, git.BranchPrefix)
+			if branch == repo.DefaultBranch {
+				refs = append(refs, indexedRef{Name: indexer.RepoIndexerDefaultRef, Sha: sha})
+			} else if matchRefPatterns(branchPatterns, branch) {
+				refs = append(refs, indexedRef{Name: refName, Sha: sha})
+			}
+		} else if matchRefPatterns(tagPatterns, strings.TrimPrefix(refName, git.TagPrefix)) {
+			refs = append(refs, indexedRef{Name: refName, Sha: sha})
+		}
+	}
+	return refs, nil
+}
+
 func updateRepoIndexer(repo *Repository) error {
-	sha, err := getDefaultBranchSha(repo)
+	if repo.IsEmpty {
+		return nil
+	}
+	refs, err := getRefsToIndex(repo)
 	if err != nil {
 		return err
 	}
-	changes, err := getRepoChanges(repo, sha)
+	statuses, err := repo.getIndexerStatuses()
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		status, ok := statuses[ref.Name]
+		if !ok {
+			status = &RepoIndexerStatus{RepoID: repo.ID, RefName: ref.Name}
+		}
+		delete(statuses, ref.Name)
+		if err = updateRepoRefIndexer(repo, status, ref.Sha); err != nil {
+			return err
+		}
+	}
+
+	// the remaining statuses are those of refs which have been deleted or
+	// are no longer matched by the patterns of the repository
+	for _, status := range statuses {
+		if err = indexer.DeleteRepoRefFromIndexer(repo.ID, status.RefName); err != nil {
+			return err
+		}
+		if _, err = x.ID(status.ID).Delete(new(RepoIndexerStatus)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateRepoRefIndexer updates the files of a ref in the indexer
+func updateRepoRefIndexer(repo *Repository, status *RepoIndexerStatus, sha string) error {
+	if status.CommitSha == sha {
+		return nil
+	}
+	changes, err := getRepoChanges(repo, status, sha)
 	if err != nil {
 		return err
 	} else if changes == nil {
 		return nil
 	}
 
-	batch := indexer.RepoIndexerBatch()
+	batch := indexer.NewRepoIndexerBatch()
 	for _, update := range changes.Updates {
-		if err := addUpdate(update, repo, batch); err != nil {
+		if err := addUpdate(update, repo, status.RefName, batch); err != nil {
 			return err
 		}
 	}
 	for _, filename := range changes.RemovedFilenames {
-		if err := addDelete(filename, repo, batch); err != nil {
+		if err := addDelete(filename, repo, status.RefName, batch); err != nil {
 			return err
 		}
 	}
 	if err = batch.Flush(); err != nil {
 		return err
 	}
-	return repo.updateIndexerStatus(sha)
+	return repo.updateIndexerStatus(status, sha)
 }
 
 // repoChanges changes (file additions/updates/removals) to a repo
@@ -169,27 +341,15 @@ type fileUpdate struct {
 	BlobSha  string
 }
 
-func getDefaultBranchSha(repo *Repository) (string, error) {
-	stdout, err := git.NewCommand("show-ref", "-s", repo.DefaultBranch).RunInDir(repo.RepoPath())
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(stdout), nil
-}
-
-// getRepoChanges returns changes to repo since last indexer update
-func getRepoChanges(repo *Repository, revision string) (*repoChanges, error) {
-	if err := repo.getIndexerStatus(); err != nil {
-		return nil, err
-	}
-
-	if len(repo.IndexerStatus.CommitSha) == 0 {
+// getRepoChanges returns changes to a ref since its last indexer update
+func getRepoChanges(repo *Repository, status *RepoIndexerStatus, revision string) (*repoChanges, error) {
+	if len(status.CommitSha) == 0 {
 		return genesisChanges(repo, revision)
 	}
-	return nonGenesisChanges(repo, revision)
+	return nonGenesisChanges(repo, status, revision)
 }
 
-func addUpdate(update fileUpdate, repo *Repository, batch rupture.FlushingBatch) error {
+func addUpdate(update fileUpdate, repo *Repository, ref string, batch *indexer.RepoIndexerBatch) error {
 	stdout, err := git.NewCommand("cat-file", "-s", update.BlobSha).
 		RunInDir(repo.RepoPath())
 	if err != nil {
@@ -213,21 +373,23 @@ func addUpdate(update fileUpdate, repo *Repository, batch rupture.FlushingBatch)
 		Op:       indexer.RepoIndexerOpUpdate,
 		Data: &indexer.RepoIndexerData{
 			RepoID:  repo.ID,
+			Ref:     ref,
 			Content: string(fileContents),
 		},
 	}
-	return indexerUpdate.AddToFlushingBatch(batch)
+	return batch.Add(indexerUpdate)
 }
 
-func addDelete(filename string, repo *Repository, batch rupture.FlushingBatch) error {
+func addDelete(filename string, repo *Repository, ref string, batch *indexer.RepoIndexerBatch) error {
 	indexerUpdate := indexer.RepoIndexerUpdate{
 		Filepath: filename,
 		Op:       indexer.RepoIndexerOpDelete,
 		Data: &indexer.RepoIndexerData{
 			RepoID: repo.ID,
+			Ref:    ref,
 		},
 	}
-	return indexerUpdate.AddToFlushingBatch(batch)
+	return batch.Add(indexerUpdate)
 }
 
 // parseGitLsTreeOutput parses the output of a `git ls-tree -r --full-name` command
@@ -259,15 +421,15 @@ func genesisChanges(repo *Repository, revision string) (*repoChanges, error) {
 }
 
 // nonGenesisChanges get changes since the previous indexer update
-func nonGenesisChanges(repo *Repository, revision string) (*repoChanges, error) {
+func nonGenesisChanges(repo *Repository, status *RepoIndexerStatus, revision string) (*repoChanges, error) {
 	diffCmd := git.NewCommand("diff", "--name-status",
-		repo.IndexerStatus.CommitSha, revision)
+		status.CommitSha, revision)
 	stdout, err := diffCmd.RunInDir(repo.RepoPath())
 	if err != nil {
 		// previous commit sha may have been removed by a force push, so
 		// try rebuilding from scratch
 		log.Warn("git diff: %v", err)
-		if err = indexer.DeleteRepoFromIndexer(repo.ID); err != nil {
+		if err = indexer.DeleteRepoRefFromIndexer(repo.ID, status.RefName); err != nil {
 			return nil, err
 		}
 		return genesisChanges(repo, revision)
@@ -309,41 +471,46 @@ func nonGenesisChanges(repo *Repository, revision string) (*repoChanges, error)
 	return &changes, err
 }
 
-func processRepoIndexerOperationQueue() {
-	for {
-		op := <-repoIndexerOperationQueue
-		if op.deleted {
-			if err := indexer.DeleteRepoFromIndexer(op.repo.ID); err != nil {
-				log.Error(4, "DeleteRepoFromIndexer: %v", err)
-			}
-		} else {
-			if err := updateRepoIndexer(op.repo); err != nil {
-				log.Error(4, "updateRepoIndexer: %v", err)
-			}
+func processRepoIndexerOperation(s string) {
+	op, err := parseRepoIndexerOperation(s)
+	if err != nil {
+		log.Error(4, "Invalid repo indexer operation %s: %v", s, err)
+		return
+	}
+	if op.deleted {
+		if err := indexer.DeleteRepoFromIndexer(op.repoID); err != nil {
+			log.Error(4, "DeleteRepoFromIndexer: %v", err)
+		}
+		return
+	}
+
+	repo, err := GetRepositoryByID(op.repoID)
+	if err != nil {
+		// a repository which has been deleted meanwhile is removed by its
+		// own operation
+		if !IsErrRepoNotExist(err) {
+			log.Error(4, "GetRepositoryByID[%d]: %v", op.repoID, err)
 		}
+		return
+	}
+	if err := updateRepoIndexer(repo); err != nil {
+		log.Error(4, "updateRepoIndexer: %v", err)
 	}
 }
 
 // DeleteRepoFromIndexer remove all of a repository's entries from the indexer
 func DeleteRepoFromIndexer(repo *Repository) {
-	addOperationToQueue(repoIndexerOperation{repo: repo, deleted: true})
+	addOperationToQueue(repoIndexerOperation{repoID: repo.ID, deleted: true})
 }
 
 // UpdateRepoIndexer update a repository's entries in the indexer
 func UpdateRepoIndexer(repo *Repository) {
-	addOperationToQueue(repoIndexerOperation{repo: repo, deleted: false})
+	addOperationToQueue(repoIndexerOperation{repoID: repo.ID, deleted: false})
 }
 
 func addOperationToQueue(op repoIndexerOperation) {
 	if !setting.Indexer.RepoIndexerEnabled {
 		return
 	}
-	select {
-	case repoIndexerOperationQueue <- op:
-		break
-	default:
-		go func() {
-			repoIndexerOperationQueue <- op
-		}()
-	}
+	go repoIndexerOperationQueue.Add(op)
 }
@@ -0,0 +1,52 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRefPatterns(t *testing.T) {
+	patterns, err := ValidateRefPatterns(" release/*,, stable ,")
+	assert.NoError(t, err)
+	assert.Equal(t, "release/*,stable", patterns)
+
+	patterns, err = ValidateRefPatterns("")
+	assert.NoError(t, err)
+	assert.Empty(t, patterns)
+
+	_, err = ValidateRefPatterns("v1.*, [v2")
+	assert.True(t, IsErrInvalidRefPattern(err))
+	assert.Equal(t, "[v2", err.(ErrInvalidRefPattern).Pattern)
+}
+
+func TestRepository_IsIndexedRef(t *testing.T) {
+	repo := &Repository{
+		DefaultBranch:   "master",
+		IndexerBranches: "release/*,stable",
+		IndexerTags:     "v1.*",
+	}
+	assert.Equal(t, []string{"release/*", "stable"}, repo.IndexerBranchPatterns())
+	assert.Equal(t, []string{"v1.*"}, repo.IndexerTagPatterns())
+
+	for refName, indexed := range map[string]bool{
+		"refs/heads/master":            true,
+		"refs/heads/release/1.8":       true,
+		"refs/heads/release/1.8/fix":   false,
+		"refs/heads/stable":            true,
+		"refs/heads/feature":           false,
+		"refs/tags/v1.8.0":             true,
+		"refs/tags/v2.0.0":             false,
+		"refs/tags/master":             false,
+		"refs/pull/1/head":             false,
+		"refs/heads/release-1.8":       false,
+		"refs/notes/commits":           false,
+		"refs/heads/stable-deprecated": false,
+	} {
+		assert.Equal(t, indexed, repo.isIndexedRef(refName), refName)
+	}
+}
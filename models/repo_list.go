@@ -264,8 +264,9 @@ func SearchRepositoryByName(opts *SearchRepoOptions) (RepositoryList, int64, err
 	return repos, count, nil
 }
 
-// FindUserAccessibleRepoIDs find all accessible repositories' ID by user's id
-func FindUserAccessibleRepoIDs(userID int64) ([]int64, error) {
+// userAccessibleRepoCond returns the condition selecting the repositories
+// the user with the given id (0 for guests) has access to
+func userAccessibleRepoCond(userID int64) builder.Cond {
 	var accessCond builder.Cond = builder.Eq{"is_private": false}
 
 	if userID > 0 {
@@ -277,14 +278,74 @@ func FindUserAccessibleRepoIDs(userID int64) ([]int64, error) {
 			),
 		)
 	}
+	return accessCond
+}
 
+// FindUserAccessibleRepoIDs find all accessible repositories' ID by user's id
+func FindUserAccessibleRepoIDs(userID int64) ([]int64, error) {
 	repoIDs := make([]int64, 0, 10)
 	if err := x.
 		Table("repository").
 		Cols("id").
-		Where(accessCond).
+		Where(userAccessibleRepoCond(userID)).
 		Find(&repoIDs); err != nil {
 		return nil, fmt.Errorf("FindUserAccesibleRepoIDs: %v", err)
 	}
 	return repoIDs, nil
 }
+
+// FindUserCodeAccessibleRepoIDs finds the IDs of the repositories whose code
+// the user (a guest if nil) can read, restricted to the repositories of the
+// given owner if ownerID is not 0
+func FindUserCodeAccessibleRepoIDs(user *User, ownerID int64) ([]int64, error) {
+	return FindUserUnitAccessibleRepoIDs(user, ownerID, UnitTypeCode)
+}
+
+// userUnitAccessibleRepoCond returns the condition selecting the repositories
+// in which the user (a guest if nil) can read the given unit, following the
+// rules of getUserRepoPermission
+func userUnitAccessibleRepoCond(user *User, unitType UnitType) builder.Cond {
+	var cond = builder.In("id", builder.Select("repo_id").From("repo_unit").Where(builder.Eq{"type": unitType}))
+	if user == nil {
+		return cond.And(builder.Eq{"is_private": false})
+	} else if user.IsAdmin {
+		return cond
+	}
+
+	return cond.And(builder.Or(
+		builder.Eq{"is_private": false},
+		builder.Eq{"owner_id": user.ID},
+		// the access of the collaborators applies to all the units
+		builder.Expr("id IN (SELECT repo_id FROM `collaboration` WHERE collaboration.user_id = ?)", user.ID),
+		// the teams of an organization only grant access to their units
+		builder.And(
+			builder.Expr("owner_id NOT IN (SELECT id FROM `user` WHERE `user`.type = ?)", UserTypeOrganization),
+			builder.Expr("id IN (SELECT repo_id FROM `access` WHERE access.user_id = ? AND access.mode >= ?)", user.ID, AccessModeRead),
+		),
+		builder.Expr("id IN (SELECT team_repo.repo_id FROM `team_repo` "+
+			"INNER JOIN `team_user` ON team_user.team_id = team_repo.team_id "+
+			"INNER JOIN `team_unit` ON team_unit.team_id = team_repo.team_id "+
+			"INNER JOIN `team` ON team.id = team_repo.team_id "+
+			"WHERE team_user.uid = ? AND team_unit.type = ? AND team.authorize >= ?)", user.ID, unitType, AccessModeRead),
+	))
+}
+
+// FindUserUnitAccessibleRepoIDs finds the IDs of the repositories in which the
+// user (a guest if nil) can read the given unit, restricted to the
+// repositories of the given owner if ownerID is not 0
+func FindUserUnitAccessibleRepoIDs(user *User, ownerID int64, unitType UnitType) ([]int64, error) {
+	var cond = userUnitAccessibleRepoCond(user, unitType)
+	if ownerID > 0 {
+		cond = cond.And(builder.Eq{"owner_id": ownerID})
+	}
+
+	repoIDs := make([]int64, 0, 10)
+	if err := x.
+		Table("repository").
+		Cols("id").
+		Where(cond).
+		Find(&repoIDs); err != nil {
+		return nil, fmt.Errorf("FindUserUnitAccessibleRepoIDs: %v", err)
+	}
+	return repoIDs, nil
+}
@@ -250,3 +250,73 @@ func TestSearchRepositoryByTopicName(t *testing.T) {
 		})
 	}
 }
+
+func TestFindUserCodeAccessibleRepoIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	admin := AssertExistsAndLoadBean(t, &User{ID: 1}).(*User)
+	user2 := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	user4 := AssertExistsAndLoadBean(t, &User{ID: 4}).(*User)
+
+	// guests can only read the public repositories
+	repoIDs, err := FindUserCodeAccessibleRepoIDs(nil, 2)
+	assert.NoError(t, err)
+	assert.Contains(t, repoIDs, int64(1))
+	assert.NotContains(t, repoIDs, int64(2))
+	assert.NotContains(t, repoIDs, int64(4))
+
+	// owners can read their private repositories
+	repoIDs, err = FindUserCodeAccessibleRepoIDs(user2, 2)
+	assert.NoError(t, err)
+	assert.Contains(t, repoIDs, int64(1))
+	assert.Contains(t, repoIDs, int64(2))
+	assert.Contains(t, repoIDs, int64(16))
+
+	// collaborators can read the private repositories they have access to
+	repoIDs, err = FindUserCodeAccessibleRepoIDs(user4, 3)
+	assert.NoError(t, err)
+	assert.Contains(t, repoIDs, int64(3))
+	assert.NotContains(t, repoIDs, int64(5))
+
+	// site administrators can read all the repositories with a code unit
+	repoIDs, err = FindUserCodeAccessibleRepoIDs(admin, 3)
+	assert.NoError(t, err)
+	assert.Contains(t, repoIDs, int64(3))
+	assert.Contains(t, repoIDs, int64(32))
+	assert.NotContains(t, repoIDs, int64(5))
+
+	repoIDs, err = FindUserCodeAccessibleRepoIDs(user4, 0)
+	assert.NoError(t, err)
+	assert.Contains(t, repoIDs, int64(1))
+	assert.Contains(t, repoIDs, int64(3))
+	assert.Contains(t, repoIDs, int64(4))
+	assert.NotContains(t, repoIDs, int64(2))
+}
+
+func TestFindUserUnitAccessibleRepoIDs(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	var users []*User
+	assert.NoError(t, x.Where("type = ?", UserTypeIndividual).Find(&users))
+	users = append(users, nil)
+	// the repositories whose fixture leaves is_private unset are not matched by any condition
+	var repos []*Repository
+	assert.NoError(t, x.Where("is_private IS NOT NULL").Find(&repos))
+
+	// the condition agrees with the permissions of every user on every repository
+	for _, user := range users {
+		for _, unitType := range []UnitType{UnitTypeCode, UnitTypeIssues, UnitTypePullRequests, UnitTypeWiki} {
+			repoIDs, err := FindUserUnitAccessibleRepoIDs(user, 0, unitType)
+			assert.NoError(t, err)
+			for _, repo := range repos {
+				perm, err := GetUserRepoPermission(repo, user)
+				assert.NoError(t, err)
+				if perm.CanRead(unitType) {
+					assert.Contains(t, repoIDs, repo.ID, "user %v, repo %d, unit %d", user, repo.ID, unitType)
+				} else {
+					assert.NotContains(t, repoIDs, repo.ID, "user %v, repo %d, unit %d", user, repo.ID, unitType)
+				}
+			}
+		}
+	}
+}
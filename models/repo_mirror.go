@@ -6,16 +6,19 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"code.gitea.io/git"
 	"code.gitea.io/gitea/modules/cache"
+	"code.gitea.io/gitea/modules/generate"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
-	"code.gitea.io/gitea/modules/sync"
 	"code.gitea.io/gitea/modules/util"
 
 	"github.com/Unknwon/com"
@@ -24,7 +27,7 @@ import (
 )
 
 // MirrorQueue holds an UniqueQueue object of the mirror
-var MirrorQueue = sync.NewUniqueQueue(setting.Repository.MirrorQueueLength)
+var MirrorQueue = queue.NewNamed("mirror_sync", setting.Repository.MirrorQueueLength)
 
 // Mirror represents mirror information of a repository.
 type Mirror struct {
@@ -37,6 +40,10 @@ type Mirror struct {
 	UpdatedUnix    util.TimeStamp `xorm:"INDEX"`
 	NextUpdateUnix util.TimeStamp `xorm:"INDEX"`
 
+	// TriggerToken is the secret of the URL which syncs the mirror when it
+	// is called by the webhooks of the upstream repository
+	TriggerToken string `xorm:"INDEX"`
+
 	address string `xorm:"-"`
 }
 
@@ -45,7 +52,34 @@ func (m *Mirror) BeforeInsert() {
 	if m != nil {
 		m.UpdatedUnix = util.TimeStampNow()
 		m.NextUpdateUnix = util.TimeStampNow()
+		if len(m.TriggerToken) == 0 {
+			var err error
+			if m.TriggerToken, err = newMirrorTriggerToken(); err != nil {
+				log.Error(3, "newMirrorTriggerToken: %v", err)
+			}
+		}
+	}
+}
+
+func newMirrorTriggerToken() (string, error) {
+	return generate.GetRandomString(40)
+}
+
+// TriggerURL returns the URL which syncs the mirror when it is called.
+func (m *Mirror) TriggerURL() string {
+	return setting.AppURL + "api/v1/mirror-sync/" + m.TriggerToken
+}
+
+// RegenerateTriggerToken replaces the secret of the trigger URL of the mirror,
+// so that the previous URL can not be used anymore.
+func (m *Mirror) RegenerateTriggerToken() error {
+	token, err := newMirrorTriggerToken()
+	if err != nil {
+		return err
 	}
+	m.TriggerToken = token
+	_, err = x.ID(m.ID).Cols("trigger_token").Update(m)
+	return err
 }
 
 // AfterLoad is invoked from XORM after setting the values of all fields of this object.
@@ -71,11 +105,17 @@ func (m *Mirror) ScheduleNextUpdate() {
 }
 
 func remoteAddress(repoPath string) (string, error) {
+	return remoteAddressByName(repoPath, "origin")
+}
+
+// remoteAddressByName returns the address of the named remote from the Git
+// repository config
+func remoteAddressByName(repoPath, name string) (string, error) {
 	cfg, err := ini.Load(GitConfigPath(repoPath))
 	if err != nil {
 		return "", err
 	}
-	return cfg.Section("remote \"origin\"").Key("url").Value(), nil
+	return cfg.Section(fmt.Sprintf("remote %q", name)).Key("url").Value(), nil
 }
 
 func (m *Mirror) readAddress() {
@@ -185,8 +225,10 @@ func parseRemoteUpdateOutput(output string) []*mirrorSyncResult {
 	return results
 }
 
-// runSync returns true if sync finished without error.
-func (m *Mirror) runSync() ([]*mirrorSyncResult, bool) {
+// runSync fetches the given references from the upstream repository, or all
+// of them and the wiki if refs is empty. It returns the updated references, or
+// an error which message is sanitized from the credentials of the remote.
+func (m *Mirror) runSync(refs []string) ([]*mirrorSyncResult, error) {
 	repoPath := m.Repo.RepoPath()
 	wikiPath := m.Repo.WikiPath()
 	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
@@ -195,6 +237,13 @@ func (m *Mirror) runSync() ([]*mirrorSyncResult, bool) {
 	if m.EnablePrune {
 		gitArgs = append(gitArgs, "--prune")
 	}
+	if len(refs) > 0 {
+		// the references are mirrored as is, like with the refspec of the remote
+		gitArgs = []string{"fetch", "origin"}
+		for _, ref := range refs {
+			gitArgs = append(gitArgs, "+"+ref+":"+ref)
+		}
+	}
 
 	_, stderr, err := process.GetManager().ExecDir(
 		timeout, repoPath, fmt.Sprintf("Mirror.runSync: %s", repoPath),
@@ -205,21 +254,21 @@ func (m *Mirror) runSync() ([]*mirrorSyncResult, bool) {
 		message, err := sanitizeOutput(stderr, repoPath)
 		if err != nil {
 			log.Error(4, "sanitizeOutput: %v", err)
-			return nil, false
+			return nil, errors.New("failed to update mirror repository")
 		}
 		desc := fmt.Sprintf("Failed to update mirror repository '%s': %s", repoPath, message)
 		log.Error(4, desc)
 		if err = CreateRepositoryNotice(desc); err != nil {
 			log.Error(4, "CreateRepositoryNotice: %v", err)
 		}
-		return nil, false
+		return nil, errors.New(message)
 	}
 	output := stderr
 
 	gitRepo, err := git.OpenRepository(repoPath)
 	if err != nil {
 		log.Error(4, "OpenRepository: %v", err)
-		return nil, false
+		return nil, err
 	}
 	if err = SyncReleasesWithTags(m.Repo, gitRepo); err != nil {
 		log.Error(4, "Failed to synchronize tags to releases for repository: %v", err)
@@ -229,7 +278,7 @@ func (m *Mirror) runSync() ([]*mirrorSyncResult, bool) {
 		log.Error(4, "Failed to update size for mirror repository: %v", err)
 	}
 
-	if m.Repo.HasWiki() {
+	if m.Repo.HasWiki() && len(refs) == 0 {
 		if _, stderr, err := process.GetManager().ExecDir(
 			timeout, wikiPath, fmt.Sprintf("Mirror.runSync: %s", wikiPath),
 			"git", "remote", "update", "--prune"); err != nil {
@@ -238,21 +287,21 @@ func (m *Mirror) runSync() ([]*mirrorSyncResult, bool) {
 			message, err := sanitizeOutput(stderr, wikiPath)
 			if err != nil {
 				log.Error(4, "sanitizeOutput: %v", err)
-				return nil, false
+				return nil, errors.New("failed to update mirror wiki repository")
 			}
 			desc := fmt.Sprintf("Failed to update mirror wiki repository '%s': %s", wikiPath, message)
 			log.Error(4, desc)
 			if err = CreateRepositoryNotice(desc); err != nil {
 				log.Error(4, "CreateRepositoryNotice: %v", err)
 			}
-			return nil, false
+			return nil, errors.New(message)
 		}
 	}
 
 	branches, err := m.Repo.GetBranches()
 	if err != nil {
 		log.Error(4, "GetBranches: %v", err)
-		return nil, false
+		return nil, err
 	}
 
 	for i := range branches {
@@ -260,7 +309,7 @@ func (m *Mirror) runSync() ([]*mirrorSyncResult, bool) {
 	}
 
 	m.UpdatedUnix = util.TimeStampNow()
-	return parseRemoteUpdateOutput(output), true
+	return parseRemoteUpdateOutput(output), nil
 }
 
 func getMirrorByRepoID(e Engine, repoID int64) (*Mirror, error) {
@@ -279,6 +328,22 @@ func GetMirrorByRepoID(repoID int64) (*Mirror, error) {
 	return getMirrorByRepoID(x, repoID)
 }
 
+// GetMirrorByTriggerToken returns the mirror which can be synced with the
+// given secret trigger token.
+func GetMirrorByTriggerToken(token string) (*Mirror, error) {
+	if len(token) == 0 {
+		return nil, ErrMirrorNotExist
+	}
+	m := &Mirror{}
+	has, err := x.Where("trigger_token = ?", token).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrMirrorNotExist
+	}
+	return m, nil
+}
+
 func updateMirror(e Engine, m *Mirror) error {
 	_, err := e.ID(m.ID).AllCols().Update(m)
 	return err
@@ -291,7 +356,10 @@ func UpdateMirror(m *Mirror) error {
 
 // DeleteMirrorByRepoID deletes a mirror by repoID
 func DeleteMirrorByRepoID(repoID int64) error {
-	_, err := x.Delete(&Mirror{RepoID: repoID})
+	if _, err := x.Delete(&Mirror{RepoID: repoID}); err != nil {
+		return err
+	}
+	_, err := x.Delete(&MirrorSyncRecord{RepoID: repoID})
 	return err
 }
 
@@ -314,115 +382,169 @@ func MirrorUpdate() {
 				return nil
 			}
 
-			MirrorQueue.Add(m.RepoID)
+			AddMirrorSyncTask(m.RepoID)
 			return nil
 		}); err != nil {
 		log.Error(4, "MirrorUpdate: %v", err)
 	}
 }
 
-// SyncMirrors checks and syncs mirrors.
-// TODO: sync more mirrors at same time.
+// mirrorSyncRefs holds the references to fetch of the mirrors waiting in the
+// queue, the mirrors which have no references in it are fully synced.
+var mirrorSyncRefs = struct {
+	sync.Mutex
+	refs map[int64][]string
+}{refs: make(map[int64][]string)}
+
+// AddMirrorSyncTask adds the mirror of the repository to the sync queue. Only
+// the given references are fetched if any, all of them otherwise. A mirror
+// waiting to be fully synced is not restricted by the references added later.
+func AddMirrorSyncTask(repoID int64, refs ...string) {
+	mirrorSyncRefs.Lock()
+	defer mirrorSyncRefs.Unlock()
+
+	pending, isPartial := mirrorSyncRefs.refs[repoID]
+	switch {
+	case len(refs) == 0:
+		delete(mirrorSyncRefs.refs, repoID)
+	case isPartial:
+		mirrorSyncRefs.refs[repoID] = append(pending, refs...)
+	case !MirrorQueue.Exist(repoID):
+		mirrorSyncRefs.refs[repoID] = refs
+	}
+	MirrorQueue.Add(repoID)
+}
+
+// takeMirrorSyncRefs returns the references to fetch of the mirror taken
+// from the queue, nil if it is fully synced.
+func takeMirrorSyncRefs(repoID int64) []string {
+	mirrorSyncRefs.Lock()
+	defer mirrorSyncRefs.Unlock()
+
+	refs := mirrorSyncRefs.refs[repoID]
+	delete(mirrorSyncRefs.refs, repoID)
+	return refs
+}
+
+// SyncMirrors starts the workers which sync the mirrors added to the queue.
 func SyncMirrors() {
-	sess := x.NewSession()
-	defer sess.Close()
-	// Start listening on new sync requests.
-	for repoID := range MirrorQueue.Queue() {
-		log.Trace("SyncMirrors [repo_id: %v]", repoID)
-		MirrorQueue.Remove(repoID)
-
-		m, err := GetMirrorByRepoID(com.StrTo(repoID).MustInt64())
+	MirrorQueue.Run(syncMirror)
+}
+
+// syncMirror syncs the mirror of the given repository.
+func syncMirror(repoID string) {
+	log.Trace("SyncMirrors [repo_id: %v]", repoID)
+
+	refs := takeMirrorSyncRefs(com.StrTo(repoID).MustInt64())
+	m, err := GetMirrorByRepoID(com.StrTo(repoID).MustInt64())
+	if err != nil {
+		log.Error(4, "GetMirrorByRepoID [%s]: %v", repoID, err)
+		return
+	}
+
+	start := time.Now()
+	results, syncErr := m.runSync(refs)
+	record := &MirrorSyncRecord{
+		RepoID:    m.RepoID,
+		Refs:      refs,
+		IsSuccess: syncErr == nil,
+		Duration:  time.Since(start).Round(time.Millisecond),
+	}
+	if syncErr != nil {
+		record.Error = syncErr.Error()
+	}
+	for _, result := range results {
+		record.ChangedRefs = append(record.ChangedRefs, result.refName)
+	}
+	if err = createMirrorSyncRecord(x, record); err != nil {
+		log.Error(4, "createMirrorSyncRecord [%s]: %v", repoID, err)
+	}
+	if syncErr != nil {
+		return
+	}
+
+	m.ScheduleNextUpdate()
+	if err = updateMirror(x, m); err != nil {
+		log.Error(4, "UpdateMirror [%s]: %v", repoID, err)
+		return
+	}
+
+	var gitRepo *git.Repository
+	if len(results) == 0 {
+		log.Trace("SyncMirrors [repo_id: %d]: no commits fetched", m.RepoID)
+	} else {
+		gitRepo, err = git.OpenRepository(m.Repo.RepoPath())
 		if err != nil {
-			log.Error(4, "GetMirrorByRepoID [%s]: %v", repoID, err)
-			continue
+			log.Error(2, "OpenRepository [%d]: %v", m.RepoID, err)
+			return
 		}
+	}
 
-		results, ok := m.runSync()
-		if !ok {
+	for _, result := range results {
+		// Discard GitHub pull requests, i.e. refs/pull/*
+		if strings.HasPrefix(result.refName, "refs/pull/") {
 			continue
 		}
 
-		m.ScheduleNextUpdate()
-		if err = updateMirror(sess, m); err != nil {
-			log.Error(4, "UpdateMirror [%s]: %v", repoID, err)
+		// Create reference
+		if result.oldCommitID == gitShortEmptySha {
+			if err = MirrorSyncCreateAction(m.Repo, result.refName); err != nil {
+				log.Error(2, "MirrorSyncCreateAction [repo_id: %d]: %v", m.RepoID, err)
+			}
 			continue
 		}
 
-		var gitRepo *git.Repository
-		if len(results) == 0 {
-			log.Trace("SyncMirrors [repo_id: %d]: no commits fetched", m.RepoID)
-		} else {
-			gitRepo, err = git.OpenRepository(m.Repo.RepoPath())
-			if err != nil {
-				log.Error(2, "OpenRepository [%d]: %v", m.RepoID, err)
-				continue
+		// Delete reference
+		if result.newCommitID == gitShortEmptySha {
+			if err = MirrorSyncDeleteAction(m.Repo, result.refName); err != nil {
+				log.Error(2, "MirrorSyncDeleteAction [repo_id: %d]: %v", m.RepoID, err)
 			}
+			continue
 		}
 
-		for _, result := range results {
-			// Discard GitHub pull requests, i.e. refs/pull/*
-			if strings.HasPrefix(result.refName, "refs/pull/") {
-				continue
-			}
-
-			// Create reference
-			if result.oldCommitID == gitShortEmptySha {
-				if err = MirrorSyncCreateAction(m.Repo, result.refName); err != nil {
-					log.Error(2, "MirrorSyncCreateAction [repo_id: %d]: %v", m.RepoID, err)
-				}
-				continue
-			}
-
-			// Delete reference
-			if result.newCommitID == gitShortEmptySha {
-				if err = MirrorSyncDeleteAction(m.Repo, result.refName); err != nil {
-					log.Error(2, "MirrorSyncDeleteAction [repo_id: %d]: %v", m.RepoID, err)
-				}
-				continue
-			}
-
-			// Push commits
-			oldCommitID, err := git.GetFullCommitID(gitRepo.Path, result.oldCommitID)
-			if err != nil {
-				log.Error(2, "GetFullCommitID [%d]: %v", m.RepoID, err)
-				continue
-			}
-			newCommitID, err := git.GetFullCommitID(gitRepo.Path, result.newCommitID)
-			if err != nil {
-				log.Error(2, "GetFullCommitID [%d]: %v", m.RepoID, err)
-				continue
-			}
-			commits, err := gitRepo.CommitsBetweenIDs(newCommitID, oldCommitID)
-			if err != nil {
-				log.Error(2, "CommitsBetweenIDs [repo_id: %d, new_commit_id: %s, old_commit_id: %s]: %v", m.RepoID, newCommitID, oldCommitID, err)
-				continue
-			}
-			if err = MirrorSyncPushAction(m.Repo, MirrorSyncPushActionOptions{
-				RefName:     result.refName,
-				OldCommitID: oldCommitID,
-				NewCommitID: newCommitID,
-				Commits:     ListToPushCommits(commits),
-			}); err != nil {
-				log.Error(2, "MirrorSyncPushAction [repo_id: %d]: %v", m.RepoID, err)
-				continue
-			}
+		// Push commits
+		oldCommitID, err := git.GetFullCommitID(gitRepo.Path, result.oldCommitID)
+		if err != nil {
+			log.Error(2, "GetFullCommitID [%d]: %v", m.RepoID, err)
+			continue
 		}
-
-		// Get latest commit date and update to current repository updated time
-		commitDate, err := git.GetLatestCommitTime(m.Repo.RepoPath())
+		newCommitID, err := git.GetFullCommitID(gitRepo.Path, result.newCommitID)
 		if err != nil {
-			log.Error(2, "GetLatestCommitDate [%s]: %v", m.RepoID, err)
+			log.Error(2, "GetFullCommitID [%d]: %v", m.RepoID, err)
 			continue
 		}
-
-		if _, err = sess.Exec("UPDATE repository SET updated_unix = ? WHERE id = ?", commitDate.Unix(), m.RepoID); err != nil {
-			log.Error(2, "Update repository 'updated_unix' [%s]: %v", m.RepoID, err)
+		commits, err := gitRepo.CommitsBetweenIDs(newCommitID, oldCommitID)
+		if err != nil {
+			log.Error(2, "CommitsBetweenIDs [repo_id: %d, new_commit_id: %s, old_commit_id: %s]: %v", m.RepoID, newCommitID, oldCommitID, err)
+			continue
+		}
+		if err = MirrorSyncPushAction(m.Repo, MirrorSyncPushActionOptions{
+			RefName:     result.refName,
+			OldCommitID: oldCommitID,
+			NewCommitID: newCommitID,
+			Commits:     ListToPushCommits(commits),
+		}); err != nil {
+			log.Error(2, "MirrorSyncPushAction [repo_id: %d]: %v", m.RepoID, err)
 			continue
 		}
 	}
+
+	// Get latest commit date and update to current repository updated time
+	commitDate, err := git.GetLatestCommitTime(m.Repo.RepoPath())
+	if err != nil {
+		log.Error(2, "GetLatestCommitDate [%s]: %v", m.RepoID, err)
+		return
+	}
+
+	if _, err = x.Exec("UPDATE repository SET updated_unix = ? WHERE id = ?", commitDate.Unix(), m.RepoID); err != nil {
+		log.Error(2, "Update repository 'updated_unix' [%s]: %v", m.RepoID, err)
+	}
 }
 
-// InitSyncMirrors initializes a go routine to sync the mirrors
+// InitSyncMirrors initializes the queue and the workers to sync the mirrors
 func InitSyncMirrors() {
-	go SyncMirrors()
+	if err := MirrorQueue.Configure(setting.GetQueueSettings("mirror_sync", setting.Repository.MirrorQueueLength)); err != nil {
+		log.Fatal(4, "Failed to create mirror queue: %v", err)
+	}
+	SyncMirrors()
 }
@@ -0,0 +1,63 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"time"
+
+	"code.gitea.io/gitea/modules/util"
+)
+
+// mirrorSyncHistoryLength is the number of synchronizations kept in the
+// history of a mirror
+const mirrorSyncHistoryLength = 20
+
+// MirrorSyncRecord represents a synchronization of a mirror with its upstream
+// repository.
+type MirrorSyncRecord struct {
+	ID     int64 `xorm:"pk autoincr"`
+	RepoID int64 `xorm:"INDEX"`
+	// Refs are the references which have been fetched, all of them if empty
+	Refs        []string `xorm:"TEXT JSON"`
+	ChangedRefs []string `xorm:"TEXT JSON"`
+	IsSuccess   bool
+	Error       string `xorm:"TEXT"`
+	Duration    time.Duration
+	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
+}
+
+// createMirrorSyncRecord adds the synchronization to the history of the
+// mirror, removing the oldest ones beyond mirrorSyncHistoryLength.
+func createMirrorSyncRecord(e Engine, record *MirrorSyncRecord) error {
+	if _, err := e.Insert(record); err != nil {
+		return err
+	}
+
+	oldest := new(MirrorSyncRecord)
+	has, err := e.
+		Where("repo_id = ?", record.RepoID).
+		Desc("id").
+		Limit(1, mirrorSyncHistoryLength).
+		Cols("id").
+		Get(oldest)
+	if err != nil || !has {
+		return err
+	}
+	_, err = e.
+		Where("repo_id = ? AND id <= ?", record.RepoID, oldest.ID).
+		Delete(new(MirrorSyncRecord))
+	return err
+}
+
+// GetMirrorSyncRecords returns the latest synchronizations of the mirror of
+// the repository.
+func GetMirrorSyncRecords(repoID int64) ([]*MirrorSyncRecord, error) {
+	records := make([]*MirrorSyncRecord, 0, mirrorSyncHistoryLength)
+	return records, x.
+		Where("repo_id = ?", repoID).
+		Desc("id").
+		Limit(mirrorSyncHistoryLength).
+		Find(&records)
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"testing"
+
+	"code.gitea.io/git"
+	"code.gitea.io/gitea/modules/process"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddMirrorSyncTask(t *testing.T) {
+	const repoID = 1000
+	defer takeMirrorSyncRefs(repoID)
+
+	AddMirrorSyncTask(repoID, "refs/heads/master")
+	AddMirrorSyncTask(repoID, "refs/tags/v1.0")
+	assert.Equal(t, []string{"refs/heads/master", "refs/tags/v1.0"}, takeMirrorSyncRefs(repoID))
+	assert.Nil(t, takeMirrorSyncRefs(repoID))
+
+	// a full sync overrides the references added before and after it
+	AddMirrorSyncTask(repoID, "refs/heads/master")
+	AddMirrorSyncTask(repoID)
+	AddMirrorSyncTask(repoID, "refs/heads/develop")
+	assert.Nil(t, takeMirrorSyncRefs(repoID))
+}
+
+func TestCreateMirrorSyncRecord(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for i := 0; i < mirrorSyncHistoryLength+5; i++ {
+		assert.NoError(t, createMirrorSyncRecord(x, &MirrorSyncRecord{
+			RepoID:    1,
+			IsSuccess: i%2 == 0,
+		}))
+	}
+	AssertCount(t, &MirrorSyncRecord{RepoID: 1}, mirrorSyncHistoryLength)
+
+	records, err := GetMirrorSyncRecords(1)
+	assert.NoError(t, err)
+	if assert.Len(t, records, mirrorSyncHistoryLength) {
+		assert.True(t, records[0].ID > records[1].ID)
+		assert.True(t, records[0].IsSuccess)
+	}
+}
+
+func TestMirror_SyncRefs(t *testing.T) {
+	PrepareTestEnv(t)
+
+	user := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	repoPath := RepoPath(user.Name, repo.Name)
+	mirror, err := MigrateRepository(user, user, MigrateRepoOptions{
+		Name:       "test_mirror_sync",
+		IsMirror:   true,
+		RemoteAddr: repoPath,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mirror.GetMirror())
+	assert.Len(t, mirror.Mirror.TriggerToken, 40)
+
+	m, err := GetMirrorByTriggerToken(mirror.Mirror.TriggerToken)
+	assert.NoError(t, err)
+	assert.Equal(t, mirror.ID, m.RepoID)
+	_, err = GetMirrorByTriggerToken("")
+	assert.Equal(t, ErrMirrorNotExist, err)
+
+	oldToken := m.TriggerToken
+	assert.NoError(t, m.RegenerateTriggerToken())
+	assert.NotEqual(t, oldToken, m.TriggerToken)
+	_, err = GetMirrorByTriggerToken(oldToken)
+	assert.Equal(t, ErrMirrorNotExist, err)
+
+	for _, ref := range []string{"refs/heads/synced", "refs/heads/not-synced"} {
+		_, stderr, err := process.GetManager().ExecDir(-1, repoPath, "TestMirror_SyncRefs",
+			"git", "update-ref", ref, "refs/heads/master")
+		assert.NoError(t, err, stderr)
+	}
+
+	results, err := m.runSync([]string{"refs/heads/synced"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, "synced", results[0].refName)
+	}
+	gitRepo, err := git.OpenRepository(mirror.RepoPath())
+	assert.NoError(t, err)
+	assert.True(t, gitRepo.IsBranchExist("synced"))
+	assert.False(t, gitRepo.IsBranchExist("not-synced"))
+
+	_, err = m.runSync([]string{"refs/heads/missing"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,276 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/modules/generate"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/process"
+	"code.gitea.io/gitea/modules/queue"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/Unknwon/com"
+	"github.com/go-xorm/xorm"
+)
+
+// ErrPushMirrorNotExist mirror does not exist error
+var ErrPushMirrorNotExist = errors.New("Push mirror does not exist")
+
+// PushMirrorQueue holds an UniqueQueue object of the push mirrors
+var PushMirrorQueue = queue.NewNamed("push_mirror_sync", setting.Repository.MirrorQueueLength)
+
+// PushMirror represents a remote of a repository which all its references are
+// pushed to. The address of the remote, which may contain its credentials,
+// is stored in the Git repository config like the one of the pull mirrors.
+type PushMirror struct {
+	ID           int64       `xorm:"pk autoincr"`
+	RepoID       int64       `xorm:"INDEX"`
+	Repo         *Repository `xorm:"-"`
+	RemoteName   string
+	Interval     time.Duration
+	SyncOnCommit bool `xorm:"NOT NULL DEFAULT false"`
+
+	CreatedUnix    util.TimeStamp `xorm:"created"`
+	UpdatedUnix    util.TimeStamp `xorm:"INDEX"`
+	NextUpdateUnix util.TimeStamp `xorm:"INDEX"`
+	LastError      string         `xorm:"TEXT"`
+
+	address string `xorm:"-"`
+}
+
+// AfterLoad is invoked from XORM after setting the values of all fields of this object.
+func (m *PushMirror) AfterLoad(session *xorm.Session) {
+	if m == nil {
+		return
+	}
+
+	var err error
+	m.Repo, err = getRepositoryByID(session, m.RepoID)
+	if err != nil {
+		log.Error(3, "getRepositoryByID[%d]: %v", m.ID, err)
+	}
+}
+
+// ScheduleNextUpdate calculates and sets next update time.
+func (m *PushMirror) ScheduleNextUpdate() {
+	if m.Interval != 0 {
+		m.NextUpdateUnix = util.TimeStampNow().AddDuration(m.Interval)
+	} else {
+		m.NextUpdateUnix = 0
+	}
+}
+
+func (m *PushMirror) readAddress() {
+	if len(m.address) > 0 {
+		return
+	}
+	var err error
+	m.address, err = remoteAddressByName(m.Repo.RepoPath(), m.RemoteName)
+	if err != nil {
+		log.Error(4, "remoteAddressByName: %v", err)
+	}
+}
+
+// Address returns the address of the remote without credentials.
+func (m *PushMirror) Address() string {
+	m.readAddress()
+	return util.SanitizeURLCredentials(m.address, false)
+}
+
+// runSync pushes all the references of the repository to the remote, and
+// returns the sanitized error output of git if it fails.
+func (m *PushMirror) runSync() error {
+	repoPath := m.Repo.RepoPath()
+	timeout := time.Duration(setting.Git.Timeout.Mirror) * time.Second
+
+	// the remote is configured with --mirror=push, so the references deleted
+	// from the repository are deleted from the remote too
+	_, stderr, err := process.GetManager().ExecDir(
+		timeout, repoPath, fmt.Sprintf("PushMirror.runSync: %s", repoPath),
+		"git", "push", m.RemoteName)
+	if err != nil {
+		m.readAddress()
+		// sanitize the output, since it may contain the remote address, which may
+		// contain a password
+		return errors.New(util.SanitizeMessage(stderr, m.address))
+	}
+	return nil
+}
+
+// AddPushMirror adds a remote to the repository, which its references are
+// pushed to every interval if it is not zero, and after every push to the
+// repository if syncOnCommit is true.
+func AddPushMirror(repo *Repository, address string, interval time.Duration, syncOnCommit bool) (*PushMirror, error) {
+	name, err := generate.GetRandomString(10)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &PushMirror{
+		RepoID:       repo.ID,
+		Repo:         repo,
+		RemoteName:   "remote_mirror_" + name,
+		Interval:     interval,
+		SyncOnCommit: syncOnCommit,
+	}
+	m.ScheduleNextUpdate()
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return nil, err
+	}
+	if _, err = sess.Insert(m); err != nil {
+		return nil, err
+	}
+
+	repoPath := repo.RepoPath()
+	if _, stderr, err := process.GetManager().ExecDir(
+		-1, repoPath, fmt.Sprintf("AddPushMirror: %s", repoPath),
+		"git", "remote", "add", "--mirror=push", m.RemoteName, address); err != nil {
+		return nil, fmt.Errorf("git remote add: %s", util.SanitizeMessage(stderr, address))
+	}
+
+	// The remote would be left without its push mirror otherwise.
+	if err = sess.Commit(); err != nil {
+		removePushMirrorRemote(m)
+		return nil, err
+	}
+	return m, nil
+}
+
+// removePushMirrorRemote removes the remote of the push mirror from its
+// repository, logging the failures since the remote is not used anymore.
+func removePushMirrorRemote(m *PushMirror) {
+	repoPath := m.Repo.RepoPath()
+	if _, stderr, err := process.GetManager().ExecDir(
+		-1, repoPath, fmt.Sprintf("removePushMirrorRemote: %s", repoPath),
+		"git", "remote", "remove", m.RemoteName); err != nil {
+		log.Error(4, "git remote remove [repo_id: %d, remote: %s]: %s", m.RepoID, m.RemoteName, stderr)
+	}
+}
+
+func getPushMirrorByID(e Engine, repoID, id int64) (*PushMirror, error) {
+	m := new(PushMirror)
+	has, err := e.Where("id = ? AND repo_id = ?", id, repoID).Get(m)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrPushMirrorNotExist
+	}
+	return m, nil
+}
+
+// GetPushMirrorByID returns the push mirror of the repository with the given ID.
+func GetPushMirrorByID(repoID, id int64) (*PushMirror, error) {
+	return getPushMirrorByID(x, repoID, id)
+}
+
+// GetPushMirrorsByRepoID returns the push mirrors of a repository.
+func GetPushMirrorsByRepoID(repoID int64) ([]*PushMirror, error) {
+	mirrors := make([]*PushMirror, 0, 2)
+	return mirrors, x.
+		Where("repo_id = ?", repoID).
+		Asc("id").
+		Find(&mirrors)
+}
+
+func updatePushMirror(e Engine, m *PushMirror) error {
+	_, err := e.ID(m.ID).AllCols().Update(m)
+	return err
+}
+
+// UpdatePushMirror updates the push mirror
+func UpdatePushMirror(m *PushMirror) error {
+	return updatePushMirror(x, m)
+}
+
+// DeletePushMirror removes the push mirror from the repository.
+func DeletePushMirror(m *PushMirror) error {
+	removePushMirrorRemote(m)
+
+	_, err := x.ID(m.ID).Delete(new(PushMirror))
+	return err
+}
+
+// PushMirrorUpdate checks and updates push mirrors.
+func PushMirrorUpdate() {
+	if !taskStatusTable.StartIfNotRunning(pushMirrorUpdate) {
+		return
+	}
+	defer taskStatusTable.Stop(pushMirrorUpdate)
+
+	log.Trace("Doing: PushMirrorUpdate")
+
+	if err := x.
+		Where("next_update_unix<=?", time.Now().Unix()).
+		And("next_update_unix!=0").
+		Iterate(new(PushMirror), func(idx int, bean interface{}) error {
+			m := bean.(*PushMirror)
+			if m.Repo == nil {
+				log.Error(4, "Disconnected push mirror repository found: %d", m.ID)
+				return nil
+			}
+
+			PushMirrorQueue.Add(m.ID)
+			return nil
+		}); err != nil {
+		log.Error(4, "PushMirrorUpdate: %v", err)
+	}
+}
+
+// SyncPushMirrorsOnCommit adds the push mirrors of the repository which are
+// synced on commit to the queue.
+func SyncPushMirrorsOnCommit(repoID int64) {
+	mirrors, err := GetPushMirrorsByRepoID(repoID)
+	if err != nil {
+		log.Error(4, "GetPushMirrorsByRepoID [%d]: %v", repoID, err)
+		return
+	}
+	for _, m := range mirrors {
+		if m.SyncOnCommit {
+			PushMirrorQueue.Add(m.ID)
+		}
+	}
+}
+
+// syncPushMirror pushes the repository to the given push mirror.
+func syncPushMirror(id string) {
+	log.Trace("SyncPushMirrors [id: %v]", id)
+
+	m := new(PushMirror)
+	has, err := x.ID(com.StrTo(id).MustInt64()).Get(m)
+	if err != nil {
+		log.Error(4, "GetPushMirrorByID [%s]: %v", id, err)
+		return
+	} else if !has || m.Repo == nil {
+		return
+	}
+
+	m.LastError = ""
+	if err = m.runSync(); err != nil {
+		m.LastError = err.Error()
+		log.Error(4, "Failed to push repository '%s' to mirror %d: %s", m.Repo.RepoPath(), m.ID, m.LastError)
+	}
+	m.UpdatedUnix = util.TimeStampNow()
+	m.ScheduleNextUpdate()
+	if err = updatePushMirror(x, m); err != nil {
+		log.Error(4, "UpdatePushMirror [%s]: %v", id, err)
+	}
+}
+
+// InitSyncPushMirrors initializes the queue and the workers to push the
+// repositories to their push mirrors
+func InitSyncPushMirrors() {
+	if err := PushMirrorQueue.Configure(setting.GetQueueSettings("push_mirror_sync", setting.Repository.MirrorQueueLength)); err != nil {
+		log.Fatal(4, "Failed to create push mirror queue: %v", err)
+	}
+	PushMirrorQueue.Run(syncPushMirror)
+}
@@ -56,6 +56,8 @@ type Review struct {
 	Issue      *Issue `xorm:"-"`
 	IssueID    int64  `xorm:"index"`
 	Content    string
+	// Dismissed reviews no longer count towards the approvals of a PR
+	Dismissed bool `xorm:"NOT NULL DEFAULT false"`
 
 	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
@@ -75,12 +77,15 @@ func (r *Review) LoadCodeComments() error {
 }
 
 func (r *Review) loadIssue(e Engine) (err error) {
+	if r.Issue != nil {
+		return nil
+	}
 	r.Issue, err = getIssueByID(e, r.IssueID)
 	return
 }
 
 func (r *Review) loadReviewer(e Engine) (err error) {
-	if r.ReviewerID == 0 {
+	if r.Reviewer != nil || r.ReviewerID == 0 {
 		return nil
 	}
 	r.Reviewer, err = getUserByID(e, r.ReviewerID)
@@ -165,7 +170,7 @@ func GetReviewByID(id int64) (*Review, error) {
 func getUniqueApprovalsByPullRequestID(e Engine, prID int64) (reviews []*Review, err error) {
 	reviews = make([]*Review, 0)
 	if err := e.
-		Where("issue_id = ? AND type = ?", prID, ReviewTypeApprove).
+		Where("issue_id = ? AND type = ? AND dismissed = ?", prID, ReviewTypeApprove, false).
 		OrderBy("updated_unix").
 		GroupBy("reviewer_id").
 		Find(&reviews); err != nil {
@@ -235,9 +240,16 @@ func createReview(e Engine, opts CreateReviewOptions) (*Review, error) {
 		return nil, err
 	}
 
+	if err := review.prepareWebhooks(e); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r *Review) prepareWebhooks(e Engine) error {
 	var reviewHookType HookEventType
 
-	switch opts.Type {
+	switch r.Type {
 	case ReviewTypeApprove:
 		reviewHookType = HookEventPullRequestApproved
 	case ReviewTypeComment:
@@ -246,32 +258,41 @@ func createReview(e Engine, opts CreateReviewOptions) (*Review, error) {
 		reviewHookType = HookEventPullRequestRejected
 	default:
 		// unsupported review webhook type here
-		return review, nil
+		return nil
 	}
 
-	pr := opts.Issue.PullRequest
+	if err := r.Issue.loadPullRequest(e); err != nil {
+		return err
+	}
+	pr := r.Issue.PullRequest
 
 	if err := pr.LoadIssue(); err != nil {
-		return nil, err
+		return err
+	}
+	if err := r.Issue.loadPoster(e); err != nil {
+		return err
+	}
+	if err := r.Issue.loadRepo(e); err != nil {
+		return err
 	}
 
-	mode, err := AccessLevel(opts.Issue.Poster, opts.Issue.Repo)
+	mode, err := accessLevelUnit(e, r.Issue.Poster, r.Issue.Repo, UnitTypeCode)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if err := PrepareWebhooks(opts.Issue.Repo, reviewHookType, &api.PullRequestPayload{
+	if err := prepareWebhooks(e, r.Issue.Repo, reviewHookType, &api.PullRequestPayload{
 		Action:      api.HookIssueSynchronized,
-		Index:       opts.Issue.Index,
+		Index:       r.Issue.Index,
 		PullRequest: pr.APIFormat(),
-		Repository:  opts.Issue.Repo.APIFormat(mode),
-		Sender:      opts.Reviewer.APIFormat(),
+		Repository:  r.Issue.Repo.APIFormat(mode),
+		Sender:      r.Reviewer.APIFormat(),
 	}); err != nil {
-		return nil, err
+		return err
 	}
-	go HookQueue.Add(opts.Issue.Repo.ID)
+	go HookQueue.Add(r.Issue.Repo.ID)
 
-	return review, nil
+	return nil
 }
 
 // CreateReview creates a new review based on opts
@@ -312,6 +333,172 @@ func UpdateReview(r *Review) error {
 	return nil
 }
 
+// SubmitReview publishes the given pending review as a review of the given type
+func SubmitReview(doer *User, review *Review, reviewType ReviewType, content string) (*Comment, error) {
+	if reviewType == ReviewTypePending || reviewType == ReviewTypeUnknown {
+		return nil, fmt.Errorf("review cannot be submitted as pending or unknown")
+	}
+	if err := review.loadAttributes(x); err != nil {
+		return nil, err
+	}
+	if err := review.Issue.loadRepo(x); err != nil {
+		return nil, err
+	}
+
+	wasPending := review.Type == ReviewTypePending
+	review.Content = content
+	review.Type = reviewType
+	if err := UpdateReview(review); err != nil {
+		return nil, err
+	}
+
+	// Reviews created as pending did not trigger any webhook yet
+	if wasPending {
+		if err := review.prepareWebhooks(x); err != nil {
+			return nil, err
+		}
+	}
+
+	comm, err := CreateComment(&CreateCommentOptions{
+		Type:     CommentTypeReview,
+		Doer:     doer,
+		Content:  review.Content,
+		Issue:    review.Issue,
+		Repo:     review.Issue.Repo,
+		ReviewID: review.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = review.Publish(); err != nil {
+		return nil, err
+	}
+
+	if reviewType == ReviewTypeApprove {
+		if pr, err := getPullRequestByIssueID(x, review.IssueID); err != nil {
+			log.Error(4, "getPullRequestByIssueID[%d]: %v", review.IssueID, err)
+		} else {
+			pr.AddToAutoMergeQueue()
+		}
+	}
+	return comm, nil
+}
+
+// DismissReview marks a submitted review as dismissed so it no longer counts for the pull request
+func DismissReview(review *Review) error {
+	if review.Type != ReviewTypeApprove && review.Type != ReviewTypeReject {
+		return fmt.Errorf("only approvals and change requests can be dismissed")
+	}
+	review.Dismissed = true
+	_, err := x.ID(review.ID).Cols("dismissed").Update(review)
+	return err
+}
+
+// DeletePendingReview deletes a pending review together with its code comments
+func DeletePendingReview(review *Review) error {
+	if review.Type != ReviewTypePending {
+		return fmt.Errorf("only pending reviews can be deleted")
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err := sess.Begin(); err != nil {
+		return err
+	}
+
+	if _, err := sess.Where("review_id = ? AND type = ?", review.ID, CommentTypeCode).Delete(new(Comment)); err != nil {
+		return err
+	}
+	if _, err := sess.ID(review.ID).Delete(new(Review)); err != nil {
+		return err
+	}
+	return sess.Commit()
+}
+
+// GetReviewComments returns all code comments of the given review
+func GetReviewComments(review *Review) ([]*Comment, error) {
+	comments, err := findComments(x, FindCommentsOptions{
+		Type:     CommentTypeCode,
+		IssueID:  review.IssueID,
+		ReviewID: review.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = CommentList(comments).loadPosters(x); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+// HTMLURL returns the URL of the review on the pull request page
+func (r *Review) HTMLURL() string {
+	comment := &Comment{
+		Type:     CommentTypeReview,
+		IssueID:  r.IssueID,
+		ReviewID: r.ID,
+	}
+	has, err := x.Get(comment)
+	if err != nil {
+		log.Error(4, "Get review comment(%d): %v", r.ID, err)
+		return ""
+	} else if !has {
+		return ""
+	}
+	return comment.HTMLURL()
+}
+
+// APIState returns the api state of the review type
+func (rt ReviewType) APIState() api.ReviewStateType {
+	switch rt {
+	case ReviewTypePending:
+		return api.ReviewStatePending
+	case ReviewTypeApprove:
+		return api.ReviewStateApproved
+	case ReviewTypeComment:
+		return api.ReviewStateComment
+	case ReviewTypeReject:
+		return api.ReviewStateRequestChanges
+	default:
+		return api.ReviewStateUnknown
+	}
+}
+
+// ReviewTypeFromAPIState returns the review type of an api review state
+func ReviewTypeFromAPIState(state api.ReviewStateType) ReviewType {
+	switch state {
+	case api.ReviewStatePending:
+		return ReviewTypePending
+	case api.ReviewStateApproved:
+		return ReviewTypeApprove
+	case api.ReviewStateComment:
+		return ReviewTypeComment
+	case api.ReviewStateRequestChanges:
+		return ReviewTypeReject
+	default:
+		return ReviewTypeUnknown
+	}
+}
+
+// APIFormat converts a Review to the api.PullReview format; Reviewer and Issue have to be loaded
+func (r *Review) APIFormat() *api.PullReview {
+	count, err := x.Where("review_id = ? AND type = ?", r.ID, CommentTypeCode).Count(new(Comment))
+	if err != nil {
+		log.Error(4, "Count code comments(%d): %v", r.ID, err)
+	}
+	return &api.PullReview{
+		ID:                r.ID,
+		Reviewer:          r.Reviewer.APIFormat(),
+		State:             r.Type.APIState(),
+		Body:              r.Content,
+		Dismissed:         r.Dismissed,
+		CodeCommentsCount: int(count),
+		Submitted:         r.UpdatedUnix.AsTime(),
+		HTMLURL:           r.HTMLURL(),
+		HTMLPullURL:       r.Issue.HTMLURL(),
+	}
+}
+
 // PullReviewersWithType represents the type used to display a review overview
 type PullReviewersWithType struct {
 	User              `xorm:"extends"`
@@ -325,17 +512,17 @@ func GetReviewersByPullID(pullID int64) (issueReviewers []*PullReviewersWithType
 	if x.Dialect().DBType() == core.MSSQL {
 		err = x.SQL(`SELECT [user].*, review.type, review.review_updated_unix FROM
 (SELECT review.id, review.type, review.reviewer_id, max(review.updated_unix) as review_updated_unix
-FROM review WHERE review.issue_id=? AND (review.type = ? OR review.type = ?)
+FROM review WHERE review.issue_id=? AND (review.type = ? OR review.type = ?) AND review.dismissed = ?
 GROUP BY review.id, review.type, review.reviewer_id) as review
 INNER JOIN [user] ON review.reviewer_id = [user].id ORDER BY review_updated_unix DESC`,
-			pullID, ReviewTypeApprove, ReviewTypeReject).
+			pullID, ReviewTypeApprove, ReviewTypeReject, false).
 			Find(&irs)
 	} else {
 		err = x.Select("`user`.*, review.type, max(review.updated_unix) as review_updated_unix").
 			Table("review").
 			Join("INNER", "`user`", "review.reviewer_id = `user`.id").
-			Where("review.issue_id = ? AND (review.type = ? OR review.type = ?)",
-				pullID, ReviewTypeApprove, ReviewTypeReject).
+			Where("review.issue_id = ? AND (review.type = ? OR review.type = ?) AND review.dismissed = ?",
+				pullID, ReviewTypeApprove, ReviewTypeReject, false).
 			GroupBy("`user`.id, review.type").
 			OrderBy("review_updated_unix DESC").
 			Find(&irs)
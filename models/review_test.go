@@ -135,3 +135,47 @@ func TestGetReviewersByPullID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedReviews, allReviews)
 }
+
+func TestSubmitReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	review := AssertExistsAndLoadBean(t, &Review{ID: 6}).(*Review)
+	assert.NoError(t, review.LoadAttributes())
+
+	comment, err := SubmitReview(review.Reviewer, review, ReviewTypeComment, "Submitted Review")
+	assert.NoError(t, err)
+	assert.Equal(t, CommentTypeReview, comment.Type)
+	assert.EqualValues(t, review.ID, comment.ReviewID)
+	AssertExistsAndLoadBean(t, &Review{ID: 6, Type: ReviewTypeComment, Content: "Submitted Review"})
+
+	_, err = SubmitReview(review.Reviewer, review, ReviewTypePending, "")
+	assert.Error(t, err)
+}
+
+func TestDismissReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	issue := AssertExistsAndLoadBean(t, &Issue{ID: 3}).(*Issue)
+
+	review := AssertExistsAndLoadBean(t, &Review{ID: 8}).(*Review)
+	assert.NoError(t, DismissReview(review))
+	AssertExistsIf(t, true, &Review{ID: 8}, Cond("dismissed = ?", true))
+
+	reviewers, err := GetReviewersByPullID(issue.ID)
+	assert.NoError(t, err)
+	for _, reviewer := range reviewers {
+		assert.NotEqual(t, review.ReviewerID, reviewer.ID)
+	}
+
+	pending := AssertExistsAndLoadBean(t, &Review{ID: 6}).(*Review)
+	assert.Error(t, DismissReview(pending))
+}
+
+func TestDeletePendingReview(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	review := AssertExistsAndLoadBean(t, &Review{ID: 4}).(*Review)
+	assert.NoError(t, DeletePendingReview(review))
+	AssertNotExistsBean(t, &Review{ID: 4})
+	AssertNotExistsBean(t, &Comment{ReviewID: 4, Type: CommentTypeCode})
+
+	approved := AssertExistsAndLoadBean(t, &Review{ID: 1}).(*Review)
+	assert.Error(t, DeletePendingReview(approved))
+}
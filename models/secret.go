@@ -0,0 +1,197 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
+)
+
+// secretNamePattern the pattern of the secret names, which are upper-cased
+// before being validated
+var secretNamePattern = regexp.MustCompile("^[A-Z_][A-Z0-9_]*$")
+
+// secretNameReservedPrefix the prefix of the secret names reserved for the
+// values provided by Gitea itself
+const secretNameReservedPrefix = "GITEA_"
+
+// Secret represents an encrypted value stored for a repository, or for all the
+// repositories of an organization. Only its name can be read by the users, the
+// value is decrypted for the services using it.
+type Secret struct {
+	ID          int64          `xorm:"pk autoincr"`
+	OwnerID     int64          `xorm:"UNIQUE(owner_repo_name) INDEX NOT NULL DEFAULT 0"`
+	RepoID      int64          `xorm:"UNIQUE(owner_repo_name) INDEX NOT NULL DEFAULT 0"`
+	Name        string         `xorm:"UNIQUE(owner_repo_name) NOT NULL"`
+	Data        string         `xorm:"LONGTEXT"`
+	CreatedUnix util.TimeStamp `xorm:"created NOT NULL"`
+	UpdatedUnix util.TimeStamp `xorm:"updated NOT NULL"`
+}
+
+// secretEncryptionKey derives from SECRET_KEY the key of the secrets of the
+// given organization or repository, so that the data of a secret copied to
+// another one can not be decrypted
+func secretEncryptionKey(ownerID, repoID int64) []byte {
+	mac := hmac.New(sha256.New, []byte(setting.SecretKey))
+	fmt.Fprintf(mac, "secret:%d:%d", ownerID, repoID)
+	return mac.Sum(nil)
+}
+
+// newSecretCipher returns the authenticated cipher of the secrets of the
+// given organization or repository
+func newSecretCipher(ownerID, repoID int64) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secretEncryptionKey(ownerID, repoID))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// setData encrypts the value of the secret with AES-GCM, authenticating its
+// name as well so that the data can not be moved to another secret
+func (s *Secret) setData(data string) error {
+	gcm, err := newSecretCipher(s.OwnerID, s.RepoID)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	encrypted := gcm.Seal(nonce, nonce, []byte(data), []byte(s.Name))
+	s.Data = base64.StdEncoding.EncodeToString(encrypted)
+	return nil
+}
+
+// GetData returns the decrypted value of the secret, or an error if its data
+// has been tampered with
+func (s *Secret) GetData() (string, error) {
+	encrypted, err := base64.StdEncoding.DecodeString(s.Data)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newSecretCipher(s.OwnerID, s.RepoID)
+	if err != nil {
+		return "", err
+	}
+	if len(encrypted) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, encrypted := encrypted[:gcm.NonceSize()], encrypted[gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, encrypted, []byte(s.Name))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// NormalizeSecretName returns the upper-cased secret name, or an
+// ErrSecretNameInvalid if it is not made of letters, digits and underscores,
+// starts with a digit or with the reserved GITEA_ prefix.
+func NormalizeSecretName(name string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(name))
+	if !secretNamePattern.MatchString(normalized) || strings.HasPrefix(normalized, secretNameReservedPrefix) {
+		return "", ErrSecretNameInvalid{Name: name}
+	}
+	return normalized, nil
+}
+
+// CreateOrUpdateSecret sets the value of the secret of the organization
+// ownerID, or of the repository repoID, with the given name. It returns true
+// if the secret has been created.
+func CreateOrUpdateSecret(ownerID, repoID int64, name, data string) (bool, error) {
+	name, err := NormalizeSecretName(name)
+	if err != nil {
+		return false, err
+	}
+
+	sess := x.NewSession()
+	defer sess.Close()
+	if err = sess.Begin(); err != nil {
+		return false, err
+	}
+
+	secret := &Secret{OwnerID: ownerID, RepoID: repoID, Name: name}
+	has, err := sess.
+		Where("owner_id = ? AND repo_id = ? AND name = ?", ownerID, repoID, name).
+		Get(secret)
+	if err != nil {
+		return false, err
+	}
+	if err = secret.setData(data); err != nil {
+		return false, fmt.Errorf("setData: %v", err)
+	}
+	if has {
+		_, err = sess.ID(secret.ID).Cols("data").Update(secret)
+	} else {
+		_, err = sess.Insert(secret)
+	}
+	if err != nil {
+		return false, err
+	}
+	return !has, sess.Commit()
+}
+
+// FindSecrets returns the secrets of the organization ownerID, or of the
+// repository repoID, sorted by name
+func FindSecrets(ownerID, repoID int64) ([]*Secret, error) {
+	secrets := make([]*Secret, 0, 5)
+	return secrets, x.
+		Where("owner_id = ? AND repo_id = ?", ownerID, repoID).
+		Asc("name").
+		Find(&secrets)
+}
+
+// DeleteSecret deletes the secret of the organization ownerID, or of the
+// repository repoID, with the given name
+func DeleteSecret(ownerID, repoID int64, name string) error {
+	normalized, err := NormalizeSecretName(name)
+	if err != nil {
+		return ErrSecretNotExist{Name: name}
+	}
+	affected, err := x.
+		Where("owner_id = ? AND repo_id = ? AND name = ?", ownerID, repoID, normalized).
+		Delete(new(Secret))
+	if err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrSecretNotExist{Name: name}
+	}
+	return nil
+}
+
+// GetRepoSecrets returns the decrypted values of the secrets available to the
+// repository by name: those of its owner organization, overridden by its own.
+func GetRepoSecrets(repo *Repository) (map[string]string, error) {
+	secrets := make([]*Secret, 0, 10)
+	if err := x.
+		Where("(owner_id = ? AND repo_id = 0) OR (owner_id = 0 AND repo_id = ?)", repo.OwnerID, repo.ID).
+		Asc("repo_id").
+		Find(&secrets); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		data, err := secret.GetData()
+		if err != nil {
+			return nil, fmt.Errorf("GetData [%d]: %v", secret.ID, err)
+		}
+		values[secret.Name] = data
+	}
+	return values, nil
+}
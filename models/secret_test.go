@@ -0,0 +1,137 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSecretName(t *testing.T) {
+	for name, expected := range map[string]string{
+		"DEPLOY_TOKEN": "DEPLOY_TOKEN",
+		" deploy_key2": "DEPLOY_KEY2",
+		"_TOKEN":       "_TOKEN",
+	} {
+		normalized, err := NormalizeSecretName(name)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, normalized)
+	}
+
+	for _, name := range []string{"", "2TOKEN", "DEPLOY-TOKEN", "DEPLOY TOKEN", "gitea_token"} {
+		_, err := NormalizeSecretName(name)
+		assert.True(t, IsErrSecretNameInvalid(err), name)
+	}
+}
+
+func TestCreateOrUpdateSecret(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	created, err := CreateOrUpdateSecret(0, 1, "deploy_token", "s3cr3t")
+	assert.NoError(t, err)
+	assert.True(t, created)
+	secret := AssertExistsAndLoadBean(t, &Secret{RepoID: 1, Name: "DEPLOY_TOKEN"}).(*Secret)
+	assert.NotContains(t, secret.Data, "s3cr3t")
+	data, err := secret.GetData()
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", data)
+
+	created, err = CreateOrUpdateSecret(0, 1, "DEPLOY_TOKEN", "updated")
+	assert.NoError(t, err)
+	assert.False(t, created)
+	secret = AssertExistsAndLoadBean(t, &Secret{RepoID: 1, Name: "DEPLOY_TOKEN"}).(*Secret)
+	data, err = secret.GetData()
+	assert.NoError(t, err)
+	assert.Equal(t, "updated", data)
+
+	// the data of a secret can only be decrypted with the key of its repository
+	secret.RepoID = 2
+	_, err = secret.GetData()
+	assert.Error(t, err)
+	secret.RepoID = 1
+
+	// nor moved to another secret, nor tampered with
+	secret.Name = "OTHER_TOKEN"
+	_, err = secret.GetData()
+	assert.Error(t, err)
+	secret.Name = "DEPLOY_TOKEN"
+	encrypted, err := base64.StdEncoding.DecodeString(secret.Data)
+	assert.NoError(t, err)
+	encrypted[len(encrypted)-1] ^= 1
+	secret.Data = base64.StdEncoding.EncodeToString(encrypted)
+	_, err = secret.GetData()
+	assert.Error(t, err)
+
+	_, err = CreateOrUpdateSecret(0, 1, "DEPLOY-TOKEN", "s3cr3t")
+	assert.True(t, IsErrSecretNameInvalid(err))
+}
+
+func TestFindSecrets(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	for _, name := range []string{"B_TOKEN", "A_TOKEN"} {
+		_, err := CreateOrUpdateSecret(0, 1, name, "repo")
+		assert.NoError(t, err)
+	}
+	_, err := CreateOrUpdateSecret(3, 0, "ORG_TOKEN", "org")
+	assert.NoError(t, err)
+
+	secrets, err := FindSecrets(0, 1)
+	assert.NoError(t, err)
+	if assert.Len(t, secrets, 2) {
+		assert.Equal(t, "A_TOKEN", secrets[0].Name)
+		assert.Equal(t, "B_TOKEN", secrets[1].Name)
+	}
+
+	secrets, err = FindSecrets(3, 0)
+	assert.NoError(t, err)
+	assert.Len(t, secrets, 1)
+
+	secrets, err = FindSecrets(0, 2)
+	assert.NoError(t, err)
+	assert.Len(t, secrets, 0)
+}
+
+func TestDeleteSecret(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	_, err := CreateOrUpdateSecret(0, 1, "DEPLOY_TOKEN", "s3cr3t")
+	assert.NoError(t, err)
+
+	assert.True(t, IsErrSecretNotExist(DeleteSecret(0, 2, "DEPLOY_TOKEN")))
+	assert.NoError(t, DeleteSecret(0, 1, "deploy_token"))
+	AssertNotExistsBean(t, &Secret{RepoID: 1, Name: "DEPLOY_TOKEN"})
+	assert.True(t, IsErrSecretNotExist(DeleteSecret(0, 1, "DEPLOY_TOKEN")))
+}
+
+func TestGetRepoSecrets(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	// repo3 belongs to the organization user3
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 3}).(*Repository)
+	for _, secret := range []struct {
+		OwnerID int64
+		RepoID  int64
+		Name    string
+		Data    string
+	}{
+		{repo.OwnerID, 0, "ORG_TOKEN", "org"},
+		{repo.OwnerID, 0, "DEPLOY_TOKEN", "org"},
+		{0, repo.ID, "DEPLOY_TOKEN", "repo"},
+		{0, 1, "OTHER_TOKEN", "other"},
+	} {
+		_, err := CreateOrUpdateSecret(secret.OwnerID, secret.RepoID, secret.Name, secret.Data)
+		assert.NoError(t, err)
+	}
+
+	values, err := GetRepoSecrets(repo)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"ORG_TOKEN":    "org",
+		"DEPLOY_TOKEN": "repo",
+	}, values)
+}
@@ -157,6 +157,23 @@ func GetLatestCommitStatus(repo *Repository, sha string, page int) ([]*CommitSta
 	return statuses, x.In("id", ids).Find(&statuses)
 }
 
+// getAllLatestCommitStatus returns all statuses with a unique context for a given commit, without paging.
+func getAllLatestCommitStatus(e Engine, repo *Repository, sha string) ([]*CommitStatus, error) {
+	ids := make([]int64, 0, 10)
+	err := e.Table(&CommitStatus{}).
+		Where("repo_id = ?", repo.ID).And("sha = ?", sha).
+		Select("max( id ) as id").
+		GroupBy("context").Find(&ids)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]*CommitStatus, 0, len(ids))
+	if len(ids) == 0 {
+		return statuses, nil
+	}
+	return statuses, e.In("id", ids).Find(&statuses)
+}
+
 // GetCommitStatus populates a given status for a given commit.
 // NOTE: If ID or Index isn't given, and only Context, TargetURL and/or Description
 //       is given, the CommitStatus created _last_ will be returned.
@@ -258,7 +275,12 @@ func NewCommitStatus(repo *Repository, creator *User, sha string, status *Commit
 		return fmt.Errorf("NewCommitStatus[repo_id: %d, user_id: %d, sha: %s]: %v", repo.ID, creator.ID, sha, err)
 	}
 
-	return sess.Commit()
+	if err := sess.Commit(); err != nil {
+		return err
+	}
+
+	go addScheduledMergesToQueue(repo.ID)
+	return nil
 }
 
 // SignCommitWithStatuses represents a commit with validation of signature and status state.
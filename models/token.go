@@ -5,6 +5,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	gouuid "github.com/satori/go.uuid"
@@ -13,6 +14,64 @@ import (
 	"code.gitea.io/gitea/modules/util"
 )
 
+// AccessTokenScope represents a permission that can be granted to an access token.
+type AccessTokenScope string
+
+// Available scopes of access tokens
+const (
+	AccessTokenScopeAll          AccessTokenScope = "all"
+	AccessTokenScopeRepoRead     AccessTokenScope = "repo:read"
+	AccessTokenScopeRepoWrite    AccessTokenScope = "repo:write"
+	AccessTokenScopeAdmin        AccessTokenScope = "admin"
+	AccessTokenScopeOrg          AccessTokenScope = "org"
+	AccessTokenScopeUser         AccessTokenScope = "user"
+	AccessTokenScopeNotification AccessTokenScope = "notification"
+)
+
+// AccessTokenScopes contains all the scopes that can be granted to an access token
+var AccessTokenScopes = []AccessTokenScope{
+	AccessTokenScopeAll,
+	AccessTokenScopeRepoRead,
+	AccessTokenScopeRepoWrite,
+	AccessTokenScopeAdmin,
+	AccessTokenScopeOrg,
+	AccessTokenScopeUser,
+	AccessTokenScopeNotification,
+}
+
+// IsValid checks if the scope is a known scope
+func (scope AccessTokenScope) IsValid() bool {
+	for _, s := range AccessTokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAccessTokenScope checks the given scopes and joins them in the format stored in AccessToken.Scope.
+// No scope at all grants full access, for compatibility with tokens created before scopes existed.
+func ParseAccessTokenScope(scopes []string) (string, error) {
+	parsed := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if len(scope) == 0 {
+			continue
+		}
+		if !AccessTokenScope(scope).IsValid() {
+			return "", ErrAccessTokenInvalidScope{scope}
+		}
+		if scope == string(AccessTokenScopeAll) {
+			return scope, nil
+		}
+		parsed = append(parsed, scope)
+	}
+	if len(parsed) == 0 {
+		return string(AccessTokenScopeAll), nil
+	}
+	return strings.Join(parsed, ","), nil
+}
+
 // AccessToken represents a personal access token.
 type AccessToken struct {
 	ID   int64 `xorm:"pk autoincr"`
@@ -20,6 +79,13 @@ type AccessToken struct {
 	Name string
 	Sha1 string `xorm:"UNIQUE VARCHAR(40)"`
 
+	// Scope is a comma separated list of AccessTokenScope
+	Scope string `xorm:"VARCHAR(255) NOT NULL DEFAULT 'all'"`
+	// RepoID restricts the token to a single repository when it is not zero
+	RepoID int64       `xorm:"INDEX NOT NULL DEFAULT 0"`
+	Repo   *Repository `xorm:"-"`
+
+	ExpiresUnix       util.TimeStamp `xorm:"INDEX NOT NULL DEFAULT 0"`
 	CreatedUnix       util.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix       util.TimeStamp `xorm:"INDEX updated"`
 	HasRecentActivity bool           `xorm:"-"`
@@ -32,9 +98,82 @@ func (t *AccessToken) AfterLoad() {
 	t.HasRecentActivity = t.UpdatedUnix.AddDuration(7*24*time.Hour) > util.TimeStampNow()
 }
 
+// Scopes returns the scopes granted to the token
+func (t *AccessToken) Scopes() []AccessTokenScope {
+	if len(t.Scope) == 0 {
+		return []AccessTokenScope{AccessTokenScopeAll}
+	}
+	fields := strings.Split(t.Scope, ",")
+	scopes := make([]AccessTokenScope, 0, len(fields))
+	for _, field := range fields {
+		scopes = append(scopes, AccessTokenScope(field))
+	}
+	return scopes
+}
+
+// HasScope returns true if the token grants the given scope.
+// The repo:write scope implies repo:read.
+func (t *AccessToken) HasScope(scope AccessTokenScope) bool {
+	for _, s := range t.Scopes() {
+		if s == AccessTokenScopeAll || s == scope ||
+			(s == AccessTokenScopeRepoWrite && scope == AccessTokenScopeRepoRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired returns true if the token has an expiry date and it has passed
+func (t *AccessToken) IsExpired() bool {
+	return t.ExpiresUnix > 0 && t.ExpiresUnix <= util.TimeStampNow()
+}
+
+// CanAccessRepo returns false if the token is restricted to another repository
+func (t *AccessToken) CanAccessRepo(repoID int64) bool {
+	return t.RepoID == 0 || t.RepoID == repoID
+}
+
+// LoadRepo loads the repository the token is restricted to, if any
+func (t *AccessToken) LoadRepo() (err error) {
+	if t.RepoID == 0 || t.Repo != nil {
+		return nil
+	}
+	t.Repo, err = GetRepositoryByID(t.RepoID)
+	return err
+}
+
+// RestrictToRepo restricts the token to the repository with the given full name ("owner/name").
+// It returns ErrRepoNotExist if the owner of the token cannot access the repository.
+func (t *AccessToken) RestrictToRepo(fullName string) error {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 {
+		return ErrRepoNotExist{0, t.UID, "", fullName}
+	}
+	repo, err := GetRepositoryByOwnerAndName(parts[0], parts[1])
+	if err != nil {
+		return err
+	}
+	user, err := GetUserByID(t.UID)
+	if err != nil {
+		return err
+	}
+	perm, err := GetUserRepoPermission(repo, user)
+	if err != nil {
+		return err
+	} else if !perm.HasAccess() {
+		return ErrRepoNotExist{repo.ID, t.UID, parts[0], parts[1]}
+	}
+	t.RepoID = repo.ID
+	t.Repo = repo
+	return nil
+}
+
 // NewAccessToken creates new access token.
 func NewAccessToken(t *AccessToken) error {
 	t.Sha1 = base.EncodeSha1(gouuid.NewV4().String())
+	if len(t.Scope) == 0 {
+		t.Scope = string(AccessTokenScopeAll)
+	}
 	_, err := x.Insert(t)
 	return err
 }
@@ -7,6 +7,8 @@ package models
 import (
 	"testing"
 
+	"code.gitea.io/gitea/modules/util"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -91,3 +93,58 @@ func TestDeleteAccessTokenByID(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, IsErrAccessTokenNotExist(err))
 }
+
+func TestParseAccessTokenScope(t *testing.T) {
+	scope, err := ParseAccessTokenScope(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "all", scope)
+
+	scope, err = ParseAccessTokenScope([]string{"repo:read", " notification", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, "repo:read,notification", scope)
+
+	scope, err = ParseAccessTokenScope([]string{"repo:read", "all"})
+	assert.NoError(t, err)
+	assert.Equal(t, "all", scope)
+
+	_, err = ParseAccessTokenScope([]string{"repo:read", "repo:admin"})
+	assert.True(t, IsErrAccessTokenInvalidScope(err))
+}
+
+func TestAccessToken_HasScope(t *testing.T) {
+	token := &AccessToken{Scope: "all"}
+	assert.True(t, token.HasScope(AccessTokenScopeAdmin))
+	assert.True(t, token.HasScope(AccessTokenScopeRepoWrite))
+
+	token = &AccessToken{Scope: "repo:write,notification"}
+	assert.True(t, token.HasScope(AccessTokenScopeRepoRead))
+	assert.True(t, token.HasScope(AccessTokenScopeRepoWrite))
+	assert.True(t, token.HasScope(AccessTokenScopeNotification))
+	assert.False(t, token.HasScope(AccessTokenScopeUser))
+
+	token = &AccessToken{Scope: "repo:read"}
+	assert.True(t, token.HasScope(AccessTokenScopeRepoRead))
+	assert.False(t, token.HasScope(AccessTokenScopeRepoWrite))
+}
+
+func TestAccessToken_IsExpired(t *testing.T) {
+	assert.False(t, (&AccessToken{}).IsExpired())
+	assert.False(t, (&AccessToken{ExpiresUnix: util.TimeStampNow() + 60}).IsExpired())
+	assert.True(t, (&AccessToken{ExpiresUnix: util.TimeStampNow() - 60}).IsExpired())
+}
+
+func TestAccessToken_RestrictToRepo(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	token := &AccessToken{UID: 2}
+	assert.NoError(t, token.RestrictToRepo("user2/repo1"))
+	assert.EqualValues(t, 1, token.RepoID)
+	assert.True(t, token.CanAccessRepo(1))
+	assert.False(t, token.CanAccessRepo(2))
+
+	// user4 cannot access the private repository of user2
+	token = &AccessToken{UID: 4}
+	assert.True(t, IsErrRepoNotExist(token.RestrictToRepo("user2/repo2")))
+	assert.True(t, IsErrRepoNotExist(token.RestrictToRepo("repo1")))
+	assert.True(t, token.CanAccessRepo(2))
+}
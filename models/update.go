@@ -81,6 +81,7 @@ func PushUpdate(branch string, opt PushUpdateOptions) error {
 	log.Trace("TriggerTask '%s/%s' by %s", repo.Name, branch, pusher.Name)
 
 	go AddTestPullRequestTask(pusher, repo.ID, branch, true)
+	go SyncPushMirrorsOnCommit(repo.ID)
 	return nil
 }
 
@@ -263,7 +264,7 @@ func pushUpdate(opts PushUpdateOptions) (repo *Repository, err error) {
 		commits = ListToPushCommits(l)
 	}
 
-	if opts.RefFullName == git.BranchPrefix+repo.DefaultBranch {
+	if repo.isIndexedRef(opts.RefFullName) {
 		UpdateRepoIndexer(repo)
 	}
 
@@ -10,13 +10,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"strings"
 	"time"
 
 	"code.gitea.io/gitea/modules/httplib"
 	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/queue"
 	"code.gitea.io/gitea/modules/setting"
-	"code.gitea.io/gitea/modules/sync"
 	"code.gitea.io/gitea/modules/util"
 	api "code.gitea.io/sdk/gitea"
 	"github.com/Unknwon/com"
@@ -24,7 +25,7 @@ import (
 )
 
 // HookQueue is a global queue of web hooks
-var HookQueue = sync.NewUniqueQueue(setting.Webhook.QueueLength)
+var HookQueue = queue.NewNamed("webhook", setting.Webhook.QueueLength)
 
 // HookContentType is the content type of a web hook
 type HookContentType int
@@ -110,6 +111,9 @@ type Webhook struct {
 	HookTaskType HookTaskType
 	Meta         string     `xorm:"TEXT"` // store hook-specific attributes
 	LastStatus   HookStatus // Last delivery status
+	// FailureCount the number of consecutive deliveries which failed after
+	// all their attempts
+	FailureCount int `xorm:"NOT NULL DEFAULT 0"`
 
 	CreatedUnix util.TimeStamp `xorm:"INDEX created"`
 	UpdatedUnix util.TimeStamp `xorm:"INDEX updated"`
@@ -141,6 +145,24 @@ func (w *Webhook) GetDiscordHook() *DiscordMeta {
 	return s
 }
 
+// GetTelegramHook returns telegram metadata
+func (w *Webhook) GetTelegramHook() *TelegramMeta {
+	s := &TelegramMeta{}
+	if err := json.Unmarshal([]byte(w.Meta), s); err != nil {
+		log.Error(4, "webhook.GetTelegramHook(%d): %v", w.ID, err)
+	}
+	return s
+}
+
+// GetMatrixHook returns matrix metadata
+func (w *Webhook) GetMatrixHook() *MatrixMeta {
+	s := &MatrixMeta{}
+	if err := json.Unmarshal([]byte(w.Meta), s); err != nil {
+		log.Error(4, "webhook.GetMatrixHook(%d): %v", w.ID, err)
+	}
+	return s
+}
+
 // History returns history of webhook by given conditions.
 func (w *Webhook) History(page int) ([]*HookTask, error) {
 	return HookTasks(w.ID, page)
@@ -318,6 +340,11 @@ func GetWebhooksByOrgID(orgID int64) (ws []*Webhook, err error) {
 
 // UpdateWebhook updates information of webhook.
 func UpdateWebhook(w *Webhook) error {
+	// editing an active webhook gives it a fresh start, in particular after
+	// it has been disabled because of its failures
+	if w.IsActive {
+		w.FailureCount = 0
+	}
 	_, err := x.ID(w.ID).AllCols().Update(w)
 	return err
 }
@@ -328,6 +355,75 @@ func UpdateWebhookLastStatus(w *Webhook) error {
 	return err
 }
 
+// updateWebhookDeliveryStatus updates the status of the webhook after the last
+// attempt of a delivery, and disables it after too many consecutive failures
+func updateWebhookDeliveryStatus(w *Webhook, isSucceed bool) error {
+	if isSucceed {
+		w.LastStatus = HookStatusSucceed
+		w.FailureCount = 0
+	} else {
+		w.LastStatus = HookStatusFail
+		w.FailureCount++
+	}
+
+	disable := w.IsActive && !isSucceed && setting.Webhook.DisableAfterFailures > 0 &&
+		w.FailureCount >= setting.Webhook.DisableAfterFailures
+	if disable {
+		w.IsActive = false
+	}
+	if _, err := x.ID(w.ID).Cols("last_status", "failure_count", "is_active").Update(w); err != nil {
+		return err
+	}
+
+	if disable {
+		log.Warn("Webhook %d disabled after %d consecutive failed deliveries", w.ID, w.FailureCount)
+		if err := notifyWebhookDisabled(w); err != nil {
+			return fmt.Errorf("notifyWebhookDisabled: %v", err)
+		}
+	}
+	return nil
+}
+
+// notifyWebhookDisabled notifies the administrators of the repository, or the
+// owners of the organization, that the webhook has been disabled
+func notifyWebhookDisabled(w *Webhook) error {
+	if !setting.Service.EnableNotifyMail {
+		return nil
+	}
+
+	var (
+		recipients []*User
+		name, link string
+	)
+	if w.RepoID > 0 {
+		repo, err := GetRepositoryByID(w.RepoID)
+		if err != nil {
+			return err
+		}
+		if recipients, err = repo.getUsersWithAccessMode(x, AccessModeAdmin); err != nil {
+			return err
+		}
+		name, link = repo.FullName(), repo.HTMLURL()
+	} else {
+		org, err := GetUserByID(w.OrgID)
+		if err != nil {
+			return err
+		}
+		team, err := org.GetOwnerTeam()
+		if err != nil {
+			return err
+		}
+		if err = team.GetMembers(); err != nil {
+			return err
+		}
+		recipients = team.Members
+		name, link = org.Name, setting.AppURL+"org/"+org.Name
+	}
+
+	SendWebhookDisabledMail(recipients, w, name, fmt.Sprintf("%s/settings/hooks/%d", link, w.ID))
+	return nil
+}
+
 // deleteWebhook uses argument bean as query condition,
 // ID must be specified and do not assign unnecessary fields.
 func deleteWebhook(bean *Webhook) (err error) {
@@ -381,6 +477,9 @@ const (
 	GITEA
 	DISCORD
 	DINGTALK
+	MSTEAMS
+	TELEGRAM
+	MATRIX
 )
 
 var hookTaskTypes = map[string]HookTaskType{
@@ -389,6 +488,9 @@ var hookTaskTypes = map[string]HookTaskType{
 	"slack":    SLACK,
 	"discord":  DISCORD,
 	"dingtalk": DINGTALK,
+	"msteams":  MSTEAMS,
+	"telegram": TELEGRAM,
+	"matrix":   MATRIX,
 }
 
 // ToHookTaskType returns HookTaskType by given name.
@@ -409,6 +511,12 @@ func (t HookTaskType) Name() string {
 		return "discord"
 	case DINGTALK:
 		return "dingtalk"
+	case MSTEAMS:
+		return "msteams"
+	case TELEGRAM:
+		return "telegram"
+	case MATRIX:
+		return "matrix"
 	}
 	return ""
 }
@@ -438,6 +546,48 @@ const (
 	HookEventPullRequestComment  HookEventType = "pull_request_comment"
 )
 
+// getIssueActionDesc describes the action of an issue or pull request event in
+// the messages of the chat webhooks
+func getIssueActionDesc(action api.HookIssueAction, assignees []*api.User) string {
+	switch action {
+	case api.HookIssueOpened:
+		return "opened"
+	case api.HookIssueClosed:
+		return "closed"
+	case api.HookIssueReOpened:
+		return "re-opened"
+	case api.HookIssueEdited:
+		return "edited"
+	case api.HookIssueAssigned:
+		names := make([]string, len(assignees))
+		for i, assignee := range assignees {
+			names[i] = assignee.UserName
+		}
+		return "assigned to " + strings.Join(names, ", ")
+	case api.HookIssueUnassigned:
+		return "unassigned"
+	case api.HookIssueLabelUpdated:
+		return "labels updated"
+	case api.HookIssueLabelCleared:
+		return "labels cleared"
+	case api.HookIssueSynchronized:
+		return "synchronized"
+	case api.HookIssueMilestoned:
+		return "milestone"
+	case api.HookIssueDemilestoned:
+		return "clear milestone"
+	}
+	return string(action)
+}
+
+// getIssueAssignees returns the assignees of an issue or pull request of a payload
+func getIssueAssignees(assignee *api.User, assignees []*api.User) []*api.User {
+	if len(assignees) == 0 && assignee != nil {
+		return []*api.User{assignee}
+	}
+	return assignees
+}
+
 // HookRequest represents hook task request information.
 type HookRequest struct {
 	Headers map[string]string `json:"headers"`
@@ -466,6 +616,10 @@ type HookTask struct {
 	IsDelivered     bool
 	Delivered       int64
 	DeliveredString string `xorm:"-"`
+	// Attempts the number of delivery attempts made so far, a failed attempt
+	// being retried while there are less than setting.Webhook.MaxAttempts
+	Attempts        int            `xorm:"NOT NULL DEFAULT 0"`
+	NextAttemptUnix util.TimeStamp `xorm:"NOT NULL DEFAULT 0"`
 
 	// History info.
 	IsSucceed       bool
@@ -525,6 +679,50 @@ func HookTasks(hookID int64, page int) ([]*HookTask, error) {
 		Find(&tasks)
 }
 
+// CountHookTasks returns the number of hook tasks of the webhook.
+func CountHookTasks(hookID int64) (int64, error) {
+	return x.Where("hook_id=?", hookID).Count(new(HookTask))
+}
+
+// GetHookTaskByID returns the hook task of the webhook by given ID.
+func GetHookTaskByID(hookID, id int64) (*HookTask, error) {
+	t := new(HookTask)
+	has, err := x.Where("id=? AND hook_id=?", id, hookID).Get(t)
+	if err != nil {
+		return nil, err
+	} else if !has {
+		return nil, ErrHookTaskNotExist{ID: id, HookID: hookID}
+	}
+	return t, nil
+}
+
+// RedeliverHookTask creates a new hook task delivering again the payload of the
+// given hook task of the webhook, with its current settings.
+func RedeliverHookTask(w *Webhook, taskID int64) (*HookTask, error) {
+	t, err := GetHookTaskByID(w.ID, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	redelivery := &HookTask{
+		RepoID:         t.RepoID,
+		HookID:         w.ID,
+		UUID:           gouuid.NewV4().String(),
+		Type:           w.HookTaskType,
+		URL:            w.URL,
+		PayloadContent: t.PayloadContent,
+		ContentType:    w.ContentType,
+		EventType:      t.EventType,
+		IsSSL:          w.IsSSL,
+	}
+	if _, err = x.Insert(redelivery); err != nil {
+		return nil, err
+	}
+
+	go HookQueue.Add(redelivery.RepoID)
+	return redelivery, nil
+}
+
 // CreateHookTask creates a new hook task,
 // it handles conversion from Payload to PayloadContent.
 func CreateHookTask(t *HookTask) error {
@@ -581,8 +779,30 @@ func prepareWebhook(e Engine, w *Webhook, repo *Repository, event HookEventType,
 		if err != nil {
 			return fmt.Errorf("GetDingtalkPayload: %v", err)
 		}
+	case MSTEAMS:
+		payloader, err = GetMSTeamsPayload(p, event, w.Meta)
+		if err != nil {
+			return fmt.Errorf("GetMSTeamsPayload: %v", err)
+		}
+	case TELEGRAM:
+		payloader, err = GetTelegramPayload(p, event, w.Meta)
+		if err != nil {
+			return fmt.Errorf("GetTelegramPayload: %v", err)
+		}
+	case MATRIX:
+		payloader, err = GetMatrixPayload(p, event, w.Meta)
+		if err != nil {
+			return fmt.Errorf("GetMatrixPayload: %v", err)
+		}
 	default:
-		p.SetSecret(w.Secret)
+		// The secret is only sent in the payload for the receivers which do
+		// not verify the signature yet, since anyone reading a payload could
+		// forge signatures with it.
+		if setting.Webhook.SecretInPayload {
+			p.SetSecret(w.Secret)
+		} else {
+			p.SetSecret("")
+		}
 		payloader = p
 	}
 
@@ -634,42 +854,56 @@ func prepareWebhooks(e Engine, repo *Repository, event HookEventType, p api.Payl
 	return nil
 }
 
-func (t *HookTask) deliver() {
-	t.IsDelivered = true
+// maxHookRetryDelay the delay after which the delay between the attempts of
+// a delivery stops doubling
+const maxHookRetryDelay = 24 * time.Hour
 
-	timeout := time.Duration(setting.Webhook.DeliverTimeout) * time.Second
-	req := httplib.Post(t.URL).SetTimeout(timeout, timeout).
-		Header("X-Gitea-Delivery", t.UUID).
-		Header("X-Gitea-Event", string(t.EventType)).
-		Header("X-Gogs-Delivery", t.UUID).
-		Header("X-Gogs-Event", string(t.EventType)).
-		HeaderWithSensitiveCase("X-GitHub-Delivery", t.UUID).
-		HeaderWithSensitiveCase("X-GitHub-Event", string(t.EventType)).
-		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: setting.Webhook.SkipTLSVerify})
+// retryDelay returns the delay before the next attempt of a delivery which
+// failed, doubling after each attempt until it reaches maxHookRetryDelay
+func (t *HookTask) retryDelay() time.Duration {
+	delay := setting.Webhook.RetryInterval
+	for i := 1; i < t.Attempts && delay < maxHookRetryDelay; i++ {
+		delay *= 2
+	}
+	return delay
+}
 
-	switch t.ContentType {
-	case ContentTypeJSON:
-		req = req.Header("Content-Type", "application/json").Body(t.PayloadContent)
-	case ContentTypeForm:
-		req.Param("payload", t.PayloadContent)
+func (t *HookTask) deliver() {
+	w, err := GetWebhookByID(t.HookID)
+
+	// The retries of the webhooks which have been deleted or disabled since
+	// their first attempt are dropped.
+	if t.Attempts > 0 && (IsErrWebhookNotExist(err) || err == nil && !w.IsActive) {
+		log.Trace("Hook %d is inactive, dropping delivery: %s", t.HookID, t.UUID)
+		t.IsDelivered = true
+		if err = UpdateHookTask(t); err != nil {
+			log.Error(4, "UpdateHookTask [%d]: %v", t.ID, err)
+		}
+		return
 	}
 
-	// Record delivery information.
+	t.Attempts++
+	t.IsDelivered = true
 	t.RequestInfo = &HookRequest{
 		Headers: map[string]string{},
 	}
-	for k, vals := range req.Headers() {
-		t.RequestInfo.Headers[k] = strings.Join(vals, ",")
-	}
-
 	t.ResponseInfo = &HookResponse{
 		Headers: map[string]string{},
 	}
 
 	defer func() {
 		t.Delivered = time.Now().UnixNano()
+		retry := !t.IsSucceed && t.Attempts < setting.Webhook.MaxAttempts
 		if t.IsSucceed {
 			log.Trace("Hook delivered: %s", t.UUID)
+		} else if retry {
+			// Keep the task undelivered until its next attempt.
+			delay := t.retryDelay()
+			t.IsDelivered = false
+			t.NextAttemptUnix = util.TimeStamp(time.Now().Add(delay).Unix())
+			log.Trace("Hook delivery failed: %s, attempt %d/%d, retrying in %v",
+				t.UUID, t.Attempts, setting.Webhook.MaxAttempts, delay)
+			scheduleHookTask(t.RepoID, delay)
 		} else {
 			log.Trace("Hook delivery failed: %s", t.UUID)
 		}
@@ -677,24 +911,74 @@ func (t *HookTask) deliver() {
 		if err := UpdateHookTask(t); err != nil {
 			log.Error(4, "UpdateHookTask [%d]: %v", t.ID, err)
 		}
-
-		// Update webhook last delivery status.
-		w, err := GetWebhookByID(t.HookID)
-		if err != nil {
-			log.Error(5, "GetWebhookByID: %v", err)
+		if retry || w == nil {
 			return
 		}
-		if t.IsSucceed {
-			w.LastStatus = HookStatusSucceed
-		} else {
-			w.LastStatus = HookStatusFail
-		}
-		if err = UpdateWebhookLastStatus(w); err != nil {
-			log.Error(5, "UpdateWebhookLastStatus: %v", err)
+
+		// Update webhook last delivery status.
+		if err := updateWebhookDeliveryStatus(w, t.IsSucceed); err != nil {
+			log.Error(5, "updateWebhookDeliveryStatus: %v", err)
 			return
 		}
 	}()
 
+	if err != nil {
+		log.Error(5, "GetWebhookByID: %v", err)
+		t.ResponseInfo.Body = fmt.Sprintf("GetWebhookByID: %v", err)
+		return
+	}
+
+	var body string
+	switch t.ContentType {
+	case ContentTypeJSON:
+		body = t.PayloadContent
+	case ContentTypeForm:
+		body = "payload=" + url.QueryEscape(t.PayloadContent)
+	}
+
+	// The bot token is kept out of the URL of telegram hooks, which is shown to the users.
+	reqURL := t.URL
+	if t.Type == TELEGRAM {
+		reqURL = telegramDeliveryURL(w.GetTelegramHook())
+	}
+
+	timeout := time.Duration(setting.Webhook.DeliverTimeout) * time.Second
+	req := httplib.Post(reqURL).SetTimeout(timeout, timeout).
+		Header("X-Gitea-Delivery", t.UUID).
+		Header("X-Gitea-Event", string(t.EventType)).
+		Header("X-Gogs-Delivery", t.UUID).
+		Header("X-Gogs-Event", string(t.EventType)).
+		HeaderWithSensitiveCase("X-GitHub-Delivery", t.UUID).
+		HeaderWithSensitiveCase("X-GitHub-Event", string(t.EventType)).
+		SetTLSClientConfig(&tls.Config{InsecureSkipVerify: setting.Webhook.SkipTLSVerify})
+
+	switch t.ContentType {
+	case ContentTypeJSON:
+		req = req.Header("Content-Type", "application/json")
+	case ContentTypeForm:
+		req = req.Header("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req = req.Body(body)
+
+	// Sign the body with the secret so that the receiver can authenticate it.
+	if len(w.Secret) > 0 {
+		signature := api.WebhookSignature(w.Secret, []byte(body))
+		req = req.Header(api.SignatureHeader, signature).
+			Header(api.SignatureHeaderSHA256, "sha256="+signature)
+	}
+
+	if t.Type == MATRIX {
+		req = req.Header("Authorization", "Bearer "+w.GetMatrixHook().AccessToken)
+	}
+
+	// Record delivery information.
+	for k, vals := range req.Headers() {
+		t.RequestInfo.Headers[k] = strings.Join(vals, ",")
+	}
+	if _, ok := t.RequestInfo.Headers["Authorization"]; ok {
+		t.RequestInfo.Headers["Authorization"] = "******"
+	}
+
 	resp, err := req.Response()
 	if err != nil {
 		t.ResponseInfo.Body = fmt.Sprintf("Delivery: %v", err)
@@ -718,7 +1002,6 @@ func (t *HookTask) deliver() {
 }
 
 // DeliverHooks checks and delivers undelivered hooks.
-// TODO: shoot more hooks at same time.
 func DeliverHooks() {
 	tasks := make([]*HookTask, 0, 10)
 	err := x.Where("is_delivered=?", false).Find(&tasks)
@@ -727,34 +1010,53 @@ func DeliverHooks() {
 		return
 	}
 
-	// Update hook task status.
+	// Update hook task status, scheduling the retries which are not due yet.
+	now := time.Now()
 	for _, t := range tasks {
+		if next := t.NextAttemptUnix.AsTime(); next.After(now) {
+			scheduleHookTask(t.RepoID, next.Sub(now))
+			continue
+		}
 		t.deliver()
 	}
 
 	// Start listening on new hook requests.
-	for repoIDStr := range HookQueue.Queue() {
-		log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
-		HookQueue.Remove(repoIDStr)
+	HookQueue.Run(deliverRepoHooks)
+}
 
-		repoID, err := com.StrTo(repoIDStr).Int64()
-		if err != nil {
-			log.Error(4, "Invalid repo ID: %s", repoIDStr)
-			continue
-		}
+// deliverRepoHooks delivers the undelivered hooks of the given repository.
+func deliverRepoHooks(repoIDStr string) {
+	log.Trace("DeliverHooks [repo_id: %v]", repoIDStr)
 
-		tasks = make([]*HookTask, 0, 5)
-		if err := x.Where("repo_id=? AND is_delivered=?", repoID, false).Find(&tasks); err != nil {
-			log.Error(4, "Get repository [%s] hook tasks: %v", repoID, err)
-			continue
-		}
-		for _, t := range tasks {
-			t.deliver()
-		}
+	repoID, err := com.StrTo(repoIDStr).Int64()
+	if err != nil {
+		log.Error(4, "Invalid repo ID: %s", repoIDStr)
+		return
+	}
+
+	tasks := make([]*HookTask, 0, 5)
+	if err := x.Where("repo_id=? AND is_delivered=? AND next_attempt_unix<=?", repoID, false, util.TimeStampNow()).
+		Find(&tasks); err != nil {
+		log.Error(4, "Get repository [%d] hook tasks: %v", repoID, err)
+		return
+	}
+	for _, t := range tasks {
+		t.deliver()
 	}
 }
 
-// InitDeliverHooks starts the hooks delivery thread
+// scheduleHookTask adds the repository to the hook queue after the delay, to
+// retry the deliveries of its hook tasks which failed
+func scheduleHookTask(repoID int64, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		HookQueue.Add(repoID)
+	})
+}
+
+// InitDeliverHooks initializes the hook queue and starts the hooks delivery thread
 func InitDeliverHooks() {
+	if err := HookQueue.Configure(setting.GetQueueSettings("webhook", setting.Webhook.QueueLength)); err != nil {
+		log.Fatal(4, "Failed to create hook queue: %v", err)
+	}
 	go DeliverHooks()
 }
@@ -0,0 +1,256 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/git"
+	api "code.gitea.io/sdk/gitea"
+)
+
+// Message types of the matrix messages
+const (
+	MatrixMessageTypeNotice = "m.notice"
+	MatrixMessageTypeText   = "m.text"
+)
+
+type (
+	// MatrixPayload represents a m.room.message event with a formatted body
+	MatrixPayload struct {
+		Body          string `json:"body"`
+		MsgType       string `json:"msgtype"`
+		Format        string `json:"format"`
+		FormattedBody string `json:"formatted_body"`
+	}
+
+	// MatrixMeta contains the matrix metadata
+	MatrixMeta struct {
+		HomeserverURL string `json:"homeserver_url"`
+		Room          string `json:"room_id"`
+		AccessToken   string `json:"access_token"`
+		MessageType   string `json:"message_type"`
+	}
+)
+
+// MatrixHookURL returns the URL of the client API of the homeserver sending
+// messages to the room of the meta
+func MatrixHookURL(meta *MatrixMeta) string {
+	return fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/send/m.room.message",
+		strings.TrimRight(meta.HomeserverURL, "/"), url.PathEscape(meta.Room))
+}
+
+// IsValidMatrixMessageType returns true if the messages of a matrix webhook
+// can be sent with the given type
+func IsValidMatrixMessageType(msgType string) bool {
+	return msgType == MatrixMessageTypeNotice || msgType == MatrixMessageTypeText
+}
+
+// SetSecret sets the matrix secret
+func (p *MatrixPayload) SetSecret(_ string) {}
+
+// JSONPayload Marshals the MatrixPayload to json
+func (p *MatrixPayload) JSONPayload() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// newMatrixPayload returns a message made of the title linking to url and the
+// text, with a plain body and an HTML formatted body
+func newMatrixPayload(meta *MatrixMeta, title, url, text string) *MatrixPayload {
+	body := title
+	formatted := html.EscapeString(title)
+	if url != "" {
+		body = fmt.Sprintf("%s (%s)", title, url)
+		formatted = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), formatted)
+	}
+	if text != "" {
+		body += "\n\n" + text
+		formatted += "<br><br>" + strings.Replace(html.EscapeString(text), "\n", "<br>", -1)
+	}
+
+	msgType := meta.MessageType
+	if !IsValidMatrixMessageType(msgType) {
+		msgType = MatrixMessageTypeNotice
+	}
+	return &MatrixPayload{
+		Body:          body,
+		MsgType:       msgType,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted,
+	}
+}
+
+func getMatrixCreatePayload(p *api.CreatePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	// created tag/branch
+	refName := git.RefEndName(p.Ref)
+	title := fmt.Sprintf("[%s] %s %s created", p.Repo.FullName, p.RefType, refName)
+
+	return newMatrixPayload(meta, title, p.Repo.HTMLURL+"/src/"+refName, ""), nil
+}
+
+func getMatrixDeletePayload(p *api.DeletePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	// deleted tag/branch
+	refName := git.RefEndName(p.Ref)
+	title := fmt.Sprintf("[%s] %s %s deleted", p.Repo.FullName, p.RefType, refName)
+
+	return newMatrixPayload(meta, title, p.Repo.HTMLURL+"/src/"+refName, ""), nil
+}
+
+func getMatrixForkPayload(p *api.ForkPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	// fork
+	title := fmt.Sprintf("%s is forked to %s", p.Forkee.FullName, p.Repo.FullName)
+
+	return newMatrixPayload(meta, title, p.Repo.HTMLURL, ""), nil
+}
+
+func getMatrixPushPayload(p *api.PushPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	var (
+		branchName = git.RefEndName(p.Ref)
+		commitDesc string
+	)
+
+	var titleLink string
+	if len(p.Commits) == 1 {
+		commitDesc = "1 new commit"
+		titleLink = p.Commits[0].URL
+	} else {
+		commitDesc = fmt.Sprintf("%d new commits", len(p.Commits))
+		titleLink = p.CompareURL
+	}
+	if titleLink == "" {
+		titleLink = p.Repo.HTMLURL + "/src/" + branchName
+	}
+
+	title := fmt.Sprintf("[%s:%s] %s", p.Repo.FullName, branchName, commitDesc)
+
+	// the commits are links, so they are appended to both bodies here
+	payload := newMatrixPayload(meta, title, titleLink, "")
+	for _, commit := range p.Commits {
+		message := strings.TrimRight(commit.Message, "\r\n")
+		var authorName string
+		if commit.Author != nil {
+			authorName = " - " + commit.Author.Name
+		}
+		payload.Body += fmt.Sprintf("\n%s %s%s", commit.ID[:7], message, authorName)
+		payload.FormattedBody += fmt.Sprintf(`<br><a href="%s">%s</a> %s`, html.EscapeString(commit.URL), commit.ID[:7],
+			html.EscapeString(message+authorName))
+	}
+	return payload, nil
+}
+
+func getMatrixIssuesPayload(p *api.IssuePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	title := fmt.Sprintf("[%s] Issue %s: #%d %s", p.Repository.FullName,
+		getIssueActionDesc(p.Action, getIssueAssignees(p.Issue.Assignee, p.Issue.Assignees)), p.Index, p.Issue.Title)
+	url := fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Index)
+
+	return newMatrixPayload(meta, title, url, p.Issue.Body), nil
+}
+
+func getMatrixIssueCommentPayload(p *api.IssueCommentPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	title := fmt.Sprintf("#%d %s", p.Issue.Index, p.Issue.Title)
+	url := fmt.Sprintf("%s/issues/%d#%s", p.Repository.HTMLURL, p.Issue.Index, CommentHashTag(p.Comment.ID))
+	switch p.Action {
+	case api.HookIssueCommentCreated:
+		title = "New comment: " + title
+	case api.HookIssueCommentEdited:
+		title = "Comment edited: " + title
+	case api.HookIssueCommentDeleted:
+		title = "Comment deleted: " + title
+		url = fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Issue.Index)
+	}
+	title = fmt.Sprintf("[%s] %s", p.Repository.FullName, title)
+
+	return newMatrixPayload(meta, title, url, p.Comment.Body), nil
+}
+
+func getMatrixPullRequestPayload(p *api.PullRequestPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	action := getIssueActionDesc(p.Action, getIssueAssignees(p.PullRequest.Assignee, p.PullRequest.Assignees))
+	if p.Action == api.HookIssueClosed && p.PullRequest.HasMerged {
+		action = "merged"
+	}
+	title := fmt.Sprintf("[%s] Pull request %s: #%d %s", p.Repository.FullName, action, p.Index, p.PullRequest.Title)
+
+	return newMatrixPayload(meta, title, p.PullRequest.HTMLURL, p.PullRequest.Body), nil
+}
+
+func getMatrixPullRequestApprovalPayload(p *api.PullRequestPayload, meta *MatrixMeta, event HookEventType) (*MatrixPayload, error) {
+	action, err := parseHookPullRequestEventType(event)
+	if err != nil {
+		return nil, err
+	}
+	title := fmt.Sprintf("[%s] Pull request review %s: #%d %s", p.Repository.FullName, action, p.Index, p.PullRequest.Title)
+
+	return newMatrixPayload(meta, title, p.PullRequest.HTMLURL, p.PullRequest.Body), nil
+}
+
+func getMatrixRepositoryPayload(p *api.RepositoryPayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	switch p.Action {
+	case api.HookRepoCreated:
+		title := fmt.Sprintf("[%s] Repository created", p.Repository.FullName)
+		return newMatrixPayload(meta, title, p.Repository.HTMLURL, ""), nil
+	case api.HookRepoDeleted:
+		title := fmt.Sprintf("[%s] Repository deleted", p.Repository.FullName)
+		return newMatrixPayload(meta, title, "", ""), nil
+	}
+	return nil, nil
+}
+
+func getMatrixReleasePayload(p *api.ReleasePayload, meta *MatrixMeta) (*MatrixPayload, error) {
+	var title string
+	switch p.Action {
+	case api.HookReleasePublished:
+		title = fmt.Sprintf("[%s] Release created", p.Release.TagName)
+	case api.HookReleaseUpdated:
+		title = fmt.Sprintf("[%s] Release updated", p.Release.TagName)
+	case api.HookReleaseDeleted:
+		title = fmt.Sprintf("[%s] Release deleted", p.Release.TagName)
+	}
+
+	return newMatrixPayload(meta, title, p.Repository.HTMLURL+"/src/"+p.Release.TagName, p.Release.Note), nil
+}
+
+// GetMatrixPayload converts a matrix webhook into a MatrixPayload
+func GetMatrixPayload(p api.Payloader, event HookEventType, meta string) (*MatrixPayload, error) {
+	s := new(MatrixPayload)
+
+	matrix := &MatrixMeta{}
+	if err := json.Unmarshal([]byte(meta), &matrix); err != nil {
+		return s, errors.New("GetMatrixPayload meta json:" + err.Error())
+	}
+
+	switch event {
+	case HookEventCreate:
+		return getMatrixCreatePayload(p.(*api.CreatePayload), matrix)
+	case HookEventDelete:
+		return getMatrixDeletePayload(p.(*api.DeletePayload), matrix)
+	case HookEventFork:
+		return getMatrixForkPayload(p.(*api.ForkPayload), matrix)
+	case HookEventIssues:
+		return getMatrixIssuesPayload(p.(*api.IssuePayload), matrix)
+	case HookEventIssueComment:
+		return getMatrixIssueCommentPayload(p.(*api.IssueCommentPayload), matrix)
+	case HookEventPush:
+		return getMatrixPushPayload(p.(*api.PushPayload), matrix)
+	case HookEventPullRequest:
+		return getMatrixPullRequestPayload(p.(*api.PullRequestPayload), matrix)
+	case HookEventPullRequestApproved, HookEventPullRequestRejected, HookEventPullRequestComment:
+		return getMatrixPullRequestApprovalPayload(p.(*api.PullRequestPayload), matrix, event)
+	case HookEventRepository:
+		return getMatrixRepositoryPayload(p.(*api.RepositoryPayload), matrix)
+	case HookEventRelease:
+		return getMatrixReleasePayload(p.(*api.ReleasePayload), matrix)
+	}
+
+	return s, nil
+}
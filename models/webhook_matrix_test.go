@@ -0,0 +1,56 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrixHookURL(t *testing.T) {
+	assert.Equal(t, "https://matrix.org/_matrix/client/r0/rooms/%21room:matrix.org/send/m.room.message",
+		MatrixHookURL(&MatrixMeta{HomeserverURL: "https://matrix.org/", Room: "!room:matrix.org"}))
+}
+
+func TestGetMatrixPayload(t *testing.T) {
+	meta := `{"homeserver_url":"https://matrix.org","room_id":"!room:matrix.org","access_token":"token","message_type":"m.text"}`
+	for event, p := range testHookPayloads() {
+		payload, err := GetMatrixPayload(p, event, meta)
+		assert.NoError(t, err, event)
+		if assert.NotNil(t, payload, event) {
+			assert.Equal(t, MatrixMessageTypeText, payload.MsgType, event)
+			assert.Equal(t, "org.matrix.custom.html", payload.Format, event)
+			assert.Contains(t, payload.Body, "test/repo", event)
+			assert.Contains(t, payload.FormattedBody, "test/repo", event)
+		}
+	}
+
+	payload, err := GetMatrixPayload(testHookPayloads()[HookEventIssues], HookEventIssues, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, "[test/repo] Issue opened: #2 crash <on> start (http://localhost:3000/test/repo/issues/2)\n\nit crashes & burns", payload.Body)
+	assert.Equal(t, `<a href="http://localhost:3000/test/repo/issues/2">[test/repo] Issue opened: #2 crash &lt;on&gt; start</a>`+
+		"<br><br>it crashes &amp; burns", payload.FormattedBody)
+
+	_, err = GetMatrixPayload(testHookPayloads()[HookEventIssues], HookEventIssues, "invalid")
+	assert.Error(t, err)
+}
+
+func TestMatrixHook_deliver(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	request, body := testDeliverServiceHook(t, MATRIX, `{"homeserver_url":"https://matrix.org","room_id":"!room:matrix.org","access_token":"s3cr3t"}`)
+	assert.Equal(t, "Bearer s3cr3t", request.Header.Get("Authorization"))
+	payload := &MatrixPayload{}
+	assert.NoError(t, json.Unmarshal(body, payload))
+	assert.Equal(t, MatrixMessageTypeNotice, payload.MsgType)
+	assert.Contains(t, payload.Body, "[test/repo:master] 2 new commits")
+	assert.Contains(t, payload.FormattedBody, `<br><a href="http://localhost:3000/test/repo/commit/2020558">2020558</a> fix &lt;b&gt;bug&lt;/b&gt; - User One`)
+
+	// the access token is not stored in the history of the deliveries
+	task := AssertExistsAndLoadBean(t, &HookTask{Type: MATRIX}).(*HookTask)
+	assert.Equal(t, "******", task.RequestInfo.Headers["Authorization"])
+}
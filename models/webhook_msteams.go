@@ -0,0 +1,302 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"code.gitea.io/git"
+	api "code.gitea.io/sdk/gitea"
+)
+
+type (
+	// MSTeamsFact for Fact Structure
+	MSTeamsFact struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	// MSTeamsSection is a MessageCard section
+	MSTeamsSection struct {
+		ActivityTitle    string        `json:"activityTitle"`
+		ActivitySubtitle string        `json:"activitySubtitle"`
+		ActivityImage    string        `json:"activityImage"`
+		Facts            []MSTeamsFact `json:"facts"`
+		Text             string        `json:"text"`
+	}
+
+	// MSTeamsActionTarget is the actionTarget
+	MSTeamsActionTarget struct {
+		Os  string `json:"os"`
+		URI string `json:"uri"`
+	}
+
+	// MSTeamsAction is an action (creates buttons, links etc)
+	MSTeamsAction struct {
+		Type    string                `json:"@type"`
+		Name    string                `json:"name"`
+		Targets []MSTeamsActionTarget `json:"targets,omitempty"`
+	}
+
+	// MSTeamsPayload is the parent object of a Microsoft Teams MessageCard
+	MSTeamsPayload struct {
+		Type            string           `json:"@type"`
+		Context         string           `json:"@context"`
+		ThemeColor      string           `json:"themeColor"`
+		Title           string           `json:"title"`
+		Summary         string           `json:"summary"`
+		Sections        []MSTeamsSection `json:"sections"`
+		PotentialAction []MSTeamsAction  `json:"potentialAction"`
+	}
+)
+
+// SetSecret sets the msteams secret
+func (p *MSTeamsPayload) SetSecret(_ string) {}
+
+// JSONPayload Marshals the MSTeamsPayload to json
+func (p *MSTeamsPayload) JSONPayload() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// newMSTeamsPayload returns a MessageCard about an event of the repository,
+// sent by the sender and linking to url
+func newMSTeamsPayload(repo *api.Repository, sender *api.User, title, text, url string, color int, facts ...MSTeamsFact) *MSTeamsPayload {
+	facts = append([]MSTeamsFact{{Name: "Repository:", Value: repo.FullName}}, facts...)
+	return &MSTeamsPayload{
+		Type:       "MessageCard",
+		Context:    "https://schema.org/extensions",
+		ThemeColor: fmt.Sprintf("%x", color),
+		Title:      title,
+		Summary:    title,
+		Sections: []MSTeamsSection{
+			{
+				ActivityTitle:    sender.FullName,
+				ActivitySubtitle: sender.UserName,
+				ActivityImage:    sender.AvatarURL,
+				Text:             text,
+				Facts:            facts,
+			},
+		},
+		PotentialAction: []MSTeamsAction{
+			{
+				Type: "OpenUri",
+				Name: "View in Gitea",
+				Targets: []MSTeamsActionTarget{
+					{
+						Os:  "default",
+						URI: url,
+					},
+				},
+			},
+		},
+	}
+}
+
+func getMSTeamsCreatePayload(p *api.CreatePayload) (*MSTeamsPayload, error) {
+	// created tag/branch
+	refName := git.RefEndName(p.Ref)
+	title := fmt.Sprintf("[%s] %s %s created", p.Repo.FullName, p.RefType, refName)
+
+	return newMSTeamsPayload(p.Repo, p.Sender, title, "", p.Repo.HTMLURL+"/src/"+refName, successColor,
+		MSTeamsFact{Name: fmt.Sprintf("%s:", p.RefType), Value: refName}), nil
+}
+
+func getMSTeamsDeletePayload(p *api.DeletePayload) (*MSTeamsPayload, error) {
+	// deleted tag/branch
+	refName := git.RefEndName(p.Ref)
+	title := fmt.Sprintf("[%s] %s %s deleted", p.Repo.FullName, p.RefType, refName)
+
+	return newMSTeamsPayload(p.Repo, p.Sender, title, "", p.Repo.HTMLURL+"/src/"+refName, warnColor,
+		MSTeamsFact{Name: fmt.Sprintf("%s:", p.RefType), Value: refName}), nil
+}
+
+func getMSTeamsForkPayload(p *api.ForkPayload) (*MSTeamsPayload, error) {
+	// fork
+	title := fmt.Sprintf("%s is forked to %s", p.Forkee.FullName, p.Repo.FullName)
+
+	return newMSTeamsPayload(p.Repo, p.Sender, title, "", p.Repo.HTMLURL, successColor,
+		MSTeamsFact{Name: "Forkee:", Value: p.Forkee.FullName}), nil
+}
+
+func getMSTeamsPushPayload(p *api.PushPayload) (*MSTeamsPayload, error) {
+	var (
+		branchName = git.RefEndName(p.Ref)
+		commitDesc string
+	)
+
+	var titleLink string
+	if len(p.Commits) == 1 {
+		commitDesc = "1 new commit"
+		titleLink = p.Commits[0].URL
+	} else {
+		commitDesc = fmt.Sprintf("%d new commits", len(p.Commits))
+		titleLink = p.CompareURL
+	}
+	if titleLink == "" {
+		titleLink = p.Repo.HTMLURL + "/src/" + branchName
+	}
+
+	title := fmt.Sprintf("[%s:%s] %s", p.Repo.FullName, branchName, commitDesc)
+
+	var text string
+	// for each commit, generate a line of text
+	for i, commit := range p.Commits {
+		text += fmt.Sprintf("[%s](%s) %s", commit.ID[:7], commit.URL,
+			strings.TrimRight(commit.Message, "\r\n"))
+		if commit.Author != nil {
+			text += " - " + commit.Author.Name
+		}
+		// add linebreak to each commit but the last
+		if i < len(p.Commits)-1 {
+			text += "\n\n"
+		}
+	}
+
+	return newMSTeamsPayload(p.Repo, p.Sender, title, text, titleLink, successColor,
+		MSTeamsFact{Name: "Commit count:", Value: fmt.Sprintf("%d", len(p.Commits))}), nil
+}
+
+func getMSTeamsIssuesPayload(p *api.IssuePayload) (*MSTeamsPayload, error) {
+	title := fmt.Sprintf("[%s] Issue %s: #%d %s", p.Repository.FullName,
+		getIssueActionDesc(p.Action, getIssueAssignees(p.Issue.Assignee, p.Issue.Assignees)), p.Index, p.Issue.Title)
+	url := fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Index)
+
+	color := warnColor
+	switch p.Action {
+	case api.HookIssueClosed:
+		color = failedColor
+	case api.HookIssueAssigned:
+		color = successColor
+	}
+
+	return newMSTeamsPayload(p.Repository, p.Sender, title, p.Issue.Body, url, color,
+		MSTeamsFact{Name: "Issue #:", Value: fmt.Sprintf("%d", p.Index)}), nil
+}
+
+func getMSTeamsIssueCommentPayload(p *api.IssueCommentPayload) (*MSTeamsPayload, error) {
+	title := fmt.Sprintf("#%d %s", p.Issue.Index, p.Issue.Title)
+	url := fmt.Sprintf("%s/issues/%d#%s", p.Repository.HTMLURL, p.Issue.Index, CommentHashTag(p.Comment.ID))
+	color := warnColor
+	switch p.Action {
+	case api.HookIssueCommentCreated:
+		title = "New comment: " + title
+		color = successColor
+	case api.HookIssueCommentEdited:
+		title = "Comment edited: " + title
+	case api.HookIssueCommentDeleted:
+		title = "Comment deleted: " + title
+		url = fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Issue.Index)
+	}
+
+	return newMSTeamsPayload(p.Repository, p.Sender, title, p.Comment.Body, url, color,
+		MSTeamsFact{Name: "Issue #:", Value: fmt.Sprintf("%d", p.Issue.Index)}), nil
+}
+
+func getMSTeamsPullRequestPayload(p *api.PullRequestPayload) (*MSTeamsPayload, error) {
+	action := getIssueActionDesc(p.Action, getIssueAssignees(p.PullRequest.Assignee, p.PullRequest.Assignees))
+	color := warnColor
+	switch p.Action {
+	case api.HookIssueClosed:
+		if p.PullRequest.HasMerged {
+			action = "merged"
+			color = successColor
+		} else {
+			color = failedColor
+		}
+	case api.HookIssueAssigned:
+		color = successColor
+	}
+	title := fmt.Sprintf("[%s] Pull request %s: #%d %s", p.Repository.FullName, action, p.Index, p.PullRequest.Title)
+
+	return newMSTeamsPayload(p.Repository, p.Sender, title, p.PullRequest.Body, p.PullRequest.HTMLURL, color,
+		MSTeamsFact{Name: "Pull request #:", Value: fmt.Sprintf("%d", p.Index)}), nil
+}
+
+func getMSTeamsPullRequestApprovalPayload(p *api.PullRequestPayload, event HookEventType) (*MSTeamsPayload, error) {
+	action, err := parseHookPullRequestEventType(event)
+	if err != nil {
+		return nil, err
+	}
+	title := fmt.Sprintf("[%s] Pull request review %s: #%d %s", p.Repository.FullName, action, p.Index, p.PullRequest.Title)
+
+	color := warnColor
+	switch event {
+	case HookEventPullRequestApproved:
+		color = successColor
+	case HookEventPullRequestRejected:
+		color = failedColor
+	}
+
+	return newMSTeamsPayload(p.Repository, p.Sender, title, p.PullRequest.Body, p.PullRequest.HTMLURL, color,
+		MSTeamsFact{Name: "Pull request #:", Value: fmt.Sprintf("%d", p.Index)}), nil
+}
+
+func getMSTeamsRepositoryPayload(p *api.RepositoryPayload) (*MSTeamsPayload, error) {
+	var title, url string
+	var color int
+	switch p.Action {
+	case api.HookRepoCreated:
+		title = fmt.Sprintf("[%s] Repository created", p.Repository.FullName)
+		url = p.Repository.HTMLURL
+		color = successColor
+	case api.HookRepoDeleted:
+		title = fmt.Sprintf("[%s] Repository deleted", p.Repository.FullName)
+		color = warnColor
+	}
+
+	return newMSTeamsPayload(p.Repository, p.Sender, title, "", url, color), nil
+}
+
+func getMSTeamsReleasePayload(p *api.ReleasePayload) (*MSTeamsPayload, error) {
+	var title string
+	switch p.Action {
+	case api.HookReleasePublished:
+		title = fmt.Sprintf("[%s] Release created", p.Release.TagName)
+	case api.HookReleaseUpdated:
+		title = fmt.Sprintf("[%s] Release updated", p.Release.TagName)
+	case api.HookReleaseDeleted:
+		title = fmt.Sprintf("[%s] Release deleted", p.Release.TagName)
+	}
+	url := p.Repository.HTMLURL + "/src/" + p.Release.TagName
+
+	return newMSTeamsPayload(p.Repository, p.Sender, title, p.Release.Note, url, successColor,
+		MSTeamsFact{Name: "Tag:", Value: p.Release.TagName}), nil
+}
+
+// GetMSTeamsPayload converts a MSTeams webhook into a MSTeamsPayload
+func GetMSTeamsPayload(p api.Payloader, event HookEventType, meta string) (*MSTeamsPayload, error) {
+	s := new(MSTeamsPayload)
+
+	switch event {
+	case HookEventCreate:
+		return getMSTeamsCreatePayload(p.(*api.CreatePayload))
+	case HookEventDelete:
+		return getMSTeamsDeletePayload(p.(*api.DeletePayload))
+	case HookEventFork:
+		return getMSTeamsForkPayload(p.(*api.ForkPayload))
+	case HookEventIssues:
+		return getMSTeamsIssuesPayload(p.(*api.IssuePayload))
+	case HookEventIssueComment:
+		return getMSTeamsIssueCommentPayload(p.(*api.IssueCommentPayload))
+	case HookEventPush:
+		return getMSTeamsPushPayload(p.(*api.PushPayload))
+	case HookEventPullRequest:
+		return getMSTeamsPullRequestPayload(p.(*api.PullRequestPayload))
+	case HookEventPullRequestApproved, HookEventPullRequestRejected, HookEventPullRequestComment:
+		return getMSTeamsPullRequestApprovalPayload(p.(*api.PullRequestPayload), event)
+	case HookEventRepository:
+		return getMSTeamsRepositoryPayload(p.(*api.RepositoryPayload))
+	case HookEventRelease:
+		return getMSTeamsReleasePayload(p.(*api.ReleasePayload))
+	}
+
+	return s, nil
+}
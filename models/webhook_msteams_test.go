@@ -0,0 +1,43 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMSTeamsPayload(t *testing.T) {
+	for event, p := range testHookPayloads() {
+		payload, err := GetMSTeamsPayload(p, event, "")
+		assert.NoError(t, err, event)
+		if assert.NotNil(t, payload, event) {
+			assert.Equal(t, "MessageCard", payload.Type, event)
+			assert.NotEmpty(t, payload.Title, event)
+			assert.Len(t, payload.Sections, 1, event)
+			assert.Equal(t, "test/repo", payload.Sections[0].Facts[0].Value, event)
+			assert.Len(t, payload.PotentialAction, 1, event)
+		}
+	}
+
+	payload, err := GetMSTeamsPayload(testHookPayloads()[HookEventIssues], HookEventIssues, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "[test/repo] Issue opened: #2 crash <on> start", payload.Title)
+	assert.Equal(t, "it crashes & burns", payload.Sections[0].Text)
+	assert.Equal(t, "http://localhost:3000/test/repo/issues/2", payload.PotentialAction[0].Targets[0].URI)
+}
+
+func TestMSTeamsHook_deliver(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	_, body := testDeliverServiceHook(t, MSTEAMS, "")
+	payload := &MSTeamsPayload{}
+	assert.NoError(t, json.Unmarshal(body, payload))
+	assert.Equal(t, "MessageCard", payload.Type)
+	assert.Equal(t, "[test/repo:master] 2 new commits", payload.Title)
+	assert.Contains(t, payload.Sections[0].Text, "[2020558](http://localhost:3000/test/repo/commit/2020558) fix <b>bug</b> - User One")
+}
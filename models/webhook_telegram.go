@@ -0,0 +1,235 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/git"
+	api "code.gitea.io/sdk/gitea"
+)
+
+type (
+	// TelegramPayload represents a message sent with the sendMessage method
+	// of the Telegram Bot API
+	TelegramPayload struct {
+		Message           string `json:"text"`
+		ParseMode         string `json:"parse_mode"`
+		DisableWebPreview bool   `json:"disable_web_page_preview"`
+	}
+
+	// TelegramMeta contains the telegram metadata
+	TelegramMeta struct {
+		BotToken string `json:"bot_token"`
+		ChatID   string `json:"chat_id"`
+	}
+)
+
+// telegramAPIURL the base URL of the Telegram Bot API
+var telegramAPIURL = "https://api.telegram.org"
+
+// TelegramHookURL returns the URL of the sendMessage method of the Telegram
+// Bot API for the chat of the meta, as shown to the users: the bot token is
+// masked, the messages being sent to the URL built by telegramDeliveryURL
+func TelegramHookURL(meta *TelegramMeta) string {
+	return fmt.Sprintf("%s/bot******/sendMessage?chat_id=%s", telegramAPIURL, url.QueryEscape(meta.ChatID))
+}
+
+// telegramDeliveryURL returns the URL of the sendMessage method of the
+// Telegram Bot API for the bot and the chat of the meta
+func telegramDeliveryURL(meta *TelegramMeta) string {
+	return fmt.Sprintf("%s/bot%s/sendMessage?chat_id=%s", telegramAPIURL,
+		url.PathEscape(meta.BotToken), url.QueryEscape(meta.ChatID))
+}
+
+// SetSecret sets the telegram secret
+func (p *TelegramPayload) SetSecret(_ string) {}
+
+// JSONPayload Marshals the TelegramPayload to json
+func (p *TelegramPayload) JSONPayload() ([]byte, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return []byte{}, err
+	}
+	return data, nil
+}
+
+// newTelegramPayload returns an HTML message made of the title linking to url
+// and the text, escaping them
+func newTelegramPayload(title, url, text string) *TelegramPayload {
+	message := html.EscapeString(title)
+	if url != "" {
+		message = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), message)
+	}
+	if text != "" {
+		message += "\n\n" + html.EscapeString(text)
+	}
+	return &TelegramPayload{
+		Message:           message,
+		ParseMode:         "HTML",
+		DisableWebPreview: true,
+	}
+}
+
+func getTelegramCreatePayload(p *api.CreatePayload) (*TelegramPayload, error) {
+	// created tag/branch
+	refName := git.RefEndName(p.Ref)
+	title := fmt.Sprintf("[%s] %s %s created", p.Repo.FullName, p.RefType, refName)
+
+	return newTelegramPayload(title, p.Repo.HTMLURL+"/src/"+refName, ""), nil
+}
+
+func getTelegramDeletePayload(p *api.DeletePayload) (*TelegramPayload, error) {
+	// deleted tag/branch
+	refName := git.RefEndName(p.Ref)
+	title := fmt.Sprintf("[%s] %s %s deleted", p.Repo.FullName, p.RefType, refName)
+
+	return newTelegramPayload(title, p.Repo.HTMLURL+"/src/"+refName, ""), nil
+}
+
+func getTelegramForkPayload(p *api.ForkPayload) (*TelegramPayload, error) {
+	// fork
+	title := fmt.Sprintf("%s is forked to %s", p.Forkee.FullName, p.Repo.FullName)
+
+	return newTelegramPayload(title, p.Repo.HTMLURL, ""), nil
+}
+
+func getTelegramPushPayload(p *api.PushPayload) (*TelegramPayload, error) {
+	var (
+		branchName = git.RefEndName(p.Ref)
+		commitDesc string
+	)
+
+	var titleLink string
+	if len(p.Commits) == 1 {
+		commitDesc = "1 new commit"
+		titleLink = p.Commits[0].URL
+	} else {
+		commitDesc = fmt.Sprintf("%d new commits", len(p.Commits))
+		titleLink = p.CompareURL
+	}
+	if titleLink == "" {
+		titleLink = p.Repo.HTMLURL + "/src/" + branchName
+	}
+
+	title := fmt.Sprintf("[%s:%s] %s", p.Repo.FullName, branchName, commitDesc)
+
+	// the commits are links, so they are formatted here rather than escaped
+	// as a whole by newTelegramPayload
+	payload := newTelegramPayload(title, titleLink, "")
+	for _, commit := range p.Commits {
+		payload.Message += fmt.Sprintf("\n<a href=\"%s\">%s</a> %s", html.EscapeString(commit.URL), commit.ID[:7],
+			html.EscapeString(strings.TrimRight(commit.Message, "\r\n")))
+		if commit.Author != nil {
+			payload.Message += " - " + html.EscapeString(commit.Author.Name)
+		}
+	}
+	return payload, nil
+}
+
+func getTelegramIssuesPayload(p *api.IssuePayload) (*TelegramPayload, error) {
+	title := fmt.Sprintf("[%s] Issue %s: #%d %s", p.Repository.FullName,
+		getIssueActionDesc(p.Action, getIssueAssignees(p.Issue.Assignee, p.Issue.Assignees)), p.Index, p.Issue.Title)
+	url := fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Index)
+
+	return newTelegramPayload(title, url, p.Issue.Body), nil
+}
+
+func getTelegramIssueCommentPayload(p *api.IssueCommentPayload) (*TelegramPayload, error) {
+	title := fmt.Sprintf("#%d %s", p.Issue.Index, p.Issue.Title)
+	url := fmt.Sprintf("%s/issues/%d#%s", p.Repository.HTMLURL, p.Issue.Index, CommentHashTag(p.Comment.ID))
+	switch p.Action {
+	case api.HookIssueCommentCreated:
+		title = "New comment: " + title
+	case api.HookIssueCommentEdited:
+		title = "Comment edited: " + title
+	case api.HookIssueCommentDeleted:
+		title = "Comment deleted: " + title
+		url = fmt.Sprintf("%s/issues/%d", p.Repository.HTMLURL, p.Issue.Index)
+	}
+	title = fmt.Sprintf("[%s] %s", p.Repository.FullName, title)
+
+	return newTelegramPayload(title, url, p.Comment.Body), nil
+}
+
+func getTelegramPullRequestPayload(p *api.PullRequestPayload) (*TelegramPayload, error) {
+	action := getIssueActionDesc(p.Action, getIssueAssignees(p.PullRequest.Assignee, p.PullRequest.Assignees))
+	if p.Action == api.HookIssueClosed && p.PullRequest.HasMerged {
+		action = "merged"
+	}
+	title := fmt.Sprintf("[%s] Pull request %s: #%d %s", p.Repository.FullName, action, p.Index, p.PullRequest.Title)
+
+	return newTelegramPayload(title, p.PullRequest.HTMLURL, p.PullRequest.Body), nil
+}
+
+func getTelegramPullRequestApprovalPayload(p *api.PullRequestPayload, event HookEventType) (*TelegramPayload, error) {
+	action, err := parseHookPullRequestEventType(event)
+	if err != nil {
+		return nil, err
+	}
+	title := fmt.Sprintf("[%s] Pull request review %s: #%d %s", p.Repository.FullName, action, p.Index, p.PullRequest.Title)
+
+	return newTelegramPayload(title, p.PullRequest.HTMLURL, p.PullRequest.Body), nil
+}
+
+func getTelegramRepositoryPayload(p *api.RepositoryPayload) (*TelegramPayload, error) {
+	switch p.Action {
+	case api.HookRepoCreated:
+		title := fmt.Sprintf("[%s] Repository created", p.Repository.FullName)
+		return newTelegramPayload(title, p.Repository.HTMLURL, ""), nil
+	case api.HookRepoDeleted:
+		title := fmt.Sprintf("[%s] Repository deleted", p.Repository.FullName)
+		return newTelegramPayload(title, "", ""), nil
+	}
+	return nil, nil
+}
+
+func getTelegramReleasePayload(p *api.ReleasePayload) (*TelegramPayload, error) {
+	var title string
+	switch p.Action {
+	case api.HookReleasePublished:
+		title = fmt.Sprintf("[%s] Release created", p.Release.TagName)
+	case api.HookReleaseUpdated:
+		title = fmt.Sprintf("[%s] Release updated", p.Release.TagName)
+	case api.HookReleaseDeleted:
+		title = fmt.Sprintf("[%s] Release deleted", p.Release.TagName)
+	}
+
+	return newTelegramPayload(title, p.Repository.HTMLURL+"/src/"+p.Release.TagName, p.Release.Note), nil
+}
+
+// GetTelegramPayload converts a telegram webhook into a TelegramPayload
+func GetTelegramPayload(p api.Payloader, event HookEventType, meta string) (*TelegramPayload, error) {
+	s := new(TelegramPayload)
+
+	switch event {
+	case HookEventCreate:
+		return getTelegramCreatePayload(p.(*api.CreatePayload))
+	case HookEventDelete:
+		return getTelegramDeletePayload(p.(*api.DeletePayload))
+	case HookEventFork:
+		return getTelegramForkPayload(p.(*api.ForkPayload))
+	case HookEventIssues:
+		return getTelegramIssuesPayload(p.(*api.IssuePayload))
+	case HookEventIssueComment:
+		return getTelegramIssueCommentPayload(p.(*api.IssueCommentPayload))
+	case HookEventPush:
+		return getTelegramPushPayload(p.(*api.PushPayload))
+	case HookEventPullRequest:
+		return getTelegramPullRequestPayload(p.(*api.PullRequestPayload))
+	case HookEventPullRequestApproved, HookEventPullRequestRejected, HookEventPullRequestComment:
+		return getTelegramPullRequestApprovalPayload(p.(*api.PullRequestPayload), event)
+	case HookEventRepository:
+		return getTelegramRepositoryPayload(p.(*api.RepositoryPayload))
+	case HookEventRelease:
+		return getTelegramReleasePayload(p.(*api.ReleasePayload))
+	}
+
+	return s, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelegramHookURL(t *testing.T) {
+	meta := &TelegramMeta{BotToken: "123:ABC", ChatID: "-100123"}
+	assert.Equal(t, "https://api.telegram.org/bot******/sendMessage?chat_id=-100123", TelegramHookURL(meta))
+	assert.Equal(t, "https://api.telegram.org/bot123:ABC/sendMessage?chat_id=-100123", telegramDeliveryURL(meta))
+}
+
+func TestGetTelegramPayload(t *testing.T) {
+	for event, p := range testHookPayloads() {
+		payload, err := GetTelegramPayload(p, event, "")
+		assert.NoError(t, err, event)
+		if assert.NotNil(t, payload, event) {
+			assert.Equal(t, "HTML", payload.ParseMode, event)
+			assert.Contains(t, payload.Message, "test/repo", event)
+		}
+	}
+
+	payload, err := GetTelegramPayload(testHookPayloads()[HookEventIssues], HookEventIssues, "")
+	assert.NoError(t, err)
+	assert.Equal(t, `<a href="http://localhost:3000/test/repo/issues/2">[test/repo] Issue opened: #2 crash &lt;on&gt; start</a>`+
+		"\n\nit crashes &amp; burns", payload.Message)
+}
+
+func TestTelegramHook_deliver(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	request, body := testDeliverServiceHook(t, TELEGRAM, `{"bot_token":"123:ABC","chat_id":"-100123"}`)
+	if assert.NotNil(t, request) {
+		assert.Equal(t, "/bot123:ABC/sendMessage", request.URL.Path)
+		assert.Equal(t, "-100123", request.URL.Query().Get("chat_id"))
+	}
+	payload := &TelegramPayload{}
+	assert.NoError(t, json.Unmarshal(body, payload))
+	assert.Equal(t, "HTML", payload.ParseMode)
+	assert.True(t, payload.DisableWebPreview)
+	assert.Contains(t, payload.Message, `<a href="http://localhost:3000/test/repo/compare/abc...def">[test/repo:master] 2 new commits</a>`)
+	assert.Contains(t, payload.Message, `<a href="http://localhost:3000/test/repo/commit/2020558">2020558</a> fix &lt;b&gt;bug&lt;/b&gt; - User One`)
+}
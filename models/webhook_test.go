@@ -6,8 +6,15 @@ package models
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 	api "code.gitea.io/sdk/gitea"
 
 	"github.com/stretchr/testify/assert"
@@ -267,6 +274,289 @@ func TestPrepareWebhooks(t *testing.T) {
 	}
 }
 
-// TODO TestHookTask_deliver
+func TestPrepareWebhook_secret(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	defer func(secretInPayload bool) {
+		setting.Webhook.SecretInPayload = secretInPayload
+	}(setting.Webhook.SecretInPayload)
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	hook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	hook.Secret = "s3cr3t"
+	assert.NoError(t, UpdateWebhook(hook))
+
+	// the secret is only sent in the payload when the deprecated setting is enabled
+	for _, secretInPayload := range []bool{false, true} {
+		setting.Webhook.SecretInPayload = secretInPayload
+		_, err := x.Delete(&HookTask{HookID: hook.ID})
+		assert.NoError(t, err)
+		assert.NoError(t, PrepareWebhook(hook, repo, HookEventPush, &api.PushPayload{}))
+		task := AssertExistsAndLoadBean(t, &HookTask{HookID: hook.ID}).(*HookTask)
+		assert.Equal(t, secretInPayload, strings.Contains(task.PayloadContent, "s3cr3t"))
+	}
+}
+
+func TestHookTask_deliver(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	defer func(maxAttempts int, retryInterval time.Duration) {
+		setting.Webhook.MaxAttempts = maxAttempts
+		setting.Webhook.RetryInterval = retryInterval
+	}(setting.Webhook.MaxAttempts, setting.Webhook.RetryInterval)
+	setting.Webhook.MaxAttempts = 2
+	setting.Webhook.RetryInterval = time.Hour
+
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	hook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	hook.URL = server.URL
+	assert.NoError(t, UpdateWebhook(hook))
+	task := &HookTask{RepoID: 1, HookID: 1, URL: server.URL, ContentType: ContentTypeJSON, Payloader: &api.PushPayload{}}
+	assert.NoError(t, CreateHookTask(task))
+
+	// the first failure is retried later
+	task.deliver()
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.False(t, task.IsDelivered)
+	assert.False(t, task.IsSucceed)
+	assert.EqualValues(t, 1, task.Attempts)
+	assert.True(t, task.NextAttemptUnix > util.TimeStampNow())
+	hook = AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.Zero(t, hook.FailureCount)
+
+	// the last attempt fails the delivery
+	task.deliver()
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsDelivered)
+	assert.False(t, task.IsSucceed)
+	assert.EqualValues(t, 2, task.Attempts)
+	hook = AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.EqualValues(t, HookStatusFail, hook.LastStatus)
+	assert.EqualValues(t, 1, hook.FailureCount)
+
+	// a success resets the failure count
+	status = http.StatusOK
+	task = &HookTask{RepoID: 1, HookID: 1, URL: server.URL, ContentType: ContentTypeJSON, Payloader: &api.PushPayload{}}
+	assert.NoError(t, CreateHookTask(task))
+	task.deliver()
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsDelivered)
+	assert.True(t, task.IsSucceed)
+	assert.EqualValues(t, 1, task.Attempts)
+	hook = AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.EqualValues(t, HookStatusSucceed, hook.LastStatus)
+	assert.Zero(t, hook.FailureCount)
+}
+
+func TestHookTask_deliverSignature(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	var headers http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	hook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	hook.URL = server.URL
+	hook.Secret = "s3cr3t"
+	assert.NoError(t, UpdateWebhook(hook))
+
+	for _, contentType := range []HookContentType{ContentTypeJSON, ContentTypeForm} {
+		task := &HookTask{RepoID: 1, HookID: 1, URL: server.URL, ContentType: contentType, Payloader: &api.PushPayload{}}
+		assert.NoError(t, CreateHookTask(task))
+		task.deliver()
+		assert.True(t, task.IsSucceed)
+
+		signature := headers.Get(api.SignatureHeader)
+		assert.Equal(t, api.WebhookSignature("s3cr3t", body), signature)
+		assert.Equal(t, "sha256="+signature, headers.Get(api.SignatureHeaderSHA256))
+		assert.True(t, api.VerifyWebhookSignature("s3cr3t", headers.Get(api.SignatureHeaderSHA256), body))
+		assert.False(t, api.VerifyWebhookSignature("wrong", signature, body))
+	}
+	assert.Equal(t, "application/x-www-form-urlencoded", headers.Get("Content-Type"))
+	assert.Contains(t, string(body), "payload=")
+
+	// deliveries of hooks without secret are not signed
+	hook.Secret = ""
+	assert.NoError(t, UpdateWebhook(hook))
+	task := &HookTask{RepoID: 1, HookID: 1, URL: server.URL, ContentType: ContentTypeJSON, Payloader: &api.PushPayload{}}
+	assert.NoError(t, CreateHookTask(task))
+	task.deliver()
+	assert.Empty(t, headers.Get(api.SignatureHeader))
+	assert.Empty(t, headers.Get(api.SignatureHeaderSHA256))
+}
+
+func TestHookTask_retryDelay(t *testing.T) {
+	defer func(retryInterval time.Duration) {
+		setting.Webhook.RetryInterval = retryInterval
+	}(setting.Webhook.RetryInterval)
+	setting.Webhook.RetryInterval = time.Minute
+
+	for attempts, expected := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute} {
+		assert.Equal(t, expected, (&HookTask{Attempts: attempts + 1}).retryDelay())
+	}
+
+	// the delay stops doubling once it reaches the maximum
+	for _, attempts := range []int{12, 64, 1000} {
+		delay := (&HookTask{Attempts: attempts}).retryDelay()
+		assert.True(t, delay >= maxHookRetryDelay && delay < 2*maxHookRetryDelay, attempts)
+	}
+}
+
+func TestHookTask_deliverInactive(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	hook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	hook.URL = server.URL
+	hook.IsActive = false
+	assert.NoError(t, UpdateWebhook(hook))
+
+	// the retry of a hook disabled since the first attempt is dropped
+	task := &HookTask{RepoID: 1, HookID: 1, URL: server.URL, ContentType: ContentTypeJSON, Payloader: &api.PushPayload{}}
+	assert.NoError(t, CreateHookTask(task))
+	task.Attempts = 1
+	task.deliver()
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsDelivered)
+	assert.False(t, task.IsSucceed)
+	assert.EqualValues(t, 1, task.Attempts)
+	assert.Zero(t, requests)
+
+	// so is the retry of a deleted hook
+	task = &HookTask{RepoID: 1, HookID: 9999, URL: server.URL, ContentType: ContentTypeJSON, Payloader: &api.PushPayload{}}
+	assert.NoError(t, CreateHookTask(task))
+	task.Attempts = 1
+	task.deliver()
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsDelivered)
+	assert.Zero(t, requests)
+}
+
+func TestUpdateWebhookDeliveryStatus(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	defer func(disableAfterFailures int) {
+		setting.Webhook.DisableAfterFailures = disableAfterFailures
+	}(setting.Webhook.DisableAfterFailures)
+	setting.Webhook.DisableAfterFailures = 2
+
+	hook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.NoError(t, updateWebhookDeliveryStatus(hook, false))
+	AssertExistsAndLoadBean(t, &Webhook{ID: 1, IsActive: true, FailureCount: 1})
+
+	assert.NoError(t, updateWebhookDeliveryStatus(hook, false))
+	hook = AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	assert.False(t, hook.IsActive)
+	assert.EqualValues(t, 2, hook.FailureCount)
+	assert.EqualValues(t, HookStatusFail, hook.LastStatus)
+
+	// enabling the webhook again resets its failure count
+	hook.IsActive = true
+	assert.NoError(t, UpdateWebhook(hook))
+	AssertExistsAndLoadBean(t, &Webhook{ID: 1, IsActive: true, FailureCount: 0})
+}
+
+func TestRedeliverHookTask(t *testing.T) {
+	assert.NoError(t, PrepareTestDatabase())
+	hook := AssertExistsAndLoadBean(t, &Webhook{ID: 1}).(*Webhook)
+	task, err := RedeliverHookTask(hook, 1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "uuid1", task.UUID)
+	assert.Equal(t, hook.URL, task.URL)
+	AssertExistsAndLoadBean(t, &HookTask{ID: task.ID, RepoID: 1, HookID: 1, IsDelivered: false})
+
+	_, err = RedeliverHookTask(hook, NonexistentID)
+	assert.True(t, IsErrHookTaskNotExist(err))
+
+	hook = AssertExistsAndLoadBean(t, &Webhook{ID: 3}).(*Webhook)
+	_, err = RedeliverHookTask(hook, 1)
+	assert.True(t, IsErrHookTaskNotExist(err))
+}
 
 // TODO TestDeliverHooks
+
+// testHookPayloads returns a payload of each event sent to the webhooks,
+// used to test the converters of the hook task types
+func testHookPayloads() map[HookEventType]api.Payloader {
+	repo := &api.Repository{FullName: "test/repo", HTMLURL: "http://localhost:3000/test/repo"}
+	sender := &api.User{UserName: "user1", FullName: "User One", AvatarURL: "http://localhost:3000/avatars/1"}
+	issue := &api.Issue{Index: 2, Title: "crash <on> start", Body: "it crashes & burns"}
+	pull := &api.PullRequest{Index: 3, Title: "Fix crash", Body: "fixes #2", HTMLURL: "http://localhost:3000/test/repo/pulls/3"}
+	return map[HookEventType]api.Payloader{
+		HookEventCreate: &api.CreatePayload{Ref: "refs/heads/feature", RefType: "branch", Repo: repo, Sender: sender},
+		HookEventDelete: &api.DeletePayload{Ref: "refs/heads/feature", RefType: "branch", Repo: repo, Sender: sender},
+		HookEventFork:   &api.ForkPayload{Forkee: &api.Repository{FullName: "user1/repo"}, Repo: repo, Sender: sender},
+		HookEventPush: &api.PushPayload{
+			Ref:        "refs/heads/master",
+			CompareURL: "http://localhost:3000/test/repo/compare/abc...def",
+			Commits: []*api.PayloadCommit{
+				{ID: "2020558fe2e34debb818a514715839cabd25e778", Message: "fix <b>bug</b>\n", URL: "http://localhost:3000/test/repo/commit/2020558",
+					Author: &api.PayloadUser{Name: "User One"}},
+				{ID: "2020558fe2e34debb818a514715839cabd25e779", Message: "add tests", URL: "http://localhost:3000/test/repo/commit/2020559"},
+			},
+			Repo:   repo,
+			Sender: sender,
+		},
+		HookEventIssues: &api.IssuePayload{Action: api.HookIssueOpened, Index: 2, Issue: issue, Repository: repo, Sender: sender},
+		HookEventIssueComment: &api.IssueCommentPayload{Action: api.HookIssueCommentCreated, Issue: issue,
+			Comment: &api.Comment{ID: 4, Body: "same here"}, Repository: repo, Sender: sender},
+		HookEventPullRequest:         &api.PullRequestPayload{Action: api.HookIssueOpened, Index: 3, PullRequest: pull, Repository: repo, Sender: sender},
+		HookEventPullRequestApproved: &api.PullRequestPayload{Action: api.HookIssueSynchronized, Index: 3, PullRequest: pull, Repository: repo, Sender: sender},
+		HookEventPullRequestRejected: &api.PullRequestPayload{Action: api.HookIssueSynchronized, Index: 3, PullRequest: pull, Repository: repo, Sender: sender},
+		HookEventPullRequestComment:  &api.PullRequestPayload{Action: api.HookIssueSynchronized, Index: 3, PullRequest: pull, Repository: repo, Sender: sender},
+		HookEventRepository:          &api.RepositoryPayload{Action: api.HookRepoCreated, Repository: repo, Sender: sender},
+		HookEventRelease: &api.ReleasePayload{Action: api.HookReleasePublished, Release: &api.Release{TagName: "v1.0", Note: "first release"},
+			Repository: repo, Sender: sender},
+	}
+}
+
+// testDeliverServiceHook creates a webhook of the given type delivering to a
+// test server, delivers a push to it and returns the received request and body
+func testDeliverServiceHook(t *testing.T, hookType HookTaskType, meta string) (*http.Request, []byte) {
+	var request *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	defer func(apiURL string) {
+		telegramAPIURL = apiURL
+	}(telegramAPIURL)
+	telegramAPIURL = server.URL
+
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	hook := &Webhook{
+		RepoID:       repo.ID,
+		URL:          server.URL,
+		ContentType:  ContentTypeJSON,
+		HookEvent:    &HookEvent{PushOnly: true},
+		IsActive:     true,
+		HookTaskType: hookType,
+		Meta:         meta,
+	}
+	assert.NoError(t, hook.UpdateEvent())
+	assert.NoError(t, CreateWebhook(hook))
+	assert.NoError(t, PrepareWebhook(hook, repo, HookEventPush, testHookPayloads()[HookEventPush]))
+
+	task := AssertExistsAndLoadBean(t, &HookTask{HookID: hook.ID}).(*HookTask)
+	assert.Equal(t, hookType, task.Type)
+	task.deliver()
+	task = AssertExistsAndLoadBean(t, &HookTask{ID: task.ID}).(*HookTask)
+	assert.True(t, task.IsSucceed)
+	if assert.NotNil(t, request) {
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	}
+	return request, body
+}
@@ -157,6 +157,9 @@ func (repo *Repository) updateWikiPage(doer *User, oldWikiName, newWikiName, con
 		}
 	} else {
 		oldWikiPath := path.Join(localPath, WikiNameToFilename(oldWikiName))
+		if oldWikiPath != newWikiPath && com.IsExist(newWikiPath) {
+			return ErrWikiAlreadyExist{newWikiPath}
+		}
 		if err := os.Remove(oldWikiPath); err != nil {
 			return fmt.Errorf("Failed to remove %s: %v", oldWikiPath, err)
 		}
@@ -209,6 +209,16 @@ func TestRepository_EditWikiPage(t *testing.T) {
 	}
 }
 
+func TestRepository_EditWikiPage_AlreadyExist(t *testing.T) {
+	PrepareTestEnv(t)
+	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
+	doer := AssertExistsAndLoadBean(t, &User{ID: 2}).(*User)
+	assert.NoError(t, repo.AddWikiPage(doer, "Other page", "content", ""))
+	err := repo.EditWikiPage(doer, "Home", "Other page", "new content", "")
+	assert.Error(t, err)
+	assert.True(t, IsErrWikiAlreadyExist(err))
+}
+
 func TestRepository_DeleteWikiPage(t *testing.T) {
 	PrepareTestEnv(t)
 	repo := AssertExistsAndLoadBean(t, &Repository{ID: 1}).(*Repository)
@@ -0,0 +1,170 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package analyze
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+var (
+	// languageFileNames maps the (lower-cased) names of files without a
+	// meaningful extension to their language
+	languageFileNames = map[string]string{
+		"dockerfile":     "Dockerfile",
+		"makefile":       "Makefile",
+		"gnumakefile":    "Makefile",
+		"cmakelists.txt": "CMake",
+		"gemfile":        "Ruby",
+		"rakefile":       "Ruby",
+		"vagrantfile":    "Ruby",
+		"jenkinsfile":    "Groovy",
+		"go.mod":         "Go Module",
+	}
+
+	// languageExtensions maps the (lower-cased) file extensions to their
+	// language, using the names of GitHub's linguist
+	languageExtensions = map[string]string{
+		".as":         "ActionScript",
+		".adb":        "Ada",
+		".ads":        "Ada",
+		".asm":        "Assembly",
+		".s":          "Assembly",
+		".sh":         "Shell",
+		".bash":       "Shell",
+		".zsh":        "Shell",
+		".fish":       "fish",
+		".bat":        "Batchfile",
+		".cmd":        "Batchfile",
+		".c":          "C",
+		".h":          "C",
+		".cs":         "C#",
+		".cpp":        "C++",
+		".cc":         "C++",
+		".cxx":        "C++",
+		".hpp":        "C++",
+		".hh":         "C++",
+		".cmake":      "CMake",
+		".clj":        "Clojure",
+		".cljs":       "Clojure",
+		".coffee":     "CoffeeScript",
+		".lisp":       "Common Lisp",
+		".css":        "CSS",
+		".d":          "D",
+		".dart":       "Dart",
+		".diff":       "Diff",
+		".patch":      "Diff",
+		".ex":         "Elixir",
+		".exs":        "Elixir",
+		".elm":        "Elm",
+		".el":         "Emacs Lisp",
+		".erl":        "Erlang",
+		".hrl":        "Erlang",
+		".fs":         "F#",
+		".fsx":        "F#",
+		".f90":        "Fortran",
+		".f":          "Fortran",
+		".go":         "Go",
+		".gradle":     "Gradle",
+		".graphql":    "GraphQL",
+		".groovy":     "Groovy",
+		".hs":         "Haskell",
+		".html":       "HTML",
+		".htm":        "HTML",
+		".tmpl":       "Go Template",
+		".ini":        "INI",
+		".java":       "Java",
+		".js":         "JavaScript",
+		".mjs":        "JavaScript",
+		".jsx":        "JavaScript",
+		".json":       "JSON",
+		".jl":         "Julia",
+		".kt":         "Kotlin",
+		".kts":        "Kotlin",
+		".less":       "Less",
+		".lua":        "Lua",
+		".md":         "Markdown",
+		".markdown":   "Markdown",
+		".m":          "Objective-C",
+		".mm":         "Objective-C++",
+		".ml":         "OCaml",
+		".mli":        "OCaml",
+		".pas":        "Pascal",
+		".pl":         "Perl",
+		".pm":         "Perl",
+		".php":        "PHP",
+		".ps1":        "PowerShell",
+		".proto":      "Protocol Buffer",
+		".py":         "Python",
+		".r":          "R",
+		".rb":         "Ruby",
+		".rs":         "Rust",
+		".sass":       "Sass",
+		".scala":      "Scala",
+		".scm":        "Scheme",
+		".scss":       "SCSS",
+		".sql":        "SQL",
+		".swift":      "Swift",
+		".tcl":        "Tcl",
+		".tex":        "TeX",
+		".toml":       "TOML",
+		".ts":         "TypeScript",
+		".tsx":        "TypeScript",
+		".txt":        "Text",
+		".vb":         "Visual Basic",
+		".vim":        "Vim script",
+		".vue":        "Vue",
+		".xml":        "XML",
+		".yml":        "YAML",
+		".yaml":       "YAML",
+		".rst":        "reStructuredText",
+		".org":        "Org",
+		".properties": "Java Properties",
+	}
+
+	// codeLanguages is the sorted list of the known languages
+	codeLanguages []string
+	// canonicalCodeLanguages maps the lower-cased languages to their name
+	canonicalCodeLanguages = make(map[string]string)
+)
+
+func init() {
+	for _, languages := range []map[string]string{languageFileNames, languageExtensions} {
+		for _, language := range languages {
+			if _, ok := canonicalCodeLanguages[strings.ToLower(language)]; !ok {
+				canonicalCodeLanguages[strings.ToLower(language)] = language
+				codeLanguages = append(codeLanguages, language)
+			}
+		}
+	}
+	sort.Slice(codeLanguages, func(i, j int) bool {
+		return strings.ToLower(codeLanguages[i]) < strings.ToLower(codeLanguages[j])
+	})
+}
+
+// GetCodeLanguage detects the language of a file from its name, it returns
+// an empty string for unknown languages
+func GetCodeLanguage(filename string) string {
+	base := strings.ToLower(path.Base(filename))
+	if language, ok := languageFileNames[base]; ok {
+		return language
+	}
+	return languageExtensions[path.Ext(base)]
+}
+
+// CodeLanguages returns the sorted list of the languages which can be detected
+func CodeLanguages() []string {
+	return codeLanguages
+}
+
+// CanonicalCodeLanguage returns the name of the language, matched case
+// insensitively, or the given name if the language is unknown
+func CanonicalCodeLanguage(language string) string {
+	if canonical, ok := canonicalCodeLanguages[strings.ToLower(language)]; ok {
+		return canonical
+	}
+	return language
+}
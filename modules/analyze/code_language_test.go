@@ -0,0 +1,49 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package analyze
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCodeLanguage(t *testing.T) {
+	for filename, language := range map[string]string{
+		"main.go":                 "Go",
+		"modules/indexer/REPO.GO": "Go",
+		"src/app.tsx":             "TypeScript",
+		"lib/vector.hpp":          "C++",
+		"Dockerfile":              "Dockerfile",
+		"build/Makefile":          "Makefile",
+		"LICENSE":                 "",
+		"archive.tar.gz":          "",
+	} {
+		assert.Equal(t, language, GetCodeLanguage(filename), filename)
+	}
+}
+
+func TestCodeLanguages(t *testing.T) {
+	languages := CodeLanguages()
+	assert.Contains(t, languages, "Go")
+	assert.Contains(t, languages, "C++")
+	assert.True(t, sort.SliceIsSorted(languages, func(i, j int) bool {
+		return strings.ToLower(languages[i]) < strings.ToLower(languages[j])
+	}))
+
+	seen := make(map[string]bool, len(languages))
+	for _, language := range languages {
+		assert.False(t, seen[language], "duplicated language %s", language)
+		seen[language] = true
+	}
+}
+
+func TestCanonicalCodeLanguage(t *testing.T) {
+	assert.Equal(t, "JavaScript", CanonicalCodeLanguage("javascript"))
+	assert.Equal(t, "C#", CanonicalCodeLanguage("c#"))
+	assert.Equal(t, "Brainfuck", CanonicalCodeLanguage("Brainfuck"))
+}
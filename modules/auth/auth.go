@@ -15,6 +15,7 @@ import (
 	"gopkg.in/macaron.v1"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
@@ -44,12 +45,21 @@ func SignedInID(ctx *macaron.Context, sess session.Store) int64 {
 			auHead := ctx.Req.Header.Get("Authorization")
 			if len(auHead) > 0 {
 				auths := strings.Fields(auHead)
-				if len(auths) == 2 && auths[0] == "token" {
+				if len(auths) == 2 && (auths[0] == "token" || strings.ToLower(auths[0]) == "bearer") {
 					tokenSHA = auths[1]
 				}
 			}
 		}
 
+		// OAuth2 access tokens are JWTs, unlike personal access tokens.
+		if strings.Contains(tokenSHA, ".") {
+			uid := CheckOAuthAccessToken(ctx, tokenSHA)
+			if uid != 0 {
+				ctx.Data["IsApiToken"] = true
+			}
+			return uid
+		}
+
 		// Let's see if token is valid.
 		if len(tokenSHA) > 0 {
 			t, err := models.GetAccessTokenBySHA(tokenSHA)
@@ -59,11 +69,16 @@ func SignedInID(ctx *macaron.Context, sess session.Store) int64 {
 				}
 				return 0
 			}
+			if t.IsExpired() {
+				log.Trace("Access token %d of user %d has expired", t.ID, t.UID)
+				return 0
+			}
 			t.UpdatedUnix = util.TimeStampNow()
 			if err = models.UpdateAccessToken(t); err != nil {
 				log.Error(4, "UpdateAccessToken: %v", err)
 			}
 			ctx.Data["IsApiToken"] = true
+			ctx.Data["ApiToken"] = t
 			return t.UID
 		}
 	}
@@ -77,6 +92,30 @@ func SignedInID(ctx *macaron.Context, sess session.Store) int64 {
 	return 0
 }
 
+// CheckOAuthAccessToken returns the id of the user who granted the oauth2 access token, or 0 if it is not valid
+func CheckOAuthAccessToken(ctx *macaron.Context, accessToken string) int64 {
+	if !setting.OAuth2.Enable {
+		return 0
+	}
+	token, err := oauth2.ParseToken(accessToken)
+	if err != nil {
+		log.Trace("oauth2.ParseToken: %v", err)
+		return 0
+	}
+	if token.Type != oauth2.TypeAccessToken {
+		return 0
+	}
+	grant, err := models.GetOAuth2GrantByID(token.GrantID)
+	if err != nil {
+		log.Error(4, "GetOAuth2GrantByID: %v", err)
+		return 0
+	} else if grant == nil {
+		return 0
+	}
+	ctx.Data["ApiToken"] = grant.AccessToken()
+	return grant.UserID
+}
+
 // SignedInUser returns the user object of signed user.
 // It returns a bool value to indicate whether user uses basic auth or not.
 func SignedInUser(ctx *macaron.Context, sess session.Store) (*models.User, bool) {
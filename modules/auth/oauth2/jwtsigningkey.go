@@ -0,0 +1,130 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// SigningKey is the RSA key used to sign and verify the tokens issued by Gitea as an OAuth2 provider
+type SigningKey struct {
+	privateKey *rsa.PrivateKey
+	id         string
+}
+
+// DefaultSigningKey is the signing key loaded by InitSigningKey
+var DefaultSigningKey *SigningKey
+
+// InitSigningKey loads the signing key from JWT_SIGNING_PRIVATE_KEY_FILE, and generates it if the file does not exist
+func InitSigningKey() error {
+	key, err := loadOrCreateSigningKey(setting.OAuth2.JWTSigningPrivateKeyFile)
+	if err != nil {
+		return fmt.Errorf("load signing key %s: %v", setting.OAuth2.JWTSigningPrivateKeyFile, err)
+	}
+	DefaultSigningKey, err = NewSigningKey(key)
+	return err
+}
+
+// NewSigningKey creates a signing key from an RSA private key
+func NewSigningKey(privateKey *rsa.PrivateKey) (*SigningKey, error) {
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(der)
+	return &SigningKey{
+		privateKey: privateKey,
+		id:         base64.RawURLEncoding.EncodeToString(hash[:]),
+	}, nil
+}
+
+func loadOrCreateSigningKey(path string) (*rsa.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return createSigningKey(path)
+	} else if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "RSA PRIVATE KEY" {
+		return nil, fmt.Errorf("no RSA private key found")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+func createSigningKey(path string) (*rsa.PrivateKey, error) {
+	log.Info("Generating OAuth2 signing key %s", path)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	})
+	if err = ioutil.WriteFile(path, data, 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// KeyID returns the identifier of the key, sent in the "kid" header of the tokens
+func (key *SigningKey) KeyID() string {
+	return key.id
+}
+
+// Sign returns the signed JWT of the claims
+func (key *SigningKey) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.privateKey)
+}
+
+// Parse verifies the signature of the JWT and its validity, and decodes its claims
+func (key *SigningKey) Parse(jwtToken string, claims jwt.Claims) error {
+	parsed, err := jwt.ParseWithClaims(jwtToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing algo: %v", token.Header["alg"])
+		}
+		return &key.privateKey.PublicKey, nil
+	})
+	if err != nil {
+		return err
+	} else if !parsed.Valid {
+		return fmt.Errorf("invalid token")
+	}
+	return nil
+}
+
+// JWK returns the public key in the JSON Web Key format, see https://tools.ietf.org/html/rfc7517
+func (key *SigningKey) JWK() map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"alg": jwt.SigningMethodRS256.Alg(),
+		"use": "sig",
+		"kid": key.id,
+		"n":   base64.RawURLEncoding.EncodeToString(key.privateKey.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.privateKey.E)).Bytes()),
+	}
+}
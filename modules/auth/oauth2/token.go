@@ -0,0 +1,70 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"fmt"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// TokenType represents the type of token for an oauth application
+type TokenType int
+
+const (
+	// TypeAccessToken is a token with short lifetime to access the api
+	TypeAccessToken TokenType = iota
+	// TypeRefreshToken is token with long lifetime to refresh access tokens obtained by the client
+	TypeRefreshToken
+)
+
+// Token represents an access or refresh token issued for an oauth2 grant
+type Token struct {
+	GrantID int64     `json:"gnt"`
+	Type    TokenType `json:"tt"`
+	Counter int64     `json:"cnt,omitempty"`
+	jwt.StandardClaims
+}
+
+// ParseToken parses a signed jwt string
+func ParseToken(jwtToken string) (*Token, error) {
+	if DefaultSigningKey == nil {
+		return nil, fmt.Errorf("oauth2 signing key is not initialized")
+	}
+	token := new(Token)
+	if err := DefaultSigningKey.Parse(jwtToken, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// SignToken signs the token with the default signing key
+func (token *Token) SignToken() (string, error) {
+	return DefaultSigningKey.Sign(token)
+}
+
+// OIDCToken represents an OpenID Connect id_token
+type OIDCToken struct {
+	jwt.StandardClaims
+	Nonce string `json:"nonce,omitempty"`
+
+	// Scope profile
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Profile           string `json:"profile,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Website           string `json:"website,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+	UpdatedAt         int64  `json:"updated_at,omitempty"`
+
+	// Scope email
+	Email         string `json:"email,omitempty"`
+	EmailVerified bool   `json:"email_verified,omitempty"`
+}
+
+// SignToken signs the id token with the default signing key
+func (token *OIDCToken) SignToken() (string, error) {
+	return DefaultSigningKey.Sign(token)
+}
@@ -0,0 +1,84 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func prepareTestSigningKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	DefaultSigningKey, err = NewSigningKey(privateKey)
+	assert.NoError(t, err)
+}
+
+func TestToken_SignAndParse(t *testing.T) {
+	prepareTestSigningKey(t)
+
+	token := &Token{
+		GrantID: 1,
+		Type:    TypeRefreshToken,
+		Counter: 3,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		},
+	}
+	signed, err := token.SignToken()
+	assert.NoError(t, err)
+
+	parsed, err := ParseToken(signed)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, parsed.GrantID)
+	assert.Equal(t, TypeRefreshToken, parsed.Type)
+	assert.EqualValues(t, 3, parsed.Counter)
+
+	// tampered tokens are rejected
+	_, err = ParseToken(signed[:len(signed)-2] + "xx")
+	assert.Error(t, err)
+
+	// tokens signed with another key are rejected
+	otherToken := *token
+	prepareTestSigningKey(t)
+	_, err = ParseToken(signed)
+	assert.Error(t, err)
+	signed, err = otherToken.SignToken()
+	assert.NoError(t, err)
+	_, err = ParseToken(signed)
+	assert.NoError(t, err)
+}
+
+func TestToken_Expired(t *testing.T) {
+	prepareTestSigningKey(t)
+
+	token := &Token{
+		GrantID: 1,
+		Type:    TypeAccessToken,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+		},
+	}
+	signed, err := token.SignToken()
+	assert.NoError(t, err)
+	_, err = ParseToken(signed)
+	assert.Error(t, err)
+}
+
+func TestSigningKey_JWK(t *testing.T) {
+	prepareTestSigningKey(t)
+
+	jwk := DefaultSigningKey.JWK()
+	assert.Equal(t, "RSA", jwk["kty"])
+	assert.Equal(t, "RS256", jwk["alg"])
+	assert.Equal(t, DefaultSigningKey.KeyID(), jwk["kid"])
+	assert.NotEmpty(t, jwk["n"])
+	assert.Equal(t, "AQAB", jwk["e"])
+}
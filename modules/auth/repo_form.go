@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/routers/utils"
 
 	"github.com/Unknwon/com"
@@ -54,6 +56,13 @@ type MigrateRepoForm struct {
 	Mirror      bool   `json:"mirror"`
 	Private     bool   `json:"private"`
 	Description string `json:"description" binding:"MaxSize(255)"`
+
+	Milestones   bool `json:"milestones"`
+	Labels       bool `json:"labels"`
+	Issues       bool `json:"issues"`
+	Comments     bool `json:"comments"`
+	PullRequests bool `json:"pull_requests"`
+	Releases     bool `json:"releases"`
 }
 
 // Validate validates the fields
@@ -89,6 +98,26 @@ func (f MigrateRepoForm) ParseRemoteAddr(user *models.User) (string, error) {
 	return remoteAddr, nil
 }
 
+// MigrateOptions returns the options to migrate the repository from the
+// remote address returned by ParseRemoteAddr
+func (f MigrateRepoForm) MigrateOptions(remoteAddr string) base.MigrateOptions {
+	return base.MigrateOptions{
+		RemoteURL:    remoteAddr,
+		AuthUsername: f.AuthUsername,
+		AuthPassword: f.AuthPassword,
+		Name:         f.RepoName,
+		Description:  f.Description,
+		Private:      f.Private || setting.Repository.ForcePrivate,
+		Mirror:       f.Mirror,
+		Milestones:   f.Milestones,
+		Labels:       f.Labels,
+		Issues:       f.Issues,
+		Comments:     f.Comments,
+		PullRequests: f.PullRequests,
+		Releases:     f.Releases,
+	}
+}
+
 // RepoSettingForm form for changing repository settings
 type RepoSettingForm struct {
 	RepoName      string `binding:"Required;AlphaDashDot;MaxSize(100)"`
@@ -99,6 +128,13 @@ type RepoSettingForm struct {
 	Private       bool
 	EnablePrune   bool
 
+	// Push mirror settings
+	PushMirrorAddress      string
+	PushMirrorUsername     string
+	PushMirrorPassword     string
+	PushMirrorInterval     string
+	PushMirrorSyncOnCommit bool
+
 	// Advanced settings
 	EnableWiki                       bool
 	EnableExternalWiki               bool
@@ -117,6 +153,11 @@ type RepoSettingForm struct {
 	EnableTimetracker                bool
 	AllowOnlyContributorsToTrackTime bool
 	EnableIssueDependencies          bool
+	EnableProjects                   bool
+
+	// Code search settings
+	IndexerBranches string
+	IndexerTags     string
 
 	// Admin settings
 	EnableHealthCheck bool
@@ -127,6 +168,25 @@ func (f *RepoSettingForm) Validate(ctx *macaron.Context, errs binding.Errors) bi
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// ParsePushMirrorAddr checks if the address of the push mirror is a valid
+// remote URL, and returns it composed with the username and password.
+func (f RepoSettingForm) ParsePushMirrorAddr() (string, error) {
+	remoteAddr := strings.TrimSpace(f.PushMirrorAddress)
+	if !strings.HasPrefix(remoteAddr, "http://") &&
+		!strings.HasPrefix(remoteAddr, "https://") &&
+		!strings.HasPrefix(remoteAddr, "git://") {
+		return "", models.ErrInvalidCloneAddr{IsURLError: true}
+	}
+	u, err := url.Parse(remoteAddr)
+	if err != nil {
+		return "", models.ErrInvalidCloneAddr{IsURLError: true}
+	}
+	if len(f.PushMirrorUsername)+len(f.PushMirrorPassword) > 0 {
+		u.User = url.UserPassword(f.PushMirrorUsername, f.PushMirrorPassword)
+	}
+	return u.String(), nil
+}
+
 // __________                             .__
 // \______   \____________    ____   ____ |  |__
 //  |    |  _/\_  __ \__  \  /    \_/ ___\|  |  \
@@ -146,6 +206,8 @@ type ProtectBranchForm struct {
 	RequiredApprovals       int64
 	ApprovalsWhitelistUsers string
 	ApprovalsWhitelistTeams string
+	EnableStatusCheck       bool
+	StatusCheckContexts     string
 }
 
 // Validate validates the fields
@@ -260,6 +322,54 @@ func (f *NewDingtalkHookForm) Validate(ctx *macaron.Context, errs binding.Errors
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// NewMSTeamsHookForm form for creating MS Teams hook
+type NewMSTeamsHookForm struct {
+	PayloadURL string `binding:"Required;ValidUrl"`
+	WebhookForm
+}
+
+// Validate validates the fields
+func (f *NewMSTeamsHookForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// NewTelegramHookForm form for creating telegram hook
+type NewTelegramHookForm struct {
+	BotToken string `binding:"Required"`
+	ChatID   string `binding:"Required"`
+	WebhookForm
+}
+
+// Validate validates the fields
+func (f *NewTelegramHookForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// NewMatrixHookForm form for creating matrix hook
+type NewMatrixHookForm struct {
+	HomeserverURL string `binding:"Required;ValidUrl"`
+	RoomID        string `binding:"Required"`
+	AccessToken   string `binding:"Required"`
+	MessageType   string
+	WebhookForm
+}
+
+// Validate validates the fields
+func (f *NewMatrixHookForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// AddSecretForm form for adding or updating a secret
+type AddSecretForm struct {
+	Name string `binding:"Required;MaxSize(255)" locale:"repo.settings.secret_name"`
+	Data string `binding:"Required;MaxSize(65536)" locale:"repo.settings.secret_data"`
+}
+
+// Validate validates the fields
+func (f *AddSecretForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .___
 // |   | ______ ________ __   ____
 // |   |/  ___//  ___/  |  \_/ __ \
@@ -325,6 +435,49 @@ func (f *CreateMilestoneForm) Validate(ctx *macaron.Context, errs binding.Errors
 	return validate(errs, ctx.Data, f, ctx.Locale)
 }
 
+// CreateProjectBoardForm form for creating project board
+type CreateProjectBoardForm struct {
+	Title   string `binding:"Required;MaxSize(100)"`
+	Content string
+}
+
+// Validate validates the fields
+func (f *CreateProjectBoardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// CreateProjectColumnForm form for creating column of project board
+type CreateProjectColumnForm struct {
+	Title string `binding:"Required;MaxSize(100)"`
+}
+
+// Validate validates the fields
+func (f *CreateProjectColumnForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// AddProjectCardForm form for adding an issue or a pull request to a project board
+type AddProjectCardForm struct {
+	ColumnID   int64 `binding:"Required"`
+	IssueIndex int64 `binding:"Required"`
+}
+
+// Validate validates the fields
+func (f *AddProjectCardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
+// MoveProjectCardForm form for moving a card of a project board
+type MoveProjectCardForm struct {
+	ColumnID int64 `binding:"Required"`
+	Position int
+}
+
+// Validate validates the fields
+func (f *MoveProjectCardForm) Validate(ctx *macaron.Context, errs binding.Errors) binding.Errors {
+	return validate(errs, ctx.Data, f, ctx.Locale)
+}
+
 // .____          ___.          .__
 // |    |   _____ \_ |__   ____ |  |
 // |    |   \__  \ | __ \_/ __ \|  |
@@ -338,6 +491,8 @@ type CreateLabelForm struct {
 	Title       string `binding:"Required;MaxSize(50)" locale:"repo.issues.label_title"`
 	Description string `binding:"MaxSize(200)" locale:"repo.issues.label_description"`
 	Color       string `binding:"Required;Size(7)" locale:"repo.issues.label_color"`
+	Exclusive   bool
+	Priority    int
 }
 
 // Validate validates the fields
@@ -363,10 +518,15 @@ func (f *InitializeLabelsForm) Validate(ctx *macaron.Context, errs binding.Error
 //                                     \/     \/   |__|           \/     \/
 
 // MergePullRequestForm form for merging Pull Request
+// swagger:model MergePullRequestOption
 type MergePullRequestForm struct {
+	// required: true
+	// enum: merge,rebase,rebase-merge,squash
 	Do                string `binding:"Required;In(merge,rebase,rebase-merge,squash)"`
 	MergeTitleField   string
 	MergeMessageField string
+	// merge as soon as the commit statuses succeed and the pull request has enough approvals
+	MergeWhenChecksSucceed bool `json:"merge_when_checks_succeed"`
 }
 
 // Validate validates the fields
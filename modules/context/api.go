@@ -23,6 +23,8 @@ import (
 type APIContext struct {
 	*Context
 	Org *APIOrganization
+	// Token is the access token used to sign in, if any
+	Token *models.AccessToken
 }
 
 // APIError is error format response
@@ -116,10 +118,26 @@ func APIContexter() macaron.Handler {
 		ctx := &APIContext{
 			Context: c,
 		}
+		ctx.Token, _ = c.Data["ApiToken"].(*models.AccessToken)
 		c.Map(ctx)
 	}
 }
 
+// HasTokenScope returns true if the request is not signed in with an access token,
+// or if the access token grants the given scope.
+func (ctx *APIContext) HasTokenScope(scope models.AccessTokenScope) bool {
+	return ctx.Token == nil || ctx.Token.HasScope(scope)
+}
+
+// CanTokenAccessRepo returns true if the request is not signed in with an access token,
+// or if the access token is not restricted to another repository than the given one.
+func (ctx *APIContext) CanTokenAccessRepo(repo *models.Repository) bool {
+	if ctx.Token == nil || ctx.Token.RepoID == 0 {
+		return true
+	}
+	return repo != nil && ctx.Token.CanAccessRepo(repo.ID)
+}
+
 // ReferencesGitRepo injects the GitRepo into the Context
 func ReferencesGitRepo() macaron.Handler {
 	return func(ctx *APIContext) {
@@ -33,6 +33,17 @@ func NewContext() {
 			go models.MirrorUpdate()
 		}
 	}
+	if setting.Cron.UpdatePushMirror.Enabled {
+		entry, err = c.AddFunc("Update push mirrors", setting.Cron.UpdatePushMirror.Schedule, models.PushMirrorUpdate)
+		if err != nil {
+			log.Fatal(4, "Cron[Update push mirrors]: %v", err)
+		}
+		if setting.Cron.UpdatePushMirror.RunAtStart {
+			entry.Prev = time.Now()
+			entry.ExecTimes++
+			go models.PushMirrorUpdate()
+		}
+	}
 	if setting.Cron.RepoHealthCheck.Enabled {
 		entry, err = c.AddFunc("Repository health check", setting.Cron.RepoHealthCheck.Schedule, models.GitFsck)
 		if err != nil {
@@ -0,0 +1,251 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package indexer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testIssueIndexer runs the tests common to all the issue indexer backends,
+// on an index which does not exist yet
+func testIssueIndexer(t *testing.T, indexer IssueIndexer) {
+	exist, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	assert.NoError(t, indexer.Index([]*IssueIndexerData{
+		{ID: 1, RepoID: 1, Title: "Search engine", Content: "Issues should be searchable"},
+		{ID: 2, RepoID: 1, Title: "Bug", Content: "Crash", Comments: []string{"the search engine crashes"}},
+		{ID: 3, RepoID: 1, Title: "Unrelated", Content: "Nothing to see"},
+		{ID: 4, RepoID: 2, Title: "Search engine", Content: "In another repository"},
+	}))
+
+	ids, err := indexer.Search([]int64{1}, "search engine")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2}, ids)
+
+	ids, err = indexer.Search([]int64{2}, "search engine")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{4}, ids)
+
+	// no repository means all the repositories
+	ids, err = indexer.Search(nil, "search engine")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{1, 2, 4}, ids)
+
+	ids, err = indexer.Search([]int64{1, 2}, "crash")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{2}, ids)
+
+	// updating an issue replaces its previous data
+	assert.NoError(t, indexer.Index([]*IssueIndexerData{
+		{ID: 1, RepoID: 1, Title: "Renamed", Content: "Issues should be listed"},
+	}))
+	ids, err = indexer.Search([]int64{1}, "search engine")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int64{2}, ids)
+
+	assert.NoError(t, indexer.Delete(2, 5))
+	ids, err = indexer.Search([]int64{1}, "search engine")
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+
+	assert.NoError(t, indexer.Reset())
+	ids, err = indexer.Search([]int64{2}, "search engine")
+	assert.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+// testRepoIndexer runs the tests common to all the repo indexer backends,
+// on an index which does not exist yet
+func testRepoIndexer(t *testing.T, indexer RepoIndexer) {
+	exist, err := indexer.Init()
+	assert.NoError(t, err)
+	assert.False(t, exist)
+
+	update := func(repoID int64, ref, filepath, content string) RepoIndexerUpdate {
+		return RepoIndexerUpdate{
+			Filepath: filepath,
+			Op:       RepoIndexerOpUpdate,
+			Data:     &RepoIndexerData{RepoID: repoID, Ref: ref, Content: content},
+		}
+	}
+	assert.NoError(t, indexer.Index([]RepoIndexerUpdate{
+		update(1, "", "main.go", "package main\n\nfunc gitea() {}\n"),
+		update(1, "", "README.md", "# Readme\n"),
+		update(2, RepoIndexerDefaultRef, "docs/gitea.md", "About gitea\n"),
+		update(1, "refs/tags/v1.0", "main.go", "package main\n\nfunc gitea() {}\n\nfunc legacy() {}\n"),
+		update(1, "refs/heads/release/1.0", "main.go", "package main\n\nfunc legacy() {}\n"),
+	}))
+
+	search := func(repoIDs []int64, keyword string, page, pageSize int) (int64, []*RepoSearchResult, error) {
+		return indexer.Search(&RepoSearchOptions{
+			RepoIDs:  repoIDs,
+			Keyword:  keyword,
+			Page:     page,
+			PageSize: pageSize,
+		})
+	}
+
+	total, results, err := search([]int64{1}, "gitea", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	if assert.Len(t, results, 1) {
+		result := results[0]
+		assert.EqualValues(t, 1, result.RepoID)
+		assert.Equal(t, "main.go", result.Filename)
+		assert.Equal(t, "Go", result.Language)
+		assert.Equal(t, "package main\n\nfunc gitea() {}\n", result.Content)
+		assert.Equal(t, "gitea", result.Content[result.StartIndex:result.EndIndex])
+	}
+
+	// no repository means all the repositories
+	total, results, err = search(nil, "gitea", 1, 1)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, results, 1)
+
+	// the other refs are only searched when requested
+	total, _, err = search(nil, "legacy", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+	for _, ref := range []string{"refs/tags/v1.0", "refs/heads/release/1.0"} {
+		total, results, err = indexer.Search(&RepoSearchOptions{
+			RepoIDs:  []int64{1},
+			Ref:      ref,
+			Keyword:  "legacy",
+			Page:     1,
+			PageSize: 10,
+		})
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, total, ref)
+		if assert.Len(t, results, 1, ref) {
+			assert.Equal(t, "main.go", results[0].Filename)
+		}
+	}
+
+	assert.NoError(t, indexer.DeleteRef(1, "refs/tags/v1.0"))
+	total, _, err = indexer.Search(&RepoSearchOptions{
+		Ref:      "refs/tags/v1.0",
+		Keyword:  "gitea",
+		Page:     1,
+		PageSize: 10,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+	total, _, err = indexer.Search(&RepoSearchOptions{
+		Ref:      "refs/heads/release/1.0",
+		Keyword:  "legacy",
+		Page:     1,
+		PageSize: 10,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	for _, c := range []struct {
+		Language    string
+		PathPattern string
+		Filenames   []string
+	}{
+		{Language: "Go", Filenames: []string{"main.go"}},
+		{Language: "Markdown", Filenames: []string{"docs/gitea.md"}},
+		{Language: "Python"},
+		{PathPattern: "docs", Filenames: []string{"docs/gitea.md"}},
+		{PathPattern: "/docs/", Filenames: []string{"docs/gitea.md"}},
+		{PathPattern: "*.go", Filenames: []string{"main.go"}},
+		{PathPattern: "*/*.md", Filenames: []string{"docs/gitea.md"}},
+		{PathPattern: "ma?n.*", Filenames: []string{"main.go"}},
+		{PathPattern: "src"},
+		{Language: "Go", PathPattern: "docs"},
+	} {
+		total, results, err = indexer.Search(&RepoSearchOptions{
+			Keyword:     "gitea",
+			Language:    c.Language,
+			PathPattern: c.PathPattern,
+			Page:        1,
+			PageSize:    10,
+		})
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(c.Filenames), total, "%+v", c)
+		filenames := make([]string, len(results))
+		for i, result := range results {
+			filenames[i] = result.Filename
+		}
+		assert.ElementsMatch(t, c.Filenames, filenames, "%+v", c)
+	}
+
+	assert.NoError(t, indexer.Index([]RepoIndexerUpdate{{
+		Filepath: "main.go",
+		Op:       RepoIndexerOpDelete,
+		Data:     &RepoIndexerData{RepoID: 1},
+	}}))
+	total, _, err = search([]int64{1}, "gitea", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+
+	assert.NoError(t, indexer.DeleteRepo(2))
+	total, _, err = search(nil, "gitea", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+
+	total, _, err = search([]int64{1}, "readme", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+
+	assert.NoError(t, indexer.Reset())
+	total, _, err = search(nil, "readme", 1, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, total)
+}
+
+func TestFilenameIndexerID(t *testing.T) {
+	id := filenameIndexerID(1, "", "docs/a:b.md")
+	assert.Equal(t, "1_HEAD:docs/a:b.md", id)
+	assert.Equal(t, "docs/a:b.md", filenameOfIndexerID(id))
+
+	id = filenameIndexerID(1, "refs/heads/release_1", "main.go")
+	assert.Equal(t, "main.go", filenameOfIndexerID(id))
+}
+
+func TestPathPatternWildcard(t *testing.T) {
+	assert.Equal(t, "docs*", pathPatternWildcard("docs"))
+	assert.Equal(t, "docs/*", pathPatternWildcard("/docs/"))
+	assert.Equal(t, "*.go", pathPatternWildcard("*.go"))
+	assert.Equal(t, "cmd/?.go", pathPatternWildcard("cmd/?.go"))
+}
+
+func TestBleveIssueIndexer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "issues.bleve")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	indexer := NewBleveIssueIndexer(filepath.Join(dir, "index"))
+	testIssueIndexer(t, indexer)
+	assert.NoError(t, indexer.index.Close())
+}
+
+func TestBleveRepoIndexer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "repos.bleve")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	indexer := NewBleveRepoIndexer(filepath.Join(dir, "index"))
+	testRepoIndexer(t, indexer)
+	assert.NoError(t, indexer.index.Close())
+}
+
+func TestNewIssueIndexer(t *testing.T) {
+	_, err := NewIssueIndexer("unknown")
+	assert.Error(t, err)
+
+	indexer, err := NewIssueIndexer(TypeElasticSearch)
+	assert.NoError(t, err)
+	assert.IsType(t, &ElasticSearchIssueIndexer{}, indexer)
+}
@@ -5,139 +5,110 @@
 package indexer
 
 import (
+	"fmt"
+
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
-
-	"github.com/blevesearch/bleve"
-	"github.com/blevesearch/bleve/analysis/analyzer/custom"
-	"github.com/blevesearch/bleve/analysis/token/lowercase"
-	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
-	"github.com/ethantkoenig/rupture"
 )
 
-// issueIndexer (thread-safe) index for searching issues
-var issueIndexer bleve.Index
-
-const (
-	issueIndexerAnalyzer = "issueIndexer"
-	issueIndexerDocType  = "issueIndexerDocType"
+// IssueIndexer is the interface implemented by the issue indexer backends
+type IssueIndexer interface {
+	// Init opens the index, creating it if needed. It returns true if the
+	// index already existed, false if it has to be populated.
+	Init() (bool, error)
+	// Index adds or updates the given issues
+	Index(issues []*IssueIndexerData) error
+	// Delete removes the issues with the given ids
+	Delete(ids ...int64) error
+	// Search returns the ids of the issues of the repositories matching the
+	// keyword, the issues of all the repositories if repoIDs is empty
+	Search(repoIDs []int64, keyword string) ([]int64, error)
+	// Reset removes all the issues from the index
+	Reset() error
+}
 
-	issueIndexerLatestVersion = 1
-)
+// issueIndexer (thread-safe) index for searching issues
+var issueIndexer IssueIndexer
 
 // IssueIndexerData data stored in the issue indexer
 type IssueIndexerData struct {
+	ID       int64
 	RepoID   int64
 	Title    string
 	Content  string
 	Comments []string
 }
 
-// Type returns the document type, for bleve's mapping.Classifier interface.
-func (i *IssueIndexerData) Type() string {
-	return issueIndexerDocType
-}
-
-// IssueIndexerUpdate an update to the issue indexer
-type IssueIndexerUpdate struct {
-	IssueID int64
-	Data    *IssueIndexerData
-}
-
-// AddToFlushingBatch adds the update to the given flushing batch.
-func (i IssueIndexerUpdate) AddToFlushingBatch(batch rupture.FlushingBatch) error {
-	return batch.Index(indexerID(i.IssueID), i.Data)
+// NewIssueIndexer returns the issue indexer backend of the given type
+func NewIssueIndexer(indexerType string) (IssueIndexer, error) {
+	switch indexerType {
+	case TypeBleve:
+		return NewBleveIssueIndexer(setting.Indexer.IssuePath), nil
+	case TypeElasticSearch:
+		return NewElasticSearchIssueIndexer(setting.Indexer.IssueConnStr, setting.Indexer.IssueIndexerName), nil
+	default:
+		return nil, fmt.Errorf("unknown issue indexer type: %s", indexerType)
+	}
 }
 
 // InitIssueIndexer initialize issue indexer
 func InitIssueIndexer(populateIndexer func() error) {
 	var err error
-	issueIndexer, err = openIndexer(setting.Indexer.IssuePath, issueIndexerLatestVersion)
+	issueIndexer, err = NewIssueIndexer(setting.Indexer.IssueType)
 	if err != nil {
 		log.Fatal(4, "InitIssueIndexer: %v", err)
 	}
-	if issueIndexer != nil {
-		return
+	exist, err := issueIndexer.Init()
+	if err != nil {
+		log.Fatal(4, "InitIssueIndexer: %v", err)
 	}
-
-	if err = createIssueIndexer(setting.Indexer.IssuePath, issueIndexerLatestVersion); err != nil {
-		log.Fatal(4, "InitIssuesIndexer: create index, %v", err)
+	if exist {
+		return
 	}
 	if err = populateIndexer(); err != nil {
 		log.Fatal(4, "InitIssueIndexer: populate index, %v", err)
 	}
 }
 
-// createIssueIndexer create an issue indexer if one does not already exist
-func createIssueIndexer(path string, latestVersion int) error {
-	mapping := bleve.NewIndexMapping()
-	docMapping := bleve.NewDocumentMapping()
-
-	numericFieldMapping := bleve.NewNumericFieldMapping()
-	numericFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+// ResetIssueIndexer removes all the issues from the issue indexer, so that it
+// can be populated again
+func ResetIssueIndexer() error {
+	return issueIndexer.Reset()
+}
 
-	textFieldMapping := bleve.NewTextFieldMapping()
-	textFieldMapping.Store = false
-	textFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("Title", textFieldMapping)
-	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
-	docMapping.AddFieldMappingsAt("Comments", textFieldMapping)
+// IssueIndexerBatch collects issues and sends them to the issue indexer by batches
+type IssueIndexerBatch struct {
+	issues []*IssueIndexerData
+}
 
-	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
-		return err
-	} else if err = mapping.AddCustomAnalyzer(issueIndexerAnalyzer, map[string]interface{}{
-		"type":          custom.Name,
-		"char_filters":  []string{},
-		"tokenizer":     unicode.Name,
-		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
-	}); err != nil {
-		return err
+// NewIssueIndexerBatch returns a new batch to add issues to
+func NewIssueIndexerBatch() *IssueIndexerBatch {
+	return &IssueIndexerBatch{
+		issues: make([]*IssueIndexerData, 0, maxBatchSize),
 	}
+}
 
-	mapping.DefaultAnalyzer = issueIndexerAnalyzer
-	mapping.AddDocumentMapping(issueIndexerDocType, docMapping)
-	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
-
-	var err error
-	issueIndexer, err = bleve.New(path, mapping)
-	if err != nil {
-		return err
+// Add adds the issue to the batch, flushing the batch once it is full
+func (batch *IssueIndexerBatch) Add(issue *IssueIndexerData) error {
+	batch.issues = append(batch.issues, issue)
+	if len(batch.issues) >= maxBatchSize {
+		return batch.Flush()
 	}
-
-	return rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
-		Version: latestVersion,
-	})
+	return nil
 }
 
-// IssueIndexerBatch batch to add updates to
-func IssueIndexerBatch() rupture.FlushingBatch {
-	return rupture.NewFlushingBatch(issueIndexer, maxBatchSize)
+// Flush sends the issues of the batch to the issue indexer
+func (batch *IssueIndexerBatch) Flush() error {
+	if len(batch.issues) == 0 {
+		return nil
+	}
+	err := issueIndexer.Index(batch.issues)
+	batch.issues = batch.issues[:0]
+	return err
 }
 
 // SearchIssuesByKeyword searches for issues by given conditions.
 // Returns the matching issue IDs
-func SearchIssuesByKeyword(repoID int64, keyword string) ([]int64, error) {
-	indexerQuery := bleve.NewConjunctionQuery(
-		numericEqualityQuery(repoID, "RepoID"),
-		bleve.NewDisjunctionQuery(
-			newMatchPhraseQuery(keyword, "Title", issueIndexerAnalyzer),
-			newMatchPhraseQuery(keyword, "Content", issueIndexerAnalyzer),
-			newMatchPhraseQuery(keyword, "Comments", issueIndexerAnalyzer),
-		))
-	search := bleve.NewSearchRequestOptions(indexerQuery, 2147483647, 0, false)
-
-	result, err := issueIndexer.Search(search)
-	if err != nil {
-		return nil, err
-	}
-
-	issueIDs := make([]int64, len(result.Hits))
-	for i, hit := range result.Hits {
-		issueIDs[i], err = idOfIndexerID(hit.ID)
-		if err != nil {
-			return nil, err
-		}
-	}
-	return issueIDs, nil
+func SearchIssuesByKeyword(repoIDs []int64, keyword string) ([]int64, error) {
+	return issueIndexer.Search(repoIDs, keyword)
 }
@@ -0,0 +1,196 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package indexer
+
+import (
+	"os"
+	"sync"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/ethantkoenig/rupture"
+)
+
+const (
+	issueIndexerAnalyzer = "issueIndexer"
+	issueIndexerDocType  = "issueIndexerDocType"
+
+	issueIndexerLatestVersion = 1
+)
+
+// bleveIssueData the document stored in the bleve issue index
+type bleveIssueData struct {
+	RepoID   int64
+	Title    string
+	Content  string
+	Comments []string
+}
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (i *bleveIssueData) Type() string {
+	return issueIndexerDocType
+}
+
+// BleveIssueIndexer implements IssueIndexer with a local bleve index
+type BleveIssueIndexer struct {
+	indexDir string
+	lock     sync.RWMutex
+	index    bleve.Index
+}
+
+// NewBleveIssueIndexer creates a new bleve issue indexer stored in the given directory
+func NewBleveIssueIndexer(indexDir string) *BleveIssueIndexer {
+	return &BleveIssueIndexer{
+		indexDir: indexDir,
+	}
+}
+
+// Init opens the bleve index, creating it if it does not exist yet
+func (b *BleveIssueIndexer) Init() (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var err error
+	b.index, err = openIndexer(b.indexDir, issueIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+	if b.index != nil {
+		return true, nil
+	}
+	b.index, err = createIssueIndexer(b.indexDir, issueIndexerLatestVersion)
+	return false, err
+}
+
+// createIssueIndexer create an issue indexer if one does not already exist
+func createIssueIndexer(path string, latestVersion int) (bleve.Index, error) {
+	mapping := bleve.NewIndexMapping()
+	docMapping := bleve.NewDocumentMapping()
+
+	numericFieldMapping := bleve.NewNumericFieldMapping()
+	numericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+
+	textFieldMapping := bleve.NewTextFieldMapping()
+	textFieldMapping.Store = false
+	textFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Title", textFieldMapping)
+	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
+	docMapping.AddFieldMappingsAt("Comments", textFieldMapping)
+
+	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(issueIndexerAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{unicodeNormalizeName, lowercase.Name},
+	}); err != nil {
+		return nil, err
+	}
+
+	mapping.DefaultAnalyzer = issueIndexerAnalyzer
+	mapping.AddDocumentMapping(issueIndexerDocType, docMapping)
+	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+
+	index, err := bleve.New(path, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	return index, rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
+		Version: latestVersion,
+	})
+}
+
+// Index adds or updates the given issues
+func (b *BleveIssueIndexer) Index(issues []*IssueIndexerData) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	batch := rupture.NewFlushingBatch(b.index, maxBatchSize)
+	for _, issue := range issues {
+		if err := batch.Index(indexerID(issue.ID), &bleveIssueData{
+			RepoID:   issue.RepoID,
+			Title:    issue.Title,
+			Content:  issue.Content,
+			Comments: issue.Comments,
+		}); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Delete removes the issues with the given ids
+func (b *BleveIssueIndexer) Delete(ids ...int64) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	batch := rupture.NewFlushingBatch(b.index, maxBatchSize)
+	for _, id := range ids {
+		if err := batch.Delete(indexerID(id)); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (b *BleveIssueIndexer) Search(repoIDs []int64, keyword string) ([]int64, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	indexerQuery := bleve.NewConjunctionQuery(
+		bleve.NewDisjunctionQuery(
+			newMatchPhraseQuery(keyword, "Title", issueIndexerAnalyzer),
+			newMatchPhraseQuery(keyword, "Content", issueIndexerAnalyzer),
+			newMatchPhraseQuery(keyword, "Comments", issueIndexerAnalyzer),
+		))
+	if len(repoIDs) > 0 {
+		repoQueries := make([]query.Query, 0, len(repoIDs))
+		for _, repoID := range repoIDs {
+			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+		}
+		indexerQuery.AddQuery(bleve.NewDisjunctionQuery(repoQueries...))
+	}
+	search := bleve.NewSearchRequestOptions(indexerQuery, 2147483647, 0, false)
+
+	result, err := b.index.Search(search)
+	if err != nil {
+		return nil, err
+	}
+
+	issueIDs := make([]int64, len(result.Hits))
+	for i, hit := range result.Hits {
+		issueIDs[i], err = idOfIndexerID(hit.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return issueIDs, nil
+}
+
+// Reset removes the bleve index and creates an empty one
+func (b *BleveIssueIndexer) Reset() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.index != nil {
+		if err := b.index.Close(); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(b.indexDir); err != nil {
+		return err
+	}
+	var err error
+	b.index, err = createIssueIndexer(b.indexDir, issueIndexerLatestVersion)
+	return err
+}
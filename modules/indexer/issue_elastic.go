@@ -0,0 +1,141 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package indexer
+
+import (
+	"sync"
+)
+
+// elasticIssueData the document stored in the Elasticsearch issue index
+type elasticIssueData struct {
+	RepoID   int64    `json:"repo_id"`
+	Title    string   `json:"title"`
+	Content  string   `json:"content"`
+	Comments []string `json:"comments"`
+}
+
+const elasticIssueIndexerLatestVersion = 1
+
+var elasticIssueIndexDefinition = elasticIndexDefinition(elasticIssueIndexerLatestVersion, nil, map[string]interface{}{
+	"repo_id":  map[string]interface{}{"type": "long"},
+	"title":    map[string]interface{}{"type": "text"},
+	"content":  map[string]interface{}{"type": "text"},
+	"comments": map[string]interface{}{"type": "text"},
+})
+
+// ElasticSearchIssueIndexer implements IssueIndexer with an Elasticsearch index
+type ElasticSearchIssueIndexer struct {
+	connStr   string
+	indexName string
+	lock      sync.RWMutex
+	client    *elasticClient
+}
+
+// NewElasticSearchIssueIndexer creates a new Elasticsearch issue indexer
+// storing the issues in the given index of the server at connStr
+func NewElasticSearchIssueIndexer(connStr, indexName string) *ElasticSearchIssueIndexer {
+	return &ElasticSearchIssueIndexer{
+		connStr:   connStr,
+		indexName: indexName,
+	}
+}
+
+// Init connects to the server and creates the index if needed
+func (b *ElasticSearchIssueIndexer) Init() (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var err error
+	b.client, err = newElasticClient(b.connStr, b.indexName)
+	if err != nil {
+		return false, err
+	}
+	return b.client.initIndex(elasticIssueIndexDefinition, elasticIssueIndexerLatestVersion)
+}
+
+// Index adds or updates the given issues
+func (b *ElasticSearchIssueIndexer) Index(issues []*IssueIndexerData) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	ops := make([]elasticBulkOp, len(issues))
+	for i, issue := range issues {
+		ops[i] = elasticBulkOp{
+			id: indexerID(issue.ID),
+			doc: &elasticIssueData{
+				RepoID:   issue.RepoID,
+				Title:    issue.Title,
+				Content:  issue.Content,
+				Comments: issue.Comments,
+			},
+		}
+	}
+	return b.client.bulk(ops)
+}
+
+// Delete removes the issues with the given ids
+func (b *ElasticSearchIssueIndexer) Delete(ids ...int64) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	ops := make([]elasticBulkOp, len(ids))
+	for i, id := range ids {
+		ops[i] = elasticBulkOp{id: indexerID(id)}
+	}
+	return b.client.bulk(ops)
+}
+
+// Search searches for issues by given conditions.
+// Returns the matching issue IDs
+func (b *ElasticSearchIssueIndexer) Search(repoIDs []int64, keyword string) ([]int64, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	filters := make([]interface{}, 0, 1)
+	if len(repoIDs) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{"repo_id": repoIDs},
+		})
+	}
+	resp, err := b.client.search(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": filters,
+				"must": map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":  keyword,
+						"type":   "phrase",
+						"fields": []string{"title", "content", "comments"},
+					},
+				},
+			},
+		},
+		"_source": false,
+		"size":    elasticMaxResultWindow,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issueIDs := make([]int64, len(resp.Hits.Hits))
+	for i, hit := range resp.Hits.Hits {
+		issueIDs[i], err = idOfIndexerID(hit.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return issueIDs, nil
+}
+
+// Reset deletes the index and creates an empty one
+func (b *ElasticSearchIssueIndexer) Reset() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.client.deleteIndex(); err != nil {
+		return err
+	}
+	return b.client.createIndex(elasticIssueIndexDefinition)
+}
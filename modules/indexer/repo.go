@@ -5,30 +5,32 @@
 package indexer
 
 import (
+	"fmt"
 	"strings"
 
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/setting"
-
-	"github.com/blevesearch/bleve"
-	"github.com/blevesearch/bleve/analysis/analyzer/custom"
-	"github.com/blevesearch/bleve/analysis/token/camelcase"
-	"github.com/blevesearch/bleve/analysis/token/lowercase"
-	"github.com/blevesearch/bleve/analysis/token/unique"
-	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
-	"github.com/blevesearch/bleve/search/query"
-	"github.com/ethantkoenig/rupture"
 )
 
-const (
-	repoIndexerAnalyzer = "repoIndexerAnalyzer"
-	repoIndexerDocType  = "repoIndexerDocType"
-
-	repoIndexerLatestVersion = 1
-)
+// RepoIndexer is the interface implemented by the repo indexer backends
+type RepoIndexer interface {
+	// Init opens the index, creating it if needed. It returns true if the
+	// index already existed, false if it has to be populated.
+	Init() (bool, error)
+	// Index applies the given updates to the index
+	Index(updates []RepoIndexerUpdate) error
+	// DeleteRepo removes all of a repository's files from the index
+	DeleteRepo(repoID int64) error
+	// DeleteRef removes the files of one of a repository's refs from the index
+	DeleteRef(repoID int64, ref string) error
+	// Search returns the files matching the search options
+	Search(opts *RepoSearchOptions) (int64, []*RepoSearchResult, error)
+	// Reset removes all the files from the index
+	Reset() error
+}
 
 // repoIndexer (thread-safe) index for repository contents
-var repoIndexer bleve.Index
+var repoIndexer RepoIndexer
 
 // RepoIndexerOp type of operation to perform on repo indexer
 type RepoIndexerOp int
@@ -41,17 +43,19 @@ const (
 	RepoIndexerOpDelete
 )
 
+// RepoIndexerDefaultRef the ref under which the files of the default
+// branch of a repository are indexed, whatever the name of this branch
+const RepoIndexerDefaultRef = "HEAD"
+
 // RepoIndexerData data stored in the repo indexer
 type RepoIndexerData struct {
-	RepoID  int64
+	RepoID int64
+	// Ref is the full name of the ref of the file, the files of the default
+	// branch are indexed under RepoIndexerDefaultRef (used if empty)
+	Ref     string
 	Content string
 }
 
-// Type returns the document type, for bleve's mapping.Classifier interface.
-func (d *RepoIndexerData) Type() string {
-	return repoIndexerDocType
-}
-
 // RepoIndexerUpdate an update to the repo indexer
 type RepoIndexerUpdate struct {
 	Filepath string
@@ -59,107 +63,124 @@ type RepoIndexerUpdate struct {
 	Data     *RepoIndexerData
 }
 
-// AddToFlushingBatch adds the update to the given flushing batch.
-func (update RepoIndexerUpdate) AddToFlushingBatch(batch rupture.FlushingBatch) error {
-	id := filenameIndexerID(update.Data.RepoID, update.Filepath)
-	switch update.Op {
-	case RepoIndexerOpUpdate:
-		return batch.Index(id, update.Data)
-	case RepoIndexerOpDelete:
-		return batch.Delete(id)
+// NewRepoIndexer returns the repo indexer backend of the given type
+func NewRepoIndexer(indexerType string) (RepoIndexer, error) {
+	switch indexerType {
+	case TypeBleve:
+		return NewBleveRepoIndexer(setting.Indexer.RepoPath), nil
+	case TypeElasticSearch:
+		return NewElasticSearchRepoIndexer(setting.Indexer.RepoConnStr, setting.Indexer.RepoIndexerName), nil
 	default:
-		log.Error(4, "Unrecognized repo indexer op: %d", update.Op)
+		return nil, fmt.Errorf("unknown repo indexer type: %s", indexerType)
 	}
-	return nil
 }
 
 // InitRepoIndexer initialize repo indexer
 func InitRepoIndexer(populateIndexer func() error) {
 	var err error
-	repoIndexer, err = openIndexer(setting.Indexer.RepoPath, repoIndexerLatestVersion)
+	repoIndexer, err = NewRepoIndexer(setting.Indexer.RepoType)
 	if err != nil {
 		log.Fatal(4, "InitRepoIndexer: %v", err)
 	}
-	if repoIndexer != nil {
-		return
+	exist, err := repoIndexer.Init()
+	if err != nil {
+		log.Fatal(4, "InitRepoIndexer: %v", err)
 	}
-
-	if err = createRepoIndexer(setting.Indexer.RepoPath, repoIndexerLatestVersion); err != nil {
-		log.Fatal(4, "CreateRepoIndexer: %v", err)
+	if exist {
+		return
 	}
 	if err = populateIndexer(); err != nil {
 		log.Fatal(4, "PopulateRepoIndex: %v", err)
 	}
 }
 
-// createRepoIndexer create a repo indexer if one does not already exist
-func createRepoIndexer(path string, latestVersion int) error {
-	var err error
-	docMapping := bleve.NewDocumentMapping()
-	numericFieldMapping := bleve.NewNumericFieldMapping()
-	numericFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
-
-	textFieldMapping := bleve.NewTextFieldMapping()
-	textFieldMapping.IncludeInAll = false
-	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
-
-	mapping := bleve.NewIndexMapping()
-	if err = addUnicodeNormalizeTokenFilter(mapping); err != nil {
-		return err
-	} else if err = mapping.AddCustomAnalyzer(repoIndexerAnalyzer, map[string]interface{}{
-		"type":          custom.Name,
-		"char_filters":  []string{},
-		"tokenizer":     unicode.Name,
-		"token_filters": []string{unicodeNormalizeName, camelcase.Name, lowercase.Name, unique.Name},
-	}); err != nil {
-		return err
-	}
-	mapping.DefaultAnalyzer = repoIndexerAnalyzer
-	mapping.AddDocumentMapping(repoIndexerDocType, docMapping)
-	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+// ResetRepoIndexer removes all the files from the repo indexer, so that it
+// can be populated again
+func ResetRepoIndexer() error {
+	return repoIndexer.Reset()
+}
 
-	repoIndexer, err = bleve.New(path, mapping)
-	if err != nil {
-		return err
+// indexerRef returns the ref under which the files of the given ref are
+// indexed, the default branch if empty
+func indexerRef(ref string) string {
+	if len(ref) == 0 {
+		return RepoIndexerDefaultRef
 	}
-	return rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
-		Version: latestVersion,
-	})
+	return ref
 }
 
-func filenameIndexerID(repoID int64, filename string) string {
-	return indexerID(repoID) + "_" + filename
+// filenameIndexerID returns the ID of a file in the index, git forbids colons
+// in the names of the refs so the filename starts after the first one.
+func filenameIndexerID(repoID int64, ref, filename string) string {
+	return indexerID(repoID) + "_" + indexerRef(ref) + ":" + filename
 }
 
 func filenameOfIndexerID(indexerID string) string {
-	index := strings.IndexByte(indexerID, '_')
+	index := strings.IndexByte(indexerID, ':')
 	if index == -1 {
 		log.Error(4, "Unexpected ID in repo indexer: %s", indexerID)
 	}
 	return indexerID[index+1:]
 }
 
-// RepoIndexerBatch batch to add updates to
-func RepoIndexerBatch() rupture.FlushingBatch {
-	return rupture.NewFlushingBatch(repoIndexer, maxBatchSize)
+// RepoIndexerBatch collects updates and sends them to the repo indexer by batches
+type RepoIndexerBatch struct {
+	updates []RepoIndexerUpdate
 }
 
-// DeleteRepoFromIndexer delete all of a repo's files from indexer
-func DeleteRepoFromIndexer(repoID int64) error {
-	query := numericEqualityQuery(repoID, "RepoID")
-	searchRequest := bleve.NewSearchRequestOptions(query, 2147483647, 0, false)
-	result, err := repoIndexer.Search(searchRequest)
-	if err != nil {
-		return err
+// NewRepoIndexerBatch returns a new batch to add updates to
+func NewRepoIndexerBatch() *RepoIndexerBatch {
+	return &RepoIndexerBatch{
+		updates: make([]RepoIndexerUpdate, 0, maxBatchSize),
 	}
-	batch := RepoIndexerBatch()
-	for _, hit := range result.Hits {
-		if err = batch.Delete(hit.ID); err != nil {
-			return err
-		}
+}
+
+// Add adds the update to the batch, flushing the batch once it is full
+func (batch *RepoIndexerBatch) Add(update RepoIndexerUpdate) error {
+	batch.updates = append(batch.updates, update)
+	if len(batch.updates) >= maxBatchSize {
+		return batch.Flush()
+	}
+	return nil
+}
+
+// Flush sends the updates of the batch to the repo indexer
+func (batch *RepoIndexerBatch) Flush() error {
+	if len(batch.updates) == 0 {
+		return nil
 	}
-	return batch.Flush()
+	err := repoIndexer.Index(batch.updates)
+	batch.updates = batch.updates[:0]
+	return err
+}
+
+// DeleteRepoFromIndexer delete all of a repo's files from indexer
+func DeleteRepoFromIndexer(repoID int64) error {
+	return repoIndexer.DeleteRepo(repoID)
+}
+
+// DeleteRepoRefFromIndexer delete the files of one of a repo's refs from indexer
+func DeleteRepoRefFromIndexer(repoID int64, ref string) error {
+	return repoIndexer.DeleteRef(repoID, indexerRef(ref))
+}
+
+// RepoSearchOptions options to search for files in the repo indexer
+type RepoSearchOptions struct {
+	// RepoIDs restricts the search to the given repositories, all the
+	// repositories are searched if empty
+	RepoIDs []int64
+	// Ref restricts the search to the files of the given ref, the default
+	// branches are searched if empty
+	Ref     string
+	Keyword string
+	// Language restricts the search to the files of the given language, as
+	// detected by analyze.GetCodeLanguage
+	Language string
+	// PathPattern restricts the search to the files whose path matches the
+	// pattern, see pathPatternWildcard
+	PathPattern string
+	Page        int
+	PageSize    int
 }
 
 // RepoSearchResult result of performing a search in a repo
@@ -168,62 +189,24 @@ type RepoSearchResult struct {
 	StartIndex int
 	EndIndex   int
 	Filename   string
+	Language   string
 	Content    string
 }
 
-// SearchRepoByKeyword searches for files in the specified repo.
-// Returns the matching file-paths
-func SearchRepoByKeyword(repoIDs []int64, keyword string, page, pageSize int) (int64, []*RepoSearchResult, error) {
-	phraseQuery := bleve.NewMatchPhraseQuery(keyword)
-	phraseQuery.FieldVal = "Content"
-	phraseQuery.Analyzer = repoIndexerAnalyzer
-
-	var indexerQuery query.Query
-	if len(repoIDs) > 0 {
-		var repoQueries = make([]query.Query, 0, len(repoIDs))
-		for _, repoID := range repoIDs {
-			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
-		}
-
-		indexerQuery = bleve.NewConjunctionQuery(
-			bleve.NewDisjunctionQuery(repoQueries...),
-			phraseQuery,
-		)
-	} else {
-		indexerQuery = phraseQuery
-	}
-
-	from := (page - 1) * pageSize
-	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, pageSize, from, false)
-	searchRequest.Fields = []string{"Content", "RepoID"}
-	searchRequest.IncludeLocations = true
-
-	result, err := repoIndexer.Search(searchRequest)
-	if err != nil {
-		return 0, nil, err
+// pathPatternWildcard returns the wildcard pattern, where * matches any
+// sequence of characters (including /) and ? any single character, matching
+// the file paths selected by the given path pattern. A pattern without
+// wildcard matches the files whose path starts with it.
+func pathPatternWildcard(pattern string) string {
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.ContainsAny(pattern, "*?") {
+		pattern += "*"
 	}
+	return pattern
+}
 
-	searchResults := make([]*RepoSearchResult, len(result.Hits))
-	for i, hit := range result.Hits {
-		var startIndex, endIndex int = -1, -1
-		for _, locations := range hit.Locations["Content"] {
-			location := locations[0]
-			locationStart := int(location.Start)
-			locationEnd := int(location.End)
-			if startIndex < 0 || locationStart < startIndex {
-				startIndex = locationStart
-			}
-			if endIndex < 0 || locationEnd > endIndex {
-				endIndex = locationEnd
-			}
-		}
-		searchResults[i] = &RepoSearchResult{
-			RepoID:     int64(hit.Fields["RepoID"].(float64)),
-			StartIndex: startIndex,
-			EndIndex:   endIndex,
-			Filename:   filenameOfIndexerID(hit.ID),
-			Content:    hit.Fields["Content"].(string),
-		}
-	}
-	return int64(result.Total), searchResults, nil
+// SearchRepoByKeyword searches for files in the repo indexer.
+// Returns the matching file-paths
+func SearchRepoByKeyword(opts *RepoSearchOptions) (int64, []*RepoSearchResult, error) {
+	return repoIndexer.Search(opts)
 }
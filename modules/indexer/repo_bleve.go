@@ -0,0 +1,283 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package indexer
+
+import (
+	"os"
+	"sync"
+
+	"code.gitea.io/gitea/modules/analyze"
+	"code.gitea.io/gitea/modules/log"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/analysis/token/camelcase"
+	"github.com/blevesearch/bleve/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/analysis/token/unique"
+	"github.com/blevesearch/bleve/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/ethantkoenig/rupture"
+)
+
+const (
+	repoIndexerAnalyzer = "repoIndexerAnalyzer"
+	repoIndexerDocType  = "repoIndexerDocType"
+
+	repoIndexerLatestVersion = 3
+)
+
+// bleveRepoData the document stored in the bleve repo index
+type bleveRepoData struct {
+	RepoID   int64
+	Ref      string
+	Content  string
+	Filename string
+	Language string
+}
+
+// Type returns the document type, for bleve's mapping.Classifier interface.
+func (d *bleveRepoData) Type() string {
+	return repoIndexerDocType
+}
+
+// BleveRepoIndexer implements RepoIndexer with a local bleve index
+type BleveRepoIndexer struct {
+	indexDir string
+	lock     sync.RWMutex
+	index    bleve.Index
+}
+
+// NewBleveRepoIndexer creates a new bleve repo indexer stored in the given directory
+func NewBleveRepoIndexer(indexDir string) *BleveRepoIndexer {
+	return &BleveRepoIndexer{
+		indexDir: indexDir,
+	}
+}
+
+// Init opens the bleve index, creating it if it does not exist yet
+func (b *BleveRepoIndexer) Init() (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var err error
+	b.index, err = openIndexer(b.indexDir, repoIndexerLatestVersion)
+	if err != nil {
+		return false, err
+	}
+	if b.index != nil {
+		return true, nil
+	}
+	b.index, err = createRepoIndexer(b.indexDir, repoIndexerLatestVersion)
+	return false, err
+}
+
+// createRepoIndexer create a repo indexer if one does not already exist
+func createRepoIndexer(path string, latestVersion int) (bleve.Index, error) {
+	docMapping := bleve.NewDocumentMapping()
+	numericFieldMapping := bleve.NewNumericFieldMapping()
+	numericFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("RepoID", numericFieldMapping)
+
+	refFieldMapping := bleve.NewTextFieldMapping()
+	refFieldMapping.Analyzer = keyword.Name
+	refFieldMapping.Store = false
+	refFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Ref", refFieldMapping)
+
+	textFieldMapping := bleve.NewTextFieldMapping()
+	textFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Content", textFieldMapping)
+
+	filenameFieldMapping := bleve.NewTextFieldMapping()
+	filenameFieldMapping.Analyzer = keyword.Name
+	filenameFieldMapping.Store = false
+	filenameFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Filename", filenameFieldMapping)
+
+	languageFieldMapping := bleve.NewTextFieldMapping()
+	languageFieldMapping.Analyzer = keyword.Name
+	languageFieldMapping.IncludeInAll = false
+	docMapping.AddFieldMappingsAt("Language", languageFieldMapping)
+
+	mapping := bleve.NewIndexMapping()
+	if err := addUnicodeNormalizeTokenFilter(mapping); err != nil {
+		return nil, err
+	} else if err = mapping.AddCustomAnalyzer(repoIndexerAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"char_filters":  []string{},
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{unicodeNormalizeName, camelcase.Name, lowercase.Name, unique.Name},
+	}); err != nil {
+		return nil, err
+	}
+	mapping.DefaultAnalyzer = repoIndexerAnalyzer
+	mapping.AddDocumentMapping(repoIndexerDocType, docMapping)
+	mapping.AddDocumentMapping("_all", bleve.NewDocumentDisabledMapping())
+
+	index, err := bleve.New(path, mapping)
+	if err != nil {
+		return nil, err
+	}
+	return index, rupture.WriteIndexMetadata(path, &rupture.IndexMetadata{
+		Version: latestVersion,
+	})
+}
+
+// Index applies the given updates to the index
+func (b *BleveRepoIndexer) Index(updates []RepoIndexerUpdate) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	batch := rupture.NewFlushingBatch(b.index, maxBatchSize)
+	for _, update := range updates {
+		id := filenameIndexerID(update.Data.RepoID, update.Data.Ref, update.Filepath)
+		var err error
+		switch update.Op {
+		case RepoIndexerOpUpdate:
+			err = batch.Index(id, &bleveRepoData{
+				RepoID:   update.Data.RepoID,
+				Ref:      indexerRef(update.Data.Ref),
+				Content:  update.Data.Content,
+				Filename: update.Filepath,
+				Language: analyze.GetCodeLanguage(update.Filepath),
+			})
+		case RepoIndexerOpDelete:
+			err = batch.Delete(id)
+		default:
+			log.Error(4, "Unrecognized repo indexer op: %d", update.Op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// DeleteRepo removes all of a repository's files from the index
+func (b *BleveRepoIndexer) DeleteRepo(repoID int64) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.deleteMatching(numericEqualityQuery(repoID, "RepoID"))
+}
+
+// DeleteRef removes the files of one of a repository's refs from the index
+func (b *BleveRepoIndexer) DeleteRef(repoID int64, ref string) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.deleteMatching(bleve.NewConjunctionQuery(
+		numericEqualityQuery(repoID, "RepoID"),
+		refQuery(ref),
+	))
+}
+
+// deleteMatching removes the files matching the query from the index
+func (b *BleveRepoIndexer) deleteMatching(query query.Query) error {
+	searchRequest := bleve.NewSearchRequestOptions(query, 2147483647, 0, false)
+	result, err := b.index.Search(searchRequest)
+	if err != nil {
+		return err
+	}
+	batch := rupture.NewFlushingBatch(b.index, maxBatchSize)
+	for _, hit := range result.Hits {
+		if err = batch.Delete(hit.ID); err != nil {
+			return err
+		}
+	}
+	return batch.Flush()
+}
+
+// refQuery returns the query matching the files of the given ref
+func refQuery(ref string) query.Query {
+	refQuery := bleve.NewTermQuery(indexerRef(ref))
+	refQuery.SetField("Ref")
+	return refQuery
+}
+
+// Search searches for files matching the search options.
+// Returns the matching file-paths
+func (b *BleveRepoIndexer) Search(opts *RepoSearchOptions) (int64, []*RepoSearchResult, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	phraseQuery := bleve.NewMatchPhraseQuery(opts.Keyword)
+	phraseQuery.FieldVal = "Content"
+	phraseQuery.Analyzer = repoIndexerAnalyzer
+
+	indexerQuery := bleve.NewConjunctionQuery(phraseQuery, refQuery(opts.Ref))
+	if len(opts.RepoIDs) > 0 {
+		var repoQueries = make([]query.Query, 0, len(opts.RepoIDs))
+		for _, repoID := range opts.RepoIDs {
+			repoQueries = append(repoQueries, numericEqualityQuery(repoID, "RepoID"))
+		}
+		indexerQuery.AddQuery(bleve.NewDisjunctionQuery(repoQueries...))
+	}
+	if len(opts.Language) > 0 {
+		languageQuery := bleve.NewTermQuery(opts.Language)
+		languageQuery.SetField("Language")
+		indexerQuery.AddQuery(languageQuery)
+	}
+	if len(opts.PathPattern) > 0 {
+		pathQuery := bleve.NewWildcardQuery(pathPatternWildcard(opts.PathPattern))
+		pathQuery.SetField("Filename")
+		indexerQuery.AddQuery(pathQuery)
+	}
+
+	from := (opts.Page - 1) * opts.PageSize
+	searchRequest := bleve.NewSearchRequestOptions(indexerQuery, opts.PageSize, from, false)
+	searchRequest.Fields = []string{"Content", "RepoID", "Language"}
+	searchRequest.IncludeLocations = true
+
+	result, err := b.index.Search(searchRequest)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	searchResults := make([]*RepoSearchResult, len(result.Hits))
+	for i, hit := range result.Hits {
+		var startIndex, endIndex int = -1, -1
+		for _, locations := range hit.Locations["Content"] {
+			location := locations[0]
+			locationStart := int(location.Start)
+			locationEnd := int(location.End)
+			if startIndex < 0 || locationStart < startIndex {
+				startIndex = locationStart
+			}
+			if endIndex < 0 || locationEnd > endIndex {
+				endIndex = locationEnd
+			}
+		}
+		language, _ := hit.Fields["Language"].(string)
+		searchResults[i] = &RepoSearchResult{
+			RepoID:     int64(hit.Fields["RepoID"].(float64)),
+			StartIndex: startIndex,
+			EndIndex:   endIndex,
+			Filename:   filenameOfIndexerID(hit.ID),
+			Language:   language,
+			Content:    hit.Fields["Content"].(string),
+		}
+	}
+	return int64(result.Total), searchResults, nil
+}
+
+// Reset removes the bleve index and creates an empty one
+func (b *BleveRepoIndexer) Reset() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.index != nil {
+		if err := b.index.Close(); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(b.indexDir); err != nil {
+		return err
+	}
+	var err error
+	b.index, err = createRepoIndexer(b.indexDir, repoIndexerLatestVersion)
+	return err
+}
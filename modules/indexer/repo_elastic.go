@@ -0,0 +1,252 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package indexer
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"code.gitea.io/gitea/modules/analyze"
+	"code.gitea.io/gitea/modules/log"
+)
+
+const (
+	elasticHighlightPreTag  = "<gitea-hl>"
+	elasticHighlightPostTag = "</gitea-hl>"
+)
+
+// elasticRepoData the document stored in the Elasticsearch repo index
+type elasticRepoData struct {
+	RepoID   int64  `json:"repo_id"`
+	Ref      string `json:"ref"`
+	Content  string `json:"content"`
+	Filename string `json:"filename"`
+	Language string `json:"language"`
+}
+
+const elasticRepoIndexerLatestVersion = 3
+
+var elasticRepoIndexDefinition = elasticIndexDefinition(elasticRepoIndexerLatestVersion, map[string]interface{}{
+	"analysis": map[string]interface{}{
+		"analyzer": map[string]interface{}{
+			"code": map[string]interface{}{
+				"type":      "custom",
+				"tokenizer": "standard",
+				"filter":    []string{"word_delimiter_graph", "lowercase"},
+			},
+		},
+	},
+}, map[string]interface{}{
+	"repo_id": map[string]interface{}{"type": "long"},
+	"ref":     map[string]interface{}{"type": "keyword"},
+	"content": map[string]interface{}{
+		"type":     "text",
+		"analyzer": "code",
+	},
+	"filename": map[string]interface{}{"type": "keyword"},
+	"language": map[string]interface{}{"type": "keyword"},
+})
+
+// ElasticSearchRepoIndexer implements RepoIndexer with an Elasticsearch index
+type ElasticSearchRepoIndexer struct {
+	connStr   string
+	indexName string
+	lock      sync.RWMutex
+	client    *elasticClient
+}
+
+// NewElasticSearchRepoIndexer creates a new Elasticsearch repo indexer
+// storing the files in the given index of the server at connStr
+func NewElasticSearchRepoIndexer(connStr, indexName string) *ElasticSearchRepoIndexer {
+	return &ElasticSearchRepoIndexer{
+		connStr:   connStr,
+		indexName: indexName,
+	}
+}
+
+// Init connects to the server and creates the index if needed
+func (b *ElasticSearchRepoIndexer) Init() (bool, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	var err error
+	b.client, err = newElasticClient(b.connStr, b.indexName)
+	if err != nil {
+		return false, err
+	}
+	return b.client.initIndex(elasticRepoIndexDefinition, elasticRepoIndexerLatestVersion)
+}
+
+// Index applies the given updates to the index
+func (b *ElasticSearchRepoIndexer) Index(updates []RepoIndexerUpdate) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	ops := make([]elasticBulkOp, 0, len(updates))
+	for _, update := range updates {
+		id := filenameIndexerID(update.Data.RepoID, update.Data.Ref, update.Filepath)
+		switch update.Op {
+		case RepoIndexerOpUpdate:
+			ops = append(ops, elasticBulkOp{
+				id: id,
+				doc: &elasticRepoData{
+					RepoID:   update.Data.RepoID,
+					Ref:      indexerRef(update.Data.Ref),
+					Content:  update.Data.Content,
+					Filename: update.Filepath,
+					Language: analyze.GetCodeLanguage(update.Filepath),
+				},
+			})
+		case RepoIndexerOpDelete:
+			ops = append(ops, elasticBulkOp{id: id})
+		default:
+			log.Error(4, "Unrecognized repo indexer op: %d", update.Op)
+		}
+	}
+	return b.client.bulk(ops)
+}
+
+// DeleteRepo removes all of a repository's files from the index
+func (b *ElasticSearchRepoIndexer) DeleteRepo(repoID int64) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.client.deleteByQuery(map[string]interface{}{
+		"term": map[string]interface{}{"repo_id": repoID},
+	})
+}
+
+// DeleteRef removes the files of one of a repository's refs from the index
+func (b *ElasticSearchRepoIndexer) DeleteRef(repoID int64, ref string) error {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	return b.client.deleteByQuery(map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{"repo_id": repoID}},
+				map[string]interface{}{"term": map[string]interface{}{"ref": indexerRef(ref)}},
+			},
+		},
+	})
+}
+
+// Search searches for files matching the search options.
+// Returns the matching file-paths
+func (b *ElasticSearchRepoIndexer) Search(opts *RepoSearchOptions) (int64, []*RepoSearchResult, error) {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	filters := make([]interface{}, 0, 4)
+	filters = append(filters, map[string]interface{}{
+		"term": map[string]interface{}{"ref": indexerRef(opts.Ref)},
+	})
+	if len(opts.RepoIDs) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{"repo_id": opts.RepoIDs},
+		})
+	}
+	if len(opts.Language) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"term": map[string]interface{}{"language": opts.Language},
+		})
+	}
+	if len(opts.PathPattern) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"wildcard": map[string]interface{}{"filename": pathPatternWildcard(opts.PathPattern)},
+		})
+	}
+	boolQuery := map[string]interface{}{
+		"must": map[string]interface{}{
+			"match_phrase": map[string]interface{}{"content": opts.Keyword},
+		},
+		"filter": filters,
+	}
+
+	resp, err := b.client.search(map[string]interface{}{
+		"query": map[string]interface{}{"bool": boolQuery},
+		"highlight": map[string]interface{}{
+			"fields": map[string]interface{}{
+				"content": map[string]interface{}{
+					"number_of_fragments": 0,
+					"pre_tags":            []string{elasticHighlightPreTag},
+					"post_tags":           []string{elasticHighlightPostTag},
+				},
+			},
+		},
+		"from":             (opts.Page - 1) * opts.PageSize,
+		"size":             opts.PageSize,
+		"track_total_hits": true,
+	})
+	if err != nil {
+		return 0, nil, err
+	}
+	total, err := resp.TotalHits()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	searchResults := make([]*RepoSearchResult, len(resp.Hits.Hits))
+	for i, hit := range resp.Hits.Hits {
+		var data elasticRepoData
+		if err = json.Unmarshal(hit.Source, &data); err != nil {
+			return 0, nil, err
+		}
+		// a hit without highlighted terms (e.g. a file too large to be
+		// analyzed by the highlighter) is shown from the top of the file
+		startIndex, endIndex := 0, 0
+		if highlights := hit.Highlight["content"]; len(highlights) > 0 {
+			if start, end := highlightBounds(highlights[0]); start >= 0 {
+				startIndex, endIndex = start, end
+			}
+		}
+		searchResults[i] = &RepoSearchResult{
+			RepoID:     data.RepoID,
+			StartIndex: startIndex,
+			EndIndex:   endIndex,
+			Filename:   filenameOfIndexerID(hit.ID),
+			Language:   data.Language,
+			Content:    data.Content,
+		}
+	}
+	return total, searchResults, nil
+}
+
+// highlightBounds returns the offsets, in the original content, of the start
+// of the first highlighted term and of the end of the last one
+func highlightBounds(highlighted string) (startIndex, endIndex int) {
+	startIndex, endIndex = -1, -1
+	offset := 0
+	for {
+		pre := strings.Index(highlighted, elasticHighlightPreTag)
+		if pre < 0 {
+			return startIndex, endIndex
+		}
+		offset += pre
+		highlighted = highlighted[pre+len(elasticHighlightPreTag):]
+		post := strings.Index(highlighted, elasticHighlightPostTag)
+		if post < 0 {
+			return startIndex, endIndex
+		}
+		if startIndex < 0 {
+			startIndex = offset
+		}
+		offset += post
+		endIndex = offset
+		highlighted = highlighted[post+len(elasticHighlightPostTag):]
+	}
+}
+
+// Reset deletes the index and creates an empty one
+func (b *ElasticSearchRepoIndexer) Reset() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if err := b.client.deleteIndex(); err != nil {
+		return err
+	}
+	return b.client.createIndex(elasticRepoIndexDefinition)
+}
@@ -0,0 +1,54 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package lfs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentStore_Put(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lfs-content-store")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	local, err := storage.NewLocalStorage(dir)
+	assert.NoError(t, err)
+	s := &ContentStore{ObjectStorage: local}
+
+	content := "Hello, World!\n"
+	meta := &models.LFSMetaObject{
+		Oid:  "c98c24b677eff44860afea6f493bbaec5bb1c4cbb209c6fc2bbb47f66ff2ad31",
+		Size: int64(len(content)),
+	}
+	assert.NoError(t, s.Put(meta, strings.NewReader(content)))
+
+	// an invalid upload does not replace nor delete the existing object
+	assert.Equal(t, errHashMismatch, s.Put(meta, strings.NewReader("Hello, Worle!\n")))
+	assert.Equal(t, errSizeMismatch, s.Put(meta, strings.NewReader("Hello")))
+	assert.Equal(t, errSizeMismatch, s.Put(meta, strings.NewReader(content+content)))
+
+	f, err := s.Get(meta, 0)
+	assert.NoError(t, err)
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	// an invalid object is not stored
+	invalid := &models.LFSMetaObject{
+		Oid:  "0000000000000000000000000000000000000000000000000000000000000000",
+		Size: int64(len(content)),
+	}
+	assert.Error(t, s.Put(invalid, strings.NewReader(content)))
+	assert.False(t, s.Exists(invalid))
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"io"
+)
+
+// Downloader downloads the data of a repository from a site
+type Downloader interface {
+	GetRepoInfo() (*Repository, error)
+	GetMilestones() ([]*Milestone, error)
+	GetLabels() ([]*Label, error)
+	GetReleases() ([]*Release, error)
+	// OpenAsset opens the content of a release asset for reading
+	OpenAsset(asset *ReleaseAsset) (io.ReadCloser, error)
+	// GetIssues returns a page of issues, sorted by number, and whether it is the last page
+	GetIssues(page, perPage int) ([]*Issue, bool, error)
+	GetComments(issueNumber int64) ([]*Comment, error)
+	// GetPullRequests returns a page of pull requests, sorted by number, and whether it is the last page
+	GetPullRequests(page, perPage int) ([]*PullRequest, bool, error)
+}
+
+// DownloaderFactory creates the downloaders of the repositories of a site
+type DownloaderFactory interface {
+	// Match returns true if the repository to migrate is hosted on the site
+	Match(opts MigrateOptions) (bool, error)
+	New(opts MigrateOptions) (Downloader, error)
+}
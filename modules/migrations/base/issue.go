@@ -0,0 +1,42 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"time"
+)
+
+// Reaction defines a reaction to an issue or a comment, its content is the
+// name of the emoji as used by Gitea, e.g. +1 or heart
+type Reaction struct {
+	UserName  string `json:"user_name"`
+	UserEmail string `json:"user_email"`
+	Content   string `json:"content"`
+}
+
+// Issue defines an issue of a repository
+type Issue struct {
+	Number      int64       `json:"number"`
+	PosterName  string      `json:"poster_name"`
+	PosterEmail string      `json:"poster_email"`
+	Title       string      `json:"title"`
+	Content     string      `json:"content"`
+	Milestone   string      `json:"milestone"`
+	State       string      `json:"state"` // open or closed
+	Created     time.Time   `json:"created"`
+	Closed      *time.Time  `json:"closed"`
+	Labels      []*Label    `json:"labels"`
+	Reactions   []*Reaction `json:"reactions"`
+}
+
+// Comment defines a comment of an issue or a pull request
+type Comment struct {
+	IssueNumber int64       `json:"issue_number"`
+	PosterName  string      `json:"poster_name"`
+	PosterEmail string      `json:"poster_email"`
+	Created     time.Time   `json:"created"`
+	Content     string      `json:"content"`
+	Reactions   []*Reaction `json:"reactions"`
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+// MigrateOptions defines the way a repository gets migrated
+type MigrateOptions struct {
+	// RemoteURL is the URL of the repository, it may contain the credentials
+	// which are used to clone it
+	RemoteURL    string
+	AuthUsername string
+	AuthPassword string
+
+	Name        string
+	Description string
+	Private     bool
+	Mirror      bool
+
+	Milestones   bool
+	Labels       bool
+	Issues       bool
+	Comments     bool
+	PullRequests bool
+	Releases     bool
+}
+
+// WithItems returns true if any item besides the git data is migrated
+func (opts MigrateOptions) WithItems() bool {
+	return opts.Milestones || opts.Labels || opts.Issues || opts.PullRequests || opts.Releases
+}
@@ -0,0 +1,42 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"time"
+)
+
+// PullRequestBranch defines the head or the base of a pull request
+type PullRequestBranch struct {
+	Ref       string `json:"ref"`
+	SHA       string `json:"sha"`
+	OwnerName string `json:"owner_name"`
+	RepoName  string `json:"repo_name"`
+}
+
+// PullRequest defines a pull request of a repository
+type PullRequest struct {
+	Number         int64             `json:"number"`
+	Title          string            `json:"title"`
+	PosterName     string            `json:"poster_name"`
+	PosterEmail    string            `json:"poster_email"`
+	Content        string            `json:"content"`
+	Milestone      string            `json:"milestone"`
+	State          string            `json:"state"` // open or closed
+	Created        time.Time         `json:"created"`
+	Closed         *time.Time        `json:"closed"`
+	Labels         []*Label          `json:"labels"`
+	Merged         bool              `json:"merged"`
+	MergedTime     *time.Time        `json:"merged_time"`
+	MergeCommitSHA string            `json:"merge_commit_sha"`
+	Head           PullRequestBranch `json:"head"`
+	Base           PullRequestBranch `json:"base"`
+	Reactions      []*Reaction       `json:"reactions"`
+}
+
+// IsForkPullRequest returns true if the head of the pull request is in another repository
+func (p *PullRequest) IsForkPullRequest() bool {
+	return p.Head.OwnerName != p.Base.OwnerName || p.Head.RepoName != p.Base.RepoName
+}
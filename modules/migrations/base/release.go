@@ -0,0 +1,34 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"time"
+)
+
+// ReleaseAsset defines a file attached to a release
+type ReleaseAsset struct {
+	Name          string    `json:"name"`
+	Size          int64     `json:"size"`
+	DownloadCount int64     `json:"download_count"`
+	Created       time.Time `json:"created"`
+	// DownloadURL is the URL or, for dumps, the path relative to the dump
+	// directory of the content of the asset
+	DownloadURL string `json:"download_url"`
+}
+
+// Release defines a release of a repository
+type Release struct {
+	TagName         string          `json:"tag_name"`
+	TargetCommitish string          `json:"target_commitish"`
+	Name            string          `json:"name"`
+	Body            string          `json:"body"`
+	Draft           bool            `json:"draft"`
+	Prerelease      bool            `json:"prerelease"`
+	PublisherName   string          `json:"publisher_name"`
+	PublisherEmail  string          `json:"publisher_email"`
+	Assets          []*ReleaseAsset `json:"assets"`
+	Created         time.Time       `json:"created"`
+}
@@ -0,0 +1,38 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+import (
+	"time"
+)
+
+// Repository defines a repository to migrate
+type Repository struct {
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	Description string `json:"description"`
+	IsPrivate   bool   `json:"is_private"`
+	OriginalURL string `json:"original_url"`
+	// CloneURL is the URL or the local path the git data is cloned from
+	CloneURL string `json:"clone_url"`
+}
+
+// Label defines a label of a repository
+type Label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// Milestone defines a milestone of a repository
+type Milestone struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Deadline    *time.Time `json:"deadline"`
+	Created     time.Time  `json:"created"`
+	Closed      *time.Time `json:"closed"`
+	// State is either open or closed
+	State string `json:"state"`
+}
@@ -0,0 +1,20 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package base
+
+// Uploader stores the downloaded data of a repository
+type Uploader interface {
+	CreateRepo(repo *Repository, opts MigrateOptions) error
+	CreateMilestones(milestones ...*Milestone) error
+	CreateLabels(labels ...*Label) error
+	CreateReleases(downloader Downloader, releases ...*Release) error
+	CreateIssues(issues ...*Issue) error
+	CreateComments(comments ...*Comment) error
+	CreatePullRequests(prs ...*PullRequest) error
+	// Finish is called once all the data has been stored
+	Finish() error
+	// Rollback removes everything which was stored after a failure
+	Rollback() error
+}
@@ -0,0 +1,178 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// restClient is a minimal client of the JSON REST APIs of the sites
+// repositories are migrated from. The credentials, set in auth or with the
+// username and the password, are only sent to the site of the base URL.
+type restClient struct {
+	baseURL  string
+	header   http.Header
+	auth     http.Header
+	username string
+	password string
+	client   *http.Client
+}
+
+func newRestClient(baseURL string) *restClient {
+	c := &restClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		header:  make(http.Header),
+		auth:    make(http.Header),
+	}
+	c.client = &http.Client{
+		Timeout:       5 * time.Minute,
+		CheckRedirect: c.checkRedirect,
+	}
+	return c
+}
+
+// isBaseSite returns true if the URL has the scheme and the host of the base URL
+func (c *restClient) isBaseSite(u *url.URL) bool {
+	baseURL, err := url.Parse(c.baseURL)
+	return err == nil && strings.EqualFold(u.Scheme, baseURL.Scheme) && strings.EqualFold(u.Host, baseURL.Host)
+}
+
+// privateNetworks the networks, besides the loopback and link-local ones,
+// which are not reachable from the internet
+var privateNetworks = func() []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// isPublicIP returns true if the IP address is reachable from the internet
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// errExternalURLNotAllowed is returned for the URLs outside of the site of the
+// base URL which can not be requested
+var errExternalURLNotAllowed = errors.New("URL is not allowed")
+
+// checkExternalURL returns an error unless the URL, which is outside of the
+// site of the base URL, is a HTTP(S) URL whose host only has public addresses
+func checkExternalURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%s: %v", u, errExternalURLNotAllowed)
+	}
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return fmt.Errorf("%s: %v", u, errExternalURLNotAllowed)
+		}
+	}
+	return nil
+}
+
+// checkRedirect removes the credentials from the requests redirected outside
+// of the site of the base URL, and checks their URL
+func (c *restClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	if c.isBaseSite(req.URL) {
+		return nil
+	}
+	for k := range c.auth {
+		req.Header.Del(k)
+	}
+	req.Header.Del("Authorization")
+	return checkExternalURL(req.URL)
+}
+
+// APIError is returned when a site responds with an error status
+type APIError struct {
+	URL     string
+	Status  int
+	Message string
+}
+
+func (err *APIError) Error() string {
+	return fmt.Sprintf("%s: %d %s", err.URL, err.Status, err.Message)
+}
+
+// open requests the given URL, which is relative to the base URL unless it
+// is absolute, and returns the body of a successful response. The URLs
+// outside of the site of the base URL are requested without credentials, and
+// only if they are public.
+func (c *restClient) open(link string, header http.Header) (io.ReadCloser, error) {
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		link = c.baseURL + link
+	}
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range c.header {
+		req.Header[k] = v
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if c.isBaseSite(req.URL) {
+		for k, v := range c.auth {
+			req.Header[k] = v
+		}
+		if len(c.username) > 0 {
+			req.SetBasicAuth(c.username, c.password)
+		}
+	} else if err = checkExternalURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &APIError{URL: link, Status: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return resp.Body, nil
+}
+
+// getJSON decodes the response to the given URL into v
+func (c *restClient) getJSON(url string, v interface{}) error {
+	return c.getJSONWithHeader(url, nil, v)
+}
+
+func (c *restClient) getJSONWithHeader(url string, header http.Header, v interface{}) error {
+	body, err := c.open(url, header)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(v)
+}
@@ -0,0 +1,81 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"140.82.112.3":  true,
+		"2606:4700::1":  true,
+		"127.0.0.1":     false,
+		"::1":           false,
+		"0.0.0.0":       false,
+		"10.1.2.3":      false,
+		"172.20.0.1":    false,
+		"192.168.1.1":   false,
+		"169.254.0.1":   false,
+		"fd00::1":       false,
+		"fe80::1":       false,
+		"100.64.0.1":    false,
+		"172.32.0.1":    true,
+		"192.169.0.1":   true,
+		"::ffff:7f00:1": false,
+	} {
+		assert.Equal(t, public, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestRestClient_open(t *testing.T) {
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("PRIVATE-TOKEN")
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, r.URL.Query().Get("to"), http.StatusFound)
+			return
+		}
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	requested := false
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer internal.Close()
+
+	c := newRestClient(server.URL)
+	c.auth.Set("PRIVATE-TOKEN", "token")
+
+	// the credentials are sent to the site of the base URL
+	for _, link := range []string{"/file", server.URL + "/file"} {
+		token = ""
+		rc, err := c.open(link, nil)
+		if assert.NoError(t, err, link) {
+			data, err := ioutil.ReadAll(rc)
+			rc.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, "data", string(data))
+		}
+		assert.Equal(t, "token", token, link)
+	}
+
+	// the other sites can not be internal, even through a redirect
+	internalURL := strings.Replace(internal.URL, "127.0.0.1", "localhost", 1)
+	for _, link := range []string{internalURL, server.URL + "/redirect?to=" + internalURL} {
+		_, err := c.open(link, nil)
+		assert.Error(t, err, link)
+	}
+	assert.False(t, requested)
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"github.com/Unknwon/com"
+)
+
+// DumpFileName is the name of the file describing the items of a migration dump
+const DumpFileName = "migration.json"
+
+var (
+	_ base.Downloader        = &DumpDownloader{}
+	_ base.DownloaderFactory = &DumpDownloaderFactory{}
+)
+
+// DumpDownloaderFactory defines a factory of downloaders reading a local
+// directory which contains a migration dump
+type DumpDownloaderFactory struct{}
+
+// dumpDir returns the local directory of the remote URL
+func dumpDir(remoteURL string) string {
+	if strings.HasPrefix(remoteURL, "file://") {
+		if u, err := url.Parse(remoteURL); err == nil {
+			return u.Path
+		}
+	}
+	return remoteURL
+}
+
+// Match returns true if the migration remote URL is a directory containing a dump
+func (f *DumpDownloaderFactory) Match(opts base.MigrateOptions) (bool, error) {
+	dir := dumpDir(opts.RemoteURL)
+	if !filepath.IsAbs(dir) {
+		return false, nil
+	}
+	return com.IsFile(filepath.Join(dir, DumpFileName)), nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *DumpDownloaderFactory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	return NewDumpDownloader(dumpDir(opts.RemoteURL))
+}
+
+// Dump defines the content of the migration.json file of a migration dump.
+// The git repository is cloned from the clone URL, which is a path relative to
+// the directory of the dump, and so are the download URLs of release assets.
+// Paths which lead outside of the directory are rejected.
+type Dump struct {
+	Repository   *base.Repository          `json:"repository"`
+	Milestones   []*base.Milestone         `json:"milestones"`
+	Labels       []*base.Label             `json:"labels"`
+	Releases     []*base.Release           `json:"releases"`
+	Issues       []*base.Issue             `json:"issues"`
+	Comments     map[int64][]*base.Comment `json:"comments"`
+	PullRequests []*base.PullRequest       `json:"pull_requests"`
+}
+
+// DumpDownloader implements a Downloader interface to read a migration dump
+type DumpDownloader struct {
+	dir  string
+	dump Dump
+}
+
+// NewDumpDownloader creates a downloader of the migration dump in the given directory
+func NewDumpDownloader(dir string) (*DumpDownloader, error) {
+	f, err := os.Open(filepath.Join(dir, DumpFileName))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	d := &DumpDownloader{dir: dir}
+	if err = json.NewDecoder(f).Decode(&d.dump); err != nil {
+		return nil, err
+	}
+	if d.dump.Repository == nil {
+		d.dump.Repository = new(base.Repository)
+	}
+	return d, nil
+}
+
+// path returns the local path of a file of the dump, making sure it does not
+// lead outside of the directory of the dump, even through symbolic links
+func (d *DumpDownloader) path(p string) (string, error) {
+	if filepath.IsAbs(p) || strings.Contains(p, "://") {
+		return "", fmt.Errorf("path %q is not relative to the dump directory", p)
+	}
+	dir, err := filepath.EvalSymlinks(d.dir)
+	if err != nil {
+		return "", err
+	}
+	full, err := filepath.EvalSymlinks(filepath.Join(dir, p))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(dir, full)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside of the dump directory", p)
+	}
+	return full, nil
+}
+
+// GetRepoInfo returns a repository information
+func (d *DumpDownloader) GetRepoInfo() (*base.Repository, error) {
+	repo := *d.dump.Repository
+	if len(repo.CloneURL) == 0 {
+		repo.CloneURL = "repo.git"
+	}
+	cloneURL, err := d.path(repo.CloneURL)
+	if err != nil {
+		return nil, err
+	}
+	repo.CloneURL = cloneURL
+	return &repo, nil
+}
+
+// GetMilestones returns milestones
+func (d *DumpDownloader) GetMilestones() ([]*base.Milestone, error) {
+	return d.dump.Milestones, nil
+}
+
+// GetLabels returns labels
+func (d *DumpDownloader) GetLabels() ([]*base.Label, error) {
+	return d.dump.Labels, nil
+}
+
+// GetReleases returns releases
+func (d *DumpDownloader) GetReleases() ([]*base.Release, error) {
+	return d.dump.Releases, nil
+}
+
+// OpenAsset opens the file of a release asset in the dump
+func (d *DumpDownloader) OpenAsset(asset *base.ReleaseAsset) (io.ReadCloser, error) {
+	p, err := d.path(asset.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(p)
+}
+
+// GetIssues returns issues according page and perPage
+func (d *DumpDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	start, end := paginate(len(d.dump.Issues), page, perPage)
+	return d.dump.Issues[start:end], end == len(d.dump.Issues), nil
+}
+
+// GetComments returns comments according issueNumber
+func (d *DumpDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	comments := d.dump.Comments[issueNumber]
+	for _, comment := range comments {
+		comment.IssueNumber = issueNumber
+	}
+	return comments, nil
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (d *DumpDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	start, end := paginate(len(d.dump.PullRequests), page, perPage)
+	return d.dump.PullRequests[start:end], end == len(d.dump.PullRequests), nil
+}
+
+// paginate returns the bounds of the given page of a slice
+func paginate(length, page, perPage int) (int, int) {
+	start := (page - 1) * perPage
+	if start > length {
+		start = length
+	}
+	end := start + perPage
+	if end > length {
+		end = length
+	}
+	return start, end
+}
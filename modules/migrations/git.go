@@ -0,0 +1,72 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"errors"
+	"io"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+)
+
+var _ base.Downloader = &PlainGitDownloader{}
+
+// PlainGitDownloader implements a Downloader interface to clone a git
+// repository without any other items
+type PlainGitDownloader struct {
+	repoName  string
+	remoteURL string
+}
+
+// NewPlainGitDownloader creates a git downloader
+func NewPlainGitDownloader(repoName, remoteURL string) *PlainGitDownloader {
+	return &PlainGitDownloader{
+		repoName:  repoName,
+		remoteURL: remoteURL,
+	}
+}
+
+// GetRepoInfo returns a repository information
+func (g *PlainGitDownloader) GetRepoInfo() (*base.Repository, error) {
+	return &base.Repository{
+		Name:     g.repoName,
+		CloneURL: g.remoteURL,
+	}, nil
+}
+
+// GetMilestones returns no milestones
+func (g *PlainGitDownloader) GetMilestones() ([]*base.Milestone, error) {
+	return nil, nil
+}
+
+// GetLabels returns no labels
+func (g *PlainGitDownloader) GetLabels() ([]*base.Label, error) {
+	return nil, nil
+}
+
+// GetReleases returns no releases
+func (g *PlainGitDownloader) GetReleases() ([]*base.Release, error) {
+	return nil, nil
+}
+
+// OpenAsset is not supported
+func (g *PlainGitDownloader) OpenAsset(asset *base.ReleaseAsset) (io.ReadCloser, error) {
+	return nil, errors.New("a git repository has no release assets")
+}
+
+// GetIssues returns no issues
+func (g *PlainGitDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	return nil, true, nil
+}
+
+// GetComments returns no comments
+func (g *PlainGitDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	return nil, nil
+}
+
+// GetPullRequests returns no pull requests
+func (g *PlainGitDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	return nil, true, nil
+}
@@ -0,0 +1,471 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"code.gitea.io/git"
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+	"code.gitea.io/gitea/modules/storage"
+	"code.gitea.io/gitea/modules/util"
+
+	gouuid "github.com/satori/go.uuid"
+)
+
+var _ base.Uploader = &GiteaLocalUploader{}
+
+// allowedReactions are the reactions which can be shown by Gitea
+var allowedReactions = map[string]bool{
+	"+1":       true,
+	"-1":       true,
+	"laugh":    true,
+	"confused": true,
+	"heart":    true,
+	"hooray":   true,
+}
+
+// GiteaLocalUploader implements an Uploader to store the migrated data in
+// this Gitea instance. Users are matched by their email addresses, the
+// migrating user becomes the poster of the items of the other users and the
+// original author is kept alongside.
+type GiteaLocalUploader struct {
+	doer     *models.User
+	owner    *models.User
+	repoName string
+	repo     *models.Repository
+	gitRepo  *git.Repository
+
+	milestones map[string]int64
+	labels     map[string]*models.Label
+	issues     map[int64]int64
+	users      map[string]int64
+	openPRs    []*models.PullRequest
+}
+
+// NewGiteaLocalUploader creates an uploader to migrate a repository to the given owner
+func NewGiteaLocalUploader(doer, owner *models.User, repoName string) *GiteaLocalUploader {
+	return &GiteaLocalUploader{
+		doer:       doer,
+		owner:      owner,
+		repoName:   repoName,
+		milestones: make(map[string]int64),
+		labels:     make(map[string]*models.Label),
+		issues:     make(map[int64]int64),
+		users:      make(map[string]int64),
+	}
+}
+
+// CreateRepo clones the git data and creates the repository
+func (g *GiteaLocalUploader) CreateRepo(repo *base.Repository, opts base.MigrateOptions) error {
+	description := opts.Description
+	if len(description) == 0 {
+		description = repo.Description
+	}
+
+	r, err := models.MigrateRepository(g.doer, g.owner, models.MigrateRepoOptions{
+		Name:        g.repoName,
+		Description: description,
+		IsPrivate:   opts.Private,
+		IsMirror:    opts.Mirror,
+		RemoteAddr:  repo.CloneURL,
+	})
+	// the repository is kept even on error so that it can be rolled back
+	g.repo = r
+	if err != nil {
+		return err
+	}
+
+	if !r.IsEmpty {
+		g.gitRepo, err = git.OpenRepository(r.RepoPath())
+	}
+	return err
+}
+
+// matchUser returns the ID of the user with the given email, or 0 if there is none
+func (g *GiteaLocalUploader) matchUser(email string) int64 {
+	email = strings.ToLower(email)
+	if len(email) == 0 {
+		return 0
+	}
+	id, ok := g.users[email]
+	if !ok {
+		if u, err := models.GetUserByEmail(email); err == nil {
+			id = u.ID
+		} else if !models.IsErrUserNotExist(err) {
+			log.Error(4, "GetUserByEmail: %v", err)
+		}
+		g.users[email] = id
+	}
+	return id
+}
+
+// userID returns the ID of the user with the given email and the name to
+// keep as the original author, which is empty if the user was found
+func (g *GiteaLocalUploader) userID(name, email string) (int64, string) {
+	if id := g.matchUser(email); id > 0 {
+		return id, ""
+	}
+	return g.doer.ID, name
+}
+
+func timeStamp(t *time.Time) util.TimeStamp {
+	if t == nil {
+		return 0
+	}
+	return util.TimeStamp(t.Unix())
+}
+
+// CreateMilestones creates milestones
+func (g *GiteaLocalUploader) CreateMilestones(milestones ...*base.Milestone) error {
+	defaultDeadline, _ := time.ParseInLocation("2006-01-02", "9999-12-31", time.Local)
+	ms := make([]*models.Milestone, 0, len(milestones))
+	for _, milestone := range milestones {
+		deadline := milestone.Deadline
+		if deadline == nil {
+			deadline = &defaultDeadline
+		}
+		ms = append(ms, &models.Milestone{
+			RepoID:         g.repo.ID,
+			Name:           milestone.Title,
+			Content:        milestone.Description,
+			IsClosed:       milestone.State == "closed",
+			DeadlineUnix:   timeStamp(deadline),
+			ClosedDateUnix: timeStamp(milestone.Closed),
+		})
+	}
+
+	if err := models.InsertMilestones(ms...); err != nil {
+		return err
+	}
+	for _, m := range ms {
+		g.milestones[m.Name] = m.ID
+	}
+	return nil
+}
+
+// CreateLabels creates labels
+func (g *GiteaLocalUploader) CreateLabels(labels ...*base.Label) error {
+	lbs := make([]*models.Label, 0, len(labels))
+	for _, label := range labels {
+		color := label.Color
+		if !strings.HasPrefix(color, "#") {
+			color = "#" + color
+		}
+		lbs = append(lbs, &models.Label{
+			RepoID:      g.repo.ID,
+			Name:        label.Name,
+			Description: label.Description,
+			Color:       color,
+		})
+	}
+
+	if err := models.NewLabels(lbs...); err != nil {
+		return err
+	}
+	for _, lb := range lbs {
+		g.labels[lb.Name] = lb
+	}
+	return nil
+}
+
+// CreateReleases creates releases and stores their assets as attachments
+func (g *GiteaLocalUploader) CreateReleases(downloader base.Downloader, releases ...*base.Release) (err error) {
+	rels := make([]*models.Release, 0, len(releases))
+	var saved []string
+	defer func() {
+		// remove the stored assets if the releases could not be created
+		if err != nil {
+			for _, p := range saved {
+				if errDelete := storage.Attachments.Delete(p); errDelete != nil {
+					log.Error(4, "Delete attachment %s: %v", p, errDelete)
+				}
+			}
+		}
+	}()
+
+	for _, release := range releases {
+		publisherID, _ := g.userID(release.PublisherName, release.PublisherEmail)
+		rel := &models.Release{
+			RepoID:       g.repo.ID,
+			PublisherID:  publisherID,
+			TagName:      release.TagName,
+			Target:       release.TargetCommitish,
+			Title:        release.Name,
+			Note:         release.Body,
+			IsDraft:      release.Draft,
+			IsPrerelease: release.Prerelease,
+			CreatedUnix:  util.TimeStamp(release.Created.Unix()),
+		}
+
+		// a release can only be published if its tag has been migrated
+		if g.gitRepo != nil && g.gitRepo.IsTagExist(release.TagName) {
+			commit, err := g.gitRepo.GetTagCommit(release.TagName)
+			if err != nil {
+				return fmt.Errorf("GetTagCommit[%s]: %v", release.TagName, err)
+			}
+			rel.Sha1 = commit.ID.String()
+			if rel.NumCommits, err = commit.CommitsCount(); err != nil {
+				return fmt.Errorf("CommitsCount: %v", err)
+			}
+		} else {
+			rel.IsDraft = true
+		}
+
+		for _, asset := range release.Assets {
+			attach, err := g.saveAsset(downloader, asset)
+			if err != nil {
+				return fmt.Errorf("asset %s of release %s: %v", asset.Name, release.TagName, err)
+			}
+			saved = append(saved, attach.RelativePath())
+			rel.Attachments = append(rel.Attachments, attach)
+		}
+		rels = append(rels, rel)
+	}
+
+	return models.InsertReleases(rels...)
+}
+
+func (g *GiteaLocalUploader) saveAsset(downloader base.Downloader, asset *base.ReleaseAsset) (*models.Attachment, error) {
+	rc, err := downloader.OpenAsset(asset)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	attach := &models.Attachment{
+		UUID:          gouuid.NewV4().String(),
+		Name:          asset.Name,
+		DownloadCount: asset.DownloadCount,
+		CreatedUnix:   util.TimeStamp(asset.Created.Unix()),
+	}
+	if attach.Size, err = storage.Attachments.Save(attach.RelativePath(), rc); err != nil {
+		return nil, err
+	}
+	return attach, nil
+}
+
+func (g *GiteaLocalUploader) convertReactions(reactions []*base.Reaction, created util.TimeStamp) []*models.Reaction {
+	result := make([]*models.Reaction, 0, len(reactions))
+	seen := make(map[string]bool)
+	for _, reaction := range reactions {
+		if !allowedReactions[reaction.Content] {
+			continue
+		}
+		// a reaction can not be attributed to someone else
+		userID := g.matchUser(reaction.UserEmail)
+		if userID == 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d", reaction.Content, userID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, &models.Reaction{
+			Type:        reaction.Content,
+			UserID:      userID,
+			CreatedUnix: created,
+		})
+	}
+	return result
+}
+
+func (g *GiteaLocalUploader) newIssue(number int64, title, content, posterName, posterEmail, milestone, state string,
+	created time.Time, closed *time.Time, labels []*base.Label, reactions []*base.Reaction) *models.Issue {
+	posterID, originalAuthor := g.userID(posterName, posterEmail)
+	issue := &models.Issue{
+		RepoID:         g.repo.ID,
+		Repo:           g.repo,
+		Index:          number,
+		PosterID:       posterID,
+		OriginalAuthor: originalAuthor,
+		Title:          title,
+		Content:        content,
+		MilestoneID:    g.milestones[milestone],
+		IsClosed:       state == "closed",
+		CreatedUnix:    util.TimeStamp(created.Unix()),
+		UpdatedUnix:    util.TimeStamp(created.Unix()),
+		ClosedUnix:     timeStamp(closed),
+		Reactions:      g.convertReactions(reactions, util.TimeStamp(created.Unix())),
+	}
+	if closed != nil {
+		issue.UpdatedUnix = timeStamp(closed)
+	}
+	for _, label := range labels {
+		if lb, ok := g.labels[label.Name]; ok {
+			issue.Labels = append(issue.Labels, lb)
+		}
+	}
+	return issue
+}
+
+// CreateIssues creates issues
+func (g *GiteaLocalUploader) CreateIssues(issues ...*base.Issue) error {
+	iss := make([]*models.Issue, 0, len(issues))
+	for _, issue := range issues {
+		iss = append(iss, g.newIssue(issue.Number, issue.Title, issue.Content, issue.PosterName, issue.PosterEmail,
+			issue.Milestone, issue.State, issue.Created, issue.Closed, issue.Labels, issue.Reactions))
+	}
+
+	if err := models.InsertIssues(iss...); err != nil {
+		return err
+	}
+	for _, issue := range iss {
+		g.issues[issue.Index] = issue.ID
+	}
+	return nil
+}
+
+// CreateComments creates comments of issues and pull requests
+func (g *GiteaLocalUploader) CreateComments(comments ...*base.Comment) error {
+	cms := make([]*models.Comment, 0, len(comments))
+	for _, comment := range comments {
+		issueID, ok := g.issues[comment.IssueNumber]
+		if !ok {
+			return fmt.Errorf("comment of unknown issue %d", comment.IssueNumber)
+		}
+		posterID, originalAuthor := g.userID(comment.PosterName, comment.PosterEmail)
+		created := util.TimeStamp(comment.Created.Unix())
+		cms = append(cms, &models.Comment{
+			Type:           models.CommentTypeComment,
+			IssueID:        issueID,
+			PosterID:       posterID,
+			OriginalAuthor: originalAuthor,
+			Content:        comment.Content,
+			CreatedUnix:    created,
+			UpdatedUnix:    created,
+			Reactions:      g.convertReactions(comment.Reactions, created),
+		})
+	}
+	return models.InsertIssueComments(cms)
+}
+
+// hasCommit returns true if the commit has been migrated
+func (g *GiteaLocalUploader) hasCommit(sha string) bool {
+	if len(sha) == 0 || g.gitRepo == nil {
+		return false
+	}
+	_, err := git.NewCommand("cat-file", "-e", sha+"^{commit}").RunInDir(g.gitRepo.Path)
+	return err == nil
+}
+
+// updateRef points the ref to the commit
+func (g *GiteaLocalUploader) updateRef(ref, sha string) error {
+	_, err := git.NewCommand("update-ref", ref, sha).RunInDir(g.gitRepo.Path)
+	return err
+}
+
+// CreatePullRequests creates pull requests, their head commits are kept in
+// the refs Gitea uses for pull requests and the heads of open pull requests
+// from forks are kept in branches named after the owner of the fork
+func (g *GiteaLocalUploader) CreatePullRequests(prs ...*base.PullRequest) error {
+	gprs := make([]*models.PullRequest, 0, len(prs))
+	for _, pr := range prs {
+		gpr, err := g.newPullRequest(pr)
+		if err != nil {
+			return fmt.Errorf("pull request %d: %v", pr.Number, err)
+		}
+		gprs = append(gprs, gpr)
+	}
+
+	if err := models.InsertPullRequests(gprs...); err != nil {
+		return err
+	}
+	for _, pr := range gprs {
+		g.issues[pr.Index] = pr.IssueID
+		if !pr.Issue.IsClosed {
+			g.openPRs = append(g.openPRs, pr)
+		}
+	}
+	return nil
+}
+
+func (g *GiteaLocalUploader) newPullRequest(pr *base.PullRequest) (*models.PullRequest, error) {
+	issue := g.newIssue(pr.Number, pr.Title, pr.Content, pr.PosterName, pr.PosterEmail,
+		pr.Milestone, pr.State, pr.Created, pr.Closed, pr.Labels, pr.Reactions)
+	issue.IsPull = true
+
+	headBranch := pr.Head.Ref
+	if pr.IsForkPullRequest() {
+		headBranch = pr.Head.OwnerName + "-" + pr.Head.Ref
+	}
+
+	merged := pr.Merged
+	if merged && pr.MergedTime == nil {
+		pr.MergedTime = pr.Closed
+	}
+	gpr := &models.PullRequest{
+		Type:           models.PullRequestGitea,
+		Status:         models.PullRequestStatusMergeable,
+		Issue:          issue,
+		HeadRepoID:     g.repo.ID,
+		BaseRepoID:     g.repo.ID,
+		HeadUserName:   g.owner.Name,
+		HeadBranch:     headBranch,
+		BaseBranch:     pr.Base.Ref,
+		MergeBase:      pr.Base.SHA,
+		HasMerged:      merged,
+		MergedCommitID: pr.MergeCommitSHA,
+		MergedUnix:     timeStamp(pr.MergedTime),
+	}
+	if merged {
+		gpr.MergerID = g.doer.ID
+	}
+	if !issue.IsClosed {
+		gpr.Status = models.PullRequestStatusChecking
+	}
+
+	if !g.hasCommit(pr.Head.SHA) {
+		return gpr, nil
+	}
+	if err := g.updateRef(fmt.Sprintf("refs/pull/%d/head", pr.Number), pr.Head.SHA); err != nil {
+		return nil, err
+	}
+	if !issue.IsClosed && !g.gitRepo.IsBranchExist(headBranch) {
+		if err := g.updateRef(git.BranchPrefix+headBranch, pr.Head.SHA); err != nil {
+			return nil, err
+		}
+	}
+	if g.hasCommit(pr.Base.SHA) {
+		mergeBase, err := git.NewCommand("merge-base", pr.Base.SHA, pr.Head.SHA).RunInDir(g.gitRepo.Path)
+		if err == nil {
+			gpr.MergeBase = strings.TrimSpace(mergeBase)
+		}
+	}
+	return gpr, nil
+}
+
+// Finish updates the counters of the repository and queues the open pull
+// requests for testing and the issues for indexing
+func (g *GiteaLocalUploader) Finish() error {
+	if err := models.UpdateRepoIssueCounters(g.repo.ID); err != nil {
+		return err
+	}
+
+	for _, pr := range g.openPRs {
+		if err := pr.UpdatePatch(); err != nil {
+			log.Error(4, "UpdatePatch[%d]: %v", pr.ID, err)
+			continue
+		}
+		pr.AddToTaskQueue()
+	}
+	for _, issueID := range g.issues {
+		models.UpdateIssueIndexer(issueID)
+	}
+	return nil
+}
+
+// Rollback deletes the repository and everything migrated to it
+func (g *GiteaLocalUploader) Rollback() error {
+	if g.repo == nil {
+		return nil
+	}
+	return models.DeleteRepository(g.doer, g.owner.ID, g.repo.ID)
+}
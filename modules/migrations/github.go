@@ -0,0 +1,468 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+)
+
+var (
+	_ base.Downloader        = &GithubDownloaderV3{}
+	_ base.DownloaderFactory = &GithubDownloaderV3Factory{}
+)
+
+// GithubDownloaderV3Factory defines a github downloader v3 factory
+type GithubDownloaderV3Factory struct{}
+
+// Match returns true if the migration remote URL matched this downloader factory
+func (f *GithubDownloaderV3Factory) Match(opts base.MigrateOptions) (bool, error) {
+	u, err := url.Parse(opts.RemoteURL)
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(u.Host, "github.com"), nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GithubDownloaderV3Factory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	owner, repo, err := parseRepoPath(opts.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	return NewGithubDownloaderV3("https://api.github.com", opts.AuthUsername, opts.AuthPassword, owner, repo, opts.RemoteURL), nil
+}
+
+// parseRepoPath returns the owner and the name of the repository of a clone URL
+func parseRepoPath(remoteURL string) (owner, repo string, err error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(fields) < 2 {
+		return "", "", fmt.Errorf("invalid repository URL: %s", u.Path)
+	}
+	return strings.Join(fields[:len(fields)-1], "/"), strings.TrimSuffix(fields[len(fields)-1], ".git"), nil
+}
+
+// GithubDownloaderV3 implements a Downloader interface to get repository informations
+// from github via APIv3
+type GithubDownloaderV3 struct {
+	client    *restClient
+	repoOwner string
+	repoName  string
+	remoteURL string
+	emails    map[string]string
+}
+
+// NewGithubDownloaderV3 creates a github Downloader via github v3 API. If the
+// username is empty, the password is used as a personal access token.
+func NewGithubDownloaderV3(baseURL, userName, password, repoOwner, repoName, remoteURL string) *GithubDownloaderV3 {
+	client := newRestClient(baseURL)
+	client.header.Set("Accept", "application/vnd.github.v3+json")
+	if len(userName) > 0 {
+		client.username = userName
+		client.password = password
+	} else if len(password) > 0 {
+		client.auth.Set("Authorization", "token "+password)
+	}
+
+	return &GithubDownloaderV3{
+		client:    client,
+		repoOwner: repoOwner,
+		repoName:  repoName,
+		remoteURL: remoteURL,
+		emails:    make(map[string]string),
+	}
+}
+
+func (g *GithubDownloaderV3) repoURL(format string, args ...interface{}) string {
+	return fmt.Sprintf("/repos/%s/%s", g.repoOwner, g.repoName) + fmt.Sprintf(format, args...)
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+	Email string `json:"email"`
+}
+
+// email returns the public email of the user
+func (g *GithubDownloaderV3) email(u *githubUser) string {
+	if u == nil {
+		return ""
+	}
+	email, ok := g.emails[u.Login]
+	if !ok {
+		var user githubUser
+		if err := g.client.getJSON("/users/"+url.PathEscape(u.Login), &user); err == nil {
+			email = user.Email
+		}
+		g.emails[u.Login] = email
+	}
+	return email
+}
+
+func (u *githubUser) login() string {
+	if u == nil {
+		return ""
+	}
+	return u.Login
+}
+
+// GetRepoInfo returns a repository information
+func (g *GithubDownloaderV3) GetRepoInfo() (*base.Repository, error) {
+	var repo struct {
+		Name        string     `json:"name"`
+		Owner       githubUser `json:"owner"`
+		Description string     `json:"description"`
+		Private     bool       `json:"private"`
+		HTMLURL     string     `json:"html_url"`
+	}
+	if err := g.client.getJSON(g.repoURL(""), &repo); err != nil {
+		return nil, err
+	}
+	return &base.Repository{
+		Name:        repo.Name,
+		Owner:       repo.Owner.Login,
+		Description: repo.Description,
+		IsPrivate:   repo.Private,
+		OriginalURL: repo.HTMLURL,
+		CloneURL:    g.remoteURL,
+	}, nil
+}
+
+// GetMilestones returns milestones
+func (g *GithubDownloaderV3) GetMilestones() ([]*base.Milestone, error) {
+	var milestones = make([]*base.Milestone, 0, 10)
+	for page := 1; ; page++ {
+		var ms []struct {
+			Title       string     `json:"title"`
+			Description string     `json:"description"`
+			State       string     `json:"state"`
+			DueOn       *time.Time `json:"due_on"`
+			CreatedAt   time.Time  `json:"created_at"`
+			ClosedAt    *time.Time `json:"closed_at"`
+		}
+		if err := g.client.getJSON(g.repoURL("/milestones?state=all&per_page=100&page=%d", page), &ms); err != nil {
+			return nil, err
+		}
+		for _, m := range ms {
+			milestones = append(milestones, &base.Milestone{
+				Title:       m.Title,
+				Description: m.Description,
+				Deadline:    m.DueOn,
+				Created:     m.CreatedAt,
+				Closed:      m.ClosedAt,
+				State:       m.State,
+			})
+		}
+		if len(ms) < 100 {
+			return milestones, nil
+		}
+	}
+}
+
+type githubLabel struct {
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+func convertGithubLabels(labels []githubLabel) []*base.Label {
+	result := make([]*base.Label, 0, len(labels))
+	for _, l := range labels {
+		result = append(result, &base.Label{
+			Name:        l.Name,
+			Color:       l.Color,
+			Description: l.Description,
+		})
+	}
+	return result
+}
+
+// GetLabels returns labels
+func (g *GithubDownloaderV3) GetLabels() ([]*base.Label, error) {
+	var labels = make([]*base.Label, 0, 10)
+	for page := 1; ; page++ {
+		var ls []githubLabel
+		if err := g.client.getJSON(g.repoURL("/labels?per_page=100&page=%d", page), &ls); err != nil {
+			return nil, err
+		}
+		labels = append(labels, convertGithubLabels(ls)...)
+		if len(ls) < 100 {
+			return labels, nil
+		}
+	}
+}
+
+// GetReleases returns releases
+func (g *GithubDownloaderV3) GetReleases() ([]*base.Release, error) {
+	var releases = make([]*base.Release, 0, 10)
+	for page := 1; ; page++ {
+		var rels []struct {
+			TagName         string      `json:"tag_name"`
+			TargetCommitish string      `json:"target_commitish"`
+			Name            string      `json:"name"`
+			Body            string      `json:"body"`
+			Draft           bool        `json:"draft"`
+			Prerelease      bool        `json:"prerelease"`
+			Author          *githubUser `json:"author"`
+			CreatedAt       time.Time   `json:"created_at"`
+			Assets          []struct {
+				Name          string    `json:"name"`
+				Size          int64     `json:"size"`
+				DownloadCount int64     `json:"download_count"`
+				CreatedAt     time.Time `json:"created_at"`
+				URL           string    `json:"url"`
+			} `json:"assets"`
+		}
+		if err := g.client.getJSON(g.repoURL("/releases?per_page=100&page=%d", page), &rels); err != nil {
+			return nil, err
+		}
+		for _, rel := range rels {
+			r := &base.Release{
+				TagName:         rel.TagName,
+				TargetCommitish: rel.TargetCommitish,
+				Name:            rel.Name,
+				Body:            rel.Body,
+				Draft:           rel.Draft,
+				Prerelease:      rel.Prerelease,
+				PublisherName:   rel.Author.login(),
+				PublisherEmail:  g.email(rel.Author),
+				Created:         rel.CreatedAt,
+			}
+			for _, asset := range rel.Assets {
+				r.Assets = append(r.Assets, &base.ReleaseAsset{
+					Name:          asset.Name,
+					Size:          asset.Size,
+					DownloadCount: asset.DownloadCount,
+					Created:       asset.CreatedAt,
+					DownloadURL:   asset.URL,
+				})
+			}
+			releases = append(releases, r)
+		}
+		if len(rels) < 100 {
+			return releases, nil
+		}
+	}
+}
+
+// OpenAsset downloads the content of a release asset through the API, which
+// also works for private repositories
+func (g *GithubDownloaderV3) OpenAsset(asset *base.ReleaseAsset) (io.ReadCloser, error) {
+	header := make(http.Header)
+	header.Set("Accept", "application/octet-stream")
+	return g.client.open(asset.DownloadURL, header)
+}
+
+type githubReactions struct {
+	TotalCount int `json:"total_count"`
+}
+
+// getReactions returns the reactions of the given URL
+func (g *GithubDownloaderV3) getReactions(reactionsURL string) ([]*base.Reaction, error) {
+	header := make(http.Header)
+	header.Set("Accept", "application/vnd.github.squirrel-girl-preview+json")
+
+	var reactions []*base.Reaction
+	for page := 1; ; page++ {
+		var rs []struct {
+			Content string      `json:"content"`
+			User    *githubUser `json:"user"`
+		}
+		if err := g.client.getJSONWithHeader(fmt.Sprintf("%s?per_page=100&page=%d", reactionsURL, page), header, &rs); err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			reactions = append(reactions, &base.Reaction{
+				UserName:  r.User.login(),
+				UserEmail: g.email(r.User),
+				Content:   r.Content,
+			})
+		}
+		if len(rs) < 100 {
+			return reactions, nil
+		}
+	}
+}
+
+// GetIssues returns issues according start and limit, the pull requests
+// which are listed as issues by github are skipped
+func (g *GithubDownloaderV3) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	var issues []struct {
+		Number    int64         `json:"number"`
+		Title     string        `json:"title"`
+		Body      string        `json:"body"`
+		State     string        `json:"state"`
+		User      *githubUser   `json:"user"`
+		Labels    []githubLabel `json:"labels"`
+		Milestone *struct {
+			Title string `json:"title"`
+		} `json:"milestone"`
+		CreatedAt   time.Time        `json:"created_at"`
+		ClosedAt    *time.Time       `json:"closed_at"`
+		PullRequest *struct{}        `json:"pull_request"`
+		Reactions   *githubReactions `json:"reactions"`
+	}
+	if err := g.client.getJSON(g.repoURL("/issues?state=all&sort=created&direction=asc&per_page=%d&page=%d", perPage, page), &issues); err != nil {
+		return nil, false, err
+	}
+
+	var result = make([]*base.Issue, 0, perPage)
+	for _, issue := range issues {
+		if issue.PullRequest != nil {
+			continue
+		}
+		var milestone string
+		if issue.Milestone != nil {
+			milestone = issue.Milestone.Title
+		}
+		var reactions []*base.Reaction
+		if issue.Reactions == nil || issue.Reactions.TotalCount > 0 {
+			var err error
+			if reactions, err = g.getReactions(g.repoURL("/issues/%d/reactions", issue.Number)); err != nil {
+				return nil, false, err
+			}
+		}
+		result = append(result, &base.Issue{
+			Number:      issue.Number,
+			PosterName:  issue.User.login(),
+			PosterEmail: g.email(issue.User),
+			Title:       issue.Title,
+			Content:     issue.Body,
+			Milestone:   milestone,
+			State:       issue.State,
+			Created:     issue.CreatedAt,
+			Closed:      issue.ClosedAt,
+			Labels:      convertGithubLabels(issue.Labels),
+			Reactions:   reactions,
+		})
+	}
+	return result, len(issues) < perPage, nil
+}
+
+// GetComments returns comments according issueNumber
+func (g *GithubDownloaderV3) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	var comments = make([]*base.Comment, 0, 10)
+	for page := 1; ; page++ {
+		var cs []struct {
+			ID        int64            `json:"id"`
+			Body      string           `json:"body"`
+			User      *githubUser      `json:"user"`
+			CreatedAt time.Time        `json:"created_at"`
+			Reactions *githubReactions `json:"reactions"`
+		}
+		if err := g.client.getJSON(g.repoURL("/issues/%d/comments?per_page=100&page=%d", issueNumber, page), &cs); err != nil {
+			return nil, err
+		}
+		for _, c := range cs {
+			var reactions []*base.Reaction
+			if c.Reactions == nil || c.Reactions.TotalCount > 0 {
+				var err error
+				if reactions, err = g.getReactions(g.repoURL("/issues/comments/%d/reactions", c.ID)); err != nil {
+					return nil, err
+				}
+			}
+			comments = append(comments, &base.Comment{
+				IssueNumber: issueNumber,
+				PosterName:  c.User.login(),
+				PosterEmail: g.email(c.User),
+				Created:     c.CreatedAt,
+				Content:     c.Body,
+				Reactions:   reactions,
+			})
+		}
+		if len(cs) < 100 {
+			return comments, nil
+		}
+	}
+}
+
+type githubBranch struct {
+	Label string `json:"label"`
+	Ref   string `json:"ref"`
+	SHA   string `json:"sha"`
+	Repo  *struct {
+		Name  string     `json:"name"`
+		Owner githubUser `json:"owner"`
+	} `json:"repo"`
+}
+
+func (b githubBranch) convert() base.PullRequestBranch {
+	branch := base.PullRequestBranch{
+		Ref: b.Ref,
+		SHA: b.SHA,
+	}
+	if b.Repo != nil {
+		branch.OwnerName = b.Repo.Owner.Login
+		branch.RepoName = b.Repo.Name
+	} else if i := strings.Index(b.Label, ":"); i > 0 {
+		// the repository has been deleted, the label is owner:branch
+		branch.OwnerName = b.Label[:i]
+	}
+	return branch
+}
+
+// GetPullRequests returns pull requests according page and perPage
+func (g *GithubDownloaderV3) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	var prs []struct {
+		Number    int64         `json:"number"`
+		Title     string        `json:"title"`
+		Body      string        `json:"body"`
+		State     string        `json:"state"`
+		User      *githubUser   `json:"user"`
+		Labels    []githubLabel `json:"labels"`
+		Milestone *struct {
+			Title string `json:"title"`
+		} `json:"milestone"`
+		CreatedAt      time.Time    `json:"created_at"`
+		ClosedAt       *time.Time   `json:"closed_at"`
+		MergedAt       *time.Time   `json:"merged_at"`
+		MergeCommitSHA string       `json:"merge_commit_sha"`
+		Head           githubBranch `json:"head"`
+		Base           githubBranch `json:"base"`
+	}
+	if err := g.client.getJSON(g.repoURL("/pulls?state=all&sort=created&direction=asc&per_page=%d&page=%d", perPage, page), &prs); err != nil {
+		return nil, false, err
+	}
+
+	var result = make([]*base.PullRequest, 0, perPage)
+	for _, pr := range prs {
+		var milestone string
+		if pr.Milestone != nil {
+			milestone = pr.Milestone.Title
+		}
+		reactions, err := g.getReactions(g.repoURL("/issues/%d/reactions", pr.Number))
+		if err != nil {
+			return nil, false, err
+		}
+		result = append(result, &base.PullRequest{
+			Number:         pr.Number,
+			Title:          pr.Title,
+			PosterName:     pr.User.login(),
+			PosterEmail:    g.email(pr.User),
+			Content:        pr.Body,
+			Milestone:      milestone,
+			State:          pr.State,
+			Created:        pr.CreatedAt,
+			Closed:         pr.ClosedAt,
+			Labels:         convertGithubLabels(pr.Labels),
+			Merged:         pr.MergedAt != nil,
+			MergedTime:     pr.MergedAt,
+			MergeCommitSHA: pr.MergeCommitSHA,
+			Head:           pr.Head.convert(),
+			Base:           pr.Base.convert(),
+			Reactions:      reactions,
+		})
+	}
+	return result, len(prs) < perPage, nil
+}
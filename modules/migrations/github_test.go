@@ -0,0 +1,153 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeServer(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.RequestURI()]
+		if !ok {
+			t.Logf("unexpected request: %s", r.URL.RequestURI())
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+func TestGithubDownloaderV3Factory_Match(t *testing.T) {
+	factory := &GithubDownloaderV3Factory{}
+	match, err := factory.Match(base.MigrateOptions{RemoteURL: "https://github.com/go-gitea/gitea.git"})
+	assert.NoError(t, err)
+	assert.True(t, match)
+	match, err = factory.Match(base.MigrateOptions{RemoteURL: "https://gitlab.com/gitea/gitea.git"})
+	assert.NoError(t, err)
+	assert.False(t, match)
+
+	downloader, err := factory.New(base.MigrateOptions{RemoteURL: "https://github.com/go-gitea/gitea.git", AuthPassword: "token"})
+	assert.NoError(t, err)
+	github := downloader.(*GithubDownloaderV3)
+	assert.EqualValues(t, "go-gitea", github.repoOwner)
+	assert.EqualValues(t, "gitea", github.repoName)
+	assert.EqualValues(t, "token token", github.client.auth.Get("Authorization"))
+}
+
+func TestGithubDownloaderV3(t *testing.T) {
+	server := newFakeServer(t, map[string]string{
+		"/repos/owner/repo": `{"name": "repo", "owner": {"login": "owner"}, "description": "a repository",
+			"private": true, "html_url": "https://github.com/owner/repo"}`,
+		"/repos/owner/repo/milestones?state=all&per_page=100&page=1": `[{"title": "v1", "state": "open",
+			"due_on": "2019-06-01T00:00:00Z", "created_at": "2019-01-01T00:00:00Z"}]`,
+		"/repos/owner/repo/labels?per_page=100&page=1": `[{"name": "bug", "color": "ee0701", "description": "broken"}]`,
+		"/repos/owner/repo/releases?per_page=100&page=1": `[{"tag_name": "v1.0", "name": "first", "author": {"login": "user1"},
+			"created_at": "2019-01-02T00:00:00Z",
+			"assets": [{"name": "binary", "size": 4, "url": "/repos/owner/repo/releases/assets/1"}]}]`,
+		"/repos/owner/repo/releases/assets/1": "data",
+		"/repos/owner/repo/issues?state=all&sort=created&direction=asc&per_page=2&page=1": `[
+			{"number": 1, "title": "issue", "body": "content", "state": "closed", "user": {"login": "user1"},
+			 "labels": [{"name": "bug"}], "milestone": {"title": "v1"},
+			 "created_at": "2019-01-01T00:00:00Z", "closed_at": "2019-01-03T00:00:00Z", "reactions": {"total_count": 1}},
+			{"number": 2, "title": "pull", "state": "open", "user": {"login": "user2"}, "pull_request": {},
+			 "created_at": "2019-01-02T00:00:00Z"}]`,
+		"/repos/owner/repo/issues/1/reactions?per_page=100&page=1": `[{"content": "heart", "user": {"login": "user2"}}]`,
+		"/repos/owner/repo/issues/1/comments?per_page=100&page=1": `[{"id": 10, "body": "comment", "user": {"login": "user2"},
+			"created_at": "2019-01-02T00:00:00Z", "reactions": {"total_count": 0}}]`,
+		"/repos/owner/repo/pulls?state=all&sort=created&direction=asc&per_page=2&page=1": `[
+			{"number": 2, "title": "pull", "body": "changes", "state": "closed", "user": {"login": "user2"},
+			 "created_at": "2019-01-02T00:00:00Z", "closed_at": "2019-01-04T00:00:00Z", "merged_at": "2019-01-04T00:00:00Z",
+			 "merge_commit_sha": "abc",
+			 "head": {"label": "user2:feature", "ref": "feature", "sha": "def", "repo": null},
+			 "base": {"label": "owner:master", "ref": "master", "sha": "123", "repo": {"name": "repo", "owner": {"login": "owner"}}}}]`,
+		"/repos/owner/repo/issues/2/reactions?per_page=100&page=1": `[]`,
+		"/users/user1": `{"login": "user1", "email": "user1@example.com"}`,
+		"/users/user2": `{"login": "user2", "email": null}`,
+	})
+	defer server.Close()
+
+	downloader := NewGithubDownloaderV3(server.URL, "", "", "owner", "repo", "https://github.com/owner/repo.git")
+
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assert.EqualValues(t, &base.Repository{
+		Name:        "repo",
+		Owner:       "owner",
+		Description: "a repository",
+		IsPrivate:   true,
+		OriginalURL: "https://github.com/owner/repo",
+		CloneURL:    "https://github.com/owner/repo.git",
+	}, repo)
+
+	milestones, err := downloader.GetMilestones()
+	assert.NoError(t, err)
+	if assert.Len(t, milestones, 1) {
+		assert.EqualValues(t, "v1", milestones[0].Title)
+		assert.NotNil(t, milestones[0].Deadline)
+		assert.Nil(t, milestones[0].Closed)
+	}
+
+	labels, err := downloader.GetLabels()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Label{{Name: "bug", Color: "ee0701", Description: "broken"}}, labels)
+
+	releases, err := downloader.GetReleases()
+	assert.NoError(t, err)
+	if assert.Len(t, releases, 1) && assert.Len(t, releases[0].Assets, 1) {
+		assert.EqualValues(t, "user1@example.com", releases[0].PublisherEmail)
+		rc, err := downloader.OpenAsset(releases[0].Assets[0])
+		assert.NoError(t, err)
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+		assert.NoError(t, err)
+		assert.EqualValues(t, "data", string(data))
+	}
+
+	// pull requests are listed as issues by github
+	issues, isEnd, err := downloader.GetIssues(1, 2)
+	assert.NoError(t, err)
+	assert.False(t, isEnd)
+	if assert.Len(t, issues, 1) {
+		issue := issues[0]
+		assert.EqualValues(t, 1, issue.Number)
+		assert.EqualValues(t, "user1", issue.PosterName)
+		assert.EqualValues(t, "user1@example.com", issue.PosterEmail)
+		assert.EqualValues(t, "v1", issue.Milestone)
+		assert.EqualValues(t, "closed", issue.State)
+		assert.NotNil(t, issue.Closed)
+		assert.EqualValues(t, []*base.Label{{Name: "bug"}}, issue.Labels)
+		assert.EqualValues(t, []*base.Reaction{{UserName: "user2", Content: "heart"}}, issue.Reactions)
+	}
+
+	comments, err := downloader.GetComments(1)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 1) {
+		assert.EqualValues(t, 1, comments[0].IssueNumber)
+		assert.EqualValues(t, "comment", comments[0].Content)
+		assert.Empty(t, comments[0].Reactions)
+	}
+
+	prs, isEnd, err := downloader.GetPullRequests(1, 2)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	if assert.Len(t, prs, 1) {
+		pr := prs[0]
+		assert.EqualValues(t, 2, pr.Number)
+		assert.True(t, pr.Merged)
+		assert.EqualValues(t, "abc", pr.MergeCommitSHA)
+		assert.EqualValues(t, base.PullRequestBranch{Ref: "feature", SHA: "def", OwnerName: "user2"}, pr.Head)
+		assert.EqualValues(t, base.PullRequestBranch{Ref: "master", SHA: "123", OwnerName: "owner", RepoName: "repo"}, pr.Base)
+		assert.True(t, pr.IsForkPullRequest())
+	}
+}
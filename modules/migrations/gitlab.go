@@ -0,0 +1,524 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+)
+
+var (
+	_ base.Downloader        = &GitlabDownloader{}
+	_ base.DownloaderFactory = &GitlabDownloaderFactory{}
+)
+
+// GitlabDownloaderFactory defines a gitlab downloader factory
+type GitlabDownloaderFactory struct{}
+
+// Match returns true if the migration remote URL matched this downloader factory
+func (f *GitlabDownloaderFactory) Match(opts base.MigrateOptions) (bool, error) {
+	u, err := url.Parse(opts.RemoteURL)
+	if err != nil {
+		return false, err
+	}
+	host := strings.ToLower(u.Hostname())
+	return host == "gitlab.com" || strings.HasPrefix(host, "gitlab."), nil
+}
+
+// New returns a Downloader related to this factory according MigrateOptions
+func (f *GitlabDownloaderFactory) New(opts base.MigrateOptions) (base.Downloader, error) {
+	u, err := url.Parse(opts.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	owner, repo, err := parseRepoPath(opts.RemoteURL)
+	if err != nil {
+		return nil, err
+	}
+	baseURL := u.Scheme + "://" + u.Host + "/api/v4"
+	return NewGitlabDownloader(baseURL, opts.AuthPassword, owner+"/"+repo, opts.RemoteURL), nil
+}
+
+// GitlabDownloader implements a Downloader interface to get repository informations
+// from gitlab via API v4. GitLab numbers issues and merge requests separately,
+// so the merge requests are numbered after the last issue.
+type GitlabDownloader struct {
+	client      *restClient
+	projectPath string
+	remoteURL   string
+	emails      map[int64]string
+	projects    map[int64]*gitlabProject
+
+	mergeRequestOffset int64
+	mergeRequests      map[int64]int64
+}
+
+// NewGitlabDownloader creates a gitlab Downloader via gitlab API v4. The
+// token is sent as private token if it's not empty.
+func NewGitlabDownloader(baseURL, token, projectPath, remoteURL string) *GitlabDownloader {
+	client := newRestClient(baseURL)
+	if len(token) > 0 {
+		client.auth.Set("PRIVATE-TOKEN", token)
+	}
+
+	return &GitlabDownloader{
+		client:             client,
+		projectPath:        projectPath,
+		remoteURL:          remoteURL,
+		emails:             make(map[int64]string),
+		projects:           make(map[int64]*gitlabProject),
+		mergeRequestOffset: -1,
+		mergeRequests:      make(map[int64]int64),
+	}
+}
+
+func (g *GitlabDownloader) projectURL(format string, args ...interface{}) string {
+	return "/projects/" + url.PathEscape(g.projectPath) + fmt.Sprintf(format, args...)
+}
+
+type gitlabUser struct {
+	ID          int64  `json:"id"`
+	Username    string `json:"username"`
+	PublicEmail string `json:"public_email"`
+}
+
+func (u *gitlabUser) username() string {
+	if u == nil {
+		return ""
+	}
+	return u.Username
+}
+
+// email returns the public email of the user
+func (g *GitlabDownloader) email(u *gitlabUser) string {
+	if u == nil {
+		return ""
+	}
+	email, ok := g.emails[u.ID]
+	if !ok {
+		var user gitlabUser
+		if err := g.client.getJSON(fmt.Sprintf("/users/%d", u.ID), &user); err == nil {
+			email = user.PublicEmail
+		}
+		g.emails[u.ID] = email
+	}
+	return email
+}
+
+type gitlabProject struct {
+	ID          int64  `json:"id"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+	WebURL      string `json:"web_url"`
+	Namespace   struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+// project returns the project of the given ID, or nil if it's not accessible
+func (g *GitlabDownloader) project(id int64) *gitlabProject {
+	project, ok := g.projects[id]
+	if !ok {
+		project = new(gitlabProject)
+		if err := g.client.getJSON(fmt.Sprintf("/projects/%d", id), project); err != nil {
+			project = nil
+		}
+		g.projects[id] = project
+	}
+	return project
+}
+
+// GetRepoInfo returns a repository information
+func (g *GitlabDownloader) GetRepoInfo() (*base.Repository, error) {
+	var project gitlabProject
+	if err := g.client.getJSON(g.projectURL(""), &project); err != nil {
+		return nil, err
+	}
+	g.projects[project.ID] = &project
+	return &base.Repository{
+		Name:        project.Path,
+		Owner:       project.Namespace.FullPath,
+		Description: project.Description,
+		IsPrivate:   project.Visibility == "private",
+		OriginalURL: project.WebURL,
+		CloneURL:    g.remoteURL,
+	}, nil
+}
+
+// GetMilestones returns milestones
+func (g *GitlabDownloader) GetMilestones() ([]*base.Milestone, error) {
+	var milestones = make([]*base.Milestone, 0, 10)
+	for page := 1; ; page++ {
+		var ms []struct {
+			Title       string    `json:"title"`
+			Description string    `json:"description"`
+			State       string    `json:"state"`
+			DueDate     string    `json:"due_date"`
+			CreatedAt   time.Time `json:"created_at"`
+			UpdatedAt   time.Time `json:"updated_at"`
+		}
+		if err := g.client.getJSON(g.projectURL("/milestones?per_page=100&page=%d", page), &ms); err != nil {
+			return nil, err
+		}
+		for _, m := range ms {
+			milestone := &base.Milestone{
+				Title:       m.Title,
+				Description: m.Description,
+				Created:     m.CreatedAt,
+				State:       "open",
+			}
+			if m.State == "closed" {
+				milestone.State = "closed"
+				closed := m.UpdatedAt
+				milestone.Closed = &closed
+			}
+			if deadline, err := time.Parse("2006-01-02", m.DueDate); err == nil {
+				milestone.Deadline = &deadline
+			}
+			milestones = append(milestones, milestone)
+		}
+		if len(ms) < 100 {
+			return milestones, nil
+		}
+	}
+}
+
+// GetLabels returns labels
+func (g *GitlabDownloader) GetLabels() ([]*base.Label, error) {
+	var labels = make([]*base.Label, 0, 10)
+	for page := 1; ; page++ {
+		var ls []struct {
+			Name        string `json:"name"`
+			Color       string `json:"color"`
+			Description string `json:"description"`
+		}
+		if err := g.client.getJSON(g.projectURL("/labels?per_page=100&page=%d", page), &ls); err != nil {
+			return nil, err
+		}
+		for _, l := range ls {
+			labels = append(labels, &base.Label{
+				Name:        l.Name,
+				Color:       strings.TrimPrefix(l.Color, "#"),
+				Description: l.Description,
+			})
+		}
+		if len(ls) < 100 {
+			return labels, nil
+		}
+	}
+}
+
+// GetReleases returns releases, the links of gitlab releases are migrated as assets
+func (g *GitlabDownloader) GetReleases() ([]*base.Release, error) {
+	var releases = make([]*base.Release, 0, 10)
+	for page := 1; ; page++ {
+		var rels []struct {
+			TagName     string      `json:"tag_name"`
+			Name        string      `json:"name"`
+			Description string      `json:"description"`
+			CreatedAt   time.Time   `json:"created_at"`
+			Author      *gitlabUser `json:"author"`
+			Commit      struct {
+				ID string `json:"id"`
+			} `json:"commit"`
+			Assets struct {
+				Links []struct {
+					Name string `json:"name"`
+					URL  string `json:"url"`
+				} `json:"links"`
+			} `json:"assets"`
+		}
+		if err := g.client.getJSON(g.projectURL("/releases?per_page=100&page=%d", page), &rels); err != nil {
+			return nil, err
+		}
+		for _, rel := range rels {
+			r := &base.Release{
+				TagName:         rel.TagName,
+				TargetCommitish: rel.Commit.ID,
+				Name:            rel.Name,
+				Body:            rel.Description,
+				PublisherName:   rel.Author.username(),
+				PublisherEmail:  g.email(rel.Author),
+				Created:         rel.CreatedAt,
+			}
+			for _, link := range rel.Assets.Links {
+				r.Assets = append(r.Assets, &base.ReleaseAsset{
+					Name:        link.Name,
+					Created:     rel.CreatedAt,
+					DownloadURL: link.URL,
+				})
+			}
+			releases = append(releases, r)
+		}
+		if len(rels) < 100 {
+			return releases, nil
+		}
+	}
+}
+
+// OpenAsset downloads the content of a release asset
+func (g *GitlabDownloader) OpenAsset(asset *base.ReleaseAsset) (io.ReadCloser, error) {
+	return g.client.open(asset.DownloadURL, nil)
+}
+
+// getReactions returns the award emojis of an issue, a merge request or a note
+func (g *GitlabDownloader) getReactions(awardsURL string) ([]*base.Reaction, error) {
+	var reactions []*base.Reaction
+	for page := 1; ; page++ {
+		var awards []struct {
+			Name string      `json:"name"`
+			User *gitlabUser `json:"user"`
+		}
+		if err := g.client.getJSON(fmt.Sprintf("%s?per_page=100&page=%d", awardsURL, page), &awards); err != nil {
+			return nil, err
+		}
+		for _, award := range awards {
+			content := award.Name
+			switch content {
+			case "thumbsup":
+				content = "+1"
+			case "thumbsdown":
+				content = "-1"
+			}
+			reactions = append(reactions, &base.Reaction{
+				UserName:  award.User.username(),
+				UserEmail: g.email(award.User),
+				Content:   content,
+			})
+		}
+		if len(awards) < 100 {
+			return reactions, nil
+		}
+	}
+}
+
+func gitlabState(state string) string {
+	if state == "opened" {
+		return "open"
+	}
+	return "closed"
+}
+
+func convertGitlabLabels(names []string) []*base.Label {
+	labels := make([]*base.Label, 0, len(names))
+	for _, name := range names {
+		labels = append(labels, &base.Label{Name: name})
+	}
+	return labels
+}
+
+type gitlabMilestone struct {
+	Title string `json:"title"`
+}
+
+func (m *gitlabMilestone) title() string {
+	if m == nil {
+		return ""
+	}
+	return m.Title
+}
+
+// GetIssues returns issues according start and limit
+func (g *GitlabDownloader) GetIssues(page, perPage int) ([]*base.Issue, bool, error) {
+	var issues []struct {
+		IID         int64            `json:"iid"`
+		Title       string           `json:"title"`
+		Description string           `json:"description"`
+		State       string           `json:"state"`
+		Author      *gitlabUser      `json:"author"`
+		Labels      []string         `json:"labels"`
+		Milestone   *gitlabMilestone `json:"milestone"`
+		CreatedAt   time.Time        `json:"created_at"`
+		ClosedAt    *time.Time       `json:"closed_at"`
+		UpdatedAt   time.Time        `json:"updated_at"`
+		Upvotes     int              `json:"upvotes"`
+		Downvotes   int              `json:"downvotes"`
+	}
+	if err := g.client.getJSON(g.projectURL("/issues?scope=all&order_by=created_at&sort=asc&per_page=%d&page=%d", perPage, page), &issues); err != nil {
+		return nil, false, err
+	}
+
+	var result = make([]*base.Issue, 0, len(issues))
+	for _, issue := range issues {
+		reactions, err := g.getReactions(g.projectURL("/issues/%d/award_emoji", issue.IID))
+		if err != nil {
+			return nil, false, err
+		}
+		state := gitlabState(issue.State)
+		closed := issue.ClosedAt
+		if state == "closed" && closed == nil {
+			closed = &issue.UpdatedAt
+		}
+		result = append(result, &base.Issue{
+			Number:      issue.IID,
+			PosterName:  issue.Author.username(),
+			PosterEmail: g.email(issue.Author),
+			Title:       issue.Title,
+			Content:     issue.Description,
+			Milestone:   issue.Milestone.title(),
+			State:       state,
+			Created:     issue.CreatedAt,
+			Closed:      closed,
+			Labels:      convertGitlabLabels(issue.Labels),
+			Reactions:   reactions,
+		})
+	}
+	return result, len(issues) < perPage, nil
+}
+
+// GetComments returns comments according issueNumber, the system notes are skipped
+func (g *GitlabDownloader) GetComments(issueNumber int64) ([]*base.Comment, error) {
+	notesURL := g.projectURL("/issues/%d/notes", issueNumber)
+	if iid, ok := g.mergeRequests[issueNumber]; ok {
+		notesURL = g.projectURL("/merge_requests/%d/notes", iid)
+	}
+
+	var comments = make([]*base.Comment, 0, 10)
+	for page := 1; ; page++ {
+		var notes []struct {
+			ID        int64       `json:"id"`
+			Body      string      `json:"body"`
+			Author    *gitlabUser `json:"author"`
+			CreatedAt time.Time   `json:"created_at"`
+			System    bool        `json:"system"`
+		}
+		if err := g.client.getJSON(fmt.Sprintf("%s?sort=asc&order_by=created_at&per_page=100&page=%d", notesURL, page), &notes); err != nil {
+			return nil, err
+		}
+		for _, note := range notes {
+			if note.System {
+				continue
+			}
+			reactions, err := g.getReactions(fmt.Sprintf("%s/%d/award_emoji", notesURL, note.ID))
+			if err != nil {
+				return nil, err
+			}
+			comments = append(comments, &base.Comment{
+				IssueNumber: issueNumber,
+				PosterName:  note.Author.username(),
+				PosterEmail: g.email(note.Author),
+				Created:     note.CreatedAt,
+				Content:     note.Body,
+				Reactions:   reactions,
+			})
+		}
+		if len(notes) < 100 {
+			return comments, nil
+		}
+	}
+}
+
+// getMergeRequestOffset returns the number the merge requests are numbered after
+func (g *GitlabDownloader) getMergeRequestOffset() (int64, error) {
+	if g.mergeRequestOffset < 0 {
+		var issues []struct {
+			IID int64 `json:"iid"`
+		}
+		if err := g.client.getJSON(g.projectURL("/issues?scope=all&order_by=created_at&sort=desc&per_page=100"), &issues); err != nil {
+			return 0, err
+		}
+		g.mergeRequestOffset = 0
+		for _, issue := range issues {
+			if issue.IID > g.mergeRequestOffset {
+				g.mergeRequestOffset = issue.IID
+			}
+		}
+	}
+	return g.mergeRequestOffset, nil
+}
+
+// branch returns the pull request branch of a merge request in the given project
+func (g *GitlabDownloader) branch(projectID int64, ref, sha string) base.PullRequestBranch {
+	branch := base.PullRequestBranch{
+		Ref: ref,
+		SHA: sha,
+	}
+	if project := g.project(projectID); project != nil {
+		branch.OwnerName = project.Namespace.FullPath
+		branch.RepoName = project.Path
+	} else {
+		branch.OwnerName = fmt.Sprint(projectID)
+	}
+	return branch
+}
+
+// GetPullRequests returns merge requests according page and perPage
+func (g *GitlabDownloader) GetPullRequests(page, perPage int) ([]*base.PullRequest, bool, error) {
+	offset, err := g.getMergeRequestOffset()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var mrs []struct {
+		IID             int64            `json:"iid"`
+		Title           string           `json:"title"`
+		Description     string           `json:"description"`
+		State           string           `json:"state"`
+		Author          *gitlabUser      `json:"author"`
+		Labels          []string         `json:"labels"`
+		Milestone       *gitlabMilestone `json:"milestone"`
+		CreatedAt       time.Time        `json:"created_at"`
+		UpdatedAt       time.Time        `json:"updated_at"`
+		ClosedAt        *time.Time       `json:"closed_at"`
+		MergedAt        *time.Time       `json:"merged_at"`
+		MergeCommitSHA  string           `json:"merge_commit_sha"`
+		SHA             string           `json:"sha"`
+		SourceBranch    string           `json:"source_branch"`
+		SourceProjectID int64            `json:"source_project_id"`
+		TargetBranch    string           `json:"target_branch"`
+		TargetProjectID int64            `json:"target_project_id"`
+		DiffRefs        struct {
+			BaseSHA string `json:"base_sha"`
+		} `json:"diff_refs"`
+	}
+	if err := g.client.getJSON(g.projectURL("/merge_requests?scope=all&order_by=created_at&sort=asc&per_page=%d&page=%d", perPage, page), &mrs); err != nil {
+		return nil, false, err
+	}
+
+	var result = make([]*base.PullRequest, 0, len(mrs))
+	for _, mr := range mrs {
+		reactions, err := g.getReactions(g.projectURL("/merge_requests/%d/award_emoji", mr.IID))
+		if err != nil {
+			return nil, false, err
+		}
+
+		number := offset + mr.IID
+		g.mergeRequests[number] = mr.IID
+
+		state := gitlabState(mr.State)
+		closed := mr.ClosedAt
+		if mr.State == "merged" {
+			closed = mr.MergedAt
+		}
+		if state == "closed" && closed == nil {
+			closed = &mr.UpdatedAt
+		}
+		result = append(result, &base.PullRequest{
+			Number:         number,
+			Title:          mr.Title,
+			PosterName:     mr.Author.username(),
+			PosterEmail:    g.email(mr.Author),
+			Content:        mr.Description,
+			Milestone:      mr.Milestone.title(),
+			State:          state,
+			Created:        mr.CreatedAt,
+			Closed:         closed,
+			Labels:         convertGitlabLabels(mr.Labels),
+			Merged:         mr.State == "merged",
+			MergedTime:     mr.MergedAt,
+			MergeCommitSHA: mr.MergeCommitSHA,
+			Head:           g.branch(mr.SourceProjectID, mr.SourceBranch, mr.SHA),
+			Base:           g.branch(mr.TargetProjectID, mr.TargetBranch, mr.DiffRefs.BaseSHA),
+			Reactions:      reactions,
+		})
+	}
+	return result, len(mrs) < perPage, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitlabDownloaderFactory_Match(t *testing.T) {
+	factory := &GitlabDownloaderFactory{}
+	for _, remoteURL := range []string{"https://gitlab.com/gitea/gitea.git", "https://gitlab.example.com/group/sub/repo"} {
+		match, err := factory.Match(base.MigrateOptions{RemoteURL: remoteURL})
+		assert.NoError(t, err)
+		assert.True(t, match, remoteURL)
+	}
+	match, err := factory.Match(base.MigrateOptions{RemoteURL: "https://github.com/go-gitea/gitea.git"})
+	assert.NoError(t, err)
+	assert.False(t, match)
+
+	downloader, err := factory.New(base.MigrateOptions{RemoteURL: "https://gitlab.example.com/group/sub/repo.git", AuthPassword: "token"})
+	assert.NoError(t, err)
+	gitlab := downloader.(*GitlabDownloader)
+	assert.EqualValues(t, "group/sub/repo", gitlab.projectPath)
+	assert.EqualValues(t, "https://gitlab.example.com/api/v4", gitlab.client.baseURL)
+	assert.EqualValues(t, "token", gitlab.client.auth.Get("PRIVATE-TOKEN"))
+}
+
+func TestGitlabDownloader(t *testing.T) {
+	server := newFakeServer(t, map[string]string{
+		"/projects/group%2Frepo": `{"id": 5, "path": "repo", "description": "a project", "visibility": "public",
+			"web_url": "https://gitlab.com/group/repo", "namespace": {"full_path": "group"}}`,
+		"/projects/group%2Frepo/milestones?per_page=100&page=1": `[{"title": "v1", "state": "closed", "due_date": "2019-06-01",
+			"created_at": "2019-01-01T00:00:00Z", "updated_at": "2019-02-01T00:00:00Z"}]`,
+		"/projects/group%2Frepo/labels?per_page=100&page=1": `[{"name": "bug", "color": "#ee0701"}]`,
+		"/projects/group%2Frepo/issues?scope=all&order_by=created_at&sort=asc&per_page=100&page=1": `[
+			{"iid": 1, "title": "issue", "state": "opened", "author": {"id": 7, "username": "user1"}, "labels": ["bug"],
+			 "milestone": {"title": "v1"}, "created_at": "2019-01-01T00:00:00Z"},
+			{"iid": 3, "title": "closed issue", "state": "closed", "author": {"id": 7, "username": "user1"},
+			 "created_at": "2019-01-02T00:00:00Z", "updated_at": "2019-01-05T00:00:00Z"}]`,
+		"/projects/group%2Frepo/issues?scope=all&order_by=created_at&sort=desc&per_page=100": `[{"iid": 3}, {"iid": 1}]`,
+		"/projects/group%2Frepo/issues/1/award_emoji?per_page=100&page=1":                    `[{"name": "thumbsup", "user": {"id": 7, "username": "user1"}}]`,
+		"/projects/group%2Frepo/issues/3/award_emoji?per_page=100&page=1":                    `[]`,
+		"/projects/group%2Frepo/issues/1/notes?sort=asc&order_by=created_at&per_page=100&page=1": `[
+			{"id": 11, "body": "added label", "system": true, "created_at": "2019-01-01T00:00:00Z"},
+			{"id": 12, "body": "comment", "author": {"id": 7, "username": "user1"}, "created_at": "2019-01-02T00:00:00Z"}]`,
+		"/projects/group%2Frepo/issues/1/notes/12/award_emoji?per_page=100&page=1": `[]`,
+		"/projects/group%2Frepo/merge_requests?scope=all&order_by=created_at&sort=asc&per_page=100&page=1": `[
+			{"iid": 1, "title": "merge request", "state": "merged", "author": {"id": 7, "username": "user1"},
+			 "created_at": "2019-01-03T00:00:00Z", "merged_at": "2019-01-04T00:00:00Z", "sha": "def", "merge_commit_sha": "abc",
+			 "source_branch": "feature", "source_project_id": 5, "target_branch": "master", "target_project_id": 5,
+			 "diff_refs": {"base_sha": "123"}}]`,
+		"/projects/group%2Frepo/merge_requests/1/award_emoji?per_page=100&page=1": `[]`,
+		"/projects/group%2Frepo/merge_requests/1/notes?sort=asc&order_by=created_at&per_page=100&page=1": `[
+			{"id": 13, "body": "merged", "system": true, "created_at": "2019-01-04T00:00:00Z"}]`,
+		"/users/7": `{"id": 7, "username": "user1", "public_email": "user1@example.com"}`,
+	})
+	defer server.Close()
+
+	downloader := NewGitlabDownloader(server.URL, "", "group/repo", "https://gitlab.com/group/repo.git")
+
+	repo, err := downloader.GetRepoInfo()
+	assert.NoError(t, err)
+	assert.EqualValues(t, &base.Repository{
+		Name:        "repo",
+		Owner:       "group",
+		Description: "a project",
+		OriginalURL: "https://gitlab.com/group/repo",
+		CloneURL:    "https://gitlab.com/group/repo.git",
+	}, repo)
+
+	milestones, err := downloader.GetMilestones()
+	assert.NoError(t, err)
+	if assert.Len(t, milestones, 1) {
+		assert.EqualValues(t, "closed", milestones[0].State)
+		assert.NotNil(t, milestones[0].Closed)
+		assert.NotNil(t, milestones[0].Deadline)
+	}
+
+	labels, err := downloader.GetLabels()
+	assert.NoError(t, err)
+	assert.EqualValues(t, []*base.Label{{Name: "bug", Color: "ee0701"}}, labels)
+
+	issues, isEnd, err := downloader.GetIssues(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	if assert.Len(t, issues, 2) {
+		assert.EqualValues(t, "open", issues[0].State)
+		assert.EqualValues(t, "user1@example.com", issues[0].PosterEmail)
+		assert.EqualValues(t, "v1", issues[0].Milestone)
+		assert.EqualValues(t, []*base.Label{{Name: "bug"}}, issues[0].Labels)
+		assert.EqualValues(t, []*base.Reaction{{UserName: "user1", UserEmail: "user1@example.com", Content: "+1"}}, issues[0].Reactions)
+		assert.EqualValues(t, "closed", issues[1].State)
+		assert.NotNil(t, issues[1].Closed)
+	}
+
+	comments, err := downloader.GetComments(1)
+	assert.NoError(t, err)
+	if assert.Len(t, comments, 1) {
+		assert.EqualValues(t, "comment", comments[0].Content)
+	}
+
+	// merge requests are numbered after the issues
+	prs, isEnd, err := downloader.GetPullRequests(1, 100)
+	assert.NoError(t, err)
+	assert.True(t, isEnd)
+	if assert.Len(t, prs, 1) {
+		pr := prs[0]
+		assert.EqualValues(t, 4, pr.Number)
+		assert.EqualValues(t, "closed", pr.State)
+		assert.True(t, pr.Merged)
+		assert.EqualValues(t, pr.MergedTime, pr.Closed)
+		assert.EqualValues(t, base.PullRequestBranch{Ref: "feature", SHA: "def", OwnerName: "group", RepoName: "repo"}, pr.Head)
+		assert.EqualValues(t, base.PullRequestBranch{Ref: "master", SHA: "123", OwnerName: "group", RepoName: "repo"}, pr.Base)
+		assert.False(t, pr.IsForkPullRequest())
+	}
+
+	comments, err = downloader.GetComments(4)
+	assert.NoError(t, err)
+	assert.Empty(t, comments)
+}
@@ -0,0 +1,175 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/migrations/base"
+)
+
+// pageSize is the number of issues and pull requests downloaded at once
+const pageSize = 100
+
+var factories []base.DownloaderFactory
+
+// RegisterDownloaderFactory registers a downloader factory
+func RegisterDownloaderFactory(factory base.DownloaderFactory) {
+	factories = append(factories, factory)
+}
+
+func init() {
+	RegisterDownloaderFactory(&GithubDownloaderV3Factory{})
+	RegisterDownloaderFactory(&GitlabDownloaderFactory{})
+	RegisterDownloaderFactory(&DumpDownloaderFactory{})
+}
+
+// newDownloader returns the downloader of the first factory which matches
+// the options, repositories of other sites are migrated as plain git
+// repositories without any other items
+func newDownloader(opts *base.MigrateOptions) (base.Downloader, error) {
+	if !opts.Mirror && opts.WithItems() {
+		for _, factory := range factories {
+			match, err := factory.Match(*opts)
+			if err != nil {
+				return nil, err
+			} else if match {
+				return factory.New(*opts)
+			}
+		}
+	}
+
+	opts.Milestones = false
+	opts.Labels = false
+	opts.Issues = false
+	opts.Comments = false
+	opts.PullRequests = false
+	opts.Releases = false
+	return NewPlainGitDownloader(opts.Name, opts.RemoteURL), nil
+}
+
+// MigrateRepository migrates a repository to the given owner, the repository
+// is deleted again if the migration fails
+func MigrateRepository(doer, owner *models.User, opts base.MigrateOptions) (*models.Repository, error) {
+	downloader, err := newDownloader(&opts)
+	if err != nil {
+		return nil, err
+	}
+
+	uploader := NewGiteaLocalUploader(doer, owner, opts.Name)
+	if err = migrateRepository(downloader, uploader, opts); err != nil {
+		if uploader.repo != nil {
+			if errRollback := uploader.Rollback(); errRollback != nil {
+				log.Error(4, "Rollback of migration to %s/%s: %v", owner.Name, opts.Name, errRollback)
+			}
+		}
+		return nil, err
+	}
+	return uploader.repo, nil
+}
+
+// migrateRepository downloads everything selected by the options and hands it to the uploader
+func migrateRepository(downloader base.Downloader, uploader base.Uploader, opts base.MigrateOptions) error {
+	repo, err := downloader.GetRepoInfo()
+	if err != nil {
+		return err
+	}
+	if err = uploader.CreateRepo(repo, opts); err != nil {
+		return err
+	}
+
+	if opts.Milestones {
+		log.Trace("migrating milestones")
+		milestones, err := downloader.GetMilestones()
+		if err != nil {
+			return fmt.Errorf("GetMilestones: %v", err)
+		}
+		if err = uploader.CreateMilestones(milestones...); err != nil {
+			return err
+		}
+	}
+
+	if opts.Labels {
+		log.Trace("migrating labels")
+		labels, err := downloader.GetLabels()
+		if err != nil {
+			return fmt.Errorf("GetLabels: %v", err)
+		}
+		if err = uploader.CreateLabels(labels...); err != nil {
+			return err
+		}
+	}
+
+	if opts.Releases {
+		log.Trace("migrating releases")
+		releases, err := downloader.GetReleases()
+		if err != nil {
+			return fmt.Errorf("GetReleases: %v", err)
+		}
+		if err = uploader.CreateReleases(downloader, releases...); err != nil {
+			return err
+		}
+	}
+
+	if opts.Issues {
+		log.Trace("migrating issues and comments")
+		for page := 1; ; page++ {
+			issues, isEnd, err := downloader.GetIssues(page, pageSize)
+			if err != nil {
+				return fmt.Errorf("GetIssues: %v", err)
+			}
+			if err = uploader.CreateIssues(issues...); err != nil {
+				return err
+			}
+
+			if opts.Comments {
+				for _, issue := range issues {
+					if err = migrateComments(downloader, uploader, issue.Number); err != nil {
+						return err
+					}
+				}
+			}
+			if isEnd {
+				break
+			}
+		}
+	}
+
+	if opts.PullRequests {
+		log.Trace("migrating pull requests and comments")
+		for page := 1; ; page++ {
+			prs, isEnd, err := downloader.GetPullRequests(page, pageSize)
+			if err != nil {
+				return fmt.Errorf("GetPullRequests: %v", err)
+			}
+			if err = uploader.CreatePullRequests(prs...); err != nil {
+				return err
+			}
+
+			if opts.Comments {
+				for _, pr := range prs {
+					if err = migrateComments(downloader, uploader, pr.Number); err != nil {
+						return err
+					}
+				}
+			}
+			if isEnd {
+				break
+			}
+		}
+	}
+
+	return uploader.Finish()
+}
+
+func migrateComments(downloader base.Downloader, uploader base.Uploader, issueNumber int64) error {
+	comments, err := downloader.GetComments(issueNumber)
+	if err != nil {
+		return fmt.Errorf("GetComments[%d]: %v", issueNumber, err)
+	}
+	return uploader.CreateComments(comments...)
+}
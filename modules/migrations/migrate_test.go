@@ -0,0 +1,221 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package migrations
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/migrations/base"
+
+	"github.com/Unknwon/com"
+	"github.com/stretchr/testify/assert"
+)
+
+const dumpJSON = `{
+	"repository": {"name": "repo1", "description": "migrated repository"},
+	"labels": [
+		{"name": "bug", "color": "ee0701"},
+		{"name": "feature", "color": "#84b6eb", "description": "new feature"}
+	],
+	"milestones": [
+		{"title": "v1", "state": "closed", "created": "2019-01-01T00:00:00Z", "closed": "2019-02-01T00:00:00Z"}
+	],
+	"releases": [
+		{"tag_name": "v1.1", "name": "first release", "body": "notes", "publisher_email": "user2@example.com",
+		 "created": "2019-01-02T00:00:00Z",
+		 "assets": [{"name": "notes.txt", "download_count": 3, "download_url": "assets/notes.txt"}]},
+		{"tag_name": "v2.0", "name": "missing tag", "created": "2019-01-03T00:00:00Z"}
+	],
+	"issues": [
+		{"number": 1, "poster_name": "someone", "poster_email": "user2@example.com", "title": "first issue",
+		 "content": "content", "milestone": "v1", "state": "closed", "created": "2019-01-01T00:00:00Z",
+		 "closed": "2019-01-05T00:00:00Z", "labels": [{"name": "bug"}],
+		 "reactions": [
+			{"user_email": "user2@example.com", "content": "heart"},
+			{"user_email": "user2@example.com", "content": "heart"},
+			{"user_email": "unknown@example.com", "content": "+1"},
+			{"user_email": "user4@example.com", "content": "rocket"}
+		 ]},
+		{"number": 3, "poster_name": "stranger", "poster_email": "stranger@example.com", "title": "second issue",
+		 "state": "open", "created": "2019-01-03T00:00:00Z"}
+	],
+	"comments": {
+		"1": [{"poster_name": "stranger", "content": "first comment", "created": "2019-01-02T00:00:00Z"}],
+		"2": [{"poster_email": "user4@example.com", "content": "looks good", "created": "2019-01-04T00:00:00Z"}]
+	},
+	"pull_requests": [
+		{"number": 2, "poster_email": "user4@example.com", "title": "a pull request", "state": "open",
+		 "created": "2019-01-02T00:00:00Z", "labels": [{"name": "feature"}],
+		 "head": {"ref": "contribution", "sha": "65f1bf27bc3bf70f64657658635e66094edbcb4d", "owner_name": "someone", "repo_name": "repo1"},
+		 "base": {"ref": "master", "sha": "65f1bf27bc3bf70f64657658635e66094edbcb4d", "owner_name": "user2", "repo_name": "repo1"}}
+	]
+}`
+
+func prepareDump(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "migration-dump")
+	assert.NoError(t, err)
+
+	var dump Dump
+	assert.NoError(t, json.Unmarshal([]byte(dumpJSON), &dump))
+	dump.Repository.CloneURL = "repo1.git"
+	data, err := json.Marshal(dump)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, DumpFileName), data, 0644))
+	assert.NoError(t, com.CopyDir(filepath.Join("..", "..", "integrations", "gitea-repositories-meta", "user2", "repo1.git"),
+		filepath.Join(dir, "repo1.git")))
+
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "assets"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "assets", "notes.txt"), []byte("release notes"), 0644))
+	return dir
+}
+
+func TestMigrateRepository_Dump(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	dir := prepareDump(t)
+	defer os.RemoveAll(dir)
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: 3}).(*models.User)
+	repo, err := MigrateRepository(doer, owner, base.MigrateOptions{
+		RemoteURL:    dir,
+		Name:         "migrated",
+		Milestones:   true,
+		Labels:       true,
+		Issues:       true,
+		Comments:     true,
+		PullRequests: true,
+		Releases:     true,
+	})
+	assert.NoError(t, err)
+	if !assert.NotNil(t, repo) {
+		return
+	}
+	assert.EqualValues(t, "migrated repository", repo.Description)
+
+	repo = models.AssertExistsAndLoadBean(t, &models.Repository{ID: repo.ID}).(*models.Repository)
+	assert.EqualValues(t, 2, repo.NumIssues)
+	assert.EqualValues(t, 1, repo.NumClosedIssues)
+	assert.EqualValues(t, 1, repo.NumPulls)
+	assert.EqualValues(t, 1, repo.NumMilestones)
+	assert.EqualValues(t, 1, repo.NumClosedMilestones)
+
+	milestone := models.AssertExistsAndLoadBean(t, &models.Milestone{RepoID: repo.ID, Name: "v1"}).(*models.Milestone)
+	assert.True(t, milestone.IsClosed)
+	assert.EqualValues(t, 1, milestone.NumIssues)
+
+	// issues keep their numbers and are attributed to the users with the same email
+	issue := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 1}).(*models.Issue)
+	assert.EqualValues(t, 2, issue.PosterID)
+	assert.Empty(t, issue.OriginalAuthor)
+	assert.True(t, issue.IsClosed)
+	assert.EqualValues(t, milestone.ID, issue.MilestoneID)
+	assert.NoError(t, issue.LoadAttributes())
+	if assert.Len(t, issue.Labels, 1) {
+		assert.EqualValues(t, "bug", issue.Labels[0].Name)
+	}
+	if assert.Len(t, issue.Reactions, 1) {
+		assert.EqualValues(t, "heart", issue.Reactions[0].Type)
+		assert.EqualValues(t, 2, issue.Reactions[0].UserID)
+	}
+	if assert.Len(t, issue.Comments, 1) {
+		assert.EqualValues(t, doer.ID, issue.Comments[0].PosterID)
+		assert.EqualValues(t, "stranger", issue.Comments[0].OriginalAuthor)
+	}
+
+	issue = models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 3}).(*models.Issue)
+	assert.EqualValues(t, doer.ID, issue.PosterID)
+	assert.EqualValues(t, "stranger", issue.OriginalAuthor)
+
+	pull := models.AssertExistsAndLoadBean(t, &models.Issue{RepoID: repo.ID, Index: 2}).(*models.Issue)
+	assert.True(t, pull.IsPull)
+	assert.EqualValues(t, 4, pull.PosterID)
+	pr := models.AssertExistsAndLoadBean(t, &models.PullRequest{IssueID: pull.ID}).(*models.PullRequest)
+	assert.EqualValues(t, "someone-contribution", pr.HeadBranch)
+	assert.EqualValues(t, "master", pr.BaseBranch)
+	models.AssertExistsAndLoadBean(t, &models.Comment{IssueID: pull.ID, PosterID: 4})
+
+	// new issues are numbered after the migrated ones
+	assert.EqualValues(t, 4, repo.NextIssueIndex())
+
+	rel := models.AssertExistsAndLoadBean(t, &models.Release{RepoID: repo.ID, LowerTagName: "v1.1"}).(*models.Release)
+	assert.EqualValues(t, "first release", rel.Title)
+	assert.False(t, rel.IsDraft)
+	attach := models.AssertExistsAndLoadBean(t, &models.Attachment{ReleaseID: rel.ID}).(*models.Attachment)
+	assert.EqualValues(t, "notes.txt", attach.Name)
+	assert.EqualValues(t, 3, attach.DownloadCount)
+	rel = models.AssertExistsAndLoadBean(t, &models.Release{RepoID: repo.ID, LowerTagName: "v2.0"}).(*models.Release)
+	assert.True(t, rel.IsDraft)
+}
+
+func TestDumpDownloader_Path(t *testing.T) {
+	dir := prepareDump(t)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.Symlink("/etc", filepath.Join(dir, "assets", "etc")))
+
+	d, err := NewDumpDownloader(dir)
+	assert.NoError(t, err)
+	f, err := d.OpenAsset(&base.ReleaseAsset{DownloadURL: "assets/notes.txt"})
+	if assert.NoError(t, err) {
+		f.Close()
+	}
+
+	// the files outside of the dump directory can not be read
+	for _, p := range []string{
+		"/etc/passwd",
+		"file:///etc/passwd",
+		"../../../../../../etc/passwd",
+		"assets/../../notes.txt",
+		"assets/etc/passwd",
+	} {
+		_, err = d.OpenAsset(&base.ReleaseAsset{DownloadURL: p})
+		assert.Error(t, err, p)
+	}
+}
+
+func TestMigrateRepository_Rollback(t *testing.T) {
+	assert.NoError(t, models.PrepareTestDatabase())
+	dir, err := ioutil.TempDir("", "migration-dump")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, DumpFileName),
+		[]byte(`{"repository": {"clone_url": "nonexistent.git"}}`), 0644))
+
+	doer := models.AssertExistsAndLoadBean(t, &models.User{ID: 1}).(*models.User)
+	owner := models.AssertExistsAndLoadBean(t, &models.User{ID: 3}).(*models.User)
+	_, err = MigrateRepository(doer, owner, base.MigrateOptions{
+		RemoteURL: dir,
+		Name:      "failed",
+		Issues:    true,
+	})
+	assert.Error(t, err)
+	models.AssertNotExistsBean(t, &models.Repository{OwnerID: owner.ID, LowerName: "failed"})
+}
+
+func TestMigrateRepository_PlainGit(t *testing.T) {
+	opts := &base.MigrateOptions{
+		RemoteURL: "https://example.com/user/repo.git",
+		Name:      "repo",
+		Issues:    true,
+		Releases:  true,
+	}
+	downloader, err := newDownloader(opts)
+	assert.NoError(t, err)
+	assert.IsType(t, &PlainGitDownloader{}, downloader)
+	assert.False(t, opts.WithItems())
+
+	opts = &base.MigrateOptions{
+		RemoteURL: "https://github.com/go-gitea/gitea",
+		Mirror:    true,
+		Issues:    true,
+	}
+	downloader, err = newDownloader(opts)
+	assert.NoError(t, err)
+	assert.IsType(t, &PlainGitDownloader{}, downloader)
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package private
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// Reindex asks the running server to empty the given indexer ("issues" or
+// "code") and populate it again
+func Reindex(indexerType string) error {
+	reqURL := setting.LocalURL + fmt.Sprintf("api/internal/indexer/%s/reindex", indexerType)
+
+	resp, err := newInternalRequest(reqURL, "POST").Response()
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	// All 2XX status codes are accepted and others will return an error
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("Failed to reindex %s: %s", indexerType, decodeJSONError(resp).Err)
+	}
+
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+var (
+	boltQueueBucket   = []byte("queue")
+	boltWaitingBucket = []byte("waiting")
+	boltRunningBucket = []byte("running")
+)
+
+// boltBackend persists the identities in a BoltDB file. The queue bucket maps
+// a sequence number to the identity to keep the order, the waiting bucket maps
+// the identity to its sequence number and the running bucket holds the
+// identities which are being processed, they are queued again on open so
+// that no work is lost when Gitea stops while processing them.
+type boltBackend struct {
+	db     *bolt.DB
+	notify chan struct{}
+}
+
+func newBoltBackend(name, dataDir string) (*boltBackend, error) {
+	if err := os.MkdirAll(dataDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filepath.Join(dataDir, name+".db"), 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	b := &boltBackend{
+		db:     db,
+		notify: make(chan struct{}, 1),
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltQueueBucket, boltWaitingBucket, boltRunningBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		running := tx.Bucket(boltRunningBucket)
+		var interrupted []string
+		if err := running.ForEach(func(k, _ []byte) error {
+			interrupted = append(interrupted, string(k))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, id := range interrupted {
+			if err := running.Delete([]byte(id)); err != nil {
+				return err
+			}
+			if _, err := boltPush(tx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	b.wakeUp()
+	return b, nil
+}
+
+func boltPush(tx *bolt.Tx, id string) (bool, error) {
+	waiting := tx.Bucket(boltWaitingBucket)
+	if waiting.Get([]byte(id)) != nil {
+		return false, nil
+	}
+
+	queue := tx.Bucket(boltQueueBucket)
+	seq, err := queue.NextSequence()
+	if err != nil {
+		return false, err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	if err = queue.Put(key, []byte(id)); err != nil {
+		return false, err
+	}
+	return true, waiting.Put([]byte(id), key)
+}
+
+// wakeUp signals a waiting worker that there may be something to pop
+func (b *boltBackend) wakeUp() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (b *boltBackend) push(id string) (added bool, err error) {
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		added, err = boltPush(tx, id)
+		return err
+	})
+	if added {
+		b.wakeUp()
+	}
+	return added, err
+}
+
+func (b *boltBackend) pop(closed <-chan struct{}) (string, error) {
+	for {
+		var id string
+		var found, more bool
+		if err := b.db.Update(func(tx *bolt.Tx) error {
+			queue := tx.Bucket(boltQueueBucket)
+			c := queue.Cursor()
+			k, v := c.First()
+			if k == nil {
+				return nil
+			}
+			id, found = string(v), true
+			if err := c.Delete(); err != nil {
+				return err
+			}
+			if err := tx.Bucket(boltWaitingBucket).Delete([]byte(id)); err != nil {
+				return err
+			}
+			next, _ := c.First()
+			more = next != nil
+			return tx.Bucket(boltRunningBucket).Put([]byte(id), []byte{})
+		}); err != nil {
+			return "", err
+		}
+
+		if found {
+			// let another worker take the next one
+			if more {
+				b.wakeUp()
+			}
+			return id, nil
+		}
+
+		select {
+		case <-b.notify:
+		case <-closed:
+			return "", errClosed
+		}
+	}
+}
+
+func (b *boltBackend) done(id string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltRunningBucket).Delete([]byte(id))
+	})
+}
+
+func (b *boltBackend) has(id string) (has bool, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		has = tx.Bucket(boltWaitingBucket).Get([]byte(id)) != nil
+		return nil
+	})
+	return has, err
+}
+
+func (b *boltBackend) len() (n int64, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		n = int64(tx.Bucket(boltQueueBucket).Stats().KeyN)
+		return nil
+	})
+	return n, err
+}
+
+func (b *boltBackend) close() error {
+	return b.db.Close()
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"sync"
+)
+
+// memoryBackend keeps the identities in a buffered channel, pushing blocks
+// while the channel is full
+type memoryBackend struct {
+	lock    sync.Mutex
+	waiting map[string]struct{}
+	queue   chan string
+}
+
+func newMemoryBackend(length int) *memoryBackend {
+	if length <= 0 {
+		length = 100
+	}
+	return &memoryBackend{
+		waiting: make(map[string]struct{}),
+		queue:   make(chan string, length),
+	}
+}
+
+func (m *memoryBackend) push(id string) (bool, error) {
+	m.lock.Lock()
+	if _, ok := m.waiting[id]; ok {
+		m.lock.Unlock()
+		return false, nil
+	}
+	m.waiting[id] = struct{}{}
+	m.lock.Unlock()
+
+	m.queue <- id
+	return true, nil
+}
+
+func (m *memoryBackend) pop(closed <-chan struct{}) (string, error) {
+	select {
+	case id := <-m.queue:
+		m.lock.Lock()
+		delete(m.waiting, id)
+		m.lock.Unlock()
+		return id, nil
+	case <-closed:
+		return "", errClosed
+	}
+}
+
+// drain takes all the waiting identities out of the line without blocking
+func (m *memoryBackend) drain() []string {
+	var ids []string
+	for {
+		select {
+		case id := <-m.queue:
+			m.lock.Lock()
+			delete(m.waiting, id)
+			m.lock.Unlock()
+			ids = append(ids, id)
+		default:
+			return ids
+		}
+	}
+}
+
+func (m *memoryBackend) done(id string) error {
+	return nil
+}
+
+func (m *memoryBackend) has(id string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, ok := m.waiting[id]
+	return ok, nil
+}
+
+func (m *memoryBackend) len() (int64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return int64(len(m.waiting)), nil
+}
+
+func (m *memoryBackend) close() error {
+	return nil
+}
@@ -0,0 +1,380 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/Unknwon/com"
+)
+
+// errClosed is returned by a backend when the queue has been closed while waiting
+var errClosed = errors.New("queue is closed")
+
+// Handler is called by the workers of a queue for every identity taken from it
+type Handler func(id string)
+
+// UniqueQueue is a queue which guarantees only one instance of same
+// identity is waiting in the line. Instances with same identity will be
+// discarded if there is already one waiting. An identity is taken out of
+// the line once a worker starts processing it, it can be queued again while
+// it is being processed but it is only handed to a worker again once the
+// processing is over, so that an identity is never processed concurrently.
+type UniqueQueue interface {
+	// Add adds the identity to the queue
+	Add(id interface{})
+	// AddFunc adds the identity to the queue and calls fn before, fn is not
+	// called if the identity is already waiting in the queue
+	AddFunc(id interface{}, fn func())
+	// Exist returns true if the identity is waiting in the queue
+	Exist(id interface{}) bool
+	// Len returns the number of identities waiting in the queue
+	Len() int64
+	// Configure replaces the in-memory line of the queue by the one described
+	// by the settings, moving the identities already waiting to it. It must
+	// be called before Run
+	Configure(cfg setting.QueueSettings) error
+	// Run starts the workers of the queue which call handler for every
+	// identity, it returns immediately
+	Run(handler Handler)
+	// Close stops the workers and releases the backend of the queue
+	Close() error
+}
+
+// backend stores the identities of a queue
+type backend interface {
+	// push adds the identity to the line and returns false if it was already waiting
+	push(id string) (bool, error)
+	// pop blocks until an identity is waiting and takes it out of the line,
+	// the identity is kept by persistent backends until done is called
+	pop(closed <-chan struct{}) (string, error)
+	// done marks the identity taken by pop as processed
+	done(id string) error
+	has(id string) (bool, error)
+	len() (int64, error)
+	close() error
+}
+
+type uniqueQueue struct {
+	name string
+
+	// lock protects the settings and the backend which are replaced by
+	// Configure, it is held while pushing so that no identity is lost
+	lock    sync.RWMutex
+	cfg     setting.QueueSettings
+	backend backend
+	started bool
+
+	// running maps the identities being processed to whether they have been
+	// queued again meanwhile, requeuing holds the identities being pushed
+	// again once processed
+	runningLock sync.Mutex
+	running     map[string]bool
+	requeuing   map[string]struct{}
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	workers   sync.WaitGroup
+}
+
+func newUniqueQueue(name string, length int) *uniqueQueue {
+	return &uniqueQueue{
+		name:      name,
+		cfg:       setting.QueueSettings{Type: setting.MemoryQueueType, Length: length, Workers: 1},
+		backend:   newMemoryBackend(length),
+		running:   make(map[string]bool),
+		requeuing: make(map[string]struct{}),
+		closed:    make(chan struct{}),
+	}
+}
+
+// Add adds the identity to the queue
+func (q *uniqueQueue) Add(id interface{}) {
+	q.AddFunc(id, nil)
+}
+
+// AddFunc adds the identity to the queue and calls fn before
+func (q *uniqueQueue) AddFunc(id interface{}, fn func()) {
+	strID := com.ToStr(id)
+
+	// an identity being processed is pushed again once it is processed
+	q.runningLock.Lock()
+	if _, ok := q.requeuing[strID]; ok {
+		q.runningLock.Unlock()
+		return
+	}
+	if queuedAgain, ok := q.running[strID]; ok {
+		q.running[strID] = true
+		q.runningLock.Unlock()
+		if !queuedAgain && fn != nil {
+			fn()
+		}
+		return
+	}
+	q.runningLock.Unlock()
+
+	if q.Exist(id) {
+		return
+	}
+	if fn != nil {
+		fn()
+	}
+	q.push(strID)
+}
+
+func (q *uniqueQueue) push(id string) {
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	if _, err := q.backend.push(id); err != nil {
+		log.Error(4, "Queue %s: push %s: %v", q.name, id, err)
+	}
+}
+
+// Exist returns true if the identity is waiting in the queue
+func (q *uniqueQueue) Exist(id interface{}) bool {
+	strID := com.ToStr(id)
+
+	q.runningLock.Lock()
+	_, requeuing := q.requeuing[strID]
+	queuedAgain := q.running[strID] || requeuing
+	q.runningLock.Unlock()
+	if queuedAgain {
+		return true
+	}
+
+	q.lock.RLock()
+	defer q.lock.RUnlock()
+	has, err := q.backend.has(strID)
+	if err != nil {
+		log.Error(4, "Queue %s: has %s: %v", q.name, strID, err)
+	}
+	return has
+}
+
+// Len returns the number of identities waiting in the queue
+func (q *uniqueQueue) Len() int64 {
+	q.lock.RLock()
+	n, err := q.backend.len()
+	q.lock.RUnlock()
+	if err != nil {
+		log.Error(4, "Queue %s: len: %v", q.name, err)
+	}
+
+	q.runningLock.Lock()
+	defer q.runningLock.Unlock()
+	for _, queuedAgain := range q.running {
+		if queuedAgain {
+			n++
+		}
+	}
+	return n + int64(len(q.requeuing))
+}
+
+// Configure replaces the in-memory backend by the configured one
+func (q *uniqueQueue) Configure(cfg setting.QueueSettings) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.started {
+		return fmt.Errorf("queue %s is already running", q.name)
+	}
+
+	b, err := newBackend(q.name, cfg)
+	if err != nil {
+		return fmt.Errorf("queue %s: %v", q.name, err)
+	}
+	if m, ok := q.backend.(*memoryBackend); ok {
+		for _, id := range m.drain() {
+			if _, err = b.push(id); err != nil {
+				b.close()
+				return fmt.Errorf("queue %s: push %s: %v", q.name, id, err)
+			}
+		}
+	}
+	if err = q.backend.close(); err != nil {
+		log.Error(4, "Queue %s: close: %v", q.name, err)
+	}
+
+	q.cfg = cfg
+	q.backend = b
+	return nil
+}
+
+// Run starts the configured number of workers
+func (q *uniqueQueue) Run(handler Handler) {
+	q.lock.Lock()
+	q.started = true
+	workers := q.cfg.Workers
+	b := q.backend
+	q.lock.Unlock()
+
+	if workers < 1 {
+		workers = 1
+	}
+	q.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work(b, handler)
+	}
+}
+
+func (q *uniqueQueue) work(b backend, handler Handler) {
+	defer q.workers.Done()
+	for {
+		id, err := b.pop(q.closed)
+		if err == errClosed {
+			return
+		} else if err != nil {
+			log.Error(4, "Queue %s: pop: %v", q.name, err)
+			select {
+			case <-q.closed:
+				return
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		// another worker is processing the identity, which has been queued
+		// again before it could be marked as running
+		q.runningLock.Lock()
+		_, isRunning := q.running[id]
+		q.running[id] = isRunning
+		q.runningLock.Unlock()
+		if isRunning {
+			if err = b.done(id); err != nil {
+				log.Error(4, "Queue %s: done %s: %v", q.name, id, err)
+			}
+			continue
+		}
+
+		handler(id)
+
+		if err = b.done(id); err != nil {
+			log.Error(4, "Queue %s: done %s: %v", q.name, id, err)
+		}
+
+		q.runningLock.Lock()
+		queuedAgain := q.running[id]
+		delete(q.running, id)
+		if queuedAgain {
+			q.requeuing[id] = struct{}{}
+		}
+		q.runningLock.Unlock()
+		if queuedAgain {
+			// pushing blocks while the line is full, which the worker
+			// must not wait for since it is the one emptying it
+			go q.requeue(b, id)
+		}
+	}
+}
+
+// requeue pushes again the identity which has been queued while it was processed
+func (q *uniqueQueue) requeue(b backend, id string) {
+	if _, err := b.push(id); err != nil {
+		log.Error(4, "Queue %s: push %s: %v", q.name, id, err)
+	}
+	q.runningLock.Lock()
+	delete(q.requeuing, id)
+	q.runningLock.Unlock()
+}
+
+// Close stops the workers, waiting for the running handlers to return, and
+// closes the backend
+func (q *uniqueQueue) Close() error {
+	var err error
+	q.closeOnce.Do(func() {
+		close(q.closed)
+		q.workers.Wait()
+		q.lock.Lock()
+		err = q.backend.close()
+		q.lock.Unlock()
+	})
+	return err
+}
+
+// Info describes a queue for monitoring
+type Info struct {
+	Name    string
+	Type    string
+	Workers int
+	Length  int64
+}
+
+var manager = struct {
+	lock   sync.Mutex
+	queues map[string]*uniqueQueue
+}{
+	queues: make(map[string]*uniqueQueue),
+}
+
+func newBackend(name string, cfg setting.QueueSettings) (backend, error) {
+	switch cfg.Type {
+	case setting.MemoryQueueType:
+		return newMemoryBackend(cfg.Length), nil
+	case setting.BoltQueueType:
+		return newBoltBackend(name, cfg.DataDir)
+	case setting.RedisQueueType:
+		return newRedisBackend(name, cfg.ConnStr)
+	}
+	return nil, fmt.Errorf("unknown queue type: %s", cfg.Type)
+}
+
+// NewNamed creates the named queue, which keeps the identities in memory
+// with the given length until it is configured. It is meant to be called
+// when initializing a package, so that the queue is available before the
+// settings are loaded. A queue which was created before with the same name
+// is closed.
+func NewNamed(name string, length int) UniqueQueue {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	if old, ok := manager.queues[name]; ok {
+		if err := old.Close(); err != nil {
+			log.Error(4, "Queue %s: close: %v", name, err)
+		}
+	}
+
+	q := newUniqueQueue(name, length)
+	manager.queues[name] = q
+	return q
+}
+
+// New creates the named queue from its configuration. A queue which was
+// created before with the same name is closed.
+func New(name string, cfg setting.QueueSettings) (UniqueQueue, error) {
+	q := NewNamed(name, cfg.Length)
+	if err := q.Configure(cfg); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Queues returns information about all named queues, sorted by name
+func Queues() []Info {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+
+	infos := make([]Info, 0, len(manager.queues))
+	for _, q := range manager.queues {
+		q.lock.RLock()
+		cfg := q.cfg
+		q.lock.RUnlock()
+		infos = append(infos, Info{
+			Name:    q.name,
+			Type:    cfg.Type,
+			Workers: cfg.Workers,
+			Length:  q.Len(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
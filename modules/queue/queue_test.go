@@ -0,0 +1,243 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testUniqueQueue(t *testing.T, q UniqueQueue) {
+	called := 0
+	q.AddFunc(1, func() { called++ })
+	q.AddFunc(1, func() { called++ })
+	q.Add("2")
+	q.Add(int64(3))
+	assert.Equal(t, 1, called)
+	assert.EqualValues(t, 3, q.Len())
+	assert.True(t, q.Exist(1))
+	assert.True(t, q.Exist("3"))
+	assert.False(t, q.Exist(4))
+
+	handled := make(chan string)
+	q.Run(func(id string) {
+		handled <- id
+	})
+
+	for _, expected := range []string{"1", "2", "3"} {
+		select {
+		case id := <-handled:
+			assert.Equal(t, expected, id)
+			assert.False(t, q.Exist(id))
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "Timeout: nothing was handled")
+			return
+		}
+	}
+
+	// an identity can be queued again once its processing started
+	q.Add(1)
+	select {
+	case id := <-handled:
+		assert.Equal(t, "1", id)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Timeout: nothing was handled")
+	}
+
+	assert.NoError(t, q.Close())
+}
+
+func TestMemoryQueue(t *testing.T) {
+	q, err := New("test_memory", setting.QueueSettings{Type: setting.MemoryQueueType, Length: 10, Workers: 1})
+	assert.NoError(t, err)
+	testUniqueQueue(t, q)
+}
+
+func TestBoltQueue(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := setting.QueueSettings{Type: setting.BoltQueueType, DataDir: dir, Workers: 2}
+	q, err := New("test_bolt", cfg)
+	assert.NoError(t, err)
+	testUniqueQueue(t, q)
+}
+
+func TestBoltQueue_Restart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	b, err := newBoltBackend("restart", dir)
+	assert.NoError(t, err)
+	for _, id := range []string{"1", "2", "3"} {
+		added, err := b.push(id)
+		assert.NoError(t, err)
+		assert.True(t, added)
+	}
+	// stop while "1" is being processed
+	id, err := b.pop(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", id)
+	assert.NoError(t, b.close())
+
+	b, err = newBoltBackend("restart", dir)
+	assert.NoError(t, err)
+	n, err := b.len()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, n)
+	for _, expected := range []string{"2", "3", "1"} {
+		id, err := b.pop(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, id)
+		assert.NoError(t, b.done(id))
+	}
+	assert.NoError(t, b.close())
+
+	b, err = newBoltBackend("restart", dir)
+	assert.NoError(t, err)
+	n, err = b.len()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 0, n)
+	assert.NoError(t, b.close())
+}
+
+func TestUniqueQueue_Running(t *testing.T) {
+	q, err := New("test_running", setting.QueueSettings{Type: setting.MemoryQueueType, Length: 10, Workers: 3})
+	assert.NoError(t, err)
+
+	started := make(chan string, 10)
+	release := make(chan struct{})
+	q.Run(func(id string) {
+		started <- id
+		<-release
+	})
+
+	q.Add(1)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Timeout: nothing was handled")
+		return
+	}
+
+	// the identity is held while it is being processed
+	called := 0
+	q.AddFunc(1, func() { called++ })
+	q.AddFunc(1, func() { called++ })
+	assert.Equal(t, 1, called)
+	assert.True(t, q.Exist(1))
+	assert.EqualValues(t, 1, q.Len())
+	select {
+	case <-started:
+		assert.Fail(t, "The identity is processed concurrently")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release <- struct{}{}
+	select {
+	case id := <-started:
+		assert.Equal(t, "1", id)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "Timeout: the identity was not processed again")
+		return
+	}
+	release <- struct{}{}
+
+	select {
+	case <-started:
+		assert.Fail(t, "The identity is processed more than twice")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.NoError(t, q.Close())
+}
+
+func TestUniqueQueue_RunningFull(t *testing.T) {
+	q, err := New("test_running_full", setting.QueueSettings{Type: setting.MemoryQueueType, Length: 1, Workers: 1})
+	assert.NoError(t, err)
+
+	started := make(chan string, 10)
+	release := make(chan struct{})
+	q.Run(func(id string) {
+		started <- id
+		<-release
+	})
+
+	q.Add(1)
+	assert.Equal(t, "1", <-started)
+
+	// the identity is pushed again while the line is full
+	q.Add(1)
+	q.Add(2)
+	assert.EqualValues(t, 2, q.Len())
+	release <- struct{}{}
+
+	for _, expected := range []string{"2", "1"} {
+		select {
+		case id := <-started:
+			assert.Equal(t, expected, id)
+			release <- struct{}{}
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "Timeout: the worker is blocked")
+			return
+		}
+	}
+	assert.NoError(t, q.Close())
+}
+
+func TestUniqueQueue_Configure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// the identities added before the queue is configured are kept
+	q := NewNamed("test_configure", 10)
+	q.Add(1)
+	q.Add(2)
+	assert.NoError(t, q.Configure(setting.QueueSettings{Type: setting.BoltQueueType, DataDir: dir, Workers: 1}))
+	assert.EqualValues(t, 2, q.Len())
+	assert.True(t, q.Exist(1))
+
+	handled := make(chan string)
+	q.Run(func(id string) {
+		handled <- id
+	})
+	assert.Error(t, q.Configure(setting.QueueSettings{Type: setting.MemoryQueueType, Length: 10, Workers: 1}))
+
+	for _, expected := range []string{"1", "2"} {
+		select {
+		case id := <-handled:
+			assert.Equal(t, expected, id)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "Timeout: nothing was handled")
+			return
+		}
+	}
+	assert.NoError(t, q.Close())
+}
+
+func TestQueues(t *testing.T) {
+	q, err := New("test_info", setting.QueueSettings{Type: setting.MemoryQueueType, Length: 10, Workers: 3})
+	assert.NoError(t, err)
+	q.Add(1)
+	defer q.Close()
+
+	for _, info := range Queues() {
+		if info.Name == "test_info" {
+			assert.Equal(t, setting.MemoryQueueType, info.Type)
+			assert.Equal(t, 3, info.Workers)
+			assert.EqualValues(t, 1, info.Length)
+			return
+		}
+	}
+	assert.Fail(t, "test_info is not listed")
+}
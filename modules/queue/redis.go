@@ -0,0 +1,131 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Unknwon/com"
+	ini "gopkg.in/ini.v1"
+	"gopkg.in/redis.v2"
+)
+
+// redisPopTimeout is the number of seconds a worker blocks waiting for an
+// identity before checking whether the queue has been closed
+const redisPopTimeout = 1
+
+// redisBackend persists the identities in a Redis server. The queue list
+// keeps the order, the waiting set is used to check for duplicates and the
+// running list holds the identities which are being processed, they are
+// queued again on open so that no work is lost when Gitea stops while
+// processing them. A Redis queue must therefore not be shared by several
+// Gitea instances.
+type redisBackend struct {
+	client     *redis.Client
+	queueKey   string
+	waitingKey string
+	runningKey string
+}
+
+// newRedisBackend connects to the Redis server described by the connection
+// string: network=tcp,addr=:6379,password=,db=0,pool_size=100,prefix=gitea_queue:
+func newRedisBackend(name, connStr string) (*redisBackend, error) {
+	cfg, err := ini.Load([]byte(strings.Replace(connStr, ",", "\n", -1)))
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &redis.Options{
+		Network: "tcp",
+	}
+	prefix := "gitea_queue:"
+	for k, v := range cfg.Section("").KeysHash() {
+		switch k {
+		case "network":
+			opt.Network = v
+		case "addr":
+			opt.Addr = v
+		case "password":
+			opt.Password = v
+		case "db":
+			opt.DB = com.StrTo(v).MustInt64()
+		case "pool_size":
+			opt.PoolSize = com.StrTo(v).MustInt()
+		case "prefix":
+			prefix = v
+		default:
+			return nil, fmt.Errorf("unsupported redis option '%s'", k)
+		}
+	}
+
+	r := &redisBackend{
+		client:     redis.NewClient(opt),
+		queueKey:   prefix + name + ":queue",
+		waitingKey: prefix + name + ":waiting",
+		runningKey: prefix + name + ":running",
+	}
+	if err = r.client.Ping().Err(); err != nil {
+		r.client.Close()
+		return nil, err
+	}
+
+	for {
+		id, err := r.client.RPop(r.runningKey).Result()
+		if err == redis.Nil {
+			break
+		} else if err != nil {
+			r.client.Close()
+			return nil, err
+		}
+		if _, err = r.push(id); err != nil {
+			r.client.Close()
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *redisBackend) push(id string) (bool, error) {
+	added, err := r.client.SAdd(r.waitingKey, id).Result()
+	if err != nil || added == 0 {
+		return false, err
+	}
+	return true, r.client.LPush(r.queueKey, id).Err()
+}
+
+func (r *redisBackend) pop(closed <-chan struct{}) (string, error) {
+	for {
+		select {
+		case <-closed:
+			return "", errClosed
+		default:
+		}
+
+		id, err := r.client.BRPopLPush(r.queueKey, r.runningKey, redisPopTimeout).Result()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		return id, r.client.SRem(r.waitingKey, id).Err()
+	}
+}
+
+func (r *redisBackend) done(id string) error {
+	return r.client.LRem(r.runningKey, 1, id).Err()
+}
+
+func (r *redisBackend) has(id string) (bool, error) {
+	return r.client.SIsMember(r.waitingKey, id).Result()
+}
+
+func (r *redisBackend) len() (int64, error) {
+	return r.client.LLen(r.queueKey).Result()
+}
+
+func (r *redisBackend) close() error {
+	return r.client.Close()
+}
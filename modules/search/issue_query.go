@@ -0,0 +1,344 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/indexer"
+	"code.gitea.io/gitea/modules/util"
+
+	"github.com/Unknwon/com"
+)
+
+// issueQueryMe the user name standing for the user performing the search
+const issueQueryMe = "@me"
+
+// issueQueryDateFormat the format of the dates of the created and updated
+// qualifiers, in the local time zone of the server
+const issueQueryDateFormat = "2006-01-02"
+
+// ErrInvalidIssueQuery represents an issue search query with an invalid
+// qualifier value
+type ErrInvalidIssueQuery struct {
+	Qualifier string
+	Value     string
+}
+
+// IsErrInvalidIssueQuery checks if an error is a ErrInvalidIssueQuery.
+func IsErrInvalidIssueQuery(err error) bool {
+	_, ok := err.(ErrInvalidIssueQuery)
+	return ok
+}
+
+func (err ErrInvalidIssueQuery) Error() string {
+	return fmt.Sprintf("invalid issue query value [qualifier: %s, value: %s]", err.Qualifier, err.Value)
+}
+
+// IssueQuery a parsed issue search query, such as
+// `crash is:open label:bug author:@me created:>2018-01-01`
+type IssueQuery struct {
+	// Keyword the words of the query which are not qualifiers, searched in
+	// the issue indexer
+	Keyword  string
+	IsClosed util.OptionalBool
+	IsPull   util.OptionalBool
+	// Labels the names of the labels the issues must all have
+	Labels []string
+	// Author, Assignee and Mentioned are user names, or @me for the user
+	// performing the search
+	Author    string
+	Assignee  string
+	Mentioned string
+	Milestone string
+	// Repos the full names of the repositories, any of which the issues
+	// must belong to
+	Repos []string
+	// the creation and update time ranges, where the lower bounds are
+	// inclusive, the upper bounds exclusive and 0 means unbounded
+	CreatedAfter  int64
+	CreatedBefore int64
+	UpdatedAfter  int64
+	UpdatedBefore int64
+}
+
+// issueQueryTerm a term of an issue search query
+type issueQueryTerm struct {
+	Text string
+	// Quoted is true if the term starts with a double quote, in which case it
+	// is never a qualifier
+	Quoted bool
+}
+
+// splitIssueQuery splits the query into its terms, separated by spaces outside
+// of double quotes. The quotes are removed.
+func splitIssueQuery(query string) []issueQueryTerm {
+	var (
+		terms    []issueQueryTerm
+		term     strings.Builder
+		inTerm   bool
+		quoted   bool
+		inQuotes bool
+	)
+	for _, r := range query {
+		switch {
+		case r == '"':
+			if !inTerm {
+				quoted = true
+			}
+			inTerm = true
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			if inTerm {
+				terms = append(terms, issueQueryTerm{Text: term.String(), Quoted: quoted})
+				term.Reset()
+				inTerm, quoted = false, false
+			}
+		default:
+			inTerm = true
+			term.WriteRune(r)
+		}
+	}
+	if inTerm {
+		terms = append(terms, issueQueryTerm{Text: term.String(), Quoted: quoted})
+	}
+	return terms
+}
+
+// parseIssueQueryDate parses a date of the created and updated qualifiers,
+// returning the timestamps of its start and of the start of the next day
+func parseIssueQueryDate(value string) (int64, int64, bool) {
+	date, err := time.ParseInLocation(issueQueryDateFormat, value, time.Local)
+	if err != nil {
+		return 0, 0, false
+	}
+	return date.Unix(), date.AddDate(0, 0, 1).Unix(), true
+}
+
+// parseIssueQueryDateRange parses the value of the created and updated
+// qualifiers: a date, a date preceded by >, >=, < or <=, or a range of dates
+// separated by .. where * stands for an unbounded end
+func parseIssueQueryDateRange(value string) (after, before int64, ok bool) {
+	switch {
+	case strings.HasPrefix(value, ">="):
+		after, _, ok = parseIssueQueryDate(value[2:])
+	case strings.HasPrefix(value, ">"):
+		_, after, ok = parseIssueQueryDate(value[1:])
+	case strings.HasPrefix(value, "<="):
+		_, before, ok = parseIssueQueryDate(value[2:])
+	case strings.HasPrefix(value, "<"):
+		before, _, ok = parseIssueQueryDate(value[1:])
+	case strings.Contains(value, ".."):
+		bounds := strings.SplitN(value, "..", 2)
+		ok = true
+		if bounds[0] != "*" {
+			after, _, ok = parseIssueQueryDate(bounds[0])
+		}
+		if ok && bounds[1] != "*" {
+			_, before, ok = parseIssueQueryDate(bounds[1])
+		}
+	default:
+		after, before, ok = parseIssueQueryDate(value)
+	}
+	return after, before, ok
+}
+
+// intersectTimeRange restricts the range to the given one
+func intersectTimeRange(after, before *int64, newAfter, newBefore int64) {
+	if newAfter > *after {
+		*after = newAfter
+	}
+	if newBefore > 0 && (*before == 0 || newBefore < *before) {
+		*before = newBefore
+	}
+}
+
+// ParseIssueQuery parses an issue search query, made of words and of
+// qualifiers among is:open, is:closed, is:issue, is:pr, label:name,
+// author:user, assignee:user, mentions:user, milestone:name,
+// repo:owner/name, created:date and updated:date. Values containing spaces
+// are written between double quotes. Unknown qualifiers are kept as words.
+func ParseIssueQuery(query string) (*IssueQuery, error) {
+	q := &IssueQuery{}
+	keywords := make([]string, 0, 5)
+	for _, term := range splitIssueQuery(query) {
+		index := strings.IndexByte(term.Text, ':')
+		if term.Quoted || index <= 0 {
+			keywords = append(keywords, term.Text)
+			continue
+		}
+		qualifier, value := strings.ToLower(term.Text[:index]), term.Text[index+1:]
+		invalid := len(value) == 0
+		switch qualifier {
+		case "is":
+			switch strings.ToLower(value) {
+			case "open":
+				q.IsClosed = util.OptionalBoolFalse
+			case "closed":
+				q.IsClosed = util.OptionalBoolTrue
+			case "issue":
+				q.IsPull = util.OptionalBoolFalse
+			case "pr", "pull":
+				q.IsPull = util.OptionalBoolTrue
+			default:
+				invalid = true
+			}
+		case "label":
+			q.Labels = append(q.Labels, value)
+		case "author":
+			q.Author = value
+		case "assignee":
+			q.Assignee = value
+		case "mentions":
+			q.Mentioned = value
+		case "milestone":
+			q.Milestone = value
+		case "repo":
+			invalid = invalid || strings.Count(value, "/") != 1
+			q.Repos = append(q.Repos, value)
+		case "created", "updated":
+			after, before, ok := parseIssueQueryDateRange(value)
+			invalid = invalid || !ok
+			if qualifier == "created" {
+				intersectTimeRange(&q.CreatedAfter, &q.CreatedBefore, after, before)
+			} else {
+				intersectTimeRange(&q.UpdatedAfter, &q.UpdatedBefore, after, before)
+			}
+		default:
+			keywords = append(keywords, term.Text)
+			continue
+		}
+		if invalid {
+			return nil, ErrInvalidIssueQuery{Qualifier: qualifier, Value: value}
+		}
+	}
+	q.Keyword = strings.Join(keywords, " ")
+	return q, nil
+}
+
+// intersectIDs returns the IDs of a which are also in b
+func intersectIDs(a, b []int64) []int64 {
+	result := make([]int64, 0, len(a))
+	for _, id := range a {
+		if com.IsSliceContainsInt64(b, id) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// forceEmptyIssues makes the options match no issue
+func forceEmptyIssues(opts *models.IssuesOptions) {
+	opts.RepoIDs = []int64{-1}
+}
+
+// issueQueryUserID returns the ID of the user with the given name, or of the
+// doer for @me. It returns false if there is no such user.
+func issueQueryUserID(name string, doer *models.User) (int64, bool, error) {
+	if name == issueQueryMe {
+		if doer == nil {
+			return 0, false, nil
+		}
+		return doer.ID, true, nil
+	}
+	user, err := models.GetUserByName(name)
+	if models.IsErrUserNotExist(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+	return user.ID, true, nil
+}
+
+// applyUserQualifier restricts the user of the options, it returns false if
+// no issue can match
+func applyUserQualifier(optsUserID *int64, name string, doer *models.User) (bool, error) {
+	if len(name) == 0 {
+		return true, nil
+	}
+	userID, ok, err := issueQueryUserID(name, doer)
+	if err != nil || !ok {
+		return false, err
+	} else if *optsUserID > 0 && *optsUserID != userID {
+		return false, nil
+	}
+	*optsUserID = userID
+	return true, nil
+}
+
+// Apply restricts the options to the issues matching the query. The query can
+// only narrow the repositories of the options, which must already be
+// restricted to those the doer (a guest if nil) can read.
+func (q *IssueQuery) Apply(opts *models.IssuesOptions, doer *models.User) error {
+	if !q.IsClosed.IsNone() {
+		opts.IsClosed = q.IsClosed
+	}
+	if !q.IsPull.IsNone() {
+		opts.IsPull = q.IsPull
+	}
+	opts.LabelNames = append(opts.LabelNames, q.Labels...)
+	if len(q.Milestone) > 0 {
+		opts.MilestoneName = q.Milestone
+	}
+	intersectTimeRange(&opts.CreatedAfterUnix, &opts.CreatedBeforeUnix, q.CreatedAfter, q.CreatedBefore)
+	intersectTimeRange(&opts.UpdatedAfterUnix, &opts.UpdatedBeforeUnix, q.UpdatedAfter, q.UpdatedBefore)
+
+	for _, c := range []struct {
+		userID *int64
+		name   string
+	}{
+		{&opts.PosterID, q.Author},
+		{&opts.AssigneeID, q.Assignee},
+		{&opts.MentionedID, q.Mentioned},
+	} {
+		if ok, err := applyUserQualifier(c.userID, c.name, doer); err != nil {
+			return err
+		} else if !ok {
+			forceEmptyIssues(opts)
+			return nil
+		}
+	}
+
+	if len(q.Repos) > 0 {
+		repoIDs := make([]int64, 0, len(q.Repos))
+		for _, fullName := range q.Repos {
+			names := strings.SplitN(fullName, "/", 2)
+			repo, err := models.GetRepositoryByOwnerAndName(names[0], names[1])
+			if models.IsErrRepoNotExist(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if len(opts.RepoIDs) == 0 || com.IsSliceContainsInt64(opts.RepoIDs, repo.ID) {
+				repoIDs = append(repoIDs, repo.ID)
+			}
+		}
+		if len(repoIDs) == 0 {
+			forceEmptyIssues(opts)
+			return nil
+		}
+		opts.RepoIDs = repoIDs
+	}
+
+	if len(q.Keyword) > 0 {
+		issueIDs, err := indexer.SearchIssuesByKeyword(opts.RepoIDs, q.Keyword)
+		if err != nil {
+			return err
+		}
+		if len(opts.IssueIDs) > 0 {
+			issueIDs = intersectIDs(opts.IssueIDs, issueIDs)
+		}
+		if len(issueIDs) == 0 {
+			forceEmptyIssues(opts)
+			return nil
+		}
+		opts.IssueIDs = issueIDs
+	}
+	return nil
+}
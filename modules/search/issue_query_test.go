@@ -0,0 +1,96 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitIssueQuery(t *testing.T) {
+	assert.Empty(t, splitIssueQuery("  "))
+	assert.Equal(t, []issueQueryTerm{
+		{Text: "crash"},
+		{Text: "milestone:v1 beta"},
+		{Text: "out of memory", Quoted: true},
+		{Text: "label:bug"},
+	}, splitIssueQuery(` crash milestone:"v1 beta"  "out of memory" label:bug`))
+}
+
+func TestParseIssueQueryDateRange(t *testing.T) {
+	day := func(date string) int64 {
+		d, err := time.ParseInLocation(issueQueryDateFormat, date, time.Local)
+		assert.NoError(t, err)
+		return d.Unix()
+	}
+
+	for _, test := range []struct {
+		Value          string
+		ExpectedAfter  int64
+		ExpectedBefore int64
+	}{
+		{"2018-01-01", day("2018-01-01"), day("2018-01-02")},
+		{">2018-01-01", day("2018-01-02"), 0},
+		{">=2018-01-01", day("2018-01-01"), 0},
+		{"<2018-01-01", 0, day("2018-01-01")},
+		{"<=2018-01-01", 0, day("2018-01-02")},
+		{"2018-01-01..2018-02-01", day("2018-01-01"), day("2018-02-02")},
+		{"*..2018-02-01", 0, day("2018-02-02")},
+		{"2018-01-01..*", day("2018-01-01"), 0},
+	} {
+		after, before, ok := parseIssueQueryDateRange(test.Value)
+		assert.True(t, ok, test.Value)
+		assert.Equal(t, test.ExpectedAfter, after, test.Value)
+		assert.Equal(t, test.ExpectedBefore, before, test.Value)
+	}
+
+	for _, value := range []string{"", ">", "yesterday", "2018-13-01", "2018-01-01..", "..2018-01-01"} {
+		_, _, ok := parseIssueQueryDateRange(value)
+		assert.False(t, ok, value)
+	}
+}
+
+func TestParseIssueQuery(t *testing.T) {
+	q, err := ParseIssueQuery(`crash is:open is:pr label:bug Label:"needs review" author:@me ` +
+		`assignee:user2 mentions:user3 milestone:"v1.0" repo:user2/repo1 foo:bar ` +
+		`created:>=2018-01-01 created:<2018-06-01 "is:closed"`)
+	assert.NoError(t, err)
+	assert.Equal(t, "crash foo:bar is:closed", q.Keyword)
+	assert.True(t, q.IsClosed.IsFalse())
+	assert.True(t, q.IsPull.IsTrue())
+	assert.Equal(t, []string{"bug", "needs review"}, q.Labels)
+	assert.Equal(t, "@me", q.Author)
+	assert.Equal(t, "user2", q.Assignee)
+	assert.Equal(t, "user3", q.Mentioned)
+	assert.Equal(t, "v1.0", q.Milestone)
+	assert.Equal(t, []string{"user2/repo1"}, q.Repos)
+	assert.NotZero(t, q.CreatedAfter)
+	assert.NotZero(t, q.CreatedBefore)
+	assert.True(t, q.CreatedAfter < q.CreatedBefore)
+	assert.Zero(t, q.UpdatedAfter)
+	assert.Zero(t, q.UpdatedBefore)
+
+	q, err = ParseIssueQuery("")
+	assert.NoError(t, err)
+	assert.Equal(t, &IssueQuery{}, q)
+
+	for _, test := range []struct {
+		Query             string
+		ExpectedQualifier string
+	}{
+		{"is:draft", "is"},
+		{"label:", "label"},
+		{"repo:repo1", "repo"},
+		{"created:yesterday", "created"},
+		{"updated:<", "updated"},
+	} {
+		_, err := ParseIssueQuery(test.Query)
+		if assert.True(t, IsErrInvalidIssueQuery(err), test.Query) {
+			assert.Equal(t, test.ExpectedQualifier, err.(ErrInvalidIssueQuery).Qualifier)
+		}
+	}
+}
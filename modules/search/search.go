@@ -10,6 +10,8 @@ import (
 	gotemplate "html/template"
 	"strings"
 
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/analyze"
 	"code.gitea.io/gitea/modules/highlight"
 	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/util"
@@ -19,12 +21,27 @@ import (
 type Result struct {
 	RepoID         int64
 	Filename       string
+	Language       string
 	HighlightClass string
 	LineNumbers    []int
 	FormattedLines gotemplate.HTML
+	Lines          []*ResultLine
+}
+
+// ResultLine a line of a search result, with the offsets of the matching
+// part of its content (equal if the line does not match)
+type ResultLine struct {
+	Number         int
+	Content        string
+	HighlightStart int
+	HighlightEnd   int
 }
 
 func indices(content string, selectionStartIndex, selectionEndIndex int) (int, int) {
+	// a selection out of the content falls back to the top of the file
+	selectionStartIndex = util.Max(util.Min(selectionStartIndex, len(content)), 0)
+	selectionEndIndex = util.Max(util.Min(selectionEndIndex, len(content)), selectionStartIndex)
+
 	startIndex := selectionStartIndex
 	numLinesBefore := 0
 	for ; startIndex > 0; startIndex-- {
@@ -67,14 +84,21 @@ func searchResult(result *indexer.RepoSearchResult, startIndex, endIndex int) (*
 
 	contentLines := strings.SplitAfter(result.Content[startIndex:endIndex], "\n")
 	lineNumbers := make([]int, len(contentLines))
+	lines := make([]*ResultLine, len(contentLines))
 	index := startIndex
 	for i, line := range contentLines {
 		var err error
+		lines[i] = &ResultLine{
+			Number:  startLineNum + i,
+			Content: strings.TrimSuffix(line, "\n"),
+		}
 		if index < result.EndIndex &&
 			result.StartIndex < index+len(line) &&
 			result.StartIndex < result.EndIndex {
 			openActiveIndex := util.Max(result.StartIndex-index, 0)
 			closeActiveIndex := util.Min(result.EndIndex-index, len(line))
+			lines[i].HighlightStart = util.Min(openActiveIndex, len(lines[i].Content))
+			lines[i].HighlightEnd = util.Min(closeActiveIndex, len(lines[i].Content))
 			err = writeStrings(&formattedLinesBuffer,
 				`<li>`,
 				html.EscapeString(line[:openActiveIndex]),
@@ -101,19 +125,21 @@ func searchResult(result *indexer.RepoSearchResult, startIndex, endIndex int) (*
 	return &Result{
 		RepoID:         result.RepoID,
 		Filename:       result.Filename,
+		Language:       result.Language,
 		HighlightClass: highlight.FileNameToHighlightClass(result.Filename),
 		LineNumbers:    lineNumbers,
 		FormattedLines: gotemplate.HTML(formattedLinesBuffer.String()),
+		Lines:          lines,
 	}, nil
 }
 
-// PerformSearch perform a search on a repository
-func PerformSearch(repoIDs []int64, keyword string, page, pageSize int) (int, []*Result, error) {
-	if len(keyword) == 0 {
+// PerformSearch perform a search on repositories
+func PerformSearch(opts *indexer.RepoSearchOptions) (int, []*Result, error) {
+	if len(opts.Keyword) == 0 {
 		return 0, nil, nil
 	}
 
-	total, results, err := indexer.SearchRepoByKeyword(repoIDs, keyword, page, pageSize)
+	total, results, err := indexer.SearchRepoByKeyword(opts)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -129,3 +155,65 @@ func PerformSearch(repoIDs []int64, keyword string, page, pageSize int) (int, []
 	}
 	return int(total), displayResults, nil
 }
+
+// CodeSearchOptions options to search for code in all the repositories
+// whose code the doer can read
+type CodeSearchOptions struct {
+	Doer        *models.User
+	OwnerID     int64
+	Keyword     string
+	Language    string
+	PathPattern string
+	Page        int
+	PageSize    int
+}
+
+// SearchCode searches for code in all the repositories whose code the doer
+// (a guest if nil) can read. It returns the total number of results, the
+// results of the requested page and the repositories of these results.
+func SearchCode(opts *CodeSearchOptions) (int, []*Result, map[int64]*models.Repository, error) {
+	if len(opts.Keyword) == 0 {
+		return 0, nil, nil, nil
+	}
+
+	var (
+		repoIDs []int64
+		err     error
+	)
+	// site administrators can read all the repositories, so there is no need
+	// to restrict the search unless an owner is given
+	if opts.Doer == nil || !opts.Doer.IsAdmin || opts.OwnerID > 0 {
+		repoIDs, err = models.FindUserCodeAccessibleRepoIDs(opts.Doer, opts.OwnerID)
+		if err != nil {
+			return 0, nil, nil, err
+		} else if len(repoIDs) == 0 {
+			return 0, nil, nil, nil
+		}
+	}
+
+	language := opts.Language
+	if len(language) > 0 {
+		language = analyze.CanonicalCodeLanguage(language)
+	}
+	total, results, err := PerformSearch(&indexer.RepoSearchOptions{
+		RepoIDs:     repoIDs,
+		Keyword:     opts.Keyword,
+		Language:    language,
+		PathPattern: opts.PathPattern,
+		Page:        opts.Page,
+		PageSize:    opts.PageSize,
+	})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	resultRepoIDs := make([]int64, 0, len(results))
+	for _, result := range results {
+		resultRepoIDs = append(resultRepoIDs, result.RepoID)
+	}
+	repoMaps, err := models.GetRepositoriesMapByIDs(resultRepoIDs)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return total, results, repoMaps, nil
+}
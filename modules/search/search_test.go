@@ -0,0 +1,45 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package search
+
+import (
+	"testing"
+
+	"code.gitea.io/gitea/modules/indexer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndices(t *testing.T) {
+	content := "line 1\nline 2\nline 3\nline 4\nline 5\n"
+
+	start, end := indices(content, 14, 20)
+	assert.Equal(t, "line 2\nline 3\nline 4", content[start:end])
+
+	// a result without selection is shown from the top of the file
+	start, end = indices(content, -1, -1)
+	assert.Equal(t, "line 1\nline 2", content[start:end])
+
+	start, end = indices(content, 100, 200)
+	assert.Equal(t, "line 5\n", content[start:end])
+}
+
+func TestSearchResult_noHighlight(t *testing.T) {
+	result := &indexer.RepoSearchResult{
+		RepoID:     1,
+		StartIndex: -1,
+		EndIndex:   -1,
+		Filename:   "main.go",
+		Content:    "package main\n\nfunc main() {}\n",
+	}
+	startIndex, endIndex := indices(result.Content, result.StartIndex, result.EndIndex)
+	res, err := searchResult(result, startIndex, endIndex)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, res.LineNumbers)
+	if assert.Len(t, res.Lines, 2) {
+		assert.Equal(t, "package main", res.Lines[0].Content)
+		assert.Equal(t, res.Lines[0].HighlightStart, res.Lines[0].HighlightEnd)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"path/filepath"
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+)
+
+const (
+	// MemoryQueueType keeps the queued items in memory, they are lost on restart
+	MemoryQueueType = "memory"
+	// BoltQueueType persists the queued items in a BoltDB file on the local disk
+	BoltQueueType = "bolt"
+	// RedisQueueType persists the queued items in a Redis server
+	RedisQueueType = "redis"
+)
+
+// QueueSettings represents the configuration of a queue
+type QueueSettings struct {
+	Type    string
+	DataDir string
+	Length  int
+	Workers int
+	ConnStr string
+}
+
+// Queue settings shared by all queues
+var Queue = QueueSettings{
+	Type:    MemoryQueueType,
+	Workers: 1,
+	ConnStr: "addr=127.0.0.1:6379,db=0",
+}
+
+func newQueueService() {
+	sec := Cfg.Section("queue")
+	Queue.Type = strings.ToLower(sec.Key("TYPE").MustString(MemoryQueueType))
+	Queue.DataDir = sec.Key("DATA_DIR").MustString(filepath.Join(AppDataPath, "queues"))
+	if !filepath.IsAbs(Queue.DataDir) {
+		Queue.DataDir = filepath.Join(AppWorkPath, Queue.DataDir)
+	}
+	Queue.Workers = sec.Key("WORKERS").MustInt(1)
+	Queue.ConnStr = sec.Key("CONN_STR").MustString(Queue.ConnStr)
+	checkQueueType(Queue.Type, sec.Name())
+}
+
+func checkQueueType(queueType, section string) {
+	switch queueType {
+	case MemoryQueueType, BoltQueueType, RedisQueueType:
+	default:
+		log.Fatal(4, "Unknown queue type '%s' in section [%s]", queueType, section)
+	}
+}
+
+// GetQueueSettings returns the settings of the named queue, every key which is
+// not set in the [queue.<name>] section falls back to the [queue] section.
+// The length only applies to memory queues, persistent queues are unbounded.
+func GetQueueSettings(name string, defaultLength int) QueueSettings {
+	sec := Cfg.Section("queue." + name)
+	q := QueueSettings{
+		Type:    strings.ToLower(sec.Key("TYPE").MustString(Queue.Type)),
+		DataDir: sec.Key("DATA_DIR").MustString(Queue.DataDir),
+		Length:  sec.Key("LENGTH").MustInt(defaultLength),
+		Workers: sec.Key("WORKERS").MustInt(Queue.Workers),
+		ConnStr: sec.Key("CONN_STR").MustString(Queue.ConnStr),
+	}
+	if !filepath.IsAbs(q.DataDir) {
+		q.DataDir = filepath.Join(AppWorkPath, q.DataDir)
+	}
+	if q.Workers < 1 {
+		q.Workers = 1
+	}
+	checkQueueType(q.Type, sec.Name())
+	return q
+}
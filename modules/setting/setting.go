@@ -180,25 +180,39 @@ var (
 
 	// Indexer settings
 	Indexer struct {
+		IssueType          string
 		IssuePath          string
+		IssueConnStr       string
+		IssueIndexerName   string
 		RepoIndexerEnabled bool
+		RepoType           string
 		RepoPath           string
+		RepoConnStr        string
+		RepoIndexerName    string
 		UpdateQueueLength  int
 		MaxIndexerFileSize int64
 	}
 
 	// Webhook settings
 	Webhook = struct {
-		QueueLength    int
-		DeliverTimeout int
-		SkipTLSVerify  bool
-		Types          []string
-		PagingNum      int
+		QueueLength          int
+		DeliverTimeout       int
+		SkipTLSVerify        bool
+		Types                []string
+		PagingNum            int
+		MaxAttempts          int
+		RetryInterval        time.Duration
+		DisableAfterFailures int
+		SecretInPayload      bool
 	}{
-		QueueLength:    1000,
-		DeliverTimeout: 5,
-		SkipTLSVerify:  false,
-		PagingNum:      10,
+		QueueLength:          1000,
+		DeliverTimeout:       5,
+		SkipTLSVerify:        false,
+		PagingNum:            10,
+		MaxAttempts:          5,
+		RetryInterval:        time.Minute,
+		DisableAfterFailures: 0,
+		SecretInPayload:      false,
 	}
 
 	// Repository settings
@@ -414,6 +428,11 @@ var (
 			RunAtStart bool
 			Schedule   string
 		} `ini:"cron.update_mirrors"`
+		UpdatePushMirror struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		} `ini:"cron.update_push_mirrors"`
 		RepoHealthCheck struct {
 			Enabled    bool
 			RunAtStart bool
@@ -454,6 +473,15 @@ var (
 			RunAtStart: false,
 			Schedule:   "@every 10m",
 		},
+		UpdatePushMirror: struct {
+			Enabled    bool
+			RunAtStart bool
+			Schedule   string
+		}{
+			Enabled:    true,
+			RunAtStart: false,
+			Schedule:   "@every 10m",
+		},
 		RepoHealthCheck: struct {
 			Enabled    bool
 			RunAtStart bool
@@ -562,6 +590,21 @@ var (
 		MaxResponseItems: 50,
 	}
 
+	// OAuth2 settings of Gitea as an OAuth2 provider
+	OAuth2 = struct {
+		Enable                     bool
+		AccessTokenExpirationTime  int64
+		RefreshTokenExpirationTime int64
+		InvalidateRefreshTokens    bool
+		JWTSigningPrivateKeyFile   string `ini:"JWT_SIGNING_PRIVATE_KEY_FILE"`
+	}{
+		Enable:                     true,
+		AccessTokenExpirationTime:  3600,
+		RefreshTokenExpirationTime: 730,
+		InvalidateRefreshTokens:    false,
+		JWTSigningPrivateKeyFile:   "jwt/private.pem",
+	}
+
 	U2F = struct {
 		AppID         string
 		TrustedFacets []string
@@ -1129,6 +1172,11 @@ func NewContext() {
 		log.Fatal(4, "Failed to map API settings: %v", err)
 	} else if err = Cfg.Section("metrics").MapTo(&Metrics); err != nil {
 		log.Fatal(4, "Failed to map Metrics settings: %v", err)
+	} else if err = Cfg.Section("oauth2").MapTo(&OAuth2); err != nil {
+		log.Fatal(4, "Failed to map OAuth2 settings: %v", err)
+	}
+	if !filepath.IsAbs(OAuth2.JWTSigningPrivateKeyFile) {
+		OAuth2.JWTSigningPrivateKeyFile = filepath.Join(AppDataPath, OAuth2.JWTSigningPrivateKeyFile)
 	}
 
 	sec = Cfg.Section("mirror")
@@ -1204,6 +1252,9 @@ func NewContext() {
 			IsInputFile:    sec.Key("IS_INPUT_FILE").MustBool(false),
 		})
 	}
+	newStorageService()
+	newQueueService()
+
 	sec = Cfg.Section("U2F")
 	U2F.TrustedFacets, _ = shellquote.Split(sec.Key("TRUSTED_FACETS").MustString(strings.TrimRight(AppURL, "/")))
 	U2F.AppID = sec.Key("APP_ID").MustString(strings.TrimRight(AppURL, "/"))
@@ -1624,8 +1675,18 @@ func newWebhookService() {
 	Webhook.QueueLength = sec.Key("QUEUE_LENGTH").MustInt(1000)
 	Webhook.DeliverTimeout = sec.Key("DELIVER_TIMEOUT").MustInt(5)
 	Webhook.SkipTLSVerify = sec.Key("SKIP_TLS_VERIFY").MustBool()
-	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk"}
+	Webhook.Types = []string{"gitea", "gogs", "slack", "discord", "dingtalk", "msteams", "telegram", "matrix"}
 	Webhook.PagingNum = sec.Key("PAGING_NUM").MustInt(10)
+	Webhook.MaxAttempts = sec.Key("MAX_ATTEMPTS").MustInt(5)
+	if Webhook.MaxAttempts < 1 {
+		Webhook.MaxAttempts = 1
+	}
+	Webhook.RetryInterval = sec.Key("RETRY_INTERVAL").MustDuration(time.Minute)
+	Webhook.DisableAfterFailures = sec.Key("DISABLE_AFTER_FAILURES").MustInt(0)
+	Webhook.SecretInPayload = sec.Key("SECRET_IN_PAYLOAD").MustBool(false)
+	if Webhook.SecretInPayload {
+		log.Warn("webhook.SECRET_IN_PAYLOAD is deprecated, the receivers should verify the signature of the deliveries instead")
+	}
 }
 
 // NewServices initializes the services
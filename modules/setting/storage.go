@@ -0,0 +1,87 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/modules/log"
+
+	ini "gopkg.in/ini.v1"
+)
+
+const (
+	// LocalStorageType stores objects on the local file system
+	LocalStorageType = "local"
+	// MinioStorageType stores objects in a S3 compatible object storage like MinIO
+	MinioStorageType = "minio"
+)
+
+// MinioStorageConfig represents the configuration of a S3 compatible object storage
+type MinioStorageConfig struct {
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	Bucket          string
+	Location        string
+	BasePath        string
+	UseSSL          bool
+}
+
+// Storage represents the configuration of an object storage
+type Storage struct {
+	Type  string
+	Path  string
+	Minio MinioStorageConfig
+}
+
+// Storage settings
+var (
+	AttachmentStorage Storage
+	AvatarStorage     Storage
+	UploadStorage     Storage
+	LFSStorage        Storage
+)
+
+// getStorage reads the storage configuration from the given section, falling
+// back to the shared [storage] section for every key which is not set
+func getStorage(sec *ini.Section, localPath, defaultBasePath string) Storage {
+	shared := Cfg.Section("storage")
+	key := func(name, defaultValue string) string {
+		if value := sec.Key(name).String(); len(value) > 0 {
+			return value
+		}
+		return shared.Key(name).MustString(defaultValue)
+	}
+
+	storage := Storage{
+		Type: strings.ToLower(key("STORAGE_TYPE", LocalStorageType)),
+		Path: localPath,
+		Minio: MinioStorageConfig{
+			Endpoint:        key("MINIO_ENDPOINT", "localhost:9000"),
+			AccessKeyID:     key("MINIO_ACCESS_KEY_ID", ""),
+			SecretAccessKey: key("MINIO_SECRET_ACCESS_KEY", ""),
+			Bucket:          key("MINIO_BUCKET", "gitea"),
+			Location:        key("MINIO_LOCATION", "us-east-1"),
+			// the base path is never shared so that all storages can use the same bucket
+			BasePath: sec.Key("MINIO_BASE_PATH").MustString(defaultBasePath),
+			UseSSL:   key("MINIO_USE_SSL", "false") == "true",
+		},
+	}
+
+	switch storage.Type {
+	case LocalStorageType, MinioStorageType:
+	default:
+		log.Fatal(4, "Unknown storage type '%s' in section [%s]", storage.Type, sec.Name())
+	}
+	return storage
+}
+
+func newStorageService() {
+	AttachmentStorage = getStorage(Cfg.Section("attachment"), AttachmentPath, "attachments/")
+	AvatarStorage = getStorage(Cfg.Section("picture"), AvatarUploadPath, "avatars/")
+	UploadStorage = getStorage(Cfg.Section("repository.upload"), Repository.Upload.TempPath, "uploads/")
+	LFSStorage = getStorage(Cfg.Section("lfs"), LFS.ContentPath, "lfs/")
+}
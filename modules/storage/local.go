@@ -0,0 +1,105 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var _ ObjectStorage = &LocalStorage{}
+
+// tmpPrefix is the name prefix of files which are still being written
+const tmpPrefix = ".tmp-"
+
+// LocalStorage stores objects as files below a directory of the local file system
+type LocalStorage struct {
+	dir string
+}
+
+// NewLocalStorage returns a local file system storage rooted at dir
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+func (l *LocalStorage) localPath(p string) string {
+	return filepath.Join(l.dir, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+// Open opens the file at the given path for reading
+func (l *LocalStorage) Open(p string) (Object, error) {
+	return os.Open(l.localPath(p))
+}
+
+// Save writes the content of the reader to a temporary file which is then
+// moved to the given path, so that readers never see partial content
+func (l *LocalStorage) Save(p string, r io.Reader) (int64, error) {
+	localPath := l.localPath(p)
+	if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		return 0, err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(localPath), tmpPrefix+filepath.Base(localPath))
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	written, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err = tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err = os.Chmod(tmpPath, 0644); err != nil {
+		return 0, err
+	}
+	return written, os.Rename(tmpPath, localPath)
+}
+
+// Stat returns information about the file at the given path
+func (l *LocalStorage) Stat(p string) (os.FileInfo, error) {
+	return os.Stat(l.localPath(p))
+}
+
+// Delete deletes the file at the given path
+func (l *LocalStorage) Delete(p string) error {
+	if err := os.Remove(l.localPath(p)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// IterateObjects calls fn for every file below the storage directory
+func (l *LocalStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	return filepath.Walk(l.dir, func(localPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(info.Name(), tmpPrefix) {
+			return nil
+		}
+		relPath, err := filepath.Rel(l.dir, localPath)
+		if err != nil {
+			return err
+		}
+		obj, err := os.Open(localPath)
+		if err != nil {
+			return err
+		}
+		defer obj.Close()
+		return fn(filepath.ToSlash(relPath), obj)
+	})
+}
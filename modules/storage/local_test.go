@@ -0,0 +1,82 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testObjectStorage(t *testing.T, s ObjectStorage) {
+	written, err := s.Save("a/b/object", strings.NewReader("some content"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 12, written)
+
+	fi, err := s.Stat("a/b/object")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 12, fi.Size())
+
+	obj, err := s.Open("a/b/object")
+	if assert.NoError(t, err) {
+		content, err := ioutil.ReadAll(obj)
+		assert.NoError(t, err)
+		assert.EqualValues(t, "some content", string(content))
+
+		_, err = obj.Seek(5, io.SeekStart)
+		assert.NoError(t, err)
+		content, err = ioutil.ReadAll(obj)
+		assert.NoError(t, err)
+		assert.EqualValues(t, "content", string(content))
+		assert.NoError(t, obj.Close())
+	}
+
+	// paths can not escape the storage
+	_, err = s.Save("../../escaped", strings.NewReader("escaped"))
+	assert.NoError(t, err)
+	_, err = s.Stat("escaped")
+	assert.NoError(t, err)
+
+	paths := make(map[string]string)
+	assert.NoError(t, s.IterateObjects(func(path string, obj Object) error {
+		content, err := ioutil.ReadAll(obj)
+		paths[path] = string(content)
+		return err
+	}))
+	assert.EqualValues(t, map[string]string{
+		"a/b/object": "some content",
+		"escaped":    "escaped",
+	}, paths)
+
+	copyDir, err := ioutil.TempDir("", "storage-copy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(copyDir)
+	dst, err := NewLocalStorage(copyDir)
+	assert.NoError(t, err)
+	written, err = Copy(dst, "copied", s, "a/b/object")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 12, written)
+
+	assert.NoError(t, s.Delete("a/b/object"))
+	assert.NoError(t, s.Delete("a/b/object"))
+	_, err = s.Stat("a/b/object")
+	assert.True(t, IsErrObjectNotExist(err))
+	_, err = s.Open("a/b/object")
+	assert.True(t, IsErrObjectNotExist(err))
+}
+
+func TestLocalStorage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := NewLocalStorage(dir)
+	assert.NoError(t, err)
+	testObjectStorage(t, s)
+}
@@ -0,0 +1,410 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+var _ ObjectStorage = &MinioStorage{}
+
+// emptySHA256 is the hex encoded SHA256 hash of an empty payload
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// MinioStorage stores objects in a bucket of a S3 compatible object storage
+// like MinIO, requests are signed with AWS signature version 4
+type MinioStorage struct {
+	client          *http.Client
+	scheme          string
+	host            string
+	bucket          string
+	location        string
+	basePath        string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+// MinioError represents an error response of the object storage
+type MinioError struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (err *MinioError) Error() string {
+	return fmt.Sprintf("object storage error [status: %d, code: %s]: %s", err.StatusCode, err.Code, err.Message)
+}
+
+// NewMinioStorage returns a storage for the configured bucket, the bucket is
+// created if it does not exist yet
+func NewMinioStorage(cfg setting.MinioStorageConfig) (*MinioStorage, error) {
+	m := &MinioStorage{
+		client:          &http.Client{},
+		scheme:          "http",
+		host:            cfg.Endpoint,
+		bucket:          cfg.Bucket,
+		location:        cfg.Location,
+		basePath:        strings.Trim(cfg.BasePath, "/"),
+		accessKeyID:     cfg.AccessKeyID,
+		secretAccessKey: cfg.SecretAccessKey,
+	}
+	if cfg.UseSSL {
+		m.scheme = "https"
+	}
+	if len(m.basePath) > 0 {
+		m.basePath += "/"
+	}
+	if len(m.location) == 0 {
+		m.location = "us-east-1"
+	}
+
+	resp, err := m.do("HEAD", "", nil, nil, 0, emptySHA256)
+	if err == nil {
+		resp.Body.Close()
+		return m, nil
+	}
+	if minioErr, ok := err.(*MinioError); !ok || minioErr.StatusCode != http.StatusNotFound {
+		return nil, err
+	}
+
+	var body []byte
+	if m.location != "us-east-1" {
+		body = []byte(`<CreateBucketConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><LocationConstraint>` +
+			m.location + `</LocationConstraint></CreateBucketConfiguration>`)
+	}
+	hash := sha256.Sum256(body)
+	resp, err = m.do("PUT", "", nil, bytes.NewReader(body), int64(len(body)), hex.EncodeToString(hash[:]))
+	if err != nil {
+		return nil, fmt.Errorf("create bucket %s: %v", m.bucket, err)
+	}
+	resp.Body.Close()
+	return m, nil
+}
+
+func (m *MinioStorage) objectKey(p string) string {
+	return m.basePath + strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// Open opens the object at the given path for reading
+func (m *MinioStorage) Open(p string) (Object, error) {
+	key := m.objectKey(p)
+	fi, err := m.stat(key)
+	if err != nil {
+		return nil, err
+	}
+	return &minioObject{storage: m, key: key, size: fi.Size()}, nil
+}
+
+// Save uploads the content of the reader to the given path. The content is
+// buffered in a temporary file first as its size and hash have to be known
+// before the upload starts.
+func (m *MinioStorage) Save(p string, r io.Reader) (int64, error) {
+	tmp, err := ioutil.TempFile("", "gitea-storage")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	resp, err := m.do("PUT", m.objectKey(p), nil, tmp, written, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return written, nil
+}
+
+// Stat returns information about the object at the given path
+func (m *MinioStorage) Stat(p string) (os.FileInfo, error) {
+	return m.stat(m.objectKey(p))
+}
+
+func (m *MinioStorage) stat(key string) (os.FileInfo, error) {
+	resp, err := m.do("HEAD", key, nil, nil, 0, emptySHA256)
+	if err != nil {
+		if minioErr, ok := err.(*MinioError); ok && minioErr.StatusCode == http.StatusNotFound {
+			return nil, ErrObjectNotExist
+		}
+		return nil, err
+	}
+	resp.Body.Close()
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &minioFileInfo{
+		name:    path.Base(key),
+		size:    resp.ContentLength,
+		modTime: modTime,
+	}, nil
+}
+
+// Delete deletes the object at the given path
+func (m *MinioStorage) Delete(p string) error {
+	resp, err := m.do("DELETE", m.objectKey(p), nil, nil, 0, emptySHA256)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// IterateObjects calls fn for every object below the base path of the storage
+func (m *MinioStorage) IterateObjects(fn func(path string, obj Object) error) error {
+	query := url.Values{
+		"list-type": {"2"},
+		"prefix":    {m.basePath},
+	}
+	for {
+		resp, err := m.do("GET", "", query, nil, 0, emptySHA256)
+		if err != nil {
+			return err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, content := range result.Contents {
+			obj := &minioObject{storage: m, key: content.Key, size: content.Size}
+			err := fn(strings.TrimPrefix(content.Key, m.basePath), obj)
+			obj.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated {
+			return nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// do sends a signed request for the given object key, or the bucket itself
+// if the key is empty, and turns error responses into a MinioError
+func (m *MinioStorage) do(method, key string, query url.Values, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	return m.doWithHeader(method, key, query, nil, body, size, payloadHash)
+}
+
+func (m *MinioStorage) doWithHeader(method, key string, query url.Values, header http.Header, body io.Reader, size int64, payloadHash string) (*http.Response, error) {
+	escapedPath := "/" + uriEncode(m.bucket, false)
+	if len(key) > 0 {
+		escapedPath += "/" + uriEncode(key, false)
+	}
+	canonicalQuery := canonicalQueryString(query)
+	rawURL := m.scheme + "://" + m.host + escapedPath
+	if len(canonicalQuery) > 0 {
+		rawURL += "?" + canonicalQuery
+	}
+
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	m.sign(req, escapedPath, canonicalQuery, payloadHash, time.Now().UTC())
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	minioErr := &MinioError{StatusCode: resp.StatusCode}
+	if method != "HEAD" {
+		xml.NewDecoder(resp.Body).Decode(minioErr)
+	}
+	if len(minioErr.Message) == 0 {
+		minioErr.Message = http.StatusText(resp.StatusCode)
+	}
+	return nil, minioErr
+}
+
+// sign adds the AWS signature version 4 authorization to the request
+func (m *MinioStorage) sign(req *http.Request, escapedPath, canonicalQuery, payloadHash string, t time.Time) {
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapedPath,
+		canonicalQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + m.location + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+m.secretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, m.location)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+m.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode percent-encodes everything but unreserved characters as required
+// by the signature, slashes are kept unless encodeSlash is set
+func uriEncode(s string, encodeSlash bool) string {
+	var buf bytes.Buffer
+	for _, b := range []byte(s) {
+		switch {
+		case 'A' <= b && b <= 'Z', 'a' <= b && b <= 'z', '0' <= b && b <= '9',
+			b == '-', b == '_', b == '.', b == '~':
+			buf.WriteByte(b)
+		case b == '/' && !encodeSlash:
+			buf.WriteByte(b)
+		default:
+			fmt.Fprintf(&buf, "%%%02X", b)
+		}
+	}
+	return buf.String()
+}
+
+func canonicalQueryString(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// minioObject reads an object with ranged requests, the request is only sent
+// on the first read after opening or seeking
+type minioObject struct {
+	storage *MinioStorage
+	key     string
+	size    int64
+	offset  int64
+	body    io.ReadCloser
+}
+
+func (o *minioObject) Read(p []byte) (int, error) {
+	if o.offset >= o.size {
+		return 0, io.EOF
+	}
+	if o.body == nil {
+		header := http.Header{}
+		if o.offset > 0 {
+			header.Set("Range", "bytes="+strconv.FormatInt(o.offset, 10)+"-")
+		}
+		resp, err := o.storage.doWithHeader("GET", o.key, nil, header, nil, 0, emptySHA256)
+		if err != nil {
+			return 0, err
+		}
+		o.body = resp.Body
+	}
+	n, err := o.body.Read(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func (o *minioObject) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = o.offset + offset
+	case io.SeekEnd:
+		newOffset = o.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if newOffset < 0 {
+		return 0, fmt.Errorf("negative position: %d", newOffset)
+	}
+	if newOffset != o.offset {
+		o.Close()
+		o.offset = newOffset
+	}
+	return newOffset, nil
+}
+
+func (o *minioObject) Close() error {
+	if o.body == nil {
+		return nil
+	}
+	err := o.body.Close()
+	o.body = nil
+	return err
+}
+
+type minioFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (fi *minioFileInfo) Name() string       { return fi.name }
+func (fi *minioFileInfo) Size() int64        { return fi.size }
+func (fi *minioFileInfo) Mode() os.FileMode  { return 0644 }
+func (fi *minioFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *minioFileInfo) IsDir() bool        { return false }
+func (fi *minioFileInfo) Sys() interface{}   { return nil }
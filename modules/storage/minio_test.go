@@ -0,0 +1,130 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal in-memory implementation of the parts of the S3 API used by MinioStorage
+type fakeS3 struct {
+	t       *testing.T
+	buckets map[string]map[string][]byte
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	assert.True(s.t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/"))
+	assert.NotEmpty(s.t, r.Header.Get("X-Amz-Date"))
+
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket, ok := s.buckets[parts[0]]
+	if len(parts) == 1 {
+		switch {
+		case r.Method == "PUT":
+			s.buckets[parts[0]] = make(map[string][]byte)
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "GET":
+			s.list(w, r, bucket)
+		}
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	key := parts[1]
+	switch r.Method {
+	case "PUT":
+		content, err := ioutil.ReadAll(r.Body)
+		assert.NoError(s.t, err)
+		assert.EqualValues(s.t, len(content), r.ContentLength)
+		bucket[key] = content
+	case "DELETE":
+		delete(bucket, key)
+		w.WriteHeader(http.StatusNoContent)
+	case "HEAD", "GET":
+		content, ok := bucket[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>")
+			return
+		}
+		if rng := r.Header.Get("Range"); len(rng) > 0 {
+			from, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			assert.NoError(s.t, err)
+			content = content[from:]
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content)
+	}
+}
+
+func (s *fakeS3) list(w http.ResponseWriter, r *http.Request, bucket map[string][]byte) {
+	assert.EqualValues(s.t, "2", r.URL.Query().Get("list-type"))
+	var result listBucketResult
+	keys := make([]string, 0, len(bucket))
+	for key := range bucket {
+		if strings.HasPrefix(key, r.URL.Query().Get("prefix")) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// return a single object per page to exercise the pagination
+	if token := r.URL.Query().Get("continuation-token"); len(token) > 0 {
+		for len(keys) > 0 && keys[0] <= token {
+			keys = keys[1:]
+		}
+	}
+	if len(keys) > 1 {
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[0]
+		keys = keys[:1]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, struct {
+			Key  string `xml:"Key"`
+			Size int64  `xml:"Size"`
+		}{key, int64(len(bucket[key]))})
+	}
+	assert.NoError(s.t, xml.NewEncoder(w).Encode(&result))
+}
+
+func TestMinioStorage(t *testing.T) {
+	fake := &fakeS3{t: t, buckets: make(map[string]map[string][]byte)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s, err := NewMinioStorage(setting.MinioStorageConfig{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		AccessKeyID:     "access",
+		SecretAccessKey: "secret",
+		Bucket:          "gitea",
+		BasePath:        "base/",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, fake.buckets, "gitea")
+
+	// objects of other storages sharing the bucket are not visible
+	fake.buckets["gitea"]["other/object"] = []byte("other")
+
+	testObjectStorage(t, s)
+	assert.Len(t, fake.buckets["gitea"], 2)
+	assert.Contains(t, fake.buckets["gitea"], "base/escaped")
+}
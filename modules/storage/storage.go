@@ -0,0 +1,93 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"code.gitea.io/gitea/modules/setting"
+)
+
+// ErrObjectNotExist is returned when an object does not exist in a storage
+var ErrObjectNotExist = errors.New("object does not exist")
+
+// IsErrObjectNotExist checks if an error means that an object does not exist
+func IsErrObjectNotExist(err error) bool {
+	return err == ErrObjectNotExist || os.IsNotExist(err)
+}
+
+// Object represents an object opened for reading from a storage
+type Object interface {
+	io.ReadCloser
+	io.Seeker
+}
+
+// ObjectStorage represents a storage of objects identified by slash separated paths
+type ObjectStorage interface {
+	// Open opens the object at the given path for reading
+	Open(path string) (Object, error)
+	// Save stores the content of the reader at the given path, replacing an
+	// existing object, and returns the number of bytes written
+	Save(path string, r io.Reader) (int64, error)
+	// Stat returns information about the object at the given path
+	Stat(path string) (os.FileInfo, error)
+	// Delete deletes the object at the given path, it is not an error if it does not exist
+	Delete(path string) error
+	// IterateObjects calls fn for every object in the storage
+	IterateObjects(fn func(path string, obj Object) error) error
+}
+
+var (
+	// Attachments represents attachments storage
+	Attachments ObjectStorage
+	// Avatars represents user avatars storage
+	Avatars ObjectStorage
+	// Uploads represents repository file uploads storage
+	Uploads ObjectStorage
+	// LFS represents lfs storage
+	LFS ObjectStorage
+)
+
+// NewStorage creates a storage from its configuration
+func NewStorage(cfg setting.Storage) (ObjectStorage, error) {
+	switch cfg.Type {
+	case setting.LocalStorageType:
+		return NewLocalStorage(cfg.Path)
+	case setting.MinioStorageType:
+		return NewMinioStorage(cfg.Minio)
+	}
+	return nil, fmt.Errorf("unknown storage type: %s", cfg.Type)
+}
+
+// Init initializes all storages from the settings
+func Init() (err error) {
+	if Attachments, err = NewStorage(setting.AttachmentStorage); err != nil {
+		return fmt.Errorf("attachments storage: %v", err)
+	}
+	if Avatars, err = NewStorage(setting.AvatarStorage); err != nil {
+		return fmt.Errorf("avatars storage: %v", err)
+	}
+	if Uploads, err = NewStorage(setting.UploadStorage); err != nil {
+		return fmt.Errorf("uploads storage: %v", err)
+	}
+	if LFS, err = NewStorage(setting.LFSStorage); err != nil {
+		return fmt.Errorf("lfs storage: %v", err)
+	}
+	return nil
+}
+
+// Copy copies an object from one storage to another
+func Copy(dst ObjectStorage, dstPath string, src ObjectStorage, srcPath string) (int64, error) {
+	f, err := src.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return dst.Save(dstPath, f)
+}
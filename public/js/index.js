@@ -590,7 +590,7 @@ function initRepository() {
     }
 
     // Labels
-    if ($('.repository.labels').length > 0) {
+    if ($('.repository.labels, .organization.settings.labels').length > 0) {
         // Create label
         var $newLabelPanel = $('.new-label.segment');
         $('.new-label.button').click(function () {
@@ -613,6 +613,8 @@ function initRepository() {
             $('.edit-label .new-label-input').val($(this).data('title'));
             $('.edit-label .new-label-desc-input').val($(this).data('description'));
             $('.edit-label .color-picker').val($(this).data('color'));
+            $('.edit-label .new-label-priority-input').val($(this).data('priority'));
+            $('.edit-label .new-label-exclusive-input').prop('checked', $(this).data('exclusive'));
             $('.minicolors-swatch-color').css("background-color", $(this).data('color'));
             $('.edit-label.modal').modal({
                 onApprove: function () {
@@ -1754,6 +1756,58 @@ function initWipTitle() {
     });
 }
 
+function initProjectBoard() {
+    if ($('.repository.project.board').length === 0) {
+        return;
+    }
+
+    var $dragged = null;
+    $('.project-card[draggable=true]').on('dragstart', function (e) {
+        $dragged = $(this);
+        e.originalEvent.dataTransfer.effectAllowed = 'move';
+        e.originalEvent.dataTransfer.setData('text/plain', $dragged.data('id'));
+    }).on('dragend', function () {
+        $dragged = null;
+    });
+
+    $('.project-column').on('dragover', function (e) {
+        if ($dragged) {
+            e.preventDefault();
+        }
+    }).on('drop', function (e) {
+        if (!$dragged) {
+            return;
+        }
+        e.preventDefault();
+
+        var $cards = $(this).find('.project-cards');
+        var $before = null;
+        $cards.children('.project-card').not($dragged).each(function () {
+            var rect = this.getBoundingClientRect();
+            if (e.originalEvent.clientY < rect.top + rect.height / 2) {
+                $before = $(this);
+                return false;
+            }
+        });
+        if ($before) {
+            $dragged.insertBefore($before);
+        } else {
+            $cards.append($dragged);
+        }
+        $('.project-column').each(function () {
+            $(this).find('.project-column-count').text($(this).find('.project-card').length);
+        });
+
+        $.post($cards.data('url') + '/' + $dragged.data('id') + '/move', {
+            "_csrf": csrf,
+            "column_id": $cards.data('column'),
+            "position": $cards.children('.project-card').index($dragged)
+        }).fail(function () {
+            window.location.reload();
+        });
+    });
+}
+
 $(document).ready(function () {
     csrf = $('meta[name=_csrf]').attr("content");
     suburl = $('meta[name=_suburl]').attr("content");
@@ -1975,6 +2029,7 @@ $(document).ready(function () {
     initIssueList();
     initWipTitle();
     initPullRequestReview();
+    initProjectBoard();
 
     // Repo clone url.
     if ($('#repo-clone-url').length > 0) {
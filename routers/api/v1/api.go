@@ -58,6 +58,7 @@
 package v1
 
 import (
+	"fmt"
 	"strings"
 
 	"code.gitea.io/gitea/models"
@@ -67,6 +68,7 @@ import (
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/routers/api/v1/admin"
 	"code.gitea.io/gitea/routers/api/v1/misc"
+	"code.gitea.io/gitea/routers/api/v1/notify"
 	"code.gitea.io/gitea/routers/api/v1/org"
 	"code.gitea.io/gitea/routers/api/v1/repo"
 	_ "code.gitea.io/gitea/routers/api/v1/swagger" // for swagger generation
@@ -85,7 +87,7 @@ func sudo() macaron.Handler {
 		}
 
 		if len(sudo) > 0 {
-			if ctx.User.IsAdmin {
+			if ctx.User.IsAdmin && ctx.HasTokenScope(models.AccessTokenScopeAdmin) {
 				user, err := models.GetUserByName(sudo)
 				if err != nil {
 					if models.IsErrUserNotExist(err) {
@@ -166,6 +168,38 @@ func repoAssignment() macaron.Handler {
 	}
 }
 
+// checkTokenScope responds with 403 if the request is signed in with an access token
+// which does not grant the given scope or which is restricted to another repository.
+func checkTokenScope(ctx *context.APIContext, scope models.AccessTokenScope) {
+	if !ctx.CanTokenAccessRepo(ctx.Repo.Repository) {
+		ctx.Error(403, "", "The access token is restricted to another repository")
+		return
+	}
+	if !ctx.HasTokenScope(scope) {
+		ctx.Error(403, "", fmt.Sprintf("The access token does not grant the %s scope", scope))
+		return
+	}
+}
+
+// reqTokenScope the access token used to sign in, if any, should grant the given scope
+func reqTokenScope(scope models.AccessTokenScope) macaron.Handler {
+	return func(ctx *context.APIContext) {
+		checkTokenScope(ctx, scope)
+	}
+}
+
+// reqRepoTokenScope the access token used to sign in, if any, should grant repo:read
+// for reading requests and repo:write for all the others
+func reqRepoTokenScope() macaron.Handler {
+	return func(ctx *context.APIContext) {
+		if ctx.Req.Method == "GET" || ctx.Req.Method == "HEAD" {
+			checkTokenScope(ctx, models.AccessTokenScopeRepoRead)
+		} else {
+			checkTokenScope(ctx, models.AccessTokenScopeRepoWrite)
+		}
+	}
+}
+
 // Contexter middleware already checks token for user sign in process.
 func reqToken() macaron.Handler {
 	return func(ctx *context.APIContext) {
@@ -388,6 +422,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Get("/version", misc.Version)
 		m.Post("/markdown", bind(api.MarkdownOption{}), misc.Markdown)
 		m.Post("/markdown/raw", misc.MarkdownRaw)
+		m.Post("/mirror-sync/:token", repo.TriggerMirrorSync)
 
 		// Users
 		m.Group("/users", func() {
@@ -397,7 +432,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Get("", user.GetInfo)
 				m.Get("/heatmap", mustEnableUserHeatmap, user.GetUserHeatmapData)
 
-				m.Get("/repos", user.ListUserRepos)
+				m.Get("/repos", reqRepoTokenScope(), user.ListUserRepos)
 				m.Group("/tokens", func() {
 					m.Combo("").Get(user.ListAccessTokens).
 						Post(bind(api.CreateAccessTokenOption{}), user.CreateAccessToken)
@@ -421,63 +456,80 @@ func RegisterRoutes(m *macaron.Macaron) {
 
 				m.Get("/subscriptions", user.GetWatchedRepos)
 			})
-		}, reqToken())
+		}, reqToken(), reqTokenScope(models.AccessTokenScopeUser))
 
 		m.Group("/user", func() {
 			m.Get("", user.GetAuthenticatedUser)
-			m.Combo("/emails").Get(user.ListEmails).
-				Post(bind(api.CreateEmailOption{}), user.AddEmail).
-				Delete(bind(api.DeleteEmailOption{}), user.DeleteEmail)
-
-			m.Get("/followers", user.ListMyFollowers)
-			m.Group("/following", func() {
-				m.Get("", user.ListMyFollowing)
-				m.Combo("/:username").Get(user.CheckMyFollowing).Put(user.Follow).Delete(user.Unfollow)
-			})
+			m.Group("", func() {
+				m.Combo("/emails").Get(user.ListEmails).
+					Post(bind(api.CreateEmailOption{}), user.AddEmail).
+					Delete(bind(api.DeleteEmailOption{}), user.DeleteEmail)
 
-			m.Group("/keys", func() {
-				m.Combo("").Get(user.ListMyPublicKeys).
-					Post(bind(api.CreateKeyOption{}), user.CreatePublicKey)
-				m.Combo("/:id").Get(user.GetPublicKey).
-					Delete(user.DeletePublicKey)
-			})
-
-			m.Group("/gpg_keys", func() {
-				m.Combo("").Get(user.ListMyGPGKeys).
-					Post(bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
-				m.Combo("/:id").Get(user.GetGPGKey).
-					Delete(user.DeleteGPGKey)
-			})
+				m.Get("/followers", user.ListMyFollowers)
+				m.Group("/following", func() {
+					m.Get("", user.ListMyFollowing)
+					m.Combo("/:username").Get(user.CheckMyFollowing).Put(user.Follow).Delete(user.Unfollow)
+				})
 
-			m.Combo("/repos").Get(user.ListMyRepos).
-				Post(bind(api.CreateRepoOption{}), repo.Create)
+				m.Group("/keys", func() {
+					m.Combo("").Get(user.ListMyPublicKeys).
+						Post(bind(api.CreateKeyOption{}), user.CreatePublicKey)
+					m.Combo("/:id").Get(user.GetPublicKey).
+						Delete(user.DeletePublicKey)
+				})
 
-			m.Group("/starred", func() {
-				m.Get("", user.GetMyStarredRepos)
-				m.Group("/:username/:reponame", func() {
-					m.Get("", user.IsStarring)
-					m.Put("", user.Star)
-					m.Delete("", user.Unstar)
-				}, repoAssignment())
-			})
-			m.Get("/times", repo.ListMyTrackedTimes)
+				m.Group("/gpg_keys", func() {
+					m.Combo("").Get(user.ListMyGPGKeys).
+						Post(bind(api.CreateGPGKeyOption{}), user.CreateGPGKey)
+					m.Combo("/:id").Get(user.GetGPGKey).
+						Delete(user.DeleteGPGKey)
+				})
+			}, reqTokenScope(models.AccessTokenScopeUser))
+
+			m.Group("", func() {
+				m.Combo("/repos").Get(user.ListMyRepos).
+					Post(bind(api.CreateRepoOption{}), repo.Create)
+
+				m.Group("/starred", func() {
+					m.Get("", user.GetMyStarredRepos)
+					m.Group("/:username/:reponame", func() {
+						m.Get("", user.IsStarring)
+						m.Put("", user.Star)
+						m.Delete("", user.Unstar)
+					}, repoAssignment())
+				})
+				m.Get("/times", repo.ListMyTrackedTimes)
 
-			m.Get("/subscriptions", user.GetMyWatchedRepos)
+				m.Get("/subscriptions", user.GetMyWatchedRepos)
+			}, reqRepoTokenScope())
 
-			m.Get("/teams", org.ListUserTeams)
+			m.Get("/teams", reqTokenScope(models.AccessTokenScopeOrg), org.ListUserTeams)
 		}, reqToken())
 
+		// Notifications
+		m.Group("/notifications", func() {
+			m.Combo("").
+				Get(notify.ListNotifications).
+				Put(notify.ReadNotifications)
+			m.Combo("/threads/:id").
+				Get(notify.GetThread).
+				Patch(notify.ReadThread)
+		}, reqToken(), reqTokenScope(models.AccessTokenScopeNotification))
+
 		// Repositories
-		m.Post("/org/:org/repos", reqToken(), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
+		m.Post("/org/:org/repos", reqToken(), reqRepoTokenScope(), bind(api.CreateRepoOption{}), repo.CreateOrgRepo)
 
 		m.Group("/repos", func() {
 			m.Get("/search", repo.Search)
-		})
+			m.Get("/issues/search", repo.SearchIssues)
+		}, reqRepoTokenScope())
+
+		m.Get("/code/search", reqRepoTokenScope(), repo.SearchCode)
 
-		m.Combo("/repositories/:id", reqToken()).Get(repo.GetByID)
+		m.Combo("/repositories/:id", reqToken(), reqRepoTokenScope()).Get(repo.GetByID)
 
 		m.Group("/repos", func() {
-			m.Post("/migrate", reqToken(), bind(auth.MigrateRepoForm{}), repo.Migrate)
+			m.Post("/migrate", reqToken(), reqRepoTokenScope(), bind(auth.MigrateRepoForm{}), repo.Migrate)
 
 			m.Group("/:username/:reponame", func() {
 				m.Combo("").Get(reqAnyRepoReader(), repo.Get).
@@ -490,6 +542,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 							Patch(bind(api.EditHookOption{}), repo.EditHook).
 							Delete(repo.DeleteHook)
 						m.Post("/tests", context.RepoRef(), repo.TestHook)
+						m.Get("/deliveries", repo.ListHookDeliveries)
+						m.Post("/deliveries/:delivery/redeliver", repo.RedeliverHook)
 					})
 				}, reqToken(), reqAdmin())
 				m.Group("/collaborators", func() {
@@ -498,14 +552,35 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Put(bind(api.AddCollaboratorOption{}), repo.AddCollaborator).
 						Delete(repo.DeleteCollaborator)
 				}, reqToken(), reqAdmin())
+				m.Group("/secrets", func() {
+					m.Get("", repo.ListSecrets)
+					m.Combo("/:secretname").Put(bind(api.CreateOrUpdateSecretOption{}), repo.CreateOrUpdateSecret).
+						Delete(repo.DeleteSecret)
+				}, reqToken(), reqAdmin())
 				m.Get("/raw/*", context.RepoRefByType(context.RepoRefAny), reqRepoReader(models.UnitTypeCode), repo.GetRawFile)
 				m.Get("/archive/*", reqRepoReader(models.UnitTypeCode), repo.GetArchive)
+				m.Group("/contents", func() {
+					m.Get("", repo.GetContents)
+					m.Combo("/*").Get(repo.GetContents).
+						Put(reqToken(), reqRepoWriter(models.UnitTypeCode), bind(api.UpdateFileOptions{}), repo.UpdateFile).
+						Delete(reqToken(), reqRepoWriter(models.UnitTypeCode), bind(api.DeleteFileOptions{}), repo.DeleteFile)
+				}, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo())
+				m.Combo("/notifications", reqToken(), reqTokenScope(models.AccessTokenScopeNotification)).
+					Get(notify.ListRepoNotifications).
+					Put(notify.ReadRepoNotifications)
 				m.Combo("/forks").Get(repo.ListForks).
 					Post(reqToken(), reqRepoReader(models.UnitTypeCode), bind(api.CreateForkOption{}), repo.CreateFork)
 				m.Group("/branches", func() {
 					m.Get("", repo.ListBranches)
 					m.Get("/*", context.RepoRefByType(context.RepoRefBranch), repo.GetBranch)
 				}, reqRepoReader(models.UnitTypeCode))
+				m.Group("/branch_protections", func() {
+					m.Combo("").Get(repo.ListBranchProtections).
+						Post(context.ReferencesGitRepo(), bind(api.CreateBranchProtectionOption{}), repo.CreateBranchProtection)
+					m.Combo("/*").Get(repo.GetBranchProtection).
+						Patch(bind(api.EditBranchProtectionOption{}), repo.EditBranchProtection).
+						Delete(repo.DeleteBranchProtection)
+				}, reqToken(), reqAdmin())
 				m.Group("/keys", func() {
 					m.Combo("").Get(repo.ListDeployKeys).
 						Post(bind(api.CreateKeyOption{}), repo.CreateDeployKey)
@@ -566,6 +641,38 @@ func RegisterRoutes(m *macaron.Macaron) {
 						Patch(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), bind(api.EditMilestoneOption{}), repo.EditMilestone).
 						Delete(reqToken(), reqRepoWriter(models.UnitTypeIssues, models.UnitTypePullRequests), repo.DeleteMilestone)
 				})
+				m.Group("/projects", func() {
+					m.Combo("").Get(repo.ListProjectBoards).
+						Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectBoardOption{}), repo.CreateProjectBoard)
+					m.Group("/:id", func() {
+						m.Combo("").Get(repo.GetProjectBoard).
+							Patch(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectBoardOption{}), repo.EditProjectBoard).
+							Delete(reqToken(), reqRepoWriter(models.UnitTypeProjects), repo.DeleteProjectBoard)
+						m.Group("/columns", func() {
+							m.Combo("").Get(repo.ListProjectColumns).
+								Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectColumnOption{}), repo.CreateProjectColumn)
+							m.Combo("/:column").
+								Patch(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.EditProjectColumnOption{}), repo.EditProjectColumn).
+								Delete(reqToken(), reqRepoWriter(models.UnitTypeProjects), repo.DeleteProjectColumn)
+							m.Combo("/:column/cards").Get(repo.ListProjectCards).
+								Post(reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.CreateProjectCardOption{}), repo.CreateProjectCard)
+						})
+						m.Group("/cards/:card", func() {
+							m.Delete("", reqToken(), reqRepoWriter(models.UnitTypeProjects), repo.DeleteProjectCard)
+							m.Post("/move", reqToken(), reqRepoWriter(models.UnitTypeProjects), bind(api.MoveProjectCardOption{}), repo.MoveProjectCard)
+						})
+					})
+				}, reqRepoReader(models.UnitTypeProjects))
+				m.Group("/wiki/pages", func() {
+					m.Combo("").Get(repo.ListWikiPages).
+						Post(reqToken(), reqRepoWriter(models.UnitTypeWiki), bind(api.CreateWikiPageOptions{}), repo.CreateWikiPage)
+					m.Group("/:page", func() {
+						m.Combo("").Get(repo.GetWikiPage).
+							Patch(reqToken(), reqRepoWriter(models.UnitTypeWiki), bind(api.EditWikiPageOptions{}), repo.EditWikiPage).
+							Delete(reqToken(), reqRepoWriter(models.UnitTypeWiki), repo.DeleteWikiPage)
+						m.Get("/revisions", repo.ListWikiPageRevisions)
+					})
+				}, reqRepoReader(models.UnitTypeWiki))
 				m.Get("/stargazers", repo.ListStargazers)
 				m.Get("/subscribers", repo.ListSubscribers)
 				m.Group("/subscription", func() {
@@ -589,7 +696,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 						})
 					})
 				}, reqRepoReader(models.UnitTypeReleases))
-				m.Post("/mirror-sync", reqToken(), reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
+				m.Combo("/mirror-sync").Get(reqRepoReader(models.UnitTypeCode), repo.ListMirrorSyncs).
+					Post(reqToken(), reqRepoWriter(models.UnitTypeCode), repo.MirrorSync)
 				m.Get("/editorconfig/:filename", context.RepoRef(), reqRepoReader(models.UnitTypeCode), repo.GetEditorconfig)
 				m.Group("/pulls", func() {
 					m.Combo("").Get(bind(api.ListPullRequestsOptions{}), repo.ListPullRequests).
@@ -598,7 +706,19 @@ func RegisterRoutes(m *macaron.Macaron) {
 						m.Combo("").Get(repo.GetPullRequest).
 							Patch(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.EditPullRequestOption{}), repo.EditPullRequest)
 						m.Combo("/merge").Get(repo.IsPullRequestMerged).
-							Post(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest)
+							Post(reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(auth.MergePullRequestForm{}), repo.MergePullRequest).
+							Delete(reqToken(), reqRepoWriter(models.UnitTypePullRequests), repo.CancelScheduledMerge)
+						m.Group("/reviews", func() {
+							m.Combo("").Get(repo.ListPullReviews).
+								Post(reqToken(), bind(api.CreatePullReviewOptions{}), repo.CreatePullReview)
+							m.Group("/:id", func() {
+								m.Combo("").Get(repo.GetPullReview).
+									Post(reqToken(), bind(api.SubmitPullReviewOptions{}), repo.SubmitPullReview).
+									Delete(reqToken(), repo.DeletePullReview)
+								m.Get("/comments", repo.GetPullReviewComments)
+								m.Post("/dismissals", reqToken(), reqRepoWriter(models.UnitTypePullRequests), bind(api.DismissPullReviewOptions{}), repo.DismissPullReview)
+							})
+						})
 					})
 				}, mustAllowPulls, reqRepoReader(models.UnitTypeCode), context.ReferencesGitRepo())
 				m.Group("/statuses", func() {
@@ -614,13 +734,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Get("/refs/*", repo.GetGitRefs)
 					m.Combo("/trees/:sha", context.RepoRef()).Get(repo.GetTree)
 				}, reqRepoReader(models.UnitTypeCode))
-			}, repoAssignment())
+			}, repoAssignment(), reqRepoTokenScope())
 		})
 
 		// Organizations
-		m.Get("/user/orgs", reqToken(), org.ListMyOrgs)
-		m.Get("/users/:username/orgs", org.ListUserOrgs)
-		m.Post("/orgs", reqToken(), bind(api.CreateOrgOption{}), org.Create)
+		m.Get("/user/orgs", reqToken(), reqTokenScope(models.AccessTokenScopeOrg), org.ListMyOrgs)
+		m.Get("/users/:username/orgs", reqTokenScope(models.AccessTokenScopeOrg), org.ListUserOrgs)
+		m.Post("/orgs", reqToken(), reqTokenScope(models.AccessTokenScopeOrg), bind(api.CreateOrgOption{}), org.Create)
 		m.Group("/orgs/:orgname", func() {
 			m.Get("/repos", user.ListOrgRepos)
 			m.Combo("").Get(org.Get).
@@ -642,11 +762,27 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Group("/hooks", func() {
 				m.Combo("").Get(org.ListHooks).
 					Post(bind(api.CreateHookOption{}), org.CreateHook)
-				m.Combo("/:id").Get(org.GetHook).
-					Patch(reqOrgOwnership(), bind(api.EditHookOption{}), org.EditHook).
-					Delete(reqOrgOwnership(), org.DeleteHook)
+				m.Group("/:id", func() {
+					m.Combo("").Get(org.GetHook).
+						Patch(reqOrgOwnership(), bind(api.EditHookOption{}), org.EditHook).
+						Delete(reqOrgOwnership(), org.DeleteHook)
+					m.Get("/deliveries", org.ListHookDeliveries)
+					m.Post("/deliveries/:delivery/redeliver", reqOrgOwnership(), org.RedeliverHook)
+				})
 			}, reqToken(), reqOrgMembership())
-		}, orgAssignment(true))
+			m.Group("/labels", func() {
+				m.Combo("").Get(org.ListLabels).
+					Post(reqToken(), reqOrgOwnership(), bind(api.CreateLabelOption{}), org.CreateLabel)
+				m.Combo("/:id").Get(org.GetLabel).
+					Patch(reqToken(), reqOrgOwnership(), bind(api.EditLabelOption{}), org.EditLabel).
+					Delete(reqToken(), reqOrgOwnership(), org.DeleteLabel)
+			})
+			m.Group("/secrets", func() {
+				m.Get("", org.ListSecrets)
+				m.Combo("/:secretname").Put(bind(api.CreateOrUpdateSecretOption{}), org.CreateOrUpdateSecret).
+					Delete(org.DeleteSecret)
+			}, reqToken(), reqOrgOwnership())
+		}, orgAssignment(true), reqTokenScope(models.AccessTokenScopeOrg))
 		m.Group("/teams/:teamid", func() {
 			m.Combo("").Get(org.GetTeam).
 				Patch(reqOrgOwnership(), bind(api.EditTeamOption{}), org.EditTeam).
@@ -664,7 +800,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					Put(org.AddTeamRepository).
 					Delete(org.RemoveTeamRepository)
 			})
-		}, orgAssignment(false, true), reqToken(), reqOrgMembership())
+		}, orgAssignment(false, true), reqToken(), reqTokenScope(models.AccessTokenScopeOrg), reqOrgMembership())
 
 		m.Any("/*", func(ctx *context.Context) {
 			ctx.Error(404)
@@ -684,7 +820,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/repos", bind(api.CreateRepoOption{}), admin.CreateRepo)
 				})
 			})
-		}, reqToken(), reqSiteAdmin())
+		}, reqToken(), reqTokenScope(models.AccessTokenScopeAdmin), reqSiteAdmin())
 
 		m.Group("/topics", func() {
 			m.Get("/search", repo.TopicSearch)
@@ -6,6 +6,7 @@ package convert
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/Unknwon/com"
 
@@ -26,6 +27,26 @@ func ToEmail(email *models.EmailAddress) *api.Email {
 	}
 }
 
+// ToAccessToken convert models.AccessToken to api.AccessToken
+func ToAccessToken(t *models.AccessToken) *api.AccessToken {
+	apiToken := &api.AccessToken{
+		ID:   t.ID,
+		Name: t.Name,
+		Sha1: t.Sha1,
+	}
+	for _, scope := range t.Scopes() {
+		apiToken.Scopes = append(apiToken.Scopes, string(scope))
+	}
+	if t.Repo != nil {
+		apiToken.Repository = t.Repo.FullName()
+	}
+	if t.ExpiresUnix > 0 {
+		expiresAt := t.ExpiresUnix.AsTime()
+		apiToken.ExpiresAt = &expiresAt
+	}
+	return apiToken
+}
+
 // ToBranch convert a commit and branch to an api.Branch
 func ToBranch(repo *models.Repository, b *models.Branch, c *git.Commit) *api.Branch {
 	return &api.Branch{
@@ -34,6 +55,46 @@ func ToBranch(repo *models.Repository, b *models.Branch, c *git.Commit) *api.Bra
 	}
 }
 
+// ToBranchProtection convert a ProtectedBranch to api.BranchProtection
+func ToBranchProtection(bp *models.ProtectedBranch) *api.BranchProtection {
+	return &api.BranchProtection{
+		BranchName:                  bp.BranchName,
+		EnablePushWhitelist:         bp.EnableWhitelist,
+		PushWhitelistUsernames:      toUserNames(bp.WhitelistUserIDs),
+		PushWhitelistTeams:          toTeamNames(bp.WhitelistTeamIDs),
+		EnableMergeWhitelist:        bp.EnableMergeWhitelist,
+		MergeWhitelistUsernames:     toUserNames(bp.MergeWhitelistUserIDs),
+		MergeWhitelistTeams:         toTeamNames(bp.MergeWhitelistTeamIDs),
+		RequiredApprovals:           bp.RequiredApprovals,
+		ApprovalsWhitelistUsernames: toUserNames(bp.ApprovalsWhitelistUserIDs),
+		ApprovalsWhitelistTeams:     toTeamNames(bp.ApprovalsWhitelistTeamIDs),
+		EnableStatusCheck:           bp.EnableStatusCheck,
+		StatusCheckContexts:         bp.StatusCheckContexts,
+		Created:                     bp.CreatedUnix.AsTime(),
+		Updated:                     bp.UpdatedUnix.AsTime(),
+	}
+}
+
+func toUserNames(userIDs []int64) []string {
+	users, err := models.GetUsersByIDs(userIDs)
+	if err != nil {
+		log.Error(4, "GetUsersByIDs: %v", err)
+	}
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names
+}
+
+func toTeamNames(teamIDs []int64) []string {
+	names, err := models.GetTeamNamesByID(teamIDs)
+	if err != nil {
+		log.Error(4, "GetTeamNamesByID: %v", err)
+	}
+	return names
+}
+
 // ToCommit convert a commit to api.PayloadCommit
 func ToCommit(repo *models.Repository, c *git.Commit) *api.PayloadCommit {
 	authorUsername := ""
@@ -138,18 +199,35 @@ func ToGPGKeyEmail(email *models.EmailAddress) *api.GPGKeyEmail {
 	}
 }
 
+// ToSecret convert models.Secret to api.Secret, without its value
+func ToSecret(s *models.Secret) *api.Secret {
+	return &api.Secret{
+		Name:    s.Name,
+		Created: s.CreatedUnix.AsTime(),
+		Updated: s.UpdatedUnix.AsTime(),
+	}
+}
+
 // ToHook convert models.Webhook to api.Hook
 func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 	config := map[string]string{
 		"url":          w.URL,
 		"content_type": w.ContentType.Name(),
 	}
-	if w.HookTaskType == models.SLACK {
+	switch w.HookTaskType {
+	case models.SLACK:
 		s := w.GetSlackHook()
 		config["channel"] = s.Channel
 		config["username"] = s.Username
 		config["icon_url"] = s.IconURL
 		config["color"] = s.Color
+	case models.TELEGRAM:
+		config["chat_id"] = w.GetTelegramHook().ChatID
+	case models.MATRIX:
+		s := w.GetMatrixHook()
+		config["homeserver_url"] = s.HomeserverURL
+		config["room_id"] = s.Room
+		config["message_type"] = s.MessageType
 	}
 
 	return &api.Hook{
@@ -164,6 +242,39 @@ func ToHook(repoLink string, w *models.Webhook) *api.Hook {
 	}
 }
 
+// ToHookDelivery convert models.HookTask to api.HookDelivery
+func ToHookDelivery(t *models.HookTask) *api.HookDelivery {
+	d := &api.HookDelivery{
+		ID:        t.ID,
+		UUID:      t.UUID,
+		Event:     string(t.EventType),
+		URL:       t.URL,
+		Delivered: t.IsDelivered,
+		Succeed:   t.IsSucceed,
+		Attempts:  t.Attempts,
+	}
+	if t.Delivered > 0 {
+		d.DeliveredAt = time.Unix(0, t.Delivered)
+	}
+	if t.ResponseInfo != nil {
+		d.StatusCode = t.ResponseInfo.Status
+	}
+	return d
+}
+
+// ToMirrorSync convert models.MirrorSyncRecord to api.MirrorSync
+func ToMirrorSync(r *models.MirrorSyncRecord) *api.MirrorSync {
+	return &api.MirrorSync{
+		ID:          r.ID,
+		Refs:        r.Refs,
+		ChangedRefs: r.ChangedRefs,
+		Succeed:     r.IsSuccess,
+		Error:       r.Error,
+		Duration:    int64(r.Duration / time.Millisecond),
+		Created:     r.CreatedUnix.AsTime(),
+	}
+}
+
 // ToDeployKey convert models.DeployKey to api.DeployKey
 func ToDeployKey(apiLink string, key *models.DeployKey) *api.DeployKey {
 	return &api.DeployKey{
@@ -0,0 +1,97 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"fmt"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/v1/convert"
+
+	api "code.gitea.io/sdk/gitea"
+)
+
+// parseTimeQuery parses an optional RFC 3339 time from the query, writing an
+// error response and returning false if it is malformed
+func parseTimeQuery(ctx *context.APIContext, name string) (int64, bool) {
+	value := ctx.Query(name)
+	if len(value) == 0 {
+		return 0, true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		ctx.Error(422, "invalid "+name, err)
+		return 0, false
+	}
+	return t.Unix(), true
+}
+
+// listNotifications writes the notifications of the signed in user matching
+// the query filters, optionally limited to a single repository
+func listNotifications(ctx *context.APIContext, repoID int64) {
+	opts := &models.FindNotificationOptions{
+		UserID:   ctx.User.ID,
+		RepoID:   repoID,
+		Page:     ctx.QueryInt("page"),
+		PageSize: convert.ToCorrectPageSize(ctx.QueryInt("limit")),
+	}
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	switch {
+	case ctx.QueryBool("pinned"):
+		opts.Status = []models.NotificationStatus{models.NotificationStatusPinned}
+	case !ctx.QueryBool("all"):
+		opts.Status = []models.NotificationStatus{models.NotificationStatusUnread, models.NotificationStatusPinned}
+	}
+
+	var ok bool
+	if opts.UpdatedAfterUnix, ok = parseTimeQuery(ctx, "since"); !ok {
+		return
+	}
+	if opts.UpdatedBeforeUnix, ok = parseTimeQuery(ctx, "before"); !ok {
+		return
+	}
+
+	count, err := models.CountNotifications(opts)
+	if err != nil {
+		ctx.Error(500, "CountNotifications", err)
+		return
+	}
+	notifications, err := models.GetNotifications(opts)
+	if err != nil {
+		ctx.Error(500, "GetNotifications", err)
+		return
+	}
+
+	threads := make([]*api.NotificationThread, len(notifications))
+	for i, n := range notifications {
+		if threads[i], err = n.APIFormat(); err != nil {
+			ctx.Error(500, "APIFormat", err)
+			return
+		}
+	}
+
+	ctx.SetLinkHeader(int(count), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(200, &threads)
+}
+
+// readNotifications marks the unread notifications of the signed in user as
+// read, optionally limited to a single repository
+func readNotifications(ctx *context.APIContext, repoID int64) {
+	lastRead, ok := parseTimeQuery(ctx, "last_read_at")
+	if !ok {
+		return
+	}
+	if err := models.MarkNotificationsRead(ctx.User, repoID, util.TimeStamp(lastRead)); err != nil {
+		ctx.Error(500, "MarkNotificationsRead", err)
+		return
+	}
+	ctx.Status(205)
+}
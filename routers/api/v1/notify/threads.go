@@ -0,0 +1,114 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+)
+
+// GetThread gets a notification thread of the authenticated user
+func GetThread(ctx *context.APIContext) {
+	// swagger:operation GET /notifications/threads/{id} notification notifyGetThread
+	// ---
+	// summary: Get a notification thread of the authenticated user
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the notification thread
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/NotificationThread"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	n := getThread(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	thread, err := n.APIFormat()
+	if err != nil {
+		ctx.Error(500, "APIFormat", err)
+		return
+	}
+	ctx.JSON(200, thread)
+}
+
+// ReadThread changes the status of a notification thread of the authenticated user
+func ReadThread(ctx *context.APIContext) {
+	// swagger:operation PATCH /notifications/threads/{id} notification notifyReadThread
+	// ---
+	// summary: Mark a notification thread of the authenticated user as read, unread or pinned
+	// parameters:
+	// - name: id
+	//   in: path
+	//   description: id of the notification thread
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: to-status
+	//   in: query
+	//   description: new status of the thread, one of "read", "unread" or "pinned"
+	//   type: string
+	//   default: read
+	// responses:
+	//   "205":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	n := getThread(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	var status models.NotificationStatus
+	switch ctx.Query("to-status") {
+	case "", "read":
+		status = models.NotificationStatusRead
+	case "unread":
+		status = models.NotificationStatusUnread
+	case "pinned":
+		status = models.NotificationStatusPinned
+	default:
+		ctx.Error(422, "", "to-status must be one of read, unread or pinned")
+		return
+	}
+
+	if err := models.SetNotificationStatus(n.ID, ctx.User, status); err != nil {
+		ctx.Error(500, "SetNotificationStatus", err)
+		return
+	}
+	ctx.Status(205)
+}
+
+// getThread loads the notification thread given by the id parameter, writing
+// an error response if it does not exist or belongs to another user
+func getThread(ctx *context.APIContext) *models.Notification {
+	n, err := models.GetNotificationByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrNotificationNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetNotificationByID", err)
+		}
+		return nil
+	}
+	if n.UserID != ctx.User.ID {
+		ctx.Error(403, "", "notification belongs to another user")
+		return nil
+	}
+	return n
+}
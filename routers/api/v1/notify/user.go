@@ -0,0 +1,71 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package notify
+
+import (
+	"code.gitea.io/gitea/modules/context"
+)
+
+// ListNotifications lists the notifications of the authenticated user
+func ListNotifications(ctx *context.APIContext) {
+	// swagger:operation GET /notifications notification notifyGetList
+	// ---
+	// summary: List the notifications of the authenticated user
+	// description: By default only unread and pinned notifications are returned.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: all
+	//   in: query
+	//   description: also return notifications which have already been read
+	//   type: boolean
+	// - name: pinned
+	//   in: query
+	//   description: only return pinned notifications
+	//   type: boolean
+	// - name: since
+	//   in: query
+	//   description: only return notifications updated at or after the given time, in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: before
+	//   in: query
+	//   description: only return notifications updated at or before the given time, in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/NotificationThreadList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	listNotifications(ctx, 0)
+}
+
+// ReadNotifications marks the notifications of the authenticated user as read
+func ReadNotifications(ctx *context.APIContext) {
+	// swagger:operation PUT /notifications notification notifyReadList
+	// ---
+	// summary: Mark the unread notifications of the authenticated user as read
+	// parameters:
+	// - name: last_read_at
+	//   in: query
+	//   description: only mark notifications updated at or before the given time as read, in RFC 3339 format
+	//   type: string
+	//   format: date-time
+	// responses:
+	//   "205":
+	//     "$ref": "#/responses/empty"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	readNotifications(ctx, 0)
+}
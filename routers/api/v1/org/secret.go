@@ -0,0 +1,92 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package org
+
+import (
+	api "code.gitea.io/sdk/gitea"
+
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+)
+
+// ListSecrets list the secrets of an organization
+func ListSecrets(ctx *context.APIContext) {
+	// swagger:operation GET /orgs/{org}/secrets organization orgListSecrets
+	// ---
+	// summary: List the secrets of an organization, without their values
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/SecretList"
+	utils.ListSecrets(ctx, ctx.Org.Organization.ID, 0)
+}
+
+// CreateOrUpdateSecret set the value of a secret of an organization
+func CreateOrUpdateSecret(ctx *context.APIContext, form api.CreateOrUpdateSecretOption) {
+	// swagger:operation PUT /orgs/{org}/secrets/{secretname} organization orgCreateOrUpdateSecret
+	// ---
+	// summary: Create a secret of an organization, or replace its value
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: secretname
+	//   in: path
+	//   description: name of the secret, made of letters, digits and
+	//                underscores, case-insensitive
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateOrUpdateSecretOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/empty"
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	utils.CreateOrUpdateSecret(ctx, ctx.Org.Organization.ID, 0, form)
+}
+
+// DeleteSecret delete a secret of an organization
+func DeleteSecret(ctx *context.APIContext) {
+	// swagger:operation DELETE /orgs/{org}/secrets/{secretname} organization orgDeleteSecret
+	// ---
+	// summary: Delete a secret of an organization
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: org
+	//   in: path
+	//   description: name of the organization
+	//   type: string
+	//   required: true
+	// - name: secretname
+	//   in: path
+	//   description: name of the secret to delete
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	utils.DeleteSecret(ctx, ctx.Org.Organization.ID, 0)
+}
@@ -103,3 +103,369 @@ func ListBranches(ctx *context.APIContext) {
 
 	ctx.JSON(200, &apiBranches)
 }
+
+// ListBranchProtections list branch protections for a repo
+func ListBranchProtections(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/branch_protections repository repoListBranchProtection
+	// ---
+	// summary: List branch protections for a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtectionList"
+	bps, err := ctx.Repo.Repository.GetProtectedBranches()
+	if err != nil {
+		ctx.Error(500, "GetProtectedBranches", err)
+		return
+	}
+
+	apiBps := make([]*api.BranchProtection, len(bps))
+	for i := range bps {
+		apiBps[i] = convert.ToBranchProtection(bps[i])
+	}
+
+	ctx.JSON(200, apiBps)
+}
+
+// GetBranchProtection gets a branch protection
+func GetBranchProtection(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/branch_protections/{name} repository repoGetBranchProtection
+	// ---
+	// summary: Get a specific branch protection for the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of protected branch
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	bp := getBranchProtection(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(200, convert.ToBranchProtection(bp))
+}
+
+// CreateBranchProtection creates a branch protection for a repo
+func CreateBranchProtection(ctx *context.APIContext, form api.CreateBranchProtectionOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/branch_protections repository repoCreateBranchProtection
+	// ---
+	// summary: Create a branch protection for a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateBranchProtectionOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo := ctx.Repo.Repository
+
+	if !ctx.Repo.GitRepo.IsBranchExist(form.BranchName) {
+		ctx.Status(404)
+		return
+	}
+
+	bp, err := models.GetProtectedBranchBy(repo.ID, form.BranchName)
+	if err != nil {
+		ctx.Error(500, "GetProtectedBranchBy", err)
+		return
+	} else if bp != nil {
+		ctx.Error(403, "", "Branch protection already exists")
+		return
+	}
+
+	if form.RequiredApprovals < 0 {
+		ctx.Error(422, "", "required_approvals must not be negative")
+		return
+	}
+
+	var opts models.WhitelistOptions
+	if opts.UserIDs = getUserIDsByNames(ctx, form.PushWhitelistUsernames); ctx.Written() {
+		return
+	}
+	if opts.MergeUserIDs = getUserIDsByNames(ctx, form.MergeWhitelistUsernames); ctx.Written() {
+		return
+	}
+	if opts.ApprovalsUserIDs = getUserIDsByNames(ctx, form.ApprovalsWhitelistUsernames); ctx.Written() {
+		return
+	}
+	if repo.Owner.IsOrganization() {
+		if opts.TeamIDs = getTeamIDsByNames(ctx, form.PushWhitelistTeams); ctx.Written() {
+			return
+		}
+		if opts.MergeTeamIDs = getTeamIDsByNames(ctx, form.MergeWhitelistTeams); ctx.Written() {
+			return
+		}
+		if opts.ApprovalsTeamIDs = getTeamIDsByNames(ctx, form.ApprovalsWhitelistTeams); ctx.Written() {
+			return
+		}
+	}
+
+	bp = &models.ProtectedBranch{
+		RepoID:               repo.ID,
+		BranchName:           form.BranchName,
+		EnableWhitelist:      form.EnablePushWhitelist,
+		EnableMergeWhitelist: form.EnableMergeWhitelist,
+		RequiredApprovals:    form.RequiredApprovals,
+		EnableStatusCheck:    form.EnableStatusCheck,
+		StatusCheckContexts:  models.CleanStatusCheckContexts(form.StatusCheckContexts),
+	}
+	if err = models.UpdateProtectBranch(repo, bp, opts); err != nil {
+		ctx.Error(500, "UpdateProtectBranch", err)
+		return
+	}
+
+	// Reload from db to get all whitelists
+	bp, err = models.GetProtectedBranchByID(bp.ID)
+	if err != nil {
+		ctx.Error(500, "GetProtectedBranchByID", err)
+		return
+	} else if bp == nil {
+		ctx.Error(500, "GetProtectedBranchByID", "branch protection not found after creation")
+		return
+	}
+
+	ctx.JSON(201, convert.ToBranchProtection(bp))
+}
+
+// EditBranchProtection edits a branch protection for a repo
+func EditBranchProtection(ctx *context.APIContext, form api.EditBranchProtectionOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/branch_protections/{name} repository repoEditBranchProtection
+	// ---
+	// summary: Edit a branch protection for a repository. Only fields that are set will be changed
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of protected branch
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditBranchProtectionOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/BranchProtection"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	repo := ctx.Repo.Repository
+	bp := getBranchProtection(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.EnablePushWhitelist != nil {
+		bp.EnableWhitelist = *form.EnablePushWhitelist
+	}
+	if form.EnableMergeWhitelist != nil {
+		bp.EnableMergeWhitelist = *form.EnableMergeWhitelist
+	}
+	if form.RequiredApprovals != nil {
+		if *form.RequiredApprovals < 0 {
+			ctx.Error(422, "", "required_approvals must not be negative")
+			return
+		}
+		bp.RequiredApprovals = *form.RequiredApprovals
+	}
+	if form.EnableStatusCheck != nil {
+		bp.EnableStatusCheck = *form.EnableStatusCheck
+	}
+	if form.StatusCheckContexts != nil {
+		bp.StatusCheckContexts = models.CleanStatusCheckContexts(form.StatusCheckContexts)
+	}
+
+	opts := models.WhitelistOptions{
+		UserIDs:          bp.WhitelistUserIDs,
+		TeamIDs:          bp.WhitelistTeamIDs,
+		MergeUserIDs:     bp.MergeWhitelistUserIDs,
+		MergeTeamIDs:     bp.MergeWhitelistTeamIDs,
+		ApprovalsUserIDs: bp.ApprovalsWhitelistUserIDs,
+		ApprovalsTeamIDs: bp.ApprovalsWhitelistTeamIDs,
+	}
+	if form.PushWhitelistUsernames != nil {
+		if opts.UserIDs = getUserIDsByNames(ctx, form.PushWhitelistUsernames); ctx.Written() {
+			return
+		}
+	}
+	if form.MergeWhitelistUsernames != nil {
+		if opts.MergeUserIDs = getUserIDsByNames(ctx, form.MergeWhitelistUsernames); ctx.Written() {
+			return
+		}
+	}
+	if form.ApprovalsWhitelistUsernames != nil {
+		if opts.ApprovalsUserIDs = getUserIDsByNames(ctx, form.ApprovalsWhitelistUsernames); ctx.Written() {
+			return
+		}
+	}
+	if repo.Owner.IsOrganization() {
+		if form.PushWhitelistTeams != nil {
+			if opts.TeamIDs = getTeamIDsByNames(ctx, form.PushWhitelistTeams); ctx.Written() {
+				return
+			}
+		}
+		if form.MergeWhitelistTeams != nil {
+			if opts.MergeTeamIDs = getTeamIDsByNames(ctx, form.MergeWhitelistTeams); ctx.Written() {
+				return
+			}
+		}
+		if form.ApprovalsWhitelistTeams != nil {
+			if opts.ApprovalsTeamIDs = getTeamIDsByNames(ctx, form.ApprovalsWhitelistTeams); ctx.Written() {
+				return
+			}
+		}
+	}
+
+	if err := models.UpdateProtectBranch(repo, bp, opts); err != nil {
+		ctx.Error(500, "UpdateProtectBranch", err)
+		return
+	}
+
+	ctx.JSON(200, convert.ToBranchProtection(bp))
+}
+
+// DeleteBranchProtection deletes a branch protection for a repo
+func DeleteBranchProtection(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/branch_protections/{name} repository repoDeleteBranchProtection
+	// ---
+	// summary: Delete a specific branch protection for the repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: name
+	//   in: path
+	//   description: name of protected branch
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	bp := getBranchProtection(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := ctx.Repo.Repository.DeleteProtectedBranch(bp.ID); err != nil {
+		ctx.Error(500, "DeleteProtectedBranch", err)
+		return
+	}
+
+	ctx.Status(204)
+}
+
+// getBranchProtection returns the branch protection named by the request path
+// or writes a 404 if the branch is not protected
+func getBranchProtection(ctx *context.APIContext) *models.ProtectedBranch {
+	bp, err := models.GetProtectedBranchBy(ctx.Repo.Repository.ID, ctx.Params("*"))
+	if err != nil {
+		ctx.Error(500, "GetProtectedBranchBy", err)
+		return nil
+	} else if bp == nil {
+		ctx.Status(404)
+		return nil
+	}
+	return bp
+}
+
+func getUserIDsByNames(ctx *context.APIContext, names []string) []int64 {
+	ids, err := models.GetUserIDsByNames(names, false)
+	if err != nil {
+		if models.IsErrUserNotExist(err) {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "GetUserIDsByNames", err)
+		}
+		return nil
+	}
+	return ids
+}
+
+func getTeamIDsByNames(ctx *context.APIContext, names []string) []int64 {
+	ids, err := models.GetTeamIDsByNames(ctx.Repo.Owner.ID, names, false)
+	if err != nil {
+		if err == models.ErrTeamNotExist {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "GetTeamIDsByNames", err)
+		}
+		return nil
+	}
+	return ids
+}
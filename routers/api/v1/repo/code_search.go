@@ -0,0 +1,140 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/search"
+	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/routers/api/v1/convert"
+
+	api "code.gitea.io/sdk/gitea"
+)
+
+// SearchCode searches for code in all the repositories readable by the user
+func SearchCode(ctx *context.APIContext) {
+	// swagger:operation GET /code/search repository searchCode
+	// ---
+	// summary: Search for code in all the repositories readable by the user
+	// description: Requires the code indexer to be enabled.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: keyword
+	//   type: string
+	//   required: true
+	// - name: language
+	//   in: query
+	//   description: only return files of this language, e.g. "Go"
+	//   type: string
+	// - name: path
+	//   in: query
+	//   description: only return files whose path matches this pattern, where
+	//                "*" matches any sequence of characters and "?" any single
+	//                character, e.g. "src/*.go". A pattern without wildcard
+	//                matches the paths starting with it.
+	//   type: string
+	// - name: owner
+	//   in: query
+	//   description: only return files of the repositories of this user or organization
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// - name: limit
+	//   in: query
+	//   description: page size of results, maximum page size is 50
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/CodeSearchResultList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	if !setting.Indexer.RepoIndexerEnabled {
+		ctx.Status(404)
+		return
+	}
+
+	opts := &search.CodeSearchOptions{
+		Doer:        ctx.User,
+		Keyword:     strings.TrimSpace(ctx.Query("q")),
+		Language:    strings.TrimSpace(ctx.Query("language")),
+		PathPattern: strings.TrimSpace(ctx.Query("path")),
+		Page:        ctx.QueryInt("page"),
+		PageSize:    convert.ToCorrectPageSize(ctx.QueryInt("limit")),
+	}
+	if len(opts.Keyword) == 0 {
+		ctx.Error(http.StatusUnprocessableEntity, "", errors.New("keyword is required"))
+		return
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	if ownerName := strings.TrimSpace(ctx.Query("owner")); len(ownerName) > 0 {
+		owner, err := models.GetUserByName(ownerName)
+		if err != nil {
+			if models.IsErrUserNotExist(err) {
+				ctx.Error(http.StatusUnprocessableEntity, "", err)
+			} else {
+				ctx.Error(500, "GetUserByName", err)
+			}
+			return
+		}
+		opts.OwnerID = owner.ID
+	}
+
+	total, results, repoMaps, err := search.SearchCode(opts)
+	if err != nil {
+		ctx.Error(500, "SearchCode", err)
+		return
+	}
+
+	apiResults := make([]*api.CodeSearchResult, 0, len(results))
+	for _, result := range results {
+		repo, ok := repoMaps[result.RepoID]
+		if !ok {
+			continue
+		}
+		apiResults = append(apiResults, toAPICodeSearchResult(repo, result))
+	}
+
+	ctx.SetLinkHeader(total, opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", total))
+	ctx.JSON(200, &apiResults)
+}
+
+func toAPICodeSearchResult(repo *models.Repository, result *search.Result) *api.CodeSearchResult {
+	lines := make([]*api.CodeSearchLine, len(result.Lines))
+	for i, line := range result.Lines {
+		lines[i] = &api.CodeSearchLine{
+			Number:         line.Number,
+			Content:        line.Content,
+			HighlightStart: line.HighlightStart,
+			HighlightEnd:   line.HighlightEnd,
+		}
+	}
+	return &api.CodeSearchResult{
+		RepoID:       repo.ID,
+		RepoFullName: repo.FullName(),
+		Filename:     result.Filename,
+		Language:     result.Language,
+		HTMLURL: repo.HTMLURL() + "/src/branch/" + (&url.URL{Path: repo.DefaultBranch}).EscapedPath() +
+			"/" + (&url.URL{Path: result.Filename}).EscapedPath(),
+		Lines: lines,
+	}
+}
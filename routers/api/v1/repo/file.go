@@ -6,11 +6,18 @@
 package repo
 
 import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/url"
+	"path"
+	"strings"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/routers/repo"
 
 	"code.gitea.io/git"
+	api "code.gitea.io/sdk/gitea"
 )
 
 // GetRawFile get a file by path on a repository
@@ -139,3 +146,528 @@ func GetEditorconfig(ctx *context.APIContext) {
 	}
 	ctx.JSON(200, def)
 }
+
+// GetContents gets the metadata and content of a file or the entries of a directory
+func GetContents(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/contents/{filepath} repository repoGetContents
+	// ---
+	// summary: Get the metadata and content of a file, or the entries of a directory, in a repository
+	// description: A file is returned as a single ContentsResponse object with base64 encoded content,
+	//   a directory is returned as a list of ContentsResponse objects without content.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: filepath
+	//   in: path
+	//   description: path of the file or directory
+	//   type: string
+	//   required: true
+	// - name: ref
+	//   in: query
+	//   description: "The name of the commit/branch/tag. Default the repository's default branch"
+	//   type: string
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ContentsResponse"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	if ctx.Repo.Repository.IsEmpty {
+		ctx.Status(404)
+		return
+	}
+
+	ref := ctx.Query("ref")
+	if len(ref) == 0 {
+		ref = ctx.Repo.Repository.DefaultBranch
+	}
+	var commit *git.Commit
+	var err error
+	gitRepo := ctx.Repo.GitRepo
+	switch {
+	case gitRepo.IsBranchExist(ref):
+		commit, err = gitRepo.GetBranchCommit(ref)
+	case gitRepo.IsTagExist(ref):
+		commit, err = gitRepo.GetTagCommit(ref)
+	case len(ref) == 40:
+		commit, err = gitRepo.GetCommit(ref)
+	default:
+		ctx.Status(404)
+		return
+	}
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetCommit", err)
+		}
+		return
+	}
+
+	treePath := strings.Trim(ctx.Params("*"), "/")
+	var entry *git.TreeEntry
+	if len(treePath) > 0 {
+		entry, err = commit.GetTreeEntryByPath(treePath)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				ctx.Status(404)
+			} else {
+				ctx.Error(500, "GetTreeEntryByPath", err)
+			}
+			return
+		}
+	}
+
+	if entry != nil && !entry.IsDir() {
+		contents, err := toContentsResponse(ctx.Repo.Repository, commit, treePath, entry, true)
+		if err != nil {
+			ctx.Error(500, "toContentsResponse", err)
+			return
+		}
+		ctx.JSON(200, contents)
+		return
+	}
+
+	tree := &commit.Tree
+	if entry != nil {
+		if tree, err = commit.SubTree(treePath); err != nil {
+			ctx.Error(500, "SubTree", err)
+			return
+		}
+	}
+	entries, err := tree.ListEntries()
+	if err != nil {
+		ctx.Error(500, "ListEntries", err)
+		return
+	}
+	contents := make([]*api.ContentsResponse, 0, len(entries))
+	for _, e := range entries {
+		c, err := toContentsResponse(ctx.Repo.Repository, commit, path.Join(treePath, e.Name()), e, false)
+		if err != nil {
+			ctx.Error(500, "toContentsResponse", err)
+			return
+		}
+		contents = append(contents, c)
+	}
+	ctx.JSON(200, contents)
+}
+
+// UpdateFile creates or updates a file in a repository
+func UpdateFile(ctx *context.APIContext, form api.UpdateFileOptions) {
+	// swagger:operation PUT /repos/{owner}/{repo}/contents/{filepath} repository repoUpdateFile
+	// ---
+	// summary: Create or update a file in a repository
+	// description: Updating an existing file requires the blob sha of the file being replaced,
+	//   if the file has been changed in the meantime the request fails with 409.
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: filepath
+	//   in: path
+	//   description: path of the file
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/UpdateFileOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/FileResponse"
+	//   "201":
+	//     "$ref": "#/responses/FileResponse"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	treePath := strings.Trim(ctx.Params("*"), "/")
+	content, err := base64.StdEncoding.DecodeString(form.Content)
+	if err != nil {
+		ctx.Error(422, "", "content must be base64 encoded: "+err.Error())
+		return
+	}
+
+	fc := getFileChange(ctx, treePath, form.BranchName, form.NewBranchName, form.Author, form.Committer)
+	if ctx.Written() {
+		return
+	}
+
+	// Make sure no parent of the new file is a file.
+	for dir := path.Dir(treePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		entry, err := fc.commit.GetTreeEntryByPath(dir)
+		if err != nil {
+			if git.IsErrNotExist(err) {
+				continue
+			}
+			ctx.Error(500, "GetTreeEntryByPath", err)
+			return
+		}
+		if !entry.IsDir() {
+			ctx.Error(422, "", "a parent of the path is not a directory: "+dir)
+			return
+		}
+	}
+
+	isNewFile := false
+	entry, err := fc.commit.GetTreeEntryByPath(treePath)
+	if err != nil {
+		if !git.IsErrNotExist(err) {
+			ctx.Error(500, "GetTreeEntryByPath", err)
+			return
+		}
+		if len(form.SHA) > 0 {
+			ctx.Error(404, "", "file does not exist: "+treePath)
+			return
+		}
+		isNewFile = true
+	} else if entry.IsDir() || entry.IsSubModule() {
+		ctx.Error(422, "", "path is not a file: "+treePath)
+		return
+	} else if len(form.SHA) == 0 {
+		ctx.Error(422, "", "sha is required to update an existing file")
+		return
+	}
+
+	message := form.Message
+	if len(message) == 0 {
+		if isNewFile {
+			message = "Add '" + treePath + "'"
+		} else {
+			message = "Update '" + treePath + "'"
+		}
+	}
+
+	if err := ctx.Repo.Repository.UpdateRepoFile(ctx.User, models.UpdateRepoFileOptions{
+		LastCommitID: fc.commit.ID.String(),
+		OldBranch:    fc.oldBranch,
+		NewBranch:    fc.newBranch,
+		OldTreeName:  treePath,
+		NewTreeName:  treePath,
+		Message:      message,
+		Content:      string(content),
+		IsNewFile:    isNewFile,
+		SHA:          form.SHA,
+		Author:       fc.author,
+		Committer:    fc.committer,
+	}); err != nil {
+		if models.IsErrSHADoesNotMatch(err) || models.IsErrRepoFileAlreadyExist(err) {
+			ctx.Error(409, "", err)
+		} else if models.IsErrRepoFileDoesNotExist(err) {
+			ctx.Error(404, "", err)
+		} else {
+			ctx.Error(500, "UpdateRepoFile", err)
+		}
+		return
+	}
+
+	resp := getFileResponse(ctx, fc.newBranch, treePath, true)
+	if ctx.Written() {
+		return
+	}
+	if isNewFile {
+		ctx.JSON(201, resp)
+	} else {
+		ctx.JSON(200, resp)
+	}
+}
+
+// DeleteFile deletes a file from a repository
+func DeleteFile(ctx *context.APIContext, form api.DeleteFileOptions) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/contents/{filepath} repository repoDeleteFile
+	// ---
+	// summary: Delete a file in a repository
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: filepath
+	//   in: path
+	//   description: path of the file to delete
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/DeleteFileOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/FileResponse"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	treePath := strings.Trim(ctx.Params("*"), "/")
+	fc := getFileChange(ctx, treePath, form.BranchName, form.NewBranchName, form.Author, form.Committer)
+	if ctx.Written() {
+		return
+	}
+
+	entry, err := fc.commit.GetTreeEntryByPath(treePath)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetTreeEntryByPath", err)
+		}
+		return
+	} else if entry.IsDir() || entry.IsSubModule() {
+		ctx.Error(422, "", "path is not a file: "+treePath)
+		return
+	}
+
+	message := form.Message
+	if len(message) == 0 {
+		message = "Delete '" + treePath + "'"
+	}
+
+	if err := ctx.Repo.Repository.DeleteRepoFile(ctx.User, models.DeleteRepoFileOptions{
+		LastCommitID: fc.commit.ID.String(),
+		OldBranch:    fc.oldBranch,
+		NewBranch:    fc.newBranch,
+		TreePath:     treePath,
+		Message:      message,
+		SHA:          form.SHA,
+		Author:       fc.author,
+		Committer:    fc.committer,
+	}); err != nil {
+		if models.IsErrSHADoesNotMatch(err) {
+			ctx.Error(409, "", err)
+		} else if models.IsErrRepoFileDoesNotExist(err) {
+			ctx.Error(404, "", err)
+		} else {
+			ctx.Error(500, "DeleteRepoFile", err)
+		}
+		return
+	}
+
+	resp := getFileResponse(ctx, fc.newBranch, treePath, false)
+	if ctx.Written() {
+		return
+	}
+	ctx.JSON(200, resp)
+}
+
+// fileChange holds the validated common options of a file change
+type fileChange struct {
+	oldBranch string
+	newBranch string
+	commit    *git.Commit
+	author    *git.Signature
+	committer *git.Signature
+}
+
+// getFileChange validates the branches and identities of a file change.
+// Writes to ctx if an error occurs.
+func getFileChange(ctx *context.APIContext, treePath, oldBranch, newBranch string, author, committer api.Identity) *fileChange {
+	repo := ctx.Repo.Repository
+	if repo.IsEmpty {
+		ctx.Status(404)
+		return nil
+	}
+	if !repo.CanEnableEditor() {
+		ctx.Error(403, "", "repository can not be edited")
+		return nil
+	}
+	if len(treePath) == 0 || strings.Contains(treePath, "//") || hasDotPathSegment(treePath) {
+		ctx.Error(422, "", "invalid file path: "+treePath)
+		return nil
+	}
+
+	fc := &fileChange{oldBranch: oldBranch, newBranch: newBranch}
+	if len(fc.oldBranch) == 0 {
+		fc.oldBranch = repo.DefaultBranch
+	}
+	if len(fc.newBranch) == 0 {
+		fc.newBranch = fc.oldBranch
+	}
+
+	var err error
+	fc.commit, err = ctx.Repo.GitRepo.GetBranchCommit(fc.oldBranch)
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.Error(404, "", "branch does not exist: "+fc.oldBranch)
+		} else {
+			ctx.Error(500, "GetBranchCommit", err)
+		}
+		return nil
+	}
+
+	if fc.newBranch != fc.oldBranch {
+		if ctx.Repo.GitRepo.IsBranchExist(fc.newBranch) {
+			ctx.Error(422, "", "branch already exists: "+fc.newBranch)
+			return nil
+		}
+	} else {
+		protected, err := repo.IsProtectedBranchForPush(fc.oldBranch, ctx.User)
+		if err != nil {
+			ctx.Error(500, "IsProtectedBranchForPush", err)
+			return nil
+		} else if protected {
+			ctx.Error(403, "", "branch is protected: "+fc.oldBranch)
+			return nil
+		}
+	}
+
+	fc.committer = ctx.User.NewGitSig()
+	if len(committer.Name) > 0 || len(committer.Email) > 0 {
+		if len(committer.Name) > 0 {
+			fc.committer.Name = committer.Name
+		}
+		if len(committer.Email) > 0 {
+			fc.committer.Email = committer.Email
+		}
+	}
+	if len(author.Name) > 0 || len(author.Email) > 0 {
+		fc.author = &git.Signature{
+			Name:  fc.committer.Name,
+			Email: fc.committer.Email,
+			When:  fc.committer.When,
+		}
+		if len(author.Name) > 0 {
+			fc.author.Name = author.Name
+		}
+		if len(author.Email) > 0 {
+			fc.author.Email = author.Email
+		}
+	}
+	return fc
+}
+
+// hasDotPathSegment returns true if treePath contains a "." or ".." segment
+func hasDotPathSegment(treePath string) bool {
+	for _, part := range strings.Split(treePath, "/") {
+		if part == "." || part == ".." || part == ".git" {
+			return true
+		}
+	}
+	return false
+}
+
+// getFileResponse returns the contents of treePath on the head of branch
+// together with the head commit. Writes to ctx if an error occurs.
+func getFileResponse(ctx *context.APIContext, branch, treePath string, withContent bool) *api.FileResponse {
+	repo := ctx.Repo.Repository
+	gitRepo, err := git.OpenRepository(repo.RepoPath())
+	if err != nil {
+		ctx.Error(500, "OpenRepository", err)
+		return nil
+	}
+	commit, err := gitRepo.GetBranchCommit(branch)
+	if err != nil {
+		ctx.Error(500, "GetBranchCommit", err)
+		return nil
+	}
+
+	resp := &api.FileResponse{
+		Commit: &api.FileCommitResponse{
+			SHA:     commit.ID.String(),
+			HTMLURL: repo.HTMLURL() + "/commit/" + commit.ID.String(),
+			Message: commit.Message(),
+			Author: &api.PayloadUser{
+				Name:  commit.Author.Name,
+				Email: commit.Author.Email,
+			},
+			Committer: &api.PayloadUser{
+				Name:  commit.Committer.Name,
+				Email: commit.Committer.Email,
+			},
+		},
+	}
+	if withContent {
+		entry, err := commit.GetTreeEntryByPath(treePath)
+		if err != nil {
+			ctx.Error(500, "GetTreeEntryByPath", err)
+			return nil
+		}
+		if resp.Content, err = toContentsResponse(repo, commit, treePath, entry, false); err != nil {
+			ctx.Error(500, "toContentsResponse", err)
+			return nil
+		}
+	}
+	return resp
+}
+
+// toContentsResponse converts a tree entry of commit to an api.ContentsResponse,
+// the content of files is only included if withContent is true.
+func toContentsResponse(repo *models.Repository, commit *git.Commit, treePath string, entry *git.TreeEntry, withContent bool) (*api.ContentsResponse, error) {
+	commitID := commit.ID.String()
+	escapedPath := (&url.URL{Path: treePath}).EscapedPath()
+	contents := &api.ContentsResponse{
+		Name:        entry.Name(),
+		Path:        treePath,
+		SHA:         entry.ID.String(),
+		Type:        "file",
+		URL:         repo.APIURL() + "/contents/" + escapedPath + "?ref=" + commitID,
+		HTMLURL:     repo.HTMLURL() + "/src/commit/" + commitID + "/" + escapedPath,
+		DownloadURL: repo.HTMLURL() + "/raw/commit/" + commitID + "/" + escapedPath,
+	}
+
+	switch {
+	case entry.IsDir():
+		contents.Type = "dir"
+		contents.DownloadURL = ""
+		return contents, nil
+	case entry.IsSubModule():
+		contents.Type = "submodule"
+		contents.DownloadURL = ""
+		return contents, nil
+	case entry.IsLink():
+		contents.Type = "symlink"
+	}
+
+	contents.Size = entry.Size()
+	if withContent {
+		reader, err := entry.Blob().Data()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+		contents.Encoding = "base64"
+		contents.Content = base64.StdEncoding.EncodeToString(data)
+	}
+	return contents, nil
+}
@@ -15,12 +15,124 @@ import (
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/search"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 
 	api "code.gitea.io/sdk/gitea"
 )
 
+// SearchIssues searches for issues in all the repositories readable by the user
+func SearchIssues(ctx *context.APIContext) {
+	// swagger:operation GET /repos/issues/search issue issueSearchIssues
+	// ---
+	// summary: Search for issues in all the repositories readable by the user
+	// description: The query may contain the qualifiers is:open, is:closed,
+	//              is:issue, is:pr, label:name, author:user, assignee:user,
+	//              mentions:user, milestone:name, repo:owner/name,
+	//              created:date and updated:date, where @me stands for the
+	//              authenticated user and dates are written YYYY-MM-DD,
+	//              optionally preceded by >, >=, < or <=, or as a range
+	//              date..date.
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: q
+	//   in: query
+	//   description: search query, e.g. "crash is:open label:bug author:@me"
+	//   type: string
+	// - name: state
+	//   in: query
+	//   description: whether issue is open or closed, or "all", overridden
+	//                by is:open and is:closed in the query
+	//   type: string
+	// - name: type
+	//   in: query
+	//   description: search for "issues" (default) or "pulls", overridden by
+	//                is:issue and is:pr in the query
+	//   type: string
+	// - name: page
+	//   in: query
+	//   description: page number of requested issues
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/IssueList"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	opts := &models.IssuesOptions{
+		Page:     ctx.QueryInt("page"),
+		PageSize: setting.UI.IssuePagingNum,
+		IsPull:   util.OptionalBoolFalse,
+		SortType: "latest",
+	}
+	switch ctx.Query("state") {
+	case "closed":
+		opts.IsClosed = util.OptionalBoolTrue
+	case "all":
+		opts.IsClosed = util.OptionalBoolNone
+	default:
+		opts.IsClosed = util.OptionalBoolFalse
+	}
+	if ctx.Query("type") == "pulls" {
+		opts.IsPull = util.OptionalBoolTrue
+	}
+	if opts.Page <= 0 {
+		opts.Page = 1
+	}
+
+	query, err := search.ParseIssueQuery(strings.TrimSpace(ctx.Query("q")))
+	if err != nil {
+		if search.IsErrInvalidIssueQuery(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(500, "ParseIssueQuery", err)
+		}
+		return
+	}
+	if !query.IsPull.IsNone() {
+		opts.IsPull = query.IsPull
+	}
+
+	unitType := models.UnitTypeIssues
+	if opts.IsPull.IsTrue() {
+		unitType = models.UnitTypePullRequests
+	}
+	opts.RepoIDs, err = models.FindUserUnitAccessibleRepoIDs(ctx.User, 0, unitType)
+	if err != nil {
+		ctx.Error(500, "FindUserUnitAccessibleRepoIDs", err)
+		return
+	}
+	if len(opts.RepoIDs) == 0 {
+		// force an empty result
+		opts.RepoIDs = []int64{-1}
+	}
+	if err = query.Apply(opts, ctx.User); err != nil {
+		ctx.Error(500, "Apply", err)
+		return
+	}
+
+	total, err := models.CountIssues(opts)
+	if err != nil {
+		ctx.Error(500, "CountIssues", err)
+		return
+	}
+	issues, err := models.Issues(opts)
+	if err != nil {
+		ctx.Error(500, "Issues", err)
+		return
+	}
+
+	apiIssues := make([]*api.Issue, len(issues))
+	for i := range issues {
+		apiIssues[i] = issues[i].APIFormat()
+	}
+
+	ctx.SetLinkHeader(int(total), opts.PageSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", total))
+	ctx.JSON(200, &apiIssues)
+}
+
 // ListIssues list the issues of a repository
 func ListIssues(ctx *context.APIContext) {
 	// swagger:operation GET /repos/{owner}/{repo}/issues issue issueListIssues
@@ -73,7 +185,7 @@ func ListIssues(ctx *context.APIContext) {
 	var issueIDs []int64
 	var err error
 	if len(keyword) > 0 {
-		issueIDs, err = indexer.SearchIssuesByKeyword(ctx.Repo.Repository.ID, keyword)
+		issueIDs, err = indexer.SearchIssuesByKeyword([]int64{ctx.Repo.Repository.ID}, keyword)
 	}
 
 	// Only fetch the issues if we either don't have a keyword or the search returned issues
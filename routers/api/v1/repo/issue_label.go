@@ -106,9 +106,9 @@ func AddIssueLabels(ctx *context.APIContext, form api.IssueLabelsOption) {
 		return
 	}
 
-	labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+	labels, err := models.GetLabelsAvailableToRepoByIDs(ctx.Repo.Repository, form.Labels)
 	if err != nil {
-		ctx.Error(500, "GetLabelsInRepoByIDs", err)
+		ctx.Error(500, "GetLabelsAvailableToRepoByIDs", err)
 		return
 	}
 
@@ -178,12 +178,12 @@ func DeleteIssueLabel(ctx *context.APIContext) {
 		return
 	}
 
-	label, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	label, err := models.GetLabelAvailableToRepoByID(ctx.Repo.Repository, ctx.ParamsInt64(":id"))
 	if err != nil {
 		if models.IsErrLabelNotExist(err) {
 			ctx.Error(422, "", err)
 		} else {
-			ctx.Error(500, "GetLabelInRepoByID", err)
+			ctx.Error(500, "GetLabelAvailableToRepoByID", err)
 		}
 		return
 	}
@@ -244,9 +244,9 @@ func ReplaceIssueLabels(ctx *context.APIContext, form api.IssueLabelsOption) {
 		return
 	}
 
-	labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+	labels, err := models.GetLabelsAvailableToRepoByIDs(ctx.Repo.Repository, form.Labels)
 	if err != nil {
-		ctx.Error(500, "GetLabelsInRepoByIDs", err)
+		ctx.Error(500, "GetLabelsAvailableToRepoByIDs", err)
 		return
 	}
 
@@ -125,9 +125,11 @@ func CreateLabel(ctx *context.APIContext, form api.CreateLabelOption) {
 	//   "201":
 	//     "$ref": "#/responses/Label"
 	label := &models.Label{
-		Name:   form.Name,
-		Color:  form.Color,
-		RepoID: ctx.Repo.Repository.ID,
+		Name:      form.Name,
+		Color:     form.Color,
+		Exclusive: form.Exclusive,
+		Priority:  form.Priority,
+		RepoID:    ctx.Repo.Repository.ID,
 	}
 	if err := models.NewLabel(label); err != nil {
 		ctx.Error(500, "NewLabel", err)
@@ -185,6 +187,12 @@ func EditLabel(ctx *context.APIContext, form api.EditLabelOption) {
 	if form.Color != nil {
 		label.Color = *form.Color
 	}
+	if form.Exclusive != nil {
+		label.Exclusive = *form.Exclusive
+	}
+	if form.Priority != nil {
+		label.Priority = *form.Priority
+	}
 	if err := models.UpdateLabel(label); err != nil {
 		ctx.ServerError("UpdateLabel", err)
 		return
@@ -0,0 +1,146 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/validation"
+	"code.gitea.io/gitea/routers/api/v1/convert"
+	api "code.gitea.io/sdk/gitea"
+)
+
+// ListMirrorSyncs list the latest synchronizations of a mirror
+func ListMirrorSyncs(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/mirror-sync repository repoListMirrorSyncs
+	// ---
+	// summary: List the latest synchronizations of a mirrored repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/MirrorSyncList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	repo := ctx.Repo.Repository
+	if !repo.IsMirror {
+		ctx.Status(404)
+		return
+	}
+
+	records, err := models.GetMirrorSyncRecords(repo.ID)
+	if err != nil {
+		ctx.Error(500, "GetMirrorSyncRecords", err)
+		return
+	}
+	apiSyncs := make([]*api.MirrorSync, len(records))
+	for i := range records {
+		apiSyncs[i] = convert.ToMirrorSync(records[i])
+	}
+	ctx.JSON(200, &apiSyncs)
+}
+
+// TriggerMirrorSync sync the mirror of the secret trigger token
+func TriggerMirrorSync(ctx *context.APIContext) {
+	// swagger:operation POST /mirror-sync/{token} repository repoTriggerMirrorSync
+	// ---
+	// summary: Sync the mirrored repository of a secret trigger token
+	// description: Meant to be called by the push webhooks of the upstream
+	//   repository. Only the pushed reference is fetched if the body is a
+	//   GitHub, GitLab or Gitea push or create event, all of them otherwise.
+	// consumes:
+	// - application/json
+	// - application/x-www-form-urlencoded
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: token
+	//   in: path
+	//   description: secret trigger token of the mirror
+	//   type: string
+	//   required: true
+	// responses:
+	//   "202":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	m, err := models.GetMirrorByTriggerToken(ctx.Params(":token"))
+	if err != nil {
+		if err == models.ErrMirrorNotExist {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetMirrorByTriggerToken", err)
+		}
+		return
+	}
+
+	body, err := ctx.Req.Body().Bytes()
+	if err != nil {
+		log.Trace("TriggerMirrorSync [repo_id: %d]: failed to read body: %v", m.RepoID, err)
+	}
+
+	models.AddMirrorSyncTask(m.RepoID, parseMirrorSyncRefs(ctx.Req.Header.Get("Content-Type"), body)...)
+	ctx.Status(202)
+}
+
+// mirrorSyncPayload holds the fields, shared by the push and create events of
+// GitHub, GitLab and Gitea, which tell the pushed reference
+type mirrorSyncPayload struct {
+	Ref     string `json:"ref"`
+	RefType string `json:"ref_type"`
+	After   string `json:"after"`
+	Deleted bool   `json:"deleted"`
+}
+
+// parseMirrorSyncRefs returns the references pushed according to the webhook
+// payload, or nil if the whole repository must be synced because the payload is
+// unknown or the reference has been deleted.
+func parseMirrorSyncRefs(contentType string, body []byte) []string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil
+		}
+		body = []byte(form.Get("payload"))
+	}
+
+	var payload mirrorSyncPayload
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Ref) == 0 {
+		return nil
+	}
+	// deleted references are removed by fully syncing with pruning
+	if payload.Deleted || (len(payload.After) > 0 && strings.Trim(payload.After, "0") == "") {
+		return nil
+	}
+
+	ref := payload.Ref
+	switch payload.RefType {
+	case "branch":
+		ref = "refs/heads/" + ref
+	case "tag":
+		ref = "refs/tags/" + ref
+	}
+	if !strings.HasPrefix(ref, "refs/") || strings.Contains(ref, "..") ||
+		validation.GitRefNamePattern.MatchString(ref) {
+		return nil
+	}
+	return []string{ref}
+}
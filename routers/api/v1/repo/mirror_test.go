@@ -0,0 +1,43 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMirrorSyncRefs(t *testing.T) {
+	const jsonType = "application/json"
+	kases := []struct {
+		contentType string
+		body        string
+		refs        []string
+	}{
+		// push events of GitHub, GitLab and Gitea
+		{jsonType, `{"ref":"refs/heads/master","after":"4b825dc642cb6eb9a060e54bf8d69288fbee4904"}`, []string{"refs/heads/master"}},
+		{jsonType, `{"ref":"refs/tags/v1.0","after":"4b825dc642cb6eb9a060e54bf8d69288fbee4904"}`, []string{"refs/tags/v1.0"}},
+		// create events of GitHub and Gitea
+		{jsonType, `{"ref":"feature/x","ref_type":"branch"}`, []string{"refs/heads/feature/x"}},
+		{jsonType, `{"ref":"v1.0","ref_type":"tag"}`, []string{"refs/tags/v1.0"}},
+		// GitHub form encoded payload
+		{"application/x-www-form-urlencoded", "payload=" + url.QueryEscape(`{"ref":"refs/heads/develop"}`), []string{"refs/heads/develop"}},
+		// deleted references
+		{jsonType, `{"ref":"refs/heads/master","deleted":true}`, nil},
+		{jsonType, `{"ref":"refs/heads/master","after":"0000000000000000000000000000000000000000"}`, nil},
+		// unknown or invalid payloads
+		{jsonType, ``, nil},
+		{jsonType, `not json`, nil},
+		{jsonType, `{"zen":"Keep it logically awesome."}`, nil},
+		{jsonType, `{"ref":"master"}`, nil},
+		{jsonType, `{"ref":"refs/heads/a..b"}`, nil},
+		{jsonType, `{"ref":"refs/heads/$(id)"}`, nil},
+	}
+	for _, kase := range kases {
+		assert.Equal(t, kase.refs, parseMirrorSyncRefs(kase.contentType, []byte(kase.body)), kase.body)
+	}
+}
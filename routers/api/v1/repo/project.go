@@ -0,0 +1,737 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+
+	api "code.gitea.io/sdk/gitea"
+)
+
+// ListProjectBoards list all the project boards of a repository
+func ListProjectBoards(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects repository repoListProjectBoards
+	// ---
+	// summary: List a repository's project boards
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoardList"
+	boards, err := models.GetProjectBoardsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.Error(500, "GetProjectBoardsByRepoID", err)
+		return
+	}
+
+	apiBoards := make([]*api.ProjectBoard, len(boards))
+	for i := range boards {
+		apiBoards[i] = boards[i].APIFormat()
+	}
+	ctx.JSON(200, &apiBoards)
+}
+
+// GetProjectBoard get a project board of a repository with its columns and cards
+func GetProjectBoard(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id} repository repoGetProjectBoard
+	// ---
+	// summary: Get a project board with its columns and cards
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := board.LoadColumnsAndCards(); err != nil {
+		ctx.Error(500, "LoadColumnsAndCards", err)
+		return
+	}
+	board.FilterCards(&ctx.Repo.Permission)
+	ctx.JSON(200, board.APIFormat())
+}
+
+// CreateProjectBoard create a project board for a repository
+func CreateProjectBoard(ctx *context.APIContext, form api.CreateProjectBoardOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects repository repoCreateProjectBoard
+	// ---
+	// summary: Create a project board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectBoardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectBoard"
+	board := &models.ProjectBoard{
+		RepoID:      ctx.Repo.Repository.ID,
+		Title:       form.Title,
+		Description: form.Description,
+		CreatorID:   ctx.User.ID,
+	}
+	if err := models.NewProjectBoard(board, form.Columns...); err != nil {
+		ctx.Error(500, "NewProjectBoard", err)
+		return
+	}
+	ctx.JSON(201, board.APIFormat())
+}
+
+// EditProjectBoard modify a project board of a repository
+func EditProjectBoard(ctx *context.APIContext, form api.EditProjectBoardOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/projects/{id} repository repoEditProjectBoard
+	// ---
+	// summary: Update a project board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectBoardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectBoard"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil {
+		board.Title = *form.Title
+	}
+	if form.Description != nil {
+		board.Description = *form.Description
+	}
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.Error(500, "UpdateProjectBoard", err)
+		return
+	}
+	ctx.JSON(200, board.APIFormat())
+}
+
+// DeleteProjectBoard delete a project board of a repository
+func DeleteProjectBoard(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id} repository repoDeleteProjectBoard
+	// ---
+	// summary: Delete a project board with its columns and cards
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	if err := models.DeleteProjectBoardByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id")); err != nil {
+		ctx.Error(500, "DeleteProjectBoardByRepoID", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// ListProjectColumns list the columns of a project board
+func ListProjectColumns(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id}/columns repository repoListProjectColumns
+	// ---
+	// summary: List a project board's columns
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumnList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := board.LoadColumns(); err != nil {
+		ctx.Error(500, "LoadColumns", err)
+		return
+	}
+	apiColumns := make([]*api.ProjectColumn, len(board.Columns))
+	for i := range board.Columns {
+		apiColumns[i] = board.Columns[i].APIFormat()
+	}
+	ctx.JSON(200, &apiColumns)
+}
+
+// CreateProjectColumn create a column at the end of a project board
+func CreateProjectColumn(ctx *context.APIContext, form api.CreateProjectColumnOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects/{id}/columns repository repoCreateProjectColumn
+	// ---
+	// summary: Create a column at the end of a project board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectColumnOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	column := &models.ProjectColumn{
+		BoardID: board.ID,
+		Title:   form.Title,
+	}
+	if err := models.NewProjectColumn(column); err != nil {
+		ctx.Error(500, "NewProjectColumn", err)
+		return
+	}
+	ctx.JSON(201, column.APIFormat())
+}
+
+// EditProjectColumn modify a column of a project board
+func EditProjectColumn(ctx *context.APIContext, form api.EditProjectColumnOption) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/projects/{id}/columns/{column} repository repoEditProjectColumn
+	// ---
+	// summary: Update a column of a project board
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditProjectColumnOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectColumn"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	column := getProjectColumn(ctx, board, ctx.ParamsInt64(":column"))
+	if ctx.Written() {
+		return
+	}
+
+	if form.Title != nil {
+		column.Title = *form.Title
+	}
+	if form.Sorting != nil {
+		column.Sorting = *form.Sorting
+	}
+	if err := models.UpdateProjectColumn(column); err != nil {
+		ctx.Error(500, "UpdateProjectColumn", err)
+		return
+	}
+	ctx.JSON(200, column.APIFormat())
+}
+
+// DeleteProjectColumn delete a column of a project board
+func DeleteProjectColumn(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id}/columns/{column} repository repoDeleteProjectColumn
+	// ---
+	// summary: Delete a column of a project board with its cards
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectColumnByBoardID(board.ID, ctx.ParamsInt64(":column")); err != nil {
+		ctx.Error(500, "DeleteProjectColumnByBoardID", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// ListProjectCards list the cards of a column of a project board
+func ListProjectCards(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/projects/{id}/columns/{column}/cards repository repoListProjectCards
+	// ---
+	// summary: List a column's cards in order
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCardList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	column := getProjectColumn(ctx, board, ctx.ParamsInt64(":column"))
+	if ctx.Written() {
+		return
+	}
+
+	cards, err := models.GetProjectCardsByColumnID(column.ID)
+	if err != nil {
+		ctx.Error(500, "GetProjectCardsByColumnID", err)
+		return
+	}
+	cards = models.FilterProjectCards(cards, &ctx.Repo.Permission)
+	apiCards := make([]*api.ProjectCard, len(cards))
+	for i := range cards {
+		apiCards[i] = cards[i].APIFormat()
+	}
+	ctx.JSON(200, &apiCards)
+}
+
+// CreateProjectCard add an issue or a pull request at the end of a column of a project board
+func CreateProjectCard(ctx *context.APIContext, form api.CreateProjectCardOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects/{id}/columns/{column}/cards repository repoCreateProjectCard
+	// ---
+	// summary: Add an issue or a pull request at the end of a column
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: column
+	//   in: path
+	//   description: id of the column
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateProjectCardOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	column := getProjectColumn(ctx, board, ctx.ParamsInt64(":column"))
+	if ctx.Written() {
+		return
+	}
+
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, form.Issue)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "GetIssueByIndex", err)
+		}
+		return
+	}
+	if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+		ctx.Status(403)
+		return
+	}
+
+	card, err := models.NewProjectCard(ctx.User, issue, column)
+	if err != nil {
+		if models.IsErrProjectCardAlreadyExist(err) {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "NewProjectCard", err)
+		}
+		return
+	}
+	ctx.JSON(201, card.APIFormat())
+}
+
+// MoveProjectCard move a card to another position or column of its project board
+func MoveProjectCard(ctx *context.APIContext, form api.MoveProjectCardOption) {
+	// swagger:operation POST /repos/{owner}/{repo}/projects/{id}/cards/{card}/move repository repoMoveProjectCard
+	// ---
+	// summary: Move a card to another position or column of its board, recording column changes in the issue
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: card
+	//   in: path
+	//   description: id of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/MoveProjectCardOption"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/ProjectCard"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	card := getProjectCard(ctx, board)
+	if ctx.Written() {
+		return
+	}
+
+	column, err := models.GetProjectColumnByBoardID(board.ID, form.ColumnID)
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.Error(422, "", err)
+		} else {
+			ctx.Error(500, "GetProjectColumnByBoardID", err)
+		}
+		return
+	}
+
+	position := -1
+	if form.Position != nil {
+		position = *form.Position
+	}
+	if err = models.MoveProjectCard(ctx.User, card, column, position); err != nil {
+		ctx.Error(500, "MoveProjectCard", err)
+		return
+	}
+	ctx.JSON(200, card.APIFormat())
+}
+
+// DeleteProjectCard remove a card from its project board
+func DeleteProjectCard(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/projects/{id}/cards/{card} repository repoDeleteProjectCard
+	// ---
+	// summary: Remove a card from its board, recording it in the issue
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the project board
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: card
+	//   in: path
+	//   description: id of the card
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	card := getProjectCard(ctx, board)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectCard(ctx.User, card); err != nil {
+		ctx.Error(500, "DeleteProjectCard", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// getProjectBoard returns the project board of the repository named by the
+// request path or writes a 404 if it does not exist
+func getProjectBoard(ctx *context.APIContext) *models.ProjectBoard {
+	board, err := models.GetProjectBoardByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetProjectBoardByRepoID", err)
+		}
+		return nil
+	}
+	return board
+}
+
+// getProjectColumn returns the column of the project board or writes a 404
+// if it does not exist
+func getProjectColumn(ctx *context.APIContext, board *models.ProjectBoard, id int64) *models.ProjectColumn {
+	column, err := models.GetProjectColumnByBoardID(board.ID, id)
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetProjectColumnByBoardID", err)
+		}
+		return nil
+	}
+	return column
+}
+
+// getProjectCard returns the card of the project board named by the request
+// path with its issue, it writes a 404 if the card does not exist or its issue
+// cannot be read, and a 403 if its issue cannot be written
+func getProjectCard(ctx *context.APIContext, board *models.ProjectBoard) *models.ProjectCard {
+	card, err := models.GetProjectCardByBoardID(board.ID, ctx.ParamsInt64(":card"))
+	if err != nil {
+		if models.IsErrProjectCardNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetProjectCardByBoardID", err)
+		}
+		return nil
+	}
+
+	if err = card.LoadIssue(); err != nil {
+		ctx.Error(500, "LoadIssue", err)
+		return nil
+	} else if !ctx.Repo.CanReadIssuesOrPulls(card.Issue.IsPull) {
+		ctx.Status(404)
+		return nil
+	} else if !ctx.Repo.CanWriteIssuesOrPulls(card.Issue.IsPull) {
+		ctx.Status(403)
+		return nil
+	}
+	return card
+}
@@ -215,9 +215,9 @@ func CreatePullRequest(ctx *context.APIContext, form api.CreatePullRequestOption
 	}
 
 	if len(form.Labels) > 0 {
-		labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+		labels, err := models.GetLabelsAvailableToRepoByIDs(ctx.Repo.Repository, form.Labels)
 		if err != nil {
-			ctx.Error(500, "GetLabelsInRepoByIDs", err)
+			ctx.Error(500, "GetLabelsAvailableToRepoByIDs", err)
 			return
 		}
 
@@ -406,9 +406,9 @@ func EditPullRequest(ctx *context.APIContext, form api.EditPullRequestOption) {
 	}
 
 	if ctx.Repo.CanWrite(models.UnitTypePullRequests) && form.Labels != nil {
-		labels, err := models.GetLabelsInRepoByIDs(ctx.Repo.Repository.ID, form.Labels)
+		labels, err := models.GetLabelsAvailableToRepoByIDs(ctx.Repo.Repository, form.Labels)
 		if err != nil {
-			ctx.Error(500, "GetLabelsInRepoByIDsError", err)
+			ctx.Error(500, "GetLabelsAvailableToRepoByIDsError", err)
 			return
 		}
 		if err = issue.ReplaceLabels(labels, ctx.User); err != nil {
@@ -518,11 +518,19 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 	//   type: integer
 	//   format: int64
 	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     $ref: "#/definitions/MergePullRequestOption"
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/empty"
+	//   "202":
+	//     "$ref": "#/responses/empty"
 	//   "405":
 	//     "$ref": "#/responses/empty"
+	//   "409":
+	//     "$ref": "#/responses/error"
 	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
 	if err != nil {
 		if models.IsErrPullRequestNotExist(err) {
@@ -554,7 +562,9 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 		return
 	}
 
-	if !pr.CanAutoMerge() || pr.HasMerged || pr.IsWorkInProgress() {
+	// A merge can be scheduled while the conflict check is still running.
+	if pr.HasMerged || pr.IsWorkInProgress() ||
+		!(pr.CanAutoMerge() || form.MergeWhenChecksSucceed && pr.IsChecking()) {
 		ctx.Status(405)
 		return
 	}
@@ -578,6 +588,40 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 		message += "\n\n" + form.MergeMessageField
 	}
 
+	if form.MergeWhenChecksSucceed {
+		ready, err := pr.IsReadyToMerge()
+		if err != nil {
+			ctx.Error(500, "IsReadyToMerge", err)
+			return
+		}
+		if !ready {
+			if err = models.ScheduleAutoMerge(ctx.User, pr, models.MergeStyle(form.Do), message); err != nil {
+				if models.IsErrInvalidMergeStyle(err) || models.IsErrNotAllowedToMerge(err) {
+					ctx.Status(405)
+				} else if models.IsErrPullAutoMergeAlreadyScheduled(err) {
+					ctx.Error(409, "ScheduleAutoMerge", err)
+				} else {
+					ctx.Error(500, "ScheduleAutoMerge", err)
+				}
+				return
+			}
+
+			log.Trace("Pull request scheduled to auto merge: %d", pr.ID)
+			ctx.Status(202)
+			return
+		}
+	}
+
+	missing, err := pr.GetMissingStatusContexts()
+	if err != nil {
+		ctx.Error(500, "GetMissingStatusContexts", err)
+		return
+	}
+	if len(missing) > 0 {
+		ctx.Error(405, "", "required status checks have not succeeded: "+strings.Join(missing, ", "))
+		return
+	}
+
 	if err := pr.Merge(ctx.User, ctx.Repo.GitRepo, models.MergeStyle(form.Do), message); err != nil {
 		if models.IsErrInvalidMergeStyle(err) {
 			ctx.Status(405)
@@ -591,6 +635,58 @@ func MergePullRequest(ctx *context.APIContext, form auth.MergePullRequestForm) {
 	ctx.Status(200)
 }
 
+// CancelScheduledMerge cancels the scheduled merge of a PR given an index
+func CancelScheduledMerge(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/merge repository repoCancelScheduledMerge
+	// ---
+	// summary: Cancel the scheduled merge of a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.NotFound("GetPullRequestByIndex", err)
+		} else {
+			ctx.Error(500, "GetPullRequestByIndex", err)
+		}
+		return
+	}
+
+	if err = models.CancelScheduledMerge(ctx.User, pr); err != nil {
+		if models.IsErrPullAutoMergeNotExist(err) {
+			ctx.NotFound("CancelScheduledMerge", err)
+		} else {
+			ctx.Error(500, "CancelScheduledMerge", err)
+		}
+		return
+	}
+
+	log.Trace("Pull request auto merge canceled: %d", pr.ID)
+	ctx.Status(204)
+}
+
 func parseCompareInfo(ctx *context.APIContext, form api.CreatePullRequestOption) (*models.User, *models.Repository, *git.Repository, *git.PullRequestInfo, string, string) {
 	baseRepo := ctx.Repo.Repository
 
@@ -0,0 +1,573 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/notification"
+
+	api "code.gitea.io/sdk/gitea"
+)
+
+// ListPullReviews lists all reviews of a pull request
+func ListPullReviews(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews repository repoListPullReviews
+	// ---
+	// summary: List all reviews for a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	pr := getPullRequestForReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	allReviews, err := models.FindReviews(models.FindReviewOptions{
+		Type:    models.ReviewTypeUnknown,
+		IssueID: pr.IssueID,
+	})
+	if err != nil {
+		ctx.Error(500, "FindReviews", err)
+		return
+	}
+
+	apiReviews := make([]*api.PullReview, 0, len(allReviews))
+	for _, review := range allReviews {
+		// pending reviews are only visible to their author
+		if review.Type == models.ReviewTypePending && (!ctx.IsSigned || review.ReviewerID != ctx.User.ID) {
+			continue
+		}
+		review.Issue = pr.Issue
+		if err = review.LoadAttributes(); err != nil {
+			ctx.Error(500, "LoadAttributes", err)
+			return
+		}
+		apiReviews = append(apiReviews, review.APIFormat())
+	}
+
+	ctx.JSON(200, &apiReviews)
+}
+
+// GetPullReview gets a specific review of a pull request
+func GetPullReview(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoGetPullReview
+	// ---
+	// summary: Get a specific review for a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	review, _ := getReviewForPullRequest(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(200, review.APIFormat())
+}
+
+// GetPullReviewComments lists all code comments of a pull request review
+func GetPullReviewComments(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/comments repository repoGetPullReviewComments
+	// ---
+	// summary: Get the code comments of a review for a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReviewCommentList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	review, _ := getReviewForPullRequest(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	comments, err := models.GetReviewComments(review)
+	if err != nil {
+		ctx.Error(500, "GetReviewComments", err)
+		return
+	}
+
+	apiComments := make([]*api.PullReviewComment, len(comments))
+	for i, comment := range comments {
+		comment.Issue = review.Issue
+		comment.Review = review
+		apiComments[i] = comment.APIFormatCodeComment()
+	}
+	ctx.JSON(200, &apiComments)
+}
+
+// DeletePullReview deletes a pending review of a pull request
+func DeletePullReview(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoDeletePullReview
+	// ---
+	// summary: Delete a pending review from a pull request
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	review, _ := getReviewForPullRequest(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if review.Type != models.ReviewTypePending {
+		ctx.Error(422, "", "only pending reviews can be deleted")
+		return
+	}
+
+	if err := models.DeletePendingReview(review); err != nil {
+		ctx.Error(500, "DeletePendingReview", err)
+		return
+	}
+	ctx.Status(204)
+}
+
+// CreatePullReview creates a review on a pull request
+func CreatePullReview(ctx *context.APIContext, opts api.CreatePullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews repository repoCreatePullReview
+	// ---
+	// summary: Create a review for a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/CreatePullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	pr := getPullRequestForReview(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if len(opts.Event) == 0 {
+		opts.Event = api.ReviewStatePending
+	}
+	reviewType := models.ReviewTypeFromAPIState(opts.Event)
+	if !checkReviewType(ctx, pr, reviewType, opts.Body, len(opts.Comments) > 0) {
+		return
+	}
+
+	// reuse the pending review of the user if there is one, like the web UI does
+	review, err := models.GetCurrentReview(ctx.User, pr.Issue)
+	if err != nil {
+		if !models.IsErrReviewNotExist(err) {
+			ctx.Error(500, "GetCurrentReview", err)
+			return
+		}
+		if review, err = models.CreateReview(models.CreateReviewOptions{
+			Type:     models.ReviewTypePending,
+			Issue:    pr.Issue,
+			Reviewer: ctx.User,
+			Content:  opts.Body,
+		}); err != nil {
+			ctx.Error(500, "CreateReview", err)
+			return
+		}
+	}
+
+	for _, c := range opts.Comments {
+		line := c.NewLineNum
+		if c.OldLineNum > 0 {
+			line = c.OldLineNum * -1
+		}
+		if len(strings.TrimSpace(c.Path)) == 0 || line == 0 || len(strings.TrimSpace(c.Body)) == 0 {
+			ctx.Error(422, "", "review comments require a path, a body and a line")
+			return
+		}
+
+		if _, err = models.CreateCodeComment(
+			ctx.User,
+			ctx.Repo.Repository,
+			pr.Issue,
+			c.Body,
+			c.Path,
+			line,
+			review.ID,
+		); err != nil {
+			ctx.Error(500, "CreateCodeComment", err)
+			return
+		}
+	}
+
+	if reviewType != models.ReviewTypePending {
+		if !submitPullReview(ctx, pr, review, reviewType, opts.Body) {
+			return
+		}
+	}
+
+	ctx.JSON(200, review.APIFormat())
+}
+
+// SubmitPullReview submits a pending review of a pull request
+func SubmitPullReview(ctx *context.APIContext, opts api.SubmitPullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id} repository repoSubmitPullReview
+	// ---
+	// summary: Submit a pending review to a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/SubmitPullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	review, pr := getReviewForPullRequest(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if review.Type != models.ReviewTypePending {
+		ctx.Error(422, "", "only a pending review can be submitted")
+		return
+	}
+
+	reviewType := models.ReviewTypeFromAPIState(opts.Event)
+	if reviewType == models.ReviewTypePending {
+		ctx.Error(422, "", "a review can not be submitted as pending")
+		return
+	}
+
+	comments, err := models.GetReviewComments(review)
+	if err != nil {
+		ctx.Error(500, "GetReviewComments", err)
+		return
+	}
+	if !checkReviewType(ctx, pr, reviewType, opts.Body, len(comments) > 0) {
+		return
+	}
+
+	if !submitPullReview(ctx, pr, review, reviewType, opts.Body) {
+		return
+	}
+	ctx.JSON(200, review.APIFormat())
+}
+
+// DismissPullReview dismisses a review of a pull request
+func DismissPullReview(ctx *context.APIContext, opts api.DismissPullReviewOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/pulls/{index}/reviews/{id}/dismissals repository repoDismissPullReview
+	// ---
+	// summary: Dismiss a review for a pull request
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: index
+	//   in: path
+	//   description: index of the pull request
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: id
+	//   in: path
+	//   description: id of the review
+	//   type: integer
+	//   format: int64
+	//   required: true
+	// - name: body
+	//   in: body
+	//   required: true
+	//   schema:
+	//     "$ref": "#/definitions/DismissPullReviewOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/PullReview"
+	//   "403":
+	//     "$ref": "#/responses/forbidden"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	review, pr := getReviewForPullRequest(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if review.Type != models.ReviewTypeApprove && review.Type != models.ReviewTypeReject {
+		ctx.Error(422, "", "only approvals and change requests can be dismissed")
+		return
+	}
+
+	if err := models.DismissReview(review); err != nil {
+		ctx.Error(500, "DismissReview", err)
+		return
+	}
+
+	if message := strings.TrimSpace(opts.Message); len(message) > 0 {
+		comment, err := models.CreateIssueComment(ctx.User, ctx.Repo.Repository, pr.Issue, message, nil)
+		if err != nil {
+			ctx.Error(500, "CreateIssueComment", err)
+			return
+		}
+		notification.NotifyCreateIssueComment(ctx.User, ctx.Repo.Repository, pr.Issue, comment)
+	}
+
+	log.Trace("Review dismissed: %d/%d/%d", ctx.Repo.Repository.ID, pr.Issue.ID, review.ID)
+	ctx.JSON(200, review.APIFormat())
+}
+
+// getPullRequestForReview returns the pull request of the :index parameter with its issue loaded
+func getPullRequestForReview(ctx *context.APIContext) *models.PullRequest {
+	pr, err := models.GetPullRequestByIndex(ctx.Repo.Repository.ID, ctx.ParamsInt64(":index"))
+	if err != nil {
+		if models.IsErrPullRequestNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetPullRequestByIndex", err)
+		}
+		return nil
+	}
+
+	if err = pr.LoadIssue(); err != nil {
+		ctx.Error(500, "LoadIssue", err)
+		return nil
+	}
+	pr.Issue.Repo = ctx.Repo.Repository
+	pr.Issue.PullRequest = pr
+	return pr
+}
+
+// getReviewForPullRequest returns the review of the :id parameter if it belongs to the pull request
+// of the :index parameter and is visible to the current user
+func getReviewForPullRequest(ctx *context.APIContext) (*models.Review, *models.PullRequest) {
+	pr := getPullRequestForReview(ctx)
+	if ctx.Written() {
+		return nil, nil
+	}
+
+	review, err := models.GetReviewByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrReviewNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetReviewByID", err)
+		}
+		return nil, nil
+	}
+
+	if review.IssueID != pr.IssueID {
+		ctx.Status(404)
+		return nil, nil
+	}
+
+	if review.Type == models.ReviewTypePending && (!ctx.IsSigned || review.ReviewerID != ctx.User.ID) {
+		ctx.Status(404)
+		return nil, nil
+	}
+
+	review.Issue = pr.Issue
+	if err = review.LoadAttributes(); err != nil {
+		ctx.Error(500, "LoadAttributes", err)
+		return nil, nil
+	}
+	return review, pr
+}
+
+// checkReviewType validates a requested review type and writes a 422 if it can not be used
+func checkReviewType(ctx *context.APIContext, pr *models.PullRequest, reviewType models.ReviewType, body string, hasComments bool) bool {
+	switch reviewType {
+	case models.ReviewTypeUnknown:
+		ctx.Error(422, "", "unknown review event")
+		return false
+	case models.ReviewTypeApprove, models.ReviewTypeReject:
+		// can not approve/reject your own PR
+		if pr.Issue.PosterID == ctx.User.ID {
+			ctx.Error(422, "", "approve or reject your own pull request is not allowed")
+			return false
+		}
+	}
+
+	if (reviewType == models.ReviewTypeComment || reviewType == models.ReviewTypeReject) &&
+		len(strings.TrimSpace(body)) == 0 && !hasComments {
+		ctx.Error(422, "", "review event "+string(reviewType.APIState())+" requires a body or comments")
+		return false
+	}
+	return true
+}
+
+// submitPullReview publishes the pending review and sends notifications
+func submitPullReview(ctx *context.APIContext, pr *models.PullRequest, review *models.Review, reviewType models.ReviewType, body string) bool {
+	comm, err := models.SubmitReview(ctx.User, review, reviewType, body)
+	if err != nil {
+		ctx.Error(500, "SubmitReview", err)
+		return false
+	}
+	notification.NotifyPullRequestReview(pr, review, comm)
+
+	log.Trace("Review submitted: %d/%d/%d", ctx.Repo.Repository.ID, pr.Issue.ID, review.ID)
+	return true
+}
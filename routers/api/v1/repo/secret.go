@@ -0,0 +1,106 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/v1/utils"
+	api "code.gitea.io/sdk/gitea"
+)
+
+// ListSecrets list the secrets of a repository
+func ListSecrets(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/secrets repository repoListSecrets
+	// ---
+	// summary: List the secrets of a repository, without their values
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/SecretList"
+	utils.ListSecrets(ctx, 0, ctx.Repo.Repository.ID)
+}
+
+// CreateOrUpdateSecret set the value of a secret of a repository
+func CreateOrUpdateSecret(ctx *context.APIContext, form api.CreateOrUpdateSecretOption) {
+	// swagger:operation PUT /repos/{owner}/{repo}/secrets/{secretname} repository repoCreateOrUpdateSecret
+	// ---
+	// summary: Create a secret of a repository, or replace its value
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: secretname
+	//   in: path
+	//   description: name of the secret, made of letters, digits and
+	//                underscores, case-insensitive
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateOrUpdateSecretOption"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/empty"
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	utils.CreateOrUpdateSecret(ctx, 0, ctx.Repo.Repository.ID, form)
+}
+
+// DeleteSecret delete a secret of a repository
+func DeleteSecret(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/secrets/{secretname} repository repoDeleteSecret
+	// ---
+	// summary: Delete a secret of a repository
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: secretname
+	//   in: path
+	//   description: name of the secret to delete
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	utils.DeleteSecret(ctx, 0, ctx.Repo.Repository.ID)
+}
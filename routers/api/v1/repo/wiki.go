@@ -0,0 +1,526 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"code.gitea.io/git"
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup/markdown"
+
+	api "code.gitea.io/sdk/gitea"
+)
+
+// ListWikiPages lists all pages of a repository wiki
+func ListWikiPages(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/wiki/pages repository repoListWikiPages
+	// ---
+	// summary: List the pages of a repository's wiki
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiPageList"
+	if !ctx.Repo.Repository.HasWiki() {
+		ctx.JSON(200, []*api.WikiPageMetaData{})
+		return
+	}
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	entries, err := commit.ListEntries()
+	if err != nil {
+		ctx.Error(500, "ListEntries", err)
+		return
+	}
+	pages := make([]*api.WikiPageMetaData, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Type != git.ObjectBlob {
+			continue
+		}
+		wikiName, err := models.WikiFilenameToName(entry.Name())
+		if err != nil {
+			if models.IsErrWikiInvalidFileName(err) {
+				continue
+			}
+			ctx.Error(500, "WikiFilenameToName", err)
+			return
+		}
+		lastCommit, err := wikiRepo.GetCommitByPath(entry.Name())
+		if err != nil {
+			ctx.Error(500, "GetCommitByPath", err)
+			return
+		}
+		pages = append(pages, toWikiPageMetaData(ctx.Repo.Repository, wikiName, lastCommit))
+	}
+
+	ctx.JSON(200, pages)
+}
+
+// GetWikiPage gets a page of a repository wiki
+func GetWikiPage(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/wiki/pages/{pageName} repository repoGetWikiPage
+	// ---
+	// summary: Get a page of a repository's wiki with its raw and rendered content
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiPage"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	wikiName := models.NormalizeWikiName(ctx.Params(":page"))
+	if !ctx.Repo.Repository.HasWiki() {
+		ctx.Status(404)
+		return
+	}
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	page := getWikiPage(ctx, wikiRepo, commit, wikiName)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(200, page)
+}
+
+// ListWikiPageRevisions lists the revisions of a page of a repository wiki
+func ListWikiPageRevisions(ctx *context.APIContext) {
+	// swagger:operation GET /repos/{owner}/{repo}/wiki/pages/{pageName}/revisions repository repoListWikiPageRevisions
+	// ---
+	// summary: List the revisions of a page of a repository's wiki
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// - name: page
+	//   in: query
+	//   description: page number of results to return (1-based)
+	//   type: integer
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiCommitList"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	wikiName := models.NormalizeWikiName(ctx.Params(":page"))
+	if !ctx.Repo.Repository.HasWiki() {
+		ctx.Status(404)
+		return
+	}
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	filename := models.WikiNameToFilename(wikiName)
+	if entry := findWikiEntry(ctx, commit, filename); entry == nil {
+		return
+	}
+
+	count, err := wikiRepo.FileCommitsCount("master", filename)
+	if err != nil {
+		ctx.Error(500, "FileCommitsCount", err)
+		return
+	}
+
+	page := ctx.QueryInt("page")
+	if page <= 1 {
+		page = 1
+	}
+	commits, err := wikiRepo.CommitsByFileAndRange("master", filename, page)
+	if err != nil {
+		ctx.Error(500, "CommitsByFileAndRange", err)
+		return
+	}
+
+	apiCommits := make([]*api.WikiCommit, 0, commits.Len())
+	for e := commits.Front(); e != nil; e = e.Next() {
+		apiCommits = append(apiCommits, toWikiCommit(e.Value.(*git.Commit)))
+	}
+
+	ctx.SetLinkHeader(int(count), git.CommitsRangeSize)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(200, apiCommits)
+}
+
+// CreateWikiPage creates a page in a repository wiki
+func CreateWikiPage(ctx *context.APIContext, form api.CreateWikiPageOptions) {
+	// swagger:operation POST /repos/{owner}/{repo}/wiki/pages repository repoCreateWikiPage
+	// ---
+	// summary: Create a page in a repository's wiki
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/CreateWikiPageOptions"
+	// responses:
+	//   "201":
+	//     "$ref": "#/responses/WikiPage"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	wikiName := models.NormalizeWikiName(form.Title)
+	if err := ctx.Repo.Repository.AddWikiPage(ctx.User, wikiName, form.Content, form.Message); err != nil {
+		if models.IsErrWikiReservedName(err) {
+			ctx.Error(422, "", err)
+		} else if models.IsErrWikiAlreadyExist(err) {
+			ctx.Error(409, "", err)
+		} else {
+			ctx.Error(500, "AddWikiPage", err)
+		}
+		return
+	}
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	page := getWikiPage(ctx, wikiRepo, commit, wikiName)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(201, page)
+}
+
+// EditWikiPage edits a page of a repository wiki
+func EditWikiPage(ctx *context.APIContext, form api.EditWikiPageOptions) {
+	// swagger:operation PATCH /repos/{owner}/{repo}/wiki/pages/{pageName} repository repoEditWikiPage
+	// ---
+	// summary: Edit a page of a repository's wiki, optionally renaming it
+	// consumes:
+	// - application/json
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// - name: body
+	//   in: body
+	//   schema:
+	//     "$ref": "#/definitions/EditWikiPageOptions"
+	// responses:
+	//   "200":
+	//     "$ref": "#/responses/WikiPage"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	//   "409":
+	//     "$ref": "#/responses/error"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
+	oldWikiName := models.NormalizeWikiName(ctx.Params(":page"))
+	newWikiName := oldWikiName
+	if len(form.Title) > 0 {
+		newWikiName = models.NormalizeWikiName(form.Title)
+	}
+	if !ctx.Repo.Repository.HasWiki() {
+		ctx.Status(404)
+		return
+	}
+
+	_, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	entry := findWikiEntry(ctx, commit, models.WikiNameToFilename(oldWikiName))
+	if entry == nil {
+		return
+	}
+
+	var content string
+	if form.Content != nil {
+		content = *form.Content
+	} else {
+		data := wikiContentsByEntry(ctx, entry)
+		if ctx.Written() {
+			return
+		}
+		content = string(data)
+	}
+
+	if err := ctx.Repo.Repository.EditWikiPage(ctx.User, oldWikiName, newWikiName, content, form.Message); err != nil {
+		if models.IsErrWikiReservedName(err) {
+			ctx.Error(422, "", err)
+		} else if models.IsErrWikiAlreadyExist(err) {
+			ctx.Error(409, "", err)
+		} else {
+			ctx.Error(500, "EditWikiPage", err)
+		}
+		return
+	}
+
+	wikiRepo, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	page := getWikiPage(ctx, wikiRepo, commit, newWikiName)
+	if ctx.Written() {
+		return
+	}
+
+	ctx.JSON(200, page)
+}
+
+// DeleteWikiPage deletes a page of a repository wiki
+func DeleteWikiPage(ctx *context.APIContext) {
+	// swagger:operation DELETE /repos/{owner}/{repo}/wiki/pages/{pageName} repository repoDeleteWikiPage
+	// ---
+	// summary: Delete a page of a repository's wiki
+	// produces:
+	// - application/json
+	// parameters:
+	// - name: owner
+	//   in: path
+	//   description: owner of the repo
+	//   type: string
+	//   required: true
+	// - name: repo
+	//   in: path
+	//   description: name of the repo
+	//   type: string
+	//   required: true
+	// - name: pageName
+	//   in: path
+	//   description: name of the page
+	//   type: string
+	//   required: true
+	// responses:
+	//   "204":
+	//     "$ref": "#/responses/empty"
+	//   "404":
+	//     "$ref": "#/responses/notFound"
+	wikiName := models.NormalizeWikiName(ctx.Params(":page"))
+	if !ctx.Repo.Repository.HasWiki() {
+		ctx.Status(404)
+		return
+	}
+
+	_, commit := findWikiRepoCommit(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if entry := findWikiEntry(ctx, commit, models.WikiNameToFilename(wikiName)); entry == nil {
+		return
+	}
+
+	if err := ctx.Repo.Repository.DeleteWikiPage(ctx.User, wikiName); err != nil {
+		ctx.Error(500, "DeleteWikiPage", err)
+		return
+	}
+
+	ctx.Status(204)
+}
+
+// findWikiRepoCommit opens the wiki repository and returns its head commit.
+// Writes to ctx if an error occurs.
+func findWikiRepoCommit(ctx *context.APIContext) (*git.Repository, *git.Commit) {
+	wikiRepo, err := git.OpenRepository(ctx.Repo.Repository.WikiPath())
+	if err != nil {
+		ctx.Error(500, "OpenRepository", err)
+		return nil, nil
+	}
+
+	commit, err := wikiRepo.GetBranchCommit("master")
+	if err != nil {
+		if git.IsErrNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "GetBranchCommit", err)
+		}
+		return nil, nil
+	}
+	return wikiRepo, commit
+}
+
+// findWikiEntry returns the tree entry of the given wiki file.
+// Writes a 404 to ctx if the file does not exist.
+func findWikiEntry(ctx *context.APIContext, commit *git.Commit, filename string) *git.TreeEntry {
+	entries, err := commit.ListEntries()
+	if err != nil {
+		ctx.Error(500, "ListEntries", err)
+		return nil
+	}
+	for _, entry := range entries {
+		if entry.Type == git.ObjectBlob && entry.Name() == filename {
+			return entry
+		}
+	}
+	ctx.Status(404)
+	return nil
+}
+
+// wikiContentsByEntry returns the contents of the wiki page referenced by the
+// given tree entry. Writes to ctx if an error occurs.
+func wikiContentsByEntry(ctx *context.APIContext, entry *git.TreeEntry) []byte {
+	reader, err := entry.Blob().Data()
+	if err != nil {
+		ctx.Error(500, "Blob.Data", err)
+		return nil
+	}
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		ctx.Error(500, "ReadAll", err)
+		return nil
+	}
+	return content
+}
+
+// getWikiPage returns the wiki page with the given name including its
+// raw and rendered content. Writes to ctx if an error occurs.
+func getWikiPage(ctx *context.APIContext, wikiRepo *git.Repository, commit *git.Commit, wikiName string) *api.WikiPage {
+	filename := models.WikiNameToFilename(wikiName)
+	entry := findWikiEntry(ctx, commit, filename)
+	if entry == nil {
+		return nil
+	}
+
+	data := wikiContentsByEntry(ctx, entry)
+	if ctx.Written() {
+		return nil
+	}
+
+	lastCommit, err := wikiRepo.GetCommitByPath(filename)
+	if err != nil {
+		ctx.Error(500, "GetCommitByPath", err)
+		return nil
+	}
+
+	commitsCount, err := wikiRepo.FileCommitsCount("master", filename)
+	if err != nil {
+		ctx.Error(500, "FileCommitsCount", err)
+		return nil
+	}
+
+	repo := ctx.Repo.Repository
+	meta := toWikiPageMetaData(repo, wikiName, lastCommit)
+	return &api.WikiPage{
+		Title:       meta.Title,
+		SubURL:      meta.SubURL,
+		HTMLURL:     meta.HTMLURL,
+		LastCommit:  meta.LastCommit,
+		CommitCount: commitsCount,
+		Content:     string(data),
+		ContentHTML: markdown.RenderWiki(data, repo.Link(), repo.ComposeMetas()),
+	}
+}
+
+func toWikiPageMetaData(repo *models.Repository, wikiName string, lastCommit *git.Commit) *api.WikiPageMetaData {
+	subURL := models.WikiNameToSubURL(wikiName)
+	return &api.WikiPageMetaData{
+		Title:      wikiName,
+		SubURL:     subURL,
+		HTMLURL:    repo.HTMLURL() + "/wiki/" + subURL,
+		LastCommit: toWikiCommit(lastCommit),
+	}
+}
+
+func toWikiCommit(c *git.Commit) *api.WikiCommit {
+	return &api.WikiCommit{
+		ID:      c.ID.String(),
+		Message: c.Message(),
+		Author: &api.PayloadUser{
+			Name:     c.Author.Name,
+			Email:    c.Author.Email,
+			UserName: userNameByEmail(c.Author.Email),
+		},
+		Committer: &api.PayloadUser{
+			Name:     c.Committer.Name,
+			Email:    c.Committer.Email,
+			UserName: userNameByEmail(c.Committer.Email),
+		},
+		Timestamp: c.Author.When,
+	}
+}
+
+func userNameByEmail(email string) string {
+	if u, err := models.GetUserByEmail(email); err == nil {
+		return u.Name
+	}
+	return ""
+}
@@ -28,6 +28,9 @@ type swaggerParameterBodies struct {
 	// in:body
 	EditHookOption api.EditHookOption
 
+	// in:body
+	CreateOrUpdateSecretOption api.CreateOrUpdateSecretOption
+
 	// in:body
 	CreateIssueOption api.CreateIssueOption
 	// in:body
@@ -69,6 +72,13 @@ type swaggerParameterBodies struct {
 	// in:body
 	EditPullRequestOption api.EditPullRequestOption
 
+	// in:body
+	CreatePullReviewOptions api.CreatePullReviewOptions
+	// in:body
+	SubmitPullReviewOptions api.SubmitPullReviewOptions
+	// in:body
+	DismissPullReviewOptions api.DismissPullReviewOptions
+
 	// in:body
 	CreateReleaseOption api.CreateReleaseOption
 	// in:body
@@ -79,9 +89,37 @@ type swaggerParameterBodies struct {
 	// in:body
 	CreateForkOption api.CreateForkOption
 
+	// in:body
+	CreateBranchProtectionOption api.CreateBranchProtectionOption
+	// in:body
+	EditBranchProtectionOption api.EditBranchProtectionOption
+
 	// in:body
 	CreateStatusOption api.CreateStatusOption
 
+	// in:body
+	UpdateFileOptions api.UpdateFileOptions
+	// in:body
+	DeleteFileOptions api.DeleteFileOptions
+
+	// in:body
+	CreateProjectBoardOption api.CreateProjectBoardOption
+	// in:body
+	EditProjectBoardOption api.EditProjectBoardOption
+	// in:body
+	CreateProjectColumnOption api.CreateProjectColumnOption
+	// in:body
+	EditProjectColumnOption api.EditProjectColumnOption
+	// in:body
+	CreateProjectCardOption api.CreateProjectCardOption
+	// in:body
+	MoveProjectCardOption api.MoveProjectCardOption
+
+	// in:body
+	CreateWikiPageOptions api.CreateWikiPageOptions
+	// in:body
+	EditWikiPageOptions api.EditWikiPageOptions
+
 	// in:body
 	CreateTeamOption api.CreateTeamOption
 	// in:body
@@ -98,6 +136,9 @@ type swaggerParameterBodies struct {
 	// in:body
 	MigrateRepoForm auth.MigrateRepoForm
 
+	// in:body
+	MergePullRequestOption auth.MergePullRequestForm
+
 	// in:body
 	EditAttachmentOptions api.EditAttachmentOptions
 }
@@ -6,10 +6,14 @@
 package user
 
 import (
+	"time"
+
 	api "code.gitea.io/sdk/gitea"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/util"
+	"code.gitea.io/gitea/routers/api/v1/convert"
 )
 
 // ListAccessTokens list all the access tokens
@@ -36,11 +40,11 @@ func ListAccessTokens(ctx *context.APIContext) {
 
 	apiTokens := make([]*api.AccessToken, len(tokens))
 	for i := range tokens {
-		apiTokens[i] = &api.AccessToken{
-			ID:   tokens[i].ID,
-			Name: tokens[i].Name,
-			Sha1: tokens[i].Sha1,
+		if err = tokens[i].LoadRepo(); err != nil && !models.IsErrRepoNotExist(err) {
+			ctx.Error(500, "LoadRepo", err)
+			return
 		}
+		apiTokens[i] = convert.ToAccessToken(tokens[i])
 	}
 	ctx.JSON(200, &apiTokens)
 }
@@ -69,22 +73,59 @@ func CreateAccessToken(ctx *context.APIContext, form api.CreateAccessTokenOption
 	//     properties:
 	//       name:
 	//         type: string
+	//       scopes:
+	//         description: scopes granted to the token, none grants full access
+	//         type: array
+	//         items:
+	//           type: string
+	//           enum: [all, "repo:read", "repo:write", admin, org, user, notification]
+	//       repository:
+	//         description: full name of the repository the token is restricted to
+	//         type: string
+	//       expires_at:
+	//         type: string
+	//         format: date-time
 	// responses:
 	//   "200":
 	//     "$ref": "#/responses/AccessToken"
+	//   "422":
+	//     "$ref": "#/responses/validationError"
 	t := &models.AccessToken{
 		UID:  ctx.User.ID,
 		Name: form.Name,
 	}
-	if err := models.NewAccessToken(t); err != nil {
+
+	var err error
+	t.Scope, err = models.ParseAccessTokenScope(form.Scopes)
+	if err != nil {
+		ctx.Error(422, "", err)
+		return
+	}
+
+	if len(form.Repository) > 0 {
+		if err = t.RestrictToRepo(form.Repository); err != nil {
+			if models.IsErrRepoNotExist(err) {
+				ctx.Error(422, "", err)
+			} else {
+				ctx.Error(500, "RestrictToRepo", err)
+			}
+			return
+		}
+	}
+
+	if form.ExpiresAt != nil {
+		if !form.ExpiresAt.After(time.Now()) {
+			ctx.Error(422, "", "expires_at must be in the future")
+			return
+		}
+		t.ExpiresUnix = util.TimeStamp(form.ExpiresAt.Unix())
+	}
+
+	if err = models.NewAccessToken(t); err != nil {
 		ctx.Error(500, "NewAccessToken", err)
 		return
 	}
-	ctx.JSON(201, &api.AccessToken{
-		Name: t.Name,
-		Sha1: t.Sha1,
-		ID:   t.ID,
-	})
+	ctx.JSON(201, convert.ToAccessToken(t))
 }
 
 // DeleteAccessToken delete access tokens
@@ -6,11 +6,13 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/routers/api/v1/convert"
 	"code.gitea.io/gitea/routers/utils"
 	api "code.gitea.io/sdk/gitea"
@@ -55,7 +57,15 @@ func CheckCreateHookOption(ctx *context.APIContext, form *api.CreateHookOption)
 		ctx.Error(422, "", "Invalid hook type")
 		return false
 	}
-	for _, name := range []string{"url", "content_type"} {
+	// the URL of the telegram and matrix hooks is built from their options
+	requiredOptions := []string{"url", "content_type"}
+	switch models.ToHookTaskType(form.Type) {
+	case models.TELEGRAM:
+		requiredOptions = []string{"content_type", "bot_token", "chat_id"}
+	case models.MATRIX:
+		requiredOptions = []string{"content_type", "homeserver_url", "room_id", "access_token"}
+	}
+	for _, name := range requiredOptions {
 		if _, ok := form.Config[name]; !ok {
 			ctx.Error(422, "", "Missing config option: "+name)
 			return false
@@ -138,6 +148,10 @@ func addHook(ctx *context.APIContext, form *api.CreateHookOption, orgID, repoID
 			return nil, false
 		}
 		w.Meta = string(meta)
+	} else if w.HookTaskType == models.TELEGRAM || w.HookTaskType == models.MATRIX {
+		if !setHookServiceMeta(ctx, w, form.Config) {
+			return nil, false
+		}
 	}
 
 	if err := w.UpdateEvent(); err != nil {
@@ -213,6 +227,10 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 				}
 				w.Meta = string(meta)
 			}
+		} else if w.HookTaskType == models.TELEGRAM || w.HookTaskType == models.MATRIX {
+			if !setHookServiceMeta(ctx, w, form.Config) {
+				return false
+			}
 		}
 	}
 
@@ -251,3 +269,95 @@ func editHook(ctx *context.APIContext, form *api.EditHookOption, w *models.Webho
 	}
 	return true
 }
+
+// ListHookDeliveries list a page of the deliveries of the webhook `w`, the
+// latest first. Writes to `ctx` accordingly
+func ListHookDeliveries(ctx *context.APIContext, w *models.Webhook) {
+	page := ctx.QueryInt("page")
+	if page <= 0 {
+		page = 1
+	}
+	tasks, err := models.HookTasks(w.ID, page)
+	if err != nil {
+		ctx.Error(500, "HookTasks", err)
+		return
+	}
+	count, err := models.CountHookTasks(w.ID)
+	if err != nil {
+		ctx.Error(500, "CountHookTasks", err)
+		return
+	}
+
+	deliveries := make([]*api.HookDelivery, len(tasks))
+	for i, t := range tasks {
+		deliveries[i] = convert.ToHookDelivery(t)
+	}
+	ctx.SetLinkHeader(int(count), setting.Webhook.PagingNum)
+	ctx.Header().Set("X-Total-Count", fmt.Sprintf("%d", count))
+	ctx.JSON(200, deliveries)
+}
+
+// RedeliverHook deliver again the payload of the delivery of the webhook `w`
+// given by the :delivery parameter. Writes to `ctx` accordingly
+func RedeliverHook(ctx *context.APIContext, w *models.Webhook) {
+	t, err := models.RedeliverHookTask(w, ctx.ParamsInt64(":delivery"))
+	if err != nil {
+		if models.IsErrHookTaskNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "RedeliverHookTask", err)
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, convert.ToHookDelivery(t))
+}
+
+// setHookServiceMeta sets the meta of the telegram or matrix webhook `w`, and
+// its URL built from them, from the options of `config` overriding the current
+// ones. If an error occurs, write to `ctx` accordingly. Return whether successful
+func setHookServiceMeta(ctx *context.APIContext, w *models.Webhook, config map[string]string) bool {
+	var (
+		meta []byte
+		err  error
+	)
+	switch w.HookTaskType {
+	case models.TELEGRAM:
+		telegram := w.GetTelegramHook()
+		if token, ok := config["bot_token"]; ok {
+			telegram.BotToken = token
+		}
+		if chatID, ok := config["chat_id"]; ok {
+			telegram.ChatID = chatID
+		}
+		w.URL = models.TelegramHookURL(telegram)
+		meta, err = json.Marshal(telegram)
+	case models.MATRIX:
+		matrix := w.GetMatrixHook()
+		if homeserverURL, ok := config["homeserver_url"]; ok {
+			matrix.HomeserverURL = homeserverURL
+		}
+		if room, ok := config["room_id"]; ok {
+			matrix.Room = room
+		}
+		if token, ok := config["access_token"]; ok {
+			matrix.AccessToken = token
+		}
+		if msgType, ok := config["message_type"]; ok {
+			if !models.IsValidMatrixMessageType(msgType) {
+				ctx.Error(422, "", "Invalid matrix message type")
+				return false
+			}
+			matrix.MessageType = msgType
+		} else if matrix.MessageType == "" {
+			matrix.MessageType = models.MatrixMessageTypeNotice
+		}
+		w.URL = models.MatrixHookURL(matrix)
+		meta, err = json.Marshal(matrix)
+	}
+	if err != nil {
+		ctx.Error(500, "JSON marshal failed", err)
+		return false
+	}
+	w.Meta = string(meta)
+	return true
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package utils
+
+import (
+	"net/http"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/routers/api/v1/convert"
+	api "code.gitea.io/sdk/gitea"
+)
+
+// ListSecrets list the secrets of the organization ownerID, or of the
+// repository repoID, without their values
+func ListSecrets(ctx *context.APIContext, ownerID, repoID int64) {
+	secrets, err := models.FindSecrets(ownerID, repoID)
+	if err != nil {
+		ctx.Error(500, "FindSecrets", err)
+		return
+	}
+	apiSecrets := make([]*api.Secret, len(secrets))
+	for i, secret := range secrets {
+		apiSecrets[i] = convert.ToSecret(secret)
+	}
+	ctx.JSON(200, apiSecrets)
+}
+
+// CreateOrUpdateSecret set the value of the secret of the organization
+// ownerID, or of the repository repoID, named after the :secretname parameter
+func CreateOrUpdateSecret(ctx *context.APIContext, ownerID, repoID int64, form api.CreateOrUpdateSecretOption) {
+	created, err := models.CreateOrUpdateSecret(ownerID, repoID, ctx.Params(":secretname"), form.Data)
+	if err != nil {
+		if models.IsErrSecretNameInvalid(err) {
+			ctx.Error(http.StatusUnprocessableEntity, "", err)
+		} else {
+			ctx.Error(500, "CreateOrUpdateSecret", err)
+		}
+		return
+	}
+	if created {
+		ctx.Status(201)
+	} else {
+		ctx.Status(204)
+	}
+}
+
+// DeleteSecret delete the secret of the organization ownerID, or of the
+// repository repoID, named after the :secretname parameter
+func DeleteSecret(ctx *context.APIContext, ownerID, repoID int64) {
+	if err := models.DeleteSecret(ownerID, repoID, ctx.Params(":secretname")); err != nil {
+		if models.IsErrSecretNotExist(err) {
+			ctx.Status(404)
+		} else {
+			ctx.Error(500, "DeleteSecret", err)
+		}
+		return
+	}
+	ctx.Status(204)
+}
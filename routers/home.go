@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/analyze"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/search"
@@ -258,95 +259,52 @@ func ExploreCode(ctx *context.Context) {
 	ctx.Data["PageIsExplore"] = true
 	ctx.Data["PageIsExploreCode"] = true
 
-	keyword := strings.TrimSpace(ctx.Query("q"))
-	page := ctx.QueryInt("page")
-	if page <= 0 {
-		page = 1
+	opts := &search.CodeSearchOptions{
+		Doer:        ctx.User,
+		Keyword:     strings.TrimSpace(ctx.Query("q")),
+		Language:    strings.TrimSpace(ctx.Query("l")),
+		PathPattern: strings.TrimSpace(ctx.Query("path")),
+		Page:        ctx.QueryInt("page"),
+		PageSize:    setting.UI.RepoSearchPagingNum,
 	}
-
-	var (
-		repoIDs []int64
-		err     error
-		isAdmin bool
-		userID  int64
-	)
-	if ctx.User != nil {
-		userID = ctx.User.ID
-		isAdmin = ctx.User.IsAdmin
-	}
-
-	// guest user or non-admin user
-	if ctx.User == nil || !isAdmin {
-		repoIDs, err = models.FindUserAccessibleRepoIDs(userID)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
+	if opts.Page <= 0 {
+		opts.Page = 1
 	}
+	ctx.Data["Keyword"] = opts.Keyword
+	ctx.Data["Language"] = opts.Language
+	ctx.Data["PathPattern"] = opts.PathPattern
+	ctx.Data["Languages"] = analyze.CodeLanguages()
 
 	var (
 		total         int
 		searchResults []*search.Result
+		repoMaps      map[int64]*models.Repository
+		err           error
 	)
-
-	// if non-admin login user, we need check UnitTypeCode at first
-	if ctx.User != nil && len(repoIDs) > 0 {
-		repoMaps, err := models.GetRepositoriesMapByIDs(repoIDs)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-
-		var rightRepoMap = make(map[int64]*models.Repository, len(repoMaps))
-		repoIDs = make([]int64, 0, len(repoMaps))
-		for id, repo := range repoMaps {
-			if repo.CheckUnitUser(userID, isAdmin, models.UnitTypeCode) {
-				rightRepoMap[id] = repo
-				repoIDs = append(repoIDs, id)
-			}
-		}
-
-		ctx.Data["RepoMaps"] = rightRepoMap
-
-		total, searchResults, err = search.PerformSearch(repoIDs, keyword, page, setting.UI.RepoSearchPagingNum)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
+	ownerName := strings.TrimSpace(ctx.Query("owner"))
+	ctx.Data["Owner"] = ownerName
+	if len(ownerName) > 0 {
+		owner, err := models.GetUserByName(ownerName)
+		if err != nil && !models.IsErrUserNotExist(err) {
+			ctx.ServerError("GetUserByName", err)
 			return
 		}
-		// if non-login user or isAdmin, no need to check UnitTypeCode
-	} else if (ctx.User == nil && len(repoIDs) > 0) || isAdmin {
-		total, searchResults, err = search.PerformSearch(repoIDs, keyword, page, setting.UI.RepoSearchPagingNum)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
-		}
-
-		var loadRepoIDs = make([]int64, 0, len(searchResults))
-		for _, result := range searchResults {
-			var find bool
-			for _, id := range loadRepoIDs {
-				if id == result.RepoID {
-					find = true
-					break
-				}
-			}
-			if !find {
-				loadRepoIDs = append(loadRepoIDs, result.RepoID)
-			}
-		}
-
-		repoMaps, err := models.GetRepositoriesMapByIDs(loadRepoIDs)
-		if err != nil {
-			ctx.ServerError("SearchResults", err)
-			return
+		// an unknown owner has no repository, so nothing can be found
+		if owner == nil {
+			opts.Keyword = ""
+		} else {
+			opts.OwnerID = owner.ID
 		}
+	}
 
-		ctx.Data["RepoMaps"] = repoMaps
+	total, searchResults, repoMaps, err = search.SearchCode(opts)
+	if err != nil {
+		ctx.ServerError("SearchResults", err)
+		return
 	}
 
-	ctx.Data["Keyword"] = keyword
-	pager := paginater.New(total, setting.UI.RepoSearchPagingNum, page, 5)
-	ctx.Data["Page"] = pager
+	ctx.Data["Page"] = paginater.New(total, setting.UI.RepoSearchPagingNum, opts.Page, 5)
+	ctx.Data["RepoMaps"] = repoMaps
 	ctx.Data["SearchResults"] = searchResults
 	ctx.Data["RequireHighlightJS"] = true
 	ctx.Data["PageIsViewCode"] = true
@@ -12,6 +12,7 @@ import (
 	"code.gitea.io/git"
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/models/migrations"
+	"code.gitea.io/gitea/modules/auth/oauth2"
 	"code.gitea.io/gitea/modules/cache"
 	"code.gitea.io/gitea/modules/cron"
 	"code.gitea.io/gitea/modules/highlight"
@@ -20,6 +21,7 @@ import (
 	"code.gitea.io/gitea/modules/markup"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/ssh"
+	"code.gitea.io/gitea/modules/storage"
 
 	macaron "gopkg.in/macaron.v1"
 )
@@ -81,10 +83,20 @@ func GlobalInit() {
 			log.Fatal(4, "ORM engine initialization failed: %v", err)
 		}
 
+		if err := storage.Init(); err != nil {
+			log.Fatal(4, "Failed to initialize object storage: %v", err)
+		}
+
 		if err := models.InitOAuth2(); err != nil {
 			log.Fatal(4, "Failed to initialize OAuth2 support: %v", err)
 		}
 
+		if setting.OAuth2.Enable {
+			if err := oauth2.InitSigningKey(); err != nil {
+				log.Fatal(4, "Failed to initialize OAuth2 provider: %v", err)
+			}
+		}
+
 		models.LoadRepoConfig()
 		models.NewRepoContext()
 
@@ -93,8 +105,10 @@ func GlobalInit() {
 		models.InitIssueIndexer()
 		models.InitRepoIndexer()
 		models.InitSyncMirrors()
+		models.InitSyncPushMirrors()
 		models.InitDeliverHooks()
 		models.InitTestPullRequests()
+		models.InitAutoMergePullRequests()
 		log.NewGitLogger(path.Join(setting.LogRootPath, "http.log"))
 	}
 	if models.EnableSQLite3 {
@@ -0,0 +1,37 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package private
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+
+	macaron "gopkg.in/macaron.v1"
+)
+
+// ReindexIndexer empties the given indexer and populates it again in the background
+func ReindexIndexer(ctx *macaron.Context) {
+	var err error
+	switch indexerType := ctx.Params(":type"); indexerType {
+	case "issues":
+		err = models.ReindexIssues()
+	case "code":
+		err = models.ReindexRepos()
+	default:
+		ctx.JSON(404, map[string]interface{}{
+			"err": fmt.Sprintf("unknown indexer %s", indexerType),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(500, map[string]interface{}{
+			"err": err.Error(),
+		})
+		return
+	}
+
+	ctx.Status(202)
+}
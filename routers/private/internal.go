@@ -89,5 +89,6 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Get("/branch/:id/*", GetProtectedBranchBy)
 		m.Get("/repository/:rid", GetRepository)
 		m.Get("/active-pull-request", GetActivePullRequest)
+		m.Post("/indexer/:type/reindex", ReindexIndexer)
 	}, CheckInternalToken)
 }
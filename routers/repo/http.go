@@ -188,6 +188,20 @@ func HTTP(ctx *context.Context) {
 					return
 				}
 
+				if token.IsExpired() {
+					ctx.HandleText(http.StatusUnauthorized, "invalid credentials")
+					return
+				}
+
+				requiredScope := models.AccessTokenScopeRepoWrite
+				if isPull {
+					requiredScope = models.AccessTokenScopeRepoRead
+				}
+				if !token.HasScope(requiredScope) || !token.CanAccessRepo(repo.ID) {
+					ctx.HandleText(http.StatusForbidden, "access token permission denied")
+					return
+				}
+
 				token.UpdatedUnix = util.TimeStampNow()
 				if err = models.UpdateAccessToken(token); err != nil {
 					ctx.ServerError("UpdateAccessToken", err)
@@ -23,10 +23,10 @@ import (
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
-	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/log"
 	"code.gitea.io/gitea/modules/markup/markdown"
 	"code.gitea.io/gitea/modules/notification"
+	"code.gitea.io/gitea/modules/search"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 )
@@ -121,28 +121,37 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 		keyword = ""
 	}
 
-	var issueIDs []int64
+	opts := &models.IssuesOptions{
+		RepoIDs:     []int64{repo.ID},
+		AssigneeID:  assigneeID,
+		PosterID:    posterID,
+		MentionedID: mentionedID,
+		MilestoneID: milestoneID,
+		IsClosed:    util.OptionalBoolOf(isShowClosed),
+		IsPull:      isPullOption,
+		Labels:      selectLabels,
+		SortType:    sortType,
+	}
 	if len(keyword) > 0 {
-		issueIDs, err = indexer.SearchIssuesByKeyword(repo.ID, keyword)
-		if len(issueIDs) == 0 {
+		query, err := search.ParseIssueQuery(keyword)
+		if search.IsErrInvalidIssueQuery(err) {
+			ctx.Flash.Error(ctx.Tr("repo.issues.invalid_search_query", err.(search.ErrInvalidIssueQuery).Qualifier), true)
 			forceEmpty = true
+		} else if err != nil {
+			ctx.ServerError("ParseIssueQuery", err)
+			return
+		} else if err = query.Apply(opts, ctx.User); err != nil {
+			ctx.ServerError("Apply", err)
+			return
 		}
+		isShowClosed = opts.IsClosed.IsTrue()
 	}
 
 	var issueStats *models.IssueStats
 	if forceEmpty {
 		issueStats = &models.IssueStats{}
 	} else {
-		issueStats, err = models.GetIssueStats(&models.IssueStatsOptions{
-			RepoID:      repo.ID,
-			Labels:      selectLabels,
-			MilestoneID: milestoneID,
-			AssigneeID:  assigneeID,
-			MentionedID: mentionedID,
-			PosterID:    posterID,
-			IsPull:      isPullOption,
-			IssueIDs:    issueIDs,
-		})
+		issueStats, err = models.GetIssueStatsByIssuesOptions(opts)
 		if err != nil {
 			ctx.ServerError("GetIssueStats", err)
 			return
@@ -166,20 +175,9 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 	if forceEmpty {
 		issues = []*models.Issue{}
 	} else {
-		issues, err = models.Issues(&models.IssuesOptions{
-			RepoIDs:     []int64{repo.ID},
-			AssigneeID:  assigneeID,
-			PosterID:    posterID,
-			MentionedID: mentionedID,
-			MilestoneID: milestoneID,
-			Page:        pager.Current(),
-			PageSize:    setting.UI.IssuePagingNum,
-			IsClosed:    util.OptionalBoolOf(isShowClosed),
-			IsPull:      isPullOption,
-			Labels:      selectLabels,
-			SortType:    sortType,
-			IssueIDs:    issueIDs,
-		})
+		opts.Page = pager.Current()
+		opts.PageSize = setting.UI.IssuePagingNum
+		issues, err = models.Issues(opts)
 		if err != nil {
 			ctx.ServerError("Issues", err)
 			return
@@ -205,9 +203,9 @@ func issues(ctx *context.Context, milestoneID int64, isPullOption util.OptionalB
 		return
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetLabelsAvailableToRepo(repo, "")
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetLabelsAvailableToRepo", err)
 		return
 	}
 	ctx.Data["Labels"] = labels
@@ -290,9 +288,9 @@ func RetrieveRepoMetas(ctx *context.Context, repo *models.Repository) []*models.
 		return nil
 	}
 
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetLabelsAvailableToRepo(repo, "")
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetLabelsAvailableToRepo", err)
 		return nil
 	}
 	ctx.Data["Labels"] = labels
@@ -626,9 +624,9 @@ func ViewIssue(ctx *context.Context) {
 	for i := range issue.Labels {
 		labelIDMark[issue.Labels[i].ID] = true
 	}
-	labels, err := models.GetLabelsByRepoID(repo.ID, "")
+	labels, err := models.GetLabelsAvailableToRepo(repo, "")
 	if err != nil {
-		ctx.ServerError("GetLabelsByRepoID", err)
+		ctx.ServerError("GetLabelsAvailableToRepo", err)
 		return
 	}
 	hasSelected := false
@@ -765,6 +763,27 @@ func ViewIssue(ctx *context.Context) {
 			if comment.MilestoneID > 0 && comment.Milestone == nil {
 				comment.Milestone = ghostMilestone
 			}
+		} else if comment.Type == models.CommentTypeProjectCard {
+			if err = comment.LoadProjectColumns(); err != nil {
+				ctx.ServerError("LoadProjectColumns", err)
+				return
+			}
+			if comment.ProjectBoard == nil {
+				comment.ProjectBoard = &models.ProjectBoard{
+					ID:    -1,
+					Title: ctx.Tr("repo.issues.deleted_project"),
+				}
+			}
+			ghostColumn := &models.ProjectColumn{
+				ID:    -1,
+				Title: ctx.Tr("repo.issues.deleted_project_column"),
+			}
+			if comment.OldProjectColumnID > 0 && comment.OldProjectColumn == nil {
+				comment.OldProjectColumn = ghostColumn
+			}
+			if comment.ProjectColumnID > 0 && comment.ProjectColumn == nil {
+				comment.ProjectColumn = ghostColumn
+			}
 		} else if comment.Type == models.CommentTypeAssignees {
 			if err = comment.LoadAssigneeUser(); err != nil {
 				ctx.ServerError("LoadAssigneeUser", err)
@@ -836,6 +855,29 @@ func ViewIssue(ctx *context.Context) {
 			ctx.Data["AllowMerge"] = false
 		}
 
+		ctx.Data["AllowAutoMerge"] = ctx.Repo.CanWrite(models.UnitTypeCode)
+		if err := pull.CheckUserAllowedToScheduleMerge(ctx.User); err != nil {
+			if !models.IsErrNotAllowedToMerge(err) {
+				ctx.ServerError("CheckUserAllowedToScheduleMerge", err)
+				return
+			}
+			ctx.Data["AllowAutoMerge"] = false
+		}
+		if !pull.HasMerged && !issue.IsClosed {
+			scheduled, err := models.GetScheduledMergeByPullID(pull.ID)
+			if err == nil {
+				ctx.Data["AutoMerge"] = scheduled
+			} else if !models.IsErrPullAutoMergeNotExist(err) {
+				ctx.ServerError("GetScheduledMergeByPullID", err)
+				return
+			}
+			if succeeded, err := pull.CommitStatusesSucceeded(); err != nil {
+				log.Error(4, "CommitStatusesSucceeded: %v", err)
+			} else {
+				ctx.Data["IsPendingChecks"] = !succeeded
+			}
+		}
+
 		// Check correct values and select default
 		if ms, ok := ctx.Data["MergeStyle"].(models.MergeStyle); !ok ||
 			!prConfig.IsMergeStyleAllowed(ms) {
@@ -859,6 +901,14 @@ func ViewIssue(ctx *context.Context) {
 			cnt := pull.ProtectedBranch.GetGrantedApprovalsCount(pull)
 			ctx.Data["IsBlockedByApprovals"] = pull.ProtectedBranch.RequiredApprovals > 0 && cnt < pull.ProtectedBranch.RequiredApprovals
 			ctx.Data["GrantedApprovals"] = cnt
+			if pull.ProtectedBranch.EnableStatusCheck && !pull.HasMerged && !issue.IsClosed {
+				missing, err := pull.GetMissingStatusContexts()
+				if err != nil {
+					log.Error(4, "GetMissingStatusContexts: %v", err)
+				}
+				ctx.Data["IsBlockedByChecks"] = len(missing) > 0
+				ctx.Data["MissingStatusContexts"] = missing
+			}
 		}
 		ctx.Data["IsPullBranchDeletable"] = canDelete && pull.HeadRepo != nil && git.IsBranchExist(pull.HeadRepo.RepoPath(), pull.HeadBranch)
 
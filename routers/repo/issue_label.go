@@ -66,6 +66,28 @@ func RetrieveLabels(ctx *context.Context) {
 		l.CalOpenIssues()
 	}
 	ctx.Data["Labels"] = labels
+
+	if owner := ctx.Repo.Repository.MustOwner(); owner.IsOrganization() {
+		orgLabels, err := models.GetLabelsByOrgID(owner.ID, ctx.Query("sort"))
+		if err != nil {
+			ctx.ServerError("GetLabelsByOrgID", err)
+			return
+		}
+		for _, l := range orgLabels {
+			l.CalOpenIssues()
+		}
+		ctx.Data["OrgLabels"] = orgLabels
+
+		if ctx.User != nil {
+			isOrgOwner, err := owner.IsOwnedBy(ctx.User.ID)
+			if err != nil {
+				ctx.ServerError("IsOwnedBy", err)
+				return
+			}
+			ctx.Data["IsOrganizationOwner"] = isOrgOwner
+		}
+	}
+
 	ctx.Data["NumLabels"] = len(labels)
 	ctx.Data["SortType"] = ctx.Query("sort")
 }
@@ -86,6 +108,8 @@ func NewLabel(ctx *context.Context, form auth.CreateLabelForm) {
 		Name:        form.Title,
 		Description: form.Description,
 		Color:       form.Color,
+		Exclusive:   form.Exclusive,
+		Priority:    form.Priority,
 	}
 	if err := models.NewLabel(l); err != nil {
 		ctx.ServerError("NewLabel", err)
@@ -96,7 +120,7 @@ func NewLabel(ctx *context.Context, form auth.CreateLabelForm) {
 
 // UpdateLabel update a label's name and color
 func UpdateLabel(ctx *context.Context, form auth.CreateLabelForm) {
-	l, err := models.GetLabelByID(form.ID)
+	l, err := models.GetLabelInRepoByID(ctx.Repo.Repository.ID, form.ID)
 	if err != nil {
 		switch {
 		case models.IsErrLabelNotExist(err):
@@ -110,6 +134,8 @@ func UpdateLabel(ctx *context.Context, form auth.CreateLabelForm) {
 	l.Name = form.Title
 	l.Description = form.Description
 	l.Color = form.Color
+	l.Exclusive = form.Exclusive
+	l.Priority = form.Priority
 	if err := models.UpdateLabel(l); err != nil {
 		ctx.ServerError("UpdateLabel", err)
 		return
@@ -147,12 +173,12 @@ func UpdateIssueLabel(ctx *context.Context) {
 			}
 		}
 	case "attach", "detach", "toggle":
-		label, err := models.GetLabelByID(ctx.QueryInt64("id"))
+		label, err := models.GetLabelAvailableToRepoByID(ctx.Repo.Repository, ctx.QueryInt64("id"))
 		if err != nil {
 			if models.IsErrLabelNotExist(err) {
-				ctx.Error(404, "GetLabelByID")
+				ctx.Error(404, "GetLabelAvailableToRepoByID")
 			} else {
-				ctx.ServerError("GetLabelByID", err)
+				ctx.ServerError("GetLabelAvailableToRepoByID", err)
 			}
 			return
 		}
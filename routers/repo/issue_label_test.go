@@ -52,6 +52,7 @@ func TestRetrieveLabels(t *testing.T) {
 		{1, "", []int64{1, 2}},
 		{1, "leastissues", []int64{2, 1}},
 		{2, "", []int64{}},
+		{3, "", []int64{}},
 	} {
 		ctx := test.MockContext(t, "user/repo/issues")
 		test.LoadUser(t, ctx, 2)
@@ -67,6 +68,17 @@ func TestRetrieveLabels(t *testing.T) {
 			}
 		}
 	}
+
+	// the labels of the owner organization are listed apart
+	ctx := test.MockContext(t, "user3/repo3/labels")
+	test.LoadUser(t, ctx, 2)
+	test.LoadRepo(t, ctx, 3)
+	RetrieveLabels(ctx)
+	assert.False(t, ctx.Written())
+	orgLabels, ok := ctx.Data["OrgLabels"].([]*models.Label)
+	assert.True(t, ok)
+	assert.Len(t, orgLabels, 2)
+	assert.Equal(t, true, ctx.Data["IsOrganizationOwner"])
 }
 
 func TestNewLabel(t *testing.T) {
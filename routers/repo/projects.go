@@ -0,0 +1,346 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"fmt"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/markup/markdown"
+)
+
+const (
+	tplProjectBoards    base.TplName = "repo/projects/list"
+	tplProjectBoardNew  base.TplName = "repo/projects/new"
+	tplProjectBoardView base.TplName = "repo/projects/view"
+)
+
+// ProjectBoards render the project boards page
+func ProjectBoards(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["CanWriteProjects"] = ctx.Repo.CanWrite(models.UnitTypeProjects)
+
+	boards, err := models.GetProjectBoardsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetProjectBoardsByRepoID", err)
+		return
+	}
+	for _, b := range boards {
+		b.RenderedContent = string(markdown.Render([]byte(b.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+	}
+	ctx.Data["Boards"] = boards
+
+	ctx.HTML(200, tplProjectBoards)
+}
+
+// getProjectBoard returns the project board of the current request,
+// rendering the error page if it does not exist
+func getProjectBoard(ctx *context.Context) *models.ProjectBoard {
+	board, err := models.GetProjectBoardByRepoID(ctx.Repo.Repository.ID, ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrProjectBoardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectBoardByRepoID", err)
+		}
+		return nil
+	}
+	return board
+}
+
+// getProjectCard returns the card of the project board with its issue,
+// rendering the error page if it does not exist or its issue cannot be
+// written by the current user
+func getProjectCard(ctx *context.Context, board *models.ProjectBoard, id int64) *models.ProjectCard {
+	card, err := models.GetProjectCardByBoardID(board.ID, id)
+	if err != nil {
+		if models.IsErrProjectCardNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectCardByBoardID", err)
+		}
+		return nil
+	}
+
+	if err = card.LoadIssue(); err != nil {
+		ctx.ServerError("LoadIssue", err)
+		return nil
+	} else if !ctx.Repo.CanReadIssuesOrPulls(card.Issue.IsPull) {
+		ctx.NotFound("", nil)
+		return nil
+	} else if !ctx.Repo.CanWriteIssuesOrPulls(card.Issue.IsPull) {
+		ctx.Error(403)
+		return nil
+	}
+	return card
+}
+
+// ViewProjectBoard render the columns and cards of a project board
+func ViewProjectBoard(ctx *context.Context) {
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	if err := board.LoadColumnsAndCards(); err != nil {
+		ctx.ServerError("LoadColumnsAndCards", err)
+		return
+	}
+	board.FilterCards(&ctx.Repo.Permission)
+	board.RenderedContent = string(markdown.Render([]byte(board.Description), ctx.Repo.RepoLink, ctx.Repo.Repository.ComposeMetas()))
+
+	ctx.Data["Title"] = board.Title
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["CanWriteProjects"] = ctx.Repo.CanWrite(models.UnitTypeProjects)
+	ctx.Data["Board"] = board
+
+	ctx.HTML(200, tplProjectBoardView)
+}
+
+// NewProjectBoard render creating project board page
+func NewProjectBoard(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+	ctx.HTML(200, tplProjectBoardNew)
+}
+
+// NewProjectBoardPost response for creating project board
+func NewProjectBoardPost(ctx *context.Context, form auth.CreateProjectBoardForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.new")
+	ctx.Data["PageIsProjects"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectBoardNew)
+		return
+	}
+
+	board := &models.ProjectBoard{
+		RepoID:      ctx.Repo.Repository.ID,
+		Title:       form.Title,
+		Description: form.Content,
+		CreatorID:   ctx.User.ID,
+	}
+	if err := models.NewProjectBoard(board,
+		ctx.Tr("repo.projects.column.todo"),
+		ctx.Tr("repo.projects.column.in_progress"),
+		ctx.Tr("repo.projects.column.done"),
+	); err != nil {
+		ctx.ServerError("NewProjectBoard", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.create_success", board.Title))
+	ctx.Redirect(fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, board.ID))
+}
+
+// EditProjectBoard render editing project board page
+func EditProjectBoard(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProjectBoard"] = true
+
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["title"] = board.Title
+	ctx.Data["content"] = board.Description
+	ctx.HTML(200, tplProjectBoardNew)
+}
+
+// EditProjectBoardPost response for editing project board
+func EditProjectBoardPost(ctx *context.Context, form auth.CreateProjectBoardForm) {
+	ctx.Data["Title"] = ctx.Tr("repo.projects.edit")
+	ctx.Data["PageIsProjects"] = true
+	ctx.Data["PageIsEditProjectBoard"] = true
+
+	if ctx.HasError() {
+		ctx.HTML(200, tplProjectBoardNew)
+		return
+	}
+
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	board.Title = form.Title
+	board.Description = form.Content
+	if err := models.UpdateProjectBoard(board); err != nil {
+		ctx.ServerError("UpdateProjectBoard", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("repo.projects.edit_success", board.Title))
+	ctx.Redirect(fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, board.ID))
+}
+
+// DeleteProjectBoard delete a project board
+func DeleteProjectBoard(ctx *context.Context) {
+	if err := models.DeleteProjectBoardByRepoID(ctx.Repo.Repository.ID, ctx.QueryInt64("id")); err != nil {
+		ctx.Flash.Error("DeleteProjectBoardByRepoID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": ctx.Repo.RepoLink + "/projects",
+	})
+}
+
+// NewProjectColumnPost response for adding a column to a project board
+func NewProjectColumnPost(ctx *context.Context, form auth.CreateProjectColumnForm) {
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	boardLink := fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, board.ID)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(boardLink)
+		return
+	}
+
+	if err := models.NewProjectColumn(&models.ProjectColumn{
+		BoardID: board.ID,
+		Title:   form.Title,
+	}); err != nil {
+		ctx.ServerError("NewProjectColumn", err)
+		return
+	}
+	ctx.Redirect(boardLink)
+}
+
+// DeleteProjectColumn delete a column of a project board with its cards
+func DeleteProjectColumn(ctx *context.Context) {
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectColumnByBoardID(board.ID, ctx.QueryInt64("id")); err != nil {
+		ctx.Flash.Error("DeleteProjectColumnByBoardID: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.column.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, board.ID),
+	})
+}
+
+// NewProjectCardPost response for adding an issue or a pull request to a project board
+func NewProjectCardPost(ctx *context.Context, form auth.AddProjectCardForm) {
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+	boardLink := fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, board.ID)
+
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.Data["ErrorMsg"].(string))
+		ctx.Redirect(boardLink)
+		return
+	}
+
+	column, err := models.GetProjectColumnByBoardID(board.ID, form.ColumnID)
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectColumnByBoardID", err)
+		}
+		return
+	}
+
+	issue, err := models.GetIssueByIndex(ctx.Repo.Repository.ID, form.IssueIndex)
+	if err != nil {
+		if models.IsErrIssueNotExist(err) {
+			ctx.Flash.Error(ctx.Tr("repo.projects.card.issue_not_exist", form.IssueIndex))
+			ctx.Redirect(boardLink)
+		} else {
+			ctx.ServerError("GetIssueByIndex", err)
+		}
+		return
+	}
+	if !ctx.Repo.CanWriteIssuesOrPulls(issue.IsPull) {
+		ctx.Error(403)
+		return
+	}
+
+	if _, err = models.NewProjectCard(ctx.User, issue, column); err != nil {
+		if models.IsErrProjectCardAlreadyExist(err) {
+			ctx.Flash.Error(ctx.Tr("repo.projects.card.already_exist", issue.Index))
+			ctx.Redirect(boardLink)
+		} else {
+			ctx.ServerError("NewProjectCard", err)
+		}
+		return
+	}
+	ctx.Redirect(boardLink)
+}
+
+// MoveProjectCard moves a card to a position of a column of its project board
+func MoveProjectCard(ctx *context.Context, form auth.MoveProjectCardForm) {
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if ctx.HasError() {
+		ctx.Error(422, ctx.Data["ErrorMsg"].(string))
+		return
+	}
+
+	card := getProjectCard(ctx, board, ctx.ParamsInt64(":card"))
+	if ctx.Written() {
+		return
+	}
+
+	column, err := models.GetProjectColumnByBoardID(board.ID, form.ColumnID)
+	if err != nil {
+		if models.IsErrProjectColumnNotExist(err) {
+			ctx.NotFound("", nil)
+		} else {
+			ctx.ServerError("GetProjectColumnByBoardID", err)
+		}
+		return
+	}
+
+	if err = models.MoveProjectCard(ctx.User, card, column, form.Position); err != nil {
+		ctx.ServerError("MoveProjectCard", err)
+		return
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"ok": true,
+	})
+}
+
+// DeleteProjectCard removes an issue or a pull request from a project board
+func DeleteProjectCard(ctx *context.Context) {
+	board := getProjectBoard(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	card := getProjectCard(ctx, board, ctx.QueryInt64("id"))
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.DeleteProjectCard(ctx.User, card); err != nil {
+		ctx.Flash.Error("DeleteProjectCard: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.projects.card.deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": fmt.Sprintf("%s/projects/%d", ctx.Repo.RepoLink, board.ID),
+	})
+}
@@ -530,7 +530,8 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 
 	pr := issue.PullRequest
 
-	if !pr.CanAutoMerge() || pr.HasMerged {
+	// A merge can be scheduled while the conflict check is still running.
+	if pr.HasMerged || !(pr.CanAutoMerge() || form.MergeWhenChecksSucceed && pr.IsChecking()) {
 		ctx.NotFound("MergePullRequest", nil)
 		return
 	}
@@ -568,6 +569,18 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 	pr.Issue = issue
 	pr.Issue.Repo = ctx.Repo.Repository
 
+	if form.MergeWhenChecksSucceed {
+		ready, err := pr.IsReadyToMerge()
+		if err != nil {
+			ctx.ServerError("IsReadyToMerge", err)
+			return
+		}
+		if !ready {
+			scheduleAutoMerge(ctx, pr, models.MergeStyle(form.Do), message)
+			return
+		}
+	}
+
 	noDeps, err := models.IssueNoDependenciesLeft(issue)
 	if err != nil {
 		return
@@ -579,6 +592,17 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 		return
 	}
 
+	missing, err := pr.GetMissingStatusContexts()
+	if err != nil {
+		ctx.ServerError("GetMissingStatusContexts", err)
+		return
+	}
+	if len(missing) > 0 {
+		ctx.Flash.Error(ctx.Tr("repo.pulls.blocked_by_status_checks_desc", strings.Join(missing, ", ")))
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+		return
+	}
+
 	if err = pr.Merge(ctx.User, ctx.Repo.GitRepo, models.MergeStyle(form.Do), message); err != nil {
 		if models.IsErrInvalidMergeStyle(err) {
 			ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
@@ -595,6 +619,48 @@ func MergePullRequest(ctx *context.Context, form auth.MergePullRequestForm) {
 	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
 }
 
+func scheduleAutoMerge(ctx *context.Context, pr *models.PullRequest, style models.MergeStyle, message string) {
+	if err := models.ScheduleAutoMerge(ctx.User, pr, style, message); err != nil {
+		if models.IsErrInvalidMergeStyle(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.invalid_merge_option"))
+		} else if models.IsErrNotAllowedToMerge(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_not_allowed"))
+		} else if models.IsErrPullAutoMergeAlreadyScheduled(err) {
+			ctx.Flash.Error(ctx.Tr("repo.pulls.auto_merge_already_scheduled"))
+		} else {
+			ctx.ServerError("ScheduleAutoMerge", err)
+			return
+		}
+		ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+		return
+	}
+
+	log.Trace("Pull request scheduled to auto merge: %d", pr.ID)
+	ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_scheduled"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(pr.Index))
+}
+
+// CancelAutoMergePullRequest cancels the scheduled merge of a pull request
+func CancelAutoMergePullRequest(ctx *context.Context) {
+	issue := checkPullInfo(ctx)
+	if ctx.Written() {
+		return
+	}
+
+	if err := models.CancelScheduledMerge(ctx.User, issue.PullRequest); err != nil {
+		if models.IsErrPullAutoMergeNotExist(err) {
+			ctx.NotFound("CancelScheduledMerge", nil)
+			return
+		}
+		ctx.ServerError("CancelScheduledMerge", err)
+		return
+	}
+
+	log.Trace("Pull request auto merge canceled: %d", issue.PullRequest.ID)
+	ctx.Flash.Success(ctx.Tr("repo.pulls.auto_merge_canceled"))
+	ctx.Redirect(ctx.Repo.RepoLink + "/pulls/" + com.ToStr(issue.Index))
+}
+
 // ParseCompareInfo parse compare info between two commit for preparing pull request
 func ParseCompareInfo(ctx *context.Context) (*models.User, *models.Repository, *git.Repository, *git.PullRequestInfo, string, string) {
 	baseRepo := ctx.Repo.Repository
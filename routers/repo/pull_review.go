@@ -163,28 +163,10 @@ func SubmitReview(ctx *context.Context, form auth.SubmitReviewForm) {
 			ctx.ServerError("CreateReview", err)
 			return
 		}
-	} else {
-		review.Content = form.Content
-		review.Type = reviewType
-		if err = models.UpdateReview(review); err != nil {
-			ctx.ServerError("UpdateReview", err)
-			return
-		}
 	}
-	comm, err := models.CreateComment(&models.CreateCommentOptions{
-		Type:     models.CommentTypeReview,
-		Doer:     ctx.User,
-		Content:  review.Content,
-		Issue:    issue,
-		Repo:     issue.Repo,
-		ReviewID: review.ID,
-	})
-	if err != nil || comm == nil {
-		ctx.ServerError("CreateComment", err)
-		return
-	}
-	if err = review.Publish(); err != nil {
-		ctx.ServerError("Publish", err)
+	comm, err := models.SubmitReview(ctx.User, review, reviewType, form.Content)
+	if err != nil {
+		ctx.ServerError("SubmitReview", err)
 		return
 	}
 
@@ -8,8 +8,10 @@ import (
 	"path"
 	"strings"
 
+	"code.gitea.io/git"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/indexer"
 	"code.gitea.io/gitea/modules/search"
 	"code.gitea.io/gitea/modules/setting"
 
@@ -18,6 +20,13 @@ import (
 
 const tplSearch base.TplName = "repo/search"
 
+// searchRef a ref of the repository which can be searched
+type searchRef struct {
+	Name      string
+	ShortName string
+	IsTag     bool
+}
+
 // Search render repository search page
 func Search(ctx *context.Context) {
 	if !setting.Indexer.RepoIndexerEnabled {
@@ -29,17 +38,53 @@ func Search(ctx *context.Context) {
 	if page <= 0 {
 		page = 1
 	}
-	total, searchResults, err := search.PerformSearch([]int64{ctx.Repo.Repository.ID},
-		keyword, page, setting.UI.RepoSearchPagingNum)
+
+	indexedRefs, err := ctx.Repo.Repository.GetIndexedRefs()
+	if err != nil {
+		ctx.ServerError("GetIndexedRefs", err)
+		return
+	}
+	// the default branch is searched unless one of the indexed refs is chosen
+	var ref *searchRef
+	refs := make([]*searchRef, len(indexedRefs))
+	for i, name := range indexedRefs {
+		refs[i] = &searchRef{
+			Name:      name,
+			ShortName: git.RefEndName(name),
+			IsTag:     strings.HasPrefix(name, git.TagPrefix),
+		}
+		if name == ctx.Query("ref") {
+			ref = refs[i]
+		}
+	}
+
+	opts := &indexer.RepoSearchOptions{
+		RepoIDs:  []int64{ctx.Repo.Repository.ID},
+		Keyword:  keyword,
+		Page:     page,
+		PageSize: setting.UI.RepoSearchPagingNum,
+	}
+	sourcePath := path.Join(ctx.Repo.Repository.Owner.Name, ctx.Repo.Repository.Name, "src", "branch", ctx.Repo.Repository.DefaultBranch)
+	if ref != nil {
+		opts.Ref = ref.Name
+		if ref.IsTag {
+			sourcePath = path.Join(ctx.Repo.Repository.Owner.Name, ctx.Repo.Repository.Name, "src", "tag", ref.ShortName)
+		} else {
+			sourcePath = path.Join(ctx.Repo.Repository.Owner.Name, ctx.Repo.Repository.Name, "src", "branch", ref.ShortName)
+		}
+		ctx.Data["Ref"] = ref.Name
+	}
+
+	total, searchResults, err := search.PerformSearch(opts)
 	if err != nil {
 		ctx.ServerError("SearchResults", err)
 		return
 	}
 	ctx.Data["Keyword"] = keyword
+	ctx.Data["Refs"] = refs
 	pager := paginater.New(total, setting.UI.RepoSearchPagingNum, page, 5)
 	ctx.Data["Page"] = pager
-	ctx.Data["SourcePath"] = setting.AppSubURL + "/" +
-		path.Join(ctx.Repo.Repository.Owner.Name, ctx.Repo.Repository.Name, "src", "branch", ctx.Repo.Repository.DefaultBranch)
+	ctx.Data["SourcePath"] = setting.AppSubURL + "/" + sourcePath
 	ctx.Data["SearchResults"] = searchResults
 	ctx.Data["RequireHighlightJS"] = true
 	ctx.Data["PageIsViewCode"] = true
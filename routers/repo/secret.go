@@ -0,0 +1,116 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package repo
+
+import (
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+)
+
+const (
+	tplSecrets    base.TplName = "repo/settings/secret/base"
+	tplOrgSecrets base.TplName = "org/settings/secrets"
+)
+
+// prepareSecrets loads the secrets of the repository or organization and
+// returns the template of its secrets page
+func prepareSecrets(ctx *context.Context, orCtx *orgRepoCtx) base.TplName {
+	ctx.Data["PageIsSettingsSecrets"] = true
+	ctx.Data["BaseLink"] = orCtx.Link
+
+	secrets, err := models.FindSecrets(orCtx.OrgID, orCtx.RepoID)
+	if err != nil {
+		ctx.ServerError("FindSecrets", err)
+		return ""
+	}
+	ctx.Data["Secrets"] = secrets
+
+	if orCtx.OrgID > 0 {
+		ctx.Data["Title"] = ctx.Tr("org.settings")
+		ctx.Data["Description"] = ctx.Tr("repo.settings.org_secrets_desc")
+		return tplOrgSecrets
+	}
+	ctx.Data["Title"] = ctx.Tr("repo.settings.secrets")
+	ctx.Data["Description"] = ctx.Tr("repo.settings.secrets_desc")
+	return tplSecrets
+}
+
+// Secrets render the secrets page of a repository or an organization
+func Secrets(ctx *context.Context) {
+	orCtx, err := getOrgRepoCtx(ctx)
+	if err != nil {
+		ctx.ServerError("getOrgRepoCtx", err)
+		return
+	}
+
+	tpl := prepareSecrets(ctx, orCtx)
+	if ctx.Written() {
+		return
+	}
+	ctx.HTML(200, tpl)
+}
+
+// SecretsPost response for adding or updating a secret of a repository or an
+// organization
+func SecretsPost(ctx *context.Context, form auth.AddSecretForm) {
+	orCtx, err := getOrgRepoCtx(ctx)
+	if err != nil {
+		ctx.ServerError("getOrgRepoCtx", err)
+		return
+	}
+
+	tpl := prepareSecrets(ctx, orCtx)
+	if ctx.Written() {
+		return
+	}
+	if ctx.HasError() {
+		ctx.Data["HasError"] = true
+		ctx.HTML(200, tpl)
+		return
+	}
+
+	created, err := models.CreateOrUpdateSecret(orCtx.OrgID, orCtx.RepoID, form.Name, form.Data)
+	if err != nil {
+		if models.IsErrSecretNameInvalid(err) {
+			ctx.Data["HasError"] = true
+			ctx.Data["Err_Name"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.settings.secret_name_invalid"), tpl, &auth.AddSecretForm{Name: form.Name})
+		} else {
+			ctx.ServerError("CreateOrUpdateSecret", err)
+		}
+		return
+	}
+
+	log.Trace("Secret %s set [owner: %d, repo: %d]", form.Name, orCtx.OrgID, orCtx.RepoID)
+	if created {
+		ctx.Flash.Success(ctx.Tr("repo.settings.add_secret_success", form.Name))
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_secret_success", form.Name))
+	}
+	ctx.Redirect(orCtx.Link + "/settings/secrets")
+}
+
+// DeleteSecret response for deleting a secret of a repository or an
+// organization
+func DeleteSecret(ctx *context.Context) {
+	orCtx, err := getOrgRepoCtx(ctx)
+	if err != nil {
+		ctx.ServerError("getOrgRepoCtx", err)
+		return
+	}
+
+	if err := models.DeleteSecret(orCtx.OrgID, orCtx.RepoID, ctx.Query("id")); err != nil {
+		ctx.Flash.Error("DeleteSecret: " + err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("repo.settings.secret_deletion_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": orCtx.Link + "/settings/secrets",
+	})
+}
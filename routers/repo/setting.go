@@ -36,16 +36,47 @@ const (
 func Settings(ctx *context.Context) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
 	ctx.Data["PageIsSettingsOptions"] = true
+	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
+
+	prepareSettingsMirrors(ctx)
+	if ctx.Written() {
+		return
+	}
+
 	ctx.HTML(200, tplSettingsOptions)
 }
 
+// prepareSettingsMirrors loads the push mirrors of the repository, and the
+// sync history of the repository if it is a mirror
+func prepareSettingsMirrors(ctx *context.Context) {
+	pushMirrors, err := models.GetPushMirrorsByRepoID(ctx.Repo.Repository.ID)
+	if err != nil {
+		ctx.ServerError("GetPushMirrorsByRepoID", err)
+		return
+	}
+	ctx.Data["PushMirrors"] = pushMirrors
+
+	if ctx.Repo.Repository.IsMirror {
+		ctx.Data["MirrorSyncRecords"], err = models.GetMirrorSyncRecords(ctx.Repo.Repository.ID)
+		if err != nil {
+			ctx.ServerError("GetMirrorSyncRecords", err)
+		}
+	}
+}
+
 // SettingsPost response for changes of a repository
 func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 	ctx.Data["Title"] = ctx.Tr("repo.settings")
 	ctx.Data["PageIsSettingsOptions"] = true
+	ctx.Data["IsRepoIndexerEnabled"] = setting.Indexer.RepoIndexerEnabled
 
 	repo := ctx.Repo.Repository
 
+	prepareSettingsMirrors(ctx)
+	if ctx.Written() {
+		return
+	}
+
 	switch ctx.Query("action") {
 	case "update":
 		if ctx.HasError() {
@@ -146,7 +177,79 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			return
 		}
 
-		go models.MirrorQueue.Add(repo.ID)
+		go models.AddMirrorSyncTask(repo.ID)
+		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "mirror-regenerate-trigger":
+		if !repo.IsMirror {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		if err := ctx.Repo.Mirror.RegenerateTriggerToken(); err != nil {
+			ctx.ServerError("RegenerateTriggerToken", err)
+			return
+		}
+		ctx.Flash.Success(ctx.Tr("repo.settings.mirror_trigger_url_regenerated"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-add":
+		interval, err := time.ParseDuration(form.PushMirrorInterval)
+		if err != nil || (interval != 0 && interval < setting.Mirror.MinInterval) {
+			ctx.Data["Err_PushMirrorInterval"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.mirror_interval_invalid"), tplSettingsOptions, &form)
+			return
+		}
+
+		address, err := form.ParsePushMirrorAddr()
+		if err != nil {
+			ctx.Data["Err_PushMirrorAddress"] = true
+			ctx.RenderWithErr(ctx.Tr("form.url_error"), tplSettingsOptions, &form)
+			return
+		}
+
+		m, err := models.AddPushMirror(repo, address, interval, form.PushMirrorSyncOnCommit)
+		if err != nil {
+			ctx.ServerError("AddPushMirror", err)
+			return
+		}
+		log.Trace("Push mirror added: %s/%s -> %s", ctx.Repo.Owner.Name, repo.Name, m.Address())
+
+		go models.PushMirrorQueue.Add(m.ID)
+		ctx.Flash.Success(ctx.Tr("repo.settings.push_mirror_add_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-remove":
+		m, err := models.GetPushMirrorByID(repo.ID, ctx.QueryInt64("push_mirror_id"))
+		if err != nil {
+			if err == models.ErrPushMirrorNotExist {
+				ctx.NotFound("GetPushMirrorByID", nil)
+			} else {
+				ctx.ServerError("GetPushMirrorByID", err)
+			}
+			return
+		}
+		if err = models.DeletePushMirror(m); err != nil {
+			ctx.ServerError("DeletePushMirror", err)
+			return
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.push_mirror_remove_success"))
+		ctx.Redirect(repo.Link() + "/settings")
+
+	case "push-mirror-sync":
+		m, err := models.GetPushMirrorByID(repo.ID, ctx.QueryInt64("push_mirror_id"))
+		if err != nil {
+			if err == models.ErrPushMirrorNotExist {
+				ctx.NotFound("GetPushMirrorByID", nil)
+			} else {
+				ctx.ServerError("GetPushMirrorByID", err)
+			}
+			return
+		}
+
+		go models.PushMirrorQueue.Add(m.ID)
 		ctx.Flash.Info(ctx.Tr("repo.settings.mirror_sync_in_progress"))
 		ctx.Redirect(repo.Link() + "/settings")
 
@@ -233,6 +336,14 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 			})
 		}
 
+		if form.EnableProjects {
+			units = append(units, models.RepoUnit{
+				RepoID: repo.ID,
+				Type:   models.UnitTypeProjects,
+				Config: new(models.UnitConfig),
+			})
+		}
+
 		if err := models.UpdateRepositoryUnits(repo, units); err != nil {
 			ctx.ServerError("UpdateRepositoryUnits", err)
 			return
@@ -242,6 +353,39 @@ func SettingsPost(ctx *context.Context, form auth.RepoSettingForm) {
 		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
 		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
 
+	case "indexer":
+		if !setting.Indexer.RepoIndexerEnabled {
+			ctx.NotFound("", nil)
+			return
+		}
+
+		branches, err := models.ValidateRefPatterns(form.IndexerBranches)
+		if err != nil {
+			ctx.Data["Err_IndexerBranches"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.settings.indexer_invalid_pattern", err.(models.ErrInvalidRefPattern).Pattern), tplSettingsOptions, &form)
+			return
+		}
+		tags, err := models.ValidateRefPatterns(form.IndexerTags)
+		if err != nil {
+			ctx.Data["Err_IndexerTags"] = true
+			ctx.RenderWithErr(ctx.Tr("repo.settings.indexer_invalid_pattern", err.(models.ErrInvalidRefPattern).Pattern), tplSettingsOptions, &form)
+			return
+		}
+
+		if repo.IndexerBranches != branches || repo.IndexerTags != tags {
+			repo.IndexerBranches = branches
+			repo.IndexerTags = tags
+			if err := models.UpdateRepository(repo, false); err != nil {
+				ctx.ServerError("UpdateRepository", err)
+				return
+			}
+			models.UpdateRepoIndexer(repo)
+			log.Trace("Repository code search settings updated: %s/%s", ctx.Repo.Owner.Name, repo.Name)
+		}
+
+		ctx.Flash.Success(ctx.Tr("repo.settings.update_settings_success"))
+		ctx.Redirect(ctx.Repo.RepoLink + "/settings")
+
 	case "admin":
 		if !ctx.User.IsAdmin {
 			ctx.Error(403)
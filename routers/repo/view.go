@@ -190,7 +190,7 @@ func renderFile(ctx *context.Context, entry *git.TreeEntry, treeLink, rawLink st
 				oid := strings.TrimPrefix(splitLines[1], models.LFSMetaFileOidPrefix)
 				size, err := strconv.ParseInt(strings.TrimPrefix(splitLines[2], "size "), 10, 64)
 				if len(oid) == 64 && err == nil {
-					contentStore := &lfs.ContentStore{BasePath: setting.LFS.ContentPath}
+					contentStore := lfs.NewContentStore()
 					meta := &models.LFSMetaObject{Oid: oid}
 					if contentStore.Exists(meta) {
 						ctx.Data["IsTextFile"] = false
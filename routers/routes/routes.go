@@ -7,8 +7,8 @@ package routes
 import (
 	"encoding/gob"
 	"net/http"
-	"os"
 	"path"
+	"strings"
 	"time"
 
 	"code.gitea.io/gitea/models"
@@ -20,6 +20,7 @@ import (
 	"code.gitea.io/gitea/modules/options"
 	"code.gitea.io/gitea/modules/public"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/storage"
 	"code.gitea.io/gitea/modules/templates"
 	"code.gitea.io/gitea/modules/validation"
 	"code.gitea.io/gitea/routers"
@@ -45,6 +46,41 @@ import (
 	macaron "gopkg.in/macaron.v1"
 )
 
+// avatarStorageHandler serves the user avatars from a storage which is not on the local file system
+func avatarStorageHandler() macaron.Handler {
+	const prefix = "/avatars/"
+	return func(ctx *macaron.Context) {
+		if ctx.Req.Method != "GET" && ctx.Req.Method != "HEAD" {
+			return
+		}
+		if !strings.HasPrefix(ctx.Req.URL.Path, prefix) {
+			return
+		}
+
+		p := strings.TrimPrefix(ctx.Req.URL.Path, prefix)
+		fi, err := storage.Avatars.Stat(p)
+		if err != nil {
+			if storage.IsErrObjectNotExist(err) {
+				ctx.Error(404)
+			} else {
+				log.Error(4, "Stat avatar %s: %v", p, err)
+				ctx.Error(500)
+			}
+			return
+		}
+		fr, err := storage.Avatars.Open(p)
+		if err != nil {
+			log.Error(4, "Open avatar %s: %v", p, err)
+			ctx.Error(500)
+			return
+		}
+		defer fr.Close()
+
+		ctx.Resp.Header().Set("Cache-Control", "public,max-age=21600")
+		http.ServeContent(ctx.Resp, ctx.Req.Request, path.Base(p), fi.ModTime(), fr)
+	}
+}
+
 // NewMacaron initializes Macaron instance.
 func NewMacaron() *macaron.Macaron {
 	gob.Register(&u2f.Challenge{})
@@ -72,14 +108,18 @@ func NewMacaron() *macaron.Macaron {
 			ExpiresAfter: time.Hour * 6,
 		},
 	))
-	m.Use(public.StaticHandler(
-		setting.AvatarUploadPath,
-		&public.Options{
-			Prefix:       "avatars",
-			SkipLogging:  setting.DisableRouterLog,
-			ExpiresAfter: time.Hour * 6,
-		},
-	))
+	if setting.AvatarStorage.Type == setting.LocalStorageType {
+		m.Use(public.StaticHandler(
+			setting.AvatarUploadPath,
+			&public.Options{
+				Prefix:       "avatars",
+				SkipLogging:  setting.DisableRouterLog,
+				ExpiresAfter: time.Hour * 6,
+			},
+		))
+	} else {
+		m.Use(avatarStorageHandler())
+	}
 
 	m.Use(templates.HTMLRenderer())
 	models.InitMailRender(templates.Mailer())
@@ -164,6 +204,13 @@ func RegisterRoutes(m *macaron.Macaron) {
 		}
 	}
 
+	oauth2Enabled := func(ctx *context.Context) {
+		if !setting.OAuth2.Enable {
+			ctx.NotFound("", nil)
+			return
+		}
+	}
+
 	m.Use(user.GetNotificationCount)
 
 	// FIXME: not all routes need go through same middlewares.
@@ -268,6 +315,14 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Combo("/applications").Get(userSetting.Applications).
 			Post(bindIgnErr(auth.NewAccessTokenForm{}), userSetting.ApplicationsPost)
 		m.Post("/applications/delete", userSetting.DeleteApplication)
+		m.Group("/applications/oauth2", func() {
+			m.Get("/:id", userSetting.OAuth2ApplicationShow)
+			m.Post("/:id", bindIgnErr(auth.EditOAuth2ApplicationForm{}), userSetting.OAuthApplicationsEdit)
+			m.Post("/:id/regenerate_secret", userSetting.OAuthApplicationsRegenerateSecret)
+			m.Post("", bindIgnErr(auth.EditOAuth2ApplicationForm{}), userSetting.OAuthApplicationsPost)
+			m.Post("/delete", userSetting.DeleteOAuth2Application)
+			m.Post("/grants/revoke", userSetting.RevokeOAuth2Grant)
+		}, oauth2Enabled)
 		m.Combo("/keys").Get(userSetting.Keys).
 			Post(bindIgnErr(auth.AddKeyForm{}), userSetting.KeysPost)
 		m.Post("/keys/delete", userSetting.DeleteKey)
@@ -296,6 +351,18 @@ func RegisterRoutes(m *macaron.Macaron) {
 		ctx.Data["AllThemes"] = setting.UI.Themes
 	})
 
+	m.Group("/login/oauth", func() {
+		m.Get("/authorize", bindIgnErr(auth.AuthorizationForm{}), user.AuthorizeOAuth)
+		m.Post("/grant", bindIgnErr(auth.GrantApplicationForm{}), user.GrantApplicationOAuth)
+	}, reqSignIn, oauth2Enabled)
+	m.Group("/login/oauth", func() {
+		// the client authenticates itself, there is no session to protect against csrf
+		m.Post("/access_token", bindIgnErr(auth.AccessTokenForm{}), user.AccessTokenOAuth)
+		m.Combo("/userinfo").Get(user.InfoOAuth).Post(user.InfoOAuth)
+		m.Get("/keys", user.OIDCKeys)
+	}, ignSignInAndCsrf, oauth2Enabled)
+	m.Get("/.well-known/openid-configuration", oauth2Enabled, user.OIDCWellKnown)
+
 	m.Group("/user", func() {
 		// r.Get("/feeds", binding.Bind(auth.FeedsForm{}), user.Feeds)
 		m.Any("/activate", user.Activate)
@@ -366,7 +433,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 				return
 			}
 
-			fr, err := os.Open(attach.LocalPath())
+			fr, err := storage.Attachments.Open(attach.RelativePath())
 			if err != nil {
 				ctx.ServerError("Open", err)
 				return
@@ -405,6 +472,8 @@ func RegisterRoutes(m *macaron.Macaron) {
 	reqRepoPullsReader := context.RequireRepoReader(models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsWriter := context.RequireRepoWriterOr(models.UnitTypeIssues, models.UnitTypePullRequests)
 	reqRepoIssuesOrPullsReader := context.RequireRepoReaderOr(models.UnitTypeIssues, models.UnitTypePullRequests)
+	reqRepoProjectsWriter := context.RequireRepoWriter(models.UnitTypeProjects)
+	reqRepoProjectsReader := context.RequireRepoReader(models.UnitTypeProjects)
 
 	// ***** START: Organization *****
 	m.Group("/org", func() {
@@ -451,14 +520,45 @@ func RegisterRoutes(m *macaron.Macaron) {
 					m.Post("/slack/new", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksNewPost)
 					m.Post("/discord/new", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksNewPost)
 					m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
+					m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+					m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
+					m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 					m.Get("/:id", repo.WebHooksEdit)
+					m.Post("/:id/deliveries/:task/redeliver", repo.RedeliverWebhook)
 					m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 					m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 					m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
 					m.Post("/discord/:id", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksEditPost)
 					m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
+					m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
+					m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
+					m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
 				})
 
+				m.Group("/labels", func() {
+					m.Get("", org.Labels)
+					m.Post("/new", bindIgnErr(auth.CreateLabelForm{}), org.NewLabel)
+					m.Post("/edit", bindIgnErr(auth.CreateLabelForm{}), org.UpdateLabel)
+					m.Post("/delete", org.DeleteLabel)
+					m.Post("/initialize", bindIgnErr(auth.InitializeLabelsForm{}), org.InitializeLabels)
+				})
+
+				m.Group("/secrets", func() {
+					m.Combo("").Get(repo.Secrets).
+						Post(bindIgnErr(auth.AddSecretForm{}), repo.SecretsPost)
+					m.Post("/delete", repo.DeleteSecret)
+				})
+
+				m.Group("/applications", func() {
+					m.Get("", org.Applications)
+					m.Post("/oauth2", bindIgnErr(auth.EditOAuth2ApplicationForm{}), org.OAuthApplicationsPost)
+					m.Group("/oauth2/:id", func() {
+						m.Combo("").Get(org.OAuth2ApplicationShow).Post(bindIgnErr(auth.EditOAuth2ApplicationForm{}), org.OAuth2ApplicationEdit)
+						m.Post("/regenerate_secret", org.OAuthApplicationsRegenerateSecret)
+					})
+					m.Post("/oauth2/delete", org.DeleteOAuth2Application)
+				}, oauth2Enabled)
+
 				m.Route("/delete", "GET,POST", org.SettingsDelete)
 			})
 		}, context.OrgAssignment(true, true))
@@ -504,13 +604,20 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/slack/new", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksNewPost)
 				m.Post("/discord/new", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksNewPost)
 				m.Post("/dingtalk/new", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksNewPost)
+				m.Post("/msteams/new", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksNewPost)
+				m.Post("/telegram/new", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksNewPost)
+				m.Post("/matrix/new", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksNewPost)
 				m.Get("/:id", repo.WebHooksEdit)
 				m.Post("/:id/test", repo.TestWebhook)
+				m.Post("/:id/deliveries/:task/redeliver", repo.RedeliverWebhook)
 				m.Post("/gitea/:id", bindIgnErr(auth.NewWebhookForm{}), repo.WebHooksEditPost)
 				m.Post("/gogs/:id", bindIgnErr(auth.NewGogshookForm{}), repo.GogsHooksEditPost)
 				m.Post("/slack/:id", bindIgnErr(auth.NewSlackHookForm{}), repo.SlackHooksEditPost)
 				m.Post("/discord/:id", bindIgnErr(auth.NewDiscordHookForm{}), repo.DiscordHooksEditPost)
 				m.Post("/dingtalk/:id", bindIgnErr(auth.NewDingtalkHookForm{}), repo.DingtalkHooksEditPost)
+				m.Post("/msteams/:id", bindIgnErr(auth.NewMSTeamsHookForm{}), repo.MSTeamsHooksEditPost)
+				m.Post("/telegram/:id", bindIgnErr(auth.NewTelegramHookForm{}), repo.TelegramHooksEditPost)
+				m.Post("/matrix/:id", bindIgnErr(auth.NewMatrixHookForm{}), repo.MatrixHooksEditPost)
 
 				m.Group("/git", func() {
 					m.Get("", repo.GitHooks)
@@ -525,6 +632,12 @@ func RegisterRoutes(m *macaron.Macaron) {
 				m.Post("/delete", repo.DeleteDeployKey)
 			})
 
+			m.Group("/secrets", func() {
+				m.Combo("").Get(repo.Secrets).
+					Post(bindIgnErr(auth.AddSecretForm{}), repo.SecretsPost)
+				m.Post("/delete", repo.DeleteSecret)
+			})
+
 		}, func(ctx *context.Context) {
 			ctx.Data["PageIsSettings"] = true
 		})
@@ -586,6 +699,18 @@ func RegisterRoutes(m *macaron.Macaron) {
 		m.Group("/milestone", func() {
 			m.Get("/:id", repo.MilestoneIssuesAndPulls)
 		}, reqRepoIssuesOrPullsWriter, context.RepoRef())
+		m.Group("/projects", func() {
+			m.Combo("/new").Get(repo.NewProjectBoard).
+				Post(bindIgnErr(auth.CreateProjectBoardForm{}), repo.NewProjectBoardPost)
+			m.Get("/:id/edit", repo.EditProjectBoard)
+			m.Post("/:id/edit", bindIgnErr(auth.CreateProjectBoardForm{}), repo.EditProjectBoardPost)
+			m.Post("/delete", repo.DeleteProjectBoard)
+			m.Post("/:id/columns/new", bindIgnErr(auth.CreateProjectColumnForm{}), repo.NewProjectColumnPost)
+			m.Post("/:id/columns/delete", repo.DeleteProjectColumn)
+			m.Post("/:id/cards/new", bindIgnErr(auth.AddProjectCardForm{}), repo.NewProjectCardPost)
+			m.Post("/:id/cards/:card/move", bindIgnErr(auth.MoveProjectCardForm{}), repo.MoveProjectCard)
+			m.Post("/:id/cards/delete", repo.DeleteProjectCard)
+		}, reqRepoProjectsWriter, context.RepoRef())
 		m.Combo("/compare/*", reqRepoCodeReader, reqRepoPullsReader, repo.MustAllowPulls, repo.SetEditorconfigIfExists).
 			Get(repo.SetDiffViewStyle, repo.CompareAndPullRequest).
 			Post(bindIgnErr(auth.CreateIssueForm{}), repo.CompareAndPullRequestPost)
@@ -662,6 +787,11 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get("/milestones", reqRepoIssuesOrPullsReader, repo.Milestones)
 		}, context.RepoRef())
 
+		m.Group("/projects", func() {
+			m.Get("", repo.ProjectBoards)
+			m.Get("/:id", repo.ViewProjectBoard)
+		}, reqRepoProjectsReader, context.RepoRef())
+
 		m.Group("/wiki", func() {
 			m.Get("/?:page", repo.Wiki)
 			m.Get("/_pages", repo.WikiPages)
@@ -695,6 +825,7 @@ func RegisterRoutes(m *macaron.Macaron) {
 			m.Get(".patch", repo.DownloadPullPatch)
 			m.Get("/commits", context.RepoRef(), repo.ViewPullCommits)
 			m.Post("/merge", reqRepoPullsWriter, bindIgnErr(auth.MergePullRequestForm{}), repo.MergePullRequest)
+			m.Post("/cancel_auto_merge", reqRepoPullsWriter, repo.CancelAutoMergePullRequest)
 			m.Post("/cleanup", context.RepoRef(), repo.CleanUpPullRequest)
 			m.Group("/files", func() {
 				m.Get("", context.RepoRef(), repo.SetEditorconfigIfExists, repo.SetDiffViewStyle, repo.SetWhitespaceBehavior, repo.ViewPullFiles)
@@ -8,10 +8,12 @@ import (
 	"bytes"
 	"fmt"
 	"sort"
+	"strings"
 
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/search"
 	"code.gitea.io/gitea/modules/setting"
 	"code.gitea.io/gitea/modules/util"
 
@@ -248,6 +250,29 @@ func Issues(ctx *context.Context) {
 		opts.MentionedID = ctxUser.ID
 	}
 
+	keyword := strings.Trim(ctx.Query("q"), " ")
+	if bytes.Contains([]byte(keyword), []byte{0x00}) {
+		keyword = ""
+	}
+	if len(keyword) > 0 {
+		// the query can only narrow the repositories of the options
+		if len(opts.RepoIDs) == 0 {
+			opts.RepoIDs = userRepoIDs
+		}
+		query, err := search.ParseIssueQuery(keyword)
+		if search.IsErrInvalidIssueQuery(err) {
+			ctx.Flash.Error(ctx.Tr("repo.issues.invalid_search_query", err.(search.ErrInvalidIssueQuery).Qualifier), true)
+			opts.RepoIDs = []int64{-1}
+		} else if err != nil {
+			ctx.ServerError("ParseIssueQuery", err)
+			return
+		} else if err = query.Apply(opts, ctx.User); err != nil {
+			ctx.ServerError("Apply", err)
+			return
+		}
+		isShowClosed = opts.IsClosed.IsTrue()
+	}
+
 	counts, err := models.CountIssuesByRepo(opts)
 	if err != nil {
 		ctx.ServerError("CountIssuesByRepo", err)
@@ -321,6 +346,16 @@ func Issues(ctx *context.Context) {
 		ctx.ServerError("GetUserIssueStats", err)
 		return
 	}
+	if len(keyword) > 0 {
+		// the numbers of open and closed issues matching the query
+		queryStats, err := models.GetIssueStatsByIssuesOptions(opts)
+		if err != nil {
+			ctx.ServerError("GetIssueStatsByIssuesOptions", err)
+			return
+		}
+		issueStats.OpenCount = queryStats.OpenCount
+		issueStats.ClosedCount = queryStats.ClosedCount
+	}
 
 	var total int
 	if !isShowClosed {
@@ -338,6 +373,7 @@ func Issues(ctx *context.Context) {
 	ctx.Data["SortType"] = sortType
 	ctx.Data["RepoID"] = repoID
 	ctx.Data["IsShowClosed"] = isShowClosed
+	ctx.Data["Keyword"] = keyword
 
 	if isShowClosed {
 		ctx.Data["State"] = "closed"
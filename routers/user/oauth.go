@@ -0,0 +1,642 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package user
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/auth/oauth2"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/log"
+	"code.gitea.io/gitea/modules/setting"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	tplGrantAccess base.TplName = "user/auth/grant"
+	tplGrantError  base.TplName = "user/auth/grant_error"
+)
+
+// AuthorizeErrorCode represents an error code specified in RFC 6749
+type AuthorizeErrorCode string
+
+const (
+	// ErrorCodeInvalidRequest represents the according error in RFC 6749
+	ErrorCodeInvalidRequest AuthorizeErrorCode = "invalid_request"
+	// ErrorCodeUnauthorizedClient represents the according error in RFC 6749
+	ErrorCodeUnauthorizedClient AuthorizeErrorCode = "unauthorized_client"
+	// ErrorCodeAccessDenied represents the according error in RFC 6749
+	ErrorCodeAccessDenied AuthorizeErrorCode = "access_denied"
+	// ErrorCodeUnsupportedResponseType represents the according error in RFC 6749
+	ErrorCodeUnsupportedResponseType AuthorizeErrorCode = "unsupported_response_type"
+	// ErrorCodeServerError represents the according error in RFC 6749
+	ErrorCodeServerError AuthorizeErrorCode = "server_error"
+)
+
+// AuthorizeError represents an error type specified in RFC 6749
+type AuthorizeError struct {
+	ErrorCode        AuthorizeErrorCode `json:"error" form:"error"`
+	ErrorDescription string
+	State            string
+}
+
+// Error returns the error message
+func (err AuthorizeError) Error() string {
+	return fmt.Sprintf("%s: %s", err.ErrorCode, err.ErrorDescription)
+}
+
+// AccessTokenErrorCode represents an error code specified in RFC 6749
+type AccessTokenErrorCode string
+
+const (
+	// AccessTokenErrorCodeInvalidRequest represents an error code specified in RFC 6749
+	AccessTokenErrorCodeInvalidRequest AccessTokenErrorCode = "invalid_request"
+	// AccessTokenErrorCodeInvalidClient represents an error code specified in RFC 6749
+	AccessTokenErrorCodeInvalidClient AccessTokenErrorCode = "invalid_client"
+	// AccessTokenErrorCodeInvalidGrant represents an error code specified in RFC 6749
+	AccessTokenErrorCodeInvalidGrant AccessTokenErrorCode = "invalid_grant"
+	// AccessTokenErrorCodeUnsupportedGrantType represents an error code specified in RFC 6749
+	AccessTokenErrorCodeUnsupportedGrantType AccessTokenErrorCode = "unsupported_grant_type"
+)
+
+// AccessTokenError represents an error response specified in RFC 6749
+type AccessTokenError struct {
+	ErrorCode        AccessTokenErrorCode `json:"error" form:"error"`
+	ErrorDescription string               `json:"error_description"`
+}
+
+// Error returns the error message
+func (err AccessTokenError) Error() string {
+	return fmt.Sprintf("%s: %s", err.ErrorCode, err.ErrorDescription)
+}
+
+// TokenType specifies the kind of token
+type TokenType string
+
+const (
+	// TokenTypeBearer represents a token type specified in RFC 6749
+	TokenTypeBearer TokenType = "bearer"
+	// TokenTypeMAC represents a token type specified in RFC 6749
+	TokenTypeMAC TokenType = "mac"
+)
+
+// AccessTokenResponse represents a successful access token response
+type AccessTokenResponse struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    TokenType `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"`
+	RefreshToken string    `json:"refresh_token"`
+	IDToken      string    `json:"id_token,omitempty"`
+}
+
+func newAccessTokenResponse(grant *models.OAuth2Grant, app *models.OAuth2Application) (*AccessTokenResponse, *AccessTokenError) {
+	if setting.OAuth2.InvalidateRefreshTokens {
+		if err := grant.IncreaseCounter(); err != nil {
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+				ErrorDescription: "cannot increase the grant counter",
+			}
+		}
+	}
+
+	now := time.Now()
+	// generate access token to access the API
+	expirationDate := now.Add(time.Duration(setting.OAuth2.AccessTokenExpirationTime) * time.Second)
+	accessToken := &oauth2.Token{
+		GrantID: grant.ID,
+		Type:    oauth2.TypeAccessToken,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: expirationDate.Unix(),
+		},
+	}
+	signedAccessToken, err := accessToken.SignToken()
+	if err != nil {
+		return nil, &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot sign token",
+		}
+	}
+
+	// generate refresh token to request an access token after it expired later
+	refreshExpirationDate := now.Add(time.Duration(setting.OAuth2.RefreshTokenExpirationTime) * time.Hour)
+	refreshToken := &oauth2.Token{
+		GrantID: grant.ID,
+		Counter: grant.Counter,
+		Type:    oauth2.TypeRefreshToken,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: refreshExpirationDate.Unix(),
+		},
+	}
+	signedRefreshToken, err := refreshToken.SignToken()
+	if err != nil {
+		return nil, &AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+			ErrorDescription: "cannot sign token",
+		}
+	}
+
+	// generate OpenID Connect id_token
+	signedIDToken := ""
+	if grant.ScopeContains("openid") {
+		user, err := models.GetUserByID(grant.UserID)
+		if err != nil {
+			log.Error(4, "GetUserByID [%d]: %v", grant.UserID, err)
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot find user",
+			}
+		}
+		idToken := &oauth2.OIDCToken{
+			StandardClaims: jwt.StandardClaims{
+				ExpiresAt: expirationDate.Unix(),
+				Issuer:    setting.AppURL,
+				Audience:  app.ClientID,
+				Subject:   fmt.Sprint(grant.UserID),
+				IssuedAt:  now.Unix(),
+			},
+			Nonce: grant.Nonce,
+		}
+		if grant.ScopeContains("profile") {
+			idToken.Name = user.DisplayName()
+			idToken.PreferredUsername = user.Name
+			idToken.Profile = user.HTMLURL()
+			idToken.Picture = user.AvatarLink()
+			idToken.Website = user.Website
+			idToken.Locale = user.Language
+			idToken.UpdatedAt = int64(user.UpdatedUnix)
+		}
+		if grant.ScopeContains("email") {
+			idToken.Email = user.Email
+			idToken.EmailVerified = user.IsActive
+		}
+		signedIDToken, err = idToken.SignToken()
+		if err != nil {
+			return nil, &AccessTokenError{
+				ErrorCode:        AccessTokenErrorCodeInvalidRequest,
+				ErrorDescription: "cannot sign token",
+			}
+		}
+	}
+
+	return &AccessTokenResponse{
+		AccessToken:  signedAccessToken,
+		TokenType:    TokenTypeBearer,
+		ExpiresIn:    setting.OAuth2.AccessTokenExpirationTime,
+		RefreshToken: signedRefreshToken,
+		IDToken:      signedIDToken,
+	}, nil
+}
+
+// AuthorizeOAuth manages authorize requests
+func AuthorizeOAuth(ctx *context.Context, form auth.AuthorizationForm) {
+	app, err := models.GetOAuth2ApplicationByClientID(form.ClientID)
+	if err != nil {
+		if models.IsErrOAuthClientIDInvalid(err) {
+			handleAuthorizeError(ctx, AuthorizeError{
+				ErrorCode:        ErrorCodeUnauthorizedClient,
+				ErrorDescription: "Client ID not registered",
+				State:            form.State,
+			}, "")
+			return
+		}
+		ctx.ServerError("GetOAuth2ApplicationByClientID", err)
+		return
+	}
+	if err := app.LoadUser(); err != nil {
+		ctx.ServerError("LoadUser", err)
+		return
+	}
+
+	if !app.ContainsRedirectURI(form.RedirectURI) {
+		handleAuthorizeError(ctx, AuthorizeError{
+			ErrorCode:        ErrorCodeInvalidRequest,
+			ErrorDescription: "Unregistered Redirect URI",
+			State:            form.State,
+		}, "")
+		return
+	}
+
+	if form.ResponseType != "code" {
+		handleAuthorizeError(ctx, AuthorizeError{
+			ErrorCode:        ErrorCodeUnsupportedResponseType,
+			ErrorDescription: "Only code response type is supported.",
+			State:            form.State,
+		}, form.RedirectURI)
+		return
+	}
+
+	// pkce support
+	switch form.CodeChallengeMethod {
+	case "S256", "plain":
+	case "":
+		if !app.ConfidentialClient && len(form.CodeChallenge) == 0 {
+			handleAuthorizeError(ctx, AuthorizeError{
+				ErrorCode:        ErrorCodeInvalidRequest,
+				ErrorDescription: "PKCE is required for public clients",
+				State:            form.State,
+			}, form.RedirectURI)
+			return
+		}
+		if len(form.CodeChallenge) > 0 {
+			form.CodeChallengeMethod = "plain"
+		}
+	default:
+		handleAuthorizeError(ctx, AuthorizeError{
+			ErrorCode:        ErrorCodeInvalidRequest,
+			ErrorDescription: "unsupported code challenge method",
+			State:            form.State,
+		}, form.RedirectURI)
+		return
+	}
+
+	grant, err := app.GetGrantByUserID(ctx.User.ID)
+	if err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		return
+	}
+
+	// Redirect if user already granted access and the requested scopes are covered
+	if grant != nil && grantCoversScope(grant, form.Scope) {
+		if err := grant.SetScopeAndNonce(grant.Scope, form.Nonce); err != nil {
+			handleServerError(ctx, form.State, form.RedirectURI)
+			return
+		}
+		redirectWithAuthorizationCode(ctx, grant, form.RedirectURI, form.State, form.CodeChallenge, form.CodeChallengeMethod)
+		return
+	}
+
+	ctx.Data["Title"] = ctx.Tr("auth.authorize_title", app.Name)
+	ctx.Data["Application"] = app
+	ctx.Data["RedirectURI"] = form.RedirectURI
+	ctx.Data["State"] = form.State
+	ctx.Data["Scope"] = form.Scope
+	ctx.Data["Scopes"] = strings.Fields(form.Scope)
+	ctx.Data["Nonce"] = form.Nonce
+	ctx.Data["CodeChallenge"] = form.CodeChallenge
+	ctx.Data["CodeChallengeMethod"] = form.CodeChallengeMethod
+	ctx.HTML(200, tplGrantAccess)
+}
+
+// grantCoversScope returns true if all the scopes of the request have already been granted
+func grantCoversScope(grant *models.OAuth2Grant, scope string) bool {
+	for _, s := range strings.Fields(scope) {
+		if !grant.ScopeContains(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func redirectWithAuthorizationCode(ctx *context.Context, grant *models.OAuth2Grant, redirectURI, state, codeChallenge, codeChallengeMethod string) {
+	code, err := grant.GenerateNewAuthorizationCode(redirectURI, codeChallenge, codeChallengeMethod)
+	if err != nil {
+		handleServerError(ctx, state, redirectURI)
+		return
+	}
+	redirect, err := code.GenerateRedirectURI(state)
+	if err != nil {
+		handleServerError(ctx, state, redirectURI)
+		return
+	}
+	ctx.Redirect(redirect.String(), 302)
+}
+
+// GrantApplicationOAuth manages the post request submitted when a user grants access to an application
+func GrantApplicationOAuth(ctx *context.Context, form auth.GrantApplicationForm) {
+	app, err := models.GetOAuth2ApplicationByClientID(form.ClientID)
+	if err != nil {
+		if models.IsErrOAuthClientIDInvalid(err) {
+			ctx.Error(400, "Client ID not registered")
+			return
+		}
+		ctx.ServerError("GetOAuth2ApplicationByClientID", err)
+		return
+	}
+	if !app.ContainsRedirectURI(form.RedirectURI) {
+		ctx.Error(400, "Unregistered Redirect URI")
+		return
+	}
+
+	if !form.Granted {
+		handleAuthorizeError(ctx, AuthorizeError{
+			ErrorCode:        ErrorCodeAccessDenied,
+			ErrorDescription: "the request is denied",
+			State:            form.State,
+		}, form.RedirectURI)
+		return
+	}
+
+	grant, err := app.GetGrantByUserID(ctx.User.ID)
+	if err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		return
+	}
+	if grant == nil {
+		grant, err = app.CreateGrant(ctx.User.ID, form.Scope)
+		if err != nil {
+			handleAuthorizeError(ctx, AuthorizeError{
+				State:            form.State,
+				ErrorDescription: "cannot create grant for user",
+				ErrorCode:        ErrorCodeServerError,
+			}, form.RedirectURI)
+			return
+		}
+	}
+	if err := grant.SetScopeAndNonce(form.Scope, form.Nonce); err != nil {
+		handleServerError(ctx, form.State, form.RedirectURI)
+		return
+	}
+
+	redirectWithAuthorizationCode(ctx, grant, form.RedirectURI, form.State, form.CodeChallenge, form.CodeChallengeMethod)
+}
+
+// AccessTokenOAuth manages all access token requests by the client
+func AccessTokenOAuth(ctx *context.Context, form auth.AccessTokenForm) {
+	// clients may authenticate with http basic auth instead of the client_id and client_secret parameters
+	if authHeader := ctx.Req.Header.Get("Authorization"); len(authHeader) > 0 {
+		fields := strings.Fields(authHeader)
+		if len(fields) == 2 && strings.EqualFold(fields[0], "basic") {
+			clientID, clientSecret, err := base.BasicAuthDecode(fields[1])
+			if err != nil {
+				handleAccessTokenError(ctx, AccessTokenError{
+					ErrorCode:        AccessTokenErrorCodeInvalidClient,
+					ErrorDescription: "cannot parse basic auth header",
+				})
+				return
+			}
+			if clientID, err = url.QueryUnescape(clientID); err == nil {
+				form.ClientID = clientID
+			}
+			if clientSecret, err = url.QueryUnescape(clientSecret); err == nil {
+				form.ClientSecret = clientSecret
+			}
+		}
+	}
+
+	switch form.GrantType {
+	case "refresh_token":
+		handleRefreshToken(ctx, form)
+	case "authorization_code":
+		handleAuthorizationCode(ctx, form)
+	default:
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeUnsupportedGrantType,
+			ErrorDescription: "Only refresh_token or authorization_code grant type is supported",
+		})
+	}
+}
+
+// authenticateClient returns the application of the request if the client credentials are valid.
+// Public clients are not authenticated, they have to prove the authorization with PKCE instead.
+func authenticateClient(ctx *context.Context, form auth.AccessTokenForm) (*models.OAuth2Application, bool) {
+	app, err := models.GetOAuth2ApplicationByClientID(form.ClientID)
+	if err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: fmt.Sprintf("cannot load client with client id: '%s'", form.ClientID),
+		})
+		return nil, false
+	}
+	if app.ConfidentialClient && !app.ValidateClientSecret(form.ClientSecret) {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: "invalid client secret",
+		})
+		return nil, false
+	}
+	return app, true
+}
+
+func handleRefreshToken(ctx *context.Context, form auth.AccessTokenForm) {
+	app, ok := authenticateClient(ctx, form)
+	if !ok {
+		return
+	}
+
+	token, err := oauth2.ParseToken(form.RefreshToken)
+	if err != nil || token.Type != oauth2.TypeRefreshToken {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "invalid refresh token",
+		})
+		return
+	}
+
+	grant, err := models.GetOAuth2GrantByID(token.GrantID)
+	if err != nil || grant == nil || grant.ApplicationID != app.ID {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "grant does not exist",
+		})
+		return
+	}
+
+	// check if token got already used
+	if setting.OAuth2.InvalidateRefreshTokens && (grant.Counter != token.Counter || token.Counter == 0) {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "token was already used",
+		})
+		log.Warn("A client tried to use a refresh token for grant_id = %d was used twice!", grant.ID)
+		return
+	}
+
+	accessToken, tokenErr := newAccessTokenResponse(grant, app)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	ctx.JSON(200, accessToken)
+}
+
+func handleAuthorizationCode(ctx *context.Context, form auth.AccessTokenForm) {
+	app, ok := authenticateClient(ctx, form)
+	if !ok {
+		return
+	}
+	if !app.ContainsRedirectURI(form.RedirectURI) {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidClient,
+			ErrorDescription: "unexpected redirect URI",
+		})
+		return
+	}
+
+	authorizationCode, err := models.GetOAuth2AuthorizationByCode(form.Code)
+	if err != nil || authorizationCode == nil || authorizationCode.IsExpired() ||
+		authorizationCode.Grant.ApplicationID != app.ID || authorizationCode.RedirectURI != form.RedirectURI {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "client is not authorized",
+		})
+		return
+	}
+
+	// check if code verifier authorizes the client, PKCE support
+	if !app.ConfidentialClient && len(authorizationCode.CodeChallenge) == 0 ||
+		!authorizationCode.ValidateCodeChallenge(form.CodeVerifier) {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "failed PKCE code challenge",
+		})
+		return
+	}
+
+	// remove the code so that it cannot be used twice
+	if err := authorizationCode.Invalidate(); err != nil {
+		handleAccessTokenError(ctx, AccessTokenError{
+			ErrorCode:        AccessTokenErrorCodeInvalidGrant,
+			ErrorDescription: "cannot proceed your request",
+		})
+		return
+	}
+
+	resp, tokenErr := newAccessTokenResponse(authorizationCode.Grant, app)
+	if tokenErr != nil {
+		handleAccessTokenError(ctx, *tokenErr)
+		return
+	}
+	ctx.JSON(200, resp)
+}
+
+func handleAccessTokenError(ctx *context.Context, acErr AccessTokenError) {
+	status := 400
+	if acErr.ErrorCode == AccessTokenErrorCodeInvalidClient {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Basic realm="oauth2"`)
+		status = 401
+	}
+	ctx.JSON(status, acErr)
+}
+
+func handleServerError(ctx *context.Context, state string, redirectURI string) {
+	handleAuthorizeError(ctx, AuthorizeError{
+		ErrorCode:        ErrorCodeServerError,
+		ErrorDescription: "A server error occurred",
+		State:            state,
+	}, redirectURI)
+}
+
+// handleAuthorizeError redirects the user agent back to the client with the error,
+// or shows it directly when there is no trusted redirect uri.
+func handleAuthorizeError(ctx *context.Context, authErr AuthorizeError, redirectURI string) {
+	if redirectURI == "" {
+		log.Warn("Authorization failed: %v", authErr.ErrorDescription)
+		ctx.Data["Error"] = authErr
+		ctx.HTML(400, tplGrantError)
+		return
+	}
+	redirect, err := url.Parse(redirectURI)
+	if err != nil {
+		ctx.ServerError("url.Parse", err)
+		return
+	}
+	q := redirect.Query()
+	q.Set("error", string(authErr.ErrorCode))
+	q.Set("error_description", authErr.ErrorDescription)
+	q.Set("state", authErr.State)
+	redirect.RawQuery = q.Encode()
+	ctx.Redirect(redirect.String(), 302)
+}
+
+// userInfoResponse represents the claims returned by the OpenID Connect userinfo endpoint
+type userInfoResponse struct {
+	Sub               string `json:"sub"`
+	Name              string `json:"name,omitempty"`
+	PreferredUsername string `json:"preferred_username,omitempty"`
+	Profile           string `json:"profile,omitempty"`
+	Picture           string `json:"picture,omitempty"`
+	Website           string `json:"website,omitempty"`
+	Locale            string `json:"locale,omitempty"`
+	Email             string `json:"email,omitempty"`
+	EmailVerified     bool   `json:"email_verified,omitempty"`
+}
+
+// InfoOAuth returns the OpenID Connect claims of the user the access token was issued for
+func InfoOAuth(ctx *context.Context) {
+	var accessToken string
+	if fields := strings.Fields(ctx.Req.Header.Get("Authorization")); len(fields) == 2 && strings.EqualFold(fields[0], "bearer") {
+		accessToken = fields[1]
+	} else {
+		accessToken = ctx.Query("access_token")
+	}
+
+	uid := auth.CheckOAuthAccessToken(ctx.Context, accessToken)
+	if uid == 0 {
+		ctx.Resp.Header().Set("WWW-Authenticate", `Bearer realm="", error="invalid_token"`)
+		ctx.JSON(401, map[string]string{"error": "invalid_token"})
+		return
+	}
+	user, err := models.GetUserByID(uid)
+	if err != nil {
+		ctx.NotFoundOrServerError("GetUserByID", models.IsErrUserNotExist, err)
+		return
+	}
+
+	scopes := make(map[string]bool)
+	if token, ok := ctx.Data["ApiToken"].(*models.AccessToken); ok {
+		for _, scope := range strings.Split(token.Scope, ",") {
+			scopes[scope] = true
+		}
+	}
+
+	info := &userInfoResponse{
+		Sub: fmt.Sprint(user.ID),
+	}
+	if scopes["profile"] {
+		info.Name = user.DisplayName()
+		info.PreferredUsername = user.Name
+		info.Profile = user.HTMLURL()
+		info.Picture = user.AvatarLink()
+		info.Website = user.Website
+		info.Locale = user.Language
+	}
+	if scopes["email"] {
+		info.Email = user.Email
+		info.EmailVerified = user.IsActive
+	}
+	ctx.JSON(200, info)
+}
+
+// OIDCKeys returns the public keys used to sign the tokens as JSON Web Key Set
+func OIDCKeys(ctx *context.Context) {
+	if oauth2.DefaultSigningKey == nil {
+		ctx.Error(500, "signing key is not initialized")
+		return
+	}
+	ctx.JSON(200, map[string]interface{}{
+		"keys": []map[string]string{oauth2.DefaultSigningKey.JWK()},
+	})
+}
+
+// OIDCWellKnown generates JSON so OIDC clients know Gitea's capabilities
+func OIDCWellKnown(ctx *context.Context) {
+	base := setting.AppURL + "login/oauth/"
+	ctx.JSON(200, map[string]interface{}{
+		"issuer":                                setting.AppURL,
+		"authorization_endpoint":                base + "authorize",
+		"token_endpoint":                        base + "access_token",
+		"userinfo_endpoint":                     base + "userinfo",
+		"jwks_uri":                              base + "keys",
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"scopes_supported":                      []string{"openid", "profile", "email"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":      []string{"plain", "S256"},
+		"claims_supported": []string{
+			"aud", "exp", "iat", "iss", "sub", "nonce",
+			"name", "preferred_username", "profile", "picture", "website", "locale", "updated_at",
+			"email", "email_verified",
+		},
+	})
+}
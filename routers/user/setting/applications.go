@@ -6,11 +6,14 @@
 package setting
 
 import (
+	"time"
+
 	"code.gitea.io/gitea/models"
 	"code.gitea.io/gitea/modules/auth"
 	"code.gitea.io/gitea/modules/base"
 	"code.gitea.io/gitea/modules/context"
 	"code.gitea.io/gitea/modules/setting"
+	"code.gitea.io/gitea/modules/util"
 )
 
 const (
@@ -43,6 +46,39 @@ func ApplicationsPost(ctx *context.Context, form auth.NewAccessTokenForm) {
 		UID:  ctx.User.ID,
 		Name: form.Name,
 	}
+
+	var err error
+	t.Scope, err = models.ParseAccessTokenScope(form.Scopes)
+	if err != nil {
+		loadApplicationsData(ctx)
+		ctx.RenderWithErr(ctx.Tr("settings.access_token_invalid_scope", err.(models.ErrAccessTokenInvalidScope).Scope), tplSettingsApplications, &form)
+		return
+	}
+
+	if len(form.Repository) > 0 {
+		if err = t.RestrictToRepo(form.Repository); err != nil {
+			if !models.IsErrRepoNotExist(err) {
+				ctx.ServerError("RestrictToRepo", err)
+				return
+			}
+			loadApplicationsData(ctx)
+			ctx.Data["Err_Repository"] = true
+			ctx.RenderWithErr(ctx.Tr("settings.access_token_repo_not_exist"), tplSettingsApplications, &form)
+			return
+		}
+	}
+
+	if len(form.ExpiresAt) > 0 {
+		expiresAt, err := time.ParseInLocation("2006-01-02", form.ExpiresAt, setting.UILocation)
+		if err != nil || !expiresAt.After(time.Now()) {
+			loadApplicationsData(ctx)
+			ctx.Data["Err_ExpiresAt"] = true
+			ctx.RenderWithErr(ctx.Tr("settings.access_token_invalid_expiry"), tplSettingsApplications, &form)
+			return
+		}
+		t.ExpiresUnix = util.TimeStamp(expiresAt.Unix())
+	}
+
 	if err := models.NewAccessToken(t); err != nil {
 		ctx.ServerError("NewAccessToken", err)
 		return
@@ -73,5 +109,25 @@ func loadApplicationsData(ctx *context.Context) {
 		ctx.ServerError("ListAccessTokens", err)
 		return
 	}
+	for _, t := range tokens {
+		if err = t.LoadRepo(); err != nil && !models.IsErrRepoNotExist(err) {
+			ctx.ServerError("LoadRepo", err)
+			return
+		}
+	}
 	ctx.Data["Tokens"] = tokens
+	ctx.Data["AccessTokenScopes"] = models.AccessTokenScopes
+	if setting.OAuth2.Enable {
+		ctx.Data["Applications"], err = models.GetOAuth2ApplicationsByUserID(ctx.User.ID)
+		if err != nil {
+			ctx.ServerError("GetOAuth2ApplicationsByUserID", err)
+			return
+		}
+		ctx.Data["Grants"], err = models.GetOAuth2GrantsByUserID(ctx.User.ID)
+		if err != nil {
+			ctx.ServerError("GetOAuth2GrantsByUserID", err)
+			return
+		}
+		ctx.Data["OAuth2BasePath"] = setting.AppSubURL + "/user/settings/applications"
+	}
 }
@@ -0,0 +1,222 @@
+// Copyright 2019 The Gitea Authors. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package setting
+
+import (
+	"fmt"
+	"strings"
+
+	"code.gitea.io/gitea/models"
+	"code.gitea.io/gitea/modules/auth"
+	"code.gitea.io/gitea/modules/base"
+	"code.gitea.io/gitea/modules/context"
+	"code.gitea.io/gitea/modules/setting"
+)
+
+const (
+	tplSettingsOAuthApplicationEdit base.TplName = "user/settings/applications_oauth2_edit"
+)
+
+// OAuth2CommonHandlers contains the handlers managing the oauth2 applications
+// of a user or an organization
+type OAuth2CommonHandlers struct {
+	OwnerID            int64        // the user or organization owning the applications
+	BasePathList       string       // the link of the page listing the applications
+	BasePathEditPrefix string       // the link prefix of the pages editing an application
+	TplAppEdit         base.TplName // the template of the edit page
+}
+
+func (oa *OAuth2CommonHandlers) renderEditPage(ctx *context.Context) {
+	ctx.Data["FormActionPath"] = fmt.Sprintf("%s/%d", oa.BasePathEditPrefix, ctx.Data["App"].(*models.OAuth2Application).ID)
+	ctx.Data["OAuth2BasePath"] = oa.BasePathList
+	ctx.HTML(200, oa.TplAppEdit)
+}
+
+// parseRedirectURIs returns the non empty lines of the redirect uri field
+func parseRedirectURIs(field string) []string {
+	uris := make([]string, 0, 1)
+	for _, uri := range strings.Split(field, "\n") {
+		if uri = strings.TrimSpace(uri); len(uri) > 0 {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
+
+// getApp returns the application of the :id parameter if it belongs to the owner
+func (oa *OAuth2CommonHandlers) getApp(ctx *context.Context) *models.OAuth2Application {
+	app, err := models.GetOAuth2ApplicationByID(ctx.ParamsInt64(":id"))
+	if err != nil {
+		if models.IsErrOAuthApplicationNotFound(err) {
+			ctx.NotFound("Application not found", err)
+			return nil
+		}
+		ctx.ServerError("GetOAuth2ApplicationByID", err)
+		return nil
+	}
+	if app.UID != oa.OwnerID {
+		ctx.NotFound("Application not found", nil)
+		return nil
+	}
+	return app
+}
+
+// AddApp adds an oauth2 application and shows its client secret
+func (oa *OAuth2CommonHandlers) AddApp(ctx *context.Context, form auth.EditOAuth2ApplicationForm) {
+	if ctx.HasError() {
+		ctx.Flash.Error(ctx.GetErrMsg())
+		ctx.Redirect(oa.BasePathList)
+		return
+	}
+
+	app, err := models.CreateOAuth2Application(models.CreateOAuth2ApplicationOptions{
+		Name:               form.Name,
+		UserID:             oa.OwnerID,
+		ConfidentialClient: form.ConfidentialClient,
+		RedirectURIs:       parseRedirectURIs(form.RedirectURI),
+	})
+	if err != nil {
+		ctx.ServerError("CreateOAuth2Application", err)
+		return
+	}
+	ctx.Data["App"] = app
+	ctx.Data["ClientSecret"], err = app.GenerateClientSecret()
+	if err != nil {
+		ctx.ServerError("GenerateClientSecret", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("settings.create_oauth2_application_success"), true)
+	oa.renderEditPage(ctx)
+}
+
+// EditShow shows the edit page of an oauth2 application
+func (oa *OAuth2CommonHandlers) EditShow(ctx *context.Context) {
+	app := oa.getApp(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["App"] = app
+	oa.renderEditPage(ctx)
+}
+
+// EditSave saves the changes of an oauth2 application
+func (oa *OAuth2CommonHandlers) EditSave(ctx *context.Context, form auth.EditOAuth2ApplicationForm) {
+	app := oa.getApp(ctx)
+	if ctx.Written() {
+		return
+	}
+	if ctx.HasError() {
+		ctx.Data["App"] = app
+		oa.renderEditPage(ctx)
+		return
+	}
+
+	var err error
+	if ctx.Data["App"], err = models.UpdateOAuth2Application(models.UpdateOAuth2ApplicationOptions{
+		ID:                 app.ID,
+		Name:               form.Name,
+		UserID:             oa.OwnerID,
+		ConfidentialClient: form.ConfidentialClient,
+		RedirectURIs:       parseRedirectURIs(form.RedirectURI),
+	}); err != nil {
+		ctx.ServerError("UpdateOAuth2Application", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("settings.update_oauth2_application_success"))
+	ctx.Redirect(fmt.Sprintf("%s/%d", oa.BasePathEditPrefix, app.ID))
+}
+
+// RegenerateSecret replaces the client secret of an oauth2 application and shows the new one
+func (oa *OAuth2CommonHandlers) RegenerateSecret(ctx *context.Context) {
+	app := oa.getApp(ctx)
+	if ctx.Written() {
+		return
+	}
+	ctx.Data["App"] = app
+	var err error
+	ctx.Data["ClientSecret"], err = app.GenerateClientSecret()
+	if err != nil {
+		ctx.ServerError("GenerateClientSecret", err)
+		return
+	}
+	ctx.Flash.Success(ctx.Tr("settings.update_oauth2_application_success"), true)
+	oa.renderEditPage(ctx)
+}
+
+// DeleteApp deletes an oauth2 application with all its grants
+func (oa *OAuth2CommonHandlers) DeleteApp(ctx *context.Context) {
+	if err := models.DeleteOAuth2Application(ctx.QueryInt64("id"), oa.OwnerID); err != nil {
+		ctx.ServerError("DeleteOAuth2Application", err)
+		return
+	}
+
+	ctx.Flash.Success(ctx.Tr("settings.remove_oauth2_application_success"))
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": oa.BasePathList,
+	})
+}
+
+func newOAuth2CommonHandlers(userID int64) *OAuth2CommonHandlers {
+	return &OAuth2CommonHandlers{
+		OwnerID:            userID,
+		BasePathList:       setting.AppSubURL + "/user/settings/applications",
+		BasePathEditPrefix: setting.AppSubURL + "/user/settings/applications/oauth2",
+		TplAppEdit:         tplSettingsOAuthApplicationEdit,
+	}
+}
+
+// OAuthApplicationsPost response for adding an oauth2 application
+func OAuthApplicationsPost(ctx *context.Context, form auth.EditOAuth2ApplicationForm) {
+	ctx.Data["Title"] = ctx.Tr("settings")
+	ctx.Data["PageIsSettingsApplications"] = true
+
+	newOAuth2CommonHandlers(ctx.User.ID).AddApp(ctx, form)
+}
+
+// OAuthApplicationsEdit response for editing an oauth2 application
+func OAuthApplicationsEdit(ctx *context.Context, form auth.EditOAuth2ApplicationForm) {
+	ctx.Data["Title"] = ctx.Tr("settings")
+	ctx.Data["PageIsSettingsApplications"] = true
+
+	newOAuth2CommonHandlers(ctx.User.ID).EditSave(ctx, form)
+}
+
+// OAuthApplicationsRegenerateSecret handles the post request for regenerating the secret
+func OAuthApplicationsRegenerateSecret(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("settings")
+	ctx.Data["PageIsSettingsApplications"] = true
+
+	newOAuth2CommonHandlers(ctx.User.ID).RegenerateSecret(ctx)
+}
+
+// OAuth2ApplicationShow displays the given application
+func OAuth2ApplicationShow(ctx *context.Context) {
+	ctx.Data["Title"] = ctx.Tr("settings")
+	ctx.Data["PageIsSettingsApplications"] = true
+
+	newOAuth2CommonHandlers(ctx.User.ID).EditShow(ctx)
+}
+
+// DeleteOAuth2Application deletes the given oauth2 application
+func DeleteOAuth2Application(ctx *context.Context) {
+	newOAuth2CommonHandlers(ctx.User.ID).DeleteApp(ctx)
+}
+
+// RevokeOAuth2Grant revokes the grant with the given id
+func RevokeOAuth2Grant(ctx *context.Context) {
+	if err := models.RevokeOAuth2Grant(ctx.QueryInt64("id"), ctx.User.ID); err != nil {
+		if !models.IsErrOAuthGrantNotExist(err) {
+			ctx.ServerError("RevokeOAuth2Grant", err)
+			return
+		}
+		ctx.Flash.Error(err.Error())
+	} else {
+		ctx.Flash.Success(ctx.Tr("settings.revoke_oauth2_grant_success"))
+	}
+
+	ctx.JSON(200, map[string]interface{}{
+		"redirect": setting.AppSubURL + "/user/settings/applications",
+	})
+}